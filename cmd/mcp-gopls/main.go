@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -64,7 +65,14 @@ func buildConfigFromFlags() (server.Config, error) {
 		flagLogJSON         = flag.Bool("log-json", envBool("MCP_GOPLS_LOG_JSON"), "Emit JSON logs")
 		flagRPCTimeout      = flag.Duration("rpc-timeout", envDuration("MCP_GOPLS_RPC_TIMEOUT", 45*time.Second), "LSP RPC timeout")
 		flagShutdownTimeout = flag.Duration("shutdown-timeout", envDuration("MCP_GOPLS_SHUTDOWN_TIMEOUT", 15*time.Second), "Graceful shutdown timeout")
-		flagFSWatch         = flag.Bool("fs-watch", envBool("MCP_GOPLS_FS_WATCH"), "Watch workspace filesystem and notify gopls on .go/go.mod/go.sum changes (env: MCP_GOPLS_FS_WATCH)")
+		flagInteractive     = flag.Int("interactive-concurrency", envInt("MCP_GOPLS_INTERACTIVE_CONCURRENCY", 8), "Gopls queries (hover, definition...) run at once")
+		flagHeavy           = flag.Int("heavy-concurrency", envInt("MCP_GOPLS_HEAVY_CONCURRENCY", 2), "Tools running commands or loading packages (tests, builds, analyses) run at once")
+		flagFSWatch         = flag.Bool("fs-watch", envBool("MCP_GOPLS_FS_WATCH"), "Watch workspace filesystem, notify gopls on .go/go.mod/go.sum changes and clients when files the session touched change (env: MCP_GOPLS_FS_WATCH)")
+		flagOnboarding      = flag.Bool("onboarding", envBool("MCP_GOPLS_ONBOARDING"), "Send the workspace briefing as the server instructions on connect (env: MCP_GOPLS_ONBOARDING)")
+		flagOperation       = flag.Duration("operation-threshold", envDuration("MCP_GOPLS_OPERATION_THRESHOLD", 30*time.Second), "Hand heavy tool calls running longer over to operations polled with get_operation_status; 0 disables")
+		flagIsolated        = flag.Bool("isolated-edits", envBool("MCP_GOPLS_ISOLATED_EDITS"), "Edit a private copy of the workspace, a git worktree in a repository, until promote_changes copies the changes back (env: MCP_GOPLS_ISOLATED_EDITS)")
+		flagJobs            = flag.Bool("jobs", envBool("MCP_GOPLS_JOBS"), "Run the background jobs of .mcp-gopls.json; jobs may only call the checks that do not run repository code (env: MCP_GOPLS_JOBS)")
+		flagApproval        = flag.Bool("require-approval", envBool("MCP_GOPLS_REQUIRE_APPROVAL"), "Queue the edits of every tool until approve_pending_change is called with the approval token from MCP_GOPLS_APPROVAL_TOKEN, or one generated and printed to stderr at startup (env: MCP_GOPLS_REQUIRE_APPROVAL)")
 	)
 	flag.Parse()
 
@@ -93,6 +101,17 @@ func buildConfigFromFlags() (server.Config, error) {
 		cfg.ShutdownTimeout = *flagShutdownTimeout
 	}
 	cfg.FSWatch = *flagFSWatch
+	cfg.Onboarding = *flagOnboarding
+	cfg.InteractiveConcurrency = *flagInteractive
+	cfg.HeavyConcurrency = *flagHeavy
+	cfg.OperationThreshold = *flagOperation
+	cfg.IsolatedEdits = *flagIsolated
+	cfg.RequireApproval = *flagApproval
+	cfg.ApprovalToken = os.Getenv("MCP_GOPLS_APPROVAL_TOKEN")
+	// The commands the tools run inherit the environment, and tests can
+	// print it: the token must not outlive this read.
+	_ = os.Unsetenv("MCP_GOPLS_APPROVAL_TOKEN")
+	cfg.Jobs = *flagJobs
 
 	level, err := parseLogLevel(*flagLogLevel)
 	if err != nil {
@@ -129,6 +148,15 @@ func envDuration(key string, fallback time.Duration) time.Duration {
 	return fallback
 }
 
+func envInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
 func parseLogLevel(level string) (slog.Level, error) {
 	switch strings.ToLower(level) {
 	case "debug":
@@ -181,5 +209,8 @@ func validateTimeouts(cfg server.Config) error {
 	if cfg.ShutdownTimeout <= 0 {
 		return fmt.Errorf("shutdown-timeout must be positive, got %s", cfg.ShutdownTimeout)
 	}
+	if cfg.InteractiveConcurrency <= 0 || cfg.HeavyConcurrency <= 0 {
+		return fmt.Errorf("interactive-concurrency and heavy-concurrency must be positive, got %d and %d", cfg.InteractiveConcurrency, cfg.HeavyConcurrency)
+	}
 	return nil
 }
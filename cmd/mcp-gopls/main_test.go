@@ -161,7 +161,14 @@ func TestBuildConfigFromFlagsAndEnv(t *testing.T) {
 	setEnv(t, "MCP_GOPLS_LOG_LEVEL", "debug")
 	setEnv(t, "MCP_GOPLS_RPC_TIMEOUT", "2s")
 	setEnv(t, "MCP_GOPLS_SHUTDOWN_TIMEOUT", "3s")
-	withFreshFlags(t, []string{"-log-json", "-log-file", "app.log"}, func() {
+	setEnv(t, "MCP_GOPLS_HEAVY_CONCURRENCY", "1")
+	setEnv(t, "MCP_GOPLS_ONBOARDING", "true")
+	setEnv(t, "MCP_GOPLS_OPERATION_THRESHOLD", "0")
+	setEnv(t, "MCP_GOPLS_ISOLATED_EDITS", "1")
+	setEnv(t, "MCP_GOPLS_REQUIRE_APPROVAL", "true")
+	setEnv(t, "MCP_GOPLS_APPROVAL_TOKEN", "secret")
+	setEnv(t, "MCP_GOPLS_JOBS", "true")
+	withFreshFlags(t, []string{"-log-json", "-log-file", "app.log", "-interactive-concurrency", "4"}, func() {
 		cfg, err := buildConfigFromFlags()
 		if err != nil {
 			t.Fatalf("buildConfigFromFlags returned error: %v", err)
@@ -181,6 +188,27 @@ func TestBuildConfigFromFlagsAndEnv(t *testing.T) {
 		if cfg.ShutdownTimeout != 3*time.Second {
 			t.Fatalf("unexpected shutdown timeout %s", cfg.ShutdownTimeout)
 		}
+		if cfg.InteractiveConcurrency != 4 || cfg.HeavyConcurrency != 1 {
+			t.Fatalf("unexpected concurrency %d/%d", cfg.InteractiveConcurrency, cfg.HeavyConcurrency)
+		}
+		if !cfg.Onboarding {
+			t.Fatal("expected onboarding enabled")
+		}
+		if cfg.OperationThreshold != 0 {
+			t.Fatalf("expected operations disabled, got %s", cfg.OperationThreshold)
+		}
+		if !cfg.IsolatedEdits {
+			t.Fatal("expected isolated edits enabled")
+		}
+		if !cfg.RequireApproval || cfg.ApprovalToken != "secret" {
+			t.Fatalf("expected approvals required with the token, got %v %q", cfg.RequireApproval, cfg.ApprovalToken)
+		}
+		if _, ok := os.LookupEnv("MCP_GOPLS_APPROVAL_TOKEN"); ok {
+			t.Fatal("expected the approval token to be removed from the environment")
+		}
+		if !cfg.Jobs {
+			t.Fatal("expected background jobs enabled")
+		}
 	})
 }
 
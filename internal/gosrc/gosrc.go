@@ -0,0 +1,246 @@
+// Package gosrc loads the Go packages of a workspace from source. It only
+// relies on the standard parser, which keeps it usable for syntax-driven
+// inventories without a working build or a running gopls.
+package gosrc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Options controls which files Load includes.
+type Options struct {
+	// Tests includes _test.go files. External test packages (package
+	// foo_test) are returned as separate packages.
+	Tests bool
+	// AllPlatforms ignores build constraints instead of evaluating them for
+	// the host GOOS/GOARCH.
+	AllPlatforms bool
+}
+
+// Workspace is the set of packages found below a module root.
+type Workspace struct {
+	Root        string
+	ModulePath  string
+	Fset        *token.FileSet
+	Packages    []*Package
+	ParseErrors []string
+}
+
+// Package groups the files of one directory sharing a package clause.
+type Package struct {
+	Dir        string
+	RelDir     string
+	ImportPath string
+	Name       string
+	Files      []*File
+}
+
+// File is a parsed Go source file.
+type File struct {
+	Path    string
+	RelPath string
+	Test    bool
+	Src     []byte
+	Syntax  *ast.File
+	tokFile *token.File
+}
+
+// Load parses every Go package below root. Directories that Go tooling
+// ignores (testdata, vendor, hidden or underscore-prefixed) are skipped, as
+// are nested modules.
+func Load(root string, opts Options) (*Workspace, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	ws := &Workspace{Root: root, Fset: token.NewFileSet()}
+	if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+		ws.ModulePath = modfile.ModulePath(data)
+	}
+
+	ctxt := build.Default
+	byKey := make(map[string]*Package)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" || name == "node_modules" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := d.Name()
+		if !strings.HasSuffix(name, ".go") || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			return nil
+		}
+		isTest := strings.HasSuffix(name, "_test.go")
+		if isTest && !opts.Tests {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		if !opts.AllPlatforms {
+			if match, err := ctxt.MatchFile(dir, name); err != nil || !match {
+				return nil
+			}
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			ws.ParseErrors = append(ws.ParseErrors, err.Error())
+			return nil
+		}
+		syntax, err := parser.ParseFile(ws.Fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+		if syntax == nil {
+			ws.ParseErrors = append(ws.ParseErrors, err.Error())
+			return nil
+		}
+		if err != nil {
+			ws.ParseErrors = append(ws.ParseErrors, err.Error())
+		}
+
+		relPath := relSlash(root, path)
+		file := &File{
+			Path:    path,
+			RelPath: relPath,
+			Test:    isTest,
+			Src:     src,
+			Syntax:  syntax,
+			tokFile: ws.Fset.File(syntax.Pos()),
+		}
+		pkgName := syntax.Name.Name
+		key := dir + "\x00" + pkgName
+		pkg, ok := byKey[key]
+		if !ok {
+			relDir := relSlash(root, dir)
+			pkg = &Package{
+				Dir:        dir,
+				RelDir:     relDir,
+				ImportPath: importPath(ws.ModulePath, relDir, pkgName),
+				Name:       pkgName,
+			}
+			byKey[key] = pkg
+			ws.Packages = append(ws.Packages, pkg)
+		}
+		pkg.Files = append(pkg.Files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", root, err)
+	}
+
+	sort.Slice(ws.Packages, func(i, j int) bool {
+		if ws.Packages[i].ImportPath != ws.Packages[j].ImportPath {
+			return ws.Packages[i].ImportPath < ws.Packages[j].ImportPath
+		}
+		return ws.Packages[i].Name < ws.Packages[j].Name
+	})
+	return ws, nil
+}
+
+func importPath(modulePath, relDir, pkgName string) string {
+	path := modulePath
+	if relDir != "." {
+		if path == "" {
+			path = relDir
+		} else {
+			path += "/" + relDir
+		}
+	}
+	if strings.HasSuffix(pkgName, "_test") {
+		path += "_test"
+	}
+	return path
+}
+
+func relSlash(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// File returns the parsed file containing pos, or nil.
+func (w *Workspace) File(pos token.Pos) *File {
+	for _, pkg := range w.Packages {
+		for _, file := range pkg.Files {
+			if file.tokFile != nil && file.tokFile.Base() <= int(pos) && int(pos) <= file.tokFile.Base()+file.tokFile.Size() {
+				return file
+			}
+		}
+	}
+	return nil
+}
+
+// Position converts pos to a zero-based line and UTF-16 character offset,
+// the coordinates used by the Language Server Protocol.
+func (f *File) Position(pos token.Pos) (line, character int) {
+	if f.tokFile == nil || !pos.IsValid() {
+		return 0, 0
+	}
+	offset := f.tokFile.Offset(pos)
+	p := f.tokFile.Position(pos)
+	lineStart := offset - (p.Column - 1)
+	if lineStart < 0 {
+		lineStart = 0
+	}
+	return p.Line - 1, utf16Len(f.Src[lineStart:offset])
+}
+
+// Line returns the one-based line number of pos.
+func (f *File) Line(pos token.Pos) int {
+	if f.tokFile == nil || !pos.IsValid() {
+		return 0
+	}
+	return f.tokFile.Line(pos)
+}
+
+// Offset returns the byte offset of pos in Src.
+func (f *File) Offset(pos token.Pos) int {
+	return f.tokFile.Offset(pos)
+}
+
+// Text returns the source between two positions of the file.
+func (f *File) Text(start, end token.Pos) string {
+	if f.tokFile == nil || !start.IsValid() || !end.IsValid() {
+		return ""
+	}
+	return string(f.Src[f.tokFile.Offset(start):f.tokFile.Offset(end)])
+}
+
+func utf16Len(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+		b = b[size:]
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+package gosrc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/mod\n\ngo 1.22\n")
+	writeFile(t, root, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, root, "lib/lib.go", "package lib\n\n// Héllo 𝔊 says hi.\nvar Greeting = \"𝔊\" + Name\n\nconst Name = \"x\"\n")
+	writeFile(t, root, "lib/lib_test.go", "package lib_test\n")
+	writeFile(t, root, "lib/ignored.go", "//go:build ignore\n\npackage lib\n")
+	writeFile(t, root, "lib/testdata/data.go", "package data\n")
+	writeFile(t, root, "nested/go.mod", "module example.com/nested\n")
+	writeFile(t, root, "nested/n.go", "package nested\n")
+
+	ws, err := Load(root, Options{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if ws.ModulePath != "example.com/mod" || len(ws.Packages) != 2 {
+		t.Fatalf("unexpected workspace %#v", ws)
+	}
+	lib := ws.Packages[1]
+	if lib.ImportPath != "example.com/mod/lib" || lib.RelDir != "lib" || len(lib.Files) != 1 {
+		t.Fatalf("unexpected lib package %#v", lib)
+	}
+	if ws.Packages[0].ImportPath != "example.com/mod" || ws.Packages[0].Name != "main" {
+		t.Fatalf("unexpected root package %#v", ws.Packages[0])
+	}
+
+	file := lib.Files[0]
+	spec := file.Syntax.Decls[0]
+	line, character := file.Position(spec.End())
+	// `var Greeting = "𝔊" + Name` ends after Name; 𝔊 counts as two UTF-16 units.
+	if line != 3 || character != 26 {
+		t.Fatalf("unexpected position %d:%d", line, character)
+	}
+	if ws.File(spec.Pos()) != file {
+		t.Fatal("Workspace.File did not locate the file")
+	}
+
+	withTests, err := Load(root, Options{Tests: true})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(withTests.Packages) != 3 || withTests.Packages[2].ImportPath != "example.com/mod/lib_test" {
+		t.Fatalf("unexpected packages with tests %#v", withTests.Packages)
+	}
+}
@@ -0,0 +1,198 @@
+// Package projectconfig reads the optional per-workspace settings file,
+// .mcp-gopls.json, that lets a project tune tools to its own conventions.
+package projectconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// FileName is the name of the settings file at the workspace root.
+const FileName = ".mcp-gopls.json"
+
+// jobName keeps job names usable in resource URIs.
+var jobName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Config is the content of the settings file. Every section is optional.
+type Config struct {
+	FeatureFlags *FeatureFlags `json:"feature_flags,omitempty"`
+	Integration  *Integration  `json:"integration,omitempty"`
+	// Gopls holds gopls settings, such as {"gofumpt": true}, passed to
+	// gopls when it starts.
+	Gopls map[string]any `json:"gopls,omitempty"`
+	// AnalysisProfiles adds analysis profiles, or replaces the built-in
+	// fast, thorough and security ones, by name.
+	AnalysisProfiles map[string]AnalysisProfile `json:"analysis_profiles,omitempty"`
+	// Jobs are tool calls the server repeats in the background; unlike
+	// the other keys, changes apply after the server restarts.
+	Jobs []Job `json:"jobs,omitempty"`
+	// Baseline configures the suppression baseline: the findings recorded
+	// by create_diagnostics_baseline are left out of the diagnostics tools.
+	Baseline *Baseline `json:"baseline,omitempty"`
+}
+
+// Baseline names the suppression baseline file and its policies.
+type Baseline struct {
+	// File is the baseline, relative to the workspace root. It defaults to
+	// .mcp-gopls-baseline.json, the file diagnostics_delta compares
+	// against.
+	File string `json:"file,omitempty"`
+	// Policies set how long findings stay suppressed and whether the
+	// baseline may grow, by diagnostic source as in analysis profiles; the
+	// exact source wins over the longest matching pattern, and "*" matches
+	// every source.
+	Policies map[string]BaselinePolicy `json:"policies,omitempty"`
+}
+
+// BaselinePolicy is the suppression policy of some diagnostic sources.
+type BaselinePolicy struct {
+	// Expires is how long a finding stays suppressed after it was first
+	// baselined, as a Go duration such as 720h; empty never expires.
+	Expires string `json:"expires,omitempty"`
+	// Ratchet keeps the baseline from growing: updates drop the fixed
+	// findings but never record new ones, which must be fixed instead.
+	Ratchet bool `json:"ratchet,omitempty"`
+}
+
+// Job is a tool call the server repeats in the background, such as
+// run_govulncheck every 24h. Its latest result is served as the
+// resource://jobs/{name} resource. Jobs run only when the server is
+// started with --jobs, and only for the checks that do not run the code of
+// the repository (go_build, go_vet, run_govulncheck...).
+type Job struct {
+	Name      string         `json:"name"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	// Every is the interval between runs, as a Go duration. The first run
+	// starts with the server.
+	Every string `json:"every"`
+}
+
+// AnalysisProfile bundles the analyzers, timeout and scope of a diagnostics
+// run. Analyzers and Skip match diagnostic sources: an analyzer name such
+// as printf, a staticcheck code such as SA1019, a prefix ending in * such
+// as SA1*, or staticcheck for every staticcheck check.
+type AnalysisProfile struct {
+	// Analyzers keeps only the diagnostics of these sources; empty keeps
+	// every source.
+	Analyzers []string `json:"analyzers,omitempty"`
+	// Skip drops the diagnostics of these sources.
+	Skip []string `json:"skip,omitempty"`
+	// Timeout bounds the wait for gopls, as a Go duration.
+	Timeout string `json:"timeout,omitempty"`
+	// Scope is workspace, the default, or changed for the packages with
+	// uncommitted changes.
+	Scope string `json:"scope,omitempty"`
+	// Vulncheck also runs govulncheck.
+	Vulncheck bool `json:"vulncheck,omitempty"`
+}
+
+// FeatureFlags lists the functions that evaluate feature flags.
+type FeatureFlags struct {
+	Functions []FlagFunction `json:"functions"`
+}
+
+// FlagFunction describes one flag-evaluation function. Call is either
+// "pkg.Func", where pkg is an import path or its last element, or
+// "*.Method" for a method on any receiver. NameArg and DefaultArg are
+// zero-based argument positions; DefaultArg is omitted when the function
+// takes no default value.
+type FlagFunction struct {
+	Call       string `json:"call"`
+	NameArg    int    `json:"name_arg"`
+	DefaultArg *int   `json:"default_arg,omitempty"`
+}
+
+// Integration describes how to run the integration tests. Tags and
+// Packages default to the build tags gating test files and the packages
+// declaring them.
+type Integration struct {
+	Tags     []string `json:"tags,omitempty"`
+	Packages []string `json:"packages,omitempty"`
+	// ComposeFile is a docker compose file, relative to the workspace root,
+	// started before the tests and stopped afterwards. Services limits it
+	// to some of its services.
+	ComposeFile string            `json:"compose_file,omitempty"`
+	Services    []string          `json:"services,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	// Timeout is passed to go test -timeout.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Load reads the settings file of the workspace at root. A missing file
+// yields an empty configuration.
+func Load(root string) (*Config, error) {
+	path := filepath.Join(root, FileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", FileName, err)
+	}
+	if cfg.FeatureFlags != nil {
+		for i, fn := range cfg.FeatureFlags.Functions {
+			if fn.Call == "" {
+				return nil, fmt.Errorf("%s: feature_flags.functions[%d]: call is required", FileName, i)
+			}
+			if fn.NameArg < 0 {
+				return nil, fmt.Errorf("%s: feature_flags.functions[%d]: name_arg must not be negative", FileName, i)
+			}
+		}
+	}
+	if cfg.Integration != nil && cfg.Integration.Timeout != "" {
+		if _, err := time.ParseDuration(cfg.Integration.Timeout); err != nil {
+			return nil, fmt.Errorf("%s: integration.timeout: %w", FileName, err)
+		}
+	}
+	names := make(map[string]bool, len(cfg.Jobs))
+	for i, job := range cfg.Jobs {
+		if !jobName.MatchString(job.Name) {
+			return nil, fmt.Errorf("%s: jobs[%d]: name must be made of letters, digits, '.', '_' and '-'", FileName, i)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("%s: jobs[%d]: duplicate name %q", FileName, i, job.Name)
+		}
+		names[job.Name] = true
+		if job.Tool == "" {
+			return nil, fmt.Errorf("%s: jobs[%d]: tool is required", FileName, i)
+		}
+		if every, err := time.ParseDuration(job.Every); err != nil || every <= 0 {
+			return nil, fmt.Errorf("%s: jobs[%d]: every must be a positive duration such as 1h", FileName, i)
+		}
+	}
+	for name, profile := range cfg.AnalysisProfiles {
+		if profile.Timeout != "" {
+			if _, err := time.ParseDuration(profile.Timeout); err != nil {
+				return nil, fmt.Errorf("%s: analysis_profiles.%s.timeout: %w", FileName, name, err)
+			}
+		}
+		if profile.Scope != "" && profile.Scope != "workspace" && profile.Scope != "changed" {
+			return nil, fmt.Errorf("%s: analysis_profiles.%s.scope must be workspace or changed", FileName, name)
+		}
+	}
+	if cfg.Baseline != nil {
+		if cfg.Baseline.File != "" && !filepath.IsLocal(cfg.Baseline.File) {
+			return nil, fmt.Errorf("%s: baseline.file must be a path inside the workspace", FileName)
+		}
+		for pattern, policy := range cfg.Baseline.Policies {
+			if policy.Expires == "" {
+				continue
+			}
+			if expires, err := time.ParseDuration(policy.Expires); err != nil || expires <= 0 {
+				return nil, fmt.Errorf("%s: baseline.policies.%s.expires must be a positive duration such as 720h", FileName, pattern)
+			}
+		}
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,98 @@
+package projectconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := Load(root)
+	if err != nil || cfg.FeatureFlags != nil {
+		t.Fatalf("expected empty config without a file, got %+v, %v", cfg, err)
+	}
+
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, FileName), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"feature_flags": {"functions": [{"call": "flags.Enabled", "name_arg": 0, "default_arg": 1}]}}`)
+	cfg, err = Load(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	fn := cfg.FeatureFlags.Functions[0]
+	if fn.Call != "flags.Enabled" || fn.NameArg != 0 || fn.DefaultArg == nil || *fn.DefaultArg != 1 {
+		t.Fatalf("unexpected function: %+v", fn)
+	}
+
+	write(`{"feature_flags": {"functions": [{"name_arg": 0}]}}`)
+	if _, err := Load(root); err == nil || !strings.Contains(err.Error(), "call is required") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	write(`{"integration": {"compose_file": "docker-compose.test.yml", "tags": ["integration"], "env": {"DB_URL": "postgres://localhost"}, "timeout": "5m"}}`)
+	cfg, err = Load(root)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Integration.ComposeFile != "docker-compose.test.yml" || cfg.Integration.Env["DB_URL"] == "" || cfg.Integration.Timeout != "5m" {
+		t.Fatalf("unexpected integration settings: %+v", cfg.Integration)
+	}
+	write(`{"integration": {"timeout": "soon"}}`)
+	if _, err := Load(root); err == nil || !strings.Contains(err.Error(), "integration.timeout") {
+		t.Fatalf("expected timeout validation error, got %v", err)
+	}
+
+	write(`{"gopls": {"gofumpt": true}}`)
+	if cfg, err = Load(root); err != nil || cfg.Gopls["gofumpt"] != true {
+		t.Fatalf("unexpected gopls settings: %+v, %v", cfg.Gopls, err)
+	}
+
+	write(`{"analysis_profiles": {"ci": {"skip": ["staticcheck"], "timeout": "1m", "scope": "changed"}}}`)
+	if cfg, err = Load(root); err != nil || cfg.AnalysisProfiles["ci"].Scope != "changed" || cfg.AnalysisProfiles["ci"].Skip[0] != "staticcheck" {
+		t.Fatalf("unexpected analysis profiles: %+v, %v", cfg.AnalysisProfiles, err)
+	}
+	write(`{"analysis_profiles": {"ci": {"scope": "repo"}}}`)
+	if _, err := Load(root); err == nil || !strings.Contains(err.Error(), "analysis_profiles.ci.scope") {
+		t.Fatalf("expected scope validation error, got %v", err)
+	}
+
+	write(`{"jobs": [{"name": "nightly-vulncheck", "tool": "run_govulncheck", "every": "24h"}]}`)
+	if cfg, err = Load(root); err != nil || len(cfg.Jobs) != 1 || cfg.Jobs[0].Tool != "run_govulncheck" {
+		t.Fatalf("unexpected jobs: %+v, %v", cfg.Jobs, err)
+	}
+	for content, want := range map[string]string{
+		`{"jobs": [{"name": "a b", "tool": "x", "every": "1h"}]}`:                                          "name must be",
+		`{"jobs": [{"name": "a", "tool": "x", "every": "1h"}, {"name": "a", "tool": "y", "every": "1h"}]}`: "duplicate name",
+		`{"jobs": [{"name": "a", "every": "1h"}]}`:                                                         "tool is required",
+		`{"jobs": [{"name": "a", "tool": "x", "every": "nightly"}]}`:                                       "every must be",
+	} {
+		write(content)
+		if _, err := Load(root); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q error, got %v", content, want, err)
+		}
+	}
+
+	write(`{"baseline": {"file": "lint/baseline.json", "policies": {"SA1019": {"expires": "720h"}, "*": {"ratchet": true}}}}`)
+	if cfg, err = Load(root); err != nil || cfg.Baseline.File != "lint/baseline.json" || cfg.Baseline.Policies["SA1019"].Expires != "720h" || !cfg.Baseline.Policies["*"].Ratchet {
+		t.Fatalf("unexpected baseline: %+v, %v", cfg.Baseline, err)
+	}
+	for content, want := range map[string]string{
+		`{"baseline": {"file": "../baseline.json"}}`:                       "baseline.file",
+		`{"baseline": {"policies": {"SA1019": {"expires": "next year"}}}}`: "baseline.policies.SA1019.expires",
+	} {
+		write(content)
+		if _, err := Load(root); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: expected %q error, got %v", content, want, err)
+		}
+	}
+
+	write(`{`)
+	if _, err := Load(root); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
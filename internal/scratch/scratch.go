@@ -0,0 +1,337 @@
+// Package scratch keeps a private copy of a workspace for isolated edits:
+// tools write to the copy, and the user's checkout only changes when the
+// changes are promoted.
+package scratch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Workspace is a copy of a workspace: a detached git worktree of its
+// repository when it is in one, a plain copy of its files otherwise. Both
+// start from the files of the checkout, uncommitted changes included.
+type Workspace struct {
+	original string
+	dir      string
+	root     string
+	// repo is the top level of the original repository, empty for a plain
+	// copy.
+	repo     string
+	worktree string
+
+	mu sync.Mutex
+	// baseline maps the slash path of every file of the checkout to the
+	// hash of its content when it was copied or last promoted.
+	baseline map[string][32]byte
+}
+
+// Change is a file that differs between the copy and the checkout.
+type Change struct {
+	// Path is relative to the workspace, in slash form.
+	Path string
+	// Original is the content in the checkout, nil when the file is not
+	// there; Scratch is the content in the copy, nil when it was deleted.
+	Original []byte
+	Scratch  []byte
+	// Conflict is set when the file of the checkout changed since it was
+	// copied, so promoting the copy would overwrite those changes.
+	Conflict bool
+}
+
+// Status is added, deleted or modified.
+func (c Change) Status() string {
+	switch {
+	case c.Original == nil:
+		return "added"
+	case c.Scratch == nil:
+		return "deleted"
+	}
+	return "modified"
+}
+
+// New copies the workspace at original into a temporary directory.
+func New(ctx context.Context, original string) (*Workspace, error) {
+	original, err := filepath.Abs(original)
+	if err == nil {
+		original, err = filepath.EvalSymlinks(original)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve workspace: %w", err)
+	}
+	root, err := os.MkdirTemp("", "mcp-gopls-scratch-")
+	if err != nil {
+		return nil, err
+	}
+	w := &Workspace{original: original, root: root, baseline: make(map[string][32]byte)}
+	if err := w.create(ctx); err != nil {
+		_ = w.remove(ctx)
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Workspace) create(ctx context.Context) error {
+	repo, err := git(ctx, w.original, "rev-parse", "--show-toplevel")
+	if err == nil {
+		_, err = git(ctx, w.original, "rev-parse", "--verify", "--quiet", "HEAD")
+	}
+	if err == nil {
+		repo = strings.TrimSpace(repo)
+		rel, err := filepath.Rel(repo, w.original)
+		if err != nil {
+			return err
+		}
+		w.worktree = filepath.Join(w.root, "worktree")
+		if _, err := git(ctx, repo, "worktree", "add", "--detach", w.worktree, "HEAD"); err != nil {
+			w.worktree = ""
+			return err
+		}
+		w.repo = repo
+		w.dir = filepath.Join(w.worktree, rel)
+		// The workspace may be a directory that was never committed.
+		if err := os.MkdirAll(w.dir, 0o755); err != nil {
+			return err
+		}
+	} else {
+		w.dir = filepath.Join(w.root, "copy")
+	}
+
+	originalFiles, err := w.files(ctx, w.original)
+	if err != nil {
+		return err
+	}
+	scratchFiles, err := w.files(ctx, w.dir)
+	if err != nil {
+		return err
+	}
+	// A worktree has the committed files; bring the uncommitted changes of
+	// the checkout over.
+	for _, rel := range originalFiles {
+		data, err := os.ReadFile(filepath.Join(w.original, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		w.baseline[rel] = sha256.Sum256(data)
+		if current, err := os.ReadFile(filepath.Join(w.dir, filepath.FromSlash(rel))); err == nil && bytes.Equal(current, data) {
+			continue
+		}
+		if err := copyFile(filepath.Join(w.original, filepath.FromSlash(rel)), filepath.Join(w.dir, filepath.FromSlash(rel))); err != nil {
+			return err
+		}
+	}
+	for _, rel := range scratchFiles {
+		if _, ok := w.baseline[rel]; !ok {
+			if err := os.Remove(filepath.Join(w.dir, filepath.FromSlash(rel))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Dir is the workspace directory of the copy.
+func (w *Workspace) Dir() string { return w.dir }
+
+// Original is the workspace directory of the checkout.
+func (w *Workspace) Original() string { return w.original }
+
+// Mode is worktree or copy.
+func (w *Workspace) Mode() string {
+	if w.worktree != "" {
+		return "worktree"
+	}
+	return "copy"
+}
+
+// Changes returns the files of the copy that differ from the checkout,
+// sorted by path.
+func (w *Workspace) Changes(ctx context.Context) ([]Change, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.changes(ctx)
+}
+
+func (w *Workspace) changes(ctx context.Context) ([]Change, error) {
+	scratchFiles, err := w.files(ctx, w.dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := append(slices.Collect(maps.Keys(w.baseline)), scratchFiles...)
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	var changes []Change
+	for _, rel := range paths {
+		scratch, err := readOptional(filepath.Join(w.dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		base, tracked := w.baseline[rel]
+		if scratch != nil && tracked && sha256.Sum256(scratch) == base || scratch == nil && !tracked {
+			continue
+		}
+		original, err := readOptional(filepath.Join(w.original, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		if scratch == nil && original == nil || scratch != nil && original != nil && bytes.Equal(scratch, original) {
+			continue
+		}
+		conflict := original != nil && (!tracked || sha256.Sum256(original) != base) || original == nil && tracked
+		changes = append(changes, Change{Path: rel, Original: original, Scratch: scratch, Conflict: conflict})
+	}
+	return changes, nil
+}
+
+// Promote copies the changes of the files at paths, or of every changed
+// file when paths is empty, to the checkout. Files in conflict are left
+// alone unless force is set. It returns the promoted files and those left
+// in conflict.
+func (w *Workspace) Promote(ctx context.Context, paths []string, force bool) (promoted, conflicts []string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changes, err := w.changes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, rel := range paths {
+		if !slices.ContainsFunc(changes, func(change Change) bool { return change.Path == rel }) {
+			return nil, nil, fmt.Errorf("%s has no change to promote", rel)
+		}
+	}
+	for _, change := range changes {
+		if len(paths) > 0 && !slices.Contains(paths, change.Path) {
+			continue
+		}
+		if change.Conflict && !force {
+			conflicts = append(conflicts, change.Path)
+			continue
+		}
+		target := filepath.Join(w.original, filepath.FromSlash(change.Path))
+		if change.Scratch == nil {
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return promoted, conflicts, err
+			}
+			delete(w.baseline, change.Path)
+		} else {
+			if err := copyFile(filepath.Join(w.dir, filepath.FromSlash(change.Path)), target); err != nil {
+				return promoted, conflicts, err
+			}
+			w.baseline[change.Path] = sha256.Sum256(change.Scratch)
+		}
+		promoted = append(promoted, change.Path)
+	}
+	return promoted, conflicts, nil
+}
+
+// Close removes the copy. A copy with changes that were not promoted is
+// kept, and the error says where.
+func (w *Workspace) Close(ctx context.Context) error {
+	changes, err := w.Changes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("%d changed files were not promoted; they are kept in %s", len(changes), w.dir)
+	}
+	return w.remove(ctx)
+}
+
+func (w *Workspace) remove(ctx context.Context) error {
+	if w.worktree != "" {
+		if _, err := git(ctx, w.repo, "worktree", "remove", "--force", w.worktree); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(w.root)
+}
+
+// files lists the files of the workspace at dir, as slash paths: those git
+// does not ignore for a worktree, every regular file outside .git for a
+// plain copy.
+func (w *Workspace) files(ctx context.Context, dir string) ([]string, error) {
+	if w.worktree == "" {
+		var files []string
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return filepath.SkipAll
+			}
+			if err == nil && entry.IsDir() && entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if err != nil || !entry.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+			return err
+		})
+		return files, err
+	}
+	output, err := git(ctx, dir, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for rel := range strings.SplitSeq(output, "\x00") {
+		// Tracked files deleted from the working tree are still listed.
+		if info, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(rel))); rel != "" && err == nil && info.Mode().IsRegular() {
+			files = append(files, rel)
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+func copyFile(from, to string) error {
+	info, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(to, data, info.Mode().Perm())
+}
+
+// readOptional reads a file, returning nil when it does not exist.
+func readOptional(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if data == nil && err == nil {
+		data = []byte{}
+	}
+	return data, err
+}
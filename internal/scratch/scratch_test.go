@@ -0,0 +1,154 @@
+package scratch
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWorkspaceCopy(t *testing.T) {
+	ctx := context.Background()
+	original := t.TempDir()
+	writeFile(t, original, "go.mod", "module example.com/app\n")
+	writeFile(t, original, "main.go", "package main\n")
+	writeFile(t, original, "old.go", "package main\n")
+
+	w, err := New(ctx, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Mode() != "copy" || readFile(t, w.Dir(), "main.go") != "package main\n" {
+		t.Fatalf("unexpected copy in %s mode", w.Mode())
+	}
+	writeFile(t, w.Dir(), "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, w.Dir(), "util/util.go", "package util\n")
+	if err := os.Remove(filepath.Join(w.Dir(), "old.go")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, original, "main.go"); got != "package main\n" {
+		t.Fatalf("the checkout changed: %q", got)
+	}
+
+	changes, err := w.Changes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, change := range changes {
+		statuses = append(statuses, change.Path+" "+change.Status())
+	}
+	if want := []string{"main.go modified", "old.go deleted", "util/util.go added"}; !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("expected %v, got %v", want, statuses)
+	}
+
+	writeFile(t, original, "main.go", "package main // edited by hand\n")
+	promoted, conflicts, err := w.Promote(ctx, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(promoted, []string{"old.go", "util/util.go"}) || !reflect.DeepEqual(conflicts, []string{"main.go"}) {
+		t.Fatalf("unexpected promotion %v, conflicts %v", promoted, conflicts)
+	}
+	if _, err := os.Stat(filepath.Join(original, "old.go")); !os.IsNotExist(err) || readFile(t, original, "util/util.go") != "package util\n" {
+		t.Fatal("the promoted changes were not copied to the checkout")
+	}
+	if got := readFile(t, original, "main.go"); got != "package main // edited by hand\n" {
+		t.Fatalf("a conflicting file was overwritten: %q", got)
+	}
+	if _, _, err := w.Promote(ctx, []string{"old.go"}, false); err == nil {
+		t.Fatal("expected a promoted file to have no change left")
+	}
+
+	if err := w.Close(ctx); err == nil {
+		t.Fatal("expected a copy with unpromoted changes to be kept")
+	}
+	if _, _, err := w.Promote(ctx, []string{"main.go"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(w.Dir()); !os.IsNotExist(err) {
+		t.Fatalf("the copy was not removed: %v", err)
+	}
+}
+
+func TestWorkspaceWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	writeFile(t, repo, ".gitignore", "bin/\n")
+	writeFile(t, repo, "app/main.go", "package main\n")
+	writeFile(t, repo, "app/gone.go", "package main\n")
+	run("init", "-q")
+	run("add", ".")
+	run("commit", "-qm", "initial")
+	writeFile(t, repo, "app/main.go", "package main // uncommitted\n")
+	writeFile(t, repo, "app/new.go", "package main\n")
+	writeFile(t, repo, "app/bin/tool", "binary")
+	if err := os.Remove(filepath.Join(repo, "app", "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(ctx, filepath.Join(repo, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Mode() != "worktree" || readFile(t, w.Dir(), "main.go") != "package main // uncommitted\n" || readFile(t, w.Dir(), "new.go") != "package main\n" {
+		t.Fatalf("the uncommitted changes were not copied in %s mode", w.Mode())
+	}
+	if _, err := os.Stat(filepath.Join(w.Dir(), "gone.go")); !os.IsNotExist(err) {
+		t.Fatal("a file deleted in the checkout is in the worktree")
+	}
+	if changes, err := w.Changes(ctx); err != nil || len(changes) != 0 {
+		t.Fatalf("expected a fresh worktree to have no change, got %v, %v", changes, err)
+	}
+
+	writeFile(t, w.Dir(), "new.go", "package main\n\nfunc helper() {}\n")
+	if promoted, _, err := w.Promote(ctx, []string{"new.go"}, false); err != nil || !reflect.DeepEqual(promoted, []string{"new.go"}) {
+		t.Fatalf("unexpected promotion %v, %v", promoted, err)
+	}
+	if got := readFile(t, repo, "app/new.go"); got != "package main\n\nfunc helper() {}\n" {
+		t.Fatalf("new.go not promoted: %q", got)
+	}
+	if err := w.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(w.Dir()); !os.IsNotExist(err) {
+		t.Fatalf("the worktree was not removed: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+// Package textedit applies byte-offset replacements to source text and
+// renders the result as a unified diff. It is the rewrite engine shared by
+// the tools that modify workspace files.
+package textedit
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// contextLines is the number of unchanged lines shown around each hunk.
+const contextLines = 3
+
+// Edit replaces src[Start:End] with New.
+type Edit struct {
+	Start int
+	End   int
+	New   string
+}
+
+// Apply returns src with edits applied. Edits may be given in any order but
+// must not overlap.
+func Apply(src []byte, edits []Edit) ([]byte, error) {
+	sorted, err := sortEdits(src, edits)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.Grow(len(src))
+	last := 0
+	for _, edit := range sorted {
+		out.Write(src[last:edit.Start])
+		out.WriteString(edit.New)
+		last = edit.End
+	}
+	out.Write(src[last:])
+	return out.Bytes(), nil
+}
+
+// Offset converts a zero-based line and UTF-16 character offset, the
+// coordinates used by the Language Server Protocol, to a byte offset in src.
+// A character past the end of the line is clamped to the end of the line.
+func Offset(src []byte, line, character int) (int, error) {
+	if line < 0 || character < 0 {
+		return 0, fmt.Errorf("invalid position %d:%d", line, character)
+	}
+	offset := 0
+	for i := 0; i < line; i++ {
+		next := bytes.IndexByte(src[offset:], '\n')
+		if next < 0 {
+			if i == line-1 && character == 0 {
+				// The position just past a final line without a newline.
+				return len(src), nil
+			}
+			return 0, fmt.Errorf("line %d out of range", line)
+		}
+		offset += next + 1
+	}
+	for units := 0; units < character && offset < len(src) && src[offset] != '\n'; {
+		r, size := utf8.DecodeRune(src[offset:])
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+		offset += size
+	}
+	return offset, nil
+}
+
+func sortEdits(src []byte, edits []Edit) ([]Edit, error) {
+	sorted := append([]Edit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	for i, edit := range sorted {
+		if edit.Start < 0 || edit.End < edit.Start || edit.End > len(src) {
+			return nil, fmt.Errorf("edit [%d,%d) out of range for %d bytes", edit.Start, edit.End, len(src))
+		}
+		if i > 0 && edit.Start < sorted[i-1].End {
+			return nil, fmt.Errorf("edits [%d,%d) and [%d,%d) overlap", sorted[i-1].Start, sorted[i-1].End, edit.Start, edit.End)
+		}
+	}
+	return sorted, nil
+}
+
+// Unified renders the line-level difference between before and after in
+// unified diff format, labelled with path. It returns an empty string when
+// the contents are equal.
+func Unified(path string, before, after []byte) string {
+	if bytes.Equal(before, after) {
+		return ""
+	}
+	a, b := splitLines(before), splitLines(after)
+
+	// Strip the common prefix and suffix; the changed middle is diffed with
+	// an LCS table, which stays small for the localized rewrites tools make.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]op, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: ' ', text: a[i]})
+	}
+	ops = append(ops, diffLines(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := len(a) - suffix; i < len(a); i++ {
+		ops = append(ops, op{kind: ' ', text: a[i]})
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", path, path)
+	writeHunks(&out, ops)
+	return out.String()
+}
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func diffLines(a, b []string) []op {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{kind: ' ', text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{kind: '-', text: a[i]})
+			i++
+		default:
+			ops = append(ops, op{kind: '+', text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{kind: '-', text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{kind: '+', text: b[j]})
+	}
+	return ops
+}
+
+func writeHunks(out *strings.Builder, ops []op) {
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk while changes are separated by at most twice the
+		// context, so neighbouring edits share one hunk.
+		end := start
+		for k := start; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k + 1
+			} else if k-end >= 2*contextLines {
+				break
+			}
+		}
+		from := max(0, start-contextLines)
+		to := min(len(ops), end+contextLines)
+
+		oldStart, newStart := 1, 1
+		for _, o := range ops[:from] {
+			if o.kind != '+' {
+				oldStart++
+			}
+			if o.kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, o := range ops[from:to] {
+			if o.kind != '+' {
+				oldCount++
+			}
+			if o.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, o := range ops[from:to] {
+			out.WriteByte(o.kind)
+			out.WriteString(o.text)
+			if !strings.HasSuffix(o.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range names the line before it.
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,76 @@
+package textedit
+
+import "testing"
+
+func TestApply(t *testing.T) {
+	src := []byte("hello brave new world")
+	got, err := Apply(src, []Edit{
+		{Start: 16, End: 21, New: "gophers"},
+		{Start: 0, End: 5, New: "goodbye"},
+		{Start: 6, End: 12, New: ""},
+	})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if string(got) != "goodbye new gophers" {
+		t.Fatalf("unexpected result %q", got)
+	}
+
+	if _, err := Apply(src, []Edit{{Start: 0, End: 5}, {Start: 3, End: 8}}); err == nil {
+		t.Fatalf("expected overlapping edits to fail")
+	}
+	if _, err := Apply(src, []Edit{{Start: 20, End: 30}}); err == nil {
+		t.Fatalf("expected out of range edit to fail")
+	}
+}
+
+func TestOffset(t *testing.T) {
+	src := []byte("ab\nx😀y\n")
+	cases := []struct {
+		line, character, want int
+	}{
+		{0, 0, 0},
+		{0, 2, 2},
+		{1, 1, 4},
+		// The emoji is two UTF-16 code units and four bytes.
+		{1, 3, 8},
+		{1, 99, 9},
+		{2, 0, 10},
+	}
+	for _, tc := range cases {
+		got, err := Offset(src, tc.line, tc.character)
+		if err != nil || got != tc.want {
+			t.Fatalf("Offset(%d, %d) = %d, %v; want %d", tc.line, tc.character, got, err, tc.want)
+		}
+	}
+	if _, err := Offset(src, 4, 0); err == nil {
+		t.Fatalf("expected an error for a line past the end")
+	}
+}
+
+func TestUnified(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn"
+	want := `--- a/x.go
++++ b/x.go
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+\ No newline at end of file
+`
+	if got := Unified("x.go", []byte(before), []byte(after)); got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if got := Unified("x.go", []byte(before), []byte(before)); got != "" {
+		t.Fatalf("expected empty diff, got %q", got)
+	}
+}
@@ -33,6 +33,7 @@ type Notifier interface {
 type Watcher struct {
 	workspaceDir string
 	notifier     Notifier
+	onChange     func(context.Context, []protocol.FileEvent)
 	logger       *slog.Logger
 }
 
@@ -52,6 +53,13 @@ func (w *Watcher) WithLogger(logger *slog.Logger) *Watcher {
 	return w
 }
 
+// WithChangeHandler sets a function called with each batch of changes,
+// after gopls was notified of it.
+func (w *Watcher) WithChangeHandler(handler func(context.Context, []protocol.FileEvent)) *Watcher {
+	w.onChange = handler
+	return w
+}
+
 // Run starts filesystem watching and blocks until ctx is cancelled.
 func (w *Watcher) Run(ctx context.Context) {
 	fsWatcher, err := fsnotify.NewWatcher()
@@ -118,6 +126,9 @@ func (w *Watcher) eventLoop(ctx context.Context, fsWatcher *fsnotify.Watcher) {
 		} else {
 			w.logger.Debug("notified gopls about file changes", "count", len(changes))
 		}
+		if w.onChange != nil {
+			w.onChange(ctx, changes)
+		}
 	}
 
 	for {
@@ -195,6 +206,15 @@ func toFileChangeType(op fsnotify.Op) protocol.FileChangeType {
 	}
 }
 
+// URIToPath converts a file:// URI of a change event back to its path.
+func URIToPath(uri string) string {
+	path := strings.TrimPrefix(uri, "file://")
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:] // drop the slash pathToURI puts before a drive letter
+	}
+	return filepath.FromSlash(path)
+}
+
 // pathToURI converts an absolute filesystem path to an LSP file:// URI.
 func pathToURI(path string) string {
 	abs, err := filepath.Abs(path)
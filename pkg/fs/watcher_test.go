@@ -222,3 +222,31 @@ func TestWatcher_SkipsVendorDir(t *testing.T) {
 		}
 	}
 }
+
+func TestWatcher_ChangeHandler(t *testing.T) {
+	dir := t.TempDir()
+	notifier := newStubNotifier()
+	handled := make(chan []protocol.FileEvent, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := fs.NewWatcher(dir, notifier).WithChangeHandler(func(_ context.Context, changes []protocol.FileEvent) {
+		handled <- changes
+	})
+	go w.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	path := filepath.Join(dir, "foo.go")
+	if err := os.WriteFile(path, []byte("package foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changes := <-handled:
+		resolved, _ := filepath.EvalSymlinks(path)
+		if len(changes) == 0 || (fs.URIToPath(changes[0].URI) != path && fs.URIToPath(changes[0].URI) != resolved) {
+			t.Errorf("unexpected changes %v", changes)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the change handler")
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,8 +28,43 @@ const (
 	defaultCallTimeout = 45 * time.Second
 	clientName         = "mcp-gopls"
 	clientVersion      = "2.0.0-dev"
+
+	quiescencePollInterval = 50 * time.Millisecond
+)
+
+// inlayHintSettings enables every inlay hint gopls can compute; it returns
+// none unless asked to. Configured settings can override it.
+var inlayHintSettings = map[string]any{
+	"assignVariableTypes":    true,
+	"compositeLiteralFields": true,
+	"compositeLiteralTypes":  true,
+	"constantValues":         true,
+	"functionTypeParameters": true,
+	"parameterNames":         true,
+	"rangeVariableTypes":     true,
+}
+
+// semanticTokenTypes and semanticTokenModifiers are the standard LSP token
+// types and modifiers, which the client announces it understands.
+var (
+	semanticTokenTypes = []string{
+		"namespace", "type", "class", "enum", "interface", "struct", "typeParameter", "parameter",
+		"variable", "property", "enumMember", "event", "function", "method", "macro", "keyword",
+		"modifier", "comment", "string", "number", "regexp", "operator", "decorator", "label",
+	}
+	semanticTokenModifiers = []string{
+		"declaration", "definition", "readonly", "static", "deprecated", "abstract",
+		"async", "modification", "documentation", "defaultLibrary",
+	}
 )
 
+// codeLensSettings enables the code lenses gopls leaves off by default, so
+// that test functions get their run lens. Configured settings can override
+// it.
+var codeLensSettings = map[string]any{
+	"test": true,
+}
+
 // Option configures the gopls client.
 type Option func(*clientOptions)
 
@@ -36,6 +73,7 @@ type clientOptions struct {
 	workspaceDir string
 	logger       *slog.Logger
 	callTimeout  time.Duration
+	settings     map[string]any
 }
 
 // WithExecutable overrides the gopls binary path.
@@ -68,6 +106,14 @@ func WithCallTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithSettings passes gopls settings, such as {"gofumpt": true}, as
+// initialization options and as the answer to workspace/configuration.
+func WithSettings(settings map[string]any) Option {
+	return func(cfg *clientOptions) {
+		cfg.settings = settings
+	}
+}
+
 // GoplsClient implements the LSPClient interface using a managed gopls process.
 type GoplsClient struct {
 	cmd          *exec.Cmd
@@ -75,9 +121,11 @@ type GoplsClient struct {
 	logger       *slog.Logger
 	callTimeout  time.Duration
 	callOverride func(context.Context, string, any) (*protocol.JSONRPCMessage, error)
+	retry        retryPolicy
 
 	workspaceDir string
 	workspaceURI string
+	settings     map[string]any
 
 	sendMu      sync.Mutex
 	nextID      atomic.Int64
@@ -91,6 +139,17 @@ type GoplsClient struct {
 
 	openedDocs sync.Map
 
+	// semanticLegend decodes semantic tokens; nil when gopls does not
+	// provide them.
+	semanticLegend *protocol.SemanticTokensLegend
+	// commands are the workspace/executeCommand commands gopls advertises.
+	commands []string
+
+	// changes holds the file changes waiting for changeDebounce to pass;
+	// they are sent right away when it is zero.
+	changes        changeBatch
+	changeDebounce time.Duration
+
 	readerCtx    context.Context
 	readerCancel context.CancelFunc
 	readerDone   chan struct{}
@@ -100,6 +159,19 @@ type GoplsClient struct {
 
 	diagnosticsWaiters map[string][]chan struct{}
 
+	// progressTokens holds the titles of the work-done progress gopls has
+	// begun and not ended; lastActivity is when it last reported progress
+	// or published diagnostics.
+	activityMu     sync.Mutex
+	progressTokens map[string]string
+	lastActivity   time.Time
+
+	// commandMu serializes ExecuteCommand so the workspace/applyEdit
+	// requests gopls sends while a command runs are attributed to it.
+	commandMu    sync.Mutex
+	appliedMu    sync.Mutex
+	appliedEdits *[]protocol.WorkspaceEdit
+
 	closeOnce sync.Once
 }
 
@@ -168,12 +240,16 @@ func NewGoplsClient(opts ...Option) (*GoplsClient, error) {
 		transport:           protocol.NewTransport(bufio.NewReader(stdout), bufio.NewWriter(stdin)),
 		logger:              cfg.logger.With("component", "lsp_client"),
 		callTimeout:         cfg.callTimeout,
+		retry:               defaultRetryPolicy,
+		changeDebounce:      changeDebounce,
 		workspaceDir:        workspaceDir,
 		workspaceURI:        workspaceURI,
+		settings:            cfg.settings,
 		diagnosticsCache:    make(map[string][]protocol.Diagnostic),
 		diagnosticsHandlers: make(map[int64]DiagnosticsHandler),
 		pending:             make(map[int64]chan rpcResponse),
 		diagnosticsWaiters:  make(map[string][]chan struct{}),
+		progressTokens:      make(map[string]string),
 	}
 
 	client.nextID.Store(1)
@@ -212,6 +288,11 @@ func (c *GoplsClient) readerLoop() {
 			continue
 		}
 
+		if msg.Method != "" {
+			c.handleServerRequest(msg)
+			continue
+		}
+
 		respID, ok := parseMessageID(msg.ID)
 		if !ok {
 			c.logger.Warn("response id has unexpected type", "id", msg.ID)
@@ -309,7 +390,7 @@ func (c *GoplsClient) call(ctx context.Context, method string, params any) (*pro
 			return nil, resp.err
 		}
 		if resp.msg.Error != nil {
-			return nil, fmt.Errorf("lsp error: %s (code %d)", resp.msg.Error.Message, resp.msg.Error.Code)
+			return nil, fmt.Errorf("lsp error: %w", resp.msg.Error)
 		}
 		return resp.msg, nil
 	}
@@ -362,11 +443,34 @@ func (c *GoplsClient) notify(method string, params any) error {
 	return nil
 }
 
+func (c *GoplsClient) respond(msg *protocol.JSONRPCMessage) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.closed.Load() {
+		return errors.New("client closed")
+	}
+	if err := c.transport.SendMessage(msg); err != nil {
+		c.closed.Store(true)
+		return fmt.Errorf("send response: %w", err)
+	}
+	return nil
+}
+
 func (c *GoplsClient) invoke(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+	if err := c.flushFileChanges(); err != nil {
+		return nil, err
+	}
+	call := c.call
 	if c.callOverride != nil {
-		return c.callOverride(ctx, method, params)
+		call = c.callOverride
 	}
-	return c.call(ctx, method, params)
+	if c.retry.attempts <= 1 || !idempotentMethods[method] {
+		return call(ctx, method, params)
+	}
+	return c.retry.do(ctx, c.logger, method, func() (*protocol.JSONRPCMessage, error) {
+		return call(ctx, method, params)
+	})
 }
 
 // Initialize satisfies the LSPClient interface.
@@ -381,8 +485,12 @@ func (c *GoplsClient) Initialize(ctx context.Context) error {
 			"name":    clientName,
 			"version": clientVersion,
 		},
-		"rootUri": c.workspaceURI,
+		"rootUri":               c.workspaceURI,
+		"initializationOptions": c.initializationOptions(),
 		"capabilities": map[string]any{
+			"window": map[string]any{
+				"workDoneProgress": true,
+			},
 			"textDocument": map[string]any{
 				"synchronization": map[string]any{
 					"dynamicRegistration": true,
@@ -401,15 +509,61 @@ func (c *GoplsClient) Initialize(ctx context.Context) error {
 				"definition": map[string]any{
 					"dynamicRegistration": true,
 				},
+				"typeDefinition": map[string]any{
+					"dynamicRegistration": true,
+				},
+				"declaration": map[string]any{
+					"dynamicRegistration": true,
+				},
 				"references": map[string]any{
 					"dynamicRegistration": true,
 				},
+				"documentSymbol": map[string]any{
+					"hierarchicalDocumentSymbolSupport": true,
+				},
+				"inlayHint": map[string]any{},
+				"selectionRange": map[string]any{
+					"dynamicRegistration": true,
+				},
+				"semanticTokens": map[string]any{
+					"requests": map[string]any{
+						"range": true,
+						"full":  true,
+					},
+					"tokenTypes":     semanticTokenTypes,
+					"tokenModifiers": semanticTokenModifiers,
+					"formats":        []string{"relative"},
+				},
+				"foldingRange": map[string]any{
+					"lineFoldingOnly": true,
+				},
+				"codeLens": map[string]any{
+					"dynamicRegistration": true,
+				},
+				"documentHighlight": map[string]any{
+					"dynamicRegistration": true,
+				},
 				"publishDiagnostics": map[string]any{
 					"relatedInformation": true,
 				},
+				"codeAction": map[string]any{
+					"dataSupport": true,
+					"resolveSupport": map[string]any{
+						"properties": []string{"edit"},
+					},
+					"codeActionLiteralSupport": map[string]any{
+						"codeActionKind": map[string]any{
+							"valueSet": []string{"", "quickfix", "refactor", "refactor.extract", "refactor.inline", "refactor.rewrite", "source", "source.organizeImports", "source.fixAll", "source.addTest"},
+						},
+					},
+				},
 			},
 			"workspace": map[string]any{
 				"applyEdit": true,
+				"workspaceEdit": map[string]any{
+					"documentChanges":    true,
+					"resourceOperations": []string{"create"},
+				},
 				"symbol": map[string]any{
 					"dynamicRegistration": true,
 				},
@@ -420,8 +574,10 @@ func (c *GoplsClient) Initialize(ctx context.Context) error {
 
 	var lastErr error
 	for attempt := 1; attempt <= 3; attempt++ {
-		_, lastErr = c.call(ctx, "initialize", initParams)
+		var resp *protocol.JSONRPCMessage
+		resp, lastErr = c.call(ctx, "initialize", initParams)
 		if lastErr == nil {
+			c.recordCapabilities(resp)
 			c.initialized.Store(true)
 			break
 		}
@@ -445,6 +601,31 @@ func (c *GoplsClient) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// recordCapabilities keeps what the client needs from the server
+// capabilities of the initialize result.
+func (c *GoplsClient) recordCapabilities(resp *protocol.JSONRPCMessage) {
+	var result struct {
+		Capabilities struct {
+			SemanticTokensProvider *struct {
+				Legend protocol.SemanticTokensLegend `json:"legend"`
+			} `json:"semanticTokensProvider"`
+			ExecuteCommandProvider *struct {
+				Commands []string `json:"commands"`
+			} `json:"executeCommandProvider"`
+		} `json:"capabilities"`
+	}
+	if err := resp.ParseResult(&result); err != nil {
+		c.logger.Warn("failed to decode server capabilities", "error", err)
+		return
+	}
+	if provider := result.Capabilities.SemanticTokensProvider; provider != nil {
+		c.semanticLegend = &provider.Legend
+	}
+	if provider := result.Capabilities.ExecuteCommandProvider; provider != nil {
+		c.commands = slices.Sorted(slices.Values(provider.Commands))
+	}
+}
+
 // Shutdown gracefully shuts gopls down.
 func (c *GoplsClient) Shutdown(ctx context.Context) error {
 	if !c.initialized.Load() {
@@ -466,6 +647,11 @@ func (c *GoplsClient) Close(ctx context.Context) error {
 
 	c.closeOnce.Do(func() {
 		c.closed.Store(true)
+		c.changes.mu.Lock()
+		if c.changes.timer != nil {
+			c.changes.timer.Stop()
+		}
+		c.changes.mu.Unlock()
 
 		if c.readerCancel != nil {
 			c.readerCancel()
@@ -525,6 +711,59 @@ func (c *GoplsClient) GoToDefinition(ctx context.Context, uri string, line, char
 	return locations, nil
 }
 
+// GoToTypeDefinition implements LSPClient.
+func (c *GoplsClient) GoToTypeDefinition(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	params := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position: protocol.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+
+	resp, err := c.invoke(ctx, "textDocument/typeDefinition", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []protocol.Location
+	if err := resp.ParseResult(&locations); err != nil {
+		return nil, fmt.Errorf("decode type definition: %w", err)
+	}
+	return locations, nil
+}
+
+// GoToDeclaration implements LSPClient. Go has no declarations separate
+// from definitions, and gopls versions that do not implement
+// textDocument/declaration are answered with the definition.
+func (c *GoplsClient) GoToDeclaration(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	params := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position: protocol.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+
+	resp, err := c.invoke(ctx, "textDocument/declaration", params)
+	if err == nil && resp.Error != nil {
+		err = resp.Error
+	}
+	var rpcErr *protocol.JSONRPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == protocol.CodeMethodNotFound {
+		return c.GoToDefinition(ctx, uri, line, character)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []protocol.Location
+	if err := resp.ParseResult(&locations); err != nil {
+		return nil, fmt.Errorf("decode declaration: %w", err)
+	}
+	return locations, nil
+}
+
 // FindReferences implements LSPClient.
 func (c *GoplsClient) FindReferences(ctx context.Context, uri string, line, character int, includeDeclaration bool) ([]protocol.Location, error) {
 	params := protocol.ReferenceParams{
@@ -552,6 +791,123 @@ func (c *GoplsClient) FindReferences(ctx context.Context, uri string, line, char
 	return locations, nil
 }
 
+// FindImplementations implements LSPClient.
+func (c *GoplsClient) FindImplementations(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	params := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position: protocol.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+
+	resp, err := c.invoke(ctx, "textDocument/implementation", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []protocol.Location
+	if err := resp.ParseResult(&locations); err != nil {
+		return nil, fmt.Errorf("decode implementation: %w", err)
+	}
+	return locations, nil
+}
+
+// PrepareCallHierarchy implements LSPClient.
+func (c *GoplsClient) PrepareCallHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.CallHierarchyItem, error) {
+	params := protocol.CallHierarchyPrepareParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position: protocol.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+
+	resp, err := c.invoke(ctx, "textDocument/prepareCallHierarchy", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []protocol.CallHierarchyItem
+	if err := resp.ParseResult(&items); err != nil {
+		return nil, fmt.Errorf("decode call hierarchy: %w", err)
+	}
+	return items, nil
+}
+
+// IncomingCalls implements LSPClient.
+func (c *GoplsClient) IncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	resp, err := c.invoke(ctx, "callHierarchy/incomingCalls", protocol.CallHierarchyCallsParams{Item: item})
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []protocol.CallHierarchyIncomingCall
+	if err := resp.ParseResult(&calls); err != nil {
+		return nil, fmt.Errorf("decode incoming calls: %w", err)
+	}
+	return calls, nil
+}
+
+// OutgoingCalls implements LSPClient.
+func (c *GoplsClient) OutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	resp, err := c.invoke(ctx, "callHierarchy/outgoingCalls", protocol.CallHierarchyCallsParams{Item: item})
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []protocol.CallHierarchyOutgoingCall
+	if err := resp.ParseResult(&calls); err != nil {
+		return nil, fmt.Errorf("decode outgoing calls: %w", err)
+	}
+	return calls, nil
+}
+
+// PrepareTypeHierarchy implements LSPClient.
+func (c *GoplsClient) PrepareTypeHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.TypeHierarchyItem, error) {
+	params := protocol.TypeHierarchyPrepareParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position: protocol.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+
+	resp, err := c.invoke(ctx, "textDocument/prepareTypeHierarchy", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []protocol.TypeHierarchyItem
+	if err := resp.ParseResult(&items); err != nil {
+		return nil, fmt.Errorf("decode type hierarchy: %w", err)
+	}
+	return items, nil
+}
+
+// Supertypes implements LSPClient.
+func (c *GoplsClient) Supertypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	return c.typeHierarchyTypes(ctx, "typeHierarchy/supertypes", item)
+}
+
+// Subtypes implements LSPClient.
+func (c *GoplsClient) Subtypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	return c.typeHierarchyTypes(ctx, "typeHierarchy/subtypes", item)
+}
+
+func (c *GoplsClient) typeHierarchyTypes(ctx context.Context, method string, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	resp, err := c.invoke(ctx, method, protocol.TypeHierarchyTypesParams{Item: item})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []protocol.TypeHierarchyItem
+	if err := resp.ParseResult(&items); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", method, err)
+	}
+	return items, nil
+}
+
 func (c *GoplsClient) waitForDiagnostics(ctx context.Context, uri string) error {
 	if ctx == nil {
 		ctx = context.Background()
@@ -626,12 +982,20 @@ func (c *GoplsClient) DidOpen(ctx context.Context, uri, languageID, text string)
 	return err
 }
 
+// OpenDocument implements LSPClient.
+func (c *GoplsClient) OpenDocument(ctx context.Context, uri, languageID, text string) (bool, error) {
+	return c.ensureDocumentOpen(uri, languageID, text)
+}
+
 func (c *GoplsClient) ensureDocumentOpen(uri, languageID, text string) (bool, error) {
 	if uri == "" {
 		return false, errors.New("uri is required")
 	}
 
-	if _, alreadyOpen := c.openedDocs.LoadOrStore(uri, struct{}{}); alreadyOpen {
+	if err := c.flushFileChanges(); err != nil {
+		return false, err
+	}
+	if _, alreadyOpen := c.openedDocs.LoadOrStore(uri, &openDocument{version: 1}); alreadyOpen {
 		return false, nil
 	}
 
@@ -737,6 +1101,19 @@ func (c *GoplsClient) GetHover(ctx context.Context, uri string, line, character
 
 // GetCompletion implements LSPClient.
 func (c *GoplsClient) GetCompletion(ctx context.Context, uri string, line, character int) ([]string, error) {
+	list, err := c.GetCompletionList(ctx, uri, line, character)
+	if err != nil {
+		return nil, err
+	}
+	var completions []string
+	for _, item := range list.Items {
+		completions = append(completions, item.Label)
+	}
+	return completions, nil
+}
+
+// GetCompletionList implements LSPClient.
+func (c *GoplsClient) GetCompletionList(ctx context.Context, uri string, line, character int) (*protocol.CompletionList, error) {
 	opened, err := c.ensureDocumentOpen(uri, "go", "")
 	if err != nil {
 		return nil, err
@@ -759,23 +1136,14 @@ func (c *GoplsClient) GetCompletion(ctx context.Context, uri string, line, chara
 		return nil, err
 	}
 
-	var payload map[string]any
-	if err := resp.ParseResult(&payload); err != nil {
-		return nil, fmt.Errorf("decode completion: %w", err)
-	}
-
-	var completions []string
-	if items, ok := payload["items"].([]any); ok {
-		for _, item := range items {
-			if dict, ok := item.(map[string]any); ok {
-				if label, ok := dict["label"].(string); ok {
-					completions = append(completions, label)
-				}
-			}
+	// The result is a CompletionList or a bare array of items.
+	var list protocol.CompletionList
+	if err := resp.ParseResult(&list); err != nil {
+		if err := resp.ParseResult(&list.Items); err != nil {
+			return nil, fmt.Errorf("decode completion: %w", err)
 		}
 	}
-
-	return completions, nil
+	return &list, nil
 }
 
 func (c *GoplsClient) DocumentFormatting(ctx context.Context, uri string) ([]protocol.TextEdit, error) {
@@ -823,12 +1191,13 @@ func (c *GoplsClient) Rename(ctx context.Context, uri string, line, character in
 	return &edit, nil
 }
 
-func (c *GoplsClient) CodeActions(ctx context.Context, uri string, rng protocol.Range) ([]protocol.CodeAction, error) {
+func (c *GoplsClient) CodeActions(ctx context.Context, uri string, rng protocol.Range, only ...string) ([]protocol.CodeAction, error) {
 	params := protocol.CodeActionParams{
 		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
 		Range:        rng,
 		Context: protocol.CodeActionContext{
 			Diagnostics: []protocol.Diagnostic{},
+			Only:        only,
 		},
 	}
 
@@ -844,6 +1213,59 @@ func (c *GoplsClient) CodeActions(ctx context.Context, uri string, rng protocol.
 	return actions, nil
 }
 
+// ResolveCodeAction implements LSPClient.
+func (c *GoplsClient) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (*protocol.CodeAction, error) {
+	resp, err := c.invoke(ctx, "codeAction/resolve", action)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved protocol.CodeAction
+	if err := resp.ParseResult(&resolved); err != nil {
+		return nil, fmt.Errorf("decode resolved code action: %w", err)
+	}
+	return &resolved, nil
+}
+
+// ExecuteCommand implements LSPClient. The edits gopls asks the client to
+// apply while the command runs are acknowledged and returned, not written:
+// the caller decides what to do with them.
+func (c *GoplsClient) ExecuteCommand(ctx context.Context, command protocol.Command) ([]protocol.WorkspaceEdit, error) {
+	_, edits, err := c.RunCommand(ctx, command)
+	return edits, err
+}
+
+// RunCommand implements LSPClient.
+func (c *GoplsClient) RunCommand(ctx context.Context, command protocol.Command) (json.RawMessage, []protocol.WorkspaceEdit, error) {
+	c.commandMu.Lock()
+	defer c.commandMu.Unlock()
+
+	edits := []protocol.WorkspaceEdit{}
+	c.appliedMu.Lock()
+	c.appliedEdits = &edits
+	c.appliedMu.Unlock()
+	defer func() {
+		c.appliedMu.Lock()
+		c.appliedEdits = nil
+		c.appliedMu.Unlock()
+	}()
+
+	params := protocol.ExecuteCommandParams{Command: command.Command, Arguments: command.Arguments}
+	resp, err := c.invoke(ctx, "workspace/executeCommand", params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.appliedMu.Lock()
+	defer c.appliedMu.Unlock()
+	return resp.Result, edits, nil
+}
+
+// Commands implements LSPClient.
+func (c *GoplsClient) Commands() []string {
+	return slices.Clone(c.commands)
+}
+
 func (c *GoplsClient) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
 	params := protocol.WorkspaceSymbolParams{Query: query}
 	resp, err := c.invoke(ctx, "workspace/symbol", params)
@@ -858,14 +1280,133 @@ func (c *GoplsClient) WorkspaceSymbols(ctx context.Context, query string) ([]pro
 	return symbols, nil
 }
 
+// DocumentSymbols implements LSPClient.
+func (c *GoplsClient) DocumentSymbols(ctx context.Context, uri string) ([]protocol.DocumentSymbol, error) {
+	params := protocol.DocumentSymbolParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}}
+	resp, err := c.invoke(ctx, "textDocument/documentSymbol", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []protocol.DocumentSymbol
+	if err := resp.ParseResult(&symbols); err != nil {
+		return nil, fmt.Errorf("decode document symbols: %w", err)
+	}
+	return symbols, nil
+}
+
+// InlayHints implements LSPClient.
+func (c *GoplsClient) InlayHints(ctx context.Context, uri string, rng protocol.Range) ([]protocol.InlayHint, error) {
+	params := protocol.InlayHintParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}, Range: rng}
+	resp, err := c.invoke(ctx, "textDocument/inlayHint", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var hints []protocol.InlayHint
+	if err := resp.ParseResult(&hints); err != nil {
+		return nil, fmt.Errorf("decode inlay hints: %w", err)
+	}
+	return hints, nil
+}
+
+// SemanticTokens implements LSPClient.
+func (c *GoplsClient) SemanticTokens(ctx context.Context, uri string, rng protocol.Range) ([]protocol.SemanticToken, error) {
+	if c.semanticLegend == nil {
+		return nil, errors.New("gopls does not provide semantic tokens; enable its semanticTokens setting")
+	}
+	params := protocol.SemanticTokensRangeParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}, Range: rng}
+	resp, err := c.invoke(ctx, "textDocument/semanticTokens/range", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens protocol.SemanticTokens
+	if err := resp.ParseResult(&tokens); err != nil {
+		return nil, fmt.Errorf("decode semantic tokens: %w", err)
+	}
+	return protocol.DecodeSemanticTokens(tokens.Data, *c.semanticLegend), nil
+}
+
+// SelectionRanges implements LSPClient.
+func (c *GoplsClient) SelectionRanges(ctx context.Context, uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+	params := protocol.SelectionRangeParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}, Positions: positions}
+	resp, err := c.invoke(ctx, "textDocument/selectionRange", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []protocol.SelectionRange
+	if err := resp.ParseResult(&ranges); err != nil {
+		return nil, fmt.Errorf("decode selection ranges: %w", err)
+	}
+	return ranges, nil
+}
+
+// FoldingRanges implements LSPClient.
+func (c *GoplsClient) FoldingRanges(ctx context.Context, uri string) ([]protocol.FoldingRange, error) {
+	params := protocol.FoldingRangeParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}}
+	resp, err := c.invoke(ctx, "textDocument/foldingRange", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []protocol.FoldingRange
+	if err := resp.ParseResult(&ranges); err != nil {
+		return nil, fmt.Errorf("decode folding ranges: %w", err)
+	}
+	return ranges, nil
+}
+
+// CodeLenses implements LSPClient.
+func (c *GoplsClient) CodeLenses(ctx context.Context, uri string) ([]protocol.CodeLens, error) {
+	params := protocol.CodeLensParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}}
+	resp, err := c.invoke(ctx, "textDocument/codeLens", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var lenses []protocol.CodeLens
+	if err := resp.ParseResult(&lenses); err != nil {
+		return nil, fmt.Errorf("decode code lenses: %w", err)
+	}
+	return lenses, nil
+}
+
+// DocumentHighlights implements LSPClient.
+func (c *GoplsClient) DocumentHighlights(ctx context.Context, uri string, line, character int) ([]protocol.DocumentHighlight, error) {
+	params := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position: protocol.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+	resp, err := c.invoke(ctx, "textDocument/documentHighlight", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var highlights []protocol.DocumentHighlight
+	if err := resp.ParseResult(&highlights); err != nil {
+		return nil, fmt.Errorf("decode document highlights: %w", err)
+	}
+	return highlights, nil
+}
+
 // NotifyDidChangeWatchedFiles sends a workspace/didChangeWatchedFiles notification
 // to gopls, causing it to invalidate its cache and re-index the changed files.
+// Changes are batched: they are sent once no more came for a short while,
+// or before the next request, and several changes of a file count as one.
 func (c *GoplsClient) NotifyDidChangeWatchedFiles(_ context.Context, changes []protocol.FileEvent) error {
 	if len(changes) == 0 {
 		return nil
 	}
-	params := protocol.DidChangeWatchedFilesParams{Changes: changes}
-	return c.notify("workspace/didChangeWatchedFiles", params)
+	if c.changeDebounce <= 0 {
+		return c.sendFileChanges(changes)
+	}
+	c.queueFileChanges(changes)
+	return nil
 }
 
 // OnDiagnostics registers a handler for publishDiagnostics notifications.
@@ -895,12 +1436,143 @@ func (c *GoplsClient) handleNotification(msg *protocol.JSONRPCMessage) {
 			return
 		}
 		c.updateDiagnostics(params)
+	case "$/progress":
+		var params protocol.ProgressParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.logger.Warn("failed to decode progress", "error", err)
+			return
+		}
+		c.updateProgress(params)
 	default:
 		c.logger.Debug("ignoring notification", "method", msg.Method)
 	}
 }
 
+// handleServerRequest answers a request gopls sends to the client. Every
+// request gets a reply, so gopls never waits on one.
+func (c *GoplsClient) handleServerRequest(msg *protocol.JSONRPCMessage) {
+	result, rpcErr := c.serverRequestResult(msg)
+	resp, err := protocol.NewResponse(msg.ID, result, rpcErr)
+	if err == nil {
+		err = c.respond(resp)
+	}
+	if err != nil {
+		c.logger.Warn("failed to answer server request", "method", msg.Method, "error", err)
+	}
+}
+
+// goplsSettings are the configured settings over the client's defaults.
+func (c *GoplsClient) goplsSettings() map[string]any {
+	// gopls only provides semantic tokens when asked to.
+	settings := map[string]any{"hints": inlayHintSettings, "codelenses": codeLensSettings, "semanticTokens": true}
+	maps.Copy(settings, c.settings)
+	return settings
+}
+
+// initializationOptions are the gopls settings, plus the progress
+// reporting WaitForQuiescence watches for background work, diagnostics
+// included.
+func (c *GoplsClient) initializationOptions() map[string]any {
+	options := c.goplsSettings()
+	options["verboseWorkDoneProgress"] = true
+	return options
+}
+
+func (c *GoplsClient) serverRequestResult(msg *protocol.JSONRPCMessage) (any, *protocol.JSONRPCError) {
+	switch msg.Method {
+	case "workspace/applyEdit":
+		var params protocol.ApplyWorkspaceEditParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &protocol.JSONRPCError{Code: -32602, Message: err.Error()}
+		}
+		c.appliedMu.Lock()
+		defer c.appliedMu.Unlock()
+		if c.appliedEdits == nil {
+			return protocol.ApplyWorkspaceEditResult{FailureReason: "edits are only accepted while a command runs"}, nil
+		}
+		*c.appliedEdits = append(*c.appliedEdits, params.Edit)
+		return protocol.ApplyWorkspaceEditResult{Applied: true}, nil
+	case "workspace/configuration":
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		// One entry per requested item: the settings for the gopls
+		// section, null (the defaults) for anything else.
+		results := make([]any, len(params.Items))
+		for i, item := range params.Items {
+			var scope struct {
+				Section string `json:"section"`
+			}
+			_ = json.Unmarshal(item, &scope)
+			if scope.Section == "gopls" {
+				results[i] = c.goplsSettings()
+			}
+		}
+		return results, nil
+	case "window/workDoneProgress/create", "client/registerCapability", "client/unregisterCapability":
+		return nil, nil
+	default:
+		c.logger.Debug("unsupported server request", "method", msg.Method)
+		return nil, &protocol.JSONRPCError{Code: protocol.CodeMethodNotFound, Message: "method not supported: " + msg.Method}
+	}
+}
+
+func (c *GoplsClient) updateProgress(params protocol.ProgressParams) {
+	token := string(params.Token)
+	c.activityMu.Lock()
+	defer c.activityMu.Unlock()
+	switch params.Value.Kind {
+	case "begin":
+		c.progressTokens[token] = params.Value.Title
+	case "end":
+		delete(c.progressTokens, token)
+	}
+	c.lastActivity = time.Now()
+}
+
+// WaitForQuiescence implements LSPClient. gopls announces no end to a
+// diagnostics pass, so quiescence is approximated: no progress underway
+// and nothing reported for quiet, counted from the call at the earliest.
+func (c *GoplsClient) WaitForQuiescence(ctx context.Context, quiet time.Duration) error {
+	start := time.Now()
+	ticker := time.NewTicker(quiescencePollInterval)
+	defer ticker.Stop()
+	for {
+		c.activityMu.Lock()
+		busy := len(c.progressTokens) > 0
+		last := c.lastActivity
+		c.activityMu.Unlock()
+		if last.Before(start) {
+			last = start
+		}
+		if !busy && time.Since(last) >= quiet {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkspaceDiagnostics implements LSPClient.
+func (c *GoplsClient) WorkspaceDiagnostics() map[string][]protocol.Diagnostic {
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+	snapshot := make(map[string][]protocol.Diagnostic, len(c.diagnosticsCache))
+	for uri, items := range c.diagnosticsCache {
+		snapshot[uri] = slices.Clone(items)
+	}
+	return snapshot
+}
+
 func (c *GoplsClient) updateDiagnostics(params protocol.PublishDiagnosticsParams) {
+	c.activityMu.Lock()
+	c.lastActivity = time.Now()
+	c.activityMu.Unlock()
+
 	c.diagnosticsMu.Lock()
 	c.diagnosticsCache[params.URI] = params.Diagnostics
 
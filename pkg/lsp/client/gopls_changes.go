@@ -0,0 +1,174 @@
+package client
+
+import (
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	// changeDebounce is how long file changes are held for more to come,
+	// so that the files an edit rewrites one after the other reach gopls
+	// as one batch.
+	changeDebounce = 50 * time.Millisecond
+	// changeMaxDelay bounds how long a steady stream of changes is held.
+	changeMaxDelay = 250 * time.Millisecond
+)
+
+// openDocument is a document open in gopls, whose overlay must be updated
+// with didChange when the file changes on disk.
+type openDocument struct {
+	mu      sync.Mutex
+	version int
+}
+
+// changeBatch holds the file changes not yet sent to gopls, with one
+// coalesced change per URI in the order they were first changed. sendMu is
+// held from taking the batch until it is sent, so that a flush never returns
+// while another is still sending changes made before it.
+type changeBatch struct {
+	sendMu  sync.Mutex
+	mu      sync.Mutex
+	pending map[string]protocol.FileChangeType
+	order   []string
+	started time.Time
+	timer   *time.Timer
+}
+
+// coalesceChange merges a change into the one pending for the same file: a
+// file created then changed is still created, created then deleted needs
+// no event, and deleted then created is changed.
+func coalesceChange(previous, next protocol.FileChangeType) (protocol.FileChangeType, bool) {
+	switch {
+	case previous == protocol.FileCreated && next == protocol.FileDeleted:
+		return 0, false
+	case previous == protocol.FileCreated:
+		return protocol.FileCreated, true
+	case previous == protocol.FileDeleted && next != protocol.FileDeleted:
+		return protocol.FileChanged, true
+	default:
+		return next, true
+	}
+}
+
+// queueFileChanges adds changes to the batch and (re)arms the debounce.
+func (c *GoplsClient) queueFileChanges(changes []protocol.FileEvent) {
+	b := &c.changes
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending == nil {
+		b.pending = make(map[string]protocol.FileChangeType)
+	}
+	for _, change := range changes {
+		previous, ok := b.pending[change.URI]
+		if !ok {
+			b.pending[change.URI] = change.Type
+			b.order = append(b.order, change.URI)
+			continue
+		}
+		if merged, keep := coalesceChange(previous, change.Type); keep {
+			b.pending[change.URI] = merged
+		} else {
+			delete(b.pending, change.URI)
+			b.order = slices.DeleteFunc(b.order, func(uri string) bool { return uri == change.URI })
+		}
+	}
+
+	switch {
+	case b.timer == nil:
+		b.started = time.Now()
+		b.timer = time.AfterFunc(c.changeDebounce, func() {
+			if c.closed.Load() {
+				return
+			}
+			if err := c.flushFileChanges(); err != nil {
+				c.logger.Warn("failed to notify gopls about file changes", "error", err)
+			}
+		})
+	case time.Since(b.started) < changeMaxDelay:
+		b.timer.Reset(c.changeDebounce)
+	}
+}
+
+// flushFileChanges sends the pending file changes now. Requests call it
+// first, so that gopls answers them with the edits made just before.
+func (c *GoplsClient) flushFileChanges() error {
+	b := &c.changes
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	b.mu.Lock()
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	changes := make([]protocol.FileEvent, 0, len(b.order))
+	for _, uri := range b.order {
+		changes = append(changes, protocol.FileEvent{URI: uri, Type: b.pending[uri]})
+	}
+	b.pending, b.order = nil, nil
+	b.mu.Unlock()
+	return c.sendFileChanges(changes)
+}
+
+// sendFileChanges tells gopls about changed files: the overlays of open
+// documents get their new content through didChange, since gopls does not
+// read them from disk, and the other files are reported as watched files.
+func (c *GoplsClient) sendFileChanges(changes []protocol.FileEvent) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	watched := make([]protocol.FileEvent, 0, len(changes))
+	for _, change := range changes {
+		if change.Type == protocol.FileChanged {
+			if sent, err := c.updateOverlay(change.URI); err != nil {
+				return err
+			} else if sent {
+				continue
+			}
+		}
+		watched = append(watched, change)
+	}
+
+	// Diagnostics for the batch come after it: waiting for quiescence
+	// now counts from here.
+	c.activityMu.Lock()
+	c.lastActivity = time.Now()
+	c.activityMu.Unlock()
+
+	if len(watched) == 0 {
+		return nil
+	}
+	return c.notify("workspace/didChangeWatchedFiles", protocol.DidChangeWatchedFilesParams{Changes: watched})
+}
+
+// updateOverlay sends the content of an open document with didChange. It
+// reports false when the document is not open.
+func (c *GoplsClient) updateOverlay(uri string) (bool, error) {
+	value, ok := c.openedDocs.Load(uri)
+	if !ok {
+		return false, nil
+	}
+	path, err := uriToPath(uri)
+	if err != nil {
+		return false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, nil
+	}
+	doc := value.(*openDocument)
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+	doc.version++
+	params := map[string]any{
+		"textDocument": map[string]any{
+			"uri":     uri,
+			"version": doc.version,
+		},
+		"contentChanges": []map[string]any{{"text": string(data)}},
+	}
+	return true, c.notify("textDocument/didChange", params)
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// newNotifyingClient returns a client whose notifications are delivered on
+// the returned channel.
+func newNotifyingClient(t *testing.T, debounce time.Duration) (*GoplsClient, <-chan *protocol.JSONRPCMessage) {
+	t.Helper()
+	reader, writer := io.Pipe()
+	t.Cleanup(func() { _ = writer.Close() })
+	messages := make(chan *protocol.JSONRPCMessage, 8)
+	go func() {
+		incoming := protocol.NewTransport(reader, nil)
+		for {
+			msg, err := incoming.ReceiveMessage(context.Background())
+			if err != nil {
+				return
+			}
+			messages <- msg
+		}
+	}()
+	client := &GoplsClient{
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		transport:      protocol.NewTransport(nil, writer),
+		changeDebounce: debounce,
+	}
+	return client, messages
+}
+
+func receiveNotification(t *testing.T, messages <-chan *protocol.JSONRPCMessage, method string, params any) {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		if msg.Method != method {
+			t.Fatalf("expected %s, got %s", method, msg.Method)
+		}
+		if err := json.Unmarshal(msg.Params, params); err != nil {
+			t.Fatalf("decode %s: %v", method, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no %s notification", method)
+	}
+}
+
+func TestFileChangesAreCoalescedUntilTheNextRequest(t *testing.T) {
+	client, messages := newNotifyingClient(t, time.Hour)
+	for _, change := range []protocol.FileEvent{
+		{URI: "file:///a.go", Type: protocol.FileCreated},
+		{URI: "file:///b.go", Type: protocol.FileChanged},
+		{URI: "file:///c.go", Type: protocol.FileCreated},
+		{URI: "file:///a.go", Type: protocol.FileChanged},
+		{URI: "file:///b.go", Type: protocol.FileDeleted},
+		{URI: "file:///c.go", Type: protocol.FileDeleted},
+		{URI: "file:///d.go", Type: protocol.FileDeleted},
+		{URI: "file:///d.go", Type: protocol.FileCreated},
+	} {
+		if err := client.NotifyDidChangeWatchedFiles(context.Background(), []protocol.FileEvent{change}); err != nil {
+			t.Fatalf("NotifyDidChangeWatchedFiles: %v", err)
+		}
+	}
+	select {
+	case msg := <-messages:
+		t.Fatalf("changes should wait for the debounce, got %s", msg.Method)
+	default:
+	}
+
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		return &protocol.JSONRPCMessage{Result: json.RawMessage("[]")}, nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.WorkspaceSymbols(context.Background(), "main")
+		done <- err
+	}()
+	var params protocol.DidChangeWatchedFilesParams
+	receiveNotification(t, messages, "workspace/didChangeWatchedFiles", &params)
+	want := []protocol.FileEvent{
+		{URI: "file:///a.go", Type: protocol.FileCreated},
+		{URI: "file:///b.go", Type: protocol.FileDeleted},
+		{URI: "file:///d.go", Type: protocol.FileChanged},
+	}
+	if !reflect.DeepEqual(params.Changes, want) {
+		t.Fatalf("unexpected coalesced changes %#v", params.Changes)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("WorkspaceSymbols: %v", err)
+	}
+}
+
+func TestFileChangesUpdateOpenDocuments(t *testing.T) {
+	client, messages := newNotifyingClient(t, 10*time.Millisecond)
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uri := "file://" + filepath.ToSlash(path)
+	client.openedDocs.Store(uri, &openDocument{version: 1})
+
+	changes := []protocol.FileEvent{
+		{URI: uri, Type: protocol.FileChanged},
+		{URI: "file:///other.go", Type: protocol.FileChanged},
+	}
+	if err := client.NotifyDidChangeWatchedFiles(context.Background(), changes); err != nil {
+		t.Fatalf("NotifyDidChangeWatchedFiles: %v", err)
+	}
+	var change struct {
+		TextDocument struct {
+			URI     string `json:"uri"`
+			Version int    `json:"version"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	receiveNotification(t, messages, "textDocument/didChange", &change)
+	if change.TextDocument.URI != uri || change.TextDocument.Version != 2 || len(change.ContentChanges) != 1 || change.ContentChanges[0].Text != "package main\n" {
+		t.Fatalf("unexpected didChange %#v", change)
+	}
+	var watched protocol.DidChangeWatchedFilesParams
+	receiveNotification(t, messages, "workspace/didChangeWatchedFiles", &watched)
+	if len(watched.Changes) != 1 || watched.Changes[0].URI != "file:///other.go" {
+		t.Fatalf("only the closed file should be reported as watched, got %#v", watched.Changes)
+	}
+}
+
+func TestRequestsWaitForAFlushInProgress(t *testing.T) {
+	reader, writer := io.Pipe()
+	t.Cleanup(func() { _ = writer.Close() })
+	client := &GoplsClient{
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		transport:      protocol.NewTransport(nil, writer),
+		changeDebounce: time.Hour,
+	}
+	if err := client.NotifyDidChangeWatchedFiles(context.Background(), []protocol.FileEvent{{URI: "file:///a.go", Type: protocol.FileChanged}}); err != nil {
+		t.Fatalf("NotifyDidChangeWatchedFiles: %v", err)
+	}
+	// The debounced flush takes the batch, then blocks sending it until the
+	// pipe is read.
+	go func() { _ = client.flushFileChanges() }()
+	for taken := false; !taken; {
+		client.changes.mu.Lock()
+		taken = client.changes.order == nil
+		client.changes.mu.Unlock()
+	}
+
+	requested := make(chan struct{})
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		close(requested)
+		return &protocol.JSONRPCMessage{Result: json.RawMessage("[]")}, nil
+	}
+	go func() { _, _ = client.WorkspaceSymbols(context.Background(), "main") }()
+	select {
+	case <-requested:
+		t.Fatal("the request was sent before the changes made just before it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	msg, err := protocol.NewTransport(reader, nil).ReceiveMessage(context.Background())
+	if err != nil || msg.Method != "workspace/didChangeWatchedFiles" {
+		t.Fatalf("expected the changes first, got %v %v", msg, err)
+	}
+	select {
+	case <-requested:
+	case <-time.After(time.Second):
+		t.Fatal("the request never followed the changes")
+	}
+}
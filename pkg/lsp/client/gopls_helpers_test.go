@@ -3,9 +3,12 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
@@ -42,6 +45,116 @@ func TestNavigationAndRefactorHelpers(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:         "document highlight",
+			expectMethod: "textDocument/documentHighlight",
+			call: func(c *GoplsClient) (any, error) {
+				return c.DocumentHighlights(context.Background(), uri, 7, 8)
+			},
+			response: []protocol.DocumentHighlight{{Kind: 3}, {Kind: 2}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.TextDocumentPositionParams)
+				if !ok || p.Position.Line != 7 || p.Position.Character != 8 {
+					t.Fatalf("unexpected params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				highlights := result.([]protocol.DocumentHighlight)
+				if len(highlights) != 2 || highlights[0].Kind != 3 {
+					t.Fatalf("unexpected highlights %#v", highlights)
+				}
+			},
+		},
+		{
+			name:         "selection range",
+			expectMethod: "textDocument/selectionRange",
+			call: func(c *GoplsClient) (any, error) {
+				return c.SelectionRanges(context.Background(), uri, []protocol.Position{{Line: 3, Character: 4}})
+			},
+			response: []protocol.SelectionRange{{Range: protocol.Range{End: protocol.Position{Character: 2}}, Parent: &protocol.SelectionRange{Range: protocol.Range{End: protocol.Position{Line: 1}}}}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.SelectionRangeParams)
+				if !ok || len(p.Positions) != 1 || p.Positions[0].Line != 3 {
+					t.Fatalf("unexpected params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				ranges := result.([]protocol.SelectionRange)
+				if len(ranges) != 1 || ranges[0].Parent == nil || ranges[0].Parent.Range.End.Line != 1 {
+					t.Fatalf("unexpected selection ranges %#v", ranges)
+				}
+			},
+		},
+		{
+			name:         "folding range",
+			expectMethod: "textDocument/foldingRange",
+			call: func(c *GoplsClient) (any, error) {
+				return c.FoldingRanges(context.Background(), uri)
+			},
+			response: []protocol.FoldingRange{{StartLine: 2, EndLine: 4, Kind: "imports"}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.FoldingRangeParams)
+				if !ok || p.TextDocument.URI != uri {
+					t.Fatalf("unexpected params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				ranges := result.([]protocol.FoldingRange)
+				if len(ranges) != 1 || ranges[0].EndLine != 4 || ranges[0].Kind != "imports" {
+					t.Fatalf("unexpected folding ranges %#v", ranges)
+				}
+			},
+		},
+		{
+			name:         "code lens",
+			expectMethod: "textDocument/codeLens",
+			call: func(c *GoplsClient) (any, error) {
+				return c.CodeLenses(context.Background(), uri)
+			},
+			response: []protocol.CodeLens{{Command: &protocol.Command{Title: "run test", Command: "gopls.run_tests"}}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.CodeLensParams)
+				if !ok || p.TextDocument.URI != uri {
+					t.Fatalf("unexpected params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				lenses := result.([]protocol.CodeLens)
+				if len(lenses) != 1 || lenses[0].Command.Command != "gopls.run_tests" {
+					t.Fatalf("unexpected lenses %#v", lenses)
+				}
+			},
+		},
+		{
+			name:         "type definition",
+			expectMethod: "textDocument/typeDefinition",
+			call: func(c *GoplsClient) (any, error) {
+				return c.GoToTypeDefinition(context.Background(), uri, 5, 6)
+			},
+			response: []protocol.Location{{URI: "file://tmp/types.go"}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.TextDocumentPositionParams)
+				if !ok || p.Position.Line != 5 || p.Position.Character != 6 {
+					t.Fatalf("unexpected params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				locs := result.([]protocol.Location)
+				if len(locs) != 1 || locs[0].URI != "file://tmp/types.go" {
+					t.Fatalf("unexpected locations %#v", locs)
+				}
+			},
+		},
 		{
 			name:         "references",
 			expectMethod: "textDocument/references",
@@ -64,6 +177,180 @@ func TestNavigationAndRefactorHelpers(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:         "implementation",
+			expectMethod: "textDocument/implementation",
+			call: func(c *GoplsClient) (any, error) {
+				return c.FindImplementations(context.Background(), uri, 5, 6)
+			},
+			response: []protocol.Location{{URI: uri}, {URI: uri}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.TextDocumentPositionParams)
+				if !ok || p.Position.Line != 5 || p.Position.Character != 6 {
+					t.Fatalf("unexpected implementation params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				locs := result.([]protocol.Location)
+				if len(locs) != 2 {
+					t.Fatalf("unexpected implementations %#v", locs)
+				}
+			},
+		},
+		{
+			name:         "prepare call hierarchy",
+			expectMethod: "textDocument/prepareCallHierarchy",
+			call: func(c *GoplsClient) (any, error) {
+				return c.PrepareCallHierarchy(context.Background(), uri, 7, 8)
+			},
+			response: []protocol.CallHierarchyItem{{Name: "Run", URI: uri, Data: map[string]any{"id": 1}}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.TextDocumentPositionParams)
+				if !ok || p.Position.Line != 7 {
+					t.Fatalf("unexpected call hierarchy params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				items := result.([]protocol.CallHierarchyItem)
+				if len(items) != 1 || items[0].Name != "Run" || items[0].Data == nil {
+					t.Fatalf("unexpected call hierarchy items %#v", items)
+				}
+			},
+		},
+		{
+			name:         "incoming calls",
+			expectMethod: "callHierarchy/incomingCalls",
+			call: func(c *GoplsClient) (any, error) {
+				return c.IncomingCalls(context.Background(), protocol.CallHierarchyItem{Name: "Run", URI: uri})
+			},
+			response: []protocol.CallHierarchyIncomingCall{{From: protocol.CallHierarchyItem{Name: "main"}, FromRanges: []protocol.Range{{}}}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.CallHierarchyCallsParams)
+				if !ok || p.Item.Name != "Run" {
+					t.Fatalf("unexpected incoming calls params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				calls := result.([]protocol.CallHierarchyIncomingCall)
+				if len(calls) != 1 || calls[0].From.Name != "main" {
+					t.Fatalf("unexpected incoming calls %#v", calls)
+				}
+			},
+		},
+		{
+			name:         "outgoing calls",
+			expectMethod: "callHierarchy/outgoingCalls",
+			call: func(c *GoplsClient) (any, error) {
+				return c.OutgoingCalls(context.Background(), protocol.CallHierarchyItem{Name: "Run", URI: uri})
+			},
+			response: []protocol.CallHierarchyOutgoingCall{{To: protocol.CallHierarchyItem{Name: "helper"}}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				if _, ok := params.(protocol.CallHierarchyCallsParams); !ok {
+					t.Fatalf("unexpected outgoing calls params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				calls := result.([]protocol.CallHierarchyOutgoingCall)
+				if len(calls) != 1 || calls[0].To.Name != "helper" {
+					t.Fatalf("unexpected outgoing calls %#v", calls)
+				}
+			},
+		},
+		{
+			name:         "prepare type hierarchy",
+			expectMethod: "textDocument/prepareTypeHierarchy",
+			call: func(c *GoplsClient) (any, error) {
+				return c.PrepareTypeHierarchy(context.Background(), uri, 3, 5)
+			},
+			response: []protocol.TypeHierarchyItem{{Name: "Store", Kind: 11, URI: uri, Data: map[string]any{"id": 2}}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.TextDocumentPositionParams)
+				if !ok || p.Position.Line != 3 || p.Position.Character != 5 {
+					t.Fatalf("unexpected type hierarchy params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				items := result.([]protocol.TypeHierarchyItem)
+				if len(items) != 1 || items[0].Name != "Store" || items[0].Data == nil {
+					t.Fatalf("unexpected type hierarchy items %#v", items)
+				}
+			},
+		},
+		{
+			name:         "supertypes",
+			expectMethod: "typeHierarchy/supertypes",
+			call: func(c *GoplsClient) (any, error) {
+				return c.Supertypes(context.Background(), protocol.TypeHierarchyItem{Name: "memStore", URI: uri})
+			},
+			response: []protocol.TypeHierarchyItem{{Name: "Store"}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.TypeHierarchyTypesParams)
+				if !ok || p.Item.Name != "memStore" {
+					t.Fatalf("unexpected supertypes params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				items := result.([]protocol.TypeHierarchyItem)
+				if len(items) != 1 || items[0].Name != "Store" {
+					t.Fatalf("unexpected supertypes %#v", items)
+				}
+			},
+		},
+		{
+			name:         "subtypes",
+			expectMethod: "typeHierarchy/subtypes",
+			call: func(c *GoplsClient) (any, error) {
+				return c.Subtypes(context.Background(), protocol.TypeHierarchyItem{Name: "Store", URI: uri})
+			},
+			response: []protocol.TypeHierarchyItem{{Name: "memStore"}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				if _, ok := params.(protocol.TypeHierarchyTypesParams); !ok {
+					t.Fatalf("unexpected subtypes params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				items := result.([]protocol.TypeHierarchyItem)
+				if len(items) != 1 || items[0].Name != "memStore" {
+					t.Fatalf("unexpected subtypes %#v", items)
+				}
+			},
+		},
+		{
+			name:         "resolve code action",
+			expectMethod: "codeAction/resolve",
+			call: func(c *GoplsClient) (any, error) {
+				return c.ResolveCodeAction(context.Background(), protocol.CodeAction{Title: "Extract function", Data: map[string]any{"id": 3}})
+			},
+			response: protocol.CodeAction{Title: "Extract function", Edit: &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{uri: {{NewText: "x"}}}}},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				p, ok := params.(protocol.CodeAction)
+				if !ok || p.Data == nil {
+					t.Fatalf("the action should be sent back with its data, got %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				action := result.(*protocol.CodeAction)
+				if action.Edit == nil || len(action.Edit.Changes[uri]) != 1 {
+					t.Fatalf("unexpected resolved action %#v", action)
+				}
+			},
+		},
 		{
 			name:         "hover",
 			expectMethod: "textDocument/hover",
@@ -109,6 +396,32 @@ func TestNavigationAndRefactorHelpers(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:         "completion list",
+			expectMethod: "textDocument/completion",
+			call: func(c *GoplsClient) (any, error) {
+				return c.GetCompletionList(context.Background(), uri, 1, 1)
+			},
+			response: map[string]any{
+				"isIncomplete": true,
+				"items": []any{
+					map[string]any{"label": "Foo", "kind": 2, "documentation": map[string]any{"kind": "markdown", "value": "Foo does it."}},
+				},
+			},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				if _, ok := params.(protocol.TextDocumentPositionParams); !ok {
+					t.Fatalf("unexpected completion params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				list := result.(*protocol.CompletionList)
+				if !list.IsIncomplete || len(list.Items) != 1 || list.Items[0].Label != "Foo" || list.Items[0].DocumentationText() != "Foo does it." {
+					t.Fatalf("unexpected completion %#v", list)
+				}
+			},
+		},
 		{
 			name:         "formatting",
 			expectMethod: "textDocument/formatting",
@@ -193,6 +506,30 @@ func TestNavigationAndRefactorHelpers(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:         "inlay hints",
+			expectMethod: "textDocument/inlayHint",
+			call: func(c *GoplsClient) (any, error) {
+				return c.InlayHints(context.Background(), uri, protocol.Range{End: protocol.Position{Line: 10}})
+			},
+			response: []any{
+				map[string]any{"position": map[string]any{"line": 1, "character": 4}, "label": "n:", "kind": 2},
+				map[string]any{"position": map[string]any{"line": 2, "character": 7}, "label": []any{map[string]any{"value": "[int"}, map[string]any{"value": "]"}}, "kind": 1},
+			},
+			checkParams: func(t *testing.T, params any) {
+				t.Helper()
+				if p, ok := params.(protocol.InlayHintParams); !ok || p.Range.End.Line != 10 {
+					t.Fatalf("unexpected inlay hint params %#v", params)
+				}
+			},
+			verify: func(t *testing.T, result any) {
+				t.Helper()
+				hints := result.([]protocol.InlayHint)
+				if len(hints) != 2 || hints[0].LabelText() != "n:" || hints[1].LabelText() != "[int]" {
+					t.Fatalf("unexpected hints %#v", hints)
+				}
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -200,7 +537,7 @@ func TestNavigationAndRefactorHelpers(t *testing.T) {
 			client := &GoplsClient{
 				logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 			}
-			client.openedDocs.Store(uri, struct{}{})
+			client.openedDocs.Store(uri, &openDocument{version: 1})
 			client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
 				if method != tc.expectMethod {
 					t.Fatalf("expected method %s, got %s", tc.expectMethod, method)
@@ -221,3 +558,157 @@ func TestNavigationAndRefactorHelpers(t *testing.T) {
 		})
 	}
 }
+
+func TestGoToDeclarationFallsBackToDefinition(t *testing.T) {
+	client := &GoplsClient{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	var methods []string
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		methods = append(methods, method)
+		if method == "textDocument/declaration" {
+			return &protocol.JSONRPCMessage{Error: &protocol.JSONRPCError{Code: protocol.CodeMethodNotFound, Message: "not implemented"}}, nil
+		}
+		data, _ := json.Marshal([]protocol.Location{{URI: "file:///def.go"}})
+		return &protocol.JSONRPCMessage{Result: data}, nil
+	}
+	locations, err := client.GoToDeclaration(context.Background(), "file:///main.go", 1, 2)
+	if err != nil {
+		t.Fatalf("GoToDeclaration: %v", err)
+	}
+	if len(locations) != 1 || locations[0].URI != "file:///def.go" || len(methods) != 2 || methods[1] != "textDocument/definition" {
+		t.Fatalf("expected the definition after %v, got %#v", methods, locations)
+	}
+
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		return nil, errors.New("boom")
+	}
+	if _, err := client.GoToDeclaration(context.Background(), "file:///main.go", 1, 2); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected other errors to be returned, got %v", err)
+	}
+}
+
+func TestSemanticTokensUseTheAdvertisedLegend(t *testing.T) {
+	client := &GoplsClient{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if _, err := client.SemanticTokens(context.Background(), "file:///main.go", protocol.Range{}); err == nil {
+		t.Fatal("expected an error when gopls provides no semantic tokens")
+	}
+
+	client.recordCapabilities(&protocol.JSONRPCMessage{Result: json.RawMessage(`{"capabilities":{"semanticTokensProvider":{"legend":{"tokenTypes":["namespace","type","function"],"tokenModifiers":["declaration"]},"range":true}}}`)})
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		if p, ok := params.(protocol.SemanticTokensRangeParams); method != "textDocument/semanticTokens/range" || !ok || p.Range.End.Line != 3 {
+			t.Fatalf("unexpected request %s %#v", method, params)
+		}
+		return &protocol.JSONRPCMessage{Result: json.RawMessage(`{"data":[1,5,4,2,1]}`)}, nil
+	}
+	tokens, err := client.SemanticTokens(context.Background(), "file:///main.go", protocol.Range{End: protocol.Position{Line: 3}})
+	if err != nil {
+		t.Fatalf("SemanticTokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].Type != "function" || tokens[0].Position.Line != 1 || len(tokens[0].Modifiers) != 1 {
+		t.Fatalf("unexpected tokens %#v", tokens)
+	}
+}
+
+func TestExecuteCommandCollectsAppliedEdits(t *testing.T) {
+	client := &GoplsClient{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	applyEdit := func(text string) protocol.ApplyWorkspaceEditResult {
+		t.Helper()
+		params, _ := json.Marshal(protocol.ApplyWorkspaceEditParams{Edit: protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{"file:///a.go": {{NewText: text}}}}})
+		result, rpcErr := client.serverRequestResult(&protocol.JSONRPCMessage{ID: 1, Method: "workspace/applyEdit", Params: params})
+		if rpcErr != nil {
+			t.Fatalf("applyEdit: %v", rpcErr)
+		}
+		return result.(protocol.ApplyWorkspaceEditResult)
+	}
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		if p, ok := params.(protocol.ExecuteCommandParams); method != "workspace/executeCommand" || !ok || p.Command != "gopls.apply_fix" {
+			t.Fatalf("unexpected call %s %#v", method, params)
+		}
+		// gopls sends the edit back before answering the command.
+		if result := applyEdit("fixed"); !result.Applied {
+			t.Fatalf("edits sent during a command should be accepted, got %#v", result)
+		}
+		return &protocol.JSONRPCMessage{Result: json.RawMessage("null")}, nil
+	}
+
+	edits, err := client.ExecuteCommand(context.Background(), protocol.Command{Command: "gopls.apply_fix"})
+	if err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if len(edits) != 1 || edits[0].Changes["file:///a.go"][0].NewText != "fixed" {
+		t.Fatalf("unexpected edits %#v", edits)
+	}
+	if result := applyEdit("late"); result.Applied {
+		t.Fatal("edits outside a command must be refused")
+	}
+}
+
+func TestRunCommandReturnsTheResult(t *testing.T) {
+	client := &GoplsClient{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	client.recordCapabilities(&protocol.JSONRPCMessage{Result: json.RawMessage(`{"capabilities":{"executeCommandProvider":{"commands":["gopls.tidy","gopls.list_known_packages"]}}}`)})
+	if commands := client.Commands(); !slices.Equal(commands, []string{"gopls.list_known_packages", "gopls.tidy"}) {
+		t.Fatalf("unexpected advertised commands %v", commands)
+	}
+
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		return &protocol.JSONRPCMessage{Result: json.RawMessage(`{"Packages":["fmt"]}`)}, nil
+	}
+	result, edits, err := client.RunCommand(context.Background(), protocol.Command{Command: "gopls.list_known_packages"})
+	if err != nil {
+		t.Fatalf("RunCommand: %v", err)
+	}
+	if string(result) != `{"Packages":["fmt"]}` || len(edits) != 0 {
+		t.Fatalf("unexpected result %s %#v", result, edits)
+	}
+}
+
+func TestServerRequestResult(t *testing.T) {
+	client := &GoplsClient{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	result, rpcErr := client.serverRequestResult(&protocol.JSONRPCMessage{ID: 1, Method: "workspace/configuration", Params: json.RawMessage(`{"items":[{"section":"gopls"},{}]}`)})
+	if rpcErr != nil || len(result.([]any)) != 2 {
+		t.Fatalf("configuration should return one entry per item, got %#v %v", result, rpcErr)
+	}
+	client.settings = map[string]any{"gofumpt": true, "hints": map[string]any{"parameterNames": false}}
+	result, _ = client.serverRequestResult(&protocol.JSONRPCMessage{ID: 1, Method: "workspace/configuration", Params: json.RawMessage(`{"items":[{"section":"gopls"},{}]}`)})
+	if items := result.([]any); items[0].(map[string]any)["gofumpt"] != true || items[1] != nil {
+		t.Fatalf("configured settings should answer the gopls section only, got %#v", items)
+	}
+	if hints := client.goplsSettings()["hints"].(map[string]any); hints["parameterNames"] != false || len(hints) != 1 {
+		t.Fatalf("configured hints should replace the defaults, got %#v", hints)
+	}
+	if options := client.initializationOptions(); options["gofumpt"] != true || options["verboseWorkDoneProgress"] != true {
+		t.Fatalf("unexpected initialization options %#v", options)
+	}
+	if _, rpcErr := client.serverRequestResult(&protocol.JSONRPCMessage{ID: 2, Method: "window/workDoneProgress/create"}); rpcErr != nil {
+		t.Fatalf("progress creation should be accepted, got %v", rpcErr)
+	}
+	if _, rpcErr := client.serverRequestResult(&protocol.JSONRPCMessage{ID: 3, Method: "window/showDocument"}); rpcErr == nil || rpcErr.Code != -32601 {
+		t.Fatalf("unknown requests should fail with method not found, got %v", rpcErr)
+	}
+}
+
+func TestWaitForQuiescence(t *testing.T) {
+	client := &GoplsClient{
+		logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		progressTokens:   make(map[string]string),
+		diagnosticsCache: make(map[string][]protocol.Diagnostic),
+	}
+	progress := func(kind string) {
+		client.handleNotification(&protocol.JSONRPCMessage{Method: "$/progress", Params: json.RawMessage(`{"token":"diag","value":{"kind":"` + kind + `","title":"diagnosing"}}`)})
+	}
+
+	progress("begin")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := client.WaitForQuiescence(ctx, 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected to time out while work is in progress, got %v", err)
+	}
+
+	progress("end")
+	client.updateDiagnostics(protocol.PublishDiagnosticsParams{URI: "file:///a.go", Diagnostics: []protocol.Diagnostic{{Message: "boom"}}})
+	if err := client.WaitForQuiescence(context.Background(), 10*time.Millisecond); err != nil {
+		t.Fatalf("WaitForQuiescence: %v", err)
+	}
+	if got := client.WorkspaceDiagnostics(); len(got["file:///a.go"]) != 1 {
+		t.Fatalf("unexpected diagnostics %#v", got)
+	}
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// idempotentMethods are the requests that only read gopls state, so that
+// sending them again after a transient failure is harmless. Commands and
+// lifecycle requests are never retried.
+var idempotentMethods = map[string]bool{
+	"textDocument/hover":                true,
+	"textDocument/completion":           true,
+	"textDocument/definition":           true,
+	"textDocument/typeDefinition":       true,
+	"textDocument/declaration":          true,
+	"textDocument/references":           true,
+	"textDocument/implementation":       true,
+	"textDocument/documentSymbol":       true,
+	"textDocument/documentHighlight":    true,
+	"textDocument/inlayHint":            true,
+	"textDocument/codeLens":             true,
+	"textDocument/codeAction":           true,
+	"textDocument/formatting":           true,
+	"textDocument/rename":               true,
+	"textDocument/prepareCallHierarchy": true,
+	"callHierarchy/incomingCalls":       true,
+	"callHierarchy/outgoingCalls":       true,
+	"textDocument/prepareTypeHierarchy": true,
+	"typeHierarchy/supertypes":          true,
+	"typeHierarchy/subtypes":            true,
+	"codeAction/resolve":                true,
+	"workspace/symbol":                  true,
+}
+
+// transientMessages are parts of the errors gopls returns while it is
+// still loading the workspace or reloading it after a change.
+var transientMessages = []string{
+	"no package metadata",
+	"no views",
+	"content modified",
+}
+
+// retryPolicy retries a request up to attempts times in all, waiting
+// initial before the second attempt and doubling the wait up to max.
+type retryPolicy struct {
+	attempts int
+	initial  time.Duration
+	max      time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{attempts: 4, initial: 100 * time.Millisecond, max: time.Second}
+
+// RetryError is returned when a request still failed with a transient error
+// after the last attempt. Err is the error of that attempt.
+type RetryError struct {
+	Method   string
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s failed after %d attempts: %v", e.Method, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// isTransient reports whether err is a failure gopls may not repeat once
+// it has caught up with the workspace.
+func isTransient(err error) bool {
+	var rpcErr *protocol.JSONRPCError
+	if errors.As(err, &rpcErr) && (rpcErr.Code == protocol.CodeContentModified || rpcErr.Code == protocol.CodeServerCancelled) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, transient := range transientMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// do runs send until it returns something other than a transient error,
+// the attempts run out or ctx is done.
+func (p retryPolicy) do(ctx context.Context, logger *slog.Logger, method string, send func() (*protocol.JSONRPCMessage, error)) (*protocol.JSONRPCMessage, error) {
+	wait := p.initial
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+		cause := err
+		if cause == nil && resp != nil && resp.Error != nil {
+			cause = resp.Error
+		}
+		if cause == nil || !isTransient(cause) {
+			return resp, err
+		}
+		if attempt == p.attempts {
+			return nil, &RetryError{Method: method, Attempts: attempt, Err: cause}
+		}
+		logger.Debug("retrying request after transient error", "method", method, "attempt", attempt, "error", cause)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &RetryError{Method: method, Attempts: attempt, Err: cause}
+		case <-timer.C:
+		}
+		wait = min(2*wait, p.max)
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestInvokeRetriesTransientErrors(t *testing.T) {
+	client := &GoplsClient{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		retry:  retryPolicy{attempts: 3, initial: time.Millisecond, max: 2 * time.Millisecond},
+	}
+	symbols, _ := json.Marshal([]protocol.SymbolInformation{{Name: "main"}})
+	failures := []error{
+		errors.New("no package metadata for file file:///main.go"),
+		&protocol.JSONRPCError{Code: protocol.CodeContentModified, Message: "modified"},
+	}
+	calls := 0
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		calls++
+		if calls <= len(failures) {
+			if rpcErr, ok := failures[calls-1].(*protocol.JSONRPCError); ok {
+				return &protocol.JSONRPCMessage{Error: rpcErr}, nil
+			}
+			return nil, failures[calls-1]
+		}
+		return &protocol.JSONRPCMessage{Result: symbols}, nil
+	}
+	found, err := client.WorkspaceSymbols(context.Background(), "main")
+	if err != nil || len(found) != 1 || calls != 3 {
+		t.Fatalf("expected the symbols after two retries, got %#v %v after %d calls", found, err, calls)
+	}
+
+	calls = 0
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		calls++
+		return &protocol.JSONRPCMessage{Error: &protocol.JSONRPCError{Code: protocol.CodeServerCancelled, Message: "cancelled"}}, nil
+	}
+	_, err = client.WorkspaceSymbols(context.Background(), "main")
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 || retryErr.Method != "workspace/symbol" || calls != 3 {
+		t.Fatalf("expected a RetryError after 3 attempts, got %v after %d calls", err, calls)
+	}
+	var rpcErr *protocol.JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != protocol.CodeServerCancelled {
+		t.Fatalf("the RetryError should wrap the last error, got %v", err)
+	}
+}
+
+func TestInvokeDoesNotRetry(t *testing.T) {
+	client := &GoplsClient{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		retry:  retryPolicy{attempts: 3, initial: time.Millisecond, max: time.Millisecond},
+	}
+	calls := 0
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		calls++
+		return nil, errors.New("no package metadata for file file:///main.go")
+	}
+	if _, err := client.ExecuteCommand(context.Background(), protocol.Command{Command: "gopls.tidy"}); err == nil || calls != 1 {
+		t.Fatalf("commands must not be retried, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		calls++
+		return nil, errors.New("boom")
+	}
+	if _, err := client.WorkspaceSymbols(context.Background(), "main"); err == nil || err.Error() != "boom" || calls != 1 {
+		t.Fatalf("other errors must be returned as is, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	client.retry = retryPolicy{attempts: 5, initial: time.Hour, max: time.Hour}
+	client.callOverride = func(ctx context.Context, method string, params any) (*protocol.JSONRPCMessage, error) {
+		calls++
+		return nil, errors.New("no views")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var retryErr *RetryError
+	if _, err := client.WorkspaceSymbols(ctx, "main"); !errors.As(err, &retryErr) || retryErr.Attempts != 1 || calls != 1 {
+		t.Fatalf("the backoff should stop when the context is done, got %v after %d calls", err, calls)
+	}
+}
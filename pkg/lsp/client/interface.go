@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
@@ -18,23 +20,59 @@ type LSPClient interface {
 
 	// Méthodes de navigation de code
 	GoToDefinition(ctx context.Context, uri string, line, character int) ([]protocol.Location, error)
+	GoToTypeDefinition(ctx context.Context, uri string, line, character int) ([]protocol.Location, error)
+	GoToDeclaration(ctx context.Context, uri string, line, character int) ([]protocol.Location, error)
 	FindReferences(ctx context.Context, uri string, line, character int, includeDeclaration bool) ([]protocol.Location, error)
+	FindImplementations(ctx context.Context, uri string, line, character int) ([]protocol.Location, error)
+	PrepareCallHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.CallHierarchyItem, error)
+	IncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error)
+	OutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error)
+	PrepareTypeHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.TypeHierarchyItem, error)
+	Supertypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error)
+	Subtypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error)
 
 	// Méthodes de diagnostic
 	GetDiagnostics(ctx context.Context, uri string) ([]protocol.Diagnostic, error)
+	// WorkspaceDiagnostics returns the latest diagnostics gopls published,
+	// by document URI.
+	WorkspaceDiagnostics() map[string][]protocol.Diagnostic
+	// WaitForQuiescence blocks until gopls has no work in progress and has
+	// published nothing for the quiet period.
+	WaitForQuiescence(ctx context.Context, quiet time.Duration) error
 
 	// Méthodes de document
 	DidOpen(ctx context.Context, uri, languageID, text string) error
+	// OpenDocument opens the document unless it is already open and
+	// reports whether it opened it, so that callers close only the
+	// documents they opened.
+	OpenDocument(ctx context.Context, uri, languageID, text string) (bool, error)
 	DidClose(ctx context.Context, uri string) error
 
 	// Support avancé
 	GetHover(ctx context.Context, uri string, line, character int) (string, error)
 	GetCompletion(ctx context.Context, uri string, line, character int) ([]string, error)
+	GetCompletionList(ctx context.Context, uri string, line, character int) (*protocol.CompletionList, error)
 
 	DocumentFormatting(ctx context.Context, uri string) ([]protocol.TextEdit, error)
 	Rename(ctx context.Context, uri string, line, character int, newName string) (*protocol.WorkspaceEdit, error)
-	CodeActions(ctx context.Context, uri string, rng protocol.Range) ([]protocol.CodeAction, error)
+	// CodeActions lists the code actions for a range, restricted to the
+	// given kinds, such as source.organizeImports, when any are passed.
+	CodeActions(ctx context.Context, uri string, rng protocol.Range, only ...string) ([]protocol.CodeAction, error)
+	ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (*protocol.CodeAction, error)
+	ExecuteCommand(ctx context.Context, command protocol.Command) ([]protocol.WorkspaceEdit, error)
+	// RunCommand executes a command and also returns its result, which is
+	// null for most commands.
+	RunCommand(ctx context.Context, command protocol.Command) (json.RawMessage, []protocol.WorkspaceEdit, error)
+	// Commands lists the commands gopls advertised at initialization.
+	Commands() []string
 	WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error)
+	DocumentSymbols(ctx context.Context, uri string) ([]protocol.DocumentSymbol, error)
+	DocumentHighlights(ctx context.Context, uri string, line, character int) ([]protocol.DocumentHighlight, error)
+	InlayHints(ctx context.Context, uri string, rng protocol.Range) ([]protocol.InlayHint, error)
+	CodeLenses(ctx context.Context, uri string) ([]protocol.CodeLens, error)
+	FoldingRanges(ctx context.Context, uri string) ([]protocol.FoldingRange, error)
+	SelectionRanges(ctx context.Context, uri string, positions []protocol.Position) ([]protocol.SelectionRange, error)
+	SemanticTokens(ctx context.Context, uri string, rng protocol.Range) ([]protocol.SemanticToken, error)
 
 	// Observability
 	OnDiagnostics(handler DiagnosticsHandler) func()
@@ -14,6 +14,18 @@ type JSONRPCMessage struct {
 	Error   *JSONRPCError   `json:"error,omitempty"`
 }
 
+// CodeMethodNotFound is the JSON-RPC error code for a method the peer does
+// not implement.
+const CodeMethodNotFound = -32601
+
+// LSP error codes for a request the server abandoned: because the document
+// changed while it ran (CodeContentModified) or for another reason it may
+// not have on a retry (CodeServerCancelled).
+const (
+	CodeContentModified = -32801
+	CodeServerCancelled = -32802
+)
+
 type JSONRPCError struct {
 	Code    int             `json:"code"`
 	Message string          `json:"message"`
@@ -91,6 +103,20 @@ func NewNotification(method string, params any) (*JSONRPCMessage, error) {
 	}, nil
 }
 
+// NewResponse builds the reply to a request received from the server. A
+// non-nil rpcErr is sent instead of result.
+func NewResponse(id any, result any, rpcErr *JSONRPCError) (*JSONRPCMessage, error) {
+	msg := &JSONRPCMessage{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+		msg.Result = data
+	}
+	return msg, nil
+}
+
 func (msg *JSONRPCMessage) ParseResult(target any) error {
 	if msg.Error != nil {
 		return msg.Error
@@ -93,3 +93,25 @@ func TestProgressNotificationHelpers(t *testing.T) {
 		t.Fatal("expected nil message error")
 	}
 }
+
+func TestDecodeSemanticTokens(t *testing.T) {
+	legend := SemanticTokensLegend{TokenTypes: []string{"type", "function", "variable"}, TokenModifiers: []string{"declaration", "readonly"}}
+	tokens := DecodeSemanticTokens([]int{
+		2, 5, 4, 0, 1,
+		0, 7, 3, 2, 3,
+		1, 1, 5, 1, 0,
+		0, 2, 1, 9, 0,
+	}, legend)
+	if len(tokens) != 4 {
+		t.Fatalf("expected 4 tokens, got %#v", tokens)
+	}
+	if got := tokens[1]; got.Position != (Position{Line: 2, Character: 12}) || got.Type != "variable" || len(got.Modifiers) != 2 || got.Modifiers[1] != "readonly" {
+		t.Fatalf("unexpected second token %#v", got)
+	}
+	if got := tokens[2]; got.Position != (Position{Line: 3, Character: 1}) || got.Length != 5 || got.Type != "function" || got.Modifiers != nil {
+		t.Fatalf("the start should restart on a new line, got %#v", got)
+	}
+	if got := tokens[3]; got.Position.Character != 3 || got.Type != "" {
+		t.Fatalf("an unknown type should be left empty, got %#v", got)
+	}
+}
@@ -1,5 +1,10 @@
 package protocol
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 // Position représente une position dans un document texte
 type Position struct {
 	Line      int `json:"line"`
@@ -85,9 +90,13 @@ type DocumentFormattingParams struct {
 }
 
 // TextDocumentEdit représente un ensemble de modifications pour un document spécifique.
+// Les opérations sur les fichiers partagent la liste documentChanges : elles
+// n'ont pas de textDocument mais un Kind (create, rename ou delete) et une URI.
 type TextDocumentEdit struct {
 	TextDocument OptionalVersionedTextDocumentIdentifier `json:"textDocument"`
 	Edits        []TextEdit                              `json:"edits"`
+	Kind         string                                  `json:"kind,omitempty"`
+	URI          string                                  `json:"uri,omitempty"`
 }
 
 // OptionalVersionedTextDocumentIdentifier identifie un document texte avec une version optionnelle.
@@ -123,10 +132,46 @@ type CodeActionParams struct {
 
 // CodeAction représente une action de code disponible.
 type CodeAction struct {
-	Title       string         `json:"title"`
-	Kind        string         `json:"kind,omitempty"`
-	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
-	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+	Title       string              `json:"title"`
+	Kind        string              `json:"kind,omitempty"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"`
+	IsPreferred bool                `json:"isPreferred,omitempty"`
+	Disabled    *CodeActionDisabled `json:"disabled,omitempty"`
+	Edit        *WorkspaceEdit      `json:"edit,omitempty"`
+	Command     *Command            `json:"command,omitempty"`
+	Data        any                 `json:"data,omitempty"`
+}
+
+// CodeActionDisabled explains why an action cannot be applied.
+type CodeActionDisabled struct {
+	Reason string `json:"reason"`
+}
+
+// Command is a server command run through workspace/executeCommand. Code
+// actions without an edit carry one instead.
+type Command struct {
+	Title     string `json:"title"`
+	Command   string `json:"command"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+// ExecuteCommandParams are the parameters of workspace/executeCommand.
+type ExecuteCommandParams struct {
+	Command   string `json:"command"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+// ApplyWorkspaceEditParams are the parameters of the workspace/applyEdit
+// request the server sends to the client.
+type ApplyWorkspaceEditParams struct {
+	Label string        `json:"label,omitempty"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
+
+// ApplyWorkspaceEditResult is the client's reply to workspace/applyEdit.
+type ApplyWorkspaceEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason,omitempty"`
 }
 
 // WorkspaceSymbolParams paramètres pour workspace/symbol.
@@ -139,6 +184,264 @@ type SymbolInformation struct {
 	Name     string   `json:"name"`
 	Kind     int      `json:"kind"`
 	Location Location `json:"location"`
+	// ContainerName is the symbol's package path for gopls.
+	ContainerName string `json:"containerName,omitempty"`
+}
+
+// ProgressParams is a $/progress notification. Token is a string or a
+// number; Value.Kind is begin, report or end.
+type ProgressParams struct {
+	Token json.RawMessage `json:"token"`
+	Value struct {
+		Kind    string `json:"kind"`
+		Title   string `json:"title,omitempty"`
+		Message string `json:"message,omitempty"`
+	} `json:"value"`
+}
+
+// DocumentSymbolParams are the parameters of textDocument/documentSymbol.
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// DocumentSymbol is a node of a document's symbol outline. Range covers the
+// whole declaration and SelectionRange its name.
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// DocumentHighlight is an occurrence of the symbol at a position within its
+// document. Kind is 1 for text, 2 for a read and 3 for a write.
+type DocumentHighlight struct {
+	Range Range `json:"range"`
+	Kind  int   `json:"kind,omitempty"`
+}
+
+// SemanticTokensLegend names the token types and modifiers the server
+// encodes semantic tokens with, as it advertised at initialization.
+type SemanticTokensLegend struct {
+	TokenTypes     []string `json:"tokenTypes"`
+	TokenModifiers []string `json:"tokenModifiers"`
+}
+
+// SemanticTokensRangeParams are the parameters of
+// textDocument/semanticTokens/range.
+type SemanticTokensRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+// SemanticTokens is the result of a semantic tokens request: five integers
+// per token, relative to the previous token.
+type SemanticTokens struct {
+	Data []int `json:"data"`
+}
+
+// SemanticToken is a decoded semantic token.
+type SemanticToken struct {
+	Position  Position `json:"position"`
+	Length    int      `json:"length"`
+	Type      string   `json:"type"`
+	Modifiers []string `json:"modifiers,omitempty"`
+}
+
+// DecodeSemanticTokens turns the relative encoding of tokens into absolute
+// positions with the type and modifier names of the legend. Each token is
+// its line delta, its start (relative to the previous token when on the
+// same line), its length, its type index and its modifier bit set.
+func DecodeSemanticTokens(data []int, legend SemanticTokensLegend) []SemanticToken {
+	tokens := make([]SemanticToken, 0, len(data)/5)
+	line, character := 0, 0
+	for i := 0; i+5 <= len(data); i += 5 {
+		if data[i] != 0 {
+			line += data[i]
+			character = 0
+		}
+		character += data[i+1]
+		token := SemanticToken{Position: Position{Line: line, Character: character}, Length: data[i+2]}
+		if t := data[i+3]; t >= 0 && t < len(legend.TokenTypes) {
+			token.Type = legend.TokenTypes[t]
+		}
+		for bit, modifier := range legend.TokenModifiers {
+			if data[i+4]&(1<<bit) != 0 {
+				token.Modifiers = append(token.Modifiers, modifier)
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// SelectionRangeParams are the parameters of textDocument/selectionRange.
+type SelectionRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Positions    []Position             `json:"positions"`
+}
+
+// SelectionRange is a range containing a position, with the larger range
+// enclosing it as its parent.
+type SelectionRange struct {
+	Range  Range           `json:"range"`
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
+
+// FoldingRangeParams are the parameters of textDocument/foldingRange.
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// FoldingRange is a block of a document an editor can fold. With line
+// folding only, StartLine stays visible and the lines after it up to
+// EndLine are hidden. Kind is comment, imports, region or empty.
+type FoldingRange struct {
+	StartLine      int    `json:"startLine"`
+	StartCharacter int    `json:"startCharacter,omitempty"`
+	EndLine        int    `json:"endLine"`
+	EndCharacter   int    `json:"endCharacter,omitempty"`
+	Kind           string `json:"kind,omitempty"`
+}
+
+// CodeLensParams are the parameters of textDocument/codeLens.
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// CodeLens is a command shown above a range of a document, such as running
+// the test declared there.
+type CodeLens struct {
+	Range   Range    `json:"range"`
+	Command *Command `json:"command,omitempty"`
+	Data    any      `json:"data,omitempty"`
+}
+
+// InlayHintParams are the parameters of textDocument/inlayHint.
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+// InlayHint is an annotation shown inline at Position. Kind is 1 for types
+// and 2 for parameter names; Label is a string or a list of label parts,
+// LabelText returns its text.
+type InlayHint struct {
+	Position     Position        `json:"position"`
+	Label        json.RawMessage `json:"label"`
+	Kind         int             `json:"kind,omitempty"`
+	PaddingLeft  bool            `json:"paddingLeft,omitempty"`
+	PaddingRight bool            `json:"paddingRight,omitempty"`
+}
+
+// LabelText returns the text of the hint's label.
+func (hint InlayHint) LabelText() string {
+	var text string
+	if json.Unmarshal(hint.Label, &text) == nil {
+		return text
+	}
+	var parts []struct {
+		Value string `json:"value"`
+	}
+	_ = json.Unmarshal(hint.Label, &parts)
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(part.Value)
+	}
+	return b.String()
+}
+
+// CompletionList is the result of textDocument/completion. IsIncomplete
+// means typing more would yield other items.
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// CompletionItem is one completion suggestion. Documentation is a string
+// or a MarkupContent; DocumentationText returns its text.
+type CompletionItem struct {
+	Label         string          `json:"label"`
+	Kind          int             `json:"kind,omitempty"`
+	Detail        string          `json:"detail,omitempty"`
+	Documentation json.RawMessage `json:"documentation,omitempty"`
+	SortText      string          `json:"sortText,omitempty"`
+	FilterText    string          `json:"filterText,omitempty"`
+	InsertText    string          `json:"insertText,omitempty"`
+	TextEdit      *TextEdit       `json:"textEdit,omitempty"`
+}
+
+// DocumentationText returns the text of the item's documentation.
+func (item CompletionItem) DocumentationText() string {
+	var text string
+	if json.Unmarshal(item.Documentation, &text) == nil {
+		return text
+	}
+	var markup struct {
+		Value string `json:"value"`
+	}
+	_ = json.Unmarshal(item.Documentation, &markup)
+	return markup.Value
+}
+
+// CallHierarchyItem is a function or method in a call hierarchy. Data is
+// opaque server state that must be sent back unchanged.
+type CallHierarchyItem struct {
+	Name           string `json:"name"`
+	Kind           int    `json:"kind"`
+	Detail         string `json:"detail,omitempty"`
+	URI            string `json:"uri"`
+	Range          Range  `json:"range"`
+	SelectionRange Range  `json:"selectionRange"`
+	Data           any    `json:"data,omitempty"`
+}
+
+// CallHierarchyPrepareParams are the parameters of
+// textDocument/prepareCallHierarchy.
+type CallHierarchyPrepareParams = TextDocumentPositionParams
+
+// CallHierarchyCallsParams are the parameters of callHierarchy/incomingCalls
+// and callHierarchy/outgoingCalls.
+type CallHierarchyCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+}
+
+// CallHierarchyIncomingCall is a caller of an item. FromRanges are the call
+// sites, in the caller's file.
+type CallHierarchyIncomingCall struct {
+	From       CallHierarchyItem `json:"from"`
+	FromRanges []Range           `json:"fromRanges"`
+}
+
+// CallHierarchyOutgoingCall is a function an item calls. FromRanges are the
+// call sites, in the calling item's file.
+type CallHierarchyOutgoingCall struct {
+	To         CallHierarchyItem `json:"to"`
+	FromRanges []Range           `json:"fromRanges"`
+}
+
+// TypeHierarchyItem is a type in a type hierarchy. Data is opaque server
+// state that must be sent back unchanged.
+type TypeHierarchyItem struct {
+	Name           string `json:"name"`
+	Kind           int    `json:"kind"`
+	Detail         string `json:"detail,omitempty"`
+	URI            string `json:"uri"`
+	Range          Range  `json:"range"`
+	SelectionRange Range  `json:"selectionRange"`
+	Data           any    `json:"data,omitempty"`
+}
+
+// TypeHierarchyPrepareParams are the parameters of
+// textDocument/prepareTypeHierarchy.
+type TypeHierarchyPrepareParams = TextDocumentPositionParams
+
+// TypeHierarchyTypesParams are the parameters of typeHierarchy/supertypes
+// and typeHierarchy/subtypes.
+type TypeHierarchyTypesParams struct {
+	Item TypeHierarchyItem `json:"item"`
 }
 
 // FileChangeType represents the kind of file change (LSP spec 3.17).
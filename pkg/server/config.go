@@ -18,9 +18,39 @@ type Config struct {
 	ShutdownTimeout time.Duration
 	RPCTimeout      time.Duration
 	// FSWatch enables filesystem watching: when .go, go.mod or go.sum files
-	// change on disk, gopls is notified via workspace/didChangeWatchedFiles.
-	// Disabled by default; opt in with --fs-watch or MCP_GOPLS_FS_WATCH=true.
+	// change on disk, gopls is notified via workspace/didChangeWatchedFiles,
+	// and clients via notifications/stale_files when the session read or
+	// edited them. Disabled by default; opt in with --fs-watch or MCP_GOPLS_FS_WATCH=true.
 	FSWatch bool
+	// Onboarding sends the workspace briefing (module, conventions, build
+	// commands, tools) as the instructions of the initialize result.
+	// Disabled by default; the briefing is always readable as the
+	// resource://workspace/onboarding resource.
+	Onboarding bool
+	// InteractiveConcurrency and HeavyConcurrency bound how many gopls
+	// queries, and how many tools running commands or loading packages,
+	// run at once. Each kind waits on its own queue.
+	InteractiveConcurrency int
+	HeavyConcurrency       int
+	// OperationThreshold is how long a heavy tool call may run before it is
+	// handed over to a background operation, answered with an ID to poll
+	// with get_operation_status. Zero keeps every call open until it ends.
+	OperationThreshold time.Duration
+	// IsolatedEdits makes the tools work on a private copy of the
+	// workspace, a git worktree when it is in a repository, so the checkout
+	// only changes through promote_changes.
+	IsolatedEdits bool
+	// RequireApproval queues the edits of every tool as pending changes,
+	// written only by approve_pending_change calls carrying ApprovalToken.
+	// A random token is generated and printed to stderr when none is set;
+	// it must never be logged, since tools can read the log.
+	RequireApproval bool
+	ApprovalToken   string
+	// Jobs runs the background jobs of the project settings. Disabled by
+	// default, since the settings come with the repository; opt in with
+	// --jobs or MCP_GOPLS_JOBS=true. Jobs may only call the checks that do
+	// not run the code of the repository.
+	Jobs bool
 }
 
 // DefaultConfig returns sensible defaults for local development.
@@ -31,6 +61,10 @@ func DefaultConfig() Config {
 		LogJSON:         false,
 		ShutdownTimeout: 15 * time.Second,
 		RPCTimeout:      45 * time.Second,
+
+		InteractiveConcurrency: 8,
+		HeavyConcurrency:       2,
+		OperationThreshold:     30 * time.Second,
 	}
 }
 
@@ -56,6 +90,15 @@ func (c *Config) Normalize() error {
 	if c.RPCTimeout <= 0 {
 		c.RPCTimeout = 45 * time.Second
 	}
+	if c.InteractiveConcurrency <= 0 {
+		c.InteractiveConcurrency = 8
+	}
+	if c.HeavyConcurrency <= 0 {
+		c.HeavyConcurrency = 2
+	}
+	if c.OperationThreshold < 0 {
+		c.OperationThreshold = 0
+	}
 
 	return nil
 }
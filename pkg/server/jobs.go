@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+)
+
+const (
+	jobsURI       = "resource://jobs"
+	jobURIPrefix  = jobsURI + "/"
+	jobURIPattern = jobURIPrefix + "{name}"
+)
+
+// jobRun is the outcome of one run of a job. Error is set when the tool
+// could not be called; a tool reporting a failure has IsError set.
+type jobRun struct {
+	Started  time.Time           `json:"started"`
+	Duration string              `json:"duration"`
+	IsError  bool                `json:"is_error"`
+	Error    string              `json:"error,omitempty"`
+	Result   *mcp.CallToolResult `json:"result,omitempty"`
+}
+
+// jobState is a job with its latest run.
+type jobState struct {
+	projectconfig.Job
+	Runs    int       `json:"runs"`
+	Running bool      `json:"running"`
+	NextRun time.Time `json:"next_run"`
+	Last    *jobRun   `json:"last,omitempty"`
+}
+
+// jobRunner repeats the configured tool calls in the background. Calls
+// take a slot on the scheduler queues like any other, so a job never runs
+// beside more heavy tools than configured.
+type jobRunner struct {
+	server    *mcpsrv.MCPServer
+	scheduler *toolScheduler
+	logger    *slog.Logger
+
+	mu   sync.Mutex
+	jobs []*jobState
+}
+
+func newJobRunner(jobs []projectconfig.Job, server *mcpsrv.MCPServer, scheduler *toolScheduler, logger *slog.Logger) *jobRunner {
+	r := &jobRunner{server: server, scheduler: scheduler, logger: logger}
+	now := time.Now()
+	for _, job := range jobs {
+		r.jobs = append(r.jobs, &jobState{Job: job, NextRun: now})
+	}
+	return r
+}
+
+// start runs every job now and then at its interval, until ctx is done.
+// Jobs calling a tool outside jobTools are refused once and never run:
+// the settings come with the repository, which must not be able to edit
+// files or run its own code when the server starts.
+func (r *jobRunner) start(ctx context.Context) {
+	for _, state := range r.jobs {
+		if err := r.checkJobTool(state.Job); err != nil {
+			r.logger.Warn("refusing background job", "job", state.Name, "tool", state.Tool, "error", err)
+			r.mu.Lock()
+			state.Last = &jobRun{Started: time.Now(), Duration: "0s", IsError: true, Error: err.Error()}
+			r.mu.Unlock()
+			continue
+		}
+		// Load validated the interval.
+		every, _ := time.ParseDuration(state.Every)
+		go func() {
+			ticker := time.NewTicker(every)
+			defer ticker.Stop()
+			for {
+				r.run(ctx, state, every)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+// run calls the tool of a job once and publishes its result.
+func (r *jobRunner) run(ctx context.Context, state *jobState, every time.Duration) {
+	r.mu.Lock()
+	state.Running = true
+	job := state.Job
+	r.mu.Unlock()
+
+	run := &jobRun{Started: time.Now()}
+	result, err := r.call(ctx, job)
+	run.Duration = time.Since(run.Started).Round(time.Millisecond).String()
+	if err != nil {
+		run.IsError, run.Error = true, err.Error()
+	} else {
+		run.IsError, run.Result = result.IsError, result
+	}
+	if ctx.Err() != nil {
+		// The server is stopping; the run did not complete.
+		r.mu.Lock()
+		state.Running = false
+		r.mu.Unlock()
+		return
+	}
+	if run.IsError {
+		r.logger.Warn("background job failed", "job", job.Name, "tool", job.Tool, "error", run.Error)
+	} else {
+		r.logger.Info("background job finished", "job", job.Name, "tool", job.Tool, "duration", run.Duration)
+	}
+
+	r.mu.Lock()
+	state.Runs++
+	state.Running = false
+	state.Last = run
+	state.NextRun = run.Started.Add(every)
+	r.mu.Unlock()
+	r.server.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": jobURIPrefix + job.Name})
+}
+
+func (r *jobRunner) call(ctx context.Context, job projectconfig.Job) (*mcp.CallToolResult, error) {
+	if err := r.checkJobTool(job); err != nil {
+		return nil, err
+	}
+	tool := r.server.GetTool(job.Tool)
+	handler := r.scheduler.middleware(tool.Handler)
+	result, err := handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: job.Tool, Arguments: job.Arguments}})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("%s returned no result", job.Tool)
+	}
+	return result, nil
+}
+
+// jobTools are the tools jobs may call: read-only checks that load, build
+// or analyze the code without running it. Tools annotated read-only that
+// run go test, go run or a command of the repository, such as run_go_test
+// or simulate_change, are left out, since jobs come with the repository.
+var jobTools = map[string]bool{
+	"audit_globals":        true,
+	"audit_interfaces":     true,
+	"audit_panics":         true,
+	"audit_timeouts":       true,
+	"check_diagnostics":    true,
+	"check_testdata":       true,
+	"get_diagnostics":      true,
+	"go_build":             true,
+	"go_mod_why":           true,
+	"go_vet":               true,
+	"list_build_targets":   true,
+	"list_pending_changes": true,
+	"list_tests":           true,
+	"module_graph":         true,
+	"run_govulncheck":      true,
+	"session_history":      true,
+	"staticcheck":          true,
+	"vulncheck":            true,
+	"workspace_symbols":    true,
+}
+
+// checkJobTool reports an error unless the tool of job exists and is among
+// jobTools.
+func (r *jobRunner) checkJobTool(job projectconfig.Job) error {
+	if r.server.GetTool(job.Tool) == nil {
+		return fmt.Errorf("unknown tool %s", job.Tool)
+	}
+	if !jobTools[job.Tool] {
+		allowed := slices.Sorted(maps.Keys(jobTools))
+		return fmt.Errorf("%s may change files or run code from the repository; jobs may only call %s", job.Tool, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// states returns a copy of the jobs; without results when brief.
+func (r *jobRunner) states(brief bool) []jobState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make([]jobState, 0, len(r.jobs))
+	for _, state := range r.jobs {
+		copied := *state
+		if brief && copied.Last != nil {
+			last := *copied.Last
+			last.Result = nil
+			copied.Last = &last
+		}
+		states = append(states, copied)
+	}
+	return states
+}
+
+func (s *Service) handleJobs(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return jobContents(request.Params.URI, map[string]any{"jobs": s.jobs.states(true)})
+}
+
+func (s *Service) handleJob(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name := strings.TrimPrefix(request.Params.URI, jobURIPrefix)
+	for _, state := range s.jobs.states(false) {
+		if state.Name == name {
+			return jobContents(request.Params.URI, state)
+		}
+	}
+	return nil, fmt.Errorf("no job named %q", name)
+}
+
+func jobContents(uri string, value any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	content := mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}
+	return []mcp.ResourceContents{content}, nil
+}
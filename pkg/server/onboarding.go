@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/tools"
+)
+
+const onboardingURI = "resource://workspace/onboarding"
+
+// briefing returns the workspace briefing with the tools this server serves.
+func (s *Service) briefing() (tools.Briefing, error) {
+	briefing, err := tools.WorkspaceBriefing(s.config.WorkspaceDir)
+	if err != nil {
+		return tools.Briefing{}, fmt.Errorf("brief workspace: %w", err)
+	}
+	if s.server != nil {
+		for name := range s.server.ListTools() {
+			briefing.Tools = append(briefing.Tools, name)
+		}
+		slices.Sort(briefing.Tools)
+	}
+	return briefing, nil
+}
+
+func (s *Service) handleOnboarding(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	briefing, err := s.briefing()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(briefing, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	content := mcp.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}
+	return []mcp.ResourceContents{content}, nil
+}
+
+// onboard sets the instructions of the initialize result to the workspace
+// briefing, so clients hand it to the agent before its first call.
+func (s *Service) onboard(ctx context.Context, id any, request *mcp.InitializeRequest, result *mcp.InitializeResult) {
+	briefing, err := s.briefing()
+	if err != nil {
+		s.logger.Warn("skipping onboarding", "error", err)
+		return
+	}
+	result.Instructions = onboardingInstructions(briefing)
+}
+
+// onboardingInstructions renders a briefing as the short text sent at
+// initialization; the full briefing stays available as a resource.
+func onboardingInstructions(b tools.Briefing) string {
+	var sb strings.Builder
+	sb.WriteString("Go workspace " + b.Root)
+	if b.Module != "" {
+		sb.WriteString(", module " + b.Module)
+	}
+	if b.GoVersion != "" {
+		sb.WriteString(", go " + b.GoVersion)
+	}
+	fmt.Fprintf(&sb, ", %d packages.\n", b.Packages)
+	if len(b.Directories) > 0 {
+		sb.WriteString("Top-level directories: " + strings.Join(b.Directories, ", ") + "\n")
+	}
+	if len(b.Conventions) > 0 {
+		sb.WriteString("\nConventions:\n")
+		for _, convention := range b.Conventions {
+			sb.WriteString("- " + convention + "\n")
+		}
+	}
+	sb.WriteString("\nBuild commands:\n")
+	for _, command := range b.BuildCommands {
+		fmt.Fprintf(&sb, "- %s (%s)", command.Command, command.Source)
+		if command.Description != "" {
+			sb.WriteString(": " + command.Description)
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "\n%d tools are available. Read %s for this briefing as JSON with the tool names.\n", len(b.Tools), onboardingURI)
+	return sb.String()
+}
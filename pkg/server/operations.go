@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/tools"
+)
+
+const (
+	// operationOutputLimit is how many output lines an operation keeps;
+	// older ones are dropped.
+	operationOutputLimit = 1000
+	// operationRetention is how long a finished operation stays pollable.
+	operationRetention = time.Hour
+	// maxOperationWait caps how long a status poll waits for completion.
+	maxOperationWait = 30 * time.Second
+)
+
+// operationTools poll and cancel operations. They only read the operations,
+// so they take no queue slot and are never turned into operations.
+var operationTools = map[string]bool{
+	"get_operation_status": true,
+	"cancel_operation":     true,
+}
+
+// operation is a heavy tool call that outlived the threshold and carries on
+// in the background, recording its output until it finishes.
+type operation struct {
+	ID      string
+	Tool    string
+	Started time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	output    []string
+	dropped   int
+	cancelled bool
+	finished  time.Time
+	result    *mcp.CallToolResult
+	err       error
+}
+
+func (op *operation) record(message string) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.output = append(op.output, message)
+	if len(op.output) > operationOutputLimit {
+		op.dropped += len(op.output) - operationOutputLimit
+		op.output = slices.Clone(op.output[len(op.output)-operationOutputLimit:])
+	}
+}
+
+func (op *operation) run(ctx context.Context, next mcpsrv.ToolHandlerFunc, request mcp.CallToolRequest) {
+	defer close(op.done)
+	result, err := next(ctx, request)
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.finished = time.Now()
+	op.result, op.err = result, err
+}
+
+// status reports the state of the operation with the output lines from
+// since on, and its result once finished.
+func (op *operation) status(since int) map[string]any {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	status := map[string]any{
+		"operation_id": op.ID,
+		"tool":         op.Tool,
+		"started":      op.Started,
+	}
+	end := op.finished
+	switch {
+	case end.IsZero():
+		status["status"] = "running"
+		end = time.Now()
+	case op.cancelled:
+		status["status"] = "cancelled"
+	case op.err != nil:
+		status["status"] = "failed"
+		status["error"] = op.err.Error()
+	case op.result == nil || op.result.IsError:
+		status["status"] = "failed"
+		status["result"] = op.result
+	default:
+		status["status"] = "completed"
+		status["result"] = op.result
+	}
+	status["elapsed"] = end.Sub(op.Started).Round(time.Millisecond).String()
+
+	if since < op.dropped {
+		status["truncated"] = true
+		since = op.dropped
+	}
+	output := []string{}
+	if since-op.dropped < len(op.output) {
+		output = op.output[since-op.dropped:]
+	}
+	status["output"] = output
+	status["next"] = op.dropped + len(op.output)
+	return status
+}
+
+func (op *operation) running() bool {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.finished.IsZero()
+}
+
+// operationManager hands heavy tool calls still running after the threshold
+// over to the background, answering with an operation ID to poll instead of
+// holding the call open past client timeouts.
+type operationManager struct {
+	threshold time.Duration
+	logger    *slog.Logger
+
+	mu         sync.Mutex
+	next       int
+	operations map[string]*operation
+}
+
+func newOperationManager(threshold time.Duration, logger *slog.Logger) *operationManager {
+	return &operationManager{threshold: threshold, logger: logger, operations: make(map[string]*operation)}
+}
+
+func (m *operationManager) middleware(next mcpsrv.ToolHandlerFunc) mcpsrv.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.Params.Name
+		if m.threshold <= 0 || tools.IsInteractive(name) || operationTools[name] {
+			return next(ctx, request)
+		}
+
+		op := &operation{Tool: name, Started: time.Now(), done: make(chan struct{})}
+		// The call outlives the request once handed over, but keeps its
+		// session so progress notifications still reach the client.
+		opCtx, cancel := context.WithCancel(tools.WithProgressSink(context.WithoutCancel(ctx), op.record))
+		op.cancel = cancel
+		go op.run(opCtx, next, request)
+
+		timer := time.NewTimer(m.threshold)
+		defer timer.Stop()
+		select {
+		case <-op.done:
+			cancel()
+			return op.result, op.err
+		case <-ctx.Done():
+			cancel()
+			return nil, fmt.Errorf("%s cancelled: %w", name, ctx.Err())
+		case <-timer.C:
+		}
+
+		m.add(op)
+		m.logger.Info("tool call handed over to an operation", "tool", name, "operation", op.ID)
+		return mcp.NewToolResultJSON(map[string]any{
+			"operation_id": op.ID,
+			"tool":         name,
+			"status":       "running",
+			"message":      fmt.Sprintf("%s is still running after %s; poll get_operation_status with this operation_id for its output and result, or stop it with cancel_operation", name, m.threshold),
+		})
+	}
+}
+
+// add registers op under a new ID and forgets the operations finished
+// longer than the retention ago.
+func (m *operationManager) add(op *operation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next++
+	op.ID = fmt.Sprintf("op-%d", m.next)
+	m.operations[op.ID] = op
+	for id, old := range m.operations {
+		old.mu.Lock()
+		expired := !old.finished.IsZero() && time.Since(old.finished) > operationRetention
+		old.mu.Unlock()
+		if expired {
+			delete(m.operations, id)
+		}
+	}
+}
+
+func (m *operationManager) get(id string) *operation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.operations[id]
+}
+
+// list returns the operations, oldest first.
+func (m *operationManager) list() []*operation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	operations := make([]*operation, 0, len(m.operations))
+	for _, op := range m.operations {
+		operations = append(operations, op)
+	}
+	slices.SortFunc(operations, func(a, b *operation) int { return a.Started.Compare(b.Started) })
+	return operations
+}
+
+func (m *operationManager) register(s *mcpsrv.MCPServer) {
+	status := mcp.NewTool("get_operation_status",
+		mcp.WithDescription("Poll a long-running tool call handed over to an operation: its status (running, completed, failed or cancelled), the output lines recorded since a cursor, and the tool result once finished. Lists the operations when operation_id is omitted"),
+		mcp.WithTitleAnnotation("Get Operation Status"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("operation_id",
+			mcp.Description("Operation ID returned by the tool call; list the operations when omitted"),
+		),
+		mcp.WithNumber("since",
+			mcp.Description("Return the output lines from this cursor on: the next value of the previous poll (default 0)"),
+		),
+		mcp.WithNumber("wait_seconds",
+			mcp.Description(fmt.Sprintf("Wait up to this many seconds for the operation to finish before answering (default 0, at most %d)", int(maxOperationWait.Seconds()))),
+		),
+	)
+	s.AddTool(status, m.handleStatus)
+
+	cancel := mcp.NewTool("cancel_operation",
+		mcp.WithDescription("Cancel a running operation, stopping the commands it runs, and return its final status"),
+		mcp.WithTitleAnnotation("Cancel Operation"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("operation_id",
+			mcp.Required(),
+			mcp.Description("Operation ID returned by the tool call"),
+		),
+	)
+	s.AddTool(cancel, m.handleCancel)
+}
+
+func (m *operationManager) handleStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := request.GetString("operation_id", "")
+	if id == "" {
+		var operations []map[string]any
+		for _, op := range m.list() {
+			status := op.status(0)
+			delete(status, "output")
+			delete(status, "result")
+			operations = append(operations, status)
+		}
+		return mcp.NewToolResultJSON(map[string]any{"operations": operations, "count": len(operations)})
+	}
+	op := m.get(id)
+	if op == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no operation %s; it may have finished more than %s ago", id, operationRetention)), nil
+	}
+	since := request.GetInt("since", 0)
+	if since < 0 {
+		return mcp.NewToolResultError("since must not be negative"), nil
+	}
+
+	wait := min(time.Duration(request.GetFloat("wait_seconds", 0)*float64(time.Second)), maxOperationWait)
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-op.done:
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return mcp.NewToolResultJSON(op.status(since))
+}
+
+func (m *operationManager) handleCancel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("operation_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	op := m.get(id)
+	if op == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no operation %s", id)), nil
+	}
+	if !op.running() {
+		return mcp.NewToolResultError(fmt.Sprintf("operation %s already finished; get_operation_status returns its result", id)), nil
+	}
+	op.mu.Lock()
+	op.cancelled = true
+	op.mu.Unlock()
+	op.cancel()
+
+	// Commands stop on cancellation; give them a moment to report it.
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-op.done:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	m.logger.Info("operation cancelled", "operation", id, "tool", op.Tool)
+	status := op.status(0)
+	delete(status, "output")
+	return mcp.NewToolResultJSON(status)
+}
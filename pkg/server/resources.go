@@ -17,7 +17,7 @@ type resourceDefinition struct {
 }
 
 func (s *Service) resourceDefinitions() []resourceDefinition {
-	return []resourceDefinition{
+	defs := []resourceDefinition{
 		{
 			resource: mcp.Resource{
 				URI:         "resource://workspace/overview",
@@ -36,7 +36,28 @@ func (s *Service) resourceDefinitions() []resourceDefinition {
 			},
 			handler: s.handleGoModFile,
 		},
+		{
+			resource: mcp.Resource{
+				URI:         onboardingURI,
+				Name:        "Workspace Onboarding",
+				Description: "Briefing for a new session: module, layout, detected conventions, build commands and available tools.",
+				MIMEType:    "application/json",
+			},
+			handler: s.handleOnboarding,
+		},
 	}
+	if s.jobs != nil {
+		defs = append(defs, resourceDefinition{
+			resource: mcp.Resource{
+				URI:         jobsURI,
+				Name:        "Background Jobs",
+				Description: "Background jobs from the project settings, with the status of their latest run.",
+				MIMEType:    "application/json",
+			},
+			handler: s.handleJobs,
+		})
+	}
+	return defs
 }
 
 func (s *Service) registerResources() {
@@ -47,6 +68,12 @@ func (s *Service) registerResources() {
 	for _, def := range s.resourceDefinitions() {
 		s.server.AddResource(def.resource, def.handler)
 	}
+	if s.jobs != nil {
+		s.server.AddResourceTemplate(mcp.NewResourceTemplate(jobURIPattern, "Background Job Result",
+			mcp.WithTemplateDescription("Latest result of a background job, as returned by its tool."),
+			mcp.WithTemplateMIMEType("application/json"),
+		), s.handleJob)
+	}
 }
 
 func (s *Service) handleWorkspaceOverview(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/tools"
+)
+
+// spareStdioWorkers is how many stdio workers there are beyond the two
+// queues' slots, for the heavy calls waiting for their turn: a waiting call
+// holds a worker, and interactive calls need a free one.
+const spareStdioWorkers = 32
+
+// toolScheduler runs interactive tools and heavy ones on separate queues,
+// each with its own number of slots, so that a long test or coverage run
+// does not delay a hover.
+type toolScheduler struct {
+	interactive chan struct{}
+	heavy       chan struct{}
+	logger      *slog.Logger
+}
+
+func newToolScheduler(interactive, heavy int, logger *slog.Logger) *toolScheduler {
+	return &toolScheduler{
+		interactive: make(chan struct{}, interactive),
+		heavy:       make(chan struct{}, heavy),
+		logger:      logger,
+	}
+}
+
+// workers is the stdio worker pool size the queues need.
+func (s *toolScheduler) workers() int {
+	return cap(s.interactive) + cap(s.heavy) + spareStdioWorkers
+}
+
+func (s *toolScheduler) middleware(next mcpsrv.ToolHandlerFunc) mcpsrv.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.Params.Name
+		if operationTools[name] {
+			return next(ctx, request)
+		}
+		queue, class := s.heavy, "heavy"
+		if tools.IsInteractive(name) {
+			queue, class = s.interactive, "interactive"
+		}
+		select {
+		case queue <- struct{}{}:
+		default:
+			s.logger.Debug("tool call queued", "tool", name, "queue", class)
+			select {
+			case queue <- struct{}{}:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%s cancelled while waiting on the %s queue: %w", name, class, ctx.Err())
+			}
+		}
+		defer func() { <-queue }()
+		return next(ctx, request)
+	}
+}
@@ -10,8 +10,11 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	mcpsrv "github.com/mark3labs/mcp-go/server"
 
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+	"github.com/hloiseau/mcp-gopls/v2/internal/scratch"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/fs"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/tools"
@@ -26,14 +29,22 @@ var (
 		return tools.NewLSPTools(lsp, workspace)
 	}
 
-	newStdioServer = func(s *mcpsrv.MCPServer) stdioServer {
-		return &stdioServerAdapter{inner: mcpsrv.NewStdioServer(s)}
+	newStdioServer = func(s *mcpsrv.MCPServer, opts ...mcpsrv.StdioOption) stdioServer {
+		inner := mcpsrv.NewStdioServer(s)
+		for _, opt := range opts {
+			opt(inner)
+		}
+		return &stdioServerAdapter{inner: inner}
 	}
 )
 
 type toolRegistrar interface {
 	SetClientGetter(func() client.LSPClient)
 	SetResetFunc(func(error) bool)
+	SetScratchWorkspace(*scratch.Workspace)
+	SetApprovalToken(string)
+	TrackToolCall(*mcp.CallToolRequest, any)
+	StaleFiles([]string) []tools.StaleFile
 	Register(*mcpsrv.MCPServer)
 }
 
@@ -52,9 +63,11 @@ func (a *stdioServerAdapter) Listen(ctx context.Context, in io.Reader, out io.Wr
 type Service struct {
 	config Config
 
-	server  *mcpsrv.MCPServer
-	logger  *slog.Logger
-	logFile *os.File
+	server     *mcpsrv.MCPServer
+	scheduler  *toolScheduler
+	operations *operationManager
+	logger     *slog.Logger
+	logFile    *os.File
 
 	lspClient   client.LSPClient
 	clientMutex sync.RWMutex
@@ -62,6 +75,17 @@ type Service struct {
 	// fsWatcher watches the workspace filesystem and notifies gopls on changes.
 	// Nil when FSWatch is disabled in config.
 	fsWatcher *fs.Watcher
+
+	// jobs runs the background jobs of the project settings; nil when
+	// there are none.
+	jobs *jobRunner
+
+	// tools are the registered tools, nil until RegisterTools.
+	tools toolRegistrar
+
+	// scratch is the copy of the workspace the tools edit with
+	// IsolatedEdits; nil otherwise.
+	scratch *scratch.Workspace
 }
 
 func (s *Service) initLSPClient(ctx context.Context) error {
@@ -85,6 +109,11 @@ func (s *Service) initLSPClient(ctx context.Context) error {
 	if s.config.GoplsPath != "" {
 		opts = append(opts, client.WithExecutable(s.config.GoplsPath))
 	}
+	if cfg, err := projectconfig.Load(s.config.WorkspaceDir); err != nil {
+		s.logger.Warn("ignoring project settings", "error", err)
+	} else if len(cfg.Gopls) > 0 {
+		opts = append(opts, client.WithSettings(cfg.Gopls))
+	}
 
 	lspClient, err := newLSPClient(opts...)
 	if err != nil {
@@ -135,7 +164,14 @@ func (s *Service) RegisterTools() {
 	lspTools.SetResetFunc(func(err error) bool {
 		return s.resetLSPClientIfNeeded(err)
 	})
+	if s.scratch != nil {
+		lspTools.SetScratchWorkspace(s.scratch)
+	}
+	if s.config.RequireApproval {
+		lspTools.SetApprovalToken(s.config.ApprovalToken)
+	}
 	lspTools.Register(s.server)
+	s.tools = lspTools
 }
 
 func (s *Service) Start(ctx context.Context) error {
@@ -143,14 +179,21 @@ func (s *Service) Start(ctx context.Context) error {
 		ctx = context.Background()
 	}
 
-	// Start filesystem watcher if enabled in config.
+	s.RegisterTools()
+	// Start filesystem watcher if enabled in config, once the tools it
+	// reports stale files from are registered.
 	if s.fsWatcher != nil {
 		go s.fsWatcher.Run(ctx)
 	}
+	if s.jobs != nil {
+		s.jobs.start(ctx)
+	}
 
-	s.RegisterTools()
-
-	stdioServer := newStdioServer(s.server)
+	var opts []mcpsrv.StdioOption
+	if s.scheduler != nil {
+		opts = append(opts, mcpsrv.WithWorkerPoolSize(s.scheduler.workers()))
+	}
+	stdioServer := newStdioServer(s.server, opts...)
 
 	s.logger.Info("serving MCP over stdio")
 	if err := stdioServer.Listen(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
@@ -173,6 +216,13 @@ func (s *Service) cleanup(ctx context.Context) {
 		_ = client.Close(ctx)
 	}
 
+	if s.scratch != nil {
+		if err := s.scratch.Close(ctx); err != nil {
+			s.logger.Warn("isolated workspace kept", "error", err)
+		}
+		s.scratch = nil
+	}
+
 	if s.logFile != nil {
 		_ = s.logFile.Close()
 		s.logFile = nil
@@ -202,7 +252,7 @@ func setupLogger(cfg Config) (*os.File, *slog.Logger, error) {
 	return file, slog.New(handler), nil
 }
 
-func setupServer(logger *slog.Logger) *mcpsrv.MCPServer {
+func setupServer(logger *slog.Logger, scheduler *toolScheduler, operations *operationManager, hooks *mcpsrv.Hooks) *mcpsrv.MCPServer {
 	srv := mcpsrv.NewMCPServer(
 		"MCP LSP Go",
 		"2.0.0",
@@ -210,6 +260,10 @@ func setupServer(logger *slog.Logger) *mcpsrv.MCPServer {
 		mcpsrv.WithToolCapabilities(true),
 		mcpsrv.WithResourceCapabilities(true, true),
 		mcpsrv.WithPromptCapabilities(true),
+		// Outermost, so that a call waiting on its queue can be handed over.
+		mcpsrv.WithToolHandlerMiddleware(operations.middleware),
+		mcpsrv.WithToolHandlerMiddleware(scheduler.middleware),
+		mcpsrv.WithHooks(hooks),
 	)
 
 	if logger != nil {
@@ -2,11 +2,23 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
 
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+	"github.com/hloiseau/mcp-gopls/v2/internal/scratch"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/fs"
 )
 
+// approvalTokenOutput receives the generated approval token. It is kept
+// out of the log, which tools can read.
+var approvalTokenOutput io.Writer = os.Stderr
+
 // NewService creates a fully configured MCP service ready to serve requests.
 func NewService(cfg Config) (*Service, error) {
 	if err := cfg.Normalize(); err != nil {
@@ -19,10 +31,30 @@ func NewService(cfg Config) (*Service, error) {
 	}
 
 	svc := &Service{
-		config:  cfg,
 		logger:  logger,
 		logFile: logFile,
 	}
+	if cfg.IsolatedEdits {
+		workspace, err := scratch.New(context.Background(), cfg.WorkspaceDir)
+		if err != nil {
+			svc.cleanup(context.Background())
+			return nil, fmt.Errorf("create isolated workspace: %w", err)
+		}
+		logger.Info("isolated edits", "original", workspace.Original(), "workspace", workspace.Dir(), "mode", workspace.Mode())
+		svc.scratch = workspace
+		cfg.WorkspaceDir = workspace.Dir()
+	}
+	if cfg.RequireApproval && cfg.ApprovalToken == "" {
+		secret := make([]byte, 16)
+		if _, err := rand.Read(secret); err != nil {
+			svc.cleanup(context.Background())
+			return nil, fmt.Errorf("generate approval token: %w", err)
+		}
+		cfg.ApprovalToken = hex.EncodeToString(secret)
+		fmt.Fprintf(approvalTokenOutput, "mcp-gopls: edits wait for approval; pass this token to approve_pending_change: %s\n", cfg.ApprovalToken)
+		logger.Info("edits wait for approval; the generated approval token was printed to stderr")
+	}
+	svc.config = cfg
 
 	if err := svc.initLSPClient(context.Background()); err != nil {
 		svc.cleanup(context.Background())
@@ -32,10 +64,26 @@ func NewService(cfg Config) (*Service, error) {
 	// Initialise the watcher after the LSP client so we can hand it a live client.
 	if cfg.FSWatch {
 		svc.fsWatcher = fs.NewWatcher(cfg.WorkspaceDir, svc.lspClient).
-			WithLogger(logger.With("component", "fs_watcher"))
+			WithLogger(logger.With("component", "fs_watcher")).
+			WithChangeHandler(svc.notifyStaleFiles)
 	}
 
-	svc.server = setupServer(logger)
+	svc.scheduler = newToolScheduler(cfg.InteractiveConcurrency, cfg.HeavyConcurrency, logger.With("component", "scheduler"))
+	hooks := &mcpsrv.Hooks{}
+	if cfg.Onboarding {
+		hooks.AddAfterInitialize(svc.onboard)
+	}
+	hooks.AddAfterCallTool(svc.trackToolCall)
+	svc.operations = newOperationManager(cfg.OperationThreshold, logger.With("component", "operations"))
+	svc.server = setupServer(logger, svc.scheduler, svc.operations, hooks)
+	svc.operations.register(svc.server)
+	if settings, err := projectconfig.Load(cfg.WorkspaceDir); err != nil {
+		logger.Warn("ignoring background jobs", "error", err)
+	} else if len(settings.Jobs) > 0 && !cfg.Jobs {
+		logger.Info("background jobs are configured but disabled; enable them with --jobs", "jobs", len(settings.Jobs))
+	} else if len(settings.Jobs) > 0 {
+		svc.jobs = newJobRunner(settings.Jobs, svc.server, svc.scheduler, logger.With("component", "jobs"))
+	}
 	svc.registerResources()
 	svc.registerPrompts()
 	return svc, nil
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -15,8 +17,11 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	mcpsrv "github.com/mark3labs/mcp-go/server"
 
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+	"github.com/hloiseau/mcp-gopls/v2/internal/scratch"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/tools"
 )
 
 type stubLSPClient struct {
@@ -30,32 +35,100 @@ func (s *stubLSPClient) Close(ctx context.Context) error      { return s.closeEr
 func (s *stubLSPClient) GoToDefinition(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
 	return nil, nil
 }
+func (s *stubLSPClient) GoToTypeDefinition(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) GoToDeclaration(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	return nil, nil
+}
 func (s *stubLSPClient) FindReferences(ctx context.Context, uri string, line, character int, includeDeclaration bool) ([]protocol.Location, error) {
 	return nil, nil
 }
+func (s *stubLSPClient) FindImplementations(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) PrepareCallHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.CallHierarchyItem, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) IncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) OutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) PrepareTypeHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.TypeHierarchyItem, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) Supertypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) Subtypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (*protocol.CodeAction, error) {
+	return &action, nil
+}
+func (s *stubLSPClient) ExecuteCommand(ctx context.Context, command protocol.Command) ([]protocol.WorkspaceEdit, error) {
+	return nil, nil
+}
 func (s *stubLSPClient) GetDiagnostics(ctx context.Context, uri string) ([]protocol.Diagnostic, error) {
 	return nil, nil
 }
+func (s *stubLSPClient) WorkspaceDiagnostics() map[string][]protocol.Diagnostic { return nil }
+func (s *stubLSPClient) WaitForQuiescence(ctx context.Context, quiet time.Duration) error {
+	return nil
+}
 func (s *stubLSPClient) DidOpen(ctx context.Context, uri, languageID, text string) error { return nil }
 func (s *stubLSPClient) DidClose(ctx context.Context, uri string) error                  { return nil }
+func (s *stubLSPClient) OpenDocument(ctx context.Context, uri, languageID, text string) (bool, error) {
+	return false, nil
+}
 func (s *stubLSPClient) GetHover(ctx context.Context, uri string, line, character int) (string, error) {
 	return "", nil
 }
 func (s *stubLSPClient) GetCompletion(ctx context.Context, uri string, line, character int) ([]string, error) {
 	return nil, nil
 }
+func (s *stubLSPClient) GetCompletionList(ctx context.Context, uri string, line, character int) (*protocol.CompletionList, error) {
+	return nil, nil
+}
 func (s *stubLSPClient) DocumentFormatting(ctx context.Context, uri string) ([]protocol.TextEdit, error) {
 	return nil, nil
 }
 func (s *stubLSPClient) Rename(ctx context.Context, uri string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
 	return nil, nil
 }
-func (s *stubLSPClient) CodeActions(ctx context.Context, uri string, rng protocol.Range) ([]protocol.CodeAction, error) {
+func (s *stubLSPClient) CodeActions(ctx context.Context, uri string, rng protocol.Range, only ...string) ([]protocol.CodeAction, error) {
 	return nil, nil
 }
 func (s *stubLSPClient) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
 	return nil, nil
 }
+func (s *stubLSPClient) DocumentSymbols(ctx context.Context, uri string) ([]protocol.DocumentSymbol, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) DocumentHighlights(ctx context.Context, uri string, line, character int) ([]protocol.DocumentHighlight, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) InlayHints(ctx context.Context, uri string, rng protocol.Range) ([]protocol.InlayHint, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) CodeLenses(ctx context.Context, uri string) ([]protocol.CodeLens, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) FoldingRanges(ctx context.Context, uri string) ([]protocol.FoldingRange, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) SemanticTokens(ctx context.Context, uri string, rng protocol.Range) ([]protocol.SemanticToken, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) SelectionRanges(ctx context.Context, uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+	return nil, nil
+}
+func (s *stubLSPClient) RunCommand(ctx context.Context, command protocol.Command) (json.RawMessage, []protocol.WorkspaceEdit, error) {
+	return nil, nil, nil
+}
+func (s *stubLSPClient) Commands() []string                                     { return nil }
 func (s *stubLSPClient) OnDiagnostics(handler client.DiagnosticsHandler) func() { return func() {} }
 func (s *stubLSPClient) NotifyDidChangeWatchedFiles(_ context.Context, _ []protocol.FileEvent) error {
 	return nil
@@ -75,8 +148,8 @@ func TestResourceDefinitions(t *testing.T) {
 
 	svc := &Service{config: Config{WorkspaceDir: tmp}}
 	defs := svc.resourceDefinitions()
-	if len(defs) != 2 {
-		t.Fatalf("expected 2 resources, got %d", len(defs))
+	if len(defs) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(defs))
 	}
 
 	var overview resourceDefinition
@@ -192,6 +265,77 @@ func TestRegisterToolsUsesFactory(t *testing.T) {
 	if fake.registeredWith != svc.server {
 		t.Fatal("tools not registered with server")
 	}
+	if fake.setScratchWorkspace {
+		t.Fatal("expected no scratch workspace without isolated edits")
+	}
+}
+
+func TestNewServiceIsolatedEdits(t *testing.T) {
+	origClient, origFactory := newLSPClient, newLSPTools
+	t.Cleanup(func() { newLSPClient, newLSPTools = origClient, origFactory })
+	newLSPClient = func(...client.Option) (client.LSPClient, error) { return &stubLSPClient{}, nil }
+	fake := &fakeToolset{}
+	newLSPTools = func(client.LSPClient, string) toolRegistrar { return fake }
+
+	original := t.TempDir()
+	if err := os.WriteFile(filepath.Join(original, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.WorkspaceDir = original
+	cfg.IsolatedEdits = true
+	svc, err := NewService(cfg)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	workspace := svc.config.WorkspaceDir
+	if svc.scratch == nil || workspace != svc.scratch.Dir() || workspace == original {
+		t.Fatalf("expected the service to work on a copy, got %s", workspace)
+	}
+	if data, err := os.ReadFile(filepath.Join(workspace, "main.go")); err != nil || string(data) != "package main\n" {
+		t.Fatalf("the copy lacks main.go: %q, %v", data, err)
+	}
+	svc.RegisterTools()
+	if !fake.setScratchWorkspace {
+		t.Fatal("expected the tools to get the copy")
+	}
+	svc.Close(context.Background())
+	if _, err := os.Stat(workspace); !os.IsNotExist(err) {
+		t.Fatalf("expected the unchanged copy to be removed, got %v", err)
+	}
+}
+
+func TestNewServiceApprovalToken(t *testing.T) {
+	origClient, origFactory := newLSPClient, newLSPTools
+	t.Cleanup(func() { newLSPClient, newLSPTools = origClient, origFactory })
+	newLSPClient = func(...client.Option) (client.LSPClient, error) { return &stubLSPClient{}, nil }
+	fake := &fakeToolset{}
+	newLSPTools = func(client.LSPClient, string) toolRegistrar { return fake }
+
+	origOutput := approvalTokenOutput
+	t.Cleanup(func() { approvalTokenOutput = origOutput })
+	var printed bytes.Buffer
+	approvalTokenOutput = &printed
+
+	cfg := DefaultConfig()
+	cfg.WorkspaceDir = t.TempDir()
+	cfg.LogFile = filepath.Join(t.TempDir(), "server.log")
+	cfg.RequireApproval = true
+	svc, err := NewService(cfg)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	defer svc.Close(context.Background())
+	svc.RegisterTools()
+	if len(fake.approvalToken) != 32 || fake.approvalToken != svc.config.ApprovalToken {
+		t.Fatalf("expected a generated approval token, got %q", fake.approvalToken)
+	}
+	if !strings.Contains(printed.String(), fake.approvalToken) {
+		t.Fatalf("expected the token to be printed, got %q", printed.String())
+	}
+	if logged, err := os.ReadFile(cfg.LogFile); err != nil || strings.Contains(string(logged), fake.approvalToken) {
+		t.Fatalf("expected the token to stay out of the log, got %q (%v)", logged, err)
+	}
 }
 
 func TestResetLSPClientIfNeeded(t *testing.T) {
@@ -234,7 +378,7 @@ func TestServiceStartInvokesStdioServer(t *testing.T) {
 	}
 
 	fakeStdio := &fakeStdioServer{}
-	newStdioServer = func(*mcpsrv.MCPServer) stdioServer {
+	newStdioServer = func(*mcpsrv.MCPServer, ...mcpsrv.StdioOption) stdioServer {
 		return fakeStdio
 	}
 
@@ -273,7 +417,7 @@ func TestConfigNormalize(t *testing.T) {
 			t.Fatalf("expected absolute workspace, got %s", cfg.WorkspaceDir)
 		}
 	}
-	if cfg.RPCTimeout != 45*time.Second || cfg.ShutdownTimeout != 15*time.Second {
+	if cfg.RPCTimeout != 45*time.Second || cfg.ShutdownTimeout != 15*time.Second || cfg.InteractiveConcurrency != 8 || cfg.HeavyConcurrency != 2 || cfg.OperationThreshold != 0 {
 		t.Fatalf("unexpected defaults %+v", cfg)
 	}
 
@@ -283,6 +427,188 @@ func TestConfigNormalize(t *testing.T) {
 	}
 }
 
+func TestToolSchedulerSeparatesQueues(t *testing.T) {
+	scheduler := newToolScheduler(1, 1, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	release := make(chan struct{})
+	started := make(chan string, 4)
+	handler := scheduler.middleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started <- request.Params.Name
+		if request.Params.Name == "run_go_test" {
+			<-release
+		}
+		return mcp.NewToolResultText(request.Params.Name), nil
+	})
+	call := func(ctx context.Context, name string) error {
+		_, err := handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name}})
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- call(context.Background(), "run_go_test") }()
+	if name := <-started; name != "run_go_test" {
+		t.Fatalf("unexpected first call %s", name)
+	}
+	if err := call(context.Background(), "hover"); err != nil {
+		t.Fatalf("an interactive call should not wait for a heavy one: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := call(ctx, "analyze_coverage"); err == nil || !strings.Contains(err.Error(), "heavy queue") {
+		t.Fatalf("a second heavy call should wait for the first, got %v", err)
+	}
+	select {
+	case name := <-started:
+		t.Fatalf("%s ran while the heavy queue was full", name)
+	default:
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("run_go_test: %v", err)
+	}
+	if err := call(context.Background(), "analyze_coverage"); err != nil {
+		t.Fatalf("the heavy slot should be free again: %v", err)
+	}
+	if scheduler.workers() != 2+spareStdioWorkers {
+		t.Fatalf("unexpected worker count %d", scheduler.workers())
+	}
+}
+
+func TestOnboardingInstructions(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), []byte("module example.com/test\n\ngo 1.26\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "Makefile"), []byte("## Run the tests\ntest:\n\tgo test ./...\n"), 0o644); err != nil {
+		t.Fatalf("write Makefile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := &Service{config: Config{WorkspaceDir: tmp}, logger: logger}
+	hooks := &mcpsrv.Hooks{}
+	hooks.AddAfterInitialize(svc.onboard)
+	svc.server = setupServer(logger, newToolScheduler(1, 1, logger), newOperationManager(0, logger), hooks)
+	svc.server.AddTool(mcp.NewTool("hover"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	})
+
+	message := svc.server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`))
+	response, ok := message.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("unexpected initialize response %#v", message)
+	}
+	instructions := response.Result.(mcp.InitializeResult).Instructions
+	for _, want := range []string{"module example.com/test, go 1.26, 1 packages", "- make test (Makefile): Run the tests", "- go vet ./... (go)", "1 tools are available", onboardingURI} {
+		if !strings.Contains(instructions, want) {
+			t.Fatalf("instructions miss %q:\n%s", want, instructions)
+		}
+	}
+
+	contents, err := svc.handleOnboarding(context.Background(), mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: onboardingURI}})
+	if err != nil {
+		t.Fatalf("onboarding resource: %v", err)
+	}
+	var briefing struct {
+		Module string   `json:"module"`
+		Tools  []string `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(contents[0].(mcp.TextResourceContents).Text), &briefing); err != nil {
+		t.Fatalf("unmarshal briefing: %v", err)
+	}
+	if briefing.Module != "example.com/test" || len(briefing.Tools) != 1 || briefing.Tools[0] != "hover" {
+		t.Fatalf("unexpected briefing %+v", briefing)
+	}
+}
+
+func TestJobRunner(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	scheduler := newToolScheduler(1, 1, logger)
+	svc := &Service{config: Config{WorkspaceDir: t.TempDir()}, logger: logger}
+	svc.server = setupServer(logger, scheduler, newOperationManager(0, logger), &mcpsrv.Hooks{})
+	calls := 0
+	svc.server.AddTool(mcp.NewTool("run_govulncheck", mcp.WithReadOnlyHintAnnotation(true)), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		return mcp.NewToolResultText(fmt.Sprintf("scan %d of %v", calls, request.GetArguments()["pattern"])), nil
+	})
+	svc.jobs = newJobRunner([]projectconfig.Job{
+		{Name: "nightly-vulncheck", Tool: "run_govulncheck", Arguments: map[string]any{"pattern": "./..."}, Every: "24h"},
+		{Name: "typo", Tool: "run_gobuild", Every: "1h"},
+		{Name: "build", Tool: "run_build_target", Arguments: map[string]any{"target": "build"}, Every: "1h"},
+		{Name: "tests", Tool: "run_go_test", Arguments: map[string]any{"path": "./..."}, Every: "1h"},
+	}, svc.server, scheduler, logger)
+	built := false
+	svc.server.AddTool(mcp.NewTool("run_build_target"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		built = true
+		return mcp.NewToolResultText("built"), nil
+	})
+	if len(svc.resourceDefinitions()) != 4 {
+		t.Fatal("expected the jobs index among the resources")
+	}
+
+	ctx := context.Background()
+	svc.jobs.run(ctx, svc.jobs.jobs[0], 24*time.Hour)
+	svc.jobs.run(ctx, svc.jobs.jobs[0], 24*time.Hour)
+	svc.jobs.run(ctx, svc.jobs.jobs[1], time.Hour)
+
+	contents, err := svc.handleJob(ctx, mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: "resource://jobs/nightly-vulncheck"}})
+	if err != nil {
+		t.Fatalf("job resource: %v", err)
+	}
+	var job jobState
+	if err := json.Unmarshal([]byte(contents[0].(mcp.TextResourceContents).Text), &job); err != nil {
+		t.Fatalf("unmarshal job: %v", err)
+	}
+	if job.Runs != 2 || job.Last == nil || job.Last.IsError || job.Running || !job.NextRun.After(job.Last.Started) {
+		t.Fatalf("unexpected job state %+v", job)
+	}
+	if text := job.Last.Result.Content[0].(mcp.TextContent).Text; text != "scan 2 of ./..." {
+		t.Fatalf("unexpected job result %q", text)
+	}
+
+	contents, err = svc.handleJobs(ctx, mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: jobsURI}})
+	if err != nil {
+		t.Fatalf("jobs resource: %v", err)
+	}
+	var index struct {
+		Jobs []jobState `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(contents[0].(mcp.TextResourceContents).Text), &index); err != nil {
+		t.Fatalf("unmarshal jobs: %v", err)
+	}
+	if len(index.Jobs) != 4 || index.Jobs[0].Last.Result != nil {
+		t.Fatalf("the index should list the jobs without their results, got %+v", index.Jobs)
+	}
+	if last := index.Jobs[1].Last; !last.IsError || !strings.Contains(last.Error, "unknown tool run_gobuild") {
+		t.Fatalf("expected the unknown tool to be reported, got %+v", last)
+	}
+	if _, err := svc.handleJob(ctx, mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: "resource://jobs/missing"}}); err == nil {
+		t.Fatal("expected an error for an unknown job")
+	}
+
+	// run_go_test is read-only but runs the tests of the repository.
+	tested := false
+	svc.server.AddTool(mcp.NewTool("run_go_test", mcp.WithReadOnlyHintAnnotation(true)), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tested = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+	svc.jobs.run(ctx, svc.jobs.jobs[2], time.Hour)
+	svc.jobs.run(ctx, svc.jobs.jobs[3], time.Hour)
+	states := svc.jobs.states(true)
+	for _, state := range states[2:] {
+		if last := state.Last; last == nil || !strings.Contains(last.Error, state.Tool+" may change files or run code from the repository") {
+			t.Fatalf("expected the job calling %s to be refused, got %+v", state.Tool, last)
+		}
+	}
+	if built || tested {
+		t.Fatalf("refused jobs must not run, built %v tested %v", built, tested)
+	}
+}
+
 func TestBuildWorkspaceSummary(t *testing.T) {
 	tmp := t.TempDir()
 	if err := os.WriteFile(filepath.Join(tmp, "main.go"), []byte("package main"), 0o644); err != nil {
@@ -305,10 +631,13 @@ func TestBuildWorkspaceSummary(t *testing.T) {
 }
 
 type fakeToolset struct {
-	setClientGetter bool
-	setResetFunc    bool
-	registerCalled  bool
-	registeredWith  *mcpsrv.MCPServer
+	setClientGetter     bool
+	setResetFunc        bool
+	registerCalled      bool
+	setScratchWorkspace bool
+	approvalToken       string
+	trackedCalls        []string
+	registeredWith      *mcpsrv.MCPServer
 }
 
 func (f *fakeToolset) SetClientGetter(func() client.LSPClient) {
@@ -319,11 +648,68 @@ func (f *fakeToolset) SetResetFunc(func(error) bool) {
 	f.setResetFunc = true
 }
 
+func (f *fakeToolset) SetScratchWorkspace(*scratch.Workspace) {
+	f.setScratchWorkspace = true
+}
+
+func (f *fakeToolset) SetApprovalToken(token string) {
+	f.approvalToken = token
+}
+
+func (f *fakeToolset) TrackToolCall(request *mcp.CallToolRequest, _ any) {
+	f.trackedCalls = append(f.trackedCalls, request.Params.Name)
+}
+
+func (f *fakeToolset) StaleFiles(paths []string) []tools.StaleFile {
+	var stale []tools.StaleFile
+	for _, path := range paths {
+		stale = append(stale, tools.StaleFile{Path: filepath.Base(path), Access: "read"})
+	}
+	return stale
+}
+
 func (f *fakeToolset) Register(s *mcpsrv.MCPServer) {
 	f.registerCalled = true
 	f.registeredWith = s
 }
 
+type fakeSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (f *fakeSession) Initialize()                                         {}
+func (f *fakeSession) Initialized() bool                                   { return true }
+func (f *fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return f.notifications }
+func (f *fakeSession) SessionID() string                                   { return "fake" }
+
+func TestNotifyStaleFiles(t *testing.T) {
+	fake := &fakeToolset{}
+	svc := &Service{
+		server: mcpsrv.NewMCPServer("test", "1.0"),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		tools:  fake,
+	}
+	session := &fakeSession{notifications: make(chan mcp.JSONRPCNotification, 1)}
+	if err := svc.server.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.trackToolCall(context.Background(), 1, &mcp.CallToolRequest{Params: mcp.CallToolParams{Name: "hover"}}, nil)
+	if len(fake.trackedCalls) != 1 || fake.trackedCalls[0] != "hover" {
+		t.Fatalf("expected the call to be tracked, got %v", fake.trackedCalls)
+	}
+	svc.notifyStaleFiles(context.Background(), []protocol.FileEvent{{URI: "file:///work/main.go", Type: protocol.FileChanged}})
+	select {
+	case notification := <-session.notifications:
+		files, _ := notification.Params.AdditionalFields["files"].([]tools.StaleFile)
+		if notification.Method != staleFilesNotification || len(files) != 1 || files[0].Path != "main.go" {
+			t.Fatalf("unexpected notification %+v", notification)
+		}
+	default:
+		t.Fatal("expected a stale files notification")
+	}
+}
+
 type fakeStdioServer struct {
 	listenCalled bool
 }
@@ -332,3 +718,83 @@ func (f *fakeStdioServer) Listen(ctx context.Context, _ io.Reader, _ io.Writer)
 	f.listenCalled = true
 	return context.Canceled
 }
+
+func TestOperationHandles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	operations := newOperationManager(20*time.Millisecond, logger)
+	srv := setupServer(logger, newToolScheduler(2, 2, logger), operations, &mcpsrv.Hooks{})
+	operations.register(srv)
+	release := make(chan struct{})
+	srv.AddTool(mcp.NewTool("run_go_test"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.GetBool("slow", false) {
+			<-release
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+	srv.AddTool(mcp.NewTool("analyze_coverage"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	call := func(name string, args map[string]any) map[string]any {
+		t.Helper()
+		data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": map[string]any{"name": name, "arguments": args}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, ok := srv.HandleMessage(context.Background(), data).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("%s failed", name)
+		}
+		result := response.Result.(*mcp.CallToolResult)
+		if result.StructuredContent == nil {
+			return map[string]any{"text": result.Content[0].(mcp.TextContent).Text, "is_error": result.IsError}
+		}
+		data, _ = json.Marshal(result.StructuredContent)
+		var content map[string]any
+		if err := json.Unmarshal(data, &content); err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+
+	if got := call("run_go_test", nil); got["text"] != "ok" {
+		t.Fatalf("a call within the threshold should answer directly, got %v", got)
+	}
+	handle := call("run_go_test", map[string]any{"slow": true})
+	if handle["operation_id"] != "op-1" || handle["status"] != "running" {
+		t.Fatalf("a slow call should be handed over, got %v", handle)
+	}
+	if status := call("get_operation_status", map[string]any{"operation_id": "op-1"}); status["status"] != "running" {
+		t.Fatalf("expected op-1 running, got %v", status)
+	}
+	close(release)
+	status := call("get_operation_status", map[string]any{"operation_id": "op-1", "wait_seconds": 5})
+	if status["status"] != "completed" || status["result"] == nil {
+		t.Fatalf("expected op-1 completed with its result, got %v", status)
+	}
+
+	call("analyze_coverage", nil)
+	if list := call("get_operation_status", nil); list["count"] != float64(2) {
+		t.Fatalf("expected two operations, got %v", list)
+	}
+	if cancelled := call("cancel_operation", map[string]any{"operation_id": "op-2"}); cancelled["status"] != "cancelled" {
+		t.Fatalf("expected op-2 cancelled, got %v", cancelled)
+	}
+	if again := call("cancel_operation", map[string]any{"operation_id": "op-2"}); again["is_error"] != true {
+		t.Fatalf("cancelling a finished operation should fail, got %v", again)
+	}
+	if missing := call("get_operation_status", map[string]any{"operation_id": "op-9"}); missing["is_error"] != true {
+		t.Fatalf("expected an error for an unknown operation, got %v", missing)
+	}
+
+	op := operations.get("op-1")
+	for i := range operationOutputLimit + 5 {
+		op.record(fmt.Sprintf("line %d", i))
+	}
+	if status := op.status(0); status["truncated"] != true || status["next"] != operationOutputLimit+5 || len(status["output"].([]string)) != operationOutputLimit {
+		t.Fatalf("expected the oldest lines dropped, got next %v", status["next"])
+	}
+	if output := op.status(operationOutputLimit + 3)["output"].([]string); len(output) != 2 || output[1] != fmt.Sprintf("line %d", operationOutputLimit+4) {
+		t.Fatalf("expected the lines from the cursor on, got %v", output)
+	}
+}
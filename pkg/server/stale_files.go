@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/fs"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// staleFilesNotification is the method of the notification listing the
+// files the session read or edited that another process modified.
+const staleFilesNotification = "notifications/stale_files"
+
+// trackToolCall hands each finished tool call to the tools, which record it
+// in the session history along with the file it read.
+func (s *Service) trackToolCall(ctx context.Context, id any, request *mcp.CallToolRequest, result any) {
+	if s.tools != nil {
+		s.tools.TrackToolCall(request, result)
+	}
+}
+
+// notifyStaleFiles tells the clients which of the files the file watcher
+// saw change the session read or edited, so the agent re-reads them
+// before editing them.
+func (s *Service) notifyStaleFiles(ctx context.Context, changes []protocol.FileEvent) {
+	if s.tools == nil {
+		return
+	}
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, fs.URIToPath(change.URI))
+	}
+	stale := s.tools.StaleFiles(paths)
+	if len(stale) == 0 {
+		return
+	}
+	s.logger.Info("files changed on disk since the session saw them", "count", len(stale))
+	s.server.SendNotificationToAllClients(staleFilesNotification, map[string]any{
+		"files":   stale,
+		"message": fmt.Sprintf("%d file(s) the session read or edited changed on disk; re-read them before editing", len(stale)),
+	})
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// analysisProfiles are the built-in analysis profiles. The project settings
+// replace them or add others by name.
+var analysisProfiles = map[string]projectconfig.AnalysisProfile{
+	"fast":     {Skip: []string{"staticcheck"}, Timeout: "10s", Scope: "changed"},
+	"thorough": {Timeout: "2m", Scope: "workspace"},
+	"security": {Analyzers: securityAnalyzers, Timeout: "2m", Scope: "workspace", Vulncheck: true},
+}
+
+// securityAnalyzers keep build errors and the checks flagging unsafe
+// pointer and cgo use, leaked responses and contexts, and misused standard
+// library calls.
+var securityAnalyzers = []string{"compiler", "go list", "cgocall", "httpresponse", "lostcancel", "stringintconv", "unsafeptr", "SA1*", "SA5*"}
+
+var staticcheckCode = regexp.MustCompile(`^(SA|S|ST|QF)[0-9]+$`)
+
+// analysisProfile returns the named profile, looking at the project
+// settings first.
+func (t *LSPTools) analysisProfile(name string) (projectconfig.AnalysisProfile, error) {
+	profiles := maps.Clone(analysisProfiles)
+	cfg, err := projectconfig.Load(t.workspaceDir)
+	if err != nil {
+		return projectconfig.AnalysisProfile{}, err
+	}
+	maps.Copy(profiles, cfg.AnalysisProfiles)
+	profile, ok := profiles[name]
+	if !ok {
+		return projectconfig.AnalysisProfile{}, fmt.Errorf("unknown profile %q; available: %s", name, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+	}
+	return profile, nil
+}
+
+// profileKeeps reports whether profile keeps the diagnostics of source.
+func profileKeeps(profile projectconfig.AnalysisProfile, source string) bool {
+	if len(profile.Analyzers) > 0 && !slices.ContainsFunc(profile.Analyzers, func(p string) bool { return sourceMatches(p, source) }) {
+		return false
+	}
+	return !slices.ContainsFunc(profile.Skip, func(p string) bool { return sourceMatches(p, source) })
+}
+
+// sourceMatches matches a diagnostic source against an analyzer name, a
+// prefix ending in *, or staticcheck for any staticcheck code. Diagnostics
+// without a source come from the compiler.
+func sourceMatches(pattern, source string) bool {
+	if source == "" {
+		source = "compiler"
+	}
+	switch {
+	case pattern == "staticcheck":
+		return staticcheckCode.MatchString(source)
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(source, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == source
+}
+
+// filterByProfile drops the diagnostics profile does not keep and, when
+// dirs is not nil, the files outside those workspace directories.
+func (t *LSPTools) filterByProfile(published map[string][]protocol.Diagnostic, profile projectconfig.AnalysisProfile, dirs map[string]bool) map[string][]protocol.Diagnostic {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	filtered := make(map[string][]protocol.Diagnostic, len(published))
+	for uri, diagnostics := range published {
+		if dirs != nil && !dirs[path.Dir(relativeSlashPath(root, convertURIToPath(uri)))] {
+			continue
+		}
+		kept := []protocol.Diagnostic{}
+		for _, diagnostic := range diagnostics {
+			if profileKeeps(profile, diagnostic.Source) {
+				kept = append(kept, diagnostic)
+			}
+		}
+		filtered[uri] = kept
+	}
+	return filtered
+}
+
+// changedPackageDirs returns the workspace directories, slash-separated and
+// relative to the root, holding Go files changed since HEAD or untracked.
+func (t *LSPTools) changedPackageDirs(ctx context.Context, s *server.MCPServer) (map[string]bool, error) {
+	dirs := make(map[string]bool)
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		result, err := t.runCommand(ctx, s, nil, "git", args...)
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %w", args[0], err)
+		}
+		for line := range strings.Lines(result.Stdout) {
+			if name := strings.TrimSpace(line); strings.HasSuffix(name, ".go") {
+				dirs[path.Dir(name)] = true
+			}
+		}
+	}
+	return dirs, nil
+}
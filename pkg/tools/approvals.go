@@ -0,0 +1,239 @@
+package tools
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	pendingChangesURI       = "resource://pending-changes"
+	pendingChangeURIPrefix  = pendingChangesURI + "/"
+	pendingChangeURIPattern = pendingChangeURIPrefix + "{id}"
+)
+
+// pendingChange is an edit a tool proposed while edits need approval,
+// waiting in the queue until it is approved or rejected.
+type pendingChange struct {
+	ID       string              `json:"id"`
+	Proposed time.Time           `json:"proposed"`
+	Files    []fileChangeSummary `json:"files"`
+	changes  []fileChange
+}
+
+// approvalQueue holds the pending changes, in the order they were
+// proposed, and the token an approval must present.
+type approvalQueue struct {
+	token string
+
+	mu      sync.Mutex
+	next    int
+	pending []*pendingChange
+}
+
+type approvedWriteKey struct{}
+
+// pendingApprovalError is returned by writeFileChanges when the changes were
+// queued for approval instead of written.
+type pendingApprovalError struct {
+	change *pendingChange
+}
+
+func (e *pendingApprovalError) Error() string {
+	return fmt.Sprintf("the edit waits for approval as pending change %s; nothing was written", e.change.ID)
+}
+
+// SetApprovalToken makes writeFileChanges queue every edit as a pending
+// change, written only when approve_pending_change is called with token.
+func (t *LSPTools) SetApprovalToken(token string) {
+	t.approvals = &approvalQueue{token: token}
+}
+
+// enqueue adds changes to the queue.
+func (q *approvalQueue) enqueue(summaries []fileChangeSummary, changes []fileChange) *pendingChange {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.next++
+	change := &pendingChange{ID: fmt.Sprintf("change-%d", q.next), Proposed: time.Now().UTC(), Files: summaries, changes: changes}
+	q.pending = append(q.pending, change)
+	return change
+}
+
+// take removes the pending change id from the queue.
+func (q *approvalQueue) take(id string) (*pendingChange, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	index := slices.IndexFunc(q.pending, func(change *pendingChange) bool { return change.ID == id })
+	if index < 0 {
+		return nil, false
+	}
+	change := q.pending[index]
+	q.pending = slices.Delete(q.pending, index, index+1)
+	return change, true
+}
+
+func (q *approvalQueue) list() []*pendingChange {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.pending)
+}
+
+func (q *approvalQueue) get(id string) (*pendingChange, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	index := slices.IndexFunc(q.pending, func(change *pendingChange) bool { return change.ID == id })
+	if index < 0 {
+		return nil, false
+	}
+	return q.pending[index], true
+}
+
+// queueForApproval queues changes unless ctx carries an approval; it
+// returns the *pendingApprovalError to report when it did.
+func (t *LSPTools) queueForApproval(ctx context.Context, changes []fileChange) error {
+	if t.approvals == nil || ctx.Value(approvedWriteKey{}) != nil {
+		return nil
+	}
+	return &pendingApprovalError{change: t.approvals.enqueue(t.summarizeFileChanges(changes), changes)}
+}
+
+func (t *LSPTools) registerApprovalTools(s *server.MCPServer) {
+	if t.approvals == nil {
+		return
+	}
+	t.registerListPendingChanges(s)
+	t.registerApprovePendingChange(s)
+	t.registerRejectPendingChange(s)
+
+	s.AddResource(mcp.Resource{
+		URI:         pendingChangesURI,
+		Name:        "Pending Changes",
+		Description: "Edits proposed by tools, waiting for approval, with their diffs.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return pendingChangeContents(request.Params.URI, map[string]any{"pending": t.approvals.list()})
+	})
+	s.AddResourceTemplate(mcp.NewResourceTemplate(pendingChangeURIPattern, "Pending Change",
+		mcp.WithTemplateDescription("An edit waiting for approval, with the diff of each file."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		id := strings.TrimPrefix(request.Params.URI, pendingChangeURIPrefix)
+		change, ok := t.approvals.get(id)
+		if !ok {
+			return nil, fmt.Errorf("no pending change %q", id)
+		}
+		return pendingChangeContents(request.Params.URI, change)
+	})
+}
+
+func pendingChangeContents(uri string, value any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)}}, nil
+}
+
+func (t *LSPTools) registerListPendingChanges(s *server.MCPServer) {
+	tool := mcp.NewTool("list_pending_changes",
+		mcp.WithDescription("List the edits waiting for approval: while the server requires approval, every tool that writes files queues its edit here instead, and only approve_pending_change, called with the approval token the user holds, writes it. Each change has its ID, the time it was proposed and the diff of each file; they are also readable as resource://pending-changes"),
+		mcp.WithTitleAnnotation("List Pending Changes"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pending := t.approvals.list()
+		result, err := mcp.NewToolResultJSON(map[string]any{"pending": pending, "count": len(pending)})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) registerApprovePendingChange(s *server.MCPServer) {
+	tool := mcp.NewTool("approve_pending_change",
+		mcp.WithDescription("Write a pending change to disk. The call must carry the approval token given to the user when the server started, so an agent cannot approve its own edits. A change whose files were modified since it was proposed cannot be written and is dropped from the queue"),
+		mcp.WithTitleAnnotation("Approve Pending Change"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID of the pending change, as listed by list_pending_changes"),
+		),
+		mcp.WithString("token",
+			mcp.Required(),
+			mcp.Description("Approval token"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		id, err := getStringArg(args, "id")
+		if err != nil {
+			return nil, err
+		}
+		token := getOptionalStringArg(args, "token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.approvals.token)) != 1 {
+			return mcp.NewToolResultError("the approval token does not match; only the user can approve changes"), nil
+		}
+		change, ok := t.approvals.take(id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("no pending change %s; list_pending_changes lists them", id)), nil
+		}
+		if err := t.writeFileChanges(context.WithValue(ctx, approvedWriteKey{}, true), change.changes); err != nil {
+			return writeFailureResult(fmt.Sprintf("write pending change %s, which was dropped", id), err), nil
+		}
+
+		var files []string
+		for _, file := range change.Files {
+			files = append(files, file.Path)
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{"approved": id, "files": files})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) registerRejectPendingChange(s *server.MCPServer) {
+	tool := mcp.NewTool("reject_pending_change",
+		mcp.WithDescription("Drop a pending change without writing it"),
+		mcp.WithTitleAnnotation("Reject Pending Change"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID of the pending change"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		id, err := getStringArg(args, "id")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := t.approvals.take(id); !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("no pending change %s; list_pending_changes lists them", id)), nil
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{"rejected": id})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
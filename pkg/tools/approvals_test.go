@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestApprovalQueue(t *testing.T) {
+	workspace := t.TempDir()
+	original := "package calc\n\nfunc Double(n int) int { return n * 2 }\n"
+	calcPath := writeWorkspaceFile(t, workspace, "calc/calc.go", original)
+	calcURI := convertPathToURI(calcPath)
+	fakeClient := &fakeLSPClient{rename: &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{calcURI: {{
+		Range:   protocol.Range{Start: protocol.Position{Line: 2, Character: 5}, End: protocol.Position{Line: 2, Character: 11}},
+		NewText: "Twice",
+	}}}}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	tools.SetApprovalToken("secret")
+	server := mcpsrv.NewMCPServer("test", "1.0", mcpsrv.WithResourceCapabilities(true, true))
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	rename := func() map[string]any {
+		t.Helper()
+		result := call("rename_symbol", map[string]any{
+			"file_uri": calcURI, "position": map[string]any{"line": 2, "character": 6}, "new_name": "Twice", "apply": true,
+		})
+		if result.IsError {
+			t.Fatalf("rename_symbol: %v", result)
+		}
+		return structured(result)
+	}
+	readCalc := func() string {
+		data, _ := os.ReadFile(calcPath)
+		return string(data)
+	}
+
+	queued := rename()
+	if queued["status"] != "pending_approval" || queued["id"] != "change-1" || strings.Contains(readCalc(), "Twice") {
+		t.Fatalf("expected the rename to be queued, got %v", queued)
+	}
+	listed := structured(call("list_pending_changes", map[string]any{}))
+	if listed["count"] != float64(1) {
+		t.Fatalf("unexpected pending changes %v", listed)
+	}
+	file := listed["pending"].([]any)[0].(map[string]any)["files"].([]any)[0].(map[string]any)
+	if file["path"] != "calc/calc.go" || !strings.Contains(file["diff"].(string), "+func Twice") {
+		t.Fatalf("unexpected pending file %v", file)
+	}
+	response := server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"resource://pending-changes/change-1"}}`))
+	if data, _ := json.Marshal(response); !strings.Contains(string(data), "calc/calc.go") {
+		t.Fatalf("unexpected resource %s", data)
+	}
+
+	if result := call("approve_pending_change", map[string]any{"id": "change-1", "token": "guess"}); !result.IsError {
+		t.Fatal("expected a wrong token to be refused")
+	}
+	if result := call("approve_pending_change", map[string]any{"id": "change-1", "token": "secret"}); result.IsError {
+		t.Fatalf("approve: %v", result)
+	}
+	if !strings.Contains(readCalc(), "func Twice") {
+		t.Fatalf("the approved change was not written: %q", readCalc())
+	}
+	if result := call("approve_pending_change", map[string]any{"id": "change-1", "token": "secret"}); !result.IsError {
+		t.Fatal("expected an approved change to leave the queue")
+	}
+
+	writeWorkspaceFile(t, workspace, "calc/calc.go", original)
+	if queued := rename(); queued["id"] != "change-2" {
+		t.Fatalf("unexpected queued change %v", queued)
+	}
+	writeWorkspaceFile(t, workspace, "calc/calc.go", original+"\nfunc Half(n int) int { return n / 2 }\n")
+	if result := call("approve_pending_change", map[string]any{"id": "change-2", "token": "secret"}); !result.IsError {
+		t.Fatal("expected a change to a file modified since to be refused")
+	}
+	writeWorkspaceFile(t, workspace, "calc/calc.go", original)
+	if queued := rename(); queued["id"] != "change-3" {
+		t.Fatalf("unexpected queued change %v", queued)
+	}
+	if result := call("reject_pending_change", map[string]any{"id": "change-3"}); result.IsError {
+		t.Fatalf("reject: %v", result)
+	}
+	if listed := structured(call("list_pending_changes", map[string]any{})); listed["count"] != float64(0) || strings.Contains(readCalc(), "Twice") {
+		t.Fatalf("expected the rejected change to be dropped unwritten, got %v", listed)
+	}
+	if result := call("refactor_plan", map[string]any{"steps": []any{map[string]any{"tool": "format_code"}}}); !result.IsError {
+		t.Fatal("expected refactor_plan to be refused while edits need approval")
+	}
+	formatted := structured(call("format_code", map[string]any{"file_uri": calcURI, "apply": true}))
+	if listed := structured(call("list_pending_changes", map[string]any{})); formatted["status"] != nil || formatted["applied"] != false || listed["count"] != float64(0) {
+		t.Fatalf("expected nothing to format to queue no change, got %v and %v", formatted, listed)
+	}
+}
+
+func TestApprovalRefusesSelfWritingCommands(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "Makefile", "build:\n\tgo build ./...\n")
+	uri := convertPathToURI(filepath.Join(workspace, "go.mod"))
+	fakeClient := &fakeLSPClient{
+		lenses:   []protocol.CodeLens{{Command: &protocol.Command{Title: "Run go mod tidy", Command: "gopls.tidy"}}},
+		commands: map[string][]protocol.WorkspaceEdit{"gopls.tidy": nil, "gopls.apply_fix": nil},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	tools.SetApprovalToken("secret")
+	server := mcpsrv.NewMCPServer("test", "1.0", mcpsrv.WithResourceCapabilities(true, true))
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+
+	if result := call("gopls_command", map[string]any{"command": "gopls.tidy"}); !result.IsError {
+		t.Fatal("expected gopls.tidy to be refused while edits need approval")
+	}
+	if result := call("code_lens", map[string]any{"file_uri": uri, "execute": 0}); !result.IsError {
+		t.Fatal("expected the tidy lens to be refused while edits need approval")
+	}
+	if result := call("run_build_target", map[string]any{"target": "build"}); !result.IsError {
+		t.Fatal("expected run_build_target to be refused while edits need approval")
+	}
+	if !slices.Equal(fakeClient.executed, nil) {
+		t.Fatalf("refused commands must not run, executed %v", fakeClient.executed)
+	}
+	if result := call("gopls_command", map[string]any{"command": "gopls.apply_fix"}); result.IsError {
+		t.Fatalf("edit-only commands should still run: %v", result)
+	}
+	if annotations := server.GetTool("gopls_command").Tool.Annotations; annotations.DestructiveHint != nil && !*annotations.DestructiveHint {
+		t.Fatal("gopls_command can rewrite go.mod, go.sum and vendor/ and must not claim to be non-destructive")
+	}
+}
@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const defaultAssemblyLineLimit = 500
+
+var (
+	// assemblyHeader starts the listing of a function in the compiler's -S
+	// output, such as "example.com/app/store.New STEXT size=76 args=0x8".
+	assemblyHeader = regexp.MustCompile(`^(\S+) STEXT(.*?) size=(\d+)`)
+	// assemblyInstruction is an instruction line: pc, decimal pc, position
+	// and instruction.
+	assemblyInstruction = regexp.MustCompile(`^\t(0x[0-9a-f]+) \d+ \(([^)]*)\)\t(.*)$`)
+)
+
+// assemblyFunction is the listing of a compiled function.
+type assemblyFunction struct {
+	Symbol       string `json:"symbol"`
+	Size         int    `json:"size"`
+	Flags        string `json:"flags,omitempty"`
+	Source       string `json:"source,omitempty"`
+	Instructions int    `json:"instructions"`
+	Assembly     string `json:"assembly"`
+	Truncated    bool   `json:"truncated,omitempty"`
+
+	local string
+	lines []string
+}
+
+func (t *LSPTools) registerShowAssembly(s *server.MCPServer) {
+	tool := mcp.NewTool("show_assembly",
+		mcp.WithDescription("Return the assembly the compiler generates for a function (go build -gcflags=-S, as gopls's assembly view shows it): each instruction with its program counter and source line, for inspecting codegen such as bounds checks, spills, calls and inlined code. Closures defined in the function follow it. Compiles the file's package; nothing is written to the workspace"),
+		mcp.WithTitleAnnotation("Show Assembly"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file",
+			mcp.Required(),
+			mcp.Description("Go file URI or path in the function's package"),
+		),
+		mcp.WithString("function",
+			mcp.Required(),
+			mcp.Description("Function or method (Name, pkg.Name, Type.Method or (*Type).Method)"),
+		),
+		mcp.WithString("goarch",
+			mcp.Description("Target architecture, such as arm64 (default the host's)"),
+		),
+		mcp.WithBoolean("include_pseudo",
+			mcp.Description("Keep the PCDATA and FUNCDATA pseudo-instructions (default false)"),
+		),
+		mcp.WithBoolean("include_closures",
+			mcp.Description("Also return the closures defined in the function (default true)"),
+		),
+		mcp.WithNumber("max_lines",
+			mcp.Description(fmt.Sprintf("Maximum instructions returned per function (default %d)", defaultAssemblyLineLimit)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file")
+		if err != nil {
+			return nil, err
+		}
+		name, err := getStringArg(args, "function")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		path := convertURIToPath(fileURI)
+		if !fileExists(path) {
+			return mcp.NewToolResultError(fmt.Sprintf("%s does not exist", path)), nil
+		}
+		maxLines := defaultAssemblyLineLimit
+		if _, ok := args["max_lines"]; ok {
+			if maxLines, err = getIntFromObject(args, "max_lines"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if maxLines < 1 {
+				return mcp.NewToolResultError("max_lines must be at least 1"), nil
+			}
+		}
+		includeClosures := true
+		if _, ok := args["include_closures"]; ok {
+			includeClosures = getOptionalBoolArg(args, "include_closures")
+		}
+		includePseudo := getOptionalBoolArg(args, "include_pseudo")
+
+		spec := commandSpec{name: "go", args: []string{"build", "-gcflags=-S", "-o=" + os.DevNull, "."}, dir: filepath.Dir(path)}
+		goarch := getOptionalStringArg(args, "goarch")
+		if goarch != "" {
+			spec.env = []string{"GOARCH=" + goarch}
+		}
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Compiling "+filepath.Base(spec.dir)+" with -gcflags=-S")
+		// The compiler output is replayed from the build cache, so unchanged
+		// packages are listed without recompiling. It is not streamed: a
+		// package listing runs to thousands of lines.
+		result, err := t.runCommandSpec(ctx, s, nil, spec)
+		if err != nil {
+			return t.commandFailureResult("go build -gcflags=-S", result, err)
+		}
+
+		root, err := filepath.Abs(t.workspaceDir)
+		if err != nil {
+			root = t.workspaceDir
+		}
+		functions := parseAssembly(result.Stderr, root, includePseudo)
+		want := normalizeAssemblyName(name, filepath.Base(spec.dir))
+		var selected []assemblyFunction
+		for _, fn := range functions {
+			local := normalizeAssemblyName(fn.local, "")
+			if local == want || includeClosures && strings.HasPrefix(local, want+".func") {
+				selected = append(selected, fn)
+			}
+		}
+		if len(selected) == 0 {
+			var names []string
+			for _, fn := range functions {
+				if !slices.Contains(names, fn.local) {
+					names = append(names, fn.local)
+				}
+			}
+			if len(names) > 20 {
+				names = append(names[:20], "...")
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("no compiled function %s in the package; it may be inlined everywhere and never emitted, or unused and generic. Compiled: %s", name, strings.Join(names, ", "))), nil
+		}
+		for i := range selected {
+			fn := &selected[i]
+			fn.Instructions = len(fn.lines)
+			if len(fn.lines) > maxLines {
+				fn.lines, fn.Truncated = fn.lines[:maxLines], true
+			}
+			fn.Assembly = strings.Join(fn.lines, "\n")
+		}
+
+		payload := map[string]any{
+			"function":  name,
+			"functions": selected,
+		}
+		if goarch != "" {
+			payload["goarch"] = goarch
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// parseAssembly splits the compiler's -S output into functions, with
+// positions relative to root and without the hex dumps and relocations.
+func parseAssembly(output, root string, includePseudo bool) []assemblyFunction {
+	var functions []assemblyFunction
+	var current *assemblyFunction
+	for line := range strings.Lines(output) {
+		line = strings.TrimRight(line, "\n")
+		if match := assemblyHeader.FindStringSubmatch(line); match != nil {
+			size, _ := strconv.Atoi(match[3])
+			functions = append(functions, assemblyFunction{
+				Symbol: match[1],
+				Size:   size,
+				Flags:  strings.TrimSpace(match[2]),
+				local:  localSymbolName(match[1]),
+			})
+			current = &functions[len(functions)-1]
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			// Data symbols and package headers end the function.
+			current = nil
+			continue
+		}
+		match := assemblyInstruction.FindStringSubmatch(line)
+		if current == nil || match == nil {
+			continue
+		}
+		instruction := strings.ReplaceAll(match[3], "\t", " ")
+		if !includePseudo && (strings.HasPrefix(instruction, "PCDATA ") || strings.HasPrefix(instruction, "FUNCDATA ")) {
+			continue
+		}
+		position := match[2]
+		if filepath.IsAbs(position) {
+			if rel := relativeSlashPath(root, position); !strings.HasPrefix(rel, "../") {
+				position = rel
+			}
+		}
+		if current.Source == "" {
+			current.Source = position
+		}
+		current.lines = append(current.lines, fmt.Sprintf("%s (%s) %s", match[1], position, instruction))
+	}
+	return functions
+}
+
+// localSymbolName drops the package path of a symbol, keeping the type
+// arguments of instantiations, whose names may contain slashes.
+func localSymbolName(symbol string) string {
+	prefix, _, _ := strings.Cut(symbol, "[")
+	start := strings.LastIndex(prefix, "/") + 1
+	dot := strings.Index(prefix[start:], ".")
+	if dot < 0 {
+		return symbol
+	}
+	return symbol[start+dot+1:]
+}
+
+// normalizeAssemblyName reduces a function name to Type.Method form without
+// type arguments, dropping a leading package qualifier named pkg.
+func normalizeAssemblyName(name, pkg string) string {
+	var sb strings.Builder
+	depth := 0
+	for _, r := range name {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case depth == 0 && r != '(' && r != ')' && r != '*':
+			sb.WriteRune(r)
+		}
+	}
+	name = sb.String()
+	name = name[strings.LastIndex(name, "/")+1:]
+	if qualifier, rest, ok := strings.Cut(name, "."); ok && pkg != "" && qualifier == pkg {
+		name = rest
+	}
+	return name
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestShowAssembly(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+type Item struct{ n int }
+
+//go:noinline
+func (i *Item) Adder(k int) func() int {
+	return func() int { return i.n + k }
+}
+
+//go:noinline
+func Max[T int | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+var _ = Max(1, 2)
+`)
+
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("show_assembly").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "show_assembly", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("show_assembly: %v", err)
+		}
+		return result
+	}
+	file := filepath.Join(workspace, "store", "store.go")
+
+	functions := structured(call(map[string]any{"file": file, "function": "store.Item.Adder"}))["functions"].([]any)
+	if len(functions) != 2 {
+		t.Fatalf("expected the method and its closure, got %v", functions)
+	}
+	method := functions[0].(map[string]any)
+	assembly := method["assembly"].(string)
+	if method["symbol"] != "example.com/app/store.(*Item).Adder" || method["source"] != "store/store.go:6" || !strings.Contains(assembly, "(store/store.go:7)") {
+		t.Fatalf("unexpected listing %v", method)
+	}
+	if strings.Contains(assembly, "PCDATA") || !strings.Contains(functions[1].(map[string]any)["symbol"].(string), "Adder.func1") {
+		t.Fatalf("expected no pseudo-instructions and the closure second, got %v", functions)
+	}
+
+	only := structured(call(map[string]any{"file": file, "function": "(*Item).Adder", "include_closures": false, "max_lines": 2}))["functions"].([]any)
+	if len(only) != 1 || only[0].(map[string]any)["truncated"] != true || strings.Count(only[0].(map[string]any)["assembly"].(string), "\n") != 1 {
+		t.Fatalf("expected the method alone, truncated to two lines, got %v", only)
+	}
+	if generic := structured(call(map[string]any{"file": file, "function": "Max"}))["functions"].([]any); len(generic) == 0 {
+		t.Fatal("expected the instantiations of Max")
+	}
+	if missing := call(map[string]any{"file": file, "function": "Min"}); !missing.IsError || !strings.Contains(missing.Content[0].(mcp.TextContent).Text, "Adder") {
+		t.Fatalf("expected an error listing the compiled functions, got %v", missing.Content)
+	}
+}
@@ -0,0 +1,15 @@
+package tools
+
+import "github.com/mark3labs/mcp-go/server"
+
+// The audit tools look for risky patterns across the workspace (inconsistent
+// logging, hidden global state, missing timeouts). Like the inventories they
+// work from syntax, and any rewrite they offer is previewed as a diff unless
+// the caller asks to apply it.
+func (t *LSPTools) registerAuditTools(s *server.MCPServer) {
+	t.registerAuditLogging(s)
+	t.registerAuditGlobals(s)
+	t.registerAuditTimeouts(s)
+	t.registerAuditPanics(s)
+	t.registerAuditInterfaces(s)
+}
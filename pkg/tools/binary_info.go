@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/version"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/mod/modfile"
+)
+
+// binaryModule is a module recorded in a binary's build information, with
+// the module that replaced it if any.
+type binaryModule struct {
+	Path        string        `json:"path"`
+	Version     string        `json:"version"`
+	Sum         string        `json:"sum,omitempty"`
+	Replacement *binaryModule `json:"replacement,omitempty"`
+}
+
+// binaryInfo is the output of go version -m.
+type binaryInfo struct {
+	GoVersion string            `json:"go_version"`
+	Package   string            `json:"package"`
+	Main      binaryModule      `json:"main"`
+	Deps      []binaryModule    `json:"deps"`
+	Settings  map[string]string `json:"settings"`
+}
+
+// moduleDrift is a module whose version in the binary differs from the one
+// the workspace go.mod selects.
+type moduleDrift struct {
+	Path      string `json:"path"`
+	Binary    string `json:"binary,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	Problem   string `json:"problem"`
+}
+
+func (t *LSPTools) registerInspectBinary(s *server.MCPServer) {
+	tool := mcp.NewTool("inspect_binary",
+		mcp.WithDescription("Read the build information of a built Go binary with go version -m (Go version, main package, module versions, VCS revision and build settings) and compare it with the workspace: modules whose version or replacement differs from go.mod, a different main module, a revision other than the current HEAD, and builds from a modified tree. Use it to tell whether a deployed binary matches the source"),
+		mcp.WithTitleAnnotation("Inspect Binary"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("binary",
+			mcp.Required(),
+			mcp.Description("Path of the binary, absolute or relative to the workspace"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		binary, err := getStringArg(args, "binary")
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(binary) {
+			binary = filepath.Join(t.workspaceDir, binary)
+		}
+		if _, err := os.Stat(binary); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", binary, err)), nil
+		}
+
+		output, err := t.runCommand(ctx, s, nil, "go", "version", "-m", binary)
+		if err != nil {
+			return t.commandFailureResult("go version -m", output, err)
+		}
+		info, err := parseGoVersionM(output.Stdout)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s: %v", binary, err)), nil
+		}
+
+		payload := map[string]any{
+			"binary": relativeSlashPath(t.workspaceDir, binary),
+			"info":   info,
+		}
+		findings := []string{}
+		data, err := os.ReadFile(filepath.Join(t.workspaceDir, "go.mod"))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("unable to read go.mod: %v", err)), nil
+		}
+		mod, err := modfile.Parse("go.mod", data, nil)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("parse go.mod: %v", err)), nil
+		}
+		drift := compareBinaryModules(info, mod)
+		payload["drift"] = drift
+		if len(drift) > 0 {
+			findings = append(findings, fmt.Sprintf("%d modules differ from go.mod", len(drift)))
+		}
+		if mod.Module != nil && info.Main.Path != mod.Module.Mod.Path {
+			findings = append(findings, fmt.Sprintf("built from module %s, not the workspace module %s", info.Main.Path, mod.Module.Mod.Path))
+		}
+		toolchain, _, _ := strings.Cut(info.GoVersion, " ")
+		if mod.Go != nil && version.IsValid(toolchain) && version.Compare(toolchain, "go"+mod.Go.Version) < 0 {
+			findings = append(findings, fmt.Sprintf("built with %s, older than the go %s go.mod requires", info.GoVersion, mod.Go.Version))
+		}
+
+		if revision := info.Settings["vcs.revision"]; revision != "" {
+			head, err := t.runCommand(ctx, s, nil, "git", "rev-parse", "HEAD")
+			if current := strings.TrimSpace(head.Stdout); err == nil && current != "" {
+				payload["head"] = current
+				if current != revision {
+					findings = append(findings, fmt.Sprintf("built from revision %s, the workspace is at %s", revision, current))
+				}
+			}
+		} else {
+			findings = append(findings, "no VCS information; the binary was built outside a repository or with -buildvcs=false")
+		}
+		if info.Settings["vcs.modified"] == "true" {
+			findings = append(findings, "built from a tree with uncommitted changes")
+		}
+
+		payload["matches"] = len(findings) == 0
+		payload["findings"] = findings
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// parseGoVersionM parses the output of go version -m for one binary:
+//
+//	/path/app: go1.22.1
+//		path	example.com/app/cmd/app
+//		mod	example.com/app	(devel)
+//		dep	golang.org/x/mod	v0.14.0	h1:...
+//		=>	../mod	(devel)
+//		build	vcs.revision=...
+func parseGoVersionM(output string) (binaryInfo, error) {
+	info := binaryInfo{Deps: []binaryModule{}, Settings: make(map[string]string)}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) == 0 || !strings.Contains(lines[0], ": go") {
+		return info, fmt.Errorf("not a Go binary")
+	}
+	info.GoVersion = lines[0][strings.LastIndex(lines[0], ": go")+2:]
+	if len(lines) == 1 {
+		return info, fmt.Errorf("no module information; the binary was not built in module mode")
+	}
+	last := &info.Main
+	for _, line := range lines[1:] {
+		fields := strings.Split(strings.TrimPrefix(line, "\t"), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		module := func() binaryModule {
+			m := binaryModule{Path: fields[1]}
+			if len(fields) > 2 {
+				m.Version = fields[2]
+			}
+			if len(fields) > 3 {
+				m.Sum = fields[3]
+			}
+			return m
+		}
+		switch fields[0] {
+		case "path":
+			info.Package = fields[1]
+		case "mod":
+			info.Main = module()
+			last = &info.Main
+		case "dep":
+			info.Deps = append(info.Deps, module())
+			last = &info.Deps[len(info.Deps)-1]
+		case "=>":
+			replacement := module()
+			last.Replacement = &replacement
+		case "build":
+			key, value, _ := strings.Cut(fields[1], "=")
+			info.Settings[key] = value
+		}
+	}
+	return info, nil
+}
+
+// compareBinaryModules reports the modules of the binary whose version, or
+// replacement, is not what go.mod selects. Modules of go.mod missing from
+// the binary are not reported: a binary only records the modules it links.
+func compareBinaryModules(info binaryInfo, mod *modfile.File) []moduleDrift {
+	required := make(map[string]string, len(mod.Require))
+	for _, req := range mod.Require {
+		required[req.Mod.Path] = req.Mod.Version
+	}
+	replaced := make(map[string]*modfile.Replace, len(mod.Replace))
+	for _, rep := range mod.Replace {
+		if _, ok := replaced[rep.Old.Path]; !ok || rep.Old.Version == required[rep.Old.Path] {
+			replaced[rep.Old.Path] = rep
+		}
+	}
+
+	drift := []moduleDrift{}
+	for _, dep := range info.Deps {
+		want, ok := required[dep.Path]
+		if !ok {
+			drift = append(drift, moduleDrift{Path: dep.Path, Binary: dep.Version, Problem: "not required by go.mod"})
+			continue
+		}
+		if dep.Version != want {
+			drift = append(drift, moduleDrift{Path: dep.Path, Binary: dep.Version, Workspace: want, Problem: "version differs"})
+		}
+		rep := replaced[dep.Path]
+		if rep != nil && rep.Old.Version != "" && rep.Old.Version != want {
+			rep = nil
+		}
+		switch {
+		case rep == nil && dep.Replacement != nil:
+			drift = append(drift, moduleDrift{Path: dep.Path, Binary: describeBinaryModule(*dep.Replacement), Problem: "replaced in the binary but not in go.mod"})
+		case rep != nil && dep.Replacement == nil:
+			drift = append(drift, moduleDrift{Path: dep.Path, Workspace: describeReplacement(rep), Problem: "replaced in go.mod but not in the binary"})
+		case rep != nil && (dep.Replacement.Path != rep.New.Path || dep.Replacement.Version != rep.New.Version && rep.New.Version != ""):
+			drift = append(drift, moduleDrift{Path: dep.Path, Binary: describeBinaryModule(*dep.Replacement), Workspace: describeReplacement(rep), Problem: "replacement differs"})
+		}
+	}
+	return drift
+}
+
+func describeBinaryModule(m binaryModule) string {
+	if m.Version == "" || m.Version == "(devel)" {
+		return m.Path
+	}
+	return m.Path + "@" + m.Version
+}
+
+func describeReplacement(rep *modfile.Replace) string {
+	if rep.New.Version == "" {
+		return rep.New.Path
+	}
+	return rep.New.Path + "@" + rep.New.Version
+}
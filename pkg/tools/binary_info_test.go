@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+const goVersionMOutput = `/work/bin/app: go1.22.1
+	path	example.com/app/cmd/app
+	mod	example.com/app	v1.4.0	h1:abc=
+	dep	github.com/google/uuid	v1.5.0	h1:uuid=
+	dep	golang.org/x/mod	v0.14.0	h1:mod=
+	=>	../mod	(devel)	
+	dep	golang.org/x/sync	v0.6.0	h1:sync=
+	build	-ldflags=-X main.version=1.4.0
+	build	GOOS=linux
+	build	vcs.revision=0123abc
+	build	vcs.modified=true
+`
+
+func TestParseGoVersionM(t *testing.T) {
+	info, err := parseGoVersionM(goVersionMOutput)
+	if err != nil {
+		t.Fatalf("parseGoVersionM: %v", err)
+	}
+	if info.GoVersion != "go1.22.1" || info.Package != "example.com/app/cmd/app" || info.Main.Version != "v1.4.0" {
+		t.Fatalf("unexpected header %+v", info)
+	}
+	if len(info.Deps) != 3 || info.Deps[1].Replacement == nil || info.Deps[1].Replacement.Path != "../mod" || info.Deps[0].Sum != "h1:uuid=" {
+		t.Fatalf("unexpected deps %+v", info.Deps)
+	}
+	if info.Settings["-ldflags"] != "-X main.version=1.4.0" || info.Settings["vcs.modified"] != "true" {
+		t.Fatalf("unexpected settings %v", info.Settings)
+	}
+	if _, err := parseGoVersionM("/work/notes.txt: could not read Go build info from /work/notes.txt: unrecognized file format\n"); err == nil {
+		t.Fatal("expected an error for a file that is not a Go binary")
+	}
+}
+
+func TestInspectBinary(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", `module example.com/app
+
+go 1.23
+
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/mod v0.14.0
+	golang.org/x/sync v0.6.0
+)
+
+replace golang.org/x/sync => golang.org/x/sync v0.7.0
+`)
+	writeWorkspaceFile(t, workspace, "bin/app", "binary")
+	binary := filepath.Join(workspace, "bin", "app")
+
+	runner := &fakeCommandRunner{results: map[string]commandResult{
+		"go version -m " + binary: {Stdout: goVersionMOutput},
+		"git rev-parse HEAD":      {Stdout: "4567def\n"},
+	}}
+	tools := NewLSPTools(nil, workspace)
+	tools.commandRunner = runner.Run
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	result, err := server.GetTool("inspect_binary").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "inspect_binary", Arguments: map[string]any{"binary": "bin/app"}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("inspect_binary: %v %#v", err, result)
+	}
+	content := structured(result)
+
+	var drift []string
+	for _, entry := range content["drift"].([]any) {
+		entry := entry.(map[string]any)
+		drift = append(drift, entry["path"].(string)+": "+entry["problem"].(string))
+	}
+	want := []string{
+		"github.com/google/uuid: version differs",
+		"golang.org/x/mod: replaced in the binary but not in go.mod",
+		"golang.org/x/sync: replaced in go.mod but not in the binary",
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Fatalf("drift = %v\nwant %v", drift, want)
+	}
+	findings := content["findings"].([]any)
+	if content["matches"] != false || content["head"] != "4567def" || len(findings) != 4 {
+		t.Fatalf("unexpected findings %#v", content)
+	}
+	if findings[1] != "built with go1.22.1, older than the go 1.23 go.mod requires" || findings[2] != "built from revision 0123abc, the workspace is at 4567def" {
+		t.Fatalf("unexpected findings %v", findings)
+	}
+}
@@ -0,0 +1,372 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// bookmarksFile keeps the bookmarks saved for the workspace, at its
+	// root.
+	bookmarksFile = ".mcp-gopls-bookmarks.json"
+
+	bookmarksURI       = "resource://bookmarks"
+	bookmarksReportURI = "resource://bookmarks/report"
+)
+
+// bookmark is a location the agent tagged with a note.
+type bookmark struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column,omitempty"`
+	Note    string    `json:"note"`
+	Tags    []string  `json:"tags,omitempty"`
+	Source  string    `json:"source,omitempty"`
+	Created time.Time `json:"created"`
+	// Workspace is set on the bookmarks saved for the workspace, which
+	// later sessions list too.
+	Workspace bool `json:"workspace,omitempty"`
+}
+
+// bookmarkStore holds the bookmarks of the session, with those saved for
+// the workspace loaded on first use.
+type bookmarkStore struct {
+	mu        sync.Mutex
+	loaded    bool
+	next      int
+	bookmarks []bookmark
+}
+
+// load reads the workspace bookmarks of file the first time it is called.
+func (b *bookmarkStore) load(file string) error {
+	if b.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("read %s: %w", bookmarksFile, err)
+	}
+	var saved []bookmark
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return fmt.Errorf("parse %s: %w", bookmarksFile, err)
+		}
+	}
+	for _, mark := range saved {
+		mark.Workspace = true
+		if n, err := strconv.Atoi(strings.TrimPrefix(mark.ID, "bookmark-")); err == nil && n > b.next {
+			b.next = n
+		}
+		b.bookmarks = append(b.bookmarks, mark)
+	}
+	b.loaded = true
+	return nil
+}
+
+// save writes the workspace bookmarks to file, removing it when there are
+// none left.
+func (b *bookmarkStore) save(file string) error {
+	saved := []bookmark{}
+	for _, mark := range b.bookmarks {
+		if mark.Workspace {
+			mark.Workspace = false
+			saved = append(saved, mark)
+		}
+	}
+	if len(saved) == 0 {
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("save bookmarks: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("save bookmarks: %w", err)
+	}
+	return nil
+}
+
+// bookmarksPath is the workspace bookmarks file. In isolated edits mode it
+// is kept in the checkout rather than in the copy the tools edit.
+func (t *LSPTools) bookmarksPath() string {
+	if t.scratch != nil {
+		return filepath.Join(t.scratch.Original(), bookmarksFile)
+	}
+	return filepath.Join(t.workspaceDir, bookmarksFile)
+}
+
+// listBookmarks returns the bookmarks carrying tag under the path prefix,
+// by path and line.
+func (t *LSPTools) listBookmarks(tag, prefix string) ([]bookmark, error) {
+	t.bookmarks.mu.Lock()
+	defer t.bookmarks.mu.Unlock()
+	if err := t.bookmarks.load(t.bookmarksPath()); err != nil {
+		return nil, err
+	}
+	marks := []bookmark{}
+	for _, mark := range t.bookmarks.bookmarks {
+		if strings.HasPrefix(mark.Path, prefix) && (tag == "" || slices.Contains(mark.Tags, tag)) {
+			marks = append(marks, mark)
+		}
+	}
+	slices.SortStableFunc(marks, func(a, b bookmark) int {
+		if a.Path != b.Path {
+			return strings.Compare(a.Path, b.Path)
+		}
+		return a.Line - b.Line
+	})
+	return marks, nil
+}
+
+// bookmarksReport renders bookmarks as a markdown report, by file.
+func bookmarksReport(marks []bookmark) string {
+	var sb strings.Builder
+	sb.WriteString("# Bookmarks\n")
+	if len(marks) == 0 {
+		sb.WriteString("\nNo bookmarks.\n")
+		return sb.String()
+	}
+	path := ""
+	for _, mark := range marks {
+		if mark.Path != path {
+			path = mark.Path
+			fmt.Fprintf(&sb, "\n## %s\n\n", path)
+		}
+		fmt.Fprintf(&sb, "- line %d: %s", mark.Line, mark.Note)
+		if len(mark.Tags) > 0 {
+			sb.WriteString(" (" + strings.Join(mark.Tags, ", ") + ")")
+		}
+		sb.WriteString("\n")
+		if mark.Source != "" {
+			fmt.Fprintf(&sb, "  `%s`\n", mark.Source)
+		}
+	}
+	return sb.String()
+}
+
+func (t *LSPTools) registerBookmarkTools(s *server.MCPServer) {
+	t.registerAddBookmark(s)
+	t.registerListBookmarks(s)
+	t.registerRemoveBookmark(s)
+
+	s.AddResource(mcp.Resource{
+		URI:         bookmarksURI,
+		Name:        "Bookmarks",
+		Description: "Locations tagged with notes by the session, and those saved for the workspace.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		marks, err := t.listBookmarks("", "")
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(marks, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: bookmarksURI, MIMEType: "application/json", Text: string(data)}}, nil
+	})
+	s.AddResource(mcp.Resource{
+		URI:         bookmarksReportURI,
+		Name:        "Bookmarks Report",
+		Description: "The bookmarks as a markdown report, by file.",
+		MIMEType:    "text/markdown",
+	}, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		marks, err := t.listBookmarks("", "")
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: bookmarksReportURI, MIMEType: "text/markdown", Text: bookmarksReport(marks)}}, nil
+	})
+}
+
+func (t *LSPTools) registerAddBookmark(s *server.MCPServer) {
+	tool := mcp.NewTool("add_bookmark",
+		mcp.WithDescription("Tag a location with a note while exploring (\"probable bug here\", \"needs test\"), to come back to it or report it at the end of the run. Bookmarks last for the session; with workspace they are also saved in .mcp-gopls-bookmarks.json at the workspace root, where later sessions find them. list_bookmarks lists them, and resource://bookmarks/report renders them as markdown"),
+		mcp.WithTitleAnnotation("Add Bookmark"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position to bookmark"),
+		),
+		mcp.WithString("note",
+			mcp.Required(),
+			mcp.Description("What is worth noting at this location"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Tags to filter bookmarks by, such as [\"bug\", \"needs-test\"]"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("workspace",
+			mcp.Description("Also save the bookmark for the workspace, for later sessions (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return nil, err
+		}
+		note := strings.TrimSpace(getOptionalStringArg(args, "note"))
+		if note == "" {
+			return mcp.NewToolResultError("note must not be empty"), nil
+		}
+		path := convertURIToPath(fileURI)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("read %s: %v", fileURI, err)), nil
+		}
+		lines := bytes.Split(content, []byte("\n"))
+		if line < 0 || line >= len(lines) {
+			return mcp.NewToolResultError(fmt.Sprintf("line %d is outside the file", line)), nil
+		}
+		mark := bookmark{
+			Path:      relativeSlashPath(t.workspaceDir, path),
+			Line:      line + 1,
+			Column:    character + 1,
+			Note:      note,
+			Tags:      getOptionalStringListArg(args, "tags"),
+			Source:    strings.TrimSpace(string(lines[line])),
+			Created:   time.Now().UTC(),
+			Workspace: getOptionalBoolArg(args, "workspace"),
+		}
+
+		t.bookmarks.mu.Lock()
+		defer t.bookmarks.mu.Unlock()
+		file := t.bookmarksPath()
+		if err := t.bookmarks.load(file); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		t.bookmarks.next++
+		mark.ID = fmt.Sprintf("bookmark-%d", t.bookmarks.next)
+		t.bookmarks.bookmarks = append(t.bookmarks.bookmarks, mark)
+		if mark.Workspace {
+			if err := t.bookmarks.save(file); err != nil {
+				t.bookmarks.bookmarks = t.bookmarks.bookmarks[:len(t.bookmarks.bookmarks)-1]
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		result, err := mcp.NewToolResultJSON(mark)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) registerListBookmarks(s *server.MCPServer) {
+	tool := mcp.NewTool("list_bookmarks",
+		mcp.WithDescription("List the bookmarks of the session and those saved for the workspace, by file and line, each with its note, tags and source line. format markdown returns them as a report to hand over at the end of the run"),
+		mcp.WithTitleAnnotation("List Bookmarks"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("tag",
+			mcp.Description("Only list bookmarks with this tag"),
+		),
+		mcp.WithString("path",
+			mcp.Description("Only list bookmarks whose path, relative to the workspace, starts with this prefix"),
+		),
+		mcp.WithString("format",
+			mcp.Description("json (default) or markdown"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		format := getOptionalStringArg(args, "format")
+		if format != "" && format != "json" && format != "markdown" {
+			return mcp.NewToolResultError("format must be json or markdown"), nil
+		}
+		marks, err := t.listBookmarks(getOptionalStringArg(args, "tag"), getOptionalStringArg(args, "path"))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if format == "markdown" {
+			return mcp.NewToolResultText(bookmarksReport(marks)), nil
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{"bookmarks": marks, "count": len(marks)})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) registerRemoveBookmark(s *server.MCPServer) {
+	tool := mcp.NewTool("remove_bookmark",
+		mcp.WithDescription("Remove a bookmark once it is dealt with, from the workspace bookmarks file too when it was saved there"),
+		mcp.WithTitleAnnotation("Remove Bookmark"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID of the bookmark, as listed by list_bookmarks"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		id, err := getStringArg(args, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		t.bookmarks.mu.Lock()
+		defer t.bookmarks.mu.Unlock()
+		file := t.bookmarksPath()
+		if err := t.bookmarks.load(file); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		index := slices.IndexFunc(t.bookmarks.bookmarks, func(mark bookmark) bool { return mark.ID == id })
+		if index < 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("no bookmark %s; list_bookmarks lists them", id)), nil
+		}
+		removed := t.bookmarks.bookmarks[index]
+		t.bookmarks.bookmarks = slices.Delete(t.bookmarks.bookmarks, index, index+1)
+		if removed.Workspace {
+			if err := t.bookmarks.save(file); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{"removed": id})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestBookmarks(t *testing.T) {
+	workspace := t.TempDir()
+	calcURI := convertPathToURI(writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nfunc Div(a, b int) int { return a / b }\n"))
+	mainURI := convertPathToURI(writeWorkspaceFile(t, workspace, "main.go", "package main\n\nfunc main() {}\n"))
+	session := func() (*mcpsrv.MCPServer, func(string, map[string]any) *mcp.CallToolResult) {
+		server := mcpsrv.NewMCPServer("test", "1.0", mcpsrv.WithResourceCapabilities(true, true))
+		NewLSPTools(nil, workspace).Register(server)
+		return server, func(name string, args map[string]any) *mcp.CallToolResult {
+			t.Helper()
+			result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Name: name, Arguments: args},
+			})
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			return result
+		}
+	}
+	server, call := session()
+
+	added := structured(call("add_bookmark", map[string]any{
+		"file_uri": calcURI, "position": map[string]any{"line": 2, "character": 33},
+		"note": "probable bug here: b may be zero", "tags": []any{"bug"}, "workspace": true,
+	}))
+	if added["id"] != "bookmark-1" || added["path"] != "calc/calc.go" || added["line"] != float64(3) || added["source"] != "func Div(a, b int) int { return a / b }" {
+		t.Fatalf("unexpected bookmark %v", added)
+	}
+	call("add_bookmark", map[string]any{"file_uri": mainURI, "position": map[string]any{"line": 2, "character": 5}, "note": "needs test", "tags": []any{"test"}})
+	if result := call("add_bookmark", map[string]any{"file_uri": mainURI, "position": map[string]any{"line": 9, "character": 0}, "note": "x"}); !result.IsError {
+		t.Fatal("expected a line outside the file to be refused")
+	}
+
+	listed := structured(call("list_bookmarks", map[string]any{}))
+	marks := listed["bookmarks"].([]any)
+	if listed["count"] != float64(2) || marks[0].(map[string]any)["path"] != "calc/calc.go" || marks[0].(map[string]any)["workspace"] != true {
+		t.Fatalf("unexpected bookmarks %v", listed)
+	}
+	if tagged := structured(call("list_bookmarks", map[string]any{"tag": "test"})); tagged["count"] != float64(1) {
+		t.Fatalf("unexpected tagged bookmarks %v", tagged)
+	}
+	report := call("list_bookmarks", map[string]any{"format": "markdown"}).Content[0].(mcp.TextContent).Text
+	if !strings.Contains(report, "## calc/calc.go\n\n- line 3: probable bug here: b may be zero (bug)") || !strings.Contains(report, "## main.go") {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+	response := server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"resource://bookmarks/report"}}`))
+	if data, _ := json.Marshal(response); !strings.Contains(string(data), "needs test") {
+		t.Fatalf("unexpected resource %s", data)
+	}
+
+	// A later session only finds the bookmark saved for the workspace.
+	_, call = session()
+	listed = structured(call("list_bookmarks", map[string]any{}))
+	if listed["count"] != float64(1) || listed["bookmarks"].([]any)[0].(map[string]any)["id"] != "bookmark-1" {
+		t.Fatalf("unexpected bookmarks in a new session %v", listed)
+	}
+	if next := structured(call("add_bookmark", map[string]any{"file_uri": mainURI, "position": map[string]any{"line": 0, "character": 0}, "note": "entry point"})); next["id"] != "bookmark-2" {
+		t.Fatalf("expected IDs to continue after the saved ones, got %v", next)
+	}
+	if result := call("remove_bookmark", map[string]any{"id": "bookmark-1"}); result.IsError {
+		t.Fatalf("remove_bookmark: %v", result)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, bookmarksFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected the bookmarks file to be removed with its last bookmark, got %v", err)
+	}
+}
@@ -0,0 +1,549 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	runnerMake = "make"
+	runnerTask = "task"
+	runnerMage = "mage"
+
+	defaultBuildTargetTimeout = 10 * time.Minute
+)
+
+var lookupBuildToolBinary = exec.LookPath
+
+var (
+	makefileNames = []string{"GNUmakefile", "makefile", "Makefile"}
+	taskfileNames = []string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml", "Taskfile.dist.yml", "Taskfile.dist.yaml"}
+
+	makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./-]*(?:[ \t]+[A-Za-z0-9][A-Za-z0-9_./-]*)*)[ \t]*:(.*)$`)
+)
+
+type buildTarget struct {
+	Name        string `json:"name"`
+	Runner      string `json:"runner"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Description string `json:"description,omitempty"`
+}
+
+func (t *LSPTools) registerBuildTargetTools(s *server.MCPServer) {
+	t.registerListBuildTargets(s)
+	t.registerRunBuildTarget(s)
+}
+
+func (t *LSPTools) registerListBuildTargets(s *server.MCPServer) {
+	tool := mcp.NewTool("list_build_targets",
+		mcp.WithDescription("List Makefile, Taskfile and mage targets defined at the workspace root"),
+		mcp.WithTitleAnnotation("List Build Targets"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		targets, err := discoverBuildTargets(t.workspaceDir)
+		if err != nil {
+			return nil, err
+		}
+
+		runners := make(map[string]bool)
+		for _, target := range targets {
+			if _, seen := runners[target.Runner]; seen {
+				continue
+			}
+			_, err := determineBuildRunnerCommand(target.Runner)
+			runners[target.Runner] = err == nil
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"targets":           targets,
+			"runners_available": runners,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) registerRunBuildTarget(s *server.MCPServer) {
+	tool := mcp.NewTool("run_build_target",
+		mcp.WithDescription(fmt.Sprintf("Run a target discovered by list_build_targets (make, task or mage) in the workspace, with the runner binary found in PATH; stdout and stderr are truncated past %d bytes", maxPackageOutput)),
+		mcp.WithTitleAnnotation("Run Build Target"),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("target",
+			mcp.Required(),
+			mcp.Description("Target name as returned by list_build_targets"),
+		),
+		mcp.WithString("runner",
+			mcp.Description("Runner owning the target when the name is ambiguous"),
+			mcp.Enum(runnerMake, runnerTask, runnerMage),
+		),
+		mcp.WithString("timeout",
+			mcp.Description("Maximum run time (e.g. 90s, 5m). Defaults to 10m"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := getStringArg(args, "target")
+		if err != nil {
+			return nil, err
+		}
+		if t.approvals != nil {
+			return mcp.NewToolResultError("build targets run commands that may change files, bypassing the approval queue; they cannot run while edits wait for approval"), nil
+		}
+		if err := t.checkCommandContract("run_build_target"); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		runner := getOptionalStringArg(args, "runner")
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultBuildTargetTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		targets, err := discoverBuildTargets(t.workspaceDir)
+		if err != nil {
+			return nil, err
+		}
+		target, err := selectBuildTarget(targets, name, runner)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		cmd, err := determineBuildRunnerCommand(target.Runner)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Running %s %s", target.Runner, target.Name))
+
+		runCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result, err := t.runCommand(runCtx, s, token, cmd, target.Name)
+		if err != nil {
+			if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+				return mcp.NewToolResultError(fmt.Sprintf("%s %s timed out after %s", target.Runner, target.Name, timeout)), nil
+			}
+			return t.commandFailureResult(fmt.Sprintf("%s %s", target.Runner, target.Name), result, err)
+		}
+
+		// Targets can print without bound; keep what the other commands keep.
+		result.Stdout = truncateOutput(result.Stdout)
+		result.Stderr = truncateOutput(result.Stderr)
+		toolResult, err := mcp.NewToolResultJSON(map[string]any{
+			"target": target,
+			"result": result,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+func selectBuildTarget(targets []buildTarget, name, runner string) (buildTarget, error) {
+	var matches []buildTarget
+	for _, target := range targets {
+		if target.Name != name {
+			continue
+		}
+		if runner != "" && target.Runner != runner {
+			continue
+		}
+		matches = append(matches, target)
+	}
+
+	switch len(matches) {
+	case 0:
+		return buildTarget{}, fmt.Errorf("unknown build target %q; call list_build_targets to see available targets", name)
+	case 1:
+		return matches[0], nil
+	default:
+		runners := make([]string, 0, len(matches))
+		for _, match := range matches {
+			runners = append(runners, match.Runner)
+		}
+		return buildTarget{}, fmt.Errorf("target %q is defined by several runners (%s); pass runner to choose one", name, strings.Join(runners, ", "))
+	}
+}
+
+// determineBuildRunnerCommand returns the binary of runner found in PATH.
+// There is no go run fallback: it would fetch and run an unpinned version.
+func determineBuildRunnerCommand(runner string) (string, error) {
+	switch runner {
+	case runnerMake, runnerTask, runnerMage:
+		path, err := lookupBuildToolBinary(runner)
+		if err != nil {
+			return "", fmt.Errorf("%s binary not found in PATH; install it to run %s targets", runner, runner)
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("unsupported runner %q", runner)
+	}
+}
+
+func discoverBuildTargets(root string) ([]buildTarget, error) {
+	var targets []buildTarget
+
+	if path := firstExistingFile(root, makefileNames); path != "" {
+		found, err := parseMakefileTargets(path)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, withTargetFile(found, root, path)...)
+	}
+
+	if path := firstExistingFile(root, taskfileNames); path != "" {
+		found, err := parseTaskfileTargets(path)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, withTargetFile(found, root, path)...)
+	}
+
+	mageTargets, err := discoverMageTargets(root)
+	if err != nil {
+		return nil, err
+	}
+	targets = append(targets, mageTargets...)
+
+	return targets, nil
+}
+
+func firstExistingFile(dir string, names []string) string {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func withTargetFile(targets []buildTarget, root, path string) []buildTarget {
+	rel := relativeSlashPath(root, path)
+	for i := range targets {
+		targets[i].File = rel
+	}
+	return targets
+}
+
+func relativeSlashPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func parseMakefileTargets(path string) ([]buildTarget, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var (
+		targets  []buildTarget
+		seen     = make(map[string]struct{})
+		comments []string
+		lineNo   int
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			comments = nil
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			comments = append(comments, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			continue
+		}
+
+		match := makeTargetPattern.FindStringSubmatch(line)
+		if match == nil {
+			comments = nil
+			continue
+		}
+		rest := match[2]
+		if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":=") || strings.HasPrefix(rest, "::=") {
+			comments = nil
+			continue
+		}
+
+		description := ""
+		if idx := strings.Index(rest, "##"); idx >= 0 {
+			description = strings.TrimSpace(rest[idx+2:])
+		} else if len(comments) > 0 {
+			description = strings.Join(comments, " ")
+		}
+		comments = nil
+
+		for _, name := range strings.Fields(match[1]) {
+			if _, dup := seen[name]; dup {
+				continue
+			}
+			seen[name] = struct{}{}
+			targets = append(targets, buildTarget{
+				Name:        name,
+				Runner:      runnerMake,
+				Line:        lineNo,
+				Description: description,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return targets, nil
+}
+
+func parseTaskfileTargets(path string) ([]buildTarget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	tasks := yamlMappingValue(doc.Content[0], "tasks")
+	if tasks == nil || tasks.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var targets []buildTarget
+	for i := 0; i+1 < len(tasks.Content); i += 2 {
+		key, value := tasks.Content[i], tasks.Content[i+1]
+		var details struct {
+			Desc     string `yaml:"desc"`
+			Summary  string `yaml:"summary"`
+			Internal bool   `yaml:"internal"`
+		}
+		if value.Kind == yaml.MappingNode {
+			_ = value.Decode(&details)
+		}
+		if details.Internal {
+			continue
+		}
+		description := details.Desc
+		if description == "" {
+			description = strings.TrimSpace(details.Summary)
+		}
+		targets = append(targets, buildTarget{
+			Name:        key.Value,
+			Runner:      runnerTask,
+			Line:        key.Line,
+			Description: description,
+		})
+	}
+	return targets, nil
+}
+
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func discoverMageTargets(root string) ([]buildTarget, error) {
+	var files []string
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("read workspace: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		files = append(files, filepath.Join(root, entry.Name()))
+	}
+	rootFiles := len(files)
+
+	mageDir := filepath.Join(root, "magefiles")
+	if dirEntries, err := os.ReadDir(mageDir); err == nil {
+		for _, entry := range dirEntries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+				continue
+			}
+			files = append(files, filepath.Join(mageDir, entry.Name()))
+		}
+	}
+
+	var targets []buildTarget
+	for i, path := range files {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		if i < rootFiles && !hasMageBuildTag(file) {
+			continue
+		}
+		for _, target := range mageTargetsFromFile(fset, file) {
+			target.File = relativeSlashPath(root, path)
+			targets = append(targets, target)
+		}
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].File != targets[j].File {
+			return targets[i].File < targets[j].File
+		}
+		return targets[i].Line < targets[j].Line
+	})
+	return targets, nil
+}
+
+func hasMageBuildTag(file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) && !constraint.IsPlusBuild(comment.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(comment.Text)
+			if err != nil {
+				continue
+			}
+			if expr.Eval(func(tag string) bool { return tag == "mage" }) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func mageTargetsFromFile(fset *token.FileSet, file *ast.File) []buildTarget {
+	namespaces := make(map[string]struct{})
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			if sel, ok := typeSpec.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Namespace" {
+				namespaces[typeSpec.Name.Name] = struct{}{}
+			}
+		}
+	}
+
+	var targets []buildTarget
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !fn.Name.IsExported() || !isMageTargetSignature(fn.Type) {
+			continue
+		}
+
+		name := lowerFirst(fn.Name.Name)
+		if fn.Recv != nil {
+			if len(fn.Recv.List) != 1 {
+				continue
+			}
+			recv, ok := fn.Recv.List[0].Type.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if _, isNamespace := namespaces[recv.Name]; !isNamespace {
+				continue
+			}
+			name = lowerFirst(recv.Name) + ":" + name
+		}
+
+		description := ""
+		if fn.Doc != nil {
+			description = strings.Join(strings.Fields(fn.Doc.Text()), " ")
+		}
+		targets = append(targets, buildTarget{
+			Name:        name,
+			Runner:      runnerMage,
+			Line:        fset.Position(fn.Pos()).Line,
+			Description: description,
+		})
+	}
+	return targets
+}
+
+func isMageTargetSignature(fnType *ast.FuncType) bool {
+	params := fnType.Params.List
+	if len(params) > 1 || (len(params) == 1 && len(params[0].Names) > 1) {
+		return false
+	}
+	if len(params) == 1 {
+		sel, ok := params[0].Type.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Context" {
+			return false
+		}
+	}
+
+	if fnType.Results == nil {
+		return true
+	}
+	results := fnType.Results.List
+	if len(results) != 1 || len(results[0].Names) > 1 {
+		return false
+	}
+	ident, ok := results[0].Type.(*ast.Ident)
+	return ok && ident.Name == "error"
+}
+
+func lowerFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	if r == utf8.RuneError {
+		return name
+	}
+	return string(unicode.ToLower(r)) + name[size:]
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func writeWorkspaceFile(t *testing.T, root, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", rel, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+	return path
+}
+
+func TestDiscoverBuildTargets(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "Makefile", `.PHONY: build test
+GO := go
+VERSION ::= 1.0
+
+# Compile the binary
+build:
+	$(GO) build ./...
+
+test lint: build ## Run checks
+	$(GO) test ./...
+`)
+	writeWorkspaceFile(t, workspace, "Taskfile.yml", `version: '3'
+tasks:
+  generate:
+    desc: Run go generate
+    cmds:
+      - go generate ./...
+  helper:
+    internal: true
+  short: echo hi
+`)
+	writeWorkspaceFile(t, workspace, "magefile.go", `//go:build mage
+
+package main
+
+import (
+	"context"
+
+	"github.com/magefile/mage/mg"
+)
+
+type Docker mg.Namespace
+
+// Release publishes artifacts.
+func Release(ctx context.Context) error { return nil }
+
+// Push pushes the image.
+func (Docker) Push() error { return nil }
+
+func helper(name string) {}
+`)
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nfunc Build() {}\n")
+
+	targets, err := discoverBuildTargets(workspace)
+	if err != nil {
+		t.Fatalf("discoverBuildTargets returned error: %v", err)
+	}
+
+	got := make(map[string]buildTarget)
+	for _, target := range targets {
+		got[target.Runner+":"+target.Name] = target
+	}
+
+	expected := map[string]string{
+		"make:build":       "Compile the binary",
+		"make:test":        "Run checks",
+		"make:lint":        "Run checks",
+		"task:generate":    "Run go generate",
+		"task:short":       "",
+		"mage:release":     "Release publishes artifacts.",
+		"mage:docker:push": "Push pushes the image.",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected targets %#v", targets)
+	}
+	for key, description := range expected {
+		target, ok := got[key]
+		if !ok {
+			t.Fatalf("missing target %s in %#v", key, targets)
+		}
+		if target.Description != description {
+			t.Fatalf("target %s: expected description %q, got %q", key, description, target.Description)
+		}
+	}
+	if got["make:build"].Line != 6 || got["make:build"].File != "Makefile" {
+		t.Fatalf("unexpected make target location %#v", got["make:build"])
+	}
+}
+
+func TestSelectBuildTargetAmbiguous(t *testing.T) {
+	targets := []buildTarget{
+		{Name: "build", Runner: runnerMake},
+		{Name: "build", Runner: runnerTask},
+	}
+
+	if _, err := selectBuildTarget(targets, "build", ""); err == nil {
+		t.Fatal("expected ambiguity error")
+	}
+	target, err := selectBuildTarget(targets, "build", runnerTask)
+	if err != nil {
+		t.Fatalf("selectBuildTarget returned error: %v", err)
+	}
+	if target.Runner != runnerTask {
+		t.Fatalf("unexpected runner %s", target.Runner)
+	}
+	if _, err := selectBuildTarget(targets, "deploy", ""); err == nil {
+		t.Fatal("expected unknown target error")
+	}
+}
+
+func TestRunBuildTargetNeedsTheRunnerInPath(t *testing.T) {
+	originalLookup := lookupBuildToolBinary
+	t.Cleanup(func() { lookupBuildToolBinary = originalLookup })
+	lookupBuildToolBinary = func(name string) (string, error) {
+		if name == runnerMake {
+			return "/usr/bin/make", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "Makefile", "build:\n\tgo build ./...\n")
+	writeWorkspaceFile(t, workspace, "Taskfile.yml", "version: '3'\ntasks:\n  lint:\n    cmds:\n      - golangci-lint run\n")
+	tools := NewLSPTools(nil, workspace)
+	var ran []string
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		ran = append(ran, strings.Join(append([]string{spec.name}, spec.args...), " "))
+		return commandResult{Stdout: strings.Repeat("x", 2*maxPackageOutput)}, nil
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(target string) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("run_build_target").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "run_build_target", Arguments: map[string]any{"target": target}},
+		})
+		if err != nil {
+			t.Fatalf("run_build_target: %v", err)
+		}
+		return result
+	}
+
+	if result := call("lint"); !result.IsError {
+		t.Fatalf("expected task missing from PATH to be refused, got %v", result)
+	}
+	built := structured(call("build"))
+	if stdout := built["result"].(map[string]any)["stdout"].(string); len(stdout) > maxPackageOutput+100 {
+		t.Fatalf("expected the output to be truncated, got %d bytes", len(stdout))
+	}
+	if !slices.Equal(ran, []string{"/usr/bin/make build"}) {
+		t.Fatalf("expected only make to run, without a go run fallback, got %v", ran)
+	}
+}
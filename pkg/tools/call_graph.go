@@ -0,0 +1,437 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+const (
+	defaultCallGraphDepth = 3
+	maxCallGraphDepth     = 10
+	// maxCallGraphEdges caps the exported graph; larger graphs come back
+	// truncated.
+	maxCallGraphEdges = 2000
+)
+
+// callGraphNode is a function of the call graph. Functions outside the
+// workspace module are marked External and carry no location.
+type callGraphNode struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Package  string `json:"package"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	External bool   `json:"external,omitempty"`
+}
+
+// callGraphEdge is a call from one node to another. Dynamic marks calls
+// through an interface or a function value, whose callees are the
+// algorithm's estimate.
+type callGraphEdge struct {
+	Caller  int    `json:"caller"`
+	Callee  int    `json:"callee"`
+	Site    string `json:"site,omitempty"`
+	Dynamic bool   `json:"dynamic,omitempty"`
+}
+
+type callGraphExport struct {
+	Nodes     []callGraphNode `json:"nodes"`
+	Edges     []callGraphEdge `json:"edges"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+func (t *LSPTools) registerCallGraph(s *server.MCPServer) {
+	tool := mcp.NewTool("call_graph",
+		mcp.WithDescription("Compute the static call graph of a package, or the part reachable from one function, with class hierarchy analysis (cha, every method an interface call could reach) or rapid type analysis (rta, only types the program instantiates), exported as JSON nodes and edges with locations, or as Graphviz DOT"),
+		mcp.WithTitleAnnotation("Call Graph"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package",
+			mcp.Description("Package pattern to analyze, such as ./pkg/server or ./... (default ./...)"),
+		),
+		mcp.WithString("function",
+			mcp.Description("Root function or method (Name, pkg.Name or Type.Method); without it the graph covers every function of the packages"),
+		),
+		mcp.WithString("algorithm",
+			mcp.Description("cha (default) or rta"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description(fmt.Sprintf("With function, how many levels of calls to follow (default %d, at most %d)", defaultCallGraphDepth, maxCallGraphDepth)),
+		),
+		mcp.WithString("format",
+			mcp.Description("json (default) or dot"),
+		),
+		mcp.WithBoolean("include_external",
+			mcp.Description("Include calls into packages outside the workspace module, such as the standard library (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		pattern := getOptionalStringArg(args, "package")
+		if pattern == "" {
+			pattern = "./..."
+		}
+		algorithm := getOptionalStringArg(args, "algorithm")
+		if algorithm == "" {
+			algorithm = "cha"
+		}
+		if algorithm != "cha" && algorithm != "rta" {
+			return mcp.NewToolResultError("algorithm must be cha or rta"), nil
+		}
+		format := getOptionalStringArg(args, "format")
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "dot" {
+			return mcp.NewToolResultError("format must be json or dot"), nil
+		}
+		depth := defaultCallGraphDepth
+		if _, ok := args["depth"]; ok {
+			var err error
+			if depth, err = getIntFromObject(args, "depth"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if depth < 1 || depth > maxCallGraphDepth {
+				return mcp.NewToolResultError(fmt.Sprintf("depth must be between 1 and %d", maxCallGraphDepth)), nil
+			}
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Loading and type-checking %s", pattern))
+		prog, initial, modulePath, err := t.loadSSAProgram(ctx, pattern, false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var root *ssa.Function
+		if name := getOptionalStringArg(args, "function"); name != "" {
+			var problem string
+			if root, problem = findSSAFunction(initial, name); problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+		}
+
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Building the call graph (%s)", algorithm))
+		graph := buildCallGraph(prog, initial, root, algorithm)
+		export := t.exportCallGraph(prog, graph, initial, root, depth, modulePath, getOptionalBoolArg(args, "include_external"))
+
+		payload := map[string]any{
+			"package":   pattern,
+			"algorithm": algorithm,
+			"nodes":     len(export.Nodes),
+			"edges":     len(export.Edges),
+		}
+		if root != nil {
+			payload["function"] = root.String()
+			payload["depth"] = depth
+		}
+		if export.Truncated {
+			payload["truncated"] = true
+		}
+		if format == "dot" {
+			payload["dot"] = export.dot()
+		} else {
+			payload["graph"] = export
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// loadSSAProgram type-checks the packages matching pattern, with their
+// test variants and test binaries when tests is set, and builds their SSA
+// form, returning the program, the SSA packages of the matched packages and
+// the workspace module path. Packages with errors are reported rather than
+// analyzed, since their call graph would be partial.
+func (t *LSPTools) loadSSAProgram(ctx context.Context, pattern string, tests bool) (*ssa.Program, []*ssa.Package, string, error) {
+	return t.loadSSAProgramMode(ctx, pattern, tests, ssa.InstantiateGenerics)
+}
+
+// loadSSAProgramMode is loadSSAProgram with the builder passes of mode.
+func (t *LSPTools) loadSSAProgramMode(ctx context.Context, pattern string, tests bool, mode ssa.BuilderMode) (*ssa.Program, []*ssa.Package, string, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Dir:     t.workspaceDir,
+		Tests:   tests,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports |
+			packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedTypesSizes | packages.NeedModule,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("load %s: %w", pattern, err)
+	}
+	if len(pkgs) == 0 {
+		return nil, nil, "", fmt.Errorf("no packages match %s", pattern)
+	}
+	var problems []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, pkgErr := range pkg.Errors {
+			problems = append(problems, pkgErr.Error())
+		}
+	})
+	if len(problems) > 0 {
+		if len(problems) > 5 {
+			problems = append(problems[:5], fmt.Sprintf("and %d more", len(problems)-5))
+		}
+		return nil, nil, "", fmt.Errorf("packages have errors; fix them first:\n%s", strings.Join(problems, "\n"))
+	}
+	modulePath := ""
+	if pkgs[0].Module != nil {
+		modulePath = pkgs[0].Module.Path
+	}
+
+	prog, initial := ssautil.AllPackages(pkgs, mode)
+	prog.Build()
+	return prog, slices.DeleteFunc(initial, func(pkg *ssa.Package) bool { return pkg == nil }), modulePath, nil
+}
+
+// findSSAFunction resolves a function or method name, qualified by package
+// name or import path if needed, among the functions of pkgs. A bare method
+// name matches the methods of every type.
+func findSSAFunction(pkgs []*ssa.Package, name string) (*ssa.Function, string) {
+	var matches []*ssa.Function
+	for _, pkg := range pkgs {
+		for _, fn := range packageFunctions(pkg) {
+			short := ssaFunctionName(fn)
+			if short == name || pkg.Pkg.Name()+"."+short == name || pkg.Pkg.Path()+"."+short == name || (fn.Signature.Recv() != nil && fn.Name() == name) {
+				matches = append(matches, fn)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Sprintf("no function named %q in the analyzed packages", name)
+	case 1:
+		return matches[0], ""
+	}
+	candidates := make([]string, 0, len(matches))
+	for _, fn := range matches {
+		candidates = append(candidates, fn.Pkg.Pkg.Path()+"."+ssaFunctionName(fn))
+	}
+	return nil, fmt.Sprintf("function name %q is ambiguous: %s; qualify it with its package", name, strings.Join(candidates, ", "))
+}
+
+// packageFunctions lists the package-level functions and the methods of
+// the named types of an SSA package, in a stable order.
+func packageFunctions(pkg *ssa.Package) []*ssa.Function {
+	var functions []*ssa.Function
+	for _, member := range pkg.Members {
+		switch member := member.(type) {
+		case *ssa.Function:
+			if member.Synthetic == "" {
+				functions = append(functions, member)
+			}
+		case *ssa.Type:
+			named, ok := member.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			for i := range named.NumMethods() {
+				if fn := pkg.Prog.FuncValue(named.Method(i)); fn != nil {
+					functions = append(functions, fn)
+				}
+			}
+		}
+	}
+	slices.SortFunc(functions, func(a, b *ssa.Function) int { return cmp.Compare(a.Pos(), b.Pos()) })
+	return functions
+}
+
+// ssaFunctionName names a function as the other tools do: Name for
+// functions, Type.Method for methods, with closures keeping the $n suffix.
+func ssaFunctionName(fn *ssa.Function) string {
+	if recv := fn.Signature.Recv(); recv != nil {
+		typ := recv.Type()
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		if named, ok := typ.(*types.Named); ok {
+			return named.Obj().Name() + "." + fn.Name()
+		}
+	}
+	if parent := fn.Parent(); parent != nil {
+		return ssaFunctionName(parent) + strings.TrimPrefix(fn.Name(), parent.Name())
+	}
+	return fn.Name()
+}
+
+// buildCallGraph runs the algorithm. RTA starts from the root when there
+// is one, else from the packages' main and init functions, or from all
+// their functions for libraries.
+func buildCallGraph(prog *ssa.Program, initial []*ssa.Package, root *ssa.Function, algorithm string) *callgraph.Graph {
+	var graph *callgraph.Graph
+	if algorithm == "rta" {
+		var roots []*ssa.Function
+		if root != nil {
+			roots = []*ssa.Function{root}
+		} else {
+			for _, pkg := range initial {
+				if main := pkg.Func("main"); main != nil && pkg.Pkg.Name() == "main" {
+					roots = append(roots, main)
+				}
+				if init := pkg.Func("init"); init != nil {
+					roots = append(roots, init)
+				}
+			}
+			if !slices.ContainsFunc(roots, func(fn *ssa.Function) bool { return fn.Name() == "main" }) {
+				for _, pkg := range initial {
+					roots = append(roots, packageFunctions(pkg)...)
+				}
+			}
+		}
+		graph = rta.Analyze(roots, true).CallGraph
+	} else {
+		graph = cha.CallGraph(prog)
+	}
+	graph.DeleteSyntheticNodes()
+	return graph
+}
+
+// exportCallGraph selects the part of the graph to export: what is
+// reachable from root within depth calls, or every call made by the
+// functions of the initial packages. Callees outside the module are left
+// out unless includeExternal is set, and are never followed further.
+func (t *LSPTools) exportCallGraph(prog *ssa.Program, graph *callgraph.Graph, initial []*ssa.Package, root *ssa.Function, depth int, modulePath string, includeExternal bool) callGraphExport {
+	external := func(fn *ssa.Function) bool {
+		return fn.Pkg == nil || !inModule(fn.Pkg.Pkg.Path(), modulePath)
+	}
+	export := callGraphExport{Nodes: []callGraphNode{}, Edges: []callGraphEdge{}}
+	ids := make(map[*ssa.Function]int)
+	nodeID := func(fn *ssa.Function) int {
+		if id, ok := ids[fn]; ok {
+			return id
+		}
+		id := len(export.Nodes)
+		ids[fn] = id
+		node := callGraphNode{ID: id, Name: ssaFunctionName(fn)}
+		if fn.Pkg != nil {
+			node.Package = fn.Pkg.Pkg.Path()
+			node.Name = fn.Pkg.Pkg.Name() + "." + node.Name
+		}
+		if node.External = external(fn); !node.External {
+			if pos := prog.Fset.Position(fn.Pos()); pos.IsValid() {
+				node.Path, node.Line = relativeSlashPath(t.workspaceDir, pos.Filename), pos.Line
+			}
+		}
+		export.Nodes = append(export.Nodes, node)
+		return id
+	}
+	addEdges := func(fn *ssa.Function) []*ssa.Function {
+		node := graph.Nodes[fn]
+		if node == nil {
+			return nil
+		}
+		caller := nodeID(fn)
+		var callees []*ssa.Function
+		for _, edge := range node.Out {
+			callee := edge.Callee.Func
+			if !includeExternal && external(callee) {
+				continue
+			}
+			if len(export.Edges) == maxCallGraphEdges {
+				export.Truncated = true
+				return callees
+			}
+			entry := callGraphEdge{Caller: caller, Callee: nodeID(callee)}
+			if edge.Site != nil {
+				entry.Site = sitePosition(t.workspaceDir, prog.Fset, edge.Site.Pos())
+				entry.Dynamic = edge.Site.Common().StaticCallee() == nil
+			}
+			export.Edges = append(export.Edges, entry)
+			if !external(callee) {
+				callees = append(callees, callee)
+			}
+		}
+		return callees
+	}
+
+	if root != nil {
+		nodeID(root)
+		visited := map[*ssa.Function]bool{root: true}
+		level := []*ssa.Function{root}
+		for range depth {
+			var next []*ssa.Function
+			for _, fn := range level {
+				for _, callee := range addEdges(fn) {
+					if !visited[callee] {
+						visited[callee] = true
+						next = append(next, callee)
+					}
+				}
+			}
+			level = next
+		}
+		return export
+	}
+
+	inInitial := make(map[*ssa.Package]bool, len(initial))
+	for _, pkg := range initial {
+		inInitial[pkg] = true
+	}
+	var functions []*ssa.Function
+	for fn := range graph.Nodes {
+		if fn != nil && fn.Pkg != nil && inInitial[fn.Pkg] && fn.Synthetic == "" {
+			functions = append(functions, fn)
+		}
+	}
+	slices.SortFunc(functions, func(a, b *ssa.Function) int {
+		return cmp.Or(strings.Compare(a.Pkg.Pkg.Path(), b.Pkg.Pkg.Path()), cmp.Compare(a.Pos(), b.Pos()), strings.Compare(a.Name(), b.Name()))
+	})
+	for _, fn := range functions {
+		addEdges(fn)
+	}
+	return export
+}
+
+func sitePosition(workspaceDir string, fset *token.FileSet, pos token.Pos) string {
+	position := fset.Position(pos)
+	if !position.IsValid() {
+		return ""
+	}
+	return relativeSlashPath(workspaceDir, position.Filename) + ":" + strconv.Itoa(position.Line)
+}
+
+// inModule reports whether an import path belongs to the module.
+func inModule(path, modulePath string) bool {
+	return modulePath != "" && (path == modulePath || strings.HasPrefix(path, modulePath+"/"))
+}
+
+// dot renders the graph in Graphviz DOT, dashing dynamic calls.
+func (g callGraphExport) dot() string {
+	var b strings.Builder
+	b.WriteString("digraph callgraph {\n\tnode [shape=box];\n")
+	for _, node := range g.Nodes {
+		label := node.Name
+		if node.Path != "" {
+			label += fmt.Sprintf("\n%s:%d", node.Path, node.Line)
+		}
+		fmt.Fprintf(&b, "\tn%d [label=%s];\n", node.ID, strconv.Quote(label))
+	}
+	for _, edge := range g.Edges {
+		style := ""
+		if edge.Dynamic {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&b, "\tn%d -> n%d%s;\n", edge.Caller, edge.Callee, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
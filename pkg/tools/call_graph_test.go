@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCallGraph(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "shapes/shapes.go", `package shapes
+
+type Shape interface{ Area() int }
+
+type Square struct{ Side int }
+
+func (s Square) Area() int { return s.Side * s.Side }
+
+// Circle is never instantiated, so RTA leaves its method out.
+type Circle struct{ R int }
+
+func (c Circle) Area() int { return 3 * c.R * c.R }
+
+func Total(shapes []Shape) int {
+	sum := 0
+	for _, s := range shapes {
+		sum += s.Area()
+	}
+	return sum
+}
+
+func Run() int {
+	return Total([]Shape{Square{Side: 2}})
+}
+`)
+
+	tools := NewLSPTools(nil, workspace)
+	prog, initial, modulePath, err := tools.loadSSAProgram(context.Background(), "./...", false)
+	if err != nil {
+		t.Fatalf("loadSSAProgram: %v", err)
+	}
+	if modulePath != "example.com/app" {
+		t.Fatalf("module path = %q", modulePath)
+	}
+	root, problem := findSSAFunction(initial, "shapes.Run")
+	if problem != "" {
+		t.Fatalf("findSSAFunction: %s", problem)
+	}
+	if _, problem := findSSAFunction(initial, "Area"); !strings.Contains(problem, "ambiguous") {
+		t.Fatalf("expected Area to be ambiguous, got %q", problem)
+	}
+
+	edges := func(algorithm string) []string {
+		graph := buildCallGraph(prog, initial, root, algorithm)
+		export := tools.exportCallGraph(prog, graph, initial, root, 3, modulePath, false)
+		var got []string
+		for _, edge := range export.Edges {
+			line := export.Nodes[edge.Caller].Name + " -> " + export.Nodes[edge.Callee].Name
+			if edge.Dynamic {
+				line += " (dynamic)"
+			}
+			got = append(got, line)
+		}
+		slices.Sort(got)
+		return got
+	}
+	cha := []string{"shapes.Run -> shapes.Total", "shapes.Total -> shapes.Circle.Area (dynamic)", "shapes.Total -> shapes.Square.Area (dynamic)"}
+	if got := edges("cha"); !slices.Equal(got, cha) {
+		t.Fatalf("cha edges = %v, want %v", got, cha)
+	}
+	rta := []string{"shapes.Run -> shapes.Total", "shapes.Total -> shapes.Square.Area (dynamic)"}
+	if got := edges("rta"); !slices.Equal(got, rta) {
+		t.Fatalf("rta edges = %v, want %v", got, rta)
+	}
+
+	graph := buildCallGraph(prog, initial, nil, "cha")
+	export := tools.exportCallGraph(prog, graph, initial, nil, 0, modulePath, false)
+	if len(export.Edges) != 3 || export.Nodes[0].Path != "shapes/shapes.go" {
+		t.Fatalf("unexpected package graph %+v", export)
+	}
+	if dot := export.dot(); !strings.Contains(dot, "[style=dashed]") || !strings.HasPrefix(dot, "digraph callgraph {") {
+		t.Fatalf("unexpected dot output:\n%s", dot)
+	}
+}
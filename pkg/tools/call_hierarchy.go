@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const maxCallHierarchyDepth = 5
+
+// callHierarchyEntry is one caller or callee. Parent names the function it
+// was reached from, so entries beyond depth 1 form a tree.
+type callHierarchyEntry struct {
+	Name      string              `json:"name"`
+	Detail    string              `json:"detail,omitempty"`
+	Location  protocol.Location   `json:"location"`
+	CallSites []protocol.Location `json:"call_sites"`
+	Depth     int                 `json:"depth"`
+	Parent    string              `json:"parent"`
+	// Truncated marks entries whose own calls were not followed because
+	// they were already listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (t *LSPTools) registerCallHierarchy(s *server.MCPServer) {
+	for _, direction := range []string{"incoming", "outgoing"} {
+		description := "List the functions calling the function at a position (callers), with the call sites, via gopls call hierarchy"
+		title := "Call Hierarchy Incoming"
+		if direction == "outgoing" {
+			description = "List the functions called by the function at a position (callees), with the call sites, via gopls call hierarchy"
+			title = "Call Hierarchy Outgoing"
+		}
+		tool := mcp.NewTool("call_hierarchy_"+direction,
+			mcp.WithDescription(description),
+			mcp.WithTitleAnnotation(title),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("file_uri",
+				mcp.Required(),
+				mcp.Description("URI of the file"),
+			),
+			mcp.WithObject("position",
+				mcp.Required(),
+				mcp.Description("Position of the function or method name"),
+			),
+			mcp.WithNumber("depth",
+				mcp.Description(fmt.Sprintf("How many levels of calls to follow (default 1, at most %d)", maxCallHierarchyDepth)),
+			),
+		)
+
+		s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args, err := getArguments(request)
+			if err != nil {
+				return nil, err
+			}
+			fileURI, err := getStringArg(args, "file_uri")
+			if err != nil {
+				return nil, err
+			}
+			line, character, err := parsePosition(args)
+			if err != nil {
+				return nil, err
+			}
+			depth := 1
+			if _, ok := args["depth"]; ok {
+				if depth, err = getIntFromObject(args, "depth"); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			if depth < 1 || depth > maxCallHierarchyDepth {
+				return mcp.NewToolResultError(fmt.Sprintf("depth must be between 1 and %d", maxCallHierarchyDepth)), nil
+			}
+			if !strings.HasPrefix(fileURI, "file://") {
+				fileURI = convertPathToURI(fileURI)
+			}
+
+			lspClient := t.getClient()
+			if lspClient == nil {
+				return nil, fmt.Errorf("LSP client not available")
+			}
+			items, err := lspClient.PrepareCallHierarchy(ctx, fileURI, line, character)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if len(items) == 0 {
+				return mcp.NewToolResultError("no function or method at this position"), nil
+			}
+			calls, err := walkCallHierarchy(ctx, lspClient, items, direction == "incoming", depth)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+
+			roots := make([]callHierarchyEntry, 0, len(items))
+			for _, item := range items {
+				roots = append(roots, callHierarchyEntry{
+					Name:     item.Name,
+					Detail:   item.Detail,
+					Location: protocol.Location{URI: item.URI, Range: item.SelectionRange},
+				})
+			}
+			result, err := mcp.NewToolResultJSON(map[string]any{
+				"file_uri":  fileURI,
+				"direction": direction,
+				"items":     roots,
+				"calls":     calls,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		})
+	}
+}
+
+// walkCallHierarchy follows calls breadth-first up to depth levels. Each
+// function is expanded once, which keeps recursion and shared callers from
+// blowing up the result.
+func walkCallHierarchy(ctx context.Context, lspClient client.LSPClient, roots []protocol.CallHierarchyItem, incoming bool, depth int) ([]callHierarchyEntry, error) {
+	key := func(item protocol.CallHierarchyItem) string {
+		return fmt.Sprintf("%s:%d:%d", item.URI, item.SelectionRange.Start.Line, item.SelectionRange.Start.Character)
+	}
+	expanded := make(map[string]bool)
+	for _, root := range roots {
+		expanded[key(root)] = true
+	}
+
+	entries := []callHierarchyEntry{}
+	level := roots
+	for d := 1; d <= depth && len(level) > 0; d++ {
+		var next []protocol.CallHierarchyItem
+		for _, item := range level {
+			type call struct {
+				item  protocol.CallHierarchyItem
+				sites []protocol.Location
+			}
+			var calls []call
+			if incoming {
+				results, err := lspClient.IncomingCalls(ctx, item)
+				if err != nil {
+					return nil, err
+				}
+				for _, result := range results {
+					// Incoming call sites are in the caller's file.
+					calls = append(calls, call{result.From, rangeLocations(result.From.URI, result.FromRanges)})
+				}
+			} else {
+				results, err := lspClient.OutgoingCalls(ctx, item)
+				if err != nil {
+					return nil, err
+				}
+				for _, result := range results {
+					calls = append(calls, call{result.To, rangeLocations(item.URI, result.FromRanges)})
+				}
+			}
+			for _, c := range calls {
+				entry := callHierarchyEntry{
+					Name:      c.item.Name,
+					Detail:    c.item.Detail,
+					Location:  protocol.Location{URI: c.item.URI, Range: c.item.SelectionRange},
+					CallSites: c.sites,
+					Depth:     d,
+					Parent:    item.Name,
+				}
+				if expanded[key(c.item)] {
+					entry.Truncated = d < depth
+				} else {
+					expanded[key(c.item)] = true
+					next = append(next, c.item)
+				}
+				entries = append(entries, entry)
+			}
+		}
+		level = next
+	}
+	return entries, nil
+}
+
+func rangeLocations(uri string, ranges []protocol.Range) []protocol.Location {
+	locations := make([]protocol.Location, 0, len(ranges))
+	for _, rng := range ranges {
+		locations = append(locations, protocol.Location{URI: uri, Range: rng})
+	}
+	return locations
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	removeParamKind   = "refactor.rewrite.removeUnusedParam"
+	moveParamLeftKind = "refactor.rewrite.moveParamLeft"
+)
+
+func (t *LSPTools) registerChangeSignature(s *server.MCPServer) {
+	tool := mcp.NewTool("change_signature",
+		mcp.WithDescription("Change the parameters of a function or method and update every call site, with the gopls change signature refactoring: remove a parameter the body does not use, its arguments being dropped from the calls unless they have effects, or reorder the parameters, the arguments of the calls following. A reorder is done as a series of gopls parameter moves; one needing more than one move is written to disk step by step and needs apply, and is rolled back if a step fails. Returns the edit as a unified diff, and writes it when apply is true"),
+		mcp.WithTitleAnnotation("Change Signature"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file declaring the function"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position of the function's name in its declaration"),
+		),
+		mcp.WithString("remove",
+			mcp.Description("Parameter to remove, by name, or by 0-based index for unnamed parameters"),
+		),
+		mcp.WithArray("order",
+			mcp.Description("Every parameter in its new order, by name or 0-based index, such as [\"ctx\", \"name\", \"opts\"]"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edit to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		remove := getOptionalStringArg(args, "remove")
+		rawOrder, hasOrder := args["order"].([]any)
+		if (remove == "") == !hasOrder {
+			return mcp.NewToolResultError("set remove or order"), nil
+		}
+		apply := getOptionalBoolArg(args, "apply")
+
+		path := convertURIToPath(fileURI)
+		decl, problem := funcDeclAt(path, line, character, "position is not on the name of a function or method declaration")
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		payload := map[string]any{"symbol": decl.symbol}
+		var changes []fileChange
+		if remove != "" {
+			index, problem := paramIndex(decl.params, remove)
+			if problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+			position := decl.params[index].position
+			changes, _, problem, err = kindActionChanges(ctx, lspClient, fileURI, protocol.Range{Start: position, End: position}, removeParamKind,
+				fmt.Sprintf("remove parameter %s", remove), fmt.Sprintf("gopls offers no removal of %s; it only removes parameters the body does not use", remove))
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+			if apply {
+				if err := t.writeFileChanges(ctx, changes); err != nil {
+					return writeFailureResult("apply change_signature", err), nil
+				}
+			}
+		} else {
+			order := make([]int, 0, len(rawOrder))
+			for _, item := range rawOrder {
+				param, _ := item.(string)
+				index, problem := paramIndex(decl.params, param)
+				if problem != "" {
+					return mcp.NewToolResultError(problem), nil
+				}
+				if slices.Contains(order, index) {
+					return mcp.NewToolResultError(fmt.Sprintf("order lists %s twice", param)), nil
+				}
+				order = append(order, index)
+			}
+			if len(order) != len(decl.params) {
+				return mcp.NewToolResultError(fmt.Sprintf("order must list all %d parameters of %s", len(decl.params), decl.symbol)), nil
+			}
+			moves := paramMoves(order)
+			switch {
+			case len(moves) == 0:
+				payload["message"] = "the parameters are already in this order"
+				apply = false
+			case len(moves) > 1 && !apply:
+				return mcp.NewToolResultError(fmt.Sprintf("this order takes %d parameter moves, each computed by gopls from the result of the previous one on disk; set apply to true, and review the diff afterwards", len(moves))), nil
+			case len(moves) > 1 && t.approvals != nil:
+				return mcp.NewToolResultError(fmt.Sprintf("this order takes %d parameter moves, each written before the next is computed; it cannot be applied while edits wait for approval", len(moves))), nil
+			case len(moves) == 1:
+				position := decl.params[moves[0]].position
+				changes, _, problem, err = kindActionChanges(ctx, lspClient, fileURI, protocol.Range{Start: position, End: position}, moveParamLeftKind,
+					"move the parameter", "gopls offers no parameter move here; it needs gopls v0.17 or later")
+				if err != nil {
+					return nil, t.handleLSPError(err)
+				}
+				if problem != "" {
+					return mcp.NewToolResultError(problem), nil
+				}
+				if apply {
+					if err := t.writeFileChanges(ctx, changes); err != nil {
+						return writeFailureResult("apply change_signature", err), nil
+					}
+				}
+			default:
+				var result *mcp.CallToolResult
+				if changes, result, err = t.moveParams(ctx, lspClient, fileURI, decl.symbol, moves); result != nil || err != nil {
+					return result, err
+				}
+			}
+		}
+
+		payload["files"] = t.summarizeFileChanges(changes)
+		payload["applied"] = apply
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// moveParams moves parameters of the function symbol one place left, by
+// their index at the time of each move, writing each move before the next
+// is computed. When a move fails, the earlier ones are undone and the
+// returned result reports the failure.
+func (t *LSPTools) moveParams(ctx context.Context, lspClient client.LSPClient, fileURI, symbol string, moves []int) ([]fileChange, *mcp.CallToolResult, error) {
+	path := convertURIToPath(fileURI)
+	journal := newEditJournal()
+	journaled := withEditJournal(ctx, journal)
+	undo := func() (bool, error) {
+		changes, err := journal.changes(true)
+		if err != nil || len(changes) == 0 {
+			return false, err
+		}
+		return true, t.writeFileChanges(ctx, changes)
+	}
+	fail := func(message string) ([]fileChange, *mcp.CallToolResult, error) {
+		switch undone, err := undo(); {
+		case err != nil:
+			message += fmt.Sprintf("; undoing the earlier moves failed too: %v", err)
+		case undone:
+			message += "; the earlier moves were undone"
+		}
+		return nil, mcp.NewToolResultError(message), nil
+	}
+	for i, index := range moves {
+		if i > 0 {
+			if err := t.awaitEdit(ctx, lspClient); err != nil {
+				_, _ = undo()
+				return nil, nil, err
+			}
+		}
+		decl, problem := funcDeclNamed(path, symbol)
+		if problem == "" && index >= len(decl.params) {
+			problem = fmt.Sprintf("%s no longer has parameter %d", symbol, index)
+		}
+		if problem != "" {
+			return fail(problem)
+		}
+		position := decl.params[index].position
+		changes, _, problem, err := kindActionChanges(ctx, lspClient, fileURI, protocol.Range{Start: position, End: position}, moveParamLeftKind,
+			fmt.Sprintf("move parameter %d", index), "gopls offers no parameter move here; it needs gopls v0.17 or later")
+		if err != nil {
+			_, _ = undo()
+			return nil, nil, t.handleLSPError(err)
+		}
+		if problem != "" {
+			return fail(fmt.Sprintf("move %d of %d: %s", i+1, len(moves), problem))
+		}
+		if err := t.writeFileChanges(journaled, changes); err != nil {
+			return fail(fmt.Sprintf("move %d of %d: %v", i+1, len(moves), err))
+		}
+	}
+	changes, err := journal.changes(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return changes, nil, nil
+}
+
+// paramIndex returns the index of the parameter named name, or whose
+// index name spells.
+func paramIndex(params []funcParam, name string) (int, string) {
+	if index := slices.IndexFunc(params, func(param funcParam) bool { return param.name == name && name != "_" }); index >= 0 {
+		return index, ""
+	}
+	if index, err := strconv.Atoi(name); err == nil && index >= 0 && index < len(params) {
+		return index, ""
+	}
+	var names []string
+	for i, param := range params {
+		if param.name == "" || param.name == "_" {
+			names = append(names, strconv.Itoa(i))
+		} else {
+			names = append(names, param.name)
+		}
+	}
+	return 0, fmt.Sprintf("no parameter %q; the parameters are %s", name, strings.Join(names, ", "))
+}
+
+// paramMoves returns the moves of one place left, by the index of the
+// moved parameter at the time, that put the parameters in order, order
+// listing the current index of each parameter in its new place.
+func paramMoves(order []int) []int {
+	current := make([]int, len(order))
+	for i := range current {
+		current[i] = i
+	}
+	var moves []int
+	for target, param := range order {
+		for at := slices.Index(current, param); at > target; at-- {
+			moves = append(moves, at)
+			current[at-1], current[at] = current[at], current[at-1]
+		}
+	}
+	return moves
+}
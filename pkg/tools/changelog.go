@@ -0,0 +1,278 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var conventionalCommitPattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+type changelogEntry struct {
+	Summary  string `json:"summary"`
+	Scope    string `json:"scope,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Breaking bool   `json:"breaking,omitempty"`
+	Source   string `json:"source"`
+}
+
+type changelogDraft struct {
+	Since    string           `json:"since,omitempty"`
+	Commits  int              `json:"commits"`
+	Added    []changelogEntry `json:"added"`
+	Changed  []changelogEntry `json:"changed"`
+	Fixed    []changelogEntry `json:"fixed"`
+	Skipped  int              `json:"skipped_commits"`
+	API      string           `json:"api_status"`
+	Markdown string           `json:"markdown"`
+}
+
+type gitCommit struct {
+	Hash    string
+	Subject string
+	Body    string
+	Files   []string
+}
+
+func (t *LSPTools) registerDraftChangelog(s *server.MCPServer) {
+	tool := mcp.NewTool("draft_changelog",
+		mcp.WithDescription("Draft a changelog (added/changed/fixed) from the git log since the latest release tag combined with the apidiff report"),
+		mcp.WithTitleAnnotation("Draft Changelog"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("since", mcp.Description("Git ref to start from. Defaults to the latest release tag")),
+		mcp.WithBoolean("skip_apidiff", mcp.Description("Do not compare the exported API against the starting ref")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		token := getProgressToken(request.Params.Meta)
+
+		modPath, err := readModulePath(t.workspaceDir)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		since := getOptionalStringArg(args, "since")
+		if since == "" {
+			sendProgressNotification(ctx, s, token, "Looking up the latest release tag")
+			_, since = t.checkReleaseTags(ctx, s, modPath)
+		}
+
+		logArgs := []string{"log", "--no-merges", "--format=%x1e%H%x1f%s%x1f%b%x1f", "--name-only"}
+		if since != "" {
+			logArgs = append(logArgs, since+"..HEAD")
+		}
+		logArgs = append(logArgs, "--", ".")
+		sendProgressNotification(ctx, s, token, "Reading git history")
+		logResult, err := t.runCommand(ctx, s, nil, "git", logArgs...)
+		if err != nil {
+			return t.commandFailureResult("git log", logResult, err)
+		}
+		commits := parseGitLog(logResult.Stdout)
+
+		var changes *apiChanges
+		apiStatus := "skipped: no starting ref"
+		switch {
+		case getOptionalBoolArg(args, "skip_apidiff"):
+			apiStatus = "skipped on request"
+		case since != "":
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Comparing API against %s", since))
+			var check releaseCheck
+			check, changes = t.checkAPICompatibility(ctx, s, modPath, since)
+			apiStatus = check.Status + ": " + check.Summary
+		}
+
+		draft := buildChangelogDraft(commits, changes)
+		draft.Since = since
+		draft.API = apiStatus
+		draft.Markdown = renderChangelogMarkdown(draft)
+
+		result, err := mcp.NewToolResultJSON(draft)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// parseGitLog reads the output of git log using record (0x1e) and unit
+// (0x1f) separators followed by --name-only file lists.
+func parseGitLog(output string) []gitCommit {
+	var commits []gitCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		parts := strings.SplitN(record, "\x1f", 4)
+		if len(parts) < 3 {
+			continue
+		}
+		commit := gitCommit{
+			Hash:    strings.TrimSpace(parts[0]),
+			Subject: strings.TrimSpace(parts[1]),
+			Body:    strings.TrimSpace(parts[2]),
+		}
+		if len(parts) == 4 {
+			commit.Files = splitLines(strings.TrimSpace(parts[3]))
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+func buildChangelogDraft(commits []gitCommit, changes *apiChanges) changelogDraft {
+	draft := changelogDraft{
+		Commits: len(commits),
+		Added:   []changelogEntry{},
+		Changed: []changelogEntry{},
+		Fixed:   []changelogEntry{},
+	}
+
+	for _, commit := range commits {
+		section, entry := classifyCommit(commit)
+		switch section {
+		case "added":
+			draft.Added = append(draft.Added, entry)
+		case "changed":
+			draft.Changed = append(draft.Changed, entry)
+		case "fixed":
+			draft.Fixed = append(draft.Fixed, entry)
+		default:
+			draft.Skipped++
+		}
+	}
+
+	if changes != nil {
+		for _, change := range changes.Incompatible {
+			draft.Changed = append(draft.Changed, changelogEntry{Summary: change, Breaking: true, Source: "apidiff"})
+		}
+		for _, change := range changes.Compatible {
+			draft.Added = append(draft.Added, changelogEntry{Summary: change, Source: "apidiff"})
+		}
+	}
+
+	// Group fixes by scope so related fixes read together.
+	sort.SliceStable(draft.Fixed, func(i, j int) bool {
+		return draft.Fixed[i].Scope < draft.Fixed[j].Scope
+	})
+	return draft
+}
+
+// classifyCommit maps a commit onto a changelog section. Conventional commit
+// types are used when present; otherwise the leading verb of the subject
+// decides. Housekeeping commits (docs, tests, CI, chores) return "".
+func classifyCommit(commit gitCommit) (string, changelogEntry) {
+	entry := changelogEntry{
+		Summary: commit.Subject,
+		Scope:   commitScope(commit.Files),
+		Commit:  shortHash(commit.Hash),
+		Source:  "git",
+	}
+	if strings.Contains(commit.Body, "BREAKING CHANGE") {
+		entry.Breaking = true
+	}
+
+	if match := conventionalCommitPattern.FindStringSubmatch(commit.Subject); match != nil {
+		entry.Summary = match[4]
+		if match[2] != "" {
+			entry.Scope = match[2]
+		}
+		if match[3] != "" {
+			entry.Breaking = true
+		}
+		switch strings.ToLower(match[1]) {
+		case "feat", "feature":
+			if entry.Breaking {
+				return "changed", entry
+			}
+			return "added", entry
+		case "fix", "bugfix", "hotfix":
+			return "fixed", entry
+		case "docs", "test", "tests", "ci", "chore", "style", "build":
+			if entry.Breaking {
+				return "changed", entry
+			}
+			return "", entry
+		default:
+			return "changed", entry
+		}
+	}
+
+	word := strings.ToLower(strings.Fields(commit.Subject + " x")[0])
+	switch word {
+	case "add", "adds", "added", "introduce", "introduces", "support", "implement", "implements":
+		return "added", entry
+	case "fix", "fixes", "fixed", "resolve", "resolves", "correct", "handle", "prevent", "avoid":
+		return "fixed", entry
+	case "bump", "merge", "release", "docs", "test", "tests", "ci":
+		return "", entry
+	default:
+		return "changed", entry
+	}
+}
+
+// commitScope returns the package directory shared by the commit's Go files,
+// or an empty string when the commit spans several packages.
+func commitScope(files []string) string {
+	scope := ""
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		dir := path.Dir(file)
+		if scope == "" {
+			scope = dir
+		} else if scope != dir {
+			return ""
+		}
+	}
+	if scope == "." {
+		return ""
+	}
+	return scope
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func renderChangelogMarkdown(draft changelogDraft) string {
+	var b strings.Builder
+	b.WriteString("## Unreleased\n")
+	if draft.Since != "" {
+		fmt.Fprintf(&b, "\nChanges since %s.\n", draft.Since)
+	}
+	writeSection := func(title string, entries []changelogEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for _, entry := range entries {
+			b.WriteString("- ")
+			if entry.Breaking {
+				b.WriteString("**Breaking:** ")
+			}
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "%s: ", entry.Scope)
+			}
+			b.WriteString(entry.Summary)
+			if entry.Commit != "" {
+				fmt.Fprintf(&b, " (%s)", entry.Commit)
+			}
+			b.WriteString("\n")
+		}
+	}
+	writeSection("Added", draft.Added)
+	writeSection("Changed", draft.Changed)
+	writeSection("Fixed", draft.Fixed)
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGitLog(t *testing.T) {
+	output := "\x1eabc123def4567890\x1ffeat(server): add prompts\x1f\x1f\n\npkg/server/prompts.go\npkg/server/service.go\n" +
+		"\x1e0123456789abcdef\x1fFix crash on shutdown\x1fDetails here.\n\nBREAKING CHANGE: none\x1f\n\npkg/lsp/client/gopls.go\n"
+
+	commits := parseGitLog(output)
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %#v", commits)
+	}
+	if commits[0].Subject != "feat(server): add prompts" || len(commits[0].Files) != 2 {
+		t.Fatalf("unexpected first commit %#v", commits[0])
+	}
+	if !strings.Contains(commits[1].Body, "BREAKING CHANGE") || commits[1].Files[0] != "pkg/lsp/client/gopls.go" {
+		t.Fatalf("unexpected second commit %#v", commits[1])
+	}
+}
+
+func TestBuildChangelogDraft(t *testing.T) {
+	commits := []gitCommit{
+		{Hash: "1111111111111111", Subject: "feat(server): add prompts"},
+		{Hash: "2222222222222222", Subject: "Fix crash on shutdown", Files: []string{"pkg/lsp/client/gopls.go"}},
+		{Hash: "3333333333333333", Subject: "docs: update README"},
+		{Hash: "4444444444444444", Subject: "refactor!: drop legacy flags"},
+		{Hash: "5555555555555555", Subject: "Rework progress notifications", Files: []string{"pkg/tools/a.go", "pkg/server/b.go"}},
+	}
+	changes := &apiChanges{
+		Incompatible: []string{"pkg: Foo: removed"},
+		Compatible:   []string{"pkg: Bar: added"},
+	}
+
+	draft := buildChangelogDraft(commits, changes)
+	if draft.Commits != 5 || draft.Skipped != 1 {
+		t.Fatalf("unexpected counts %#v", draft)
+	}
+	if len(draft.Added) != 2 || draft.Added[0].Scope != "server" || draft.Added[1].Source != "apidiff" {
+		t.Fatalf("unexpected added entries %#v", draft.Added)
+	}
+	if len(draft.Fixed) != 1 || draft.Fixed[0].Scope != "pkg/lsp/client" || draft.Fixed[0].Commit != "222222222222" {
+		t.Fatalf("unexpected fixed entries %#v", draft.Fixed)
+	}
+	if len(draft.Changed) != 3 || !draft.Changed[0].Breaking || draft.Changed[1].Scope != "" || !draft.Changed[2].Breaking {
+		t.Fatalf("unexpected changed entries %#v", draft.Changed)
+	}
+
+	markdown := renderChangelogMarkdown(draft)
+	for _, want := range []string{"### Added", "### Fixed", "- **Breaking:** drop legacy flags (444444444444)", "- pkg/lsp/client: Fix crash on shutdown"} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+}
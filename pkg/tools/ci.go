@@ -0,0 +1,528 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	ciProviderGitHub = "github_actions"
+	ciProviderGitLab = "gitlab_ci"
+)
+
+var (
+	matrixExpressionPattern = regexp.MustCompile(`\$\{\{\s*matrix\.([A-Za-z0-9_-]+)\s*\}\}`)
+	golangImagePattern      = regexp.MustCompile(`(?:^|/)golang:(\d+(?:\.\d+){0,2})`)
+	goDirectivePattern      = regexp.MustCompile(`(?m)^go\s+(\S+)`)
+
+	// gitlabReservedKeys are top-level .gitlab-ci.yml keywords that are not jobs.
+	gitlabReservedKeys = map[string]struct{}{
+		"stages": {}, "variables": {}, "default": {}, "include": {}, "workflow": {},
+		"image": {}, "services": {}, "before_script": {}, "after_script": {}, "cache": {},
+	}
+)
+
+type ciJob struct {
+	Provider   string              `json:"provider"`
+	File       string              `json:"file"`
+	Line       int                 `json:"line"`
+	Workflow   string              `json:"workflow,omitempty"`
+	Triggers   []string            `json:"triggers,omitempty"`
+	ID         string              `json:"id"`
+	Name       string              `json:"name,omitempty"`
+	Stage      string              `json:"stage,omitempty"`
+	RunsOn     string              `json:"runs_on,omitempty"`
+	Image      string              `json:"image,omitempty"`
+	Matrix     map[string][]string `json:"matrix,omitempty"`
+	GoVersions []string            `json:"go_versions,omitempty"`
+	Actions    []string            `json:"actions,omitempty"`
+	Commands   []string            `json:"commands,omitempty"`
+	Categories []string            `json:"categories,omitempty"`
+}
+
+func (t *LSPTools) registerSummarizeCI(s *server.MCPServer) {
+	tool := mcp.NewTool("summarize_ci",
+		mcp.WithDescription("Summarize Go-related jobs from GitHub Actions and GitLab CI configuration (commands, Go versions, matrices)"),
+		mcp.WithTitleAnnotation("Summarize CI"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		jobs, files, err := collectCIJobs(t.workspaceDir)
+		if err != nil {
+			return nil, err
+		}
+
+		versionSet := make(map[string]struct{})
+		for _, job := range jobs {
+			for _, version := range job.GoVersions {
+				versionSet[version] = struct{}{}
+			}
+		}
+
+		payload := map[string]any{
+			"files":       files,
+			"jobs":        jobs,
+			"go_versions": sortedKeys(versionSet),
+		}
+		if version := readGoModVersion(t.workspaceDir); version != "" {
+			payload["go_mod_version"] = version
+		}
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func collectCIJobs(root string) ([]ciJob, []string, error) {
+	var (
+		jobs  []ciJob
+		files []string
+	)
+
+	workflows, _ := filepath.Glob(filepath.Join(root, ".github", "workflows", "*.yml"))
+	more, _ := filepath.Glob(filepath.Join(root, ".github", "workflows", "*.yaml"))
+	workflows = append(workflows, more...)
+	sort.Strings(workflows)
+
+	for _, path := range workflows {
+		found, err := parseGitHubWorkflow(root, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, relativeSlashPath(root, path))
+		jobs = append(jobs, found...)
+	}
+
+	if path := filepath.Join(root, ".gitlab-ci.yml"); fileExists(path) {
+		found, err := parseGitLabCI(root, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, relativeSlashPath(root, path))
+		jobs = append(jobs, found...)
+	}
+
+	return jobs, files, nil
+}
+
+type githubWorkflow struct {
+	Name string    `yaml:"name"`
+	On   yaml.Node `yaml:"on"`
+	Jobs yaml.Node `yaml:"jobs"`
+}
+
+type githubJob struct {
+	Name     string    `yaml:"name"`
+	RunsOn   yaml.Node `yaml:"runs-on"`
+	Uses     string    `yaml:"uses"`
+	Strategy struct {
+		Matrix yaml.Node `yaml:"matrix"`
+	} `yaml:"strategy"`
+	Container yaml.Node    `yaml:"container"`
+	Steps     []githubStep `yaml:"steps"`
+}
+
+type githubStep struct {
+	Uses string               `yaml:"uses"`
+	Run  string               `yaml:"run"`
+	With map[string]yaml.Node `yaml:"with"`
+}
+
+func parseGitHubWorkflow(root, path string) ([]ciJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", relativeSlashPath(root, path), err)
+	}
+
+	var workflow githubWorkflow
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", relativeSlashPath(root, path), err)
+	}
+	if workflow.Jobs.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	triggers := yamlNodeKeys(&workflow.On)
+	var jobs []ciJob
+	for i := 0; i+1 < len(workflow.Jobs.Content); i += 2 {
+		key, value := workflow.Jobs.Content[i], workflow.Jobs.Content[i+1]
+		var spec githubJob
+		if err := value.Decode(&spec); err != nil {
+			continue
+		}
+
+		job := ciJob{
+			Provider: ciProviderGitHub,
+			File:     relativeSlashPath(root, path),
+			Line:     key.Line,
+			Workflow: workflow.Name,
+			Triggers: triggers,
+			ID:       key.Value,
+			Name:     spec.Name,
+			RunsOn:   strings.Join(yamlScalarValues(&spec.RunsOn), ", "),
+			Matrix:   yamlMatrix(&spec.Strategy.Matrix),
+		}
+		if spec.Container.Kind == yaml.ScalarNode {
+			job.Image = spec.Container.Value
+		} else if image := yamlMappingValue(&spec.Container, "image"); image != nil {
+			job.Image = image.Value
+		}
+		if spec.Uses != "" {
+			job.Actions = append(job.Actions, spec.Uses)
+		}
+
+		var versions []string
+		for _, step := range spec.Steps {
+			if step.Uses != "" {
+				job.Actions = append(job.Actions, step.Uses)
+			}
+			if strings.HasPrefix(step.Uses, "actions/setup-go") {
+				if node, ok := step.With["go-version"]; ok {
+					versions = append(versions, expandMatrixExpression(node.Value, job.Matrix)...)
+				}
+				if node, ok := step.With["go-version-file"]; ok {
+					if version := readGoVersionFile(root, node.Value); version != "" {
+						versions = append(versions, version)
+					}
+				}
+			}
+			job.Commands = append(job.Commands, goRelatedCommands(step.Run)...)
+		}
+		if version := golangImageVersion(job.Image); version != "" {
+			versions = append(versions, version)
+		}
+
+		job.GoVersions = uniqueStrings(versions)
+		job.Categories = classifyCIJob(job.Commands, job.Actions)
+		if isGoCIJob(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+type gitlabJob struct {
+	Stage        string    `yaml:"stage"`
+	Image        yaml.Node `yaml:"image"`
+	Script       yaml.Node `yaml:"script"`
+	BeforeScript yaml.Node `yaml:"before_script"`
+	Parallel     struct {
+		Matrix []map[string]yaml.Node `yaml:"matrix"`
+	} `yaml:"parallel"`
+}
+
+func parseGitLabCI(root, path string) ([]ciJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", relativeSlashPath(root, path), err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", relativeSlashPath(root, path), err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	top := doc.Content[0]
+
+	defaultImage := gitlabImage(yamlMappingValue(top, "image"))
+	if defaults := yamlMappingValue(top, "default"); defaults != nil {
+		if image := gitlabImage(yamlMappingValue(defaults, "image")); image != "" {
+			defaultImage = image
+		}
+	}
+
+	var jobs []ciJob
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		key, value := top.Content[i], top.Content[i+1]
+		if _, reserved := gitlabReservedKeys[key.Value]; reserved || strings.HasPrefix(key.Value, ".") {
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			continue
+		}
+		var spec gitlabJob
+		if err := value.Decode(&spec); err != nil {
+			continue
+		}
+
+		job := ciJob{
+			Provider: ciProviderGitLab,
+			File:     relativeSlashPath(root, path),
+			Line:     key.Line,
+			ID:       key.Value,
+			Stage:    spec.Stage,
+			Image:    gitlabImage(&spec.Image),
+		}
+		if job.Image == "" {
+			job.Image = defaultImage
+		}
+		if len(spec.Parallel.Matrix) > 0 {
+			job.Matrix = make(map[string][]string)
+			for _, entry := range spec.Parallel.Matrix {
+				for name, node := range entry {
+					job.Matrix[name] = uniqueStrings(append(job.Matrix[name], yamlScalarValues(&node)...))
+				}
+			}
+		}
+		for _, line := range append(yamlScalarValues(&spec.BeforeScript), yamlScalarValues(&spec.Script)...) {
+			job.Commands = append(job.Commands, goRelatedCommands(line)...)
+		}
+		if version := golangImageVersion(job.Image); version != "" {
+			job.GoVersions = []string{version}
+		}
+		job.Categories = classifyCIJob(job.Commands, nil)
+		if isGoCIJob(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func gitlabImage(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	if name := yamlMappingValue(node, "name"); name != nil {
+		return name.Value
+	}
+	return ""
+}
+
+// goRelatedCommands extracts shell lines that invoke Go tooling or common
+// build wrappers from a CI script block.
+func goRelatedCommands(script string) []string {
+	var commands []string
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "go", "make", "task", "mage", "golangci-lint", "staticcheck", "govulncheck", "gotestsum", "goreleaser", "gofmt", "goimports":
+			commands = append(commands, line)
+		}
+	}
+	return commands
+}
+
+func classifyCIJob(commands, actions []string) []string {
+	categories := make(map[string]struct{})
+	add := func(name string) { categories[name] = struct{}{} }
+
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		switch {
+		case fields[0] == "golangci-lint" || fields[0] == "staticcheck" || fields[0] == "gofmt" || fields[0] == "goimports":
+			add("lint")
+		case fields[0] == "govulncheck":
+			add("vuln")
+		case fields[0] == "gotestsum":
+			add("test")
+		case fields[0] == "goreleaser":
+			add("release")
+		case fields[0] == "go" && len(fields) > 1:
+			switch fields[1] {
+			case "test":
+				add("test")
+			case "build", "install":
+				add("build")
+			case "vet":
+				add("vet")
+			case "generate":
+				add("generate")
+			case "mod":
+				add("modules")
+			case "run":
+				if strings.Contains(command, "govulncheck") {
+					add("vuln")
+				}
+			}
+		case len(fields) > 1:
+			// make/task/mage targets commonly mirror the Go sub-command names.
+			for _, target := range fields[1:] {
+				switch {
+				case strings.Contains(target, "lint") || strings.Contains(target, "fmt"):
+					add("lint")
+				case strings.Contains(target, "test") || strings.Contains(target, "cover"):
+					add("test")
+				case strings.Contains(target, "build"):
+					add("build")
+				case strings.Contains(target, "vet"):
+					add("vet")
+				case strings.Contains(target, "tidy"):
+					add("modules")
+				}
+			}
+		}
+	}
+
+	for _, action := range actions {
+		switch {
+		case strings.Contains(action, "golangci-lint-action"):
+			add("lint")
+		case strings.Contains(action, "goreleaser-action"):
+			add("release")
+		case strings.Contains(action, "govulncheck-action"):
+			add("vuln")
+		}
+	}
+	return sortedKeys(categories)
+}
+
+func isGoCIJob(job ciJob) bool {
+	if len(job.Commands) > 0 || len(job.GoVersions) > 0 {
+		return true
+	}
+	for _, action := range job.Actions {
+		if strings.HasPrefix(action, "actions/setup-go") {
+			return true
+		}
+	}
+	return len(job.Categories) > 0
+}
+
+func expandMatrixExpression(value string, matrix map[string][]string) []string {
+	match := matrixExpressionPattern.FindStringSubmatch(value)
+	if match == nil {
+		return []string{value}
+	}
+	values, ok := matrix[match[1]]
+	if !ok {
+		return []string{value}
+	}
+	return values
+}
+
+func golangImageVersion(image string) string {
+	match := golangImagePattern.FindStringSubmatch(image)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func readGoVersionFile(root, name string) string {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(name, ".mod") || strings.HasSuffix(name, ".work") {
+		match := goDirectivePattern.FindSubmatch(data)
+		if match == nil {
+			return ""
+		}
+		return string(match[1])
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(data)), "go")
+}
+
+func readGoModVersion(root string) string {
+	return readGoVersionFile(root, "go.mod")
+}
+
+func yamlMatrix(node *yaml.Node) map[string][]string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	matrix := make(map[string][]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if key == "include" || key == "exclude" {
+			continue
+		}
+		if values := yamlScalarValues(node.Content[i+1]); len(values) > 0 {
+			matrix[key] = values
+		}
+	}
+	if len(matrix) == 0 {
+		return nil
+	}
+	return matrix
+}
+
+func yamlScalarValues(node *yaml.Node) []string {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			return nil
+		}
+		return []string{node.Value}
+	case yaml.SequenceNode:
+		var values []string
+		for _, child := range node.Content {
+			if child.Kind == yaml.ScalarNode {
+				values = append(values, child.Value)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func yamlNodeKeys(node *yaml.Node) []string {
+	switch node.Kind {
+	case yaml.ScalarNode, yaml.SequenceNode:
+		return yamlScalarValues(node)
+	case yaml.MappingNode:
+		keys := make([]string, 0, len(node.Content)/2)
+		for i := 0; i < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+		}
+		return keys
+	default:
+		return nil
+	}
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	var result []string
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollectCIJobsGitHubActions(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/demo\n\ngo 1.24.2\n")
+	writeWorkspaceFile(t, workspace, ".github/workflows/ci.yml", `name: CI
+on:
+  push:
+  pull_request:
+jobs:
+  test:
+    runs-on: ${{ matrix.os }}
+    strategy:
+      matrix:
+        os: [ubuntu-latest, macos-latest]
+        go: ["1.23", "1.24"]
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: ${{ matrix.go }}
+      - run: |
+          go vet ./...
+          go test -race ./...
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+      - uses: golangci/golangci-lint-action@v6
+  docs:
+    runs-on: ubuntu-latest
+    steps:
+      - run: npm run build
+`)
+
+	jobs, files, err := collectCIJobs(workspace)
+	if err != nil {
+		t.Fatalf("collectCIJobs returned error: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{".github/workflows/ci.yml"}) {
+		t.Fatalf("unexpected files %#v", files)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected the two Go jobs, got %#v", jobs)
+	}
+
+	test := jobs[0]
+	if test.ID != "test" || !reflect.DeepEqual(test.GoVersions, []string{"1.23", "1.24"}) {
+		t.Fatalf("unexpected test job %#v", test)
+	}
+	if !reflect.DeepEqual(test.Categories, []string{"test", "vet"}) {
+		t.Fatalf("unexpected categories %#v", test.Categories)
+	}
+	if !reflect.DeepEqual(test.Triggers, []string{"push", "pull_request"}) {
+		t.Fatalf("unexpected triggers %#v", test.Triggers)
+	}
+
+	lint := jobs[1]
+	if !reflect.DeepEqual(lint.GoVersions, []string{"1.24.2"}) || !reflect.DeepEqual(lint.Categories, []string{"lint"}) {
+		t.Fatalf("unexpected lint job %#v", lint)
+	}
+}
+
+func TestCollectCIJobsGitLab(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, ".gitlab-ci.yml", `image: golang:1.22
+stages: [test]
+.template:
+  script: [go env]
+unit:
+  stage: test
+  script:
+    - go test ./...
+  parallel:
+    matrix:
+      - GOARCH: [amd64, arm64]
+pages:
+  image: node:20
+  script:
+    - npm ci
+`)
+
+	jobs, _, err := collectCIJobs(workspace)
+	if err != nil {
+		t.Fatalf("collectCIJobs returned error: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("expected one Go job, got %#v", jobs)
+	}
+	job := jobs[0]
+	if job.ID != "unit" || job.Stage != "test" || job.Image != "golang:1.22" {
+		t.Fatalf("unexpected job %#v", job)
+	}
+	if !reflect.DeepEqual(job.GoVersions, []string{"1.22"}) {
+		t.Fatalf("unexpected go versions %#v", job.GoVersions)
+	}
+	if !reflect.DeepEqual(job.Matrix["GOARCH"], []string{"amd64", "arm64"}) {
+		t.Fatalf("unexpected matrix %#v", job.Matrix)
+	}
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// codeLens is a gopls code lens with the line it annotates. Index
+// identifies it when executing it; Line is 1-based.
+type codeLens struct {
+	Index     int            `json:"index"`
+	Title     string         `json:"title"`
+	Command   string         `json:"command"`
+	Arguments []any          `json:"arguments,omitempty"`
+	Range     protocol.Range `json:"range"`
+	Line      int            `json:"line"`
+	Code      string         `json:"code,omitempty"`
+}
+
+func (t *LSPTools) registerCodeLens(s *server.MCPServer) {
+	tool := mcp.NewTool("code_lens",
+		mcp.WithDescription("List the gopls code lenses of a file (run test or benchmark, go generate, regenerate cgo definitions, tidy, upgrade or vendor a module), or execute one through workspace/executeCommand by its index. The edits the command sends back are returned as a unified diff per file and written when apply is true; commands such as go generate change files themselves, so they do not run while edits wait for approval, and test output is not captured (use run_go_test for it)"),
+		mcp.WithTitleAnnotation("Code Lens"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the Go file or go.mod"),
+		),
+		mcp.WithNumber("execute",
+			mcp.Description("Index of the lens to execute, as listed; list only when omitted"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edits of the executed command instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		lenses, err := lspClient.CodeLenses(ctx, fileURI)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		// Lenses without a command need codeLens/resolve, which gopls
+		// does not use.
+		lenses = slices.DeleteFunc(lenses, func(lens protocol.CodeLens) bool { return lens.Command == nil })
+		slices.SortStableFunc(lenses, func(a, b protocol.CodeLens) int {
+			return cmp.Or(cmp.Compare(a.Range.Start.Line, b.Range.Start.Line), cmp.Compare(a.Range.Start.Character, b.Range.Start.Character))
+		})
+		src, _ := os.ReadFile(convertURIToPath(fileURI))
+		listed := describeCodeLenses(lenses, src)
+
+		payload := map[string]any{"file_uri": fileURI, "lenses": listed}
+		if _, ok := args["execute"]; ok {
+			index, err := getIntFromObject(args, "execute")
+			if err != nil {
+				return nil, err
+			}
+			if index < 0 || index >= len(lenses) {
+				return mcp.NewToolResultError(fmt.Sprintf("no code lens %d; the file has %d", index, len(lenses))), nil
+			}
+			lens := listed[index]
+			if t.approvals != nil && !editOnlyCommands[lens.Command] {
+				return mcp.NewToolResultError(fmt.Sprintf("%s may change files or run go commands itself, bypassing the approval queue; it cannot run while edits wait for approval", lens.Command)), nil
+			}
+			if !editOnlyCommands[lens.Command] {
+				if err := t.checkCommandContract(lens.Command); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+			edits, err := lspClient.ExecuteCommand(ctx, *lenses[index].Command)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			changes, err := workspaceEditChanges(mergeWorkspaceEdits(edits))
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("compute code lens diff: %v", err)), nil
+			}
+			apply := getOptionalBoolArg(args, "apply") && len(changes) > 0
+			if apply {
+				if err := t.writeFileChanges(ctx, changes); err != nil {
+					return writeFailureResult("apply code lens", err), nil
+				}
+			}
+			payload = map[string]any{
+				"file_uri": fileURI,
+				"executed": lens,
+				"files":    t.summarizeFileChanges(changes),
+				"applied":  apply,
+			}
+		}
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func describeCodeLenses(lenses []protocol.CodeLens, src []byte) []codeLens {
+	lines := bytes.Split(src, []byte("\n"))
+	listed := make([]codeLens, 0, len(lenses))
+	for i, lens := range lenses {
+		entry := codeLens{
+			Index:     i,
+			Title:     lens.Command.Title,
+			Command:   lens.Command.Command,
+			Arguments: lens.Command.Arguments,
+			Range:     lens.Range,
+			Line:      lens.Range.Start.Line + 1,
+		}
+		if lens.Range.Start.Line < len(lines) {
+			entry.Code = strings.TrimSpace(string(lines[lens.Range.Start.Line]))
+		}
+		listed = append(listed, entry)
+	}
+	return listed
+}
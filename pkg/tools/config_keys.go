@@ -0,0 +1,419 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	configSourceEnv   = "env"
+	configSourceViper = "viper"
+	configSourceFlag  = "flag"
+)
+
+var viperKeyMethods = map[string]bool{
+	"Get": true, "GetString": true, "GetBool": true, "GetInt": true, "GetInt32": true, "GetInt64": true,
+	"GetUint": true, "GetUint16": true, "GetUint32": true, "GetUint64": true, "GetFloat64": true,
+	"GetDuration": true, "GetTime": true, "GetIntSlice": true, "GetStringSlice": true, "GetStringMap": true,
+	"GetStringMapString": true, "GetStringMapStringSlice": true, "GetSizeInBytes": true,
+	"IsSet": true, "SetDefault": true, "BindEnv": true, "BindPFlag": true, "Sub": true, "UnmarshalKey": true,
+}
+
+type configUsage struct {
+	Via      string            `json:"via"`
+	Location protocol.Location `json:"location"`
+}
+
+type configKey struct {
+	Key      string        `json:"key"`
+	Source   string        `json:"source"`
+	Defaults []string      `json:"defaults,omitempty"`
+	Required bool          `json:"required,omitempty"`
+	Usages   []configUsage `json:"usages"`
+}
+
+func (t *LSPTools) registerListConfigKeys(s *server.MCPServer) {
+	tool := mcp.NewTool("list_config_keys",
+		mcp.WithDescription("List the configuration a service reads: environment variables (os.Getenv, envconfig/env struct tags), viper keys and command-line flags, with defaults and locations"),
+		mcp.WithTitleAnnotation("List Config Keys"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("source", mcp.Description("Only report keys from this source"), mcp.Enum(configSourceEnv, configSourceViper, configSourceFlag)),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		source := getOptionalStringArg(args, "source")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		keys := []*configKey{}
+		for _, key := range collectConfigKeys(ws) {
+			if source == "" || key.Source == source {
+				keys = append(keys, key)
+			}
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"keys":         keys,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// envHelper describes a wrapper such as getenv(key, fallback string) whose
+// calls should be reported like os.Getenv calls.
+type envHelper struct {
+	keyIndex     int
+	defaultIndex int
+}
+
+type configCollector struct {
+	consts  map[string]map[string]string
+	helpers map[string]envHelper
+	keys    map[string]*configKey
+}
+
+func collectConfigKeys(ws *gosrc.Workspace) []*configKey {
+	c := &configCollector{
+		consts:  stringConstants(ws),
+		helpers: findEnvHelpers(ws),
+		keys:    make(map[string]*configKey),
+	}
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			c.collectFile(pkg, file)
+		}
+	}
+	keys := make([]*configKey, 0, len(c.keys))
+	for _, id := range sortedKeys(c.keys) {
+		keys = append(keys, c.keys[id])
+	}
+	return keys
+}
+
+func (c *configCollector) add(source, key, via, defaultValue string, required bool, location protocol.Location) {
+	if source == configSourceViper {
+		// Viper keys are case-insensitive.
+		key = strings.ToLower(key)
+	}
+	id := source + "\x00" + key
+	entry, ok := c.keys[id]
+	if !ok {
+		entry = &configKey{Key: key, Source: source}
+		c.keys[id] = entry
+	}
+	if defaultValue != "" && !slices.Contains(entry.Defaults, defaultValue) {
+		entry.Defaults = append(entry.Defaults, defaultValue)
+	}
+	entry.Required = entry.Required || required
+	entry.Usages = append(entry.Usages, configUsage{Via: via, Location: location})
+}
+
+func (c *configCollector) collectFile(pkg *gosrc.Package, file *gosrc.File) {
+	imports := fileImports(file.Syntax)
+	usesViper := hasImportPrefix(imports, "github.com/spf13/viper")
+	usesFlags := hasImportPrefix(imports, "flag") || hasImportPrefix(imports, "github.com/spf13/pflag")
+	key := func(expr ast.Expr) (string, bool) {
+		return constantString(pkg.ImportPath, imports, c.consts, expr)
+	}
+	// Defaults applied with cmp.Or or an `if v == "" { v = ... }` block are
+	// attached to the Getenv call they follow.
+	fallbacks := envFallbacks(file.Syntax, imports)
+
+	ast.Inspect(file.Syntax, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.StructType:
+			c.collectStructTags(file, node)
+		case *ast.CallExpr:
+			if importPath, name, ok := packageCall(imports, node); ok {
+				if (importPath == "os" || importPath == "syscall") && (name == "Getenv" || name == "LookupEnv") && len(node.Args) == 1 {
+					if k, ok := key(node.Args[0]); ok {
+						c.add(configSourceEnv, k, importPath+"."+name, fallbacks[node], false, sourceLocation(file, node.Pos(), node.End()))
+					}
+					return true
+				}
+				if helper, ok := c.helpers[importPath+"."+name]; ok {
+					c.collectHelperCall(file, node, helper, key)
+					return true
+				}
+			}
+			if fn, ok := node.Fun.(*ast.Ident); ok {
+				if helper, ok := c.helpers[pkg.ImportPath+"."+fn.Name]; ok {
+					c.collectHelperCall(file, node, helper, key)
+					return true
+				}
+			}
+			recv, name, ok := methodCall(node)
+			if !ok || len(node.Args) == 0 {
+				return true
+			}
+			if usesViper && viperKeyMethods[name] {
+				if k, ok := key(node.Args[0]); ok {
+					defaultValue := ""
+					if name == "SetDefault" && len(node.Args) == 2 {
+						defaultValue = literalText(node.Args[1])
+					}
+					c.add(configSourceViper, k, exprText(recv)+"."+name, defaultValue, false, sourceLocation(file, node.Pos(), node.End()))
+					if name == "BindEnv" {
+						for _, arg := range node.Args[1:] {
+							if envKey, ok := key(arg); ok {
+								c.add(configSourceEnv, envKey, "viper.BindEnv", "", false, sourceLocation(file, node.Pos(), node.End()))
+							}
+						}
+					}
+				}
+				return true
+			}
+			if usesFlags {
+				c.collectFlag(file, node, recv, name, key)
+			}
+		}
+		return true
+	})
+}
+
+// collectFlag records flag.String("name", "default", "usage") style
+// definitions, including the XxxVar forms and pflag's shorthand XxxP forms.
+func (c *configCollector) collectFlag(file *gosrc.File, call *ast.CallExpr, recv ast.Expr, name string, key func(ast.Expr) (string, bool)) {
+	base := name
+	shorthand := strings.HasSuffix(base, "P")
+	base = strings.TrimSuffix(base, "P")
+	nameIndex := 0
+	if strings.HasSuffix(base, "Var") {
+		nameIndex = 1
+		base = strings.TrimSuffix(base, "Var")
+	}
+	defaultIndex := nameIndex + 1
+	if shorthand {
+		defaultIndex++
+	}
+	switch base {
+	case "String", "Bool", "Int", "Int64", "Uint", "Uint64", "Float64", "Duration", "StringSlice", "IntSlice", "StringArray":
+	case "", "Func", "BoolFunc":
+		// flag.Var and flag.Func carry no default value.
+		if base == "" && nameIndex == 0 {
+			return
+		}
+		defaultIndex = -1
+	default:
+		return
+	}
+	if len(call.Args) <= max(nameIndex, defaultIndex) {
+		return
+	}
+	flagName, ok := key(call.Args[nameIndex])
+	if !ok || flagName == "" {
+		return
+	}
+	defaultValue := ""
+	if defaultIndex >= 0 {
+		defaultValue = literalText(call.Args[defaultIndex])
+	}
+	c.add(configSourceFlag, flagName, exprText(recv)+"."+name, defaultValue, false, sourceLocation(file, call.Pos(), call.End()))
+}
+
+func (c *configCollector) collectHelperCall(file *gosrc.File, call *ast.CallExpr, helper envHelper, key func(ast.Expr) (string, bool)) {
+	if helper.keyIndex >= len(call.Args) {
+		return
+	}
+	k, ok := key(call.Args[helper.keyIndex])
+	if !ok {
+		return
+	}
+	defaultValue := ""
+	if helper.defaultIndex >= 0 && helper.defaultIndex < len(call.Args) {
+		defaultValue = literalText(call.Args[helper.defaultIndex])
+	}
+	c.add(configSourceEnv, k, exprText(call.Fun), defaultValue, false, sourceLocation(file, call.Pos(), call.End()))
+}
+
+// collectStructTags records fields tagged for kelseyhightower/envconfig
+// (`envconfig:"PORT" default:"8080" required:"true"`) and caarlos0/env
+// (`env:"PORT,required" envDefault:"8080"`).
+func (c *configCollector) collectStructTags(file *gosrc.File, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tag := reflect.StructTag(raw)
+		location := sourceLocation(file, field.Pos(), field.End())
+		if name, ok := tag.Lookup("envconfig"); ok && name != "" && name != "-" {
+			name = strings.Split(name, ",")[0]
+			c.add(configSourceEnv, name, "envconfig tag", tag.Get("default"), tag.Get("required") == "true", location)
+		}
+		if value, ok := tag.Lookup("env"); ok && value != "" && value != "-" {
+			parts := strings.Split(value, ",")
+			required := false
+			for _, option := range parts[1:] {
+				if option == "required" || option == "notEmpty" {
+					required = true
+				}
+			}
+			if parts[0] != "" {
+				c.add(configSourceEnv, parts[0], "env tag", tag.Get("envDefault"), required, location)
+			}
+		}
+	}
+}
+
+// findEnvHelpers finds package-level functions that pass one of their
+// parameters to os.Getenv or os.LookupEnv. When the function takes another
+// parameter, it is assumed to be the fallback value.
+func findEnvHelpers(ws *gosrc.Workspace) map[string]envHelper {
+	helpers := make(map[string]envHelper)
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || fn.Body == nil || fn.Type.Params == nil {
+					continue
+				}
+				var params []string
+				for _, field := range fn.Type.Params.List {
+					for _, name := range field.Names {
+						params = append(params, name.Name)
+					}
+				}
+				if len(params) == 0 || len(params) > 3 {
+					continue
+				}
+				keyIndex := -1
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok || keyIndex >= 0 || len(call.Args) != 1 {
+						return keyIndex < 0
+					}
+					importPath, name, ok := packageCall(imports, call)
+					if !ok || importPath != "os" || (name != "Getenv" && name != "LookupEnv") {
+						return true
+					}
+					if ident, ok := call.Args[0].(*ast.Ident); ok {
+						for i, param := range params {
+							if param == ident.Name {
+								keyIndex = i
+							}
+						}
+					}
+					return true
+				})
+				if keyIndex < 0 {
+					continue
+				}
+				helper := envHelper{keyIndex: keyIndex, defaultIndex: -1}
+				for i := range params {
+					if i != keyIndex {
+						helper.defaultIndex = i
+						break
+					}
+				}
+				helpers[pkg.ImportPath+"."+fn.Name.Name] = helper
+			}
+		}
+	}
+	return helpers
+}
+
+// envFallbacks maps Getenv calls to the default applied right after them,
+// either through cmp.Or(os.Getenv("X"), "default") or through
+//
+//	v := os.Getenv("X")
+//	if v == "" {
+//		v = "default"
+//	}
+func envFallbacks(file *ast.File, imports map[string]string) map[*ast.CallExpr]string {
+	fallbacks := make(map[*ast.CallExpr]string)
+	isGetenv := func(expr ast.Expr) (*ast.CallExpr, bool) {
+		call, ok := unwrapParens(expr).(*ast.CallExpr)
+		if !ok {
+			return nil, false
+		}
+		importPath, name, ok := packageCall(imports, call)
+		return call, ok && importPath == "os" && name == "Getenv"
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			if importPath, name, ok := packageCall(imports, node); ok && importPath == "cmp" && name == "Or" && len(node.Args) >= 2 {
+				if call, ok := isGetenv(node.Args[0]); ok {
+					fallbacks[call] = literalText(node.Args[len(node.Args)-1])
+				}
+			}
+		case *ast.BlockStmt:
+			for i := 0; i+1 < len(node.List); i++ {
+				assign, ok := node.List[i].(*ast.AssignStmt)
+				if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+					continue
+				}
+				variable, ok := assign.Lhs[0].(*ast.Ident)
+				if !ok {
+					continue
+				}
+				call, ok := isGetenv(assign.Rhs[0])
+				if !ok {
+					continue
+				}
+				if value, ok := emptyCheckDefault(node.List[i+1], variable.Name); ok {
+					fallbacks[call] = value
+				}
+			}
+		}
+		return true
+	})
+	return fallbacks
+}
+
+// emptyCheckDefault matches `if name == "" { name = value }`.
+func emptyCheckDefault(stmt ast.Stmt, name string) (string, bool) {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok || ifStmt.Init != nil || len(ifStmt.Body.List) != 1 {
+		return "", false
+	}
+	cond, ok := ifStmt.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.EQL {
+		return "", false
+	}
+	if ident, ok := cond.X.(*ast.Ident); !ok || ident.Name != name {
+		return "", false
+	}
+	if value, ok := stringValue(cond.Y); !ok || value != "" {
+		return "", false
+	}
+	assign, ok := ifStmt.Body.List[0].(*ast.AssignStmt)
+	if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return "", false
+	}
+	if ident, ok := assign.Lhs[0].(*ast.Ident); !ok || ident.Name != name {
+		return "", false
+	}
+	return literalText(assign.Rhs[0]), true
+}
+
+// literalText renders a default value: string literals unquoted, anything
+// else as source text.
+func literalText(expr ast.Expr) string {
+	if value, ok := stringValue(expr); ok {
+		return value
+	}
+	return exprText(expr)
+}
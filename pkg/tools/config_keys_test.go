@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCollectConfigKeys(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/svc\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "config/env.go", `package config
+
+import "os"
+
+const PortKey = "PORT"
+
+func Getenv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+type Spec struct {
+	Debug   bool   `+"`"+`envconfig:"DEBUG" default:"false"`+"`"+`
+	Token   string `+"`"+`env:"API_TOKEN,required"`+"`"+`
+	Ignored string `+"`"+`json:"ignored"`+"`"+`
+}
+`)
+	writeWorkspaceFile(t, workspace, "main.go", `package main
+
+import (
+	"cmp"
+	"flag"
+	"os"
+
+	"github.com/spf13/viper"
+
+	"example.com/svc/config"
+)
+
+func main() {
+	host := os.Getenv("HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	region := cmp.Or(os.Getenv("REGION"), "eu-west-1")
+	port := config.Getenv(config.PortKey, "8080")
+	viper.SetDefault("Log.Level", "info")
+	level := viper.GetString("log.level")
+	verbose := flag.Bool("verbose", false, "verbose output")
+	var name string
+	flag.StringVar(&name, "name", "svc", "service name")
+	_, _, _, _, _, _ = host, region, port, level, verbose, name
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	keys := collectConfigKeys(ws)
+	got := make(map[string]*configKey)
+	for _, key := range keys {
+		got[key.Source+":"+key.Key] = key
+	}
+
+	expectDefaults := map[string][]string{
+		"env:HOST":        {"localhost"},
+		"env:REGION":      {"eu-west-1"},
+		"env:PORT":        {"8080"},
+		"env:DEBUG":       {"false"},
+		"env:API_TOKEN":   nil,
+		"viper:log.level": {"info"},
+		"flag:verbose":    {"false"},
+		"flag:name":       {"svc"},
+	}
+	if len(got) != len(expectDefaults) {
+		t.Fatalf("expected %d keys, got %d: %v", len(expectDefaults), len(got), sortedKeys(got))
+	}
+	for id, defaults := range expectDefaults {
+		key, ok := got[id]
+		if !ok {
+			t.Fatalf("missing key %s in %v", id, sortedKeys(got))
+		}
+		if !reflect.DeepEqual(key.Defaults, defaults) {
+			t.Errorf("%s: expected defaults %v, got %v", id, defaults, key.Defaults)
+		}
+	}
+	if !got["env:API_TOKEN"].Required {
+		t.Errorf("expected API_TOKEN to be required")
+	}
+	if usages := got["viper:log.level"].Usages; len(usages) != 2 {
+		t.Errorf("expected viper key usages to merge case-insensitively, got %+v", usages)
+	}
+	if usage := got["env:PORT"].Usages[0]; usage.Via != "config.Getenv" || usage.Location.Range.Start.Line != 18 {
+		t.Errorf("unexpected PORT usage: %+v", usage)
+	}
+}
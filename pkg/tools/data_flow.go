@@ -0,0 +1,527 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+const (
+	defaultDataFlowDepth = 5
+	maxDataFlowDepth     = 10
+	defaultDataFlowPaths = 3
+	// maxDataFlowValues bounds the values the search taints, for programs
+	// where the source reaches nearly everything.
+	maxDataFlowValues = 100000
+)
+
+// flowStep is one hop of a data flow path. Kind is source, flow (an
+// assignment or expression), store (a write to memory), call (into a
+// function of the module), return (back to its caller), external (through
+// a function outside the module) or sink.
+type flowStep struct {
+	Kind     string `json:"kind"`
+	Function string `json:"function"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Code     string `json:"code,omitempty"`
+}
+
+// flowPath is a path from the source to one call of the sink.
+type flowPath struct {
+	Sink  string     `json:"sink"`
+	Steps []flowStep `json:"steps"`
+}
+
+// flowFrame is the call through which the search entered a function, so
+// that values it returns flow back to that call only.
+type flowFrame struct {
+	site  ssa.CallInstruction
+	outer *flowFrame
+}
+
+// taintOrigin records how a value came to carry source data.
+type taintOrigin struct {
+	from  ssa.Value
+	kind  string
+	pos   token.Pos
+	fn    *ssa.Function
+	depth int
+	frame *flowFrame
+}
+
+// dataFlowSearch is a breadth-first taint propagation over the SSA form of
+// the program. It follows explicit flows only: assignments, expressions,
+// memory, channels, closures, and calls into the functions of the module up
+// to depth nested calls. Calls outside the module pass data from any
+// argument to their results and to the arguments they could write to.
+type dataFlowSearch struct {
+	prog       *ssa.Program
+	modulePath string
+	sink       string
+	depth      int
+	limit      int
+
+	origins   map[ssa.Value]taintOrigin
+	queue     []ssa.Value
+	graph     *callgraph.Graph
+	paths     []flowPath
+	sinks     map[token.Pos]bool
+	truncated bool
+	lines     map[string][]string
+	workspace string
+}
+
+func (t *LSPTools) registerDataFlow(s *server.MCPServer) {
+	tool := mcp.NewTool("data_flow",
+		mcp.WithDescription("Answer whether data from a source can reach a sink, for security reviews: the results of calls to the source function (such as os.Getenv), or one of its parameters, flowing into the arguments of calls to the sink function (such as exec.Command). Follows assignments, struct fields, slices, maps, channels, closures and calls through the module's SSA form, up to depth nested calls, and returns the path of each flow found. Only explicit data flow is tracked, not branches that depend on the data"),
+		mcp.WithTitleAnnotation("Data Flow"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("source",
+			mcp.Required(),
+			mcp.Description("Source function or method (Name, pkg.Name, import/path.Name or Type.Method)"),
+		),
+		mcp.WithString("source_param",
+			mcp.Description("Start from this parameter of the source function instead of the results of calls to it"),
+		),
+		mcp.WithString("sink",
+			mcp.Required(),
+			mcp.Description("Sink function or method, named like source; data reaches it when it flows into an argument or the receiver of a call to it"),
+		),
+		mcp.WithString("package",
+			mcp.Description("Package pattern to analyze (default ./...)"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description(fmt.Sprintf("How many nested calls into module functions to follow (default %d, at most %d)", defaultDataFlowDepth, maxDataFlowDepth)),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of paths to return, one per sink call (default %d)", defaultDataFlowPaths)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		source, err := getStringArg(args, "source")
+		if err != nil {
+			return nil, err
+		}
+		sink, err := getStringArg(args, "sink")
+		if err != nil {
+			return nil, err
+		}
+		sourceParam := getOptionalStringArg(args, "source_param")
+		pattern := getOptionalStringArg(args, "package")
+		if pattern == "" {
+			pattern = "./..."
+		}
+		depth := defaultDataFlowDepth
+		if _, ok := args["depth"]; ok {
+			if depth, err = getIntFromObject(args, "depth"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if depth < 0 || depth > maxDataFlowDepth {
+				return mcp.NewToolResultError(fmt.Sprintf("depth must be between 0 and %d", maxDataFlowDepth)), nil
+			}
+		}
+		limit := defaultDataFlowPaths
+		if _, ok := args["limit"]; ok {
+			if limit, err = getIntFromObject(args, "limit"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 {
+				return mcp.NewToolResultError("limit must be at least 1"), nil
+			}
+		}
+
+		if token := getProgressToken(request.Params.Meta); token != nil {
+			sendProgressNotification(ctx, s, token, "Building SSA for "+pattern)
+		}
+		prog, initial, modulePath, err := t.loadSSAProgram(ctx, pattern, false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		search := t.newDataFlowSearch(prog, modulePath, sink, depth, limit)
+		if problem := search.seed(initial, source, sourceParam); problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		search.run()
+
+		payload := map[string]any{
+			"source":    source,
+			"sink":      sink,
+			"package":   pattern,
+			"depth":     depth,
+			"found":     len(search.paths) > 0,
+			"paths":     search.paths,
+			"explored":  len(search.origins),
+			"truncated": search.truncated,
+		}
+		if sourceParam != "" {
+			payload["source_param"] = sourceParam
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) newDataFlowSearch(prog *ssa.Program, modulePath, sink string, depth, limit int) *dataFlowSearch {
+	return &dataFlowSearch{
+		prog:       prog,
+		modulePath: modulePath,
+		sink:       sink,
+		depth:      depth,
+		limit:      limit,
+		origins:    make(map[ssa.Value]taintOrigin),
+		paths:      []flowPath{},
+		sinks:      make(map[token.Pos]bool),
+		lines:      make(map[string][]string),
+		workspace:  t.workspaceDir,
+	}
+}
+
+// seed taints the starting values: a parameter of the source function, or
+// the results of every call to it in the module. It also checks the sink is
+// called somewhere, so a misspelled name is reported rather than answered
+// with "no flow".
+func (q *dataFlowSearch) seed(initial []*ssa.Package, source, sourceParam string) string {
+	if sourceParam != "" {
+		fn, problem := findSSAFunction(initial, source)
+		if problem != "" {
+			return problem
+		}
+		var names []string
+		for _, param := range fn.Params {
+			if param.Name() == sourceParam {
+				q.taint(param, taintOrigin{kind: "source", pos: param.Pos(), fn: fn})
+			}
+			names = append(names, param.Name())
+		}
+		if len(q.queue) == 0 {
+			return fmt.Sprintf("%s has no parameter %q; its parameters are: %s", source, sourceParam, strings.Join(names, ", "))
+		}
+	}
+
+	sinkCalled := false
+	for fn := range ssautil.AllFunctions(q.prog) {
+		if !q.inModule(fn) {
+			continue
+		}
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				if callMatches(call.Common(), q.sink) {
+					sinkCalled = true
+				}
+				if value, ok := call.(*ssa.Call); ok && sourceParam == "" && callMatches(call.Common(), source) {
+					q.taint(value, taintOrigin{kind: "source", pos: value.Pos(), fn: fn})
+				}
+			}
+		}
+	}
+	switch {
+	case len(q.queue) == 0:
+		return fmt.Sprintf("no calls to %s in the analyzed packages", source)
+	case !sinkCalled:
+		return fmt.Sprintf("no calls to %s in the analyzed packages", q.sink)
+	}
+	return ""
+}
+
+func (q *dataFlowSearch) taint(value ssa.Value, origin taintOrigin) {
+	if _, seen := q.origins[value]; seen {
+		return
+	}
+	if len(q.origins) == maxDataFlowValues {
+		q.truncated = true
+		return
+	}
+	q.origins[value] = origin
+	q.queue = append(q.queue, value)
+}
+
+func (q *dataFlowSearch) run() {
+	for len(q.queue) > 0 && len(q.paths) < q.limit {
+		value := q.queue[0]
+		q.queue = q.queue[1:]
+		origin := q.origins[value]
+		refs := value.Referrers()
+		if refs == nil {
+			continue
+		}
+		for _, instr := range *refs {
+			fn := instr.Parent()
+			next := taintOrigin{from: value, kind: "flow", pos: instr.Pos(), fn: fn, depth: origin.depth, frame: origin.frame}
+			switch instr := instr.(type) {
+			case ssa.CallInstruction:
+				q.followCall(value, origin, instr)
+				continue
+			case *ssa.Return:
+				q.followReturn(value, origin, instr)
+				continue
+			case *ssa.Store:
+				if instr.Val != value {
+					continue
+				}
+				// Writing through a field or element address taints the
+				// whole object, so later reads of it carry the data.
+				next.kind = "store"
+				for addr := instr.Addr; addr != nil; {
+					q.taint(addr, next)
+					switch a := addr.(type) {
+					case *ssa.FieldAddr:
+						addr = a.X
+					case *ssa.IndexAddr:
+						addr = a.X
+					default:
+						addr = nil
+					}
+				}
+				continue
+			case *ssa.Send:
+				if instr.X == value {
+					q.taint(instr.Chan, next)
+				}
+				continue
+			case *ssa.MapUpdate:
+				if instr.Key == value || instr.Value == value {
+					q.taint(instr.Map, next)
+				}
+				continue
+			case *ssa.MakeClosure:
+				closure := instr.Fn.(*ssa.Function)
+				for i, binding := range instr.Bindings {
+					if binding == value {
+						q.taint(closure.FreeVars[i], next)
+					}
+				}
+			}
+			if result, ok := instr.(ssa.Value); ok {
+				q.taint(result, next)
+			}
+		}
+	}
+}
+
+// followCall handles a tainted value used by a call: the sink is reached if
+// it is an argument or the receiver; calls into the module are followed
+// into the callee's parameter, and other calls taint their results and the
+// arguments they could write to.
+func (q *dataFlowSearch) followCall(value ssa.Value, origin taintOrigin, call ssa.CallInstruction) {
+	common := call.Common()
+	operands := common.Args
+	if common.IsInvoke() {
+		operands = append([]ssa.Value{common.Value}, common.Args...)
+	}
+	var positions []int
+	for i, operand := range operands {
+		if operand == value {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) == 0 {
+		return
+	}
+	fn := call.Parent()
+	if callMatches(common, q.sink) {
+		if !q.sinks[call.Pos()] {
+			q.sinks[call.Pos()] = true
+			q.paths = append(q.paths, q.path(value, call))
+		}
+		return
+	}
+
+	entered := false
+	if origin.depth < q.depth {
+		frame := &flowFrame{site: call, outer: origin.frame}
+		for _, callee := range q.callees(call) {
+			if !q.inModule(callee) || callee.Blocks == nil || len(callee.Params) != len(operands) {
+				continue
+			}
+			for _, i := range positions {
+				q.taint(callee.Params[i], taintOrigin{from: value, kind: "call", pos: call.Pos(), fn: fn, depth: origin.depth + 1, frame: frame})
+			}
+			entered = true
+		}
+	}
+	if entered {
+		return
+	}
+	next := taintOrigin{from: value, kind: "external", pos: call.Pos(), fn: fn, depth: origin.depth, frame: origin.frame}
+	if result, ok := call.(*ssa.Call); ok {
+		q.taint(result, next)
+	}
+	for _, operand := range operands {
+		if mayBeWritten(operand.Type()) {
+			q.taint(operand, next)
+		}
+	}
+}
+
+// followReturn carries a returned tainted value back to the call the search
+// entered the function through, or to every caller when the data started
+// in the function itself.
+func (q *dataFlowSearch) followReturn(value ssa.Value, origin taintOrigin, ret *ssa.Return) {
+	fn := ret.Parent()
+	next := taintOrigin{from: value, kind: "return", pos: ret.Pos(), fn: fn}
+	if origin.frame != nil {
+		if result, ok := origin.frame.site.(*ssa.Call); ok {
+			next.depth, next.frame = max(origin.depth-1, 0), origin.frame.outer
+			q.taint(result, next)
+		}
+		return
+	}
+	if origin.depth >= q.depth {
+		return
+	}
+	node := q.callGraph().Nodes[fn]
+	if node == nil {
+		return
+	}
+	next.depth = origin.depth + 1
+	for _, edge := range node.In {
+		if result, ok := edge.Site.(*ssa.Call); ok && q.inModule(edge.Caller.Func) {
+			q.taint(result, next)
+		}
+	}
+}
+
+// callees lists the functions a call may invoke: its static callee, or the
+// class hierarchy analysis estimate for calls through interfaces and
+// function values.
+func (q *dataFlowSearch) callees(call ssa.CallInstruction) []*ssa.Function {
+	if callee := call.Common().StaticCallee(); callee != nil {
+		return []*ssa.Function{callee}
+	}
+	node := q.callGraph().Nodes[call.Parent()]
+	if node == nil {
+		return nil
+	}
+	var callees []*ssa.Function
+	for _, edge := range node.Out {
+		if edge.Site == call {
+			callees = append(callees, edge.Callee.Func)
+		}
+	}
+	return callees
+}
+
+// callGraph is built on first use: most searches never need it.
+func (q *dataFlowSearch) callGraph() *callgraph.Graph {
+	if q.graph == nil {
+		q.graph = cha.CallGraph(q.prog)
+	}
+	return q.graph
+}
+
+func (q *dataFlowSearch) inModule(fn *ssa.Function) bool {
+	return fn != nil && fn.Pkg != nil && inModule(fn.Pkg.Pkg.Path(), q.modulePath)
+}
+
+// path rebuilds the steps from the source to a sink call, keeping one step
+// per source line.
+func (q *dataFlowSearch) path(value ssa.Value, sink ssa.CallInstruction) flowPath {
+	steps := []flowStep{q.step("sink", sink.Parent(), sink.Pos())}
+	for v := value; v != nil; v = q.origins[v].from {
+		origin := q.origins[v]
+		pos := origin.pos
+		if !pos.IsValid() {
+			pos = v.Pos()
+		}
+		step := q.step(origin.kind, origin.fn, pos)
+		if step.Line == 0 && origin.kind != "source" {
+			continue
+		}
+		if last := steps[len(steps)-1]; last.Path == step.Path && last.Line == step.Line {
+			if origin.kind == "source" {
+				steps[len(steps)-1].Kind = "source"
+			}
+			continue
+		}
+		steps = append(steps, step)
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return flowPath{Sink: sitePosition(q.workspace, q.prog.Fset, sink.Pos()), Steps: steps}
+}
+
+func (q *dataFlowSearch) step(kind string, fn *ssa.Function, pos token.Pos) flowStep {
+	step := flowStep{Kind: kind}
+	if fn != nil {
+		step.Function = ssaFunctionName(fn)
+		if fn.Pkg != nil {
+			step.Function = fn.Pkg.Pkg.Name() + "." + step.Function
+		}
+	}
+	position := q.prog.Fset.Position(pos)
+	if !position.IsValid() {
+		return step
+	}
+	step.Path, step.Line = relativeSlashPath(q.workspace, position.Filename), position.Line
+	lines, ok := q.lines[position.Filename]
+	if !ok {
+		if data, err := os.ReadFile(position.Filename); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		q.lines[position.Filename] = lines
+	}
+	if position.Line <= len(lines) {
+		step.Code = strings.TrimSpace(lines[position.Line-1])
+	}
+	return step
+}
+
+// callMatches reports whether a call invokes the named function or method,
+// directly or through an interface.
+func callMatches(common *ssa.CallCommon, name string) bool {
+	if common.IsInvoke() {
+		named, ok := common.Value.Type().(*types.Named)
+		if !ok || named.Obj().Pkg() == nil {
+			return false
+		}
+		return nameMatches(named.Obj().Pkg(), named.Obj().Name()+"."+common.Method.Name(), name)
+	}
+	callee := common.StaticCallee()
+	if callee == nil {
+		return false
+	}
+	if origin := callee.Origin(); origin != nil {
+		callee = origin
+	}
+	return callee.Pkg != nil && nameMatches(callee.Pkg.Pkg, ssaFunctionName(callee), name)
+}
+
+// nameMatches reports whether name designates short in pkg, bare or
+// qualified by package name or import path.
+func nameMatches(pkg *types.Package, short, name string) bool {
+	return name == short || name == pkg.Name()+"."+short || name == pkg.Path()+"."+short
+}
+
+// mayBeWritten reports whether a callee given a value of type typ could
+// store data into it.
+func mayBeWritten(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Interface:
+		return true
+	}
+	return false
+}
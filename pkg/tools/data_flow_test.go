@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDataFlowSearch(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "app/app.go", `package app
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type job struct{ cmd string }
+
+func Handle(input string) error {
+	j := &job{}
+	j.cmd = normalize(input)
+	return run(j)
+}
+
+func normalize(s string) string {
+	return strings.TrimSpace(s)
+}
+
+func run(j *job) error {
+	return exec.Command("sh", "-c", j.cmd).Run()
+}
+
+func Safe(input string) error {
+	_ = normalize(input)
+	return exec.Command("ls").Run()
+}
+
+func FromEnv() error {
+	v := os.Getenv("CMD")
+	return exec.Command(v).Run()
+}
+`)
+
+	tools := NewLSPTools(nil, workspace)
+	prog, initial, modulePath, err := tools.loadSSAProgram(context.Background(), "./...", false)
+	if err != nil {
+		t.Fatalf("loadSSAProgram: %v", err)
+	}
+	search := func(source, param, sink string, depth int) *dataFlowSearch {
+		t.Helper()
+		q := tools.newDataFlowSearch(prog, modulePath, sink, depth, 5)
+		if problem := q.seed(initial, source, param); problem != "" {
+			t.Fatalf("seed: %s", problem)
+		}
+		q.run()
+		return q
+	}
+	describe := func(path flowPath) string {
+		var parts []string
+		for _, step := range path.Steps {
+			parts = append(parts, step.Kind+"@"+step.Function)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	q := search("Handle", "input", "exec.Command", 5)
+	if len(q.paths) != 1 || q.paths[0].Sink != "app/app.go:22" {
+		t.Fatalf("expected one flow into run's exec.Command, got %+v", q.paths)
+	}
+	steps := q.paths[0].Steps
+	want := "source@app.Handle call@app.Handle return@app.normalize store@app.Handle call@app.Handle sink@app.run"
+	if got := describe(q.paths[0]); got != want {
+		t.Fatalf("path = %s\nwant   %s", got, want)
+	}
+	if steps[0].Line != 11 || steps[len(steps)-1].Code != `return exec.Command("sh", "-c", j.cmd).Run()` {
+		t.Fatalf("unexpected source or sink step %+v %+v", steps[0], steps[len(steps)-1])
+	}
+	if q := search("Handle", "input", "exec.Command", 0); len(q.paths) != 0 {
+		t.Fatalf("depth 0 must not follow calls, got %+v", q.paths)
+	}
+	if q := search("Safe", "input", "exec.Command", 5); len(q.paths) != 0 {
+		t.Fatalf("Safe passes no input to the command, got %+v", q.paths)
+	}
+	if q := search("os.Getenv", "", "os/exec.Command", 5); len(q.paths) != 1 || describe(q.paths[0]) != "source@app.FromEnv sink@app.FromEnv" {
+		t.Fatalf("unexpected Getenv flows %+v", q.paths)
+	}
+
+	q = tools.newDataFlowSearch(prog, modulePath, "exec.CommandContext", 5, 5)
+	if problem := q.seed(initial, "os.Getenv", ""); !strings.Contains(problem, "no calls to exec.CommandContext") {
+		t.Fatalf("expected a missing sink problem, got %q", problem)
+	}
+	if problem := tools.newDataFlowSearch(prog, modulePath, "exec.Command", 5, 5).seed(initial, "Handle", "cmd"); !strings.Contains(problem, "parameters are: input") {
+		t.Fatalf("expected a missing parameter problem, got %q", problem)
+	}
+}
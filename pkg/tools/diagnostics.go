@@ -2,15 +2,55 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	defaultDiagnosticsTimeout = 30 * time.Second
+	// diagnosticsQuietPeriod is how long gopls must stay idle before its
+	// diagnostics are taken as settled.
+	diagnosticsQuietPeriod = 500 * time.Millisecond
 )
 
+// diagnosticSeverities names LSP diagnostic severities, indexed by
+// severity; 1 is the most severe.
+var diagnosticSeverities = []string{1: "error", 2: "warning", 3: "information", 4: "hint"}
+
+// fileDiagnostic is a diagnostic with 1-based coordinates and a named
+// severity.
+type fileDiagnostic struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Source   string `json:"source,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+}
+
+type fileDiagnostics struct {
+	Path        string           `json:"path"`
+	FileURI     string           `json:"file_uri"`
+	Diagnostics []fileDiagnostic `json:"diagnostics"`
+}
+
 func (t *LSPTools) registerDiagnosticsTools(s *server.MCPServer) {
 	t.registerCheckDiagnostics(s)
+	t.registerGetDiagnostics(s)
+	t.registerDiagnosticsDelta(s)
+	t.registerCreateDiagnosticsBaseline(s)
 }
 
 func (t *LSPTools) registerCheckDiagnostics(s *server.MCPServer) {
@@ -22,6 +62,9 @@ func (t *LSPTools) registerCheckDiagnostics(s *server.MCPServer) {
 			mcp.Required(),
 			mcp.Description("URI of the file"),
 		),
+		mcp.WithBoolean("include_baselined",
+			mcp.Description("Also return the findings recorded in the suppression baseline (default false)"),
+		),
 	)
 
 	s.AddTool(diagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -51,14 +94,230 @@ func (t *LSPTools) registerCheckDiagnostics(s *server.MCPServer) {
 			}
 			return nil, fmt.Errorf("failed to get diagnostics: %w", err)
 		}
+		payload := map[string]any{"file_uri": fileURI}
+		if !getOptionalBoolArg(args, "include_baselined") {
+			filtered, summary, err := t.suppressBaselined(ctx, s, map[string][]protocol.Diagnostic{fileURI: diagnostics})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if summary != nil {
+				diagnostics = filtered[fileURI]
+				payload["baselined"] = summary
+			}
+		}
+		payload["diagnostics"] = diagnostics
+		checked, _ := t.collectFileDiagnostics(map[string][]protocol.Diagnostic{fileURI: diagnostics}, len(diagnosticSeverities)-1, false)
+		t.history.recordDiagnostics(checked)
 
-		result, err := mcp.NewToolResultJSON(map[string]any{
-			"file_uri":    fileURI,
-			"diagnostics": diagnostics,
-		})
+		result, err := mcp.NewToolResultJSON(payload)
 		if err != nil {
 			return nil, err
 		}
 		return result, nil
 	})
 }
+
+func (t *LSPTools) registerGetDiagnostics(s *server.MCPServer) {
+	tool := mcp.NewTool("get_diagnostics",
+		mcp.WithDescription("Return the compile errors and analyzer findings gopls has for a file or the whole workspace, after waiting for gopls to finish loading and diagnosing (quiescence). Faster than running go build to see what is broken. Findings recorded in the suppression baseline are left out unless include_baselined"),
+		mcp.WithTitleAnnotation("Get Diagnostics"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Description("URI or path of a file; omit for every file in the workspace"),
+		),
+		mcp.WithBoolean("include_baselined",
+			mcp.Description("Also return the findings recorded in the suppression baseline, see create_diagnostics_baseline (default false)"),
+		),
+		mcp.WithString("severity",
+			mcp.Description("Least severe diagnostics to include: error, warning, information or hint (default hint, everything)"),
+		),
+		mcp.WithString("timeout",
+			mcp.Description(fmt.Sprintf("How long to wait for gopls to settle, as a Go duration (default %s, or the profile's); diagnostics are returned either way", defaultDiagnosticsTimeout)),
+		),
+		mcp.WithString("profile",
+			mcp.Description("Analysis profile: fast (no staticcheck, packages changed since HEAD, 10s), thorough (everything, 2m) or security (security-relevant analyzers plus govulncheck), or one defined in .mcp-gopls.json"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		var profile projectconfig.AnalysisProfile
+		profileName := getOptionalStringArg(args, "profile")
+		defaultTimeout := defaultDiagnosticsTimeout
+		if profileName != "" {
+			var err error
+			if profile, err = t.analysisProfile(profileName); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if profile.Timeout != "" {
+				defaultTimeout, _ = time.ParseDuration(profile.Timeout)
+			}
+		}
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultTimeout)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		threshold := len(diagnosticSeverities) - 1
+		if severity := getOptionalStringArg(args, "severity"); severity != "" {
+			if threshold = slices.Index(diagnosticSeverities, severity); threshold < 1 {
+				return mcp.NewToolResultError("severity must be error, warning, information or hint"), nil
+			}
+		}
+		fileURI := getOptionalStringArg(args, "file_uri")
+		if fileURI != "" && !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		if fileURI != "" {
+			// gopls always publishes diagnostics for open files, even
+			// when there are none. A file another tool keeps open stays
+			// open, with its cached diagnostics.
+			opened, err := lspClient.OpenDocument(ctx, fileURI, "go", "")
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if opened {
+				defer func() {
+					_ = lspClient.DidClose(context.WithoutCancel(ctx), fileURI)
+				}()
+			}
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		published, quiescent, err := t.settledDiagnostics(ctx, s, token, lspClient, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if fileURI != "" {
+			published = map[string][]protocol.Diagnostic{fileURI: published[fileURI]}
+		}
+		var warnings []string
+		if profileName != "" {
+			// The scope narrows workspace-wide runs only.
+			var dirs map[string]bool
+			if profile.Scope == "changed" && fileURI == "" {
+				if dirs, err = t.changedPackageDirs(ctx, s); err != nil {
+					warnings = append(warnings, fmt.Sprintf("cannot list the changed packages, reporting the whole workspace: %v", err))
+				}
+			}
+			published = t.filterByProfile(published, profile, dirs)
+		}
+		var baselined *baselineSummary
+		if !getOptionalBoolArg(args, "include_baselined") {
+			if published, baselined, err = t.suppressBaselined(ctx, s, published); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		files, counts := t.collectFileDiagnostics(published, threshold, fileURI == "")
+		t.history.recordDiagnostics(files)
+
+		payload := map[string]any{
+			"quiescent": quiescent,
+			"counts":    counts,
+			"files":     files,
+		}
+		if fileURI != "" {
+			payload["file_uri"] = fileURI
+		}
+		if profileName != "" {
+			payload["profile"] = profileName
+		}
+		if baselined != nil {
+			payload["baselined"] = baselined
+		}
+		if profile.Vulncheck {
+			sendProgressNotification(ctx, s, token, "Running govulncheck ./...")
+			cmd, cmdArgs, _ := determineGovulncheckCommand()
+			vulns, err := t.runCommand(ctx, s, token, cmd, cmdArgs...)
+			var execErr *exec.Error
+			if errors.As(err, &execErr) {
+				warnings = append(warnings, fmt.Sprintf("govulncheck skipped: %s binary not found", execErr.Name))
+			} else {
+				payload["govulncheck"] = vulns
+			}
+		}
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// settledDiagnostics waits up to timeout for gopls to finish diagnosing and
+// returns the diagnostics it published, and whether it settled in time.
+func (t *LSPTools) settledDiagnostics(ctx context.Context, s *server.MCPServer, token mcp.ProgressToken, lspClient client.LSPClient, timeout time.Duration) (map[string][]protocol.Diagnostic, bool, error) {
+	sendProgressNotification(ctx, s, token, "Waiting for gopls to finish diagnosing")
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := lspClient.WaitForQuiescence(waitCtx, diagnosticsQuietPeriod)
+	cancel()
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, false, t.handleLSPError(err)
+	}
+	return lspClient.WorkspaceDiagnostics(), err == nil, nil
+}
+
+// collectFileDiagnostics converts published diagnostics at or above the
+// severity threshold, grouped by file in path order and counted by
+// severity. With workspaceOnly, files outside the workspace are dropped,
+// as are files left with no diagnostics.
+func (t *LSPTools) collectFileDiagnostics(published map[string][]protocol.Diagnostic, threshold int, workspaceOnly bool) ([]fileDiagnostics, map[string]int) {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	files := []fileDiagnostics{}
+	counts := make(map[string]int)
+	for uri, diagnostics := range published {
+		path := convertURIToPath(uri)
+		rel, err := filepath.Rel(root, path)
+		outside := err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+		if outside {
+			if workspaceOnly {
+				continue
+			}
+			rel = path
+		}
+		entry := fileDiagnostics{Path: filepath.ToSlash(rel), FileURI: uri, Diagnostics: []fileDiagnostic{}}
+		for _, diagnostic := range diagnostics {
+			converted, severity := toFileDiagnostic(diagnostic)
+			if severity > threshold {
+				continue
+			}
+			counts[converted.Severity]++
+			entry.Diagnostics = append(entry.Diagnostics, converted)
+		}
+		if len(entry.Diagnostics) == 0 && workspaceOnly {
+			continue
+		}
+		files = append(files, entry)
+	}
+	slices.SortFunc(files, func(a, b fileDiagnostics) int { return strings.Compare(a.Path, b.Path) })
+	return files, counts
+}
+
+// toFileDiagnostic converts a published diagnostic, returning its severity
+// as well.
+func toFileDiagnostic(diagnostic protocol.Diagnostic) (fileDiagnostic, int) {
+	severity := diagnostic.Severity
+	if severity < 1 || severity >= len(diagnosticSeverities) {
+		// The protocol leaves a missing severity to the client to
+		// interpret; treat it as an error.
+		severity = 1
+	}
+	return fileDiagnostic{
+		Line:     diagnostic.Range.Start.Line + 1,
+		Column:   diagnostic.Range.Start.Character + 1,
+		Severity: diagnosticSeverities[severity],
+		Source:   diagnostic.Source,
+		Code:     diagnostic.Code,
+		Message:  diagnostic.Message,
+	}, severity
+}
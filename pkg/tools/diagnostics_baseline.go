@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// baselineSummary tells how many findings the suppression baseline hid from
+// a diagnostics result, and how many it no longer hides because their
+// suppression expired.
+type baselineSummary struct {
+	File       string `json:"file"`
+	Suppressed int    `json:"suppressed"`
+	Expired    int    `json:"expired,omitempty"`
+}
+
+func (t *LSPTools) registerCreateDiagnosticsBaseline(s *server.MCPServer) {
+	tool := mcp.NewTool("create_diagnostics_baseline",
+		mcp.WithDescription("Record the current workspace diagnostics in the suppression baseline, like a lint baseline: the diagnostics tools then leave those findings out and report only the others. update, the default, keeps when each finding was first baselined, drops the fixed ones and adds the new ones, except for sources whose policy in .mcp-gopls.json ratchets the baseline: their new findings are refused and listed, to be fixed instead. replace records every current finding, ignoring ratchets"),
+		mcp.WithTitleAnnotation("Create Diagnostics Baseline"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("mode",
+			mcp.Description("update (default) or replace"),
+			mcp.Enum("update", "replace"),
+		),
+		mcp.WithString("timeout",
+			mcp.Description(fmt.Sprintf("How long to wait for gopls to settle, as a Go duration (default %s)", defaultDiagnosticsTimeout)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultDiagnosticsTimeout)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		mode := getOptionalStringArg(args, "mode")
+		if mode == "" {
+			mode = "update"
+		}
+		if mode != "update" && mode != "replace" {
+			return mcp.NewToolResultError("mode must be update or replace"), nil
+		}
+		settings, err := t.baselineSettings()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		existing, found, err := t.readDiagnosticsBaseline(ctx, s, settings.File, "")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		published, quiescent, err := t.settledDiagnostics(ctx, s, getProgressToken(request.Params.Meta), lspClient, timeout)
+		if err != nil {
+			return nil, err
+		}
+		current := t.baselineDiagnostics(published)
+		next, added, removed, refused := rebaseline(existing, current, settings.Policies, mode == "replace", time.Now().UTC())
+		if err := t.writeDiagnosticsBaseline(settings.File, next); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file":      settings.File,
+			"mode":      mode,
+			"created":   !found,
+			"quiescent": quiescent,
+			"entries":   len(next.Diagnostics),
+			"added":     len(added),
+			"removed":   removed,
+			"refused":   refused,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// baselineSettings returns the baseline settings of the project, with the
+// default file.
+func (t *LSPTools) baselineSettings() (projectconfig.Baseline, error) {
+	cfg, err := projectconfig.Load(t.workspaceDir)
+	if err != nil {
+		return projectconfig.Baseline{}, err
+	}
+	var settings projectconfig.Baseline
+	if cfg.Baseline != nil {
+		settings = *cfg.Baseline
+	}
+	if settings.File == "" {
+		settings.File = defaultDiagnosticsBaseline
+	}
+	return settings, nil
+}
+
+// baselineDiagnostics flattens the published diagnostics of the workspace
+// files into baseline findings.
+func (t *LSPTools) baselineDiagnostics(published map[string][]protocol.Diagnostic) []baselineDiagnostic {
+	files, _ := t.collectFileDiagnostics(published, len(diagnosticSeverities)-1, true)
+	current := []baselineDiagnostic{}
+	for _, file := range files {
+		for _, diagnostic := range file.Diagnostics {
+			current = append(current, baselineDiagnostic{Path: file.Path, fileDiagnostic: diagnostic})
+		}
+	}
+	return current
+}
+
+func (t *LSPTools) writeDiagnosticsBaseline(name string, baseline diagnosticsBaseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(t.workspaceDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("save baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("save baseline: %w", err)
+	}
+	return nil
+}
+
+// rebaseline returns the baseline recording current. Findings already in
+// existing keep when they were first baselined; fixed ones are removed.
+// Unless replace, new findings of a source whose policy ratchets are
+// refused.
+func rebaseline(existing diagnosticsBaseline, current []baselineDiagnostic, policies map[string]projectconfig.BaselinePolicy, replace bool, now time.Time) (next diagnosticsBaseline, added, removed, refused []baselineDiagnostic) {
+	next = diagnosticsBaseline{Created: now, Diagnostics: []baselineDiagnostic{}}
+	added, removed, refused = []baselineDiagnostic{}, []baselineDiagnostic{}, []baselineDiagnostic{}
+	paired := make([]bool, len(existing.Diagnostics))
+	for i, j := range pairDiagnostics(existing.Diagnostics, current) {
+		d := current[i]
+		switch {
+		case j >= 0:
+			paired[j] = true
+			d.Baselined = existing.Diagnostics[j].Baselined
+			if d.Baselined.IsZero() {
+				d.Baselined = existing.Created
+			}
+		case !replace && baselinePolicy(policies, d.Source).Ratchet:
+			refused = append(refused, d)
+			continue
+		default:
+			d.Baselined = now
+			added = append(added, d)
+		}
+		next.Diagnostics = append(next.Diagnostics, d)
+	}
+	for j, d := range existing.Diagnostics {
+		if !paired[j] {
+			removed = append(removed, d)
+		}
+	}
+	sortBaselineDiagnostics(next.Diagnostics)
+	sortBaselineDiagnostics(removed)
+	sortBaselineDiagnostics(refused)
+	return next, added, removed, refused
+}
+
+// baselinePolicy returns the policy of a diagnostic source: the one set for
+// the source itself, or else the one of the longest matching pattern.
+func baselinePolicy(policies map[string]projectconfig.BaselinePolicy, source string) projectconfig.BaselinePolicy {
+	if source == "" {
+		source = "compiler"
+	}
+	if policy, ok := policies[source]; ok {
+		return policy
+	}
+	var best string
+	var policy projectconfig.BaselinePolicy
+	for pattern, candidate := range policies {
+		if len(pattern) > len(best) && sourceMatches(pattern, source) {
+			best, policy = pattern, candidate
+		}
+	}
+	return policy
+}
+
+// suppressBaselined drops the published diagnostics recorded in the
+// suppression baseline, unless their suppression expired. Without a
+// baseline file, published is returned unchanged and the summary is nil.
+func (t *LSPTools) suppressBaselined(ctx context.Context, s *server.MCPServer, published map[string][]protocol.Diagnostic) (map[string][]protocol.Diagnostic, *baselineSummary, error) {
+	settings, err := t.baselineSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+	baseline, found, err := t.readDiagnosticsBaseline(ctx, s, settings.File, "")
+	if err != nil || !found {
+		return published, nil, err
+	}
+
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	type origin struct {
+		uri   string
+		index int
+	}
+	var current []baselineDiagnostic
+	var origins []origin
+	for uri, diagnostics := range published {
+		path := relativeSlashPath(root, convertURIToPath(uri))
+		if strings.HasPrefix(path, "../") {
+			continue
+		}
+		for i, diagnostic := range diagnostics {
+			entry, _ := toFileDiagnostic(diagnostic)
+			current = append(current, baselineDiagnostic{Path: path, fileDiagnostic: entry})
+			origins = append(origins, origin{uri, i})
+		}
+	}
+
+	summary := &baselineSummary{File: settings.File}
+	now := time.Now()
+	hidden := make(map[string]map[int]bool)
+	for i, j := range pairDiagnostics(baseline.Diagnostics, current) {
+		if j < 0 {
+			continue
+		}
+		entry := baseline.Diagnostics[j]
+		if expires := baselinePolicy(settings.Policies, entry.Source).Expires; expires != "" {
+			// Load validated the duration.
+			lifetime, _ := time.ParseDuration(expires)
+			since := entry.Baselined
+			if since.IsZero() {
+				since = baseline.Created
+			}
+			if now.Sub(since) > lifetime {
+				summary.Expired++
+				continue
+			}
+		}
+		if hidden[origins[i].uri] == nil {
+			hidden[origins[i].uri] = make(map[int]bool)
+		}
+		hidden[origins[i].uri][origins[i].index] = true
+		summary.Suppressed++
+	}
+
+	filtered := make(map[string][]protocol.Diagnostic, len(published))
+	for uri, diagnostics := range published {
+		kept := []protocol.Diagnostic{}
+		for i, diagnostic := range diagnostics {
+			if !hidden[uri][i] {
+				kept = append(kept, diagnostic)
+			}
+		}
+		filtered[uri] = kept
+	}
+	return filtered, summary, nil
+}
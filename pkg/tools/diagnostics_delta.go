@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultDiagnosticsBaseline is where diagnostics_delta keeps its baseline,
+// relative to the workspace root; commit it to compare against a ref.
+const defaultDiagnosticsBaseline = ".mcp-gopls-baseline.json"
+
+// diagnosticsBaseline is the stored set of diagnostics new findings are
+// told apart from. Paths are relative to the workspace root so the file can
+// be committed.
+type diagnosticsBaseline struct {
+	Created     time.Time            `json:"created"`
+	Diagnostics []baselineDiagnostic `json:"diagnostics"`
+}
+
+// baselineDiagnostic is a finding of a baseline. Baselined is when the
+// finding was first recorded, for the expiry of suppressions; the creation
+// of the baseline when zero.
+type baselineDiagnostic struct {
+	Path string `json:"path"`
+	fileDiagnostic
+	Baselined time.Time `json:"baselined,omitzero"`
+}
+
+// key identifies a finding regardless of its position, which edits
+// elsewhere in the file shift.
+func (d baselineDiagnostic) key() string {
+	return strings.Join([]string{d.Path, d.Severity, d.Source, d.Code, d.Message}, "\x00")
+}
+
+func (t *LSPTools) registerDiagnosticsDelta(s *server.MCPServer) {
+	tool := mcp.NewTool("diagnostics_delta",
+		mcp.WithDescription("Compare the workspace diagnostics against a stored baseline and return only the new and the fixed findings, instead of every pre-existing warning. Findings are matched on file, severity, source, code and message, so code moving within a file does not make them new. The baseline is a JSON file in the workspace, read from the working tree or from a git ref; save records the current diagnostics as the new baseline"),
+		mcp.WithTitleAnnotation("Diagnostics Delta"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("baseline",
+			mcp.Description(fmt.Sprintf("Baseline file, relative to the workspace root (default the baseline.file of .mcp-gopls.json, or %s)", defaultDiagnosticsBaseline)),
+		),
+		mcp.WithString("ref",
+			mcp.Description("Read the baseline file as committed at this git ref, such as main or HEAD~1, instead of the working tree"),
+		),
+		mcp.WithBoolean("save",
+			mcp.Description("Write the current diagnostics to the baseline file after comparing, or create it when missing (default false)"),
+		),
+		mcp.WithString("severity",
+			mcp.Description("Least severe diagnostics to compare: error, warning, information or hint (default hint, everything)"),
+		),
+		mcp.WithString("timeout",
+			mcp.Description(fmt.Sprintf("How long to wait for gopls to settle, as a Go duration (default %s)", defaultDiagnosticsTimeout)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultDiagnosticsTimeout)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		threshold := len(diagnosticSeverities) - 1
+		if severity := getOptionalStringArg(args, "severity"); severity != "" {
+			if threshold = slices.Index(diagnosticSeverities, severity); threshold < 1 {
+				return mcp.NewToolResultError("severity must be error, warning, information or hint"), nil
+			}
+		}
+		name := getOptionalStringArg(args, "baseline")
+		if name == "" {
+			settings, err := t.baselineSettings()
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			name = settings.File
+		}
+		if filepath.IsAbs(name) || !filepath.IsLocal(name) {
+			return mcp.NewToolResultError("baseline must be a path inside the workspace, relative to its root"), nil
+		}
+		ref := getOptionalStringArg(args, "ref")
+		save := getOptionalBoolArg(args, "save")
+
+		baseline, found, err := t.readDiagnosticsBaseline(ctx, s, name, ref)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if !found && !save {
+			where := name
+			if ref != "" {
+				where = ref + ":" + name
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("no baseline at %s; call diagnostics_delta with save true to record the current diagnostics as one", where)), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		published, quiescent, err := t.settledDiagnostics(ctx, s, getProgressToken(request.Params.Meta), lspClient, timeout)
+		if err != nil {
+			return nil, err
+		}
+		current := t.baselineDiagnostics(published)
+
+		kept := func(diagnostics []baselineDiagnostic) []baselineDiagnostic {
+			return slices.DeleteFunc(slices.Clone(diagnostics), func(d baselineDiagnostic) bool {
+				return slices.Index(diagnosticSeverities, d.Severity) > threshold
+			})
+		}
+		added, fixed, unchanged := diffDiagnostics(kept(baseline.Diagnostics), kept(current))
+		payload := map[string]any{
+			"baseline":  name,
+			"quiescent": quiescent,
+			"new":       added,
+			"fixed":     fixed,
+			"counts":    map[string]int{"new": len(added), "fixed": len(fixed), "unchanged": unchanged},
+		}
+		if ref != "" {
+			payload["ref"] = ref
+		}
+		if found {
+			payload["baseline_created"] = baseline.Created
+		}
+		if save {
+			// Findings still there keep when they were first baselined.
+			next, _, _, _ := rebaseline(baseline, current, nil, true, time.Now().UTC())
+			if err := t.writeDiagnosticsBaseline(name, next); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			payload["saved"] = true
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// readDiagnosticsBaseline reads the baseline file from the working tree,
+// or from ref when set. found is false when there is no such file.
+func (t *LSPTools) readDiagnosticsBaseline(ctx context.Context, s *server.MCPServer, name, ref string) (diagnosticsBaseline, bool, error) {
+	var data []byte
+	if ref == "" {
+		var err error
+		data, err = os.ReadFile(filepath.Join(t.workspaceDir, name))
+		if os.IsNotExist(err) {
+			return diagnosticsBaseline{}, false, nil
+		}
+		if err != nil {
+			return diagnosticsBaseline{}, false, fmt.Errorf("read baseline: %w", err)
+		}
+	} else {
+		// ./ makes the path relative to the working directory rather than
+		// to the repository root.
+		result, err := t.runCommand(ctx, s, nil, "git", "show", ref+":./"+filepath.ToSlash(name))
+		if err != nil {
+			if strings.Contains(result.Stderr, "does not exist") || strings.Contains(result.Stderr, "exists on disk, but not in") {
+				return diagnosticsBaseline{}, false, nil
+			}
+			return diagnosticsBaseline{}, false, fmt.Errorf("%s", buildCommandErrorMessage("git show", result, err))
+		}
+		data = []byte(result.Stdout)
+	}
+	var baseline diagnosticsBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return diagnosticsBaseline{}, false, fmt.Errorf("baseline %s is not valid: %v", name, err)
+	}
+	return baseline, true, nil
+}
+
+// pairDiagnostics pairs the current findings with the baseline ones of the
+// same key, on the same line first, then in order. It returns the index of
+// the baseline finding of each current one, -1 for the unpaired.
+func pairDiagnostics(baseline, current []baselineDiagnostic) []int {
+	free := make(map[string][]int)
+	for i, d := range baseline {
+		free[d.key()] = append(free[d.key()], i)
+	}
+	pairs := make([]int, len(current))
+	for i := range pairs {
+		pairs[i] = -1
+	}
+	take := func(i int, match func(int) bool) {
+		candidates := free[current[i].key()]
+		if j := slices.IndexFunc(candidates, match); j >= 0 {
+			pairs[i] = candidates[j]
+			free[current[i].key()] = slices.Delete(candidates, j, j+1)
+		}
+	}
+	for i, d := range current {
+		take(i, func(j int) bool { return baseline[j].Line == d.Line })
+	}
+	for i := range current {
+		if pairs[i] < 0 {
+			take(i, func(int) bool { return true })
+		}
+	}
+	return pairs
+}
+
+// diffDiagnostics returns the current findings missing from the baseline
+// and the baseline findings gone from current, in path and line order, and
+// how many are in both.
+func diffDiagnostics(baseline, current []baselineDiagnostic) (added, fixed []baselineDiagnostic, unchanged int) {
+	paired := make([]bool, len(baseline))
+	added, fixed = []baselineDiagnostic{}, []baselineDiagnostic{}
+	for i, j := range pairDiagnostics(baseline, current) {
+		if j < 0 {
+			added = append(added, current[i])
+			continue
+		}
+		paired[j] = true
+		unchanged++
+	}
+	for j, d := range baseline {
+		if !paired[j] {
+			fixed = append(fixed, d)
+		}
+	}
+	sortBaselineDiagnostics(added)
+	sortBaselineDiagnostics(fixed)
+	return added, fixed, unchanged
+}
+
+func sortBaselineDiagnostics(diagnostics []baselineDiagnostic) {
+	slices.SortFunc(diagnostics, func(a, b baselineDiagnostic) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), a.Line-b.Line, a.Column-b.Column, strings.Compare(a.Message, b.Message))
+	})
+}
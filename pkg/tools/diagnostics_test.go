@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestCollectFileDiagnostics(t *testing.T) {
+	tools := NewLSPTools(nil, "/workspace")
+	at := func(line, character int) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line, Character: character}}
+	}
+	published := map[string][]protocol.Diagnostic{
+		"file:///workspace/b/b.go": {
+			{Range: at(4, 1), Severity: 2, Source: "unusedresult", Message: "result of fmt.Sprintf call not used"},
+			{Range: at(2, 8), Message: "undefined: x"},
+		},
+		"file:///workspace/a.go":     {{Range: at(0, 0), Severity: 4, Message: "could be simplified"}},
+		"file:///workspace/clean.go": {},
+		"file:///go/pkg/mod/dep.go":  {{Severity: 1, Message: "outside"}},
+	}
+
+	files, counts := tools.collectFileDiagnostics(published, 4, true)
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"a.go", "b/b.go"}) {
+		t.Fatalf("paths = %v", paths)
+	}
+	if !reflect.DeepEqual(counts, map[string]int{"error": 1, "warning": 1, "hint": 1}) {
+		t.Fatalf("counts = %v", counts)
+	}
+	if got := files[1].Diagnostics[1]; got != (fileDiagnostic{Line: 3, Column: 9, Severity: "error", Message: "undefined: x"}) {
+		t.Fatalf("unexpected diagnostic %+v", got)
+	}
+
+	files, counts = tools.collectFileDiagnostics(published, 1, true)
+	if len(files) != 1 || len(files[0].Diagnostics) != 1 || counts["error"] != 1 || len(counts) != 1 {
+		t.Fatalf("expected only the error, got %+v %v", files, counts)
+	}
+}
+
+func TestGetDiagnosticsProfiles(t *testing.T) {
+	workspace := t.TempDir()
+	origLookup := lookupGovulncheckBinary
+	t.Cleanup(func() { lookupGovulncheckBinary = origLookup })
+	lookupGovulncheckBinary = func(string) (string, error) { return "govulncheck", nil }
+
+	uri := func(rel string) string { return convertPathToURI(filepath.Join(workspace, rel)) }
+	fakeClient := &fakeLSPClient{published: map[string][]protocol.Diagnostic{
+		uri("api/api.go"): {
+			{Severity: 1, Message: "undefined: x"},
+			{Severity: 2, Source: "SA4006", Message: "value never used"},
+			{Severity: 2, Source: "unsafeptr", Message: "possible misuse of unsafe.Pointer"},
+		},
+		uri("store/store.go"): {{Severity: 2, Source: "SA1019", Message: "deprecated"}},
+	}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	var ran []string
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		ran = append(ran, spec.name+" "+strings.Join(spec.args, " "))
+		if spec.name == "git" && spec.args[0] == "diff" {
+			return commandResult{Stdout: "api/api.go\nREADME.md\n"}, nil
+		}
+		return commandResult{Command: append([]string{spec.name}, spec.args...)}, nil
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("get_diagnostics").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "get_diagnostics", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("get_diagnostics: %v", err)
+		}
+		return result
+	}
+	messages := func(content map[string]any) []string {
+		var got []string
+		for _, file := range content["files"].([]any) {
+			for _, diagnostic := range file.(map[string]any)["diagnostics"].([]any) {
+				got = append(got, diagnostic.(map[string]any)["message"].(string))
+			}
+		}
+		return got
+	}
+
+	fast := structured(call(map[string]any{"profile": "fast"}))
+	if got := messages(fast); !reflect.DeepEqual(got, []string{"undefined: x", "possible misuse of unsafe.Pointer"}) || fast["profile"] != "fast" {
+		t.Fatalf("fast should skip staticcheck and unchanged packages, got %v", got)
+	}
+	if fast["govulncheck"] != nil {
+		t.Fatal("fast must not run govulncheck")
+	}
+
+	ran = nil
+	security := structured(call(map[string]any{"profile": "security"}))
+	if got := messages(security); !reflect.DeepEqual(got, []string{"undefined: x", "possible misuse of unsafe.Pointer", "deprecated"}) {
+		t.Fatalf("security should keep its analyzers across the workspace, got %v", got)
+	}
+	if !reflect.DeepEqual(ran, []string{"govulncheck ./..."}) || security["govulncheck"] == nil {
+		t.Fatalf("security should run govulncheck only, ran %v", ran)
+	}
+
+	writeWorkspaceFile(t, workspace, ".mcp-gopls.json", `{"analysis_profiles": {"deprecations": {"analyzers": ["SA1019"]}}}`)
+	if got := messages(structured(call(map[string]any{"profile": "deprecations"}))); !reflect.DeepEqual(got, []string{"deprecated"}) {
+		t.Fatalf("project profiles should be selectable, got %v", got)
+	}
+	if result := call(map[string]any{"profile": "paranoid"}); !result.IsError {
+		t.Fatalf("expected an error for an unknown profile, got %#v", result)
+	}
+}
+
+func TestDiagnosticsDelta(t *testing.T) {
+	workspace := t.TempDir()
+	uri := convertPathToURI(filepath.Join(workspace, "api", "api.go"))
+	fakeClient := &fakeLSPClient{published: map[string][]protocol.Diagnostic{uri: {
+		{Severity: 1, Message: "undefined: x", Range: protocol.Range{Start: protocol.Position{Line: 2}}},
+		{Severity: 2, Source: "SA4006", Message: "value never used", Range: protocol.Range{Start: protocol.Position{Line: 9}}},
+	}}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	var shown []string
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		shown = append(shown, strings.Join(spec.args, " "))
+		return commandResult{Stdout: `{"diagnostics": [{"path": "api/api.go", "line": 20, "severity": "warning", "source": "SA4006", "message": "value never used"}]}`}, nil
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("diagnostics_delta").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "diagnostics_delta", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("diagnostics_delta: %v", err)
+		}
+		return result
+	}
+	messages := func(content map[string]any, key string) []string {
+		var got []string
+		for _, d := range content[key].([]any) {
+			got = append(got, d.(map[string]any)["message"].(string))
+		}
+		return got
+	}
+
+	if result := call(nil); !result.IsError {
+		t.Fatal("expected an error without a baseline")
+	}
+	if saved := structured(call(map[string]any{"save": true})); saved["saved"] != true || len(saved["new"].([]any)) != 2 {
+		t.Fatalf("saving a first baseline should report everything as new, got %v", saved)
+	}
+
+	// The warning moves down; the error is fixed and another appears.
+	fakeClient.published[uri] = []protocol.Diagnostic{
+		{Severity: 2, Source: "SA4006", Message: "value never used", Range: protocol.Range{Start: protocol.Position{Line: 14}}},
+		{Severity: 1, Message: "missing return", Range: protocol.Range{Start: protocol.Position{Line: 30}}},
+	}
+	delta := structured(call(nil))
+	if got := messages(delta, "new"); !reflect.DeepEqual(got, []string{"missing return"}) {
+		t.Fatalf("new = %v", got)
+	}
+	if got := messages(delta, "fixed"); !reflect.DeepEqual(got, []string{"undefined: x"}) {
+		t.Fatalf("fixed = %v", got)
+	}
+	if counts := delta["counts"].(map[string]any); counts["unchanged"] != float64(1) {
+		t.Fatalf("the moved warning should be unchanged, got %v", counts)
+	}
+
+	errorsOnly := structured(call(map[string]any{"ref": "main", "severity": "error"}))
+	if !reflect.DeepEqual(shown, []string{"show main:./" + defaultDiagnosticsBaseline}) {
+		t.Fatalf("expected the baseline read from main, ran %v", shown)
+	}
+	if got := messages(errorsOnly, "new"); !reflect.DeepEqual(got, []string{"missing return"}) || len(errorsOnly["fixed"].([]any)) != 0 {
+		t.Fatalf("unexpected delta against main %v", errorsOnly)
+	}
+}
+
+func TestDiagnosticsBaseline(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, ".mcp-gopls.json", `{"baseline": {"file": "lint/baseline.json", "policies": {
+		"compiler": {"expires": "720h"},
+		"SA*": {"ratchet": true}
+	}}}`)
+	uri := convertPathToURI(filepath.Join(workspace, "api", "api.go"))
+	fakeClient := &fakeLSPClient{published: map[string][]protocol.Diagnostic{uri: {
+		{Severity: 1, Message: "undefined: x", Range: protocol.Range{Start: protocol.Position{Line: 2}}},
+		{Severity: 2, Source: "SA4006", Message: "value never used", Range: protocol.Range{Start: protocol.Position{Line: 9}}},
+	}}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(name string, args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("%s: %v %v", name, err, result)
+		}
+		return structured(result)
+	}
+	diagnostics := func(args map[string]any) (messages []string, baselined any) {
+		t.Helper()
+		content := call("get_diagnostics", args)
+		for _, file := range content["files"].([]any) {
+			for _, d := range file.(map[string]any)["diagnostics"].([]any) {
+				messages = append(messages, d.(map[string]any)["message"].(string))
+			}
+		}
+		return messages, content["baselined"]
+	}
+
+	if _, baselined := diagnostics(nil); baselined != nil {
+		t.Fatalf("nothing should be suppressed without a baseline, got %v", baselined)
+	}
+	created := call("create_diagnostics_baseline", map[string]any{"mode": "replace"})
+	if created["created"] != true || created["entries"] != float64(2) {
+		t.Fatalf("unexpected creation %v", created)
+	}
+	if !fileExists(filepath.Join(workspace, "lint", "baseline.json")) {
+		t.Fatal("expected the baseline at the configured file")
+	}
+
+	// The warning moves and a new one appears.
+	fakeClient.published[uri] = []protocol.Diagnostic{
+		{Severity: 1, Message: "undefined: x", Range: protocol.Range{Start: protocol.Position{Line: 2}}},
+		{Severity: 2, Source: "SA4006", Message: "value never used", Range: protocol.Range{Start: protocol.Position{Line: 14}}},
+		{Severity: 2, Source: "SA9003", Message: "empty branch", Range: protocol.Range{Start: protocol.Position{Line: 20}}},
+	}
+	messages, baselined := diagnostics(nil)
+	if !reflect.DeepEqual(messages, []string{"empty branch"}) || baselined.(map[string]any)["suppressed"] != float64(2) {
+		t.Fatalf("expected the baselined findings suppressed, got %v %v", messages, baselined)
+	}
+	if all, _ := diagnostics(map[string]any{"include_baselined": true}); len(all) != 3 {
+		t.Fatalf("include_baselined should return everything, got %v", all)
+	}
+	fakeClient.diagnostics = fakeClient.published[uri]
+	check := call("check_diagnostics", map[string]any{"file_uri": uri})
+	if got := check["diagnostics"].([]any); len(got) != 1 {
+		t.Fatalf("check_diagnostics should suppress too, got %v", got)
+	}
+
+	// The ratchet refuses the new staticcheck finding.
+	updated := call("create_diagnostics_baseline", nil)
+	if updated["added"] != float64(0) || len(updated["refused"].([]any)) != 1 {
+		t.Fatalf("expected the new finding refused, got %v", updated)
+	}
+
+	// Compiler findings baselined long ago are reported again.
+	baseline, _, err := tools.readDiagnosticsBaseline(context.Background(), server, "lint/baseline.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range baseline.Diagnostics {
+		baseline.Diagnostics[i].Baselined = baseline.Diagnostics[i].Baselined.Add(-1000 * time.Hour)
+	}
+	if err := tools.writeDiagnosticsBaseline("lint/baseline.json", baseline); err != nil {
+		t.Fatal(err)
+	}
+	messages, baselined = diagnostics(nil)
+	if !reflect.DeepEqual(messages, []string{"undefined: x", "empty branch"}) || baselined.(map[string]any)["expired"] != float64(1) {
+		t.Fatalf("expected the compiler finding expired, got %v %v", messages, baselined)
+	}
+}
+
+func TestGetDiagnosticsKeepsOpenDocuments(t *testing.T) {
+	workspace := t.TempDir()
+	openURI := convertPathToURI(filepath.Join(workspace, "open.go"))
+	closedURI := convertPathToURI(filepath.Join(workspace, "closed.go"))
+	fakeClient := &fakeLSPClient{open: map[string]bool{openURI: true}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	for _, uri := range []string{openURI, closedURI} {
+		result, err := server.GetTool("get_diagnostics").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "get_diagnostics", Arguments: map[string]any{"file_uri": uri, "include_baselined": true}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("get_diagnostics %s: %v %v", uri, err, result)
+		}
+	}
+	if !reflect.DeepEqual(fakeClient.closed, []string{closedURI}) {
+		t.Fatalf("expected only the document get_diagnostics opened to be closed, got %v", fakeClient.closed)
+	}
+}
@@ -0,0 +1,517 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// diffSymbol is a top-level declaration a diff adds, removes or changes.
+// Change is added, removed, renamed or moved, or for a declaration on both
+// sides what changed in it: signature, body, definition, value, imports,
+// comments or formatting. From is the previous name of a renamed
+// declaration, or the previous file of a moved one.
+type diffSymbol struct {
+	Symbol     string `json:"symbol"`
+	Kind       string `json:"kind"`
+	Path       string `json:"path"`
+	Change     string `json:"change"`
+	From       string `json:"from,omitempty"`
+	Exported   bool   `json:"exported,omitempty"`
+	Test       bool   `json:"test,omitempty"`
+	Behavioral bool   `json:"behavioral"`
+}
+
+type diffSummaryFile struct {
+	Path         string `json:"path"`
+	OldPath      string `json:"old_path,omitempty"`
+	Status       string `json:"status"`
+	Category     string `json:"category"`
+	Binary       bool   `json:"binary,omitempty"`
+	AddedLines   int    `json:"added_lines"`
+	RemovedLines int    `json:"removed_lines"`
+}
+
+// declUnit is a top-level declaration of one side of a diff with the
+// tokens of its code, without comments and the line breaks and trailing
+// commas formatting decides. Declarations a file may repeat, such as
+// imports, init functions and blank variables, are keyed by file.
+type declUnit struct {
+	symbol    string
+	kind      string
+	key       string
+	path      string
+	exported  bool
+	perFile   bool
+	raw       string
+	code      []string
+	comments  []string
+	signature []string
+	// tail is the code after the name of functions and types with a body,
+	// the same in a renamed declaration.
+	tail []string
+}
+
+func (t *LSPTools) registerSummarizeDiff(s *server.MCPServer) {
+	tool := mcp.NewTool("summarize_diff",
+		mcp.WithDescription("Summarize the changes since a git ref by declaration rather than by line, to write a commit message or pull request description without reading the raw diff: the functions, methods, types, variables and constants added, removed, renamed, moved or changed, each change classified as behavioral (signature, body, definition, value) or mechanical (comments, formatting, imports, renames and moves), the changed files by category with their line counts, and a conventional commit type when the changes settle it"),
+		mcp.WithTitleAnnotation("Summarize Diff"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("base",
+			mcp.Description("Git ref to compare against (default HEAD)"),
+		),
+		mcp.WithBoolean("staged",
+			mcp.Description("Summarize only the staged changes, as the next commit would record them, instead of the working tree and its untracked files (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		base := getOptionalStringArg(args, "base")
+		if base == "" {
+			base = "HEAD"
+		}
+		staged := getOptionalBoolArg(args, "staged")
+
+		var changes []diffFile
+		var err error
+		if staged {
+			changes, err = t.stagedDiff(ctx, s, base)
+		} else {
+			changes, err = t.workingTreeDiff(ctx, s, base)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		files := make([]diffSummaryFile, 0, len(changes))
+		before := make(map[string][]declUnit)
+		after := make(map[string][]declUnit)
+		var warnings []string
+		counts := map[string]int{"files": len(changes)}
+		for _, change := range changes {
+			file := diffSummaryFile{Path: change.Path, OldPath: change.OldPath, Status: change.Status, Category: diffCategory(change.Path), Binary: change.Binary}
+			for _, hunk := range change.Hunks {
+				file.AddedLines += hunk.NewLines
+				file.RemovedLines += hunk.OldLines
+			}
+			counts["added_lines"] += file.AddedLines
+			counts["removed_lines"] += file.RemovedLines
+			if !strings.HasSuffix(change.Path, ".go") || change.Binary {
+				files = append(files, file)
+				continue
+			}
+
+			// Both sides are keyed by the current path, so that the
+			// declarations of a renamed file pair up.
+			var old, current []declUnit
+			generated := false
+			if change.Status != "added" {
+				oldPath := cmp.Or(change.OldPath, change.Path)
+				shown, err := t.runCommand(ctx, s, nil, "git", "show", base+":./"+oldPath)
+				if err != nil {
+					return t.commandFailureResult("git show", shown, err)
+				}
+				old, generated, err = fileDeclarations(oldPath, change.Path, []byte(shown.Stdout))
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s at %s: %v", oldPath, base, err))
+				}
+			}
+			if change.Status != "deleted" {
+				var src []byte
+				if staged {
+					shown, err := t.runCommand(ctx, s, nil, "git", "show", ":./"+change.Path)
+					if err != nil {
+						return t.commandFailureResult("git show", shown, err)
+					}
+					src = []byte(shown.Stdout)
+				} else if src, err = os.ReadFile(filepath.Join(t.workspaceDir, filepath.FromSlash(change.Path))); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				var isGenerated bool
+				current, isGenerated, err = fileDeclarations(change.Path, change.Path, src)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s: %v", change.Path, err))
+				}
+				generated = generated || isGenerated
+			}
+			if generated {
+				file.Category = "generated"
+			} else {
+				dir := path.Dir(change.Path)
+				before[dir] = append(before[dir], old...)
+				after[dir] = append(after[dir], current...)
+			}
+			files = append(files, file)
+		}
+
+		symbols := []diffSymbol{}
+		dirs := slices.Concat(slices.Collect(maps.Keys(before)), slices.Collect(maps.Keys(after)))
+		slices.Sort(dirs)
+		for _, dir := range slices.Compact(dirs) {
+			symbols = append(symbols, compareDeclarations(before[dir], after[dir])...)
+		}
+		slices.SortStableFunc(symbols, func(a, b diffSymbol) int {
+			return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Symbol, b.Symbol))
+		})
+		for _, symbol := range symbols {
+			switch {
+			case symbol.Test:
+				counts["tests"]++
+			case symbol.Behavioral:
+				counts["behavioral"]++
+			default:
+				counts["mechanical"]++
+			}
+		}
+
+		payload := map[string]any{
+			"base":    base,
+			"staged":  staged,
+			"files":   files,
+			"symbols": symbols,
+			"counts":  counts,
+		}
+		if commitType := suggestedCommitType(files, symbols); commitType != "" {
+			payload["suggested_type"] = commitType
+		}
+		if len(changes) == 0 {
+			payload["message"] = "no changes since " + base
+		}
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// diffCategory sorts a changed file: test for test files and testdata, go,
+// module for go.mod, go.sum and workspace files, docs, or other.
+func diffCategory(name string) string {
+	base := path.Base(name)
+	switch {
+	case strings.HasSuffix(name, "_test.go") || strings.HasPrefix(name, "testdata/") || strings.Contains(name, "/testdata/"):
+		return "test"
+	case strings.HasSuffix(name, ".go"):
+		return "go"
+	case base == "go.mod" || base == "go.sum" || base == "go.work" || base == "go.work.sum":
+		return "module"
+	case slices.Contains([]string{".md", ".txt", ".rst", ".adoc"}, path.Ext(name)) || strings.HasPrefix(name, "docs/") || strings.Contains(name, "/docs/"):
+		return "docs"
+	}
+	return "other"
+}
+
+// fileDeclarations parses one side of a changed Go file and returns its
+// declarations, named as in path and keyed by key, or whether the file is
+// generated, in which case they are not returned.
+func fileDeclarations(name, key string, src []byte) ([]declUnit, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false, err
+	}
+	if ast.IsGenerated(file) {
+		return nil, true, nil
+	}
+	units := declarationUnits(fset, file, src)
+	for i := range units {
+		units[i].path = name
+		if units[i].perFile {
+			units[i].key = key + ":" + units[i].key
+		}
+	}
+	return units, false, nil
+}
+
+// declarationUnits splits the top-level declarations of a file, one unit
+// per spec of grouped declarations.
+func declarationUnits(fset *token.FileSet, file *ast.File, src []byte) []declUnit {
+	var units []declUnit
+	seen := make(map[string]int)
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	text := func(from, to token.Pos) string { return string(src[offset(from):offset(to)]) }
+	code := func(from, to token.Pos) []string {
+		tokens, _ := goTokens(text(from, to))
+		return tokens
+	}
+	add := func(unit declUnit, start, end token.Pos) {
+		unit.raw = text(start, end)
+		unit.code, unit.comments = goTokens(unit.raw)
+		unit.key = unit.symbol
+		if unit.perFile {
+			seen[unit.symbol]++
+			unit.key = fmt.Sprintf("%s#%d", unit.symbol, seen[unit.symbol])
+		}
+		units = append(units, unit)
+	}
+	startOf := func(node ast.Node, doc *ast.CommentGroup) token.Pos {
+		if doc != nil {
+			return doc.Pos()
+		}
+		return node.Pos()
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			unit := declUnit{symbol: funcSymbol(decl), kind: "func", exported: decl.Name.IsExported()}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				unit.kind = "method"
+				unit.exported = unit.exported && token.IsExported(receiverTypeName(decl.Recv.List[0].Type))
+			}
+			unit.perFile = decl.Recv == nil && decl.Name.Name == "init"
+			end := decl.End()
+			if decl.Body != nil {
+				end = decl.Body.Lbrace
+				if len(decl.Body.List) > 0 {
+					unit.tail = code(decl.Name.End(), decl.End())
+				}
+			}
+			unit.signature = code(decl.Pos(), end)
+			add(unit, startOf(decl, decl.Doc), decl.End())
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				add(declUnit{symbol: "import", kind: "import", perFile: true}, startOf(decl, decl.Doc), decl.End())
+				continue
+			}
+			for i, spec := range decl.Specs {
+				// The first spec carries the keyword and the doc of a group,
+				// the last its closing parenthesis.
+				start, end := spec.Pos(), spec.End()
+				if i == 0 {
+					start = startOf(decl, decl.Doc)
+				}
+				if i == len(decl.Specs)-1 {
+					end = decl.End()
+				}
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if i > 0 && spec.Doc != nil {
+						start = spec.Doc.Pos()
+					}
+					unit := declUnit{symbol: "type " + spec.Name.Name, kind: "type", exported: spec.Name.IsExported()}
+					switch typ := spec.Type.(type) {
+					case *ast.StructType:
+						if len(typ.Fields.List) > 0 {
+							unit.tail = code(spec.Name.End(), spec.End())
+						}
+					case *ast.InterfaceType:
+						if len(typ.Methods.List) > 0 {
+							unit.tail = code(spec.Name.End(), spec.End())
+						}
+					default:
+						unit.tail = code(spec.Name.End(), spec.End())
+					}
+					add(unit, start, end)
+				case *ast.ValueSpec:
+					if i > 0 && spec.Doc != nil {
+						start = spec.Doc.Pos()
+					}
+					unit := declUnit{kind: decl.Tok.String()}
+					var names []string
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+						unit.exported = unit.exported || name.IsExported()
+						unit.perFile = unit.perFile || name.Name == "_"
+					}
+					unit.symbol = unit.kind + " " + strings.Join(names, ", ")
+					add(unit, start, end)
+				}
+			}
+		}
+	}
+	return units
+}
+
+// goTokens returns the code tokens and the comments of Go source. The
+// semicolons inserted at line ends and the commas before a closing
+// bracket are left out, as line breaks decide them.
+func goTokens(src string) (code, comments []string) {
+	fset := token.NewFileSet()
+	var sc scanner.Scanner
+	sc.Init(fset.AddFile("", -1, len(src)), []byte(src), nil, scanner.ScanComments)
+	for {
+		_, tok, lit := sc.Scan()
+		switch {
+		case tok == token.EOF:
+			return code, comments
+		case tok == token.COMMENT:
+			comments = append(comments, lit)
+		case tok == token.SEMICOLON && lit == "\n":
+		case lit != "":
+			code = append(code, lit)
+		default:
+			if (tok == token.RPAREN || tok == token.RBRACE || tok == token.RBRACK) && len(code) > 0 && code[len(code)-1] == "," {
+				code = code[:len(code)-1]
+			}
+			code = append(code, tok.String())
+		}
+	}
+}
+
+// compareDeclarations pairs the declarations of a package before and after
+// a diff by key, then the added ones with the removed ones of the same
+// kind and code under another name, which are renames.
+func compareDeclarations(before, after []declUnit) []diffSymbol {
+	previous := make(map[string]declUnit, len(before))
+	for _, unit := range before {
+		previous[unit.key] = unit
+	}
+	var symbols []diffSymbol
+	var added []declUnit
+	paired := make(map[string]bool)
+	for _, unit := range after {
+		old, ok := previous[unit.key]
+		if !ok {
+			added = append(added, unit)
+			continue
+		}
+		paired[unit.key] = true
+		change := declarationChange(old, unit)
+		switch {
+		case change == "" && old.path == unit.path:
+			continue
+		case change == "":
+			change = "moved"
+		}
+		symbol := unit.diffSymbol(change)
+		if old.path != unit.path {
+			symbol.From = old.path
+		}
+		symbols = append(symbols, symbol)
+	}
+	var removed []declUnit
+	for _, unit := range before {
+		if !paired[unit.key] {
+			removed = append(removed, unit)
+		}
+	}
+	for _, unit := range added {
+		i := slices.IndexFunc(removed, func(old declUnit) bool {
+			return old.kind == unit.kind && old.tail != nil && slices.Equal(old.tail, unit.tail) && receiverOf(old.symbol) == receiverOf(unit.symbol)
+		})
+		if i < 0 {
+			symbols = append(symbols, unit.diffSymbol("added"))
+			continue
+		}
+		symbol := unit.diffSymbol("renamed")
+		symbol.From = removed[i].symbol
+		removed = slices.Delete(removed, i, i+1)
+		symbols = append(symbols, symbol)
+	}
+	for _, unit := range removed {
+		symbols = append(symbols, unit.diffSymbol("removed"))
+	}
+	return symbols
+}
+
+// declarationChange tells what changed between two sides of a
+// declaration, or "" when nothing did.
+func declarationChange(old, current declUnit) string {
+	switch {
+	case old.raw == current.raw:
+		return ""
+	case !slices.Equal(old.code, current.code):
+		switch current.kind {
+		case "func", "method":
+			if !slices.Equal(old.signature, current.signature) {
+				return "signature"
+			}
+			return "body"
+		case "type":
+			return "definition"
+		case "import":
+			return "imports"
+		}
+		return "value"
+	case !slices.Equal(old.comments, current.comments):
+		return "comments"
+	}
+	return "formatting"
+}
+
+// receiverOf returns the receiver of a method symbol, such as (*T), or ""
+// for other declarations.
+func receiverOf(symbol string) string {
+	if recv, _, ok := strings.Cut(strings.TrimPrefix(symbol, "func "), ")."); ok && strings.HasPrefix(recv, "(") {
+		return recv + ")"
+	}
+	return ""
+}
+
+func (u declUnit) diffSymbol(change string) diffSymbol {
+	behavioral := u.kind != "import" && slices.Contains([]string{"added", "removed", "signature", "body", "definition", "value"}, change)
+	return diffSymbol{
+		Symbol:     u.symbol,
+		Kind:       u.kind,
+		Path:       u.path,
+		Change:     change,
+		Exported:   u.exported,
+		Test:       strings.HasSuffix(u.path, "_test.go"),
+		Behavioral: behavioral,
+	}
+}
+
+// suggestedCommitType returns the conventional commit type the changes
+// settle: docs, test or build when only files of that category changed;
+// feat when new exported API is added; style, docs or refactor when the Go
+// changes are all mechanical. It returns "" otherwise, such as for
+// behavioral changes, which may be features or fixes.
+func suggestedCommitType(files []diffSummaryFile, symbols []diffSymbol) string {
+	categories := make(map[string]bool)
+	for _, file := range files {
+		categories[file.Category] = true
+	}
+	if len(categories) == 1 {
+		switch {
+		case categories["docs"]:
+			return "docs"
+		case categories["test"]:
+			return "test"
+		case categories["module"]:
+			return "build"
+		}
+	}
+	changes := make(map[string]bool)
+	behavioral, feature := false, false
+	for _, symbol := range symbols {
+		if symbol.Test {
+			continue
+		}
+		behavioral = behavioral || symbol.Behavioral
+		feature = feature || symbol.Behavioral && symbol.Change == "added" && symbol.Exported
+		changes[symbol.Change] = true
+	}
+	switch {
+	case feature:
+		return "feat"
+	case behavioral || len(changes) == 0 || categories["other"]:
+		return ""
+	}
+	delete(changes, "formatting")
+	switch {
+	case len(changes) == 0:
+		return "style"
+	case len(changes) == 1 && changes["comments"]:
+		return "docs"
+	}
+	return "refactor"
+}
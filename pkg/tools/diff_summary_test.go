@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestCompareDeclarations(t *testing.T) {
+	before, _, err := fileDeclarations("a.go", "a.go", []byte(`package a
+
+import "fmt"
+
+// Greet greets.
+func Greet(name string) { fmt.Println("hello", name) }
+
+func Sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+func helper() int { return 1 }
+
+func Gone() {}
+
+var limits = []int{1, 2}
+
+const (
+	A = 1
+	B = 2
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _, err := fileDeclarations("a.go", "a.go", []byte(`package a
+
+import (
+	"fmt"
+	"os"
+)
+
+// Greet greets someone.
+func Greet(name string) { fmt.Println("hello", name) }
+
+func Total(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+func helper() int { return 2 }
+
+func New() *os.File { return nil }
+
+var limits = []int{
+	1,
+	2,
+}
+
+const (
+	A = 1
+	B = 3
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(map[string]string)
+	for _, symbol := range compareDeclarations(before, after) {
+		change := symbol.Change
+		if symbol.From != "" {
+			change += " from " + symbol.From
+		}
+		if symbol.Behavioral {
+			change += " (behavioral)"
+		}
+		changes[symbol.Symbol] = change
+	}
+	want := map[string]string{
+		"import":      "imports",
+		"func Greet":  "comments",
+		"func Total":  "renamed from func Sum",
+		"func helper": "body (behavioral)",
+		"func New":    "added (behavioral)",
+		"func Gone":   "removed (behavioral)",
+		"var limits":  "formatting",
+		"const B":     "value (behavioral)",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("compareDeclarations =\n%v\nwant\n%v", changes, want)
+	}
+}
+
+func TestSummarizeDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	workspace := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = workspace
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n\nfunc Get(items []int, i int) int { return items[i] }\n")
+	writeWorkspaceFile(t, workspace, "store/extra.go", "package store\n\n// Size counts items.\nfunc Size(items []int) int { return len(items) }\n")
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("summarize_diff").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "summarize_diff", Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("summarize_diff: %v %v", err, result)
+		}
+		return structured(result)
+	}
+
+	// Moving Size to store.go, comment included, is mechanical.
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n\nfunc Get(items []int, i int) int { return items[i] }\n\n// Size counts items.\nfunc Size(items []int) int { return len(items) }\n")
+	writeWorkspaceFile(t, workspace, "store/extra.go", "package store\n")
+	writeWorkspaceFile(t, workspace, "README.md", "# app\n")
+	summary := call(map[string]any{})
+	symbols := summary["symbols"].([]any)
+	if len(symbols) != 1 || symbols[0].(map[string]any)["change"] != "moved" || symbols[0].(map[string]any)["from"] != "store/extra.go" {
+		t.Fatalf("unexpected symbols %v", symbols)
+	}
+	if summary["suggested_type"] != "refactor" || len(summary["files"].([]any)) != 3 {
+		t.Fatalf("unexpected summary %v", summary)
+	}
+
+	// Only the staged README and new exported function count when staged.
+	writeWorkspaceFile(t, workspace, "store/new.go", "package store\n\nfunc Clear(items []int) []int { return items[:0] }\n")
+	git("add", "README.md", "store/new.go")
+	staged := call(map[string]any{"staged": true})
+	files := staged["files"].([]any)
+	symbols = staged["symbols"].([]any)
+	if len(files) != 2 || len(symbols) != 1 || symbols[0].(map[string]any)["symbol"] != "func Clear" || staged["suggested_type"] != "feat" {
+		t.Fatalf("unexpected staged summary %v", staged)
+	}
+	counts := staged["counts"].(map[string]any)
+	if counts["behavioral"] != float64(1) || counts["added_lines"] != float64(4) {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+}
@@ -0,0 +1,465 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const defaultDockerBuildTimeout = 20 * time.Minute
+
+var (
+	lookupContainerBinary = exec.LookPath
+
+	dockerVariablePattern  = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::?-([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+	goCompilerErrorPattern = regexp.MustCompile(`([^\s:]+\.go):(\d+):(\d+): (.+)$`)
+	staticRuntimeImages    = []string{"scratch", "gcr.io/distroless/static", "gcr.io/distroless/base", "alpine"}
+)
+
+type dockerInstruction struct {
+	Keyword string
+	Args    string
+	Line    int
+}
+
+type dockerStage struct {
+	Name      string `json:"name,omitempty"`
+	Image     string `json:"image"`
+	Line      int    `json:"line"`
+	GoVersion string `json:"go_version,omitempty"`
+	BuildsGo  bool   `json:"builds_go"`
+
+	instructions []dockerInstruction
+}
+
+type dockerFinding struct {
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+type buildError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type dockerfileReport struct {
+	File        string          `json:"file"`
+	Stages      []dockerStage   `json:"stages"`
+	Findings    []dockerFinding `json:"findings"`
+	Build       *commandResult  `json:"build,omitempty"`
+	BuildErrors []buildError    `json:"build_errors,omitempty"`
+}
+
+func (t *LSPTools) registerCheckDockerBuild(s *server.MCPServer) {
+	tool := mcp.NewTool("check_docker_build",
+		mcp.WithDescription("Validate Go build stages in workspace Dockerfiles (Go version vs go.mod, CGO settings, go.sum copies) and optionally run the container build"),
+		mcp.WithTitleAnnotation("Check Docker Build"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file",
+			mcp.Description("Dockerfile path relative to the workspace. Defaults to every Dockerfile found"),
+		),
+		mcp.WithBoolean("build",
+			mcp.Description("Run docker (or podman) build for each checked Dockerfile"),
+		),
+		mcp.WithString("timeout",
+			mcp.Description("Maximum time per container build (e.g. 10m). Defaults to 20m"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultDockerBuildTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		var files []string
+		if file := getOptionalStringArg(args, "file"); file != "" {
+			files = []string{filepath.Join(t.workspaceDir, filepath.FromSlash(file))}
+		} else {
+			files, err = findDockerfiles(t.workspaceDir)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(files) == 0 {
+			return mcp.NewToolResultError("no Dockerfile found in the workspace"), nil
+		}
+
+		goModVersion := readGoModVersion(t.workspaceDir)
+		hasGoSum := fileExists(filepath.Join(t.workspaceDir, "go.sum"))
+		ignored := readDockerignore(t.workspaceDir)
+
+		reports := make([]dockerfileReport, 0, len(files))
+		for _, path := range files {
+			report, err := analyzeDockerfile(path, goModVersion, hasGoSum, ignored)
+			if err != nil {
+				return nil, err
+			}
+			report.File = relativeSlashPath(t.workspaceDir, path)
+			reports = append(reports, report)
+		}
+
+		if getOptionalBoolArg(args, "build") {
+			binary, err := determineContainerBinary()
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			token := getProgressToken(request.Params.Meta)
+			for i := range reports {
+				sendProgressNotification(ctx, s, token, fmt.Sprintf("Building %s", reports[i].File))
+				buildCtx, cancel := context.WithTimeout(ctx, timeout)
+				result, runErr := t.runCommand(buildCtx, s, token, binary, "build", "-f", reports[i].File, ".")
+				timedOut := errors.Is(buildCtx.Err(), context.DeadlineExceeded)
+				cancel()
+
+				reports[i].Build = &result
+				if runErr != nil {
+					reports[i].BuildErrors = parseBuildErrors(result.Stdout + "\n" + result.Stderr)
+					if timedOut {
+						reports[i].BuildErrors = append(reports[i].BuildErrors, buildError{Message: fmt.Sprintf("build timed out after %s", timeout)})
+					}
+				}
+			}
+		}
+
+		payload := map[string]any{"dockerfiles": reports}
+		if goModVersion != "" {
+			payload["go_mod_version"] = goModVersion
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func determineContainerBinary() (string, error) {
+	for _, name := range []string{"docker", "podman"} {
+		if path, err := lookupContainerBinary(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("neither docker nor podman binary found")
+}
+
+func findDockerfiles(root string) ([]string, error) {
+	var files []string
+	err := walkWorkspaceFiles(root, func(path string) error {
+		if isDockerfileName(filepath.Base(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func isDockerfileName(name string) bool {
+	lower := strings.ToLower(name)
+	return lower == "dockerfile" || lower == "containerfile" ||
+		strings.HasPrefix(lower, "dockerfile.") || strings.HasSuffix(lower, ".dockerfile")
+}
+
+func readDockerignore(root string) map[string]struct{} {
+	ignored := make(map[string]struct{})
+	data, err := os.ReadFile(filepath.Join(root, ".dockerignore"))
+	if err != nil {
+		return ignored
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		ignored[strings.TrimPrefix(line, "/")] = struct{}{}
+	}
+	return ignored
+}
+
+func parseDockerfile(path string) ([]dockerInstruction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var (
+		instructions []dockerInstruction
+		current      strings.Builder
+		startLine    int
+		lineNo       int
+	)
+	flush := func() {
+		text := strings.TrimSpace(current.String())
+		current.Reset()
+		if text == "" {
+			return
+		}
+		keyword, rest, _ := strings.Cut(text, " ")
+		instructions = append(instructions, dockerInstruction{
+			Keyword: strings.ToUpper(keyword),
+			Args:    strings.TrimSpace(rest),
+			Line:    startLine,
+		})
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if current.Len() == 0 {
+			if line == "" {
+				continue
+			}
+			startLine = lineNo
+		}
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\"))
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(line)
+		flush()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	flush()
+	return instructions, nil
+}
+
+func analyzeDockerfile(path, goModVersion string, hasGoSum bool, ignored map[string]struct{}) (dockerfileReport, error) {
+	instructions, err := parseDockerfile(path)
+	if err != nil {
+		return dockerfileReport{}, err
+	}
+
+	report := dockerfileReport{Findings: []dockerFinding{}}
+	globalArgs := make(map[string]string)
+	var stage *dockerStage
+
+	for _, inst := range instructions {
+		switch inst.Keyword {
+		case "ARG":
+			if stage == nil {
+				name, value, _ := strings.Cut(inst.Args, "=")
+				globalArgs[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		case "FROM":
+			fields := strings.Fields(inst.Args)
+			var image, name string
+			for i := 0; i < len(fields); i++ {
+				if strings.HasPrefix(fields[i], "--") {
+					continue
+				}
+				if image == "" {
+					image = expandDockerVariables(fields[i], globalArgs)
+					continue
+				}
+				if strings.EqualFold(fields[i], "as") && i+1 < len(fields) {
+					name = fields[i+1]
+					break
+				}
+			}
+			report.Stages = append(report.Stages, dockerStage{
+				Name:      name,
+				Image:     image,
+				Line:      inst.Line,
+				GoVersion: golangImageVersion(image),
+			})
+			stage = &report.Stages[len(report.Stages)-1]
+		default:
+			if stage == nil {
+				continue
+			}
+			stage.instructions = append(stage.instructions, inst)
+			if inst.Keyword == "RUN" && (strings.Contains(inst.Args, "go build") || strings.Contains(inst.Args, "go install")) {
+				stage.BuildsGo = true
+			}
+		}
+	}
+
+	if len(report.Stages) == 0 {
+		report.Findings = append(report.Findings, dockerFinding{Severity: "error", Message: "no FROM instruction found"})
+		return report, nil
+	}
+
+	runtime := report.Stages[len(report.Stages)-1]
+	for _, st := range report.Stages {
+		if !st.BuildsGo && st.GoVersion == "" {
+			continue
+		}
+		report.Findings = append(report.Findings, checkDockerGoStage(st, runtime, goModVersion, hasGoSum)...)
+	}
+
+	if _, ok := ignored["go.sum"]; ok && hasGoSum {
+		report.Findings = append(report.Findings, dockerFinding{
+			Severity: "error",
+			Message:  ".dockerignore excludes go.sum; module verification will fail inside the build",
+		})
+	}
+	return report, nil
+}
+
+func checkDockerGoStage(stage, runtime dockerStage, goModVersion string, hasGoSum bool) []dockerFinding {
+	var findings []dockerFinding
+
+	if stage.GoVersion != "" && goModVersion != "" && goVersionOlder(stage.GoVersion, goModVersion) {
+		findings = append(findings, dockerFinding{
+			Severity: "error",
+			Line:     stage.Line,
+			Message:  fmt.Sprintf("stage uses Go %s but go.mod requires go %s", stage.GoVersion, goModVersion),
+		})
+	}
+	if stage.BuildsGo && stage.GoVersion == "" {
+		findings = append(findings, dockerFinding{
+			Severity: "warning",
+			Line:     stage.Line,
+			Message:  fmt.Sprintf("stage runs go build on %q; the Go toolchain version cannot be verified against go.mod", stage.Image),
+		})
+	}
+
+	copiesGoMod, copiesGoSum, copiesContext := false, false, false
+	cgoDisabled := false
+	for _, inst := range stage.instructions {
+		switch inst.Keyword {
+		case "COPY", "ADD":
+			sources := dockerCopySources(inst.Args)
+			for _, src := range sources {
+				switch {
+				case src == "." || src == "./" || src == "*":
+					copiesContext = true
+				case strings.Contains(src, "go.sum"):
+					copiesGoSum = true
+				case strings.Contains(src, "go.mod") || strings.Contains(src, "go.*"):
+					copiesGoMod = true
+					if strings.Contains(src, "go.*") {
+						copiesGoSum = true
+					}
+				}
+			}
+		case "ENV", "RUN":
+			if strings.Contains(inst.Args, "CGO_ENABLED=0") || strings.Contains(inst.Args, "CGO_ENABLED 0") {
+				cgoDisabled = true
+			}
+		}
+	}
+
+	if copiesGoMod && !copiesGoSum && !copiesContext && hasGoSum {
+		findings = append(findings, dockerFinding{
+			Severity: "error",
+			Line:     stage.Line,
+			Message:  "go.mod is copied without go.sum; go mod download will fail or skip checksum verification",
+		})
+	}
+
+	if stage.BuildsGo && !cgoDisabled && stage.Line != runtime.Line && isStaticRuntimeImage(runtime.Image) {
+		findings = append(findings, dockerFinding{
+			Severity: "warning",
+			Line:     stage.Line,
+			Message:  fmt.Sprintf("CGO_ENABLED=0 is not set but the runtime image %q has no glibc; the binary may fail to start", runtime.Image),
+		})
+	}
+	return findings
+}
+
+func dockerCopySources(args string) []string {
+	fields := strings.Fields(args)
+	var paths []string
+	for _, field := range fields {
+		if strings.HasPrefix(field, "--") {
+			continue
+		}
+		paths = append(paths, strings.Trim(field, `[]",`))
+	}
+	if len(paths) < 2 {
+		return nil
+	}
+	return paths[:len(paths)-1]
+}
+
+func isStaticRuntimeImage(image string) bool {
+	for _, prefix := range staticRuntimeImages {
+		if image == prefix || strings.HasPrefix(image, prefix+":") || strings.HasPrefix(image, prefix+"/") || strings.HasPrefix(image, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func expandDockerVariables(value string, vars map[string]string) string {
+	return dockerVariablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		groups := dockerVariablePattern.FindStringSubmatch(match)
+		name := groups[1]
+		if name == "" {
+			name = groups[3]
+		}
+		if resolved, ok := vars[name]; ok && resolved != "" {
+			return resolved
+		}
+		return groups[2]
+	})
+}
+
+// goVersionOlder reports whether have is older than want, comparing only as
+// many components as have specifies (golang:1.24 satisfies go 1.24.2).
+func goVersionOlder(have, want string) bool {
+	haveParts := strings.Split(strings.TrimPrefix(have, "go"), ".")
+	wantParts := strings.Split(strings.TrimPrefix(want, "go"), ".")
+	for i := 0; i < len(haveParts) && i < len(wantParts); i++ {
+		h, errH := strconv.Atoi(haveParts[i])
+		w, errW := strconv.Atoi(leadingDigits(wantParts[i]))
+		if errH != nil || errW != nil {
+			return false
+		}
+		if h != w {
+			return h < w
+		}
+	}
+	return false
+}
+
+func leadingDigits(value string) string {
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	return value[:end]
+}
+
+func parseBuildErrors(output string) []buildError {
+	var errs []buildError
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if match := goCompilerErrorPattern.FindStringSubmatch(line); match != nil {
+			lineNo, _ := strconv.Atoi(match[2])
+			column, _ := strconv.Atoi(match[3])
+			errs = append(errs, buildError{File: match[1], Line: lineNo, Column: column, Message: match[4]})
+			continue
+		}
+		if strings.HasPrefix(line, "ERROR:") || strings.HasPrefix(line, "Error:") {
+			errs = append(errs, buildError{Message: line})
+		}
+	}
+	return errs
+}
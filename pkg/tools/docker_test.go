@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeDockerfileFindsGoStageProblems(t *testing.T) {
+	workspace := t.TempDir()
+	path := writeWorkspaceFile(t, workspace, "Dockerfile", `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+FROM golang:${GO_VERSION}-alpine AS build
+WORKDIR /src
+COPY go.mod ./
+RUN go mod download
+COPY cmd ./cmd
+RUN go build \
+    -o /out/app ./cmd/app
+
+FROM gcr.io/distroless/static:nonroot
+COPY --from=build /out/app /app
+`)
+
+	report, err := analyzeDockerfile(path, "1.24.1", true, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("analyzeDockerfile returned error: %v", err)
+	}
+	if len(report.Stages) != 2 {
+		t.Fatalf("unexpected stages %#v", report.Stages)
+	}
+	build := report.Stages[0]
+	if build.Name != "build" || build.GoVersion != "1.22" || !build.BuildsGo || build.Line != 3 {
+		t.Fatalf("unexpected build stage %#v", build)
+	}
+
+	var messages []string
+	for _, finding := range report.Findings {
+		messages = append(messages, finding.Message)
+	}
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{"go.mod requires go 1.24.1", "without go.sum", "CGO_ENABLED=0"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected finding containing %q, got:\n%s", want, joined)
+		}
+	}
+}
+
+func TestAnalyzeDockerfileCleanBuild(t *testing.T) {
+	workspace := t.TempDir()
+	path := writeWorkspaceFile(t, workspace, "build/app.Dockerfile", `FROM golang:1.24 AS build
+ENV CGO_ENABLED=0
+COPY go.mod go.sum ./
+RUN go mod download
+COPY . .
+RUN go build -o /app .
+FROM scratch
+COPY --from=build /app /app
+`)
+
+	report, err := analyzeDockerfile(path, "1.24.1", true, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("analyzeDockerfile returned error: %v", err)
+	}
+	if len(report.Findings) != 0 {
+		t.Fatalf("expected no findings, got %#v", report.Findings)
+	}
+
+	files, err := findDockerfiles(workspace)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("findDockerfiles returned %v, %v", files, err)
+	}
+}
+
+func TestGoVersionOlder(t *testing.T) {
+	cases := []struct {
+		have, want string
+		older      bool
+	}{
+		{"1.22", "1.24.1", true},
+		{"1.24", "1.24.1", false},
+		{"1.24.0", "1.24.1", true},
+		{"1.25", "1.24", false},
+		{"1.24", "1.24rc1", false},
+	}
+	for _, tc := range cases {
+		if got := goVersionOlder(tc.have, tc.want); got != tc.older {
+			t.Fatalf("goVersionOlder(%q, %q) = %v", tc.have, tc.want, got)
+		}
+	}
+}
+
+func TestParseBuildErrors(t *testing.T) {
+	output := "#12 1.234 ./main.go:10:2: undefined: foo\nERROR: failed to solve: process \"/bin/sh -c go build\" did not complete"
+	errs := parseBuildErrors(output)
+	if len(errs) != 2 {
+		t.Fatalf("unexpected errors %#v", errs)
+	}
+	if errs[0].File != "./main.go" || errs[0].Line != 10 || errs[0].Column != 2 || errs[0].Message != "undefined: foo" {
+		t.Fatalf("unexpected compiler error %#v", errs[0])
+	}
+}
@@ -0,0 +1,541 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+var (
+	docLinkPattern      = regexp.MustCompile(`\[(\*?[\w./-]+)\]`)
+	seeReferencePattern = regexp.MustCompile(`(?i)\bsee\s+\*?((?:[\w.-]+/)*\w+\.[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)?)`)
+	codeSpanPattern     = regexp.MustCompile("`([^`\n]+)`")
+	markdownLinkPattern = regexp.MustCompile(`\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	filePathPattern     = regexp.MustCompile(`(?:^|[\s("'])((?:\.{1,2}/)?(?:[\w.-]+/)+[\w.-]+\.(?:go|md|json|ya?ml|proto|sql|txt|sh|mod))\b`)
+	symbolRefPattern    = regexp.MustCompile(`^\*?(?:[\w.-]+/)*[A-Za-z_]\w*(?:\.[A-Za-z_]\w*){1,2}$`)
+	identifierPattern   = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+)
+
+type docReference struct {
+	File        string   `json:"file"`
+	Line        int      `json:"line"`
+	Reference   string   `json:"reference"`
+	Kind        string   `json:"kind"`
+	Reason      string   `json:"reason"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// packageSymbols holds the top-level names of a package together with the
+// methods and fields of each of its types.
+type packageSymbols struct {
+	importPath string
+	relDir     string
+	name       string
+	top        map[string]bool
+	members    map[string]map[string]bool
+}
+
+type symbolIndex struct {
+	modulePath string
+	packages   []*packageSymbols
+	byPath     map[string]*packageSymbols
+	byName     map[string][]*packageSymbols
+}
+
+func (t *LSPTools) registerCheckReferencesInDocs(s *server.MCPServer) {
+	tool := mcp.NewTool("check_references_in_docs",
+		mcp.WithDescription("Find references to Go symbols and file paths in comments and markdown that no longer resolve, with suggested new locations"),
+		mcp.WithTitleAnnotation("Check References In Docs"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Indexing workspace symbols")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		index := buildSymbolIndex(ws)
+
+		checked := 0
+		var broken []docReference
+		for _, pkg := range ws.Packages {
+			for _, file := range pkg.Files {
+				n, refs := checkCommentReferences(t.workspaceDir, index, pkg, file)
+				checked += n
+				broken = append(broken, refs...)
+			}
+		}
+		err = walkWorkspaceFiles(t.workspaceDir, func(path string) error {
+			if !strings.EqualFold(filepath.Ext(path), ".md") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			n, refs := checkMarkdownReferences(t.workspaceDir, index, relativeSlashPath(t.workspaceDir, path), string(data))
+			checked += n
+			broken = append(broken, refs...)
+			return nil
+		})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if lspClient := t.getClient(); lspClient != nil {
+			sendProgressNotification(ctx, s, token, "Searching workspace symbols for suggestions")
+			t.addSymbolSuggestions(ctx, lspClient.WorkspaceSymbols, broken)
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"checked": checked,
+			"broken":  broken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func buildSymbolIndex(ws *gosrc.Workspace) *symbolIndex {
+	index := &symbolIndex{
+		modulePath: ws.ModulePath,
+		byPath:     make(map[string]*packageSymbols),
+		byName:     make(map[string][]*packageSymbols),
+	}
+	for _, pkg := range ws.Packages {
+		importPath := strings.TrimSuffix(pkg.ImportPath, "_test")
+		syms, ok := index.byPath[importPath]
+		if !ok {
+			syms = &packageSymbols{
+				importPath: importPath,
+				relDir:     pkg.RelDir,
+				name:       strings.TrimSuffix(pkg.Name, "_test"),
+				top:        make(map[string]bool),
+				members:    make(map[string]map[string]bool),
+			}
+			index.byPath[importPath] = syms
+			index.byName[syms.name] = append(index.byName[syms.name], syms)
+			index.packages = append(index.packages, syms)
+		}
+		for _, file := range pkg.Files {
+			collectFileSymbols(syms, file.Syntax)
+		}
+	}
+	return index
+}
+
+func collectFileSymbols(syms *packageSymbols, file *ast.File) {
+	addMember := func(typeName, name string) {
+		if syms.members[typeName] == nil {
+			syms.members[typeName] = make(map[string]bool)
+		}
+		syms.members[typeName][name] = true
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				syms.top[d.Name.Name] = true
+				continue
+			}
+			if recv := receiverTypeName(d.Recv.List[0].Type); recv != "" {
+				addMember(recv, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					syms.top[sp.Name.Name] = true
+					switch typ := sp.Type.(type) {
+					case *ast.StructType:
+						for _, field := range typ.Fields.List {
+							for _, name := range field.Names {
+								addMember(sp.Name.Name, name.Name)
+							}
+							if len(field.Names) == 0 {
+								if embedded := receiverTypeName(field.Type); embedded != "" {
+									addMember(sp.Name.Name, embedded)
+								}
+							}
+						}
+					case *ast.InterfaceType:
+						for _, method := range typ.Methods.List {
+							for _, name := range method.Names {
+								addMember(sp.Name.Name, name.Name)
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for _, name := range sp.Names {
+						syms.top[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// receiverTypeName returns the base type name of a receiver or embedded
+// field expression, ignoring pointers and type parameters.
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		case *ast.SelectorExpr:
+			return e.Sel.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// lookupPackages resolves a reference qualifier given as an import path, a
+// workspace-relative directory or a package name.
+func (idx *symbolIndex) lookupPackages(qualifier string) []*packageSymbols {
+	if pkg, ok := idx.byPath[qualifier]; ok {
+		return []*packageSymbols{pkg}
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(qualifier, idx.modulePath+"/"), "./")
+	for _, pkg := range idx.packages {
+		if pkg.relDir == rel {
+			return []*packageSymbols{pkg}
+		}
+	}
+	if !strings.Contains(qualifier, "/") {
+		return idx.byName[qualifier]
+	}
+	return nil
+}
+
+// isWorkspaceQualifier reports whether an unresolved qualifier points inside
+// the workspace (and is therefore stale) rather than at another module.
+func (idx *symbolIndex) isWorkspaceQualifier(root, qualifier string) bool {
+	if idx.modulePath != "" && strings.HasPrefix(qualifier, idx.modulePath+"/") {
+		return true
+	}
+	if !strings.Contains(qualifier, "/") || strings.Contains(strings.SplitN(qualifier, "/", 2)[0], ".") {
+		return false
+	}
+	first := strings.SplitN(strings.TrimPrefix(qualifier, "./"), "/", 2)[0]
+	info, err := os.Stat(filepath.Join(root, first))
+	return err == nil && info.IsDir()
+}
+
+// resolveSymbolReference checks a reference such as "pkg/server.Config",
+// "server.Config.Start" or (inside package current) "Config.Start". It
+// returns an empty reason when the reference resolves or cannot be judged.
+func (idx *symbolIndex) resolveSymbolReference(root, ref string, current *packageSymbols) (kind, reason string) {
+	ref = strings.TrimPrefix(ref, "*")
+	qualifier, rest := "", ref
+	if slash := strings.LastIndex(ref, "/"); slash >= 0 {
+		dot := strings.Index(ref[slash+1:], ".")
+		if dot < 0 {
+			return "", ""
+		}
+		qualifier, rest = ref[:slash+1+dot], ref[slash+1+dot+1:]
+	} else if first, remainder, ok := strings.Cut(ref, "."); ok && len(idx.byName[first]) > 0 && (current == nil || !current.top[first]) {
+		qualifier, rest = first, remainder
+	}
+
+	var candidates []*packageSymbols
+	if qualifier == "" {
+		if current == nil {
+			return "", ""
+		}
+		candidates = []*packageSymbols{current}
+	} else {
+		candidates = idx.lookupPackages(qualifier)
+		if len(candidates) == 0 {
+			if idx.isWorkspaceQualifier(root, qualifier) {
+				return "package", fmt.Sprintf("package %s not found in the workspace", qualifier)
+			}
+			return "", ""
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	for _, pkg := range candidates {
+		if !pkg.top[parts[0]] {
+			continue
+		}
+		if len(parts) == 1 || pkg.members[parts[0]][parts[1]] {
+			return "", ""
+		}
+	}
+	if len(parts) > 1 {
+		for _, pkg := range candidates {
+			if pkg.top[parts[0]] {
+				return "symbol", fmt.Sprintf("%s has no field or method %s", parts[0], parts[1])
+			}
+		}
+	}
+	return "symbol", fmt.Sprintf("%s is not declared in %s", parts[0], candidates[0].importPath)
+}
+
+// suggest lists workspace declarations whose name matches the last element
+// of a broken reference.
+func (idx *symbolIndex) suggest(ref string) []string {
+	name := ref[strings.LastIndexAny(ref, "./")+1:]
+	if !identifierPattern.MatchString(name) {
+		return nil
+	}
+	var suggestions []string
+	for _, pkg := range idx.packages {
+		if pkg.top[name] {
+			suggestions = append(suggestions, pkg.relDir+"."+name)
+		}
+		for _, typeName := range sortedKeys(pkg.members) {
+			if pkg.members[typeName][name] {
+				suggestions = append(suggestions, pkg.relDir+"."+typeName+"."+name)
+			}
+		}
+	}
+	if len(suggestions) > 5 {
+		suggestions = suggestions[:5]
+	}
+	return suggestions
+}
+
+func checkCommentReferences(root string, index *symbolIndex, pkg *gosrc.Package, file *gosrc.File) (int, []docReference) {
+	current := index.byPath[strings.TrimSuffix(pkg.ImportPath, "_test")]
+	// Qualifiers naming a non-workspace import of this file (such as the
+	// mcp-go server package next to our own pkg/server) are not judged.
+	foreign := make(map[string]bool)
+	for _, spec := range file.Syntax.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if _, ok := index.byPath[importPath]; !ok {
+			foreign[name] = true
+		}
+	}
+	checked := 0
+	var broken []docReference
+	for _, group := range file.Syntax.Comments {
+		for _, comment := range group.List {
+			text := comment.Text
+			line := file.Line(comment.Slash)
+			lineOf := func(offset int) int {
+				return line + strings.Count(text[:offset], "\n")
+			}
+
+			seen := map[string]bool{}
+			symbolRef := func(ref string, offset int, unqualifiedOK bool) {
+				if seen[ref] || !symbolRefPattern.MatchString(ref) && !(unqualifiedOK && identifierPattern.MatchString(strings.TrimPrefix(ref, "*"))) {
+					return
+				}
+				seen[ref] = true
+				if first, _, _ := strings.Cut(strings.TrimPrefix(ref, "*"), "."); foreign[first] && !strings.Contains(ref, "/") {
+					return
+				}
+				checked++
+				if kind, reason := index.resolveSymbolReference(root, ref, current); reason != "" {
+					broken = append(broken, docReference{File: file.RelPath, Line: lineOf(offset), Reference: ref, Kind: kind, Reason: reason, Suggestions: index.suggest(ref)})
+				}
+			}
+
+			for _, m := range docLinkPattern.FindAllStringSubmatchIndex(text, -1) {
+				ref := text[m[2]:m[3]]
+				if next := text[m[1]:]; strings.HasPrefix(next, "(") || strings.HasPrefix(next, ":") {
+					continue
+				}
+				// Unqualified doc links only name exported identifiers.
+				name := strings.TrimPrefix(ref, "*")
+				symbolRef(ref, m[0], name != "" && name[0] >= 'A' && name[0] <= 'Z')
+			}
+			for _, m := range seeReferencePattern.FindAllStringSubmatchIndex(text, -1) {
+				ref := strings.TrimRight(text[m[2]:m[3]], ".")
+				if !isFileReference(ref) {
+					symbolRef(ref, m[0], false)
+				}
+			}
+			for _, m := range filePathPattern.FindAllStringSubmatchIndex(text, -1) {
+				ref := text[m[2]:m[3]]
+				if seen[ref] || isExternalPath(ref) {
+					continue
+				}
+				seen[ref] = true
+				checked++
+				if !pathExists(root, filepath.Dir(file.Path), ref) {
+					broken = append(broken, docReference{File: file.RelPath, Line: lineOf(m[2]), Reference: ref, Kind: "file", Reason: "file does not exist", Suggestions: suggestFiles(root, ref)})
+				}
+			}
+		}
+	}
+	return checked, broken
+}
+
+func checkMarkdownReferences(root string, index *symbolIndex, rel, markdown string) (int, []docReference) {
+	dir := filepath.Join(root, filepath.FromSlash(path.Dir(rel)))
+	checked := 0
+	var broken []docReference
+	inFence := ""
+	for i, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inFence != "" {
+			if strings.HasPrefix(trimmed, inFence) {
+				inFence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = trimmed[:3]
+			continue
+		}
+
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+			target := m[1]
+			if strings.Contains(target, "://") || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "mailto:") {
+				continue
+			}
+			target, _, _ = strings.Cut(target, "#")
+			if target == "" {
+				continue
+			}
+			checked++
+			if !pathExists(root, dir, target) {
+				broken = append(broken, docReference{File: rel, Line: i + 1, Reference: target, Kind: "file", Reason: "link target does not exist", Suggestions: suggestFiles(root, target)})
+			}
+		}
+		for _, m := range codeSpanPattern.FindAllStringSubmatch(line, -1) {
+			ref := strings.TrimSpace(m[1])
+			switch {
+			case isFileReference(ref):
+				if isExternalPath(ref) || !strings.Contains(ref, "/") {
+					continue
+				}
+				checked++
+				if !pathExists(root, dir, ref) {
+					broken = append(broken, docReference{File: rel, Line: i + 1, Reference: ref, Kind: "file", Reason: "file does not exist", Suggestions: suggestFiles(root, ref)})
+				}
+			case symbolRefPattern.MatchString(ref):
+				kind, reason := index.resolveSymbolReference(root, ref, nil)
+				if kind == "" && reason == "" && !index.judgeable(ref) {
+					continue
+				}
+				checked++
+				if reason != "" {
+					broken = append(broken, docReference{File: rel, Line: i + 1, Reference: ref, Kind: kind, Reason: reason, Suggestions: index.suggest(ref)})
+				}
+			}
+		}
+	}
+	return checked, broken
+}
+
+// judgeable reports whether a qualified reference names a workspace
+// package, so that resolved references are counted as checked.
+func (idx *symbolIndex) judgeable(ref string) bool {
+	ref = strings.TrimPrefix(ref, "*")
+	if slash := strings.LastIndex(ref, "/"); slash >= 0 {
+		if dot := strings.Index(ref[slash+1:], "."); dot >= 0 {
+			return len(idx.lookupPackages(ref[:slash+1+dot])) > 0
+		}
+		return false
+	}
+	first, _, _ := strings.Cut(ref, ".")
+	return len(idx.byName[first]) > 0
+}
+
+func isFileReference(ref string) bool {
+	switch strings.ToLower(path.Ext(ref)) {
+	case ".go", ".md", ".json", ".yaml", ".yml", ".proto", ".sql", ".txt", ".sh", ".mod":
+		return true
+	}
+	return strings.HasSuffix(ref, "/")
+}
+
+func isExternalPath(ref string) bool {
+	if strings.Contains(ref, "://") || strings.HasPrefix(ref, "~") || strings.HasPrefix(ref, "$") || strings.ContainsAny(ref, " *") {
+		return true
+	}
+	if strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") || strings.HasPrefix(ref, "/") {
+		return false
+	}
+	first := strings.SplitN(ref, "/", 2)[0]
+	return strings.Contains(first, ".") && strings.Contains(ref, "/")
+}
+
+func pathExists(root, dir, ref string) bool {
+	ref = filepath.FromSlash(ref)
+	candidates := []string{filepath.Join(dir, ref), filepath.Join(root, ref)}
+	if filepath.IsAbs(ref) {
+		candidates = []string{ref, filepath.Join(root, ref)}
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestFiles returns workspace files sharing the base name of a missing
+// path reference.
+func suggestFiles(root, ref string) []string {
+	base := path.Base(strings.TrimSuffix(ref, "/"))
+	var matches []string
+	_ = walkWorkspaceFiles(root, func(p string) error {
+		if filepath.Base(p) == base {
+			matches = append(matches, relativeSlashPath(root, p))
+		}
+		if len(matches) >= 5 {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	sort.Strings(matches)
+	return matches
+}
+
+// addSymbolSuggestions appends workspace/symbol matches for the last name of
+// each broken symbol reference. Lookup failures are ignored since the local
+// index already produced suggestions.
+func (t *LSPTools) addSymbolSuggestions(ctx context.Context, search func(context.Context, string) ([]protocol.SymbolInformation, error), broken []docReference) {
+	for i := range broken {
+		if broken[i].Kind != "symbol" {
+			continue
+		}
+		name := broken[i].Reference[strings.LastIndexAny(broken[i].Reference, "./")+1:]
+		symbols, err := search(ctx, name)
+		if err != nil {
+			return
+		}
+		for _, symbol := range symbols {
+			if symbol.Name != name && !strings.HasSuffix(symbol.Name, "."+name) {
+				continue
+			}
+			location := fmt.Sprintf("%s:%d", relativeSlashPath(t.workspaceDir, convertURIToPath(symbol.Location.URI)), symbol.Location.Range.Start.Line+1)
+			if !slices.Contains(broken[i].Suggestions, location) {
+				broken[i].Suggestions = append(broken[i].Suggestions, location)
+			}
+		}
+	}
+}
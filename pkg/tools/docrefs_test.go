@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestCheckReferencesInDocs(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/mod\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "pkg/server/config.go", `package server
+
+import "net/http"
+
+// Config configures the server. See [Config.Validate] and [Options].
+// The handler lives in pkg/server/handler.go; see pkg/config.Load and
+// [http.Handler].
+type Config struct {
+	Addr string
+}
+
+// Validate checks [Config.Addr] and [Config.Port].
+func (c Config) Validate() error { return nil }
+`)
+	writeWorkspaceFile(t, workspace, "pkg/settings/settings.go", "package settings\n\n// Options moved here.\ntype Options struct{}\n")
+	readme := "# Demo\n\nUse `server.Config` and `server.Listener`.\n" +
+		"See [usage](docs/usage.md) and [config](pkg/server/config.go#L3).\n\n```go\nserver.Missing()\n```\n\n`mcp.NewTool` is external.\n"
+	writeWorkspaceFile(t, workspace, "README.md", readme)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	index := buildSymbolIndex(ws)
+
+	var broken []docReference
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			_, refs := checkCommentReferences(workspace, index, pkg, file)
+			broken = append(broken, refs...)
+		}
+	}
+	_, refs := checkMarkdownReferences(workspace, index, "README.md", readme)
+	broken = append(broken, refs...)
+
+	got := make(map[string]docReference)
+	for _, ref := range broken {
+		got[ref.Reference] = ref
+	}
+	expected := map[string]string{
+		"Options":               "symbol",
+		"pkg/server/handler.go": "file",
+		"pkg/config.Load":       "package",
+		"Config.Port":           "symbol",
+		"server.Listener":       "symbol",
+		"docs/usage.md":         "file",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected broken references %#v", broken)
+	}
+	for ref, kind := range expected {
+		if got[ref].Kind != kind {
+			t.Fatalf("reference %s: expected kind %s, got %#v", ref, kind, got[ref])
+		}
+	}
+	if got["Options"].Line != 5 || got["pkg/config.Load"].Line != 6 || got["server.Listener"].Line != 3 {
+		t.Fatalf("unexpected lines %#v", broken)
+	}
+	if len(got["Options"].Suggestions) != 1 || got["Options"].Suggestions[0] != "pkg/settings.Options" {
+		t.Fatalf("unexpected suggestions %#v", got["Options"])
+	}
+
+	tools := NewLSPTools(nil, workspace)
+	tools.addSymbolSuggestions(context.Background(), func(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
+		if query != "Options" {
+			return nil, nil
+		}
+		return []protocol.SymbolInformation{{
+			Name:     "Options",
+			Location: protocol.Location{URI: convertPathToURI(workspace + "/pkg/settings/settings.go"), Range: protocol.Range{Start: protocol.Position{Line: 3}}},
+		}}, nil
+	}, broken)
+	for _, ref := range broken {
+		if ref.Reference == "Options" && ref.Suggestions[len(ref.Suggestions)-1] != "pkg/settings/settings.go:4" {
+			t.Fatalf("expected workspace symbol suggestion, got %#v", ref.Suggestions)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"html"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+const (
+	docsFormatMarkdown = "markdown"
+	docsFormatHTML     = "html"
+)
+
+type exportedPackageDoc struct {
+	ImportPath string `json:"import_path"`
+	Name       string `json:"name"`
+	Synopsis   string `json:"synopsis,omitempty"`
+	File       string `json:"file"`
+}
+
+func (t *LSPTools) registerDocsTools(s *server.MCPServer) {
+	t.registerExportDocs(s)
+	t.registerVerifyDocSnippets(s)
+	t.registerCheckReferencesInDocs(s)
+	t.registerPackageAPI(s)
+}
+
+func (t *LSPTools) registerExportDocs(s *server.MCPServer) {
+	tool := mcp.NewTool("export_docs",
+		mcp.WithDescription("Render package documentation for every workspace package to Markdown or HTML files under an output directory and return the index as a resource"),
+		mcp.WithTitleAnnotation("Export Docs"),
+		mcp.WithString("output_dir", mcp.Description("Output directory inside the workspace, relative to it unless absolute. Defaults to docs/api")),
+		mcp.WithString("format", mcp.Description("Output format"), mcp.Enum(docsFormatMarkdown, docsFormatHTML)),
+		mcp.WithBoolean("unexported", mcp.Description("Include unexported identifiers")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		token := getProgressToken(request.Params.Meta)
+
+		format := getOptionalStringArg(args, "format")
+		if format == "" {
+			format = docsFormatMarkdown
+		}
+		if format != docsFormatMarkdown && format != docsFormatHTML {
+			return mcp.NewToolResultError(fmt.Sprintf("unsupported format %q", format)), nil
+		}
+		outDir := getOptionalStringArg(args, "output_dir")
+		if outDir == "" {
+			outDir = filepath.Join("docs", "api")
+		}
+		if !filepath.IsAbs(outDir) {
+			outDir = filepath.Join(t.workspaceDir, outDir)
+		}
+		outDir = filepath.Clean(outDir)
+		if !isWithinDir(t.workspaceDir, outDir) {
+			return mcp.NewToolResultError(fmt.Sprintf("output_dir %s is outside the workspace", outDir)), nil
+		}
+
+		sendProgressNotification(ctx, s, token, "Parsing workspace packages")
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var mode doc.Mode
+		if getOptionalBoolArg(args, "unexported") {
+			mode = doc.AllDecls
+		}
+		ext := ".md"
+		if format == docsFormatHTML {
+			ext = ".html"
+		}
+
+		var packages []exportedPackageDoc
+		var changes []fileChange
+		for _, pkg := range ws.Packages {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			files := make([]*ast.File, 0, len(pkg.Files))
+			for _, file := range pkg.Files {
+				files = append(files, file.Syntax)
+			}
+			docPkg, err := doc.NewFromFiles(ws.Fset, files, pkg.ImportPath, mode)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("document %s: %v", pkg.ImportPath, err)), nil
+			}
+
+			rel := path.Join(pkg.RelDir, "package"+ext)
+			var content string
+			if format == docsFormatHTML {
+				content = renderPackageHTML(ws.Fset, docPkg)
+			} else {
+				content = renderPackageMarkdown(ws.Fset, docPkg)
+			}
+			change, err := contentFileChange(filepath.Join(outDir, filepath.FromSlash(rel)), content)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if change != nil {
+				changes = append(changes, *change)
+			}
+			packages = append(packages, exportedPackageDoc{
+				ImportPath: pkg.ImportPath,
+				Name:       docPkg.Name,
+				Synopsis:   docPkg.Synopsis(docPkg.Doc),
+				File:       rel,
+			})
+		}
+
+		index := renderDocsIndex(ws.ModulePath, packages, format)
+		indexPath := filepath.Join(outDir, "index"+ext)
+		change, err := contentFileChange(filepath.Join(outDir, "index"+ext), index)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+		if err := t.writeFileChanges(ctx, changes); err != nil {
+			return writeFailureResult("write documentation", err), nil
+		}
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Wrote documentation for %d packages", len(packages)))
+
+		payload := map[string]any{
+			"output_dir":   outDir,
+			"format":       format,
+			"index":        indexPath,
+			"packages":     packages,
+			"parse_errors": ws.ParseErrors,
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		mimeType := "text/markdown"
+		if format == docsFormatHTML {
+			mimeType = "text/html"
+		}
+		result.Content = append(result.Content, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+			URI:      convertPathToURI(indexPath),
+			MIMEType: mimeType,
+			Text:     index,
+		}))
+		return result, nil
+	})
+}
+
+// docSection is a heading or a documented declaration ready to be rendered.
+type docSection struct {
+	level   int
+	title   string
+	decl    string
+	comment string
+}
+
+// packageDocSections flattens a doc.Package into the pkgsite ordering:
+// constants, variables, functions, then types with their associated
+// constants, variables, constructors and methods.
+func packageDocSections(fset *token.FileSet, pkg *doc.Package) []docSection {
+	var sections []docSection
+	values := func(level int, title string, list []*doc.Value) {
+		if len(list) == 0 {
+			return
+		}
+		sections = append(sections, docSection{level: level, title: title})
+		for _, v := range list {
+			sections = append(sections, docSection{decl: printDecl(fset, v.Decl), comment: v.Doc})
+		}
+	}
+	funcs := func(level int, list []*doc.Func) {
+		for _, fn := range list {
+			title := "func " + fn.Name
+			if fn.Recv != "" {
+				title = fmt.Sprintf("func (%s) %s", fn.Recv, fn.Name)
+			}
+			sections = append(sections, docSection{level: level, title: title, decl: printDecl(fset, fn.Decl), comment: fn.Doc})
+		}
+	}
+
+	values(2, "Constants", pkg.Consts)
+	values(2, "Variables", pkg.Vars)
+	if len(pkg.Funcs) > 0 {
+		sections = append(sections, docSection{level: 2, title: "Functions"})
+		funcs(3, pkg.Funcs)
+	}
+	if len(pkg.Types) > 0 {
+		sections = append(sections, docSection{level: 2, title: "Types"})
+	}
+	for _, typ := range pkg.Types {
+		sections = append(sections, docSection{level: 3, title: "type " + typ.Name, decl: printDecl(fset, typ.Decl), comment: typ.Doc})
+		for _, list := range [][]*doc.Value{typ.Consts, typ.Vars} {
+			for _, v := range list {
+				sections = append(sections, docSection{decl: printDecl(fset, v.Decl), comment: v.Doc})
+			}
+		}
+		funcs(4, typ.Funcs)
+		funcs(4, typ.Methods)
+	}
+	return sections
+}
+
+func printDecl(fset *token.FileSet, decl ast.Decl) string {
+	if fn, ok := decl.(*ast.FuncDecl); ok {
+		clone := *fn
+		clone.Body = nil
+		clone.Doc = nil
+		decl = &clone
+	}
+	if gen, ok := decl.(*ast.GenDecl); ok {
+		clone := *gen
+		clone.Doc = nil
+		decl = &clone
+	}
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, fset, decl); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func renderPackageMarkdown(fset *token.FileSet, pkg *doc.Package) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# package %s\n\n", pkg.Name)
+	fmt.Fprintf(&b, "```go\nimport %q\n```\n\n", pkg.ImportPath)
+	if text := pkg.Markdown(pkg.Doc); len(text) > 0 {
+		b.Write(text)
+		b.WriteString("\n")
+	}
+	for _, section := range packageDocSections(fset, pkg) {
+		if section.title != "" {
+			fmt.Fprintf(&b, "%s %s\n\n", strings.Repeat("#", section.level), section.title)
+		}
+		if section.decl != "" {
+			fmt.Fprintf(&b, "```go\n%s\n```\n\n", section.decl)
+		}
+		if text := pkg.Markdown(section.comment); len(text) > 0 {
+			b.Write(text)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func renderPackageHTML(fset *token.FileSet, pkg *doc.Package) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", html.EscapeString(pkg.ImportPath))
+	fmt.Fprintf(&b, "<h1>package %s</h1>\n", html.EscapeString(pkg.Name))
+	fmt.Fprintf(&b, "<pre>import %q</pre>\n", html.EscapeString(pkg.ImportPath))
+	b.Write(pkg.HTML(pkg.Doc))
+	for _, section := range packageDocSections(fset, pkg) {
+		if section.title != "" {
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", section.level, html.EscapeString(section.title), section.level)
+		}
+		if section.decl != "" {
+			fmt.Fprintf(&b, "<pre>%s</pre>\n", html.EscapeString(section.decl))
+		}
+		b.Write(pkg.HTML(section.comment))
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func renderDocsIndex(modulePath string, packages []exportedPackageDoc, format string) string {
+	title := modulePath
+	if title == "" {
+		title = "Workspace packages"
+	}
+	var b strings.Builder
+	if format == docsFormatHTML {
+		fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", html.EscapeString(title))
+		fmt.Fprintf(&b, "<h1>%s</h1>\n<ul>\n", html.EscapeString(title))
+		for _, pkg := range packages {
+			fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a>", html.EscapeString(pkg.File), html.EscapeString(pkg.ImportPath))
+			if pkg.Synopsis != "" {
+				fmt.Fprintf(&b, " — %s", html.EscapeString(pkg.Synopsis))
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</ul>\n</body>\n</html>\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	for _, pkg := range packages {
+		fmt.Fprintf(&b, "- [%s](%s)", pkg.ImportPath, pkg.File)
+		if pkg.Synopsis != "" {
+			fmt.Fprintf(&b, " — %s", pkg.Synopsis)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
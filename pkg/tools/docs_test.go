@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"go/doc"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+)
+
+func TestRenderPackageMarkdown(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/mod\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "shapes/shapes.go", `// Package shapes computes areas.
+package shapes
+
+// Pi approximates π.
+const Pi = 3.14
+
+// Circle is a round shape.
+type Circle struct {
+	Radius float64
+	cache  float64
+}
+
+// NewCircle builds a circle.
+func NewCircle(r float64) *Circle { return &Circle{Radius: r} }
+
+// Area returns the area.
+func (c *Circle) Area() float64 { return Pi * c.Radius * c.Radius }
+
+func helper() {}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	pkg := ws.Packages[0]
+	docPkg, err := doc.NewFromFiles(ws.Fset, []*ast.File{pkg.Files[0].Syntax}, pkg.ImportPath)
+	if err != nil {
+		t.Fatalf("NewFromFiles returned error: %v", err)
+	}
+
+	markdown := renderPackageMarkdown(ws.Fset, docPkg)
+	for _, want := range []string{
+		"# package shapes",
+		`import "example.com/mod/shapes"`,
+		"Package shapes computes areas.",
+		"## Constants",
+		"const Pi = 3.14",
+		"### type Circle",
+		"// contains filtered or unexported fields",
+		"#### func NewCircle",
+		"func NewCircle(r float64) *Circle\n```",
+		"#### func (*Circle) Area",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(markdown, "helper") {
+		t.Fatalf("unexported function rendered:\n%s", markdown)
+	}
+
+	index := renderDocsIndex(ws.ModulePath, []exportedPackageDoc{{ImportPath: pkg.ImportPath, Synopsis: "Package shapes computes areas.", File: "shapes/package.md"}}, docsFormatMarkdown)
+	if !strings.Contains(index, "- [example.com/mod/shapes](shapes/package.md) — Package shapes computes areas.") {
+		t.Fatalf("unexpected index:\n%s", index)
+	}
+}
+
+func TestExportDocsWritesThroughEdits(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/mod\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "shapes/shapes.go", "// Package shapes computes areas.\npackage shapes\n")
+	fakeClient := &fakeLSPClient{}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	tools.SetApprovalToken("secret")
+	server := mcpsrv.NewMCPServer("test", "1.0", mcpsrv.WithResourceCapabilities(true, true))
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("export_docs").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "export_docs", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("export_docs: %v", err)
+		}
+		return result
+	}
+
+	outside := t.TempDir()
+	if result := call(map[string]any{"output_dir": outside}); !result.IsError {
+		t.Fatalf("expected an output_dir outside the workspace to be refused, got %v", result)
+	}
+	if result := call(map[string]any{"output_dir": "../escape"}); !result.IsError {
+		t.Fatalf("expected a relative output_dir escaping the workspace to be refused, got %v", result)
+	}
+
+	queued := structured(call(map[string]any{}))
+	if queued["status"] != "pending_approval" {
+		t.Fatalf("expected the documentation to be queued, got %v", queued)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "docs", "api")); !os.IsNotExist(err) {
+		t.Fatalf("documentation was written before approval: %v", err)
+	}
+}
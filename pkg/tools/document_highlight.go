@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// highlightKinds names the LSP DocumentHighlightKinds, indexed by kind.
+var highlightKinds = []string{1: "text", 2: "read", 3: "write"}
+
+// occurrence is a highlight with the line it is on. Line is 1-based.
+type occurrence struct {
+	Range protocol.Range `json:"range"`
+	Kind  string         `json:"kind"`
+	Line  int            `json:"line"`
+	Code  string         `json:"code"`
+}
+
+func (t *LSPTools) registerDocumentHighlight(s *server.MCPServer) {
+	tool := mcp.NewTool("document_highlight",
+		mcp.WithDescription("List the occurrences in a file of the symbol at a position, each marked as a read or a write (an assignment, increment or address taken), to find where a variable is mutated before refactoring it. Scope function restricts them to the function enclosing the position"),
+		mcp.WithTitleAnnotation("Document Highlight"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position of the symbol"),
+		),
+		mcp.WithString("scope",
+			mcp.Description("file (default) or function"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return nil, err
+		}
+		scope := getOptionalStringArg(args, "scope")
+		if scope == "" {
+			scope = "file"
+		}
+		if scope != "file" && scope != "function" {
+			return mcp.NewToolResultError("scope must be file or function"), nil
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		path := convertURIToPath(fileURI)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", path, err)), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		highlights, err := lspClient.DocumentHighlights(ctx, fileURI, line, character)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		slices.SortFunc(highlights, func(a, b protocol.DocumentHighlight) int {
+			return cmp.Or(cmp.Compare(a.Range.Start.Line, b.Range.Start.Line), cmp.Compare(a.Range.Start.Character, b.Range.Start.Character))
+		})
+
+		payload := map[string]any{"file_uri": fileURI, "scope": scope}
+		if scope == "function" {
+			start, end, name, err := enclosingFunction(path, src, line, character)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			payload["function"] = name
+			highlights = slices.DeleteFunc(highlights, func(h protocol.DocumentHighlight) bool {
+				offset, err := textedit.Offset(src, h.Range.Start.Line, h.Range.Start.Character)
+				return err != nil || offset < start || offset >= end
+			})
+		}
+
+		lines := bytes.Split(src, []byte("\n"))
+		occurrences := make([]occurrence, 0, len(highlights))
+		counts := map[string]int{}
+		for _, h := range highlights {
+			kind := "text"
+			if h.Kind > 0 && h.Kind < len(highlightKinds) {
+				kind = highlightKinds[h.Kind]
+			}
+			counts[kind]++
+			entry := occurrence{Range: h.Range, Kind: kind, Line: h.Range.Start.Line + 1}
+			if h.Range.Start.Line < len(lines) {
+				entry.Code = strings.TrimSpace(string(lines[h.Range.Start.Line]))
+			}
+			occurrences = append(occurrences, entry)
+		}
+		if len(highlights) > 0 {
+			first := highlights[0].Range
+			if start, err := textedit.Offset(src, first.Start.Line, first.Start.Character); err == nil {
+				if end, err := textedit.Offset(src, first.End.Line, first.End.Character); err == nil && end >= start {
+					payload["symbol"] = string(src[start:end])
+				}
+			}
+		}
+		payload["occurrences"] = occurrences
+		payload["reads"] = counts["read"]
+		payload["writes"] = counts["write"]
+		payload["other"] = counts["text"]
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// enclosingFunction returns the byte range and name of the function
+// declaration of src containing the position.
+func enclosingFunction(path string, src []byte, line, character int) (int, int, string, error) {
+	offset, err := textedit.Offset(src, line, character)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if file == nil {
+		return 0, 0, "", fmt.Errorf("parse %s: %w", path, err)
+	}
+	tokFile := fset.File(file.Pos())
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start, end := tokFile.Offset(fn.Pos()), tokFile.Offset(fn.End())
+		if offset < start || offset >= end {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) == 1 {
+			name = receiverTypeName(fn.Recv.List[0].Type) + "." + name
+		}
+		return start, end, name, nil
+	}
+	return 0, 0, "", fmt.Errorf("position %d:%d is not inside a function", line, character)
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// outlineSymbol is a node of a file outline. Line and EndLine are the
+// 1-based lines the declaration spans, for reading just its body; Position
+// is the 0-based position of its name, for tools taking a position.
+type outlineSymbol struct {
+	Name     string            `json:"name"`
+	Kind     string            `json:"kind"`
+	Detail   string            `json:"detail,omitempty"`
+	Line     int               `json:"line"`
+	EndLine  int               `json:"end_line"`
+	Position protocol.Position `json:"position"`
+	Children []outlineSymbol   `json:"children,omitempty"`
+}
+
+type fileOutline struct {
+	Path    string          `json:"path"`
+	FileURI string          `json:"file_uri"`
+	Symbols []outlineSymbol `json:"symbols"`
+}
+
+func (t *LSPTools) registerDocumentSymbols(s *server.MCPServer) {
+	tool := mcp.NewTool("document_symbols",
+		mcp.WithDescription("Return the outline of a Go file (types with their fields, functions, methods, constants and variables, with signatures and line spans) via gopls textDocument/documentSymbol, to skim its structure without reading it. Given a package directory, returns the outline of each of its files"),
+		mcp.WithTitleAnnotation("Document Symbols"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or path of a Go file, or of a package directory"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("How many levels of the outline to return: 1 lists only top-level declarations (default: all)"),
+		),
+		mcp.WithBoolean("include_tests",
+			mcp.Description("For a package directory, also outline its _test.go files (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		depth := 0
+		if _, ok := args["depth"]; ok {
+			if depth, err = getIntFromObject(args, "depth"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if depth < 1 {
+				return mcp.NewToolResultError("depth must be at least 1"), nil
+			}
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		path := convertURIToPath(fileURI)
+		info, err := os.Stat(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", path, err)), nil
+		}
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = packageGoFiles(path, getOptionalBoolArg(args, "include_tests")); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(files) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("no Go files in %s", path)), nil
+			}
+		}
+
+		outlines := make([]fileOutline, 0, len(files))
+		for _, file := range files {
+			uri := convertPathToURI(file)
+			symbols, err := lspClient.DocumentSymbols(ctx, uri)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			outlines = append(outlines, fileOutline{
+				Path:    relativeSlashPath(t.workspaceDir, file),
+				FileURI: uri,
+				Symbols: outlineSymbols(symbols, depth),
+			})
+		}
+
+		var payload map[string]any
+		if info.IsDir() {
+			payload = map[string]any{"directory": relativeSlashPath(t.workspaceDir, path), "files": outlines}
+		} else {
+			payload = map[string]any{"file_uri": fileURI, "path": outlines[0].Path, "symbols": outlines[0].Symbols}
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// outlineSymbols converts a documentSymbol tree, keeping depth levels of it
+// (all of them for depth 0).
+func outlineSymbols(symbols []protocol.DocumentSymbol, depth int) []outlineSymbol {
+	outline := make([]outlineSymbol, 0, len(symbols))
+	for _, symbol := range symbols {
+		node := outlineSymbol{
+			Name:     symbol.Name,
+			Kind:     symbolKindName(symbol.Kind),
+			Detail:   symbol.Detail,
+			Line:     symbol.Range.Start.Line + 1,
+			EndLine:  symbol.Range.End.Line + 1,
+			Position: symbol.SelectionRange.Start,
+		}
+		if depth != 1 && len(symbol.Children) > 0 {
+			node.Children = outlineSymbols(symbol.Children, max(depth-1, 0))
+		}
+		outline = append(outline, node)
+	}
+	return outline
+}
+
+// packageGoFiles lists the Go files of a package directory in name order.
+func packageGoFiles(dir string, includeTests bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", dir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || (!includeTests && strings.HasSuffix(name, "_test.go")) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	slices.Sort(files)
+	return files, nil
+}
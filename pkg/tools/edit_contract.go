@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/mod/modfile"
+)
+
+// maxContractViolations bounds the violations an edit contract keeps.
+const maxContractViolations = 100
+
+// editContract is the scope an agent declared for its edits: the files
+// writeFileChanges may change for the rest of the session.
+type editContract struct {
+	Packages   []string         `json:"packages,omitempty"`
+	Files      []string         `json:"files,omitempty"`
+	Reason     string           `json:"reason,omitempty"`
+	Declared   time.Time        `json:"declared"`
+	Violations []scopeViolation `json:"violations"`
+	Refused    int              `json:"refused_writes"`
+	dirs       []contractPackage
+}
+
+// contractPackage is a package directory of a contract, relative to the
+// workspace in slash form; recursive for patterns ending in /....
+type contractPackage struct {
+	dir       string
+	recursive bool
+}
+
+// scopeViolation is a file a tool tried to change outside the contract.
+type scopeViolation struct {
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+}
+
+// editScopeError is returned by writeFileChanges for changes reaching
+// outside the edit contract; nothing is written then.
+type editScopeError struct {
+	Violations []string
+	contract   editContract
+}
+
+func (e *editScopeError) Error() string {
+	return fmt.Sprintf("the edit contract does not cover %s; nothing was written", strings.Join(e.Violations, ", "))
+}
+
+func (t *LSPTools) registerEditContract(s *server.MCPServer) {
+	tool := mcp.NewTool("edit_contract",
+		mcp.WithDescription("Declare the packages and files this session may edit, so that every tool writing files refuses changes reaching outside them, with an edit_scope_violation error listing the files, instead of writing anything: a guardrail for autonomous runs. Commands that change files themselves (run_build_target, and gopls_command and code_lens commands such as gopls.generate) only run under a contract covering ./.... show returns the contract with the refused changes so far, clear removes it and needs the approval token only the user has, so the agent cannot lift its own guardrail; without --require-approval the contract lasts for the session. A contract cannot be replaced without clearing it first"),
+		mcp.WithTitleAnnotation("Edit Contract"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("action",
+			mcp.Description("show (default), declare or clear"),
+			mcp.Enum("show", "declare", "clear"),
+		),
+		mcp.WithArray("packages",
+			mcp.Description("Packages whose files may be edited, as import paths of the workspace module or directories relative to the workspace such as ./internal/store, with /... for the packages below; new packages may be listed before they exist"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("files",
+			mcp.Description("Other files that may be edited, as paths relative to the workspace or path.Match patterns such as docs/*.md"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("reason",
+			mcp.Description("What the edits are for, kept with the contract"),
+		),
+		mcp.WithString("token",
+			mcp.Description("Approval token the user was given at server startup; required to clear"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		action := getOptionalStringArg(args, "action")
+		t.contractMu.Lock()
+		defer t.contractMu.Unlock()
+		switch action {
+		case "", "show":
+		case "clear":
+			if t.approvals == nil {
+				return mcp.NewToolResultError("clearing the edit contract needs the approval token of a server started with --require-approval; the contract lasts for the session"), nil
+			}
+			if subtle.ConstantTimeCompare([]byte(getOptionalStringArg(args, "token")), []byte(t.approvals.token)) != 1 {
+				return mcp.NewToolResultError("the approval token does not match; only the user can clear the edit contract"), nil
+			}
+			t.contract = nil
+		case "declare":
+			if t.contract != nil {
+				return mcp.NewToolResultError("an edit contract is already declared; clear it first to declare another"), nil
+			}
+			contract, err := t.newEditContract(getOptionalStringListArg(args, "packages"), getOptionalStringListArg(args, "files"))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			contract.Reason = getOptionalStringArg(args, "reason")
+			t.contract = contract
+		default:
+			return mcp.NewToolResultError("action must be show, declare or clear"), nil
+		}
+
+		payload := map[string]any{"declared": t.contract != nil}
+		if t.contract != nil {
+			payload["contract"] = t.contract
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// newEditContract validates the scope of a contract.
+func (t *LSPTools) newEditContract(packages, files []string) (*editContract, error) {
+	if len(packages) == 0 && len(files) == 0 {
+		return nil, errors.New("declare at least one package or file")
+	}
+	modulePath := ""
+	if data, err := os.ReadFile(filepath.Join(t.workspaceDir, "go.mod")); err == nil {
+		modulePath = modfile.ModulePath(data)
+	}
+	contract := &editContract{Packages: packages, Files: files, Declared: time.Now().UTC(), Violations: []scopeViolation{}}
+	for _, pattern := range packages {
+		dir, recursive := strings.CutSuffix(pattern, "/...")
+		if pattern == "./..." || pattern == "..." {
+			dir, recursive = ".", true
+		}
+		switch {
+		case dir == "." || strings.HasPrefix(dir, "./"):
+			dir = path.Clean(dir)
+		case modulePath != "" && dir == modulePath:
+			dir = "."
+		case modulePath != "" && strings.HasPrefix(dir, modulePath+"/"):
+			dir = strings.TrimPrefix(dir, modulePath+"/")
+		case modulePath == "":
+			return nil, fmt.Errorf("package %s is not a directory like ./pkg, and the workspace has no go.mod to resolve import paths", pattern)
+		default:
+			return nil, fmt.Errorf("package %s is neither a directory like ./pkg nor in module %s", pattern, modulePath)
+		}
+		if dir == ".." || strings.HasPrefix(dir, "../") {
+			return nil, fmt.Errorf("package %s is outside the workspace", pattern)
+		}
+		contract.dirs = append(contract.dirs, contractPackage{dir: dir, recursive: recursive})
+	}
+	for _, pattern := range files {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("file pattern %s: %v", pattern, err)
+		}
+	}
+	return contract, nil
+}
+
+// covers reports whether the contract lets rel, a workspace-relative
+// slash path, be edited.
+func (c *editContract) covers(rel string) bool {
+	if rel == ".." || strings.HasPrefix(rel, "../") || filepath.IsAbs(rel) {
+		return false
+	}
+	for _, pattern := range c.Files {
+		if matched, _ := path.Match(path.Clean(pattern), rel); matched {
+			return true
+		}
+	}
+	dir := path.Dir(rel)
+	return slices.ContainsFunc(c.dirs, func(pkg contractPackage) bool {
+		return dir == pkg.dir || pkg.recursive && (pkg.dir == "." || strings.HasPrefix(dir, pkg.dir+"/"))
+	})
+}
+
+// checkCommandContract refuses to run command, which changes files itself
+// rather than through writeFileChanges, unless no contract is declared or
+// the contract covers the whole workspace: the files such a command writes
+// are only known once it ran.
+func (t *LSPTools) checkCommandContract(command string) error {
+	t.contractMu.Lock()
+	defer t.contractMu.Unlock()
+	if t.contract == nil || slices.Contains(t.contract.dirs, contractPackage{dir: ".", recursive: true}) {
+		return nil
+	}
+	t.contract.Refused++
+	return fmt.Errorf("%s changes files itself, which the edit contract cannot check; it only runs under a contract covering ./...", command)
+}
+
+// checkEditContract returns an *editScopeError when changes reach outside
+// the declared contract, recording the violations.
+func (t *LSPTools) checkEditContract(changes []fileChange) error {
+	t.contractMu.Lock()
+	defer t.contractMu.Unlock()
+	if t.contract == nil {
+		return nil
+	}
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	var outside []string
+	for _, change := range changes {
+		if rel := relativeSlashPath(root, change.path); !t.contract.covers(rel) {
+			outside = append(outside, rel)
+		}
+	}
+	if len(outside) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	for _, rel := range outside {
+		t.contract.Violations = append(t.contract.Violations, scopeViolation{Path: rel, Time: now})
+	}
+	if excess := len(t.contract.Violations) - maxContractViolations; excess > 0 {
+		t.contract.Violations = slices.Delete(t.contract.Violations, 0, excess)
+	}
+	t.contract.Refused++
+	return &editScopeError{Violations: outside, contract: *t.contract}
+}
+
+// writeFailureResult reports a writeFileChanges failure of action. Edits
+// outside the edit contract are reported as an edit_scope_violation with
+// the files and the contract as structured content, and edits queued for
+// approval, which are no failure, with the pending change.
+func writeFailureResult(action string, err error) *mcp.CallToolResult {
+	message := fmt.Sprintf("%s: %v", action, err)
+	var pendingErr *pendingApprovalError
+	if errors.As(err, &pendingErr) {
+		return mcp.NewToolResultStructured(map[string]any{
+			"status":  "pending_approval",
+			"id":      pendingErr.change.ID,
+			"files":   pendingErr.change.Files,
+			"message": pendingErr.Error() + "; the user approves it with approve_pending_change",
+		}, pendingErr.Error())
+	}
+	var scopeErr *editScopeError
+	if !errors.As(err, &scopeErr) {
+		return mcp.NewToolResultError(message)
+	}
+	result := mcp.NewToolResultStructured(map[string]any{
+		"error":      "edit_scope_violation",
+		"message":    message,
+		"violations": scopeErr.Violations,
+		"packages":   scopeErr.contract.Packages,
+		"files":      scopeErr.contract.Files,
+	}, message)
+	result.IsError = true
+	return result
+}
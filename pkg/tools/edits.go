@@ -0,0 +1,319 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// fileChange is a rewrite of one workspace file, computed from the content
+// the tool read. created and deleted mark changes that add or remove the
+// file instead of rewriting it.
+type fileChange struct {
+	path    string
+	before  []byte
+	after   []byte
+	created bool
+	deleted bool
+}
+
+// fileChangeSummary is the client-facing description of a fileChange.
+type fileChangeSummary struct {
+	Path string `json:"path"`
+	Diff string `json:"diff"`
+}
+
+func newFileChange(path string, before []byte, edits []textedit.Edit) (fileChange, error) {
+	after, err := textedit.Apply(before, edits)
+	if err != nil {
+		return fileChange{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return fileChange{path: path, before: before, after: after}, nil
+}
+
+// contentFileChange returns the change writing content to path, creating
+// the file when it does not exist, or nil when the file already holds it.
+func contentFileChange(path, content string) (*fileChange, error) {
+	before, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &fileChange{path: path, after: []byte(content), created: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if string(before) == content {
+		return nil, nil
+	}
+	return &fileChange{path: path, before: before, after: []byte(content)}, nil
+}
+
+// workspaceEditChanges turns an LSP workspace edit into file changes
+// against the current content of the files on disk.
+func workspaceEditChanges(edit *protocol.WorkspaceEdit) ([]fileChange, error) {
+	if edit == nil {
+		return nil, nil
+	}
+	byURI := make(map[string][]protocol.TextEdit)
+	created := make(map[string]bool)
+	for uri, edits := range edit.Changes {
+		byURI[uri] = append(byURI[uri], edits...)
+	}
+	for _, docEdit := range edit.DocumentChanges {
+		// File operations decode without a text document.
+		if docEdit.TextDocument.URI == "" {
+			if docEdit.Kind != "create" {
+				return nil, fmt.Errorf("the edit renames or deletes files, which is not supported")
+			}
+			created[docEdit.URI] = true
+			if _, ok := byURI[docEdit.URI]; !ok {
+				byURI[docEdit.URI] = nil
+			}
+			continue
+		}
+		byURI[docEdit.TextDocument.URI] = append(byURI[docEdit.TextDocument.URI], docEdit.Edits...)
+	}
+	uris := make([]string, 0, len(byURI))
+	for uri := range byURI {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	changes := make([]fileChange, 0, len(uris))
+	for _, uri := range uris {
+		path := convertURIToPath(uri)
+		before, err := os.ReadFile(path)
+		if created[uri] && errors.Is(err, fs.ErrNotExist) {
+			before, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		change, err := textEditsChange(path, before, byURI[uri])
+		if err != nil {
+			return nil, err
+		}
+		change.created = created[uri] && before == nil
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// textEditsChange applies LSP text edits to the content of path.
+func textEditsChange(path string, before []byte, lspEdits []protocol.TextEdit) (fileChange, error) {
+	edits := make([]textedit.Edit, 0, len(lspEdits))
+	for _, lspEdit := range lspEdits {
+		start, err := textedit.Offset(before, lspEdit.Range.Start.Line, lspEdit.Range.Start.Character)
+		if err != nil {
+			return fileChange{}, fmt.Errorf("%s: %w", path, err)
+		}
+		end, err := textedit.Offset(before, lspEdit.Range.End.Line, lspEdit.Range.End.Character)
+		if err != nil {
+			return fileChange{}, fmt.Errorf("%s: %w", path, err)
+		}
+		edits = append(edits, textedit.Edit{Start: start, End: end, New: lspEdit.NewText})
+	}
+	return newFileChange(path, before, edits)
+}
+
+func (t *LSPTools) summarizeFileChanges(changes []fileChange) []fileChangeSummary {
+	summaries := make([]fileChangeSummary, 0, len(changes))
+	for _, change := range changes {
+		rel := relativeSlashPath(t.workspaceDir, change.path)
+		summaries = append(summaries, fileChangeSummary{
+			Path: rel,
+			Diff: textedit.Unified(rel, change.before, change.after),
+		})
+	}
+	return summaries
+}
+
+// writeFileChanges is the single path through which tools modify workspace
+// files. Every file is checked against the edit contract, against the
+// checkout in isolated edits mode, and against the content the change was
+// computed from before anything is written. While edits need approval the
+// changes are then queued instead; otherwise files are replaced atomically,
+// and gopls is told about the new content. No changes is a no-op, never an
+// empty pending change.
+func (t *LSPTools) writeFileChanges(ctx context.Context, changes []fileChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := t.checkEditContract(changes); err != nil {
+		return err
+	}
+	if err := t.checkIsolatedEdits(changes); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if change.created {
+			if _, err := os.Stat(change.path); !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("%s already exists; re-run the tool", relativeSlashPath(t.workspaceDir, change.path))
+			}
+			continue
+		}
+		current, err := os.ReadFile(change.path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, change.before) {
+			return fmt.Errorf("%s was modified since it was read; re-run the tool", relativeSlashPath(t.workspaceDir, change.path))
+		}
+	}
+	if err := t.queueForApproval(ctx, changes); err != nil {
+		return err
+	}
+
+	if journal, ok := ctx.Value(editJournalKey{}).(*editJournal); ok {
+		journal.record(changes)
+	}
+	t.history.edits.record(changes)
+	events := make([]protocol.FileEvent, 0, len(changes))
+	for _, change := range changes {
+		uri := convertPathToURI(change.path)
+		switch {
+		case change.deleted:
+			if err := os.Remove(change.path); err != nil {
+				return err
+			}
+			events = append(events, protocol.FileEvent{URI: uri, Type: protocol.FileDeleted})
+		case change.created:
+			if err := os.MkdirAll(filepath.Dir(change.path), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(change.path, change.after, 0o644); err != nil {
+				return err
+			}
+			events = append(events, protocol.FileEvent{URI: uri, Type: protocol.FileCreated})
+		case !bytes.Equal(change.before, change.after):
+			if err := writeFileAtomic(change.path, change.after); err != nil {
+				return err
+			}
+			events = append(events, protocol.FileEvent{URI: uri, Type: protocol.FileChanged})
+		}
+		t.touched.record(change.path, "edited", change.after, change.deleted)
+		t.history.recordFile(relativeSlashPath(t.workspaceDir, change.path), "edited", "")
+	}
+
+	if lspClient := t.getClient(); lspClient != nil && len(events) > 0 {
+		// The files are already written; a failed notification only delays
+		// gopls noticing them through its own file watching.
+		_ = lspClient.NotifyDidChangeWatchedFiles(ctx, events)
+	}
+	return nil
+}
+
+type editJournalKey struct{}
+
+// editJournal records the files written through writeFileChanges under a
+// context, with the content each had before the first write, so that the
+// writes can be reported as one change or undone.
+type editJournal struct {
+	mu    sync.Mutex
+	paths []string
+	// original is nil for files the writes created.
+	original map[string][]byte
+}
+
+func newEditJournal() *editJournal {
+	return &editJournal{original: make(map[string][]byte)}
+}
+
+// withEditJournal returns a context whose tool calls record their writes
+// in journal.
+func withEditJournal(ctx context.Context, journal *editJournal) context.Context {
+	return context.WithValue(ctx, editJournalKey{}, journal)
+}
+
+func (j *editJournal) record(changes []fileChange) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, change := range changes {
+		j.keep(change.path, change.before, change.created)
+	}
+}
+
+func (j *editJournal) keep(path string, original []byte, created bool) {
+	if _, ok := j.original[path]; ok {
+		return
+	}
+	if created {
+		original = nil
+	} else if original == nil {
+		original = []byte{}
+	}
+	j.paths = append(j.paths, path)
+	j.original[path] = original
+}
+
+// absorb adds the files of other that j does not have yet.
+func (j *editJournal) absorb(other *editJournal) {
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, path := range other.paths {
+		original := other.original[path]
+		j.keep(path, original, original == nil)
+	}
+}
+
+// changes returns the changes from the recorded content to the files on
+// disk, leaving out those that ended up unchanged. With undo, they go the
+// other way, restoring the recorded content.
+func (j *editJournal) changes(undo bool) ([]fileChange, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var changes []fileChange
+	for _, path := range j.paths {
+		original := j.original[path]
+		current, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			current, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if (original == nil) == (current == nil) && bytes.Equal(original, current) {
+			continue
+		}
+		change := fileChange{path: path, before: original, after: current, created: original == nil, deleted: current == nil}
+		if undo {
+			change = fileChange{path: path, before: current, after: original, created: current == nil, deleted: original == nil}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// writeFileAtomic replaces path with data, keeping its permissions.
+func writeFileAtomic(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,410 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// errorFlowDepth bounds how many caller hops the propagation graph follows
+// from the functions producing the error.
+const errorFlowDepth = 3
+
+const (
+	errorSiteDeclared  = "declared"
+	errorSiteCreated   = "created"
+	errorSiteReturned  = "returned"
+	errorSiteWrapped   = "wrapped"
+	errorSiteJoined    = "joined"
+	errorSiteFormatted = "formatted_without_w"
+	errorSiteChecked   = "checked"
+	errorSiteCompared  = "compared"
+	errorSiteAsserted  = "asserted"
+)
+
+type errorTarget struct {
+	pkgPath string
+	name    string
+	isType  bool
+}
+
+type errorFlowSite struct {
+	Kind     string            `json:"kind"`
+	Function string            `json:"function,omitempty"`
+	Package  string            `json:"package"`
+	Expr     string            `json:"expr"`
+	Location protocol.Location `json:"location"`
+}
+
+type errorFlowNode struct {
+	Function string            `json:"function"`
+	Roles    []string          `json:"roles"`
+	Location protocol.Location `json:"location"`
+}
+
+type errorFlowEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (t *LSPTools) registerErrorFlow(s *server.MCPServer) {
+	tool := mcp.NewTool("error_flow",
+		mcp.WithDescription("For a sentinel error variable or error type, find where it is declared, created, returned, wrapped with %w and checked with errors.Is/As, ==, or type assertions, plus the caller graph it propagates through"),
+		mcp.WithTitleAnnotation("Error Flow"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("error",
+			mcp.Required(),
+			mcp.Description("Sentinel variable or error type, optionally qualified by package name or import path (ErrNotFound, store.ErrNotFound, *store.NotFoundError)"),
+		),
+		mcp.WithBoolean("include_tests", mcp.Description("Include _test.go files (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		name, err := getStringArg(args, "error")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: getOptionalBoolArg(args, "include_tests")})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		target, err := findErrorTarget(ws, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		sites, nodes, edges := traceErrorFlow(ws, target)
+		kind := "sentinel"
+		if target.isType {
+			kind = "type"
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"error":        target.pkgPath + "." + target.name,
+			"kind":         kind,
+			"sites":        sites,
+			"nodes":        nodes,
+			"edges":        edges,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// findErrorTarget resolves a possibly qualified name to a single
+// package-level variable or type declaration.
+func findErrorTarget(ws *gosrc.Workspace, raw string) (errorTarget, error) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "*")
+	qualifier, name := "", raw
+	if dot := strings.LastIndex(raw, "."); dot >= 0 {
+		qualifier, name = raw[:dot], raw[dot+1:]
+	}
+	var matches []errorTarget
+	for _, pkg := range ws.Packages {
+		if qualifier != "" && qualifier != pkg.ImportPath && qualifier != pkg.Name {
+			continue
+		}
+		for _, file := range pkg.Files {
+			for _, decl := range file.Syntax.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					switch sp := spec.(type) {
+					case *ast.TypeSpec:
+						if sp.Name.Name == name {
+							matches = append(matches, errorTarget{pkgPath: pkg.ImportPath, name: name, isType: true})
+						}
+					case *ast.ValueSpec:
+						for _, ident := range sp.Names {
+							if ident.Name == name && gen.Tok == token.VAR {
+								matches = append(matches, errorTarget{pkgPath: pkg.ImportPath, name: name})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return errorTarget{}, fmt.Errorf("no package-level error variable or type named %s", raw)
+	case 1:
+		return matches[0], nil
+	}
+	var candidates []string
+	for _, m := range matches {
+		candidates = append(candidates, m.pkgPath+"."+m.name)
+	}
+	return errorTarget{}, fmt.Errorf("%s is ambiguous (%s); qualify it with the package", raw, strings.Join(candidates, ", "))
+}
+
+// refersTo reports whether expr names the target from a file of pkgPath.
+// Pointer types are unwrapped so *T matches T.
+func (target errorTarget) refersTo(pkgPath string, imports map[string]string, expr ast.Expr) bool {
+	expr = unwrapParens(expr)
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return pkgPath == target.pkgPath && e.Name == target.name
+	case *ast.SelectorExpr:
+		ident, ok := e.X.(*ast.Ident)
+		return ok && imports[ident.Name] == target.pkgPath && e.Sel.Name == target.name
+	}
+	return false
+}
+
+// produces reports whether expr evaluates to the target error: the sentinel
+// itself, or a value of the target type built in place.
+func (target errorTarget) produces(pkgPath string, imports map[string]string, expr ast.Expr) bool {
+	expr = unwrapParens(expr)
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	if lit, ok := expr.(*ast.CompositeLit); ok {
+		return target.isType && target.refersTo(pkgPath, imports, lit.Type)
+	}
+	return !target.isType && target.refersTo(pkgPath, imports, expr)
+}
+
+func traceErrorFlow(ws *gosrc.Workspace, target errorTarget) ([]errorFlowSite, []errorFlowNode, []errorFlowEdge) {
+	sites := []errorFlowSite{}
+	roles := make(map[string][]string)
+	declared := make(map[string]protocol.Location)
+	// callers maps each function to the functions calling it, resolved
+	// through the declaration index.
+	callers := make(map[string][]string)
+	byLocation := make(map[protocol.Location]string)
+	decls := buildDeclIndex(ws)
+
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Syntax.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					key := pkg.ImportPath + "." + funcDeclName(fn)
+					location := sourceLocation(file, fn.Name.Pos(), fn.Name.End())
+					declared[key] = location
+					byLocation[location] = key
+				}
+			}
+		}
+	}
+
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			for _, decl := range file.Syntax.Decls {
+				function, key := "", ""
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					function = funcDeclName(fn)
+					key = pkg.ImportPath + "." + function
+				}
+				add := func(kind string, expr ast.Expr) {
+					sites = append(sites, errorFlowSite{
+						Kind:     kind,
+						Function: function,
+						Package:  pkg.ImportPath,
+						Expr:     exprText(expr),
+						Location: sourceLocation(file, expr.Pos(), expr.End()),
+					})
+					if key != "" && !slices.Contains(roles[key], kind) {
+						roles[key] = append(roles[key], kind)
+					}
+				}
+				// localTypes records declared variable types for errors.As
+				// targets (var nf *NotFoundError).
+				localTypes := make(map[string]ast.Expr)
+				ast.Inspect(decl, func(n ast.Node) bool {
+					switch node := n.(type) {
+					case *ast.GenDecl:
+						if key != "" {
+							return true
+						}
+						for _, spec := range node.Specs {
+							switch sp := spec.(type) {
+							case *ast.TypeSpec:
+								if target.isType && pkg.ImportPath == target.pkgPath && sp.Name.Name == target.name {
+									add(errorSiteDeclared, sp.Name)
+								}
+							case *ast.ValueSpec:
+								for i, ident := range sp.Names {
+									if !target.isType && pkg.ImportPath == target.pkgPath && ident.Name == target.name {
+										var expr ast.Expr = ident
+										if i < len(sp.Values) {
+											expr = sp.Values[i]
+										}
+										add(errorSiteDeclared, expr)
+									}
+								}
+							}
+						}
+					case *ast.ValueSpec:
+						if node.Type != nil {
+							for _, ident := range node.Names {
+								localTypes[ident.Name] = node.Type
+							}
+						}
+					case *ast.CompositeLit:
+						if target.isType && target.refersTo(pkg.ImportPath, imports, node.Type) {
+							add(errorSiteCreated, node)
+						}
+					case *ast.ReturnStmt:
+						for _, result := range node.Results {
+							if target.produces(pkg.ImportPath, imports, result) {
+								add(errorSiteReturned, result)
+							}
+						}
+					case *ast.BinaryExpr:
+						if (node.Op == token.EQL || node.Op == token.NEQ) && (target.produces(pkg.ImportPath, imports, node.X) || target.produces(pkg.ImportPath, imports, node.Y)) {
+							add(errorSiteCompared, node)
+						}
+					case *ast.TypeAssertExpr:
+						if node.Type != nil && target.refersTo(pkg.ImportPath, imports, node.Type) {
+							add(errorSiteAsserted, node)
+						}
+					case *ast.TypeSwitchStmt:
+						for _, stmt := range node.Body.List {
+							for _, expr := range stmt.(*ast.CaseClause).List {
+								if target.refersTo(pkg.ImportPath, imports, expr) {
+									add(errorSiteAsserted, expr)
+								}
+							}
+						}
+					case *ast.CallExpr:
+						if key != "" {
+							if loc := decls.resolve(pkg.ImportPath, imports, node.Fun); loc != nil {
+								if callee, ok := byLocation[*loc]; ok && !slices.Contains(callers[callee], key) {
+									callers[callee] = append(callers[callee], key)
+								}
+							}
+						}
+						if kind := errorCallKind(pkg.ImportPath, imports, target, node, localTypes); kind != "" {
+							add(kind, node)
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	// Walk up from the producers, stopping at functions that inspect the
+	// error since they usually handle it rather than pass it on.
+	var edges []errorFlowEdge
+	visited := make(map[string]bool)
+	var frontier []string
+	for _, key := range sortedKeys(roles) {
+		if isErrorProducer(roles[key]) {
+			frontier = append(frontier, key)
+			visited[key] = true
+		}
+	}
+	for depth := 0; depth < errorFlowDepth && len(frontier) > 0; depth++ {
+		var next []string
+		for _, callee := range frontier {
+			if depth > 0 && isErrorConsumer(roles[callee]) {
+				continue
+			}
+			for _, caller := range callers[callee] {
+				edges = append(edges, errorFlowEdge{From: callee, To: caller})
+				if !visited[caller] {
+					visited[caller] = true
+					next = append(next, caller)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	nodes := []errorFlowNode{}
+	for _, key := range sortedKeys(visited) {
+		nodeRoles := roles[key]
+		if len(nodeRoles) == 0 {
+			nodeRoles = []string{"propagates"}
+		}
+		nodes = append(nodes, errorFlowNode{Function: key, Roles: nodeRoles, Location: declared[key]})
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	if edges == nil {
+		edges = []errorFlowEdge{}
+	}
+	return sites, nodes, edges
+}
+
+// errorCallKind classifies calls that wrap, join or inspect the target.
+func errorCallKind(pkgPath string, imports map[string]string, target errorTarget, call *ast.CallExpr, localTypes map[string]ast.Expr) string {
+	importPath, name, ok := packageCall(imports, call)
+	if !ok {
+		return ""
+	}
+	switch {
+	case importPath == "fmt" && name == "Errorf" && len(call.Args) > 1:
+		for _, arg := range call.Args[1:] {
+			if target.produces(pkgPath, imports, arg) {
+				if format, ok := stringValue(call.Args[0]); ok && !strings.Contains(format, "%w") {
+					return errorSiteFormatted
+				}
+				return errorSiteWrapped
+			}
+		}
+	case importPath == "errors" && name == "Join":
+		for _, arg := range call.Args {
+			if target.produces(pkgPath, imports, arg) {
+				return errorSiteJoined
+			}
+		}
+	case importPath == "errors" && name == "Is" && len(call.Args) == 2 && !target.isType:
+		if target.refersTo(pkgPath, imports, call.Args[1]) {
+			return errorSiteChecked
+		}
+	case importPath == "errors" && name == "As" && len(call.Args) == 2 && target.isType:
+		arg := unwrapParens(call.Args[1])
+		if unary, ok := arg.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			if ident, ok := unary.X.(*ast.Ident); ok && localTypes[ident.Name] != nil && target.refersTo(pkgPath, imports, localTypes[ident.Name]) {
+				return errorSiteChecked
+			}
+		}
+	}
+	return ""
+}
+
+func isErrorProducer(roles []string) bool {
+	for _, role := range roles {
+		switch role {
+		case errorSiteCreated, errorSiteReturned, errorSiteWrapped, errorSiteJoined:
+			return true
+		}
+	}
+	return false
+}
+
+func isErrorConsumer(roles []string) bool {
+	for _, role := range roles {
+		switch role {
+		case errorSiteChecked, errorSiteCompared, errorSiteAsserted:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestTraceErrorFlow(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type NotFoundError struct{ Key string }
+
+func (e *NotFoundError) Error() string { return e.Key }
+
+func lookup(key string) error {
+	if key == "" {
+		return ErrNotFound
+	}
+	return &NotFoundError{Key: key}
+}
+
+func Get(key string) error {
+	if err := lookup(key); err != nil {
+		return fmt.Errorf("get %s: %w", key, ErrNotFound)
+	}
+	return fmt.Errorf("get: %v", ErrNotFound)
+}
+`)
+	writeWorkspaceFile(t, workspace, "api/api.go", `package api
+
+import (
+	"errors"
+
+	"example.com/app/store"
+)
+
+func Handle(key string) int {
+	err := store.Get(key)
+	var nf *store.NotFoundError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return 404
+	case errors.As(err, &nf):
+		return 410
+	case err == store.ErrNotFound:
+		return 404
+	}
+	return 200
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := findErrorTarget(ws, "other.ErrNotFound"); err == nil {
+		t.Fatal("expected unknown qualifier to fail")
+	}
+	target, err := findErrorTarget(ws, "store.ErrNotFound")
+	if err != nil {
+		t.Fatalf("find target: %v", err)
+	}
+
+	sites, nodes, edges := traceErrorFlow(ws, target)
+	var kinds []string
+	for _, site := range sites {
+		kinds = append(kinds, site.Function+":"+site.Kind)
+	}
+	want := []string{"Handle:checked", "Handle:compared", ":declared", "lookup:returned", "Get:wrapped", "Get:formatted_without_w"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("unexpected sites:\n got %v\nwant %v", kinds, want)
+	}
+
+	var graph []string
+	for _, edge := range edges {
+		graph = append(graph, edge.From+" -> "+edge.To)
+	}
+	wantGraph := []string{"example.com/app/store.Get -> example.com/app/api.Handle", "example.com/app/store.lookup -> example.com/app/store.Get"}
+	if !reflect.DeepEqual(graph, wantGraph) {
+		t.Fatalf("unexpected edges: %v", graph)
+	}
+	if len(nodes) != 3 || nodes[0].Function != "example.com/app/api.Handle" || !reflect.DeepEqual(nodes[0].Roles, []string{"checked", "compared"}) {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+
+	typeTarget, err := findErrorTarget(ws, "*NotFoundError")
+	if err != nil {
+		t.Fatalf("find type target: %v", err)
+	}
+	sites, _, _ = traceErrorFlow(ws, typeTarget)
+	kinds = nil
+	for _, site := range sites {
+		kinds = append(kinds, site.Function+":"+site.Kind)
+	}
+	want = []string{"Handle:checked", ":declared", "lookup:returned", "lookup:created"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("unexpected type sites:\n got %v\nwant %v", kinds, want)
+	}
+}
@@ -0,0 +1,294 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// extractRefactoring is a gopls extract refactoring exposed as a tool.
+// gopls names what it extracts itself, such as newFunction, and kinds lists
+// the code action kinds that perform it, the -all ones replacing every
+// occurrence of the selection.
+type extractRefactoring struct {
+	tool        string
+	title       string
+	description string
+	what        string
+	kinds       []string
+}
+
+var extractRefactorings = []extractRefactoring{
+	{
+		tool:        "extract_function",
+		title:       "Extract Function",
+		description: "Move the statements within a range into a new function, with the gopls extract function refactoring: free variables become parameters, variables used after the range become results, and the range is replaced by a call",
+		what:        "function",
+		kinds:       []string{"refactor.extract.function"},
+	},
+	{
+		tool:        "extract_method",
+		title:       "Extract Method",
+		description: "Move the statements within a range of a method into a new method of the same receiver, with the gopls extract method refactoring: free variables become parameters, variables used after the range become results, and the range is replaced by a call",
+		what:        "method",
+		kinds:       []string{"refactor.extract.method"},
+	},
+	{
+		tool:        "extract_variable",
+		title:       "Extract Variable",
+		description: "Move the expression within a range into a new local variable, or a constant when it is constant, declared before the statement using it, with the gopls extract variable refactoring. With all_occurrences, every occurrence of the expression in the function is replaced",
+		what:        "variable",
+		kinds:       []string{"refactor.extract.variable", "refactor.extract.variable-all", "refactor.extract.constant", "refactor.extract.constant-all"},
+	},
+}
+
+func (t *LSPTools) registerExtractTools(s *server.MCPServer) {
+	for _, refactoring := range extractRefactorings {
+		t.registerExtract(s, refactoring)
+	}
+}
+
+func (t *LSPTools) registerExtract(s *server.MCPServer, refactoring extractRefactoring) {
+	options := []mcp.ToolOption{
+		mcp.WithDescription(refactoring.description + ". Returns the edit as a unified diff, and writes it when apply is true"),
+		mcp.WithTitleAnnotation(refactoring.title),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("range",
+			mcp.Required(),
+			mcp.Description("Range to extract, 0-based like the other tools: {\"start\": {\"line\": 10, \"character\": 1}, \"end\": {\"line\": 14, \"character\": 0}}; selection_range helps finding exact bounds"),
+		),
+		mcp.WithString("name",
+			mcp.Description(fmt.Sprintf("Name of the new %s instead of the one gopls picks", refactoring.what)),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edit to disk instead of returning a preview diff (default false)"),
+		),
+	}
+	if slices.ContainsFunc(refactoring.kinds, func(kind string) bool { return strings.HasSuffix(kind, "-all") }) {
+		options = append(options, mcp.WithBoolean("all_occurrences",
+			mcp.Description("Replace every occurrence of the expression in the function, not only the selected one (default false)"),
+		))
+	}
+	tool := mcp.NewTool(refactoring.tool, options...)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		rng, err := parseRangeArg(args, "range")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		name := getOptionalStringArg(args, "name")
+		if name != "" && !token.IsIdentifier(name) {
+			return mcp.NewToolResultError(fmt.Sprintf("%q is not a valid Go identifier", name)), nil
+		}
+		all := getOptionalBoolArg(args, "all_occurrences")
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		actions, err := lspClient.CodeActions(ctx, fileURI, rng, refactoring.kinds...)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		var offered []protocol.CodeAction
+		var reasons []string
+		for _, action := range actions {
+			if !slices.Contains(refactoring.kinds, action.Kind) || strings.HasSuffix(action.Kind, "-all") != all {
+				continue
+			}
+			if action.Disabled != nil {
+				reasons = append(reasons, action.Disabled.Reason)
+				continue
+			}
+			offered = append(offered, action)
+		}
+		if len(offered) == 0 {
+			message := fmt.Sprintf("gopls cannot extract a %s from this range", refactoring.what)
+			if all {
+				message += " replacing every occurrence"
+			}
+			if len(reasons) > 0 {
+				message += ": " + strings.Join(reasons, "; ")
+			} else {
+				message += "; select complete statements, or a complete expression for a variable"
+			}
+			return mcp.NewToolResultError(message), nil
+		}
+		action := offered[0]
+
+		edit, problem, err := codeActionEdit(ctx, lspClient, action)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		changes, err := workspaceEditChanges(edit)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compute %s diff: %v", refactoring.tool, err)), nil
+		}
+		if len(changes) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("code action %q produced no edits", action.Title)), nil
+		}
+
+		generated := extractedName(changes, edit, rng)
+		var warnings []string
+		switch {
+		case name == "" || name == generated:
+		case generated == "":
+			warnings = append(warnings, fmt.Sprintf("could not tell which name gopls gave the new %s; rename it with rename_symbol", refactoring.what))
+		default:
+			renameInEdit(edit, generated, name)
+			if changes, err = workspaceEditChanges(edit); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("compute %s diff: %v", refactoring.tool, err)), nil
+			}
+			generated = name
+		}
+
+		apply := getOptionalBoolArg(args, "apply") && len(changes) > 0
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply "+refactoring.tool, err), nil
+			}
+		}
+		payload := map[string]any{
+			"file_uri": fileURI,
+			"title":    action.Title,
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
+		}
+		if generated != "" {
+			payload["name"] = generated
+		}
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// extractedName returns the name gopls gave to what it extracted: the
+// function or method the edit declares, or else the identifier replacing
+// the selected expression. It returns "" when it cannot tell.
+func extractedName(changes []fileChange, edit *protocol.WorkspaceEdit, rng protocol.Range) string {
+	for _, change := range changes {
+		declared := func(src []byte) map[string]bool {
+			names := make(map[string]bool)
+			file, _ := parser.ParseFile(token.NewFileSet(), change.path, src, parser.SkipObjectResolution)
+			if file == nil {
+				return names
+			}
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					names[fn.Name.Name] = true
+				}
+			}
+			return names
+		}
+		before := declared(change.before)
+		var added []string
+		for name := range declared(change.after) {
+			if !before[name] {
+				added = append(added, name)
+			}
+		}
+		if len(added) == 1 {
+			return added[0]
+		}
+	}
+	var found string
+	visit := func(edits []protocol.TextEdit) {
+		for _, textEdit := range edits {
+			text := strings.TrimSpace(textEdit.NewText)
+			if token.IsIdentifier(text) && rangesOverlap(textEdit.Range, rng) {
+				found = text
+			}
+		}
+	}
+	for _, edits := range edit.Changes {
+		visit(edits)
+	}
+	for _, docEdit := range edit.DocumentChanges {
+		visit(docEdit.Edits)
+	}
+	return found
+}
+
+// rangesOverlap reports whether two ranges share a position.
+func rangesOverlap(a, b protocol.Range) bool {
+	before := func(p, q protocol.Position) bool {
+		return p.Line < q.Line || p.Line == q.Line && p.Character < q.Character
+	}
+	return !before(a.End, b.Start) && !before(b.End, a.Start)
+}
+
+// renameInEdit renames the identifier old to name in the text an edit
+// inserts. gopls picks a fresh name for what it extracts, so in that text
+// old only ever refers to it.
+func renameInEdit(edit *protocol.WorkspaceEdit, old, name string) {
+	rename := func(edits []protocol.TextEdit) {
+		for i := range edits {
+			edits[i].NewText = renameIdentifier(edits[i].NewText, old, name)
+		}
+	}
+	for _, edits := range edit.Changes {
+		rename(edits)
+	}
+	for _, docEdit := range edit.DocumentChanges {
+		rename(docEdit.Edits)
+	}
+}
+
+// renameIdentifier replaces the identifier tokens old of src, leaving
+// strings and comments alone.
+func renameIdentifier(src, old, name string) string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var sc scanner.Scanner
+	sc.Init(file, []byte(src), nil, 0)
+	var b strings.Builder
+	last := 0
+	for {
+		pos, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.IDENT && lit == old {
+			offset := file.Offset(pos)
+			b.WriteString(src[last:offset])
+			b.WriteString(name)
+			last = offset + len(old)
+		}
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// defaultFlagFunctions covers the evaluation methods of the common SDKs
+// (LaunchDarkly, OpenFeature, Unleash) when the project does not configure
+// its own.
+var defaultFlagFunctions = func() []projectconfig.FlagFunction {
+	arg := func(i int) *int { return &i }
+	var fns []projectconfig.FlagFunction
+	for _, method := range []string{"BoolVariation", "StringVariation", "IntVariation", "Float64Variation", "JSONVariation"} {
+		fns = append(fns, projectconfig.FlagFunction{Call: "*." + method, NameArg: 0, DefaultArg: arg(2)})
+	}
+	for _, method := range []string{"BooleanValue", "StringValue", "IntValue", "FloatValue", "ObjectValue"} {
+		fns = append(fns, projectconfig.FlagFunction{Call: "*." + method, NameArg: 1, DefaultArg: arg(2)})
+	}
+	fns = append(fns, projectconfig.FlagFunction{Call: "unleash.IsEnabled", NameArg: 0})
+	return fns
+}()
+
+type flagUsage struct {
+	Call           string             `json:"call"`
+	Default        string             `json:"default,omitempty"`
+	Location       protocol.Location  `json:"location"`
+	EnabledBranch  *protocol.Location `json:"enabled_branch,omitempty"`
+	DisabledBranch *protocol.Location `json:"disabled_branch,omitempty"`
+}
+
+type featureFlag struct {
+	Name     string      `json:"name"`
+	Defaults []string    `json:"defaults,omitempty"`
+	Usages   []flagUsage `json:"usages"`
+}
+
+func (t *LSPTools) registerListFeatureFlags(s *server.MCPServer) {
+	tool := mcp.NewTool("list_feature_flags",
+		mcp.WithDescription("List feature flags evaluated through the flag functions configured in .mcp-gopls.json (or common SDK defaults), with default values, guarded branches and locations"),
+		mcp.WithTitleAnnotation("List Feature Flags"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("flag", mcp.Description("Only report this flag")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		only := getOptionalStringArg(args, "flag")
+
+		cfg, err := projectconfig.Load(t.workspaceDir)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		functions := defaultFlagFunctions
+		source := "defaults"
+		if cfg.FeatureFlags != nil && len(cfg.FeatureFlags.Functions) > 0 {
+			functions = cfg.FeatureFlags.Functions
+			source = projectconfig.FileName
+		}
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		flags := []*featureFlag{}
+		for _, flag := range collectFeatureFlags(ws, functions) {
+			if only == "" || flag.Name == only {
+				flags = append(flags, flag)
+			}
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"flags":          flags,
+			"functions_from": source,
+			"parse_errors":   ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func collectFeatureFlags(ws *gosrc.Workspace, functions []projectconfig.FlagFunction) []*featureFlag {
+	consts := stringConstants(ws)
+	flags := make(map[string]*featureFlag)
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			// Remember which if statements each call is the condition of,
+			// directly or negated.
+			conditions := make(map[*ast.CallExpr]*ast.IfStmt)
+			negated := make(map[*ast.CallExpr]bool)
+			ast.Inspect(file.Syntax, func(n ast.Node) bool {
+				ifStmt, ok := n.(*ast.IfStmt)
+				if !ok {
+					return true
+				}
+				cond := unwrapParens(ifStmt.Cond)
+				not := false
+				if unary, ok := cond.(*ast.UnaryExpr); ok && unary.Op == token.NOT {
+					cond, not = unwrapParens(unary.X), true
+				}
+				if call, ok := cond.(*ast.CallExpr); ok {
+					conditions[call] = ifStmt
+					negated[call] = not
+				}
+				return true
+			})
+
+			ast.Inspect(file.Syntax, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				fn, ok := matchFlagFunction(pkg.ImportPath, imports, functions, call)
+				if !ok || fn.NameArg >= len(call.Args) {
+					return true
+				}
+				name, ok := constantString(pkg.ImportPath, imports, consts, call.Args[fn.NameArg])
+				if !ok {
+					return true
+				}
+				usage := flagUsage{
+					Call:     exprText(call.Fun),
+					Location: sourceLocation(file, call.Pos(), call.End()),
+				}
+				if fn.DefaultArg != nil && *fn.DefaultArg < len(call.Args) {
+					usage.Default = literalText(call.Args[*fn.DefaultArg])
+				}
+				if ifStmt, ok := conditions[call]; ok {
+					var enabled, disabled ast.Node = ifStmt.Body, ifStmt.Else
+					if negated[call] {
+						enabled, disabled = disabled, enabled
+					}
+					usage.EnabledBranch = nodeLocation(file, enabled)
+					usage.DisabledBranch = nodeLocation(file, disabled)
+				}
+				flag, ok := flags[name]
+				if !ok {
+					flag = &featureFlag{Name: name}
+					flags[name] = flag
+				}
+				if usage.Default != "" && !slices.Contains(flag.Defaults, usage.Default) {
+					flag.Defaults = append(flag.Defaults, usage.Default)
+				}
+				flag.Usages = append(flag.Usages, usage)
+				return true
+			})
+		}
+	}
+	sorted := make([]*featureFlag, 0, len(flags))
+	for _, name := range sortedKeys(flags) {
+		sorted = append(sorted, flags[name])
+	}
+	return sorted
+}
+
+// matchFlagFunction finds the configured function call refers to.
+func matchFlagFunction(pkgPath string, imports map[string]string, functions []projectconfig.FlagFunction, call *ast.CallExpr) (projectconfig.FlagFunction, bool) {
+	var qualifier, name string
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		qualifier, name = pkgPath, fn.Name
+	case *ast.SelectorExpr:
+		name = fn.Sel.Name
+		if ident, ok := fn.X.(*ast.Ident); ok {
+			qualifier = imports[ident.Name]
+		}
+	default:
+		return projectconfig.FlagFunction{}, false
+	}
+	for _, candidate := range functions {
+		dot := strings.LastIndex(candidate.Call, ".")
+		if dot < 0 || candidate.Call[dot+1:] != name {
+			continue
+		}
+		pattern := candidate.Call[:dot]
+		switch {
+		case pattern == "*":
+			return candidate, true
+		case qualifier == "":
+		case pattern == qualifier, !strings.Contains(pattern, "/") && defaultImportName(qualifier) == pattern:
+			return candidate, true
+		}
+	}
+	return projectconfig.FlagFunction{}, false
+}
+
+func nodeLocation(file *gosrc.File, node ast.Node) *protocol.Location {
+	if node == nil {
+		return nil
+	}
+	loc := sourceLocation(file, node.Pos(), node.End())
+	return &loc
+}
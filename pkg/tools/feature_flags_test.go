@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+)
+
+func TestCollectFeatureFlags(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "flags/flags.go", `package flags
+
+const NewCheckout = "new-checkout"
+
+func Enabled(name string, fallback bool) bool { return fallback }
+`)
+	writeWorkspaceFile(t, workspace, "main.go", `package main
+
+import (
+	"example.com/app/flags"
+)
+
+func main() {
+	if flags.Enabled(flags.NewCheckout, false) {
+		newCheckout()
+	} else {
+		oldCheckout()
+	}
+	if !flags.Enabled("dark-mode", true) {
+		return
+	}
+	_ = ld.BoolVariation("ignored-without-config", nil, false)
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	defaultArg := 1
+	flags := collectFeatureFlags(ws, []projectconfig.FlagFunction{{Call: "flags.Enabled", NameArg: 0, DefaultArg: &defaultArg}})
+	if len(flags) != 2 || flags[0].Name != "dark-mode" || flags[1].Name != "new-checkout" {
+		t.Fatalf("unexpected flags: %+v", flags)
+	}
+
+	checkout := flags[1].Usages[0]
+	if checkout.Default != "false" || checkout.EnabledBranch == nil || checkout.EnabledBranch.Range.Start.Line != 7 || checkout.DisabledBranch == nil || checkout.DisabledBranch.Range.Start.Line != 9 {
+		t.Fatalf("unexpected new-checkout usage: %+v", checkout)
+	}
+	darkMode := flags[0].Usages[0]
+	if darkMode.Default != "true" || darkMode.EnabledBranch != nil || darkMode.DisabledBranch == nil || darkMode.DisabledBranch.Range.Start.Line != 12 {
+		t.Fatalf("expected negated condition to guard the disabled branch: %+v", darkMode)
+	}
+
+	if flags := collectFeatureFlags(ws, defaultFlagFunctions); len(flags) != 1 || flags[0].Name != "ignored-without-config" {
+		t.Fatalf("expected default SDK functions to match BoolVariation, got %+v", flags)
+	}
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const fillStructKind = "refactor.rewrite.fillStruct"
+
+func (t *LSPTools) registerFillStruct(s *server.MCPServer) {
+	tool := mcp.NewTool("fill_struct",
+		mcp.WithDescription("Fill the missing fields of a struct composite literal with zero values, with the gopls fill_struct code action. Returns the edit as a unified diff, and writes it when apply is true"),
+		mcp.WithTitleAnnotation("Fill Struct"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position inside the composite literal, such as between its braces"),
+		),
+		mcp.WithString("type",
+			mcp.Description("Struct type of the literal to fill, such as config.Options, when literals are nested at the position"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edit to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		position := protocol.Position{Line: line, Character: character}
+		actions, err := lspClient.CodeActions(ctx, fileURI, protocol.Range{Start: position, End: position}, fillStructKind)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		// gopls offers an action per enclosing literal with missing fields,
+		// titled after its type.
+		var fills []protocol.CodeAction
+		for _, action := range actions {
+			if action.Kind == fillStructKind && action.Disabled == nil {
+				fills = append(fills, action)
+			}
+		}
+		if len(fills) == 0 {
+			return mcp.NewToolResultError("no struct literal with missing fields at this position"), nil
+		}
+		action := fills[0]
+		if typeName := getOptionalStringArg(args, "type"); typeName != "" {
+			var problem string
+			if action, problem = selectCodeAction(fills, typeName); problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+		} else if len(fills) > 1 {
+			titles := make([]string, len(fills))
+			for i, fill := range fills {
+				titles[i] = fmt.Sprintf("%q", fill.Title)
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("several nested literals can be filled here: %s; set type to pick one", strings.Join(titles, ", "))), nil
+		}
+
+		edit, problem, err := codeActionEdit(ctx, lspClient, action)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		changes, err := workspaceEditChanges(edit)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compute fill struct diff: %v", err)), nil
+		}
+		if len(changes) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("code action %q produced no edits", action.Title)), nil
+		}
+		apply := getOptionalBoolArg(args, "apply")
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply fill struct", err), nil
+			}
+		}
+
+		payload := map[string]any{
+			"file_uri": fileURI,
+			"title":    action.Title,
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// fold is a folding range of a file. Line is the 1-based line that stays
+// visible, Header its text; Lines counts the hidden lines after it, up to
+// EndLine.
+type fold struct {
+	Line    int    `json:"line"`
+	EndLine int    `json:"end_line"`
+	Kind    string `json:"kind"`
+	Lines   int    `json:"lines"`
+	Header  string `json:"header"`
+}
+
+func (t *LSPTools) registerFoldingRanges(s *server.MCPServer) {
+	tool := mcp.NewTool("folding_ranges",
+		mcp.WithDescription("Return the block structure of a file from gopls textDocument/foldingRange: each foldable block (function bodies, composite literals, import groups, comments) with the line that stays visible and the lines it hides. With skeleton, also returns the file with its outermost blocks folded, so a large file can be skimmed and only the bodies needed read afterwards by line span"),
+		mcp.WithTitleAnnotation("Folding Ranges"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("Only return code, comment, imports or region blocks"),
+		),
+		mcp.WithNumber("min_lines",
+			mcp.Description("Ignore blocks hiding fewer lines (default 1)"),
+		),
+		mcp.WithBoolean("skeleton",
+			mcp.Description("Also return the file with its outermost blocks folded (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		kind := getOptionalStringArg(args, "kind")
+		if kind != "" && kind != "code" && kind != "comment" && kind != "imports" && kind != "region" {
+			return mcp.NewToolResultError("kind must be code, comment, imports or region"), nil
+		}
+		minLines := 1
+		if _, ok := args["min_lines"]; ok {
+			if minLines, err = getIntFromObject(args, "min_lines"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		path := convertURIToPath(fileURI)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", path, err)), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		ranges, err := lspClient.FoldingRanges(ctx, fileURI)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		lines := strings.Split(string(bytes.TrimSuffix(src, []byte("\n"))), "\n")
+		folds := foldsOf(ranges, lines)
+		folds = slices.DeleteFunc(folds, func(f fold) bool {
+			return f.Lines < minLines || kind != "" && f.Kind != kind
+		})
+
+		payload := map[string]any{
+			"file_uri":    fileURI,
+			"path":        relativeSlashPath(t.workspaceDir, path),
+			"total_lines": len(lines),
+			"folds":       folds,
+		}
+		if getOptionalBoolArg(args, "skeleton") {
+			payload["skeleton"] = foldSkeleton(lines, folds)
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// foldsOf converts folding ranges to folds sorted by position, outermost
+// first. Blocks gopls leaves without a kind are code.
+func foldsOf(ranges []protocol.FoldingRange, lines []string) []fold {
+	folds := make([]fold, 0, len(ranges))
+	for _, r := range ranges {
+		if r.StartLine < 0 || r.StartLine >= len(lines) {
+			continue
+		}
+		end := min(r.EndLine, len(lines)-1)
+		if end <= r.StartLine {
+			continue
+		}
+		f := fold{
+			Line:    r.StartLine + 1,
+			EndLine: end + 1,
+			Kind:    cmp.Or(r.Kind, "code"),
+			Lines:   end - r.StartLine,
+			Header:  strings.TrimSpace(lines[r.StartLine]),
+		}
+		folds = append(folds, f)
+	}
+	slices.SortStableFunc(folds, func(a, b fold) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(b.EndLine, a.EndLine))
+	})
+	return folds
+}
+
+// foldSkeleton returns lines with the folds that are not inside another
+// replaced by a comment giving the span they hide.
+func foldSkeleton(lines []string, folds []fold) string {
+	var b strings.Builder
+	next := 0
+	for _, f := range folds {
+		if f.Line <= next {
+			continue
+		}
+		for _, line := range lines[next:f.Line] {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+		hidden := lines[f.Line]
+		indent := hidden[:len(hidden)-len(strings.TrimLeft(hidden, " \t"))]
+		if f.Lines == 1 {
+			fmt.Fprintf(&b, "%s// ... line %d folded\n", indent, f.EndLine)
+		} else {
+			fmt.Fprintf(&b, "%s// ... lines %d-%d folded\n", indent, f.Line+1, f.EndLine)
+		}
+		next = f.EndLine
+	}
+	for _, line := range lines[next:] {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
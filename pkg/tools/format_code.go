@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+)
+
+// formatFailure is a file gopls could not format, usually because it does
+// not parse.
+type formatFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+func (t *LSPTools) registerFormatCode(s *server.MCPServer) {
+	tool := mcp.NewTool("format_code",
+		mcp.WithDescription("Format a Go file, or every Go file under a directory, with gopls textDocument/formatting, which applies gofumpt when the gopls settings of .mcp-gopls.json enable it. Reports the files that change with a unified diff each, and writes them when apply is true"),
+		mcp.WithTitleAnnotation("Format Code"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or path of a Go file, or of a directory"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("For a directory, also format its subdirectories, skipping vendor and testdata (default true)"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the formatted files to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		recursive := true
+		if _, ok := args["recursive"]; ok {
+			recursive = getOptionalBoolArg(args, "recursive")
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		path := convertURIToPath(fileURI)
+		info, err := os.Stat(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", path, err)), nil
+		}
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = goFilesUnder(path, recursive); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(files) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("no Go files in %s", path)), nil
+			}
+		}
+
+		var changes []fileChange
+		var failures []formatFailure
+		for _, file := range files {
+			before, err := os.ReadFile(file)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", file, err)), nil
+			}
+			edits, err := lspClient.DocumentFormatting(ctx, convertPathToURI(file))
+			if err != nil {
+				// One file that does not parse should not hide the others.
+				if !info.IsDir() {
+					return nil, t.handleLSPError(err)
+				}
+				failures = append(failures, formatFailure{Path: relativeSlashPath(t.workspaceDir, file), Error: err.Error()})
+				continue
+			}
+			change, err := textEditsChange(file, before, edits)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("compute formatting diff: %v", err)), nil
+			}
+			if !bytes.Equal(change.before, change.after) {
+				changes = append(changes, change)
+			}
+		}
+
+		apply := getOptionalBoolArg(args, "apply") && len(changes) > 0
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply formatting", err), nil
+			}
+		}
+
+		gofumpt := false
+		if cfg, err := projectconfig.Load(t.workspaceDir); err == nil {
+			gofumpt, _ = cfg.Gopls["gofumpt"].(bool)
+		}
+		payload := map[string]any{
+			"file_uri": fileURI,
+			"gofumpt":  gofumpt,
+			"checked":  len(files),
+			"changed":  len(changes),
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
+		}
+		if len(failures) > 0 {
+			payload["failed"] = failures
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// goFilesUnder lists the Go files of dir, and with recursive those of its
+// subdirectories as well, leaving out vendor and testdata trees like go
+// fmt ./... does.
+func goFilesUnder(dir string, recursive bool) ([]string, error) {
+	if !recursive {
+		return packageGoFiles(dir, true)
+	}
+	var files []string
+	err := walkWorkspaceFiles(dir, func(path string) error {
+		rel := relativeSlashPath(dir, path)
+		if strings.HasSuffix(path, ".go") && !slices.Contains(strings.Split(rel, "/"), "testdata") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	slices.Sort(files)
+	return files, nil
+}
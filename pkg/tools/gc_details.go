@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// gcDetailKinds maps the codes of the compiler's optimization log to the
+// kinds gc_details reports; other codes are reported as other.
+var gcDetailKinds = map[string]string{
+	"canInlineFunction":    "inlining",
+	"cannotInlineFunction": "inlining",
+	"inlineCall":           "inlining",
+	"cannotInlineCall":     "inlining",
+	"escape":               "escape",
+	"escapes":              "escape",
+	"leak":                 "escape",
+	"isInBounds":           "bounds",
+	"isSliceInBounds":      "bounds",
+	"nilcheck":             "nil",
+}
+
+var gcDetailKindNames = []string{"inlining", "escape", "bounds", "nil", "other"}
+
+// gcLogDiagnostic is a line of the compiler's optimization log, an LSP
+// diagnostic with 1-based positions and the escape flow as related
+// information.
+type gcLogDiagnostic struct {
+	protocol.Diagnostic
+	RelatedInformation []struct {
+		Location protocol.Location `json:"location"`
+		Message  string            `json:"message"`
+	} `json:"relatedInformation"`
+}
+
+// gcDetail is an optimization decision of the compiler at a position.
+type gcDetail struct {
+	Line        int      `json:"line"`
+	Column      int      `json:"column"`
+	Kind        string   `json:"kind"`
+	Code        string   `json:"code"`
+	Message     string   `json:"message,omitempty"`
+	Explanation []string `json:"explanation,omitempty"`
+}
+
+func (t *LSPTools) registerGCDetails(s *server.MCPServer) {
+	tool := mcp.NewTool("gc_details",
+		mcp.WithDescription("Show the compiler's optimization decisions for a file, the details gopls's gc_details code lens annotates: which functions can be inlined and which calls are, which values escape to the heap and why, and which bounds and nil checks remain. Compiles the file's package with the optimization log enabled; nothing is written to the workspace"),
+		mcp.WithTitleAnnotation("GC Details"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file",
+			mcp.Required(),
+			mcp.Description("Go file URI or path; its whole package is compiled"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("Only report these kinds among inlining, escape, bounds, nil and other (default all)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithObject("range",
+			mcp.Description("Only report the details within these lines, 0-based like the other tools: {\"start\": {\"line\": 10, \"character\": 0}, \"end\": {\"line\": 40, \"character\": 0}}"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		path := convertURIToPath(fileURI)
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return mcp.NewToolResultError("file must be a non-test Go file: the compiler log covers the package being built"), nil
+		}
+		if !fileExists(path) {
+			return mcp.NewToolResultError(fmt.Sprintf("%s does not exist", path)), nil
+		}
+		kinds := make(map[string]bool)
+		if raw, ok := args["kinds"].([]any); ok {
+			for _, value := range raw {
+				kind, _ := value.(string)
+				if !slices.Contains(gcDetailKindNames, kind) {
+					return mcp.NewToolResultError(fmt.Sprintf("unknown kind %v; use %s", value, strings.Join(gcDetailKindNames, ", "))), nil
+				}
+				kinds[kind] = true
+			}
+		}
+		first, last := 0, -1
+		if _, ok := args["range"]; ok {
+			rng, err := parseRangeArg(args, "range")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			first, last = rng.Start.Line+1, rng.End.Line+1
+		}
+
+		logDir, err := os.MkdirTemp("", "mcp-gopls-gcdetails-")
+		if err != nil {
+			return nil, fmt.Errorf("create log directory: %w", err)
+		}
+		defer os.RemoveAll(logDir)
+		binary := filepath.Join(logDir, "out")
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Compiling "+filepath.Base(filepath.Dir(path))+" with the optimization log")
+		// The log directory is new for every run, so the flags never match
+		// a cached build that would skip writing the log.
+		result, err := t.runCommandSpec(ctx, s, token, commandSpec{
+			name: "go",
+			args: []string{"build", "-gcflags=-json=0," + convertPathToURI(logDir), "-o=" + binary, "."},
+			dir:  filepath.Dir(path),
+		})
+		if err != nil {
+			return t.commandFailureResult("go build with the optimization log", result, err)
+		}
+
+		details, err := readGCDetails(logDir, path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("read the optimization log: %v", err)), nil
+		}
+		counts := make(map[string]int)
+		kept := []gcDetail{}
+		for _, detail := range details {
+			if len(kinds) > 0 && !kinds[detail.Kind] || last >= 0 && (detail.Line < first || detail.Line > last) {
+				continue
+			}
+			counts[detail.Kind]++
+			kept = append(kept, detail)
+		}
+
+		payload := map[string]any{
+			"file":    relativeSlashPath(t.workspaceDir, path),
+			"details": kept,
+			"counts":  counts,
+			"total":   len(kept),
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// readGCDetails reads the details of file from the optimization log the
+// compiler wrote under dir: one JSON file per source file with decisions, a
+// header then a diagnostic per line.
+func readGCDetails(dir, file string) ([]gcDetail, error) {
+	var details []gcDetail
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		var header struct {
+			File string `json:"file"`
+		}
+		if err := decoder.Decode(&header); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if !sameFile(header.File, file) {
+			return nil
+		}
+		seen := make(map[string]bool)
+		for {
+			var diagnostic gcLogDiagnostic
+			if err := decoder.Decode(&diagnostic); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+			detail := describeGCDetail(diagnostic, filepath.Dir(file))
+			// The compiler repeats some decisions without their message.
+			key := fmt.Sprint(detail.Line, detail.Column, detail.Code)
+			if detail.Message == "" && seen[key] {
+				continue
+			}
+			seen[key] = true
+			details = append(details, detail)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(details, func(a, b gcDetail) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return details, nil
+}
+
+func describeGCDetail(diagnostic gcLogDiagnostic, dir string) gcDetail {
+	kind, ok := gcDetailKinds[diagnostic.Code]
+	if !ok {
+		kind = "other"
+	}
+	detail := gcDetail{
+		Line:    diagnostic.Range.Start.Line,
+		Column:  diagnostic.Range.Start.Character,
+		Kind:    kind,
+		Code:    diagnostic.Code,
+		Message: diagnostic.Message,
+	}
+	for _, related := range diagnostic.RelatedInformation {
+		position := relativeSlashPath(dir, convertURIToPath(related.Location.URI))
+		message := strings.TrimSpace(strings.TrimPrefix(related.Message, "escflow:"))
+		detail.Explanation = append(detail.Explanation, fmt.Sprintf("%s:%d:%d: %s", position, related.Location.Range.Start.Line, related.Location.Range.Start.Character, message))
+	}
+	return detail
+}
+
+// sameFile reports whether the compiler's name for a file, which may be
+// relative to the package directory, names path.
+func sameFile(name, path string) bool {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name) == filepath.Clean(path)
+	}
+	return filepath.Base(name) == filepath.Base(path)
+}
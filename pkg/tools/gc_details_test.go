@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestGCDetails(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+type Item struct{ n int }
+
+func New(n int) *Item {
+	return &Item{n: n}
+}
+
+func At(s []int, i int) int {
+	return s[i]
+}
+`)
+
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("gc_details").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "gc_details", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("gc_details: %v", err)
+		}
+		if result.IsError {
+			t.Fatalf("gc_details failed: %v", result.Content)
+		}
+		return structured(result)
+	}
+
+	file := filepath.Join(workspace, "store", "store.go")
+	all := call(map[string]any{"file": file})
+	counts := all["counts"].(map[string]any)
+	if all["file"] != "store/store.go" || counts["inlining"] != float64(2) || counts["escape"] != float64(1) || counts["bounds"] != float64(1) {
+		t.Fatalf("unexpected details %v", all)
+	}
+
+	escapes := call(map[string]any{"file": file, "kinds": []any{"escape"}})["details"].([]any)
+	if len(escapes) != 1 {
+		t.Fatalf("expected one escape, got %v", escapes)
+	}
+	escape := escapes[0].(map[string]any)
+	explanation, _ := escape["explanation"].([]any)
+	if escape["line"] != float64(6) || !strings.Contains(escape["message"].(string), "escapes to heap") || len(explanation) == 0 || !strings.HasPrefix(explanation[0].(string), "store.go:6:") {
+		t.Fatalf("unexpected escape %v", escape)
+	}
+
+	ranged := call(map[string]any{"file": file, "range": map[string]any{
+		"start": map[string]any{"line": 8, "character": 0},
+		"end":   map[string]any{"line": 10, "character": 0},
+	}})
+	if ranged["total"] != float64(2) {
+		t.Fatalf("expected At's inlining and bounds check only, got %v", ranged["details"])
+	}
+}
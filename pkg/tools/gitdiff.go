@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// diffFile is a file changed by a git diff, with slash-separated paths
+// relative to the workspace root. OldPath is empty for added files and
+// Path for deleted ones.
+type diffFile struct {
+	Path    string     `json:"path"`
+	OldPath string     `json:"old_path,omitempty"`
+	Status  string     `json:"status"`
+	Binary  bool       `json:"binary,omitempty"`
+	Hunks   []diffHunk `json:"hunks"`
+}
+
+// diffHunk is a hunk of a zero-context diff: OldLines lines from OldStart
+// replaced by NewLines lines from NewStart, both 1-based. A hunk without
+// new lines starts after the line NewStart.
+type diffHunk struct {
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Symbols  []string `json:"symbols,omitempty"`
+}
+
+// addedLines returns the new-side line numbers the hunks of f add or
+// change.
+func (f diffFile) addedLines() map[int]bool {
+	lines := make(map[int]bool)
+	for _, hunk := range f.Hunks {
+		for line := hunk.NewStart; line < hunk.NewStart+hunk.NewLines; line++ {
+			lines[line] = true
+		}
+	}
+	return lines
+}
+
+// workingTreeDiff returns the changes of the working tree, staged or not,
+// since base, untracked files included as added.
+func (t *LSPTools) workingTreeDiff(ctx context.Context, s *server.MCPServer, base string) ([]diffFile, error) {
+	files, err := t.gitDiff(ctx, s, base)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := t.runCommand(ctx, s, nil, "git", "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("%s", buildCommandErrorMessage("git ls-files", untracked, err))
+	}
+	for _, name := range splitLines(untracked.Stdout) {
+		file := diffFile{Path: name, Status: "added", Hunks: []diffHunk{}}
+		data, err := os.ReadFile(filepath.Join(t.workspaceDir, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		lines := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+			lines++
+		}
+		if lines > 0 {
+			file.Hunks = append(file.Hunks, diffHunk{NewStart: 1, NewLines: lines})
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// stagedDiff returns the staged changes since base, as the next commit
+// would record them.
+func (t *LSPTools) stagedDiff(ctx context.Context, s *server.MCPServer, base string) ([]diffFile, error) {
+	return t.gitDiff(ctx, s, "--cached", base)
+}
+
+// gitDiff runs a zero-context git diff with args and parses its files.
+func (t *LSPTools) gitDiff(ctx context.Context, s *server.MCPServer, args ...string) ([]diffFile, error) {
+	args = append([]string{"diff", "--unified=0", "--no-color", "--no-ext-diff", "--find-renames", "--relative"}, args...)
+	result, err := t.runCommand(ctx, s, nil, "git", append(args, "--")...)
+	if err != nil {
+		return nil, fmt.Errorf("%s", buildCommandErrorMessage("git diff", result, err))
+	}
+	return parseUnifiedDiff(result.Stdout), nil
+}
+
+// parseUnifiedDiff reads the files and hunks of a git diff.
+func parseUnifiedDiff(output string) []diffFile {
+	var files []diffFile
+	var current *diffFile
+	for line := range strings.Lines(output) {
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, diffFile{Status: "modified", Hunks: []diffHunk{}})
+			current = &files[len(files)-1]
+			// Renames and binary files have no ---/+++ lines.
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/"); ok {
+				current.OldPath, current.Path = strings.TrimPrefix(a, "a/"), b
+			}
+		case current == nil:
+		case strings.HasPrefix(line, "new file mode"):
+			current.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			current.Status = "deleted"
+		case strings.HasPrefix(line, "rename from "):
+			current.Status = "renamed"
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			current.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files "):
+			current.Binary = true
+		case strings.HasPrefix(line, "--- "):
+			if name := strings.TrimPrefix(line, "--- "); name != "/dev/null" {
+				current.OldPath = strings.TrimPrefix(name, "a/")
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if name := strings.TrimPrefix(line, "+++ "); name != "/dev/null" {
+				current.Path = strings.TrimPrefix(name, "b/")
+			}
+		case strings.HasPrefix(line, "@@ "):
+			if hunk, ok := parseHunkHeader(line); ok {
+				current.Hunks = append(current.Hunks, hunk)
+			}
+		}
+	}
+	for i := range files {
+		switch files[i].Status {
+		case "added":
+			files[i].OldPath = ""
+		case "deleted":
+			files[i].Path = files[i].OldPath
+		}
+		if files[i].Status == "modified" && files[i].OldPath == files[i].Path {
+			files[i].OldPath = ""
+		}
+	}
+	return files
+}
+
+// parseHunkHeader reads "@@ -start,count +start,count @@", where a missing
+// count is 1.
+func parseHunkHeader(line string) (diffHunk, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return diffHunk{}, false
+	}
+	span := func(text string) (int, int, bool) {
+		start, count, found := strings.Cut(text, ",")
+		s, err := strconv.Atoi(start)
+		if err != nil {
+			return 0, 0, false
+		}
+		if !found {
+			return s, 1, true
+		}
+		c, err := strconv.Atoi(count)
+		return s, c, err == nil
+	}
+	oldStart, oldLines, ok1 := span(fields[1][1:])
+	newStart, newLines, ok2 := span(fields[2][1:])
+	if !ok1 || !ok2 {
+		return diffHunk{}, false
+	}
+	return diffHunk{OldStart: oldStart, OldLines: oldLines, NewStart: newStart, NewLines: newLines}, true
+}
@@ -0,0 +1,379 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	globalKindMutated     = "mutated"
+	globalKindUnwritten   = "unwritten"
+	globalKindSingleton   = "singleton"
+	globalKindSyncOnce    = "sync_once"
+	globalKindLock        = "lock"
+	globalKindSentinel    = "sentinel_error"
+	globalKindInitialized = "immutable_value"
+)
+
+// immutableInitializers are package-level initializers whose result is
+// conventionally treated as a constant.
+var immutableInitializers = map[string]string{
+	"errors.New":            globalKindSentinel,
+	"fmt.Errorf":            globalKindSentinel,
+	"regexp.MustCompile":    globalKindInitialized,
+	"template.Must":         globalKindInitialized,
+	"reflect.TypeOf":        globalKindInitialized,
+	"reflect.TypeFor":       globalKindInitialized,
+	"strings.NewReplacer":   globalKindInitialized,
+	"big.NewInt":            globalKindInitialized,
+	"netip.MustParseAddr":   globalKindInitialized,
+	"netip.MustParsePrefix": globalKindInitialized,
+}
+
+type initFunction struct {
+	Package  string            `json:"package"`
+	Location protocol.Location `json:"location"`
+	Calls    []string          `json:"calls,omitempty"`
+}
+
+type globalVariable struct {
+	Name      string            `json:"name"`
+	Package   string            `json:"package"`
+	Type      string            `json:"type,omitempty"`
+	Kind      string            `json:"kind"`
+	WrittenBy []string          `json:"written_by,omitempty"`
+	Location  protocol.Location `json:"location"`
+}
+
+type globalSingleton struct {
+	Once        string            `json:"once"`
+	Package     string            `json:"package"`
+	Initializes []string          `json:"initializes,omitempty"`
+	Accessors   []string          `json:"accessors"`
+	Location    protocol.Location `json:"location"`
+}
+
+type globalsReport struct {
+	InitFunctions []initFunction    `json:"init_functions"`
+	Variables     []*globalVariable `json:"variables"`
+	Singletons    []globalSingleton `json:"singletons"`
+}
+
+func (t *LSPTools) registerAuditGlobals(s *server.MCPServer) {
+	tool := mcp.NewTool("audit_globals",
+		mcp.WithDescription("List init() functions, package-level mutable variables (with the functions that write them) and sync.Once singletons with locations"),
+		mcp.WithTitleAnnotation("Audit Globals"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only report packages whose import path starts with this prefix")),
+		mcp.WithBoolean("include_immutable", mcp.Description("Also list sentinel errors, compiled regexps and similar write-once values (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		report := auditGlobals(ws, prefix, getOptionalBoolArg(args, "include_immutable"))
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"init_functions": report.InitFunctions,
+			"variables":      report.Variables,
+			"singletons":     report.Singletons,
+			"parse_errors":   ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func auditGlobals(ws *gosrc.Workspace, prefix string, includeImmutable bool) globalsReport {
+	report := globalsReport{
+		InitFunctions: []initFunction{},
+		Variables:     []*globalVariable{},
+		Singletons:    []globalSingleton{},
+	}
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		vars := make(map[string]*globalVariable)
+		var order []*globalVariable
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			for _, decl := range file.Syntax.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv == nil && d.Name.Name == "init" && d.Body != nil {
+						report.InitFunctions = append(report.InitFunctions, initFunction{
+							Package:  pkg.ImportPath,
+							Location: sourceLocation(file, d.Name.Pos(), d.Name.End()),
+							Calls:    calledFunctions(d.Body),
+						})
+					}
+				case *ast.GenDecl:
+					if d.Tok != token.VAR {
+						continue
+					}
+					for _, spec := range d.Specs {
+						vs := spec.(*ast.ValueSpec)
+						for i, ident := range vs.Names {
+							if ident.Name == "_" {
+								continue
+							}
+							v := &globalVariable{
+								Name:     ident.Name,
+								Package:  pkg.ImportPath,
+								Kind:     globalKindUnwritten,
+								Location: sourceLocation(file, ident.Pos(), ident.End()),
+							}
+							if vs.Type != nil {
+								v.Type = exprText(vs.Type)
+							}
+							switch qualifiedTypeText(imports, vs.Type) {
+							case "sync.Once":
+								v.Kind = globalKindSyncOnce
+							case "sync.Mutex", "sync.RWMutex":
+								v.Kind = globalKindLock
+							}
+							if i < len(vs.Values) {
+								if call, ok := vs.Values[i].(*ast.CallExpr); ok {
+									if kind, ok := immutableInitializers[qualifiedTypeText(imports, call.Fun)]; ok {
+										v.Kind = kind
+									}
+								}
+							}
+							vars[ident.Name] = v
+							order = append(order, v)
+						}
+					}
+				}
+			}
+		}
+		if len(vars) == 0 {
+			continue
+		}
+
+		singletons := make(map[string]*globalSingleton)
+		for _, file := range pkg.Files {
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				name := fn.Name.Name
+				if fn.Recv != nil && len(fn.Recv.List) > 0 {
+					name = receiverTypeName(fn.Recv.List[0].Type) + "." + name
+				}
+				locals := localNames(fn)
+				for _, written := range writtenGlobals(fn.Body, vars, locals) {
+					v := vars[written]
+					if !slices.Contains(v.WrittenBy, name) {
+						v.WrittenBy = append(v.WrittenBy, name)
+					}
+				}
+				// once.Do(func() { instance = ... }) marks a singleton.
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok || len(call.Args) != 1 {
+						return true
+					}
+					recv, method, ok := methodCall(call)
+					if !ok || method != "Do" {
+						return true
+					}
+					ident, ok := recv.(*ast.Ident)
+					if !ok || locals[ident.Name] || vars[ident.Name] == nil || vars[ident.Name].Kind != globalKindSyncOnce {
+						return true
+					}
+					singleton, ok := singletons[ident.Name]
+					if !ok {
+						singleton = &globalSingleton{Once: ident.Name, Package: pkg.ImportPath, Location: vars[ident.Name].Location}
+						singletons[ident.Name] = singleton
+					}
+					if !slices.Contains(singleton.Accessors, name) {
+						singleton.Accessors = append(singleton.Accessors, name)
+					}
+					if lit, ok := call.Args[0].(*ast.FuncLit); ok {
+						for _, written := range writtenGlobals(lit.Body, vars, locals) {
+							if !slices.Contains(singleton.Initializes, written) {
+								singleton.Initializes = append(singleton.Initializes, written)
+							}
+						}
+					}
+					return true
+				})
+			}
+		}
+		for _, singleton := range singletons {
+			for _, written := range singleton.Initializes {
+				vars[written].Kind = globalKindSingleton
+			}
+		}
+		for _, name := range sortedKeys(singletons) {
+			report.Singletons = append(report.Singletons, *singletons[name])
+		}
+
+		for _, v := range order {
+			if v.Kind == globalKindUnwritten && len(v.WrittenBy) > 0 {
+				v.Kind = globalKindMutated
+			}
+			immutable := v.Kind == globalKindSentinel || v.Kind == globalKindInitialized
+			if immutable && len(v.WrittenBy) > 0 {
+				v.Kind = globalKindMutated
+				immutable = false
+			}
+			if immutable && !includeImmutable {
+				continue
+			}
+			report.Variables = append(report.Variables, v)
+		}
+	}
+	return report
+}
+
+// qualifiedTypeText renders pkg.Name expressions with the package's last
+// import path element, so renamed imports still match.
+func qualifiedTypeText(imports map[string]string, expr ast.Expr) string {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	importPath, ok := imports[ident.Name]
+	if !ok {
+		return ""
+	}
+	return defaultImportName(importPath) + "." + sel.Sel.Name
+}
+
+// calledFunctions lists the distinct functions a body calls, in order.
+func calledFunctions(body *ast.BlockStmt) []string {
+	var calls []string
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			name := exprText(call.Fun)
+			if !slices.Contains(calls, name) {
+				calls = append(calls, name)
+			}
+		}
+		return true
+	})
+	return calls
+}
+
+// localNames collects the identifiers a function declares (parameters,
+// results, := and var), which shadow package-level variables.
+func localNames(fn *ast.FuncDecl) map[string]bool {
+	locals := make(map[string]bool)
+	addFields := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				locals[name.Name] = true
+			}
+		}
+	}
+	addFields(fn.Recv)
+	addFields(fn.Type.Params)
+	addFields(fn.Type.Results)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if node.Tok == token.DEFINE {
+				for _, lhs := range node.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						locals[ident.Name] = true
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for _, name := range node.Names {
+				locals[name.Name] = true
+			}
+		case *ast.RangeStmt:
+			if node.Tok == token.DEFINE {
+				for _, expr := range []ast.Expr{node.Key, node.Value} {
+					if ident, ok := expr.(*ast.Ident); ok {
+						locals[ident.Name] = true
+					}
+				}
+			}
+		case *ast.FuncLit:
+			if node.Type.Params != nil {
+				for _, field := range node.Type.Params.List {
+					for _, name := range field.Names {
+						locals[name.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	return locals
+}
+
+// writtenGlobals reports the package-level variables body assigns,
+// increments, or takes the address of (including through fields and index
+// expressions).
+func writtenGlobals(body ast.Node, vars map[string]*globalVariable, locals map[string]bool) []string {
+	var written []string
+	record := func(expr ast.Expr) {
+		for {
+			switch e := expr.(type) {
+			case *ast.SelectorExpr:
+				expr = e.X
+				continue
+			case *ast.IndexExpr:
+				expr = e.X
+				continue
+			case *ast.StarExpr:
+				expr = e.X
+				continue
+			case *ast.ParenExpr:
+				expr = e.X
+				continue
+			case *ast.Ident:
+				if vars[e.Name] != nil && !locals[e.Name] && !slices.Contains(written, e.Name) {
+					written = append(written, e.Name)
+				}
+			}
+			return
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if node.Tok != token.DEFINE {
+				for _, lhs := range node.Lhs {
+					record(lhs)
+				}
+			}
+		case *ast.IncDecStmt:
+			record(node.X)
+		case *ast.UnaryExpr:
+			if node.Op == token.AND {
+				record(node.X)
+			}
+		}
+		return true
+	})
+	return written
+}
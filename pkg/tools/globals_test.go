@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestAuditGlobals(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "registry/registry.go", `package registry
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrMissing = errors.New("missing")
+
+var (
+	drivers  = map[string]string{}
+	once     sync.Once
+	instance *Client
+	debug    bool
+	count    int
+)
+
+type Client struct{}
+
+func init() {
+	Register("default", "builtin")
+}
+
+func Register(name, driver string) {
+	drivers[name] = driver
+	count++
+}
+
+func Default() *Client {
+	once.Do(func() {
+		instance = &Client{}
+	})
+	return instance
+}
+
+func shadow() {
+	debug := true
+	_ = debug
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	report := auditGlobals(ws, "", false)
+	if len(report.InitFunctions) != 1 || !reflect.DeepEqual(report.InitFunctions[0].Calls, []string{"Register"}) {
+		t.Fatalf("unexpected init functions: %+v", report.InitFunctions)
+	}
+
+	kinds := make(map[string]string)
+	writers := make(map[string][]string)
+	for _, v := range report.Variables {
+		kinds[v.Name] = v.Kind
+		writers[v.Name] = v.WrittenBy
+	}
+	expected := map[string]string{
+		"drivers":  globalKindMutated,
+		"once":     globalKindSyncOnce,
+		"instance": globalKindSingleton,
+		"debug":    globalKindUnwritten,
+		"count":    globalKindMutated,
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Fatalf("unexpected variable kinds: %v", kinds)
+	}
+	if !reflect.DeepEqual(writers["drivers"], []string{"Register"}) {
+		t.Fatalf("unexpected writers for drivers: %v", writers["drivers"])
+	}
+
+	if len(report.Singletons) != 1 || report.Singletons[0].Once != "once" ||
+		!reflect.DeepEqual(report.Singletons[0].Initializes, []string{"instance"}) ||
+		!reflect.DeepEqual(report.Singletons[0].Accessors, []string{"Default"}) {
+		t.Fatalf("unexpected singletons: %+v", report.Singletons)
+	}
+
+	if all := auditGlobals(ws, "", true); len(all.Variables) != 6 {
+		t.Fatalf("expected include_immutable to add the sentinel error, got %d variables", len(all.Variables))
+	}
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// goBuildErrorPattern matches the positioned errors of go build; the
+// column is missing from some, such as those of cgo.
+var goBuildErrorPattern = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+func (t *LSPTools) registerGoBuild(s *server.MCPServer) {
+	tool := mcp.NewTool("go_build",
+		mcp.WithDescription("Compile packages with go build, discarding the binaries, and return the compile errors as {file, line, column, message} entries, file relative to the workspace, with the explanation lines the compiler indents below an error kept in its message. Errors without a position, such as a pattern matching no package, are entries with only a message"),
+		mcp.WithTitleAnnotation("Go Build"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("target",
+			mcp.Description("Package patterns to build, separated by spaces (default ./...)"),
+		),
+		mcp.WithString("tags",
+			mcp.Description("Comma-separated build tags, passed as -tags"),
+		),
+		mcp.WithString("goos",
+			mcp.Description("Target operating system, set as GOOS"),
+		),
+		mcp.WithString("goarch",
+			mcp.Description("Target architecture, set as GOARCH"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		targets := strings.Fields(getOptionalStringArg(args, "target"))
+		if len(targets) == 0 {
+			targets = []string{"./..."}
+		}
+		buildArgs := []string{"build", "-o", os.DevNull}
+		if tags := getOptionalStringArg(args, "tags"); tags != "" {
+			buildArgs = append(buildArgs, "-tags", tags)
+		}
+		buildArgs = append(buildArgs, targets...)
+		var env []string
+		for _, key := range []string{"goos", "goarch"} {
+			if value := getOptionalStringArg(args, key); value != "" {
+				env = append(env, strings.ToUpper(key)+"="+value)
+			}
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Running go build "+strings.Join(targets, " "))
+		result, buildErrors, err := t.compileErrors(ctx, s, token, commandSpec{name: "go", args: buildArgs, env: env})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return t.commandFailureResult("go build", result, err)
+		}
+
+		payload := map[string]any{
+			"ok":       len(buildErrors) == 0,
+			"targets":  targets,
+			"errors":   buildErrors,
+			"command":  result.Command,
+			"duration": result.Duration,
+		}
+		if len(env) > 0 {
+			payload["env"] = env
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// compileErrors runs the go build command of spec and returns its compile
+// errors. The error is only set when the command could not run or ctx
+// ended.
+func (t *LSPTools) compileErrors(ctx context.Context, s *server.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, []buildError, error) {
+	result, err := t.runCommandSpec(ctx, s, token, spec)
+	if err == nil {
+		return result, []buildError{}, nil
+	}
+	if ctx.Err() != nil {
+		return result, nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return result, nil, err
+	}
+	buildErrors := t.parseGoBuildOutput(result.Stderr)
+	if len(buildErrors) == 0 {
+		buildErrors = []buildError{{Message: buildCommandErrorMessage("go build", result, err)}}
+	}
+	return result, buildErrors, nil
+}
+
+// parseGoBuildOutput reads the errors of go build output. The "# package"
+// headers and progress lines are skipped, and tab-indented lines are
+// appended to the error above them.
+func (t *LSPTools) parseGoBuildOutput(output string) []buildError {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	errs := []buildError{}
+	for line := range strings.Lines(output) {
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.TrimSpace(line) == "", strings.HasPrefix(line, "# "), strings.HasPrefix(line, "go: downloading "):
+		case strings.HasPrefix(line, "\t") && len(errs) > 0:
+			errs[len(errs)-1].Message += "\n" + strings.TrimSpace(line)
+		default:
+			match := goBuildErrorPattern.FindStringSubmatch(line)
+			if match == nil {
+				errs = append(errs, buildError{Message: strings.TrimSpace(line)})
+				continue
+			}
+			file := filepath.FromSlash(match[1])
+			if filepath.IsAbs(file) {
+				file = relativeSlashPath(root, file)
+			} else {
+				file = filepath.ToSlash(filepath.Clean(file))
+			}
+			lineNo, _ := strconv.Atoi(match[2])
+			column, _ := strconv.Atoi(match[3])
+			errs = append(errs, buildError{File: file, Line: lineNo, Column: column, Message: match[4]})
+		}
+	}
+	return errs
+}
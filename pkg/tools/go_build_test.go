@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseGoBuildOutput(t *testing.T) {
+	tools := NewLSPTools(nil, "/work")
+	output := "go: downloading example.com/dep v1.0.0\n" +
+		"# example.com/app/calc\n" +
+		"calc/calc.go:5:9: not enough arguments in call to add\n" +
+		"\thave (number)\n" +
+		"\twant (int, int)\n" +
+		"./main.go:7: cgo error\n" +
+		"/work/util/util.go:3:2: undefined: missing\n" +
+		"pattern ./nothing: directory prefix nothing does not contain main module\n"
+	want := []buildError{
+		{File: "calc/calc.go", Line: 5, Column: 9, Message: "not enough arguments in call to add\nhave (number)\nwant (int, int)"},
+		{File: "main.go", Line: 7, Message: "cgo error"},
+		{File: "util/util.go", Line: 3, Column: 2, Message: "undefined: missing"},
+		{Message: "pattern ./nothing: directory prefix nothing does not contain main module"},
+	}
+	if got := tools.parseGoBuildOutput(output); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected errors:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestGoBuild(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nfunc Add(a, b int) int { return a + b }\n")
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nimport \"example.com/app/calc\"\n\nfunc main() { println(calc.Add(1)) }\n")
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	build := func(args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("go_build").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "go_build", Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("go_build: %v %v", err, result)
+		}
+		return structured(result)
+	}
+
+	if built := build(map[string]any{"target": "./calc"}); built["ok"] != true || len(built["errors"].([]any)) != 0 {
+		t.Fatalf("expected ./calc to build, got %v", built)
+	}
+	built := build(map[string]any{})
+	errs := built["errors"].([]any)
+	if built["ok"] != false || len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", built)
+	}
+	first := errs[0].(map[string]any)
+	if first["file"] != "main.go" || first["line"] != float64(5) || first["column"] == nil || !strings.Contains(first["message"].(string), "not enough arguments in call to calc.Add") {
+		t.Fatalf("unexpected error %v", first)
+	}
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// moduleFile is the content of go.mod or go.sum, nil when the file does not
+// exist.
+type moduleFile struct {
+	path    string
+	content []byte
+}
+
+func readModuleFiles(dir string) ([]moduleFile, error) {
+	var files []moduleFile
+	for _, name := range []string{"go.mod", "go.sum"} {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		files = append(files, moduleFile{path: path, content: content})
+	}
+	return files, nil
+}
+
+// restoreModuleFiles puts back the files read before go mod tidy ran and
+// returns what it changed as file changes.
+func restoreModuleFiles(before []moduleFile) ([]fileChange, error) {
+	var changes []fileChange
+	for _, file := range before {
+		after, err := os.ReadFile(file.path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		existed, exists := file.content != nil, err == nil
+		if existed == exists && bytes.Equal(file.content, after) {
+			continue
+		}
+		change := fileChange{path: file.path, before: file.content, after: after}
+		switch {
+		case !existed:
+			change.created = true
+			err = os.Remove(file.path)
+		case !exists:
+			change.deleted = true
+			err = os.WriteFile(file.path, file.content, 0o644)
+		default:
+			err = os.WriteFile(file.path, file.content, 0o644)
+		}
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (t *LSPTools) registerTidyModule(s *server.MCPServer) {
+	tool := mcp.NewTool("go_mod_tidy",
+		mcp.WithDescription("Run go mod tidy and report the diff of go.mod and go.sum, then build the workspace to tell whether it still compiles with the tidied requirements (compile errors as {file, line, column, message}). The changes go through the same checks as the other edits (edit contract, approval); with check, nothing is written and only the diff is reported, to verify that the module is tidy"),
+		mcp.WithTitleAnnotation("Go Mod Tidy"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithBoolean("check",
+			mcp.Description("Only report the diff go mod tidy would make, leaving go.mod and go.sum unchanged (default false)"),
+		),
+		mcp.WithBoolean("build",
+			mcp.Description("Build ./... after tidying (default true)"),
+		),
+	)
+
+	s.AddTool(tool, t.tidyModuleHandler(s))
+
+	// run_go_mod_tidy is the tool's original name, kept for clients that
+	// still call it; it goes through the same checks, so there is a single
+	// way to tidy the module.
+	alias := mcp.NewTool("run_go_mod_tidy",
+		mcp.WithDescription("Deprecated: use go_mod_tidy. Run go mod tidy in the workspace, reporting the diff of go.mod and go.sum and writing it through the same checks as the other edits (edit contract, approval)"),
+		mcp.WithTitleAnnotation("Run Go Mod Tidy"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithBoolean("check",
+			mcp.Description("Only report the diff go mod tidy would make, leaving go.mod and go.sum unchanged (default false)"),
+		),
+		mcp.WithBoolean("build",
+			mcp.Description("Build ./... after tidying (default true)"),
+		),
+	)
+	s.AddTool(alias, t.tidyModuleHandler(s))
+}
+
+// tidyModuleHandler runs go mod tidy, puts go.mod and go.sum back, and
+// writes what it changed through writeFileChanges.
+func (t *LSPTools) tidyModuleHandler(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		check := getOptionalBoolArg(args, "check")
+		build := !check
+		if _, ok := args["build"]; ok && !check {
+			build = getOptionalBoolArg(args, "build")
+		}
+		before, err := readModuleFiles(t.workspaceDir)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if before[0].content == nil {
+			return mcp.NewToolResultError("no go.mod at the workspace root"), nil
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Running go mod tidy")
+		result, err := t.runCommand(ctx, s, token, "go", "mod", "tidy")
+		changes, restoreErr := restoreModuleFiles(before)
+		if restoreErr != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("read the tidied go.mod and go.sum: %v", restoreErr)), nil
+		}
+		if err != nil {
+			return t.commandFailureResult("go mod tidy", result, err)
+		}
+
+		payload := map[string]any{
+			"changed": len(changes) > 0,
+			"files":   t.summarizeFileChanges(changes),
+			"applied": false,
+		}
+		if !check && len(changes) > 0 {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("write the tidied go.mod and go.sum", err), nil
+			}
+			payload["applied"] = true
+		}
+		if build {
+			sendProgressNotification(ctx, s, token, "Running go build ./...")
+			built, buildErrors, err := t.compileErrors(ctx, s, token, commandSpec{name: "go", args: []string{"build", "-o", os.DevNull, "./..."}})
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return t.commandFailureResult("go build", built, err)
+			}
+			payload["build"] = map[string]any{"ok": len(buildErrors) == 0, "errors": buildErrors}
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	}
+}
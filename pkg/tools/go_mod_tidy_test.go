@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestGoModTidy(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOPROXY", "off")
+	workspace := t.TempDir()
+	untidy := "module example.com/app\ngo 1.22\n"
+	modPath := writeWorkspaceFile(t, workspace, "go.mod", untidy)
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nfunc main() { println(missing) }\n")
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	tidy := func(args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("go_mod_tidy").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "go_mod_tidy", Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("go_mod_tidy: %v %v", err, result)
+		}
+		return structured(result)
+	}
+	readMod := func() string {
+		data, _ := os.ReadFile(modPath)
+		return string(data)
+	}
+
+	checked := tidy(map[string]any{"check": true})
+	files := checked["files"].([]any)
+	if checked["changed"] != true || checked["applied"] != false || checked["build"] != nil || len(files) != 1 {
+		t.Fatalf("unexpected check result %v", checked)
+	}
+	if file := files[0].(map[string]any); file["path"] != "go.mod" || !strings.Contains(file["diff"].(string), "+\n") {
+		t.Fatalf("unexpected diff %v", file)
+	}
+	if readMod() != untidy {
+		t.Fatalf("check must leave go.mod unchanged, got %q", readMod())
+	}
+
+	tidied := tidy(map[string]any{})
+	if tidied["applied"] != true || readMod() != "module example.com/app\n\ngo 1.22\n" {
+		t.Fatalf("expected go.mod to be tidied, got %v %q", tidied, readMod())
+	}
+	build := tidied["build"].(map[string]any)
+	if errs := build["errors"].([]any); build["ok"] != false || len(errs) != 1 || errs[0].(map[string]any)["file"] != "main.go" {
+		t.Fatalf("expected the compile error to be reported, got %v", build)
+	}
+	if again := tidy(map[string]any{"build": false}); again["changed"] != false || again["build"] != nil {
+		t.Fatalf("expected a tidy module to stay unchanged, got %v", again)
+	}
+}
+
+func TestRunGoModTidyGoesThroughEditChecks(t *testing.T) {
+	workspace := t.TempDir()
+	untidy := "module example.com/app\ngo 1.22\n"
+	modPath := writeWorkspaceFile(t, workspace, "go.mod", untidy)
+	tools := NewLSPTools(nil, workspace)
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		return commandResult{}, os.WriteFile(modPath, []byte("module example.com/app\n\ngo 1.22\n"), 0o644)
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	readMod := func() string {
+		data, _ := os.ReadFile(modPath)
+		return string(data)
+	}
+
+	if result := call("edit_contract", map[string]any{"action": "declare", "packages": []any{"./other"}}); result.IsError {
+		t.Fatalf("declare: %v", result)
+	}
+	if result := call("run_go_mod_tidy", map[string]any{"build": false}); structured(result)["error"] != "edit_scope_violation" || readMod() != untidy {
+		t.Fatalf("expected the tidy to be refused by the contract, got %v %q", result, readMod())
+	}
+
+	tools.contract = nil
+	tools.SetApprovalToken("secret")
+	if result := call("run_go_mod_tidy", map[string]any{"build": false}); structured(result)["status"] != "pending_approval" || readMod() != untidy {
+		t.Fatalf("expected the tidy to wait for approval, got %v %q", result, readMod())
+	}
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// moduleWhy is the answer of go mod why -m for one module: the shortest
+// import chain from a package of the main module to a package of it, or
+// the reason there is none.
+type moduleWhy struct {
+	Module string   `json:"module"`
+	Needed bool     `json:"needed"`
+	Chain  []string `json:"chain"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// parseModWhy reads go mod why -m output, a "# module" header followed by
+// the chain of packages, or by a parenthesized note when the main module
+// does not need it.
+func parseModWhy(output string) []moduleWhy {
+	answers := []moduleWhy{}
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "# "):
+			answers = append(answers, moduleWhy{Module: strings.TrimPrefix(line, "# "), Chain: []string{}})
+		case line == "" || len(answers) == 0:
+		case strings.HasPrefix(line, "(") && strings.HasSuffix(line, ")"):
+			answers[len(answers)-1].Reason = strings.TrimSuffix(strings.TrimPrefix(line, "("), ")")
+		default:
+			why := &answers[len(answers)-1]
+			why.Chain = append(why.Chain, line)
+			why.Needed = true
+		}
+	}
+	return answers
+}
+
+func (t *LSPTools) registerGoModWhy(s *server.MCPServer) {
+	tool := mcp.NewTool("go_mod_why",
+		mcp.WithDescription("Run go mod why -m to explain why modules are needed: for each module, the shortest import chain from a package of the main module to a package of the module, as a list of import paths, or needed false with the reason when nothing imports it (an unused requirement go mod tidy would drop). Complements module_graph, which shows the requirement chains between modules"),
+		mcp.WithTitleAnnotation("Go Mod Why"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("module",
+			mcp.Required(),
+			mcp.Description("Module path to explain, or several separated by spaces"),
+		),
+		mcp.WithBoolean("vendor",
+			mcp.Description("Ignore imports in tests of packages in other modules, as go mod vendor does (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		module, err := getStringArg(args, "module")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		modules := strings.Fields(module)
+		if len(modules) == 0 {
+			return mcp.NewToolResultError("module must not be empty"), nil
+		}
+		whyArgs := []string{"mod", "why", "-m"}
+		if getOptionalBoolArg(args, "vendor") {
+			whyArgs = append(whyArgs, "-vendor")
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Running go mod why -m "+strings.Join(modules, " "))
+		result, err := t.runCommand(ctx, s, token, "go", append(whyArgs, modules...)...)
+		if err != nil {
+			return t.commandFailureResult("go mod why", result, err)
+		}
+
+		payload := map[string]any{"modules": parseModWhy(result.Stdout)}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestGoModWhy(t *testing.T) {
+	tools := NewLSPTools(nil, t.TempDir())
+	var ran []string
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		ran = spec.args
+		return commandResult{Stdout: "# golang.org/x/text\nexample.com/app/cmd\ngolang.org/x/text/language\n\n# github.com/unused/mod\n(main module does not need module github.com/unused/mod)\n"}, nil
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	result, err := server.GetTool("go_mod_why").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "go_mod_why", Arguments: map[string]any{"module": "golang.org/x/text github.com/unused/mod", "vendor": true}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("go_mod_why: %v %v", err, result)
+	}
+	if want := []string{"mod", "why", "-m", "-vendor", "golang.org/x/text", "github.com/unused/mod"}; !slices.Equal(ran, want) {
+		t.Fatalf("ran go %v, want %v", ran, want)
+	}
+
+	modules := structured(result)["modules"].([]any)
+	if len(modules) != 2 {
+		t.Fatalf("unexpected modules %v", modules)
+	}
+	text := modules[0].(map[string]any)
+	if text["module"] != "golang.org/x/text" || text["needed"] != true || len(text["chain"].([]any)) != 2 || text["chain"].([]any)[1] != "golang.org/x/text/language" {
+		t.Fatalf("unexpected chain %v", text)
+	}
+	unused := modules[1].(map[string]any)
+	if unused["needed"] != false || len(unused["chain"].([]any)) != 0 || unused["reason"] != "main module does not need module github.com/unused/mod" {
+		t.Fatalf("unexpected unused module %v", unused)
+	}
+}
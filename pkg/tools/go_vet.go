@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// vetPositionPattern splits the file:line:column positions of go vet -json.
+var vetPositionPattern = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?$`)
+
+// vetFinding is a go vet diagnostic with its position in the workspace.
+type vetFinding struct {
+	Package        string   `json:"package"`
+	File           string   `json:"file"`
+	Line           int      `json:"line"`
+	Column         int      `json:"column,omitempty"`
+	EndLine        int      `json:"end_line,omitempty"`
+	EndColumn      int      `json:"end_column,omitempty"`
+	Message        string   `json:"message"`
+	SuggestedFixes []string `json:"suggested_fixes,omitempty"`
+}
+
+// vetJSONDiagnostic is a diagnostic as go vet -json prints it.
+type vetJSONDiagnostic struct {
+	Posn           string `json:"posn"`
+	End            string `json:"end"`
+	Message        string `json:"message"`
+	SuggestedFixes []struct {
+		Message string `json:"message"`
+	} `json:"suggested_fixes"`
+}
+
+func (t *LSPTools) registerGoVet(s *server.MCPServer) {
+	tool := mcp.NewTool("go_vet",
+		mcp.WithDescription("Run go vet -json on packages and return the findings keyed by analyzer (printf, assign, copylocks...), each with its package, file relative to the workspace, line, column, end, message and the titles of the fixes the analyzer suggests. Packages that fail to compile are reported under errors as {file, line, column, message}, the findings of the others still being returned"),
+		mcp.WithTitleAnnotation("Go Vet"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("target",
+			mcp.Description("Package patterns to vet, separated by spaces (default ./...)"),
+		),
+		mcp.WithArray("analyzers",
+			mcp.Description("Only run these analyzers, such as [\"printf\", \"copylocks\"] (default the go vet suite)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("tags",
+			mcp.Description("Comma-separated build tags, passed as -tags"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		targets := strings.Fields(getOptionalStringArg(args, "target"))
+		if len(targets) == 0 {
+			targets = []string{"./..."}
+		}
+		vetArgs := []string{"vet", "-json"}
+		for _, analyzer := range getOptionalStringListArg(args, "analyzers") {
+			if strings.HasPrefix(analyzer, "-") || strings.ContainsAny(analyzer, "= ") {
+				return mcp.NewToolResultError("analyzers must be analyzer names such as printf"), nil
+			}
+			vetArgs = append(vetArgs, "-"+analyzer)
+		}
+		if tags := getOptionalStringArg(args, "tags"); tags != "" {
+			vetArgs = append(vetArgs, "-tags", tags)
+		}
+		vetArgs = append(vetArgs, targets...)
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Running go vet "+strings.Join(targets, " "))
+		result, err := t.runCommand(ctx, s, token, "go", vetArgs...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return t.commandFailureResult("go vet", result, err)
+			}
+		}
+		findings, errs := t.parseVetOutput(result.Stdout + "\n" + result.Stderr)
+		if err != nil && len(errs) == 0 {
+			errs = append(errs, buildError{Message: buildCommandErrorMessage("go vet", result, err)})
+		}
+
+		count := 0
+		for _, list := range findings {
+			count += len(list)
+		}
+		payload := map[string]any{
+			"ok":       count == 0 && len(errs) == 0,
+			"targets":  targets,
+			"findings": findings,
+			"count":    count,
+			"errors":   errs,
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// parseVetOutput reads go vet -json output: JSON objects mapping packages
+// to analyzers to diagnostics, or to {"error": ...} for an analyzer that
+// failed, between the text errors of packages that could not be vetted.
+func (t *LSPTools) parseVetOutput(output string) (map[string][]vetFinding, []buildError) {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	findings := make(map[string][]vetFinding)
+	var text strings.Builder
+	data := []byte(output)
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+		if !bytes.HasPrefix(line, []byte("{")) {
+			text.WriteString(strings.TrimPrefix(string(line), "vet: ") + "\n")
+			data = rest
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		var packages map[string]map[string]json.RawMessage
+		if err := decoder.Decode(&packages); err != nil {
+			text.Write(line)
+			text.WriteString("\n")
+			data = rest
+			continue
+		}
+		data = data[decoder.InputOffset():]
+		for pkg, analyzers := range packages {
+			for analyzer, raw := range analyzers {
+				var diagnostics []vetJSONDiagnostic
+				if json.Unmarshal(raw, &diagnostics) != nil {
+					var failure struct {
+						Error string `json:"error"`
+					}
+					if json.Unmarshal(raw, &failure) == nil && failure.Error != "" {
+						text.WriteString(analyzer + ": " + failure.Error + "\n")
+					}
+					continue
+				}
+				for _, diagnostic := range diagnostics {
+					finding := vetFinding{Package: pkg, Message: diagnostic.Message}
+					finding.File, finding.Line, finding.Column = vetPosition(root, diagnostic.Posn)
+					if _, endLine, endColumn := vetPosition(root, diagnostic.End); endLine != finding.Line || endColumn != finding.Column {
+						finding.EndLine, finding.EndColumn = endLine, endColumn
+					}
+					for _, fix := range diagnostic.SuggestedFixes {
+						finding.SuggestedFixes = append(finding.SuggestedFixes, fix.Message)
+					}
+					findings[analyzer] = append(findings[analyzer], finding)
+				}
+			}
+		}
+	}
+	for _, list := range findings {
+		slices.SortFunc(list, func(a, b vetFinding) int {
+			if a.File != b.File {
+				return strings.Compare(a.File, b.File)
+			}
+			if a.Line != b.Line {
+				return a.Line - b.Line
+			}
+			return a.Column - b.Column
+		})
+	}
+	return findings, t.parseGoBuildOutput(text.String())
+}
+
+// vetPosition splits a go vet position, making the file relative to root.
+func vetPosition(root, position string) (string, int, int) {
+	match := vetPositionPattern.FindStringSubmatch(position)
+	if match == nil {
+		return position, 0, 0
+	}
+	file := match[1]
+	if filepath.IsAbs(file) {
+		file = relativeSlashPath(root, file)
+	}
+	line, _ := strconv.Atoi(match[2])
+	column, _ := strconv.Atoi(match[3])
+	return file, line, column
+}
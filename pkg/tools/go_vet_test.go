@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseVetOutput(t *testing.T) {
+	tools := NewLSPTools(nil, "/work")
+	output := `{
+	"example.com/app": {
+		"printf": [
+			{
+				"posn": "/work/main.go:6:14",
+				"end": "/work/main.go:6:16",
+				"message": "fmt.Printf format %d has arg \"x\" of wrong type string"
+			}
+		],
+		"assign": [
+			{
+				"posn": "/work/main.go:8:2",
+				"end": "/work/main.go:8:2",
+				"message": "self-assignment of x",
+				"suggested_fixes": [{"message": "Remove self-assignment", "edits": []}]
+			}
+		],
+		"buildtag": {"error": "analysis failed"}
+	}
+}
+# example.com/app/sub
+vet: sub/sub.go:3:12: undefined: missing
+`
+	findings, errs := tools.parseVetOutput(output)
+	printf := findings["printf"]
+	if len(printf) != 1 || printf[0].File != "main.go" || printf[0].Line != 6 || printf[0].Column != 14 || printf[0].EndColumn != 16 || printf[0].Package != "example.com/app" {
+		t.Fatalf("unexpected printf findings %+v", printf)
+	}
+	assign := findings["assign"]
+	if len(assign) != 1 || assign[0].EndLine != 0 || len(assign[0].SuggestedFixes) != 1 || assign[0].SuggestedFixes[0] != "Remove self-assignment" {
+		t.Fatalf("unexpected assign findings %+v", assign)
+	}
+	if len(errs) != 2 || errs[0].Message != "buildtag: analysis failed" || errs[1].File != "sub/sub.go" || errs[1].Line != 3 {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+}
+
+func TestGoVet(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Printf(\"%d\\n\", \"x\")\n}\n")
+	writeWorkspaceFile(t, workspace, "clean/clean.go", "package clean\n\nfunc Double(n int) int { return n * 2 }\n")
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	vet := func(args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("go_vet").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "go_vet", Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("go_vet: %v %v", err, result)
+		}
+		return structured(result)
+	}
+
+	if vetted := vet(map[string]any{"target": "./clean"}); vetted["ok"] != true || vetted["count"] != float64(0) {
+		t.Fatalf("expected ./clean to vet clean, got %v", vetted)
+	}
+	vetted := vet(map[string]any{"analyzers": []any{"printf"}})
+	printf, _ := vetted["findings"].(map[string]any)["printf"].([]any)
+	if vetted["ok"] != false || len(printf) != 1 {
+		t.Fatalf("expected one printf finding, got %v", vetted)
+	}
+	if finding := printf[0].(map[string]any); finding["file"] != "main.go" || finding["line"] != float64(6) || !strings.Contains(finding["message"].(string), "wrong type string") {
+		t.Fatalf("unexpected finding %v", finding)
+	}
+}
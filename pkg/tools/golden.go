@@ -0,0 +1,405 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+var (
+	// goldenSwitchPattern matches the flag and environment variable names
+	// tests conventionally use to regenerate expected output.
+	goldenSwitchPattern = regexp.MustCompile(`(?i)update|golden|regen|record|rewrite|overwrite`)
+	// goldenLibraries are third-party golden-file packages that register an
+	// update flag when imported.
+	goldenLibraries = map[string]string{
+		"gotest.tools/v3/golden":       "update",
+		"gotest.tools/golden":          "update",
+		"github.com/sebdah/goldie/v2":  "update",
+		"github.com/sebdah/goldie":     "update",
+		"github.com/google/go-cmdtest": "update",
+	}
+)
+
+type goldenUpdater struct {
+	Package  string            `json:"package"`
+	Flag     string            `json:"flag,omitempty"`
+	Env      string            `json:"env,omitempty"`
+	Location protocol.Location `json:"location"`
+
+	dir string
+}
+
+type goldenChange struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Diff   string `json:"diff,omitempty"`
+
+	change fileChange
+}
+
+func (t *LSPTools) registerUpdateGoldenFiles(s *server.MCPServer) {
+	tool := mcp.NewTool("update_golden_files",
+		mcp.WithDescription("Run tests with each package's golden-file update switch (an -update style flag or UPDATE_GOLDEN style environment variable, detected from the tests), then report which testdata files changed with diffs. The testdata is restored after the run and the updated files are written like other edits (edit contract, approval); with revert, nothing is written and only the diffs are reported"),
+		mcp.WithTitleAnnotation("Update Golden Files"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("package", mcp.Description("Only update packages whose import path starts with this prefix")),
+		mcp.WithString("run", mcp.Description("Only run tests matching this regular expression (go test -run)")),
+		mcp.WithString("flag", mcp.Description("Update flag to pass to every selected package instead of the detected ones (without the leading dash)")),
+		mcp.WithString("env", mcp.Description("Environment variable to set to 1 for every selected package instead of the detected switches")),
+		mcp.WithBoolean("revert", mcp.Description("Leave the original testdata in place and only report the diffs, as a preview (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+		flagName := strings.TrimLeft(getOptionalStringArg(args, "flag"), "-")
+		envName := getOptionalStringArg(args, "env")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		updaters := detectGoldenUpdaters(ws, prefix, flagName, envName)
+		if len(updaters) == 0 {
+			return mcp.NewToolResultError("no golden-file update flag or environment variable found in the selected tests; pass flag or env explicitly"), nil
+		}
+
+		var dirs []string
+		for _, updater := range updaters {
+			if !slices.Contains(dirs, updater.dir) {
+				dirs = append(dirs, updater.dir)
+			}
+		}
+		before, err := snapshotTestdata(dirs)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("snapshot testdata: %v", err)), nil
+		}
+		modes, err := testdataModes(before)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("snapshot testdata: %v", err)), nil
+		}
+
+		runs := []commandResult{}
+		for _, spec := range goldenCommands(t.workspaceDir, updaters, getOptionalStringArg(args, "run")) {
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Running %s", strings.Join(append([]string{spec.name}, spec.args...), " ")))
+			// Failing tests still leave their rewritten files behind, so
+			// the diff is reported either way.
+			result, _ := t.runCommandSpec(ctx, s, token, spec)
+			runs = append(runs, result)
+		}
+
+		after, err := snapshotTestdata(dirs)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("snapshot testdata: %v", err)), nil
+		}
+		changes := diffTestdata(t.workspaceDir, before, after)
+		// The tests wrote the files themselves; put the testdata back so the
+		// updates go through writeFileChanges like any other edit.
+		if err := restoreTestdata(before, after, modes); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("restore testdata: %v", err)), nil
+		}
+
+		revert := getOptionalBoolArg(args, "revert")
+		applied := false
+		if !revert && len(changes) > 0 {
+			updates := make([]fileChange, 0, len(changes))
+			for _, change := range changes {
+				updates = append(updates, change.change)
+			}
+			if err := t.writeFileChanges(ctx, updates); err != nil {
+				return writeFailureResult("write golden files", err), nil
+			}
+			applied = true
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"updaters":     updaters,
+			"runs":         runs,
+			"changed":      changes,
+			"reverted":     revert && len(changes) > 0,
+			"applied":      applied,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// detectGoldenUpdaters finds, per package, the flag.Bool or os.Getenv
+// switch its tests read to rewrite golden files, whether declared in the
+// tests, in an imported workspace helper package, or by a known golden-file
+// library. Explicit flag and env values apply to every selected package
+// that has tests.
+func detectGoldenUpdaters(ws *gosrc.Workspace, prefix, flagName, envName string) []goldenUpdater {
+	// Switches declared in non-test files take effect in every test binary
+	// importing their package (testutil.Update and the like).
+	helperSwitches := make(map[string][]goldenUpdater)
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			if !file.Test {
+				helperSwitches[pkg.ImportPath] = append(helperSwitches[pkg.ImportPath], goldenSwitches(file)...)
+			}
+		}
+	}
+
+	var updaters []goldenUpdater
+	seen := make(map[string]bool)
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		add := func(updater goldenUpdater) {
+			updater.Package, updater.dir = strings.TrimSuffix(pkg.ImportPath, "_test"), pkg.Dir
+			key := updater.dir + "\x00" + updater.Flag + "\x00" + updater.Env
+			if !seen[key] {
+				seen[key] = true
+				updaters = append(updaters, updater)
+			}
+		}
+		for _, file := range pkg.Files {
+			if !file.Test {
+				continue
+			}
+			if flagName != "" || envName != "" {
+				add(goldenUpdater{Flag: flagName, Env: envName})
+				break
+			}
+			for _, updater := range goldenSwitches(file) {
+				add(updater)
+			}
+			for _, importPath := range fileImports(file.Syntax) {
+				for _, updater := range helperSwitches[importPath] {
+					add(updater)
+				}
+				if flag := goldenLibraries[importPath]; flag != "" {
+					add(goldenUpdater{Flag: flag})
+				}
+			}
+		}
+	}
+	return updaters
+}
+
+// goldenSwitches lists the update flags and environment variables a file
+// reads.
+func goldenSwitches(file *gosrc.File) []goldenUpdater {
+	imports := fileImports(file.Syntax)
+	var switches []goldenUpdater
+	ast.Inspect(file.Syntax, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		importPath, name, ok := packageCall(imports, call)
+		if !ok {
+			return true
+		}
+		nameArg := -1
+		switch {
+		case importPath == "flag" && name == "Bool":
+			nameArg = 0
+		case importPath == "flag" && name == "BoolVar":
+			nameArg = 1
+		case importPath == "os" && (name == "Getenv" || name == "LookupEnv"):
+			nameArg = 0
+		}
+		if nameArg < 0 || nameArg >= len(call.Args) {
+			return true
+		}
+		value, ok := stringValue(call.Args[nameArg])
+		if !ok || !goldenSwitchPattern.MatchString(value) {
+			return true
+		}
+		updater := goldenUpdater{Location: sourceLocation(file, call.Pos(), call.End())}
+		if importPath == "flag" {
+			updater.Flag = value
+		} else {
+			updater.Env = value
+		}
+		switches = append(switches, updater)
+		return true
+	})
+	return switches
+}
+
+// goldenCommands groups packages sharing a switch into one go test run.
+// -count=1 matters: a cached result would skip the tests and write nothing.
+// Flags are only passed to packages declaring them, since the test binary
+// rejects unknown flags.
+func goldenCommands(root string, updaters []goldenUpdater, run string) []commandSpec {
+	type group struct {
+		flag, env string
+		dirs      []string
+	}
+	var groups []*group
+	for _, updater := range updaters {
+		var g *group
+		for _, candidate := range groups {
+			if candidate.flag == updater.Flag && candidate.env == updater.Env {
+				g = candidate
+			}
+		}
+		if g == nil {
+			g = &group{flag: updater.Flag, env: updater.Env}
+			groups = append(groups, g)
+		}
+		target := "./" + relativeSlashPath(root, updater.dir)
+		if target == "./." {
+			target = "."
+		}
+		if !slices.Contains(g.dirs, target) {
+			g.dirs = append(g.dirs, target)
+		}
+	}
+
+	specs := make([]commandSpec, 0, len(groups))
+	for _, g := range groups {
+		args := []string{"test", "-count=1"}
+		if run != "" {
+			args = append(args, "-run", run)
+		}
+		args = append(args, g.dirs...)
+		if g.flag != "" {
+			args = append(args, "-"+g.flag)
+		}
+		spec := commandSpec{name: "go", args: args}
+		if g.env != "" {
+			spec.env = []string{g.env + "=1"}
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// snapshotTestdata reads every file below the testdata directories of dirs.
+func snapshotTestdata(dirs []string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, dir := range dirs {
+		root := filepath.Join(dir, "testdata")
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root && os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files[path] = data
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// testdataModes returns the permissions of the files of a snapshot, so
+// that restoring them keeps executable fixtures executable.
+func testdataModes(files map[string][]byte) (map[string]fs.FileMode, error) {
+	modes := make(map[string]fs.FileMode, len(files))
+	for path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modes[path] = info.Mode().Perm()
+	}
+	return modes, nil
+}
+
+// restoreTestdata puts the files of the before snapshot back with their
+// modes and removes the files only the after snapshot has.
+func restoreTestdata(before, after map[string][]byte, modes map[string]fs.FileMode) error {
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	for path, content := range before {
+		if updated, ok := after[path]; ok && bytes.Equal(content, updated) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		mode, ok := modes[path]
+		if !ok {
+			mode = 0o644
+		}
+		if err := os.WriteFile(path, content, mode); err != nil {
+			return err
+		}
+		// WriteFile only applies mode to the files it creates.
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffTestdata compares two snapshots. Each result carries the change that
+// turns the before snapshot into the after one.
+func diffTestdata(root string, before, after map[string][]byte) []goldenChange {
+	changes := []goldenChange{}
+	paths := sortedKeys(before)
+	for _, path := range sortedKeys(after) {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		old, existed := before[path]
+		updated, exists := after[path]
+		rel := relativeSlashPath(root, path)
+		change := goldenChange{Path: rel}
+		switch {
+		case existed && exists:
+			if bytes.Equal(old, updated) {
+				continue
+			}
+			change.Status = "modified"
+			change.change = fileChange{path: path, before: old, after: updated}
+		case exists:
+			change.Status = "added"
+			change.change = fileChange{path: path, after: updated, created: true}
+		default:
+			change.Status = "deleted"
+			change.change = fileChange{path: path, before: old, deleted: true}
+		}
+		if bytes.IndexByte(old, 0) >= 0 || bytes.IndexByte(updated, 0) >= 0 {
+			change.Diff = "binary file changed"
+		} else {
+			change.Diff = textedit.Unified(rel, old, updated)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestDetectGoldenUpdaters(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "render/render_test.go", `package render
+
+import (
+	"flag"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestRender(t *testing.T) {}
+`)
+	writeWorkspaceFile(t, workspace, "parse/parse_test.go", `package parse
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		t.Log("updating")
+	}
+}
+`)
+	writeWorkspaceFile(t, workspace, "internal/testutil/golden.go", `package testutil
+
+import "flag"
+
+var Regenerate = flag.Bool("regen", false, "")
+`)
+	writeWorkspaceFile(t, workspace, "export/export_test.go", `package export_test
+
+import (
+	"testing"
+
+	"example.com/app/internal/testutil"
+)
+
+func TestExport(t *testing.T) { _ = testutil.Regenerate }
+`)
+	writeWorkspaceFile(t, workspace, "plain/plain_test.go", "package plain\n\nimport \"testing\"\n\nfunc TestPlain(t *testing.T) {}\n")
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	updaters := detectGoldenUpdaters(ws, "", "", "")
+	var got []string
+	for _, updater := range updaters {
+		got = append(got, updater.Package+" flag="+updater.Flag+" env="+updater.Env)
+	}
+	want := []string{
+		"example.com/app/export flag=regen env=",
+		"example.com/app/parse flag= env=UPDATE_GOLDEN",
+		"example.com/app/render flag=update env=",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected updaters:\n got %v\nwant %v", got, want)
+	}
+
+	var commands []string
+	for _, spec := range goldenCommands(workspace, updaters, "TestX") {
+		commands = append(commands, strings.Join(spec.env, " ")+" "+spec.name+" "+strings.Join(spec.args, " "))
+	}
+	wantCommands := []string{
+		" go test -count=1 -run TestX ./export -regen",
+		"UPDATE_GOLDEN=1 go test -count=1 -run TestX ./parse",
+		" go test -count=1 -run TestX ./render -update",
+	}
+	if !reflect.DeepEqual(commands, wantCommands) {
+		t.Fatalf("unexpected commands:\n got %v\nwant %v", commands, wantCommands)
+	}
+
+	overridden := detectGoldenUpdaters(ws, "example.com/app/plain", "update", "")
+	if len(overridden) != 1 || overridden[0].Flag != "update" {
+		t.Fatalf("expected explicit flag to apply to plain, got %+v", overridden)
+	}
+}
+
+func TestDiffAndRestoreTestdata(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "render/testdata/page.golden", "<p>old</p>\n")
+	stale := writeWorkspaceFile(t, workspace, "render/testdata/stale.golden", "gone\n")
+	if err := os.Chmod(stale, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dirs := []string{filepath.Join(workspace, "render")}
+
+	before, err := snapshotTestdata(dirs)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	modes, err := testdataModes(before)
+	if err != nil {
+		t.Fatalf("modes: %v", err)
+	}
+	// Simulate a test run with the update flag.
+	writeWorkspaceFile(t, workspace, "render/testdata/page.golden", "<p>new</p>\n")
+	writeWorkspaceFile(t, workspace, "render/testdata/extra.golden", "added\n")
+	if err := os.Remove(filepath.Join(workspace, "render/testdata/stale.golden")); err != nil {
+		t.Fatal(err)
+	}
+	after, err := snapshotTestdata(dirs)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	changes := diffTestdata(workspace, before, after)
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Path+":"+change.Status)
+	}
+	want := []string{"render/testdata/extra.golden:added", "render/testdata/page.golden:modified", "render/testdata/stale.golden:deleted"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected changes: %v", got)
+	}
+	if !strings.Contains(changes[1].Diff, "-<p>old</p>\n+<p>new</p>") {
+		t.Fatalf("unexpected diff:\n%s", changes[1].Diff)
+	}
+
+	if err := restoreTestdata(before, after, modes); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if info, err := os.Stat(stale); err != nil || info.Mode().Perm() != 0o755 {
+		t.Fatalf("expected the deleted fixture to be restored executable, got %v %v", info, err)
+	}
+	restored, err := snapshotTestdata(dirs)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(restored, before) {
+		t.Fatalf("restore did not put the testdata back: %v", restored)
+	}
+
+	tools := NewLSPTools(nil, workspace)
+	tools.SetApprovalToken("secret")
+	var updates []fileChange
+	for _, change := range changes {
+		updates = append(updates, change.change)
+	}
+	var pendingErr *pendingApprovalError
+	if err := tools.writeFileChanges(context.Background(), updates); !errors.As(err, &pendingErr) {
+		t.Fatalf("expected the updates to wait for approval, got %v", err)
+	}
+	if queued, _ := snapshotTestdata(dirs); !reflect.DeepEqual(queued, before) {
+		t.Fatalf("queued updates must leave the testdata alone: %v", queued)
+	}
+	if err := tools.writeFileChanges(context.WithValue(context.Background(), approvedWriteKey{}, true), updates); err != nil {
+		t.Fatalf("write updates: %v", err)
+	}
+	if updated, _ := snapshotTestdata(dirs); !reflect.DeepEqual(updated, after) {
+		t.Fatalf("the updates did not reproduce the test run: %v", updated)
+	}
+}
+
+func TestUpdateGoldenFilesHonoursEditContract(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "render/render_test.go", "package render\n\nimport (\n\t\"flag\"\n\t\"testing\"\n)\n\nvar update = flag.Bool(\"update\", false, \"rewrite golden files\")\n\nfunc TestRender(t *testing.T) {}\n")
+	golden := writeWorkspaceFile(t, workspace, "render/testdata/page.golden", "<p>old</p>\n")
+	writeWorkspaceFile(t, workspace, "Makefile", "build:\n\tgo build ./...\n")
+	fakeClient := &fakeLSPClient{commands: map[string][]protocol.WorkspaceEdit{"gopls.generate": nil}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	var ran []string
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		ran = append(ran, spec.name)
+		// The tests rewrite the golden file themselves.
+		return commandResult{}, os.WriteFile(golden, []byte("<p>new</p>\n"), 0o644)
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+
+	if result := call("edit_contract", map[string]any{"action": "declare", "packages": []any{"./other"}}); result.IsError {
+		t.Fatalf("declare: %v", result)
+	}
+	result := call("update_golden_files", map[string]any{})
+	if !result.IsError || structured(result)["error"] != "edit_scope_violation" {
+		t.Fatalf("expected the golden update to be refused by the contract, got %v", result)
+	}
+	if data, _ := os.ReadFile(golden); string(data) != "<p>old</p>\n" {
+		t.Fatalf("a refused golden update must leave the testdata alone, got %q", data)
+	}
+
+	ran = nil
+	if result := call("gopls_command", map[string]any{"command": "gopls.generate"}); !result.IsError {
+		t.Fatal("expected gopls.generate to be refused under a narrow contract")
+	}
+	if result := call("run_build_target", map[string]any{"target": "build"}); !result.IsError {
+		t.Fatal("expected run_build_target to be refused under a narrow contract")
+	}
+	if len(fakeClient.executed) != 0 || len(ran) != 0 {
+		t.Fatalf("refused commands must not run, executed %v and %v", fakeClient.executed, ran)
+	}
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// goplsCommandTools are the dedicated tools covering gopls commands, shown
+// next to them when listing so they are preferred over the passthrough.
+var goplsCommandTools = map[string]string{
+	"gopls.add_test":         "add_test",
+	"gopls.apply_fix":        "apply_code_action",
+	"gopls.assembly":         "show_assembly",
+	"gopls.change_signature": "change_signature",
+	"gopls.gc_details":       "gc_details",
+	"gopls.modify_tags":      "modify_struct_tags",
+	"gopls.run_govulncheck":  "vulncheck",
+	"gopls.run_tests":        "run_go_test",
+	"gopls.test":             "run_go_test",
+	"gopls.tidy":             "go_mod_tidy",
+	"gopls.vulncheck":        "vulncheck",
+}
+
+// goplsCommand is a command gopls advertises, with the tool wrapping it.
+type goplsCommand struct {
+	Name string `json:"name"`
+	Tool string `json:"tool,omitempty"`
+}
+
+func (t *LSPTools) registerGoplsCommand(s *server.MCPServer) {
+	tool := mcp.NewTool("gopls_command",
+		mcp.WithDescription("List the commands gopls advertises for workspace/executeCommand, or execute one with JSON arguments: an escape hatch for gopls features without a dedicated tool (prefer the tool shown next to a command when there is one). Returns the command result, and the edits it sends back as a unified diff per file, written when apply is true. Commands may also change files or run go commands themselves, such as gopls.tidy or gopls.vendor rewriting go.mod, go.sum and vendor/; while edits wait for approval only the commands that just send edits back run"),
+		mcp.WithTitleAnnotation("Gopls Command"),
+		mcp.WithString("command",
+			mcp.Description("Command to execute, such as gopls.list_known_packages; list the commands when omitted"),
+		),
+		mcp.WithArray("arguments",
+			mcp.Description("Arguments of the command; gopls commands take a single object, such as [{\"URI\": \"file:///path/to/file.go\"}]. An object or JSON text is accepted as that single argument"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edits the command sends back instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		advertised := lspClient.Commands()
+
+		name := getOptionalStringArg(args, "command")
+		if name == "" {
+			commands := make([]goplsCommand, 0, len(advertised))
+			for _, command := range advertised {
+				commands = append(commands, goplsCommand{Name: command, Tool: goplsCommandTools[command]})
+			}
+			result, err := mcp.NewToolResultJSON(map[string]any{"commands": commands, "count": len(commands)})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+		if len(advertised) > 0 && !slices.Contains(advertised, name) {
+			return mcp.NewToolResultError(fmt.Sprintf("gopls does not advertise %s; call gopls_command without a command to list the %d it does", name, len(advertised))), nil
+		}
+		if t.approvals != nil && !editOnlyCommands[name] {
+			return mcp.NewToolResultError(fmt.Sprintf("%s may change files or run go commands itself, bypassing the approval queue; it cannot run while edits wait for approval", name)), nil
+		}
+		if !editOnlyCommands[name] {
+			if err := t.checkCommandContract(name); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		arguments, err := commandArguments(args["arguments"])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		raw, edits, err := lspClient.RunCommand(ctx, protocol.Command{Command: name, Arguments: arguments})
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		var value any
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("decode %s result: %w", name, err)
+			}
+		}
+		changes, err := workspaceEditChanges(mergeWorkspaceEdits(edits))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compute %s diff: %v", name, err)), nil
+		}
+		apply := getOptionalBoolArg(args, "apply") && len(changes) > 0
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply "+name, err), nil
+			}
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"command":   name,
+			"arguments": arguments,
+			"result":    value,
+			"files":     t.summarizeFileChanges(changes),
+			"applied":   apply,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// commandArguments accepts the arguments of a command as an array, as the
+// single object most gopls commands take, or as JSON text of either.
+func commandArguments(value any) ([]any, error) {
+	if text, ok := value.(string); ok {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, nil
+		}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("arguments are not valid JSON: %v", err)
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	case map[string]any:
+		return []any{v}, nil
+	}
+	return nil, fmt.Errorf("arguments must be an array or an object, got %T", value)
+}
@@ -0,0 +1,395 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+type grpcMethod struct {
+	Name      string             `json:"name"`
+	Streaming bool               `json:"streaming"`
+	Location  protocol.Location  `json:"location"`
+	Handler   *protocol.Location `json:"handler,omitempty"`
+}
+
+type grpcImplementation struct {
+	Type         string              `json:"type"`
+	Package      string              `json:"package"`
+	Location     *protocol.Location  `json:"location,omitempty"`
+	RegisteredAt []protocol.Location `json:"registered_at,omitempty"`
+	Methods      []grpcMethod        `json:"methods"`
+	Missing      []string            `json:"missing,omitempty"`
+}
+
+type grpcService struct {
+	Name            string                `json:"name"`
+	Interface       string                `json:"interface"`
+	Package         string                `json:"package"`
+	Location        protocol.Location     `json:"location"`
+	Methods         []grpcMethod          `json:"methods"`
+	Implementations []*grpcImplementation `json:"implementations"`
+}
+
+func (t *LSPTools) registerListGRPCServices(s *server.MCPServer) {
+	tool := mcp.NewTool("list_grpc_services",
+		mcp.WithDescription("List generated gRPC service interfaces and their implementations, mapping each RPC method to its handler with locations"),
+		mcp.WithTitleAnnotation("List gRPC Services"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"services": collectGRPCServices(ws),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// typeInfo records where a named type is declared and the methods declared
+// on it.
+type typeInfo struct {
+	location protocol.Location
+	embeds   []string
+	methods  map[string]protocol.Location
+}
+
+func collectGRPCServices(ws *gosrc.Workspace) []*grpcService {
+	typesByKey := make(map[string]*typeInfo)
+	typeOf := func(key string) *typeInfo {
+		info, ok := typesByKey[key]
+		if !ok {
+			info = &typeInfo{methods: make(map[string]protocol.Location)}
+			typesByKey[key] = info
+		}
+		return info
+	}
+
+	var services []*grpcService
+	serviceByKey := make(map[string]*grpcService)
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			generated := ast.IsGenerated(file.Syntax)
+			for _, decl := range file.Syntax.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv != nil && len(d.Recv.List) > 0 {
+						recv := receiverTypeName(d.Recv.List[0].Type)
+						typeOf(pkg.ImportPath + "." + recv).methods[d.Name.Name] = sourceLocation(file, d.Name.Pos(), d.Name.End())
+					}
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						ts, ok := spec.(*ast.TypeSpec)
+						if !ok {
+							continue
+						}
+						info := typeOf(pkg.ImportPath + "." + ts.Name.Name)
+						info.location = sourceLocation(file, ts.Name.Pos(), ts.Name.End())
+						if st, ok := ts.Type.(*ast.StructType); ok {
+							for _, field := range st.Fields.List {
+								if len(field.Names) == 0 {
+									info.embeds = append(info.embeds, receiverTypeName(field.Type))
+								}
+							}
+						}
+						if !generated {
+							continue
+						}
+						if svc := grpcServiceFromInterface(pkg, file, ts); svc != nil {
+							services = append(services, svc)
+							serviceByKey[pkg.ImportPath+"."+svc.Interface] = svc
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(services) == 0 {
+		return []*grpcService{}
+	}
+
+	// Full service names come from the generated grpc.ServiceDesc.
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			ast.Inspect(file.Syntax, func(n ast.Node) bool {
+				lit, ok := n.(*ast.CompositeLit)
+				if !ok {
+					return true
+				}
+				var name, handlerType string
+				for _, elt := range lit.Elts {
+					kv, ok := elt.(*ast.KeyValueExpr)
+					if !ok {
+						continue
+					}
+					switch key, _ := kv.Key.(*ast.Ident); {
+					case key == nil:
+					case key.Name == "ServiceName":
+						name, _ = stringValue(kv.Value)
+					case key.Name == "HandlerType":
+						// HandlerType: (*GreeterServer)(nil)
+						value := unwrapParens(kv.Value)
+						if conv, ok := value.(*ast.CallExpr); ok {
+							value = unwrapParens(conv.Fun)
+						}
+						handlerType = receiverTypeName(value)
+					}
+				}
+				if svc, ok := serviceByKey[pkg.ImportPath+"."+handlerType]; ok && name != "" {
+					svc.Name = name
+				}
+				return true
+			})
+		}
+	}
+
+	implementations := make(map[*grpcService]map[string]*grpcImplementation)
+	implementationFor := func(svc *grpcService, pkgPath, typeName string) *grpcImplementation {
+		if implementations[svc] == nil {
+			implementations[svc] = make(map[string]*grpcImplementation)
+		}
+		key := pkgPath + "." + typeName
+		impl, ok := implementations[svc][key]
+		if !ok {
+			impl = &grpcImplementation{Type: typeName, Package: pkgPath}
+			implementations[svc][key] = impl
+			svc.Implementations = append(svc.Implementations, impl)
+		}
+		return impl
+	}
+
+	// Implementations embed Unimplemented<Service>Server...
+	for key, info := range typesByKey {
+		for _, embedded := range info.embeds {
+			if !strings.HasPrefix(embedded, "Unimplemented") {
+				continue
+			}
+			iface := strings.TrimPrefix(embedded, "Unimplemented")
+			for svcKey, svc := range serviceByKey {
+				if strings.HasSuffix(svcKey, "."+iface) {
+					dot := strings.LastIndex(key, ".")
+					implementationFor(svc, key[:dot], key[dot+1:])
+				}
+			}
+		}
+	}
+
+	// ...and are passed to Register<Service>Server.
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			if ast.IsGenerated(file.Syntax) {
+				continue
+			}
+			imports := fileImports(file.Syntax)
+			ast.Inspect(file.Syntax, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) != 2 {
+					return true
+				}
+				var (
+					fnName  string
+					svcPkg  = pkg.ImportPath
+					funcSel = call.Fun
+				)
+				switch fn := funcSel.(type) {
+				case *ast.Ident:
+					fnName = fn.Name
+				case *ast.SelectorExpr:
+					ident, ok := fn.X.(*ast.Ident)
+					if !ok {
+						return true
+					}
+					svcPkg = imports[ident.Name]
+					fnName = fn.Sel.Name
+				}
+				if !strings.HasPrefix(fnName, "Register") || !strings.HasSuffix(fnName, "Server") {
+					return true
+				}
+				svc, ok := serviceByKey[svcPkg+"."+strings.TrimPrefix(fnName, "Register")]
+				if !ok {
+					return true
+				}
+				implPkg, typeName := implementationType(pkg.ImportPath, imports, ws, call.Args[1])
+				if typeName == "" {
+					typeName = exprText(call.Args[1])
+				}
+				impl := implementationFor(svc, implPkg, typeName)
+				impl.RegisteredAt = append(impl.RegisteredAt, sourceLocation(file, call.Pos(), call.End()))
+				return true
+			})
+		}
+	}
+
+	for _, svc := range services {
+		if svc.Name == "" {
+			svc.Name = strings.TrimSuffix(svc.Interface, "Server")
+		}
+		if svc.Implementations == nil {
+			svc.Implementations = []*grpcImplementation{}
+		}
+		for _, impl := range svc.Implementations {
+			info := typesByKey[impl.Package+"."+impl.Type]
+			if info != nil && info.location.URI != "" {
+				loc := info.location
+				impl.Location = &loc
+			}
+			impl.Methods = []grpcMethod{}
+			for _, method := range svc.Methods {
+				mapped := method
+				mapped.Handler = nil
+				if info != nil {
+					if loc, ok := info.methods[method.Name]; ok {
+						mapped.Handler = &loc
+					}
+				}
+				if mapped.Handler == nil {
+					impl.Missing = append(impl.Missing, method.Name)
+				}
+				impl.Methods = append(impl.Methods, mapped)
+			}
+		}
+		sort.Slice(svc.Implementations, func(i, j int) bool {
+			return svc.Implementations[i].Package+"."+svc.Implementations[i].Type < svc.Implementations[j].Package+"."+svc.Implementations[j].Type
+		})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+// grpcServiceFromInterface recognises protoc-gen-go-grpc server interfaces:
+// a generated <Name>Server interface whose registration function
+// Register<Name>Server lives in the same file.
+func grpcServiceFromInterface(pkg *gosrc.Package, file *gosrc.File, ts *ast.TypeSpec) *grpcService {
+	iface, ok := ts.Type.(*ast.InterfaceType)
+	if !ok || !strings.HasSuffix(ts.Name.Name, "Server") {
+		return nil
+	}
+	registered := false
+	for _, decl := range file.Syntax.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "Register"+ts.Name.Name {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return nil
+	}
+
+	svc := &grpcService{
+		Interface: ts.Name.Name,
+		Package:   pkg.ImportPath,
+		Location:  sourceLocation(file, ts.Name.Pos(), ts.Name.End()),
+		Methods:   []grpcMethod{},
+	}
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			continue
+		}
+		name := field.Names[0].Name
+		if strings.HasPrefix(name, "mustEmbedUnimplemented") {
+			continue
+		}
+		svc.Methods = append(svc.Methods, grpcMethod{
+			Name:      name,
+			Streaming: isStreamingSignature(fn),
+			Location:  sourceLocation(file, field.Names[0].Pos(), field.Names[0].End()),
+		})
+	}
+	return svc
+}
+
+// isStreamingSignature reports whether a generated server method takes a
+// stream instead of a context: unary methods are (context.Context, *Req).
+func isStreamingSignature(fn *ast.FuncType) bool {
+	if fn.Params == nil || len(fn.Params.List) == 0 {
+		return false
+	}
+	first := fn.Params.List[0].Type
+	if sel, ok := first.(*ast.SelectorExpr); ok && sel.Sel.Name == "Context" {
+		return false
+	}
+	return true
+}
+
+func unwrapParens(expr ast.Expr) ast.Expr {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
+
+// implementationType infers the named type of the value registered with a
+// gRPC server: composite literals, &T{}, conversions and constructor calls
+// whose declared result is a named type.
+func implementationType(pkgPath string, imports map[string]string, ws *gosrc.Workspace, expr ast.Expr) (string, string) {
+	switch e := unwrapParens(expr).(type) {
+	case *ast.UnaryExpr:
+		return implementationType(pkgPath, imports, ws, e.X)
+	case *ast.CompositeLit:
+		return qualifiedTypeName(pkgPath, imports, e.Type)
+	case *ast.CallExpr:
+		fnPkg, fnName := qualifiedTypeName(pkgPath, imports, e.Fun)
+		if fnName == "" {
+			return "", ""
+		}
+		if fn, fnImports := findFuncDecl(ws, fnPkg, fnName); fn != nil && fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
+			return qualifiedTypeName(fnPkg, fnImports, fn.Type.Results.List[0].Type)
+		}
+		if fnName == "new" && len(e.Args) == 1 {
+			return qualifiedTypeName(pkgPath, imports, e.Args[0])
+		}
+	}
+	return "", ""
+}
+
+func qualifiedTypeName(pkgPath string, imports map[string]string, expr ast.Expr) (string, string) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return qualifiedTypeName(pkgPath, imports, e.X)
+	case *ast.Ident:
+		return pkgPath, e.Name
+	case *ast.SelectorExpr:
+		if ident, ok := e.X.(*ast.Ident); ok {
+			if importPath, ok := imports[ident.Name]; ok {
+				return importPath, e.Sel.Name
+			}
+		}
+	}
+	return "", ""
+}
+
+// findFuncDecl returns the package-level function declaration and the
+// imports of its file.
+func findFuncDecl(ws *gosrc.Workspace, pkgPath, name string) (*ast.FuncDecl, map[string]string) {
+	for _, pkg := range ws.Packages {
+		if pkg.ImportPath != pkgPath {
+			continue
+		}
+		for _, file := range pkg.Files {
+			for _, decl := range file.Syntax.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name {
+					return fn, fileImports(file.Syntax)
+				}
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCollectGRPCServices(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/svc\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "gen/greeter_grpc.pb.go", `// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package gen
+
+import (
+	"context"
+
+	grpc "google.golang.org/grpc"
+)
+
+type GreeterServer interface {
+	SayHello(context.Context, *HelloRequest) (*HelloReply, error)
+	StreamHellos(*HelloRequest, Greeter_StreamHellosServer) error
+	mustEmbedUnimplementedGreeterServer()
+}
+
+type UnimplementedGreeterServer struct{}
+
+func RegisterGreeterServer(s grpc.ServiceRegistrar, srv GreeterServer) {}
+
+var Greeter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helloworld.Greeter",
+	HandlerType: (*GreeterServer)(nil),
+}
+`)
+	writeWorkspaceFile(t, workspace, "server/server.go", `package server
+
+import (
+	"context"
+
+	"example.com/svc/gen"
+)
+
+type greeter struct {
+	gen.UnimplementedGreeterServer
+}
+
+func (g *greeter) SayHello(ctx context.Context, req *gen.HelloRequest) (*gen.HelloReply, error) {
+	return nil, nil
+}
+
+func newGreeter() *greeter { return &greeter{} }
+
+func Register(s any) {
+	gen.RegisterGreeterServer(nil, newGreeter())
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	services := collectGRPCServices(ws)
+	if len(services) != 1 {
+		t.Fatalf("expected one service, got %#v", services)
+	}
+	svc := services[0]
+	if svc.Name != "helloworld.Greeter" || svc.Interface != "GreeterServer" || len(svc.Methods) != 2 {
+		t.Fatalf("unexpected service %#v", svc)
+	}
+	if svc.Methods[0].Streaming || !svc.Methods[1].Streaming {
+		t.Fatalf("unexpected streaming flags %#v", svc.Methods)
+	}
+	if len(svc.Implementations) != 1 {
+		t.Fatalf("expected one implementation, got %#v", svc.Implementations)
+	}
+	impl := svc.Implementations[0]
+	if impl.Type != "greeter" || impl.Package != "example.com/svc/server" || impl.Location == nil {
+		t.Fatalf("unexpected implementation %#v", impl)
+	}
+	if len(impl.RegisteredAt) != 1 || impl.RegisteredAt[0].Range.Start.Line != 19 {
+		t.Fatalf("unexpected registration %#v", impl.RegisteredAt)
+	}
+	if impl.Methods[0].Handler == nil || impl.Methods[0].Handler.Range.Start.Line != 12 {
+		t.Fatalf("expected SayHello handler, got %#v", impl.Methods[0])
+	}
+	if len(impl.Missing) != 1 || impl.Missing[0] != "StreamHellos" {
+		t.Fatalf("unexpected missing methods %#v", impl.Missing)
+	}
+}
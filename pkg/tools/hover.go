@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var (
+	// hoverLinkPattern matches the documentation link gopls ends its hover
+	// with, such as [`fmt.Println` on pkg.go.dev](https://pkg.go.dev/fmt#Println).
+	hoverLinkPattern  = regexp.MustCompile("^\\[(?:`([^`]+)`)?[^\\]]*\\]\\((\\S+)\\)$")
+	hoverFencePattern = regexp.MustCompile("(?s)^```[A-Za-z0-9]*\\n(.*?)\\n?```$")
+)
+
+// hoverInfo is a gopls hover split into its parts. Details holds the
+// further code blocks some symbols get, such as the fields and methods of a
+// type.
+type hoverInfo struct {
+	Symbol     string   `json:"symbol,omitempty"`
+	Signature  string   `json:"signature,omitempty"`
+	Doc        string   `json:"doc,omitempty"`
+	Deprecated string   `json:"deprecated,omitempty"`
+	Details    []string `json:"details,omitempty"`
+	Link       string   `json:"link,omitempty"`
+}
+
+func (t *LSPTools) registerStructuredHover(s *server.MCPServer) {
+	tool := mcp.NewTool("hover",
+		mcp.WithDescription("Get the documentation of the symbol at a position as structured fields: its declaration signature, its doc comment, any deprecation notice, the fields or methods gopls lists for types, and the link to its pkg.go.dev page, rather than the raw markdown of get_hover_info"),
+		mcp.WithTitleAnnotation("Hover"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position of the symbol"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		markdown, err := lspClient.GetHover(ctx, fileURI, line, character)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri": fileURI,
+			"hover":    parseHover(markdown),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// parseHover splits the markdown of a gopls hover: a go code block with the
+// declaration, then sections separated by horizontal rules holding the doc
+// comment, more code blocks, and the documentation link.
+func parseHover(markdown string) hoverInfo {
+	var info hoverInfo
+	var doc []string
+	for i, section := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n---\n") {
+		section = strings.TrimSpace(section)
+		// The first section may be the declaration followed by the doc
+		// comment.
+		if i == 0 && strings.HasPrefix(section, "```") {
+			if end := strings.Index(section[3:], "```"); end >= 0 {
+				info.Signature = fencedCode(section[:end+6])
+				section = strings.TrimSpace(section[end+6:])
+			}
+		}
+		switch match := hoverLinkPattern.FindStringSubmatch(section); {
+		case section == "":
+		case match != nil:
+			info.Symbol, info.Link = match[1], match[2]
+		case hoverFencePattern.MatchString(section):
+			info.Details = append(info.Details, fencedCode(section))
+		default:
+			doc = append(doc, section)
+		}
+	}
+	info.Doc = strings.Join(doc, "\n\n")
+	info.Deprecated = deprecation(info.Doc)
+	return info
+}
+
+// deprecation returns the text of the Deprecated: paragraph of a doc
+// comment, on one line.
+func deprecation(doc string) string {
+	var notice string
+	for paragraph := range strings.SplitSeq(doc, "\n\n") {
+		if strings.HasPrefix(paragraph, "Deprecated: ") {
+			notice = strings.Join(strings.Fields(strings.TrimPrefix(paragraph, "Deprecated: ")), " ")
+		}
+	}
+	return notice
+}
+
+// fencedCode returns the content of a fenced code block.
+func fencedCode(block string) string {
+	if match := hoverFencePattern.FindStringSubmatch(block); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return block
+}
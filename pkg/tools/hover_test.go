@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHover(t *testing.T) {
+	cases := []struct {
+		name     string
+		markdown string
+		want     hoverInfo
+	}{
+		{
+			name:     "function",
+			markdown: "```go\nfunc fmt.Println(a ...any) (n int, err error)\n```\n\n---\n\nPrintln formats using the default formats for its operands.\n\nIt returns the number of bytes written.\n\n\n---\n\n[`fmt.Println` on pkg.go.dev](https://pkg.go.dev/fmt#Println)",
+			want: hoverInfo{
+				Symbol:    "fmt.Println",
+				Signature: "func fmt.Println(a ...any) (n int, err error)",
+				Doc:       "Println formats using the default formats for its operands.\n\nIt returns the number of bytes written.",
+				Link:      "https://pkg.go.dev/fmt#Println",
+			},
+		},
+		{
+			name:     "type with fields and deprecation",
+			markdown: "```go\ntype Options struct { // size=8\n\tLegacy bool\n}\n```\nOptions configures the client.\n\nDeprecated: use Config\ninstead.\n\n---\n\n```go\nfunc (o Options) Validate() error\n```\n\n---\n\n[`client.Options` on pkg.go.dev](https://pkg.go.dev/example.com/client#Options)",
+			want: hoverInfo{
+				Symbol:     "client.Options",
+				Signature:  "type Options struct { // size=8\n\tLegacy bool\n}",
+				Doc:        "Options configures the client.\n\nDeprecated: use Config\ninstead.",
+				Deprecated: "use Config instead.",
+				Details:    []string{"func (o Options) Validate() error"},
+				Link:       "https://pkg.go.dev/example.com/client#Options",
+			},
+		},
+		{
+			name:     "local variable",
+			markdown: "```go\nvar count int\n```",
+			want:     hoverInfo{Signature: "var count int"},
+		},
+		{
+			name:     "plain text",
+			markdown: "package main",
+			want:     hoverInfo{Doc: "package main"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseHover(tc.markdown); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("parseHover:\n got %#v\nwant %#v", got, tc.want)
+			}
+		})
+	}
+}
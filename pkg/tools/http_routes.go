@@ -0,0 +1,400 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	frameworkNetHTTP = "net/http"
+	frameworkChi     = "chi"
+	frameworkGin     = "gin"
+	frameworkEcho    = "echo"
+	frameworkGorilla = "gorilla"
+)
+
+var (
+	upperHTTPMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "HEAD": true, "OPTIONS": true, "CONNECT": true, "TRACE": true}
+	chiHTTPMethods   = map[string]string{"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE", "Head": "HEAD", "Options": "OPTIONS", "Connect": "CONNECT", "Trace": "TRACE"}
+)
+
+type httpRoute struct {
+	Method          string             `json:"method"`
+	Path            string             `json:"path"`
+	Handler         string             `json:"handler"`
+	Framework       string             `json:"framework"`
+	Location        protocol.Location  `json:"location"`
+	HandlerLocation *protocol.Location `json:"handler_location,omitempty"`
+}
+
+func (t *LSPTools) registerListHTTPRoutes(s *server.MCPServer) {
+	tool := mcp.NewTool("list_http_routes",
+		mcp.WithDescription("List HTTP route registrations (net/http, chi, gin, echo, gorilla/mux) with method, path pattern, handler and locations"),
+		mcp.WithTitleAnnotation("List HTTP Routes"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		routes := collectHTTPRoutes(ws)
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"routes":       routes,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func collectHTTPRoutes(ws *gosrc.Workspace) []httpRoute {
+	decls := buildDeclIndex(ws)
+	routes := []httpRoute{}
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			c := newRouteCollector(pkg, file, decls)
+			if len(c.frameworks) == 0 {
+				continue
+			}
+			c.walk(file.Syntax, map[string]string{})
+			routes = append(routes, c.routes...)
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+type routeCollector struct {
+	pkg        *gosrc.Package
+	file       *gosrc.File
+	decls      *declIndex
+	imports    map[string]string
+	frameworks map[string]bool
+	// methods records gorilla's .Methods("GET") calls keyed by the route
+	// call they decorate.
+	methods map[ast.Expr][]string
+	routes  []httpRoute
+}
+
+func newRouteCollector(pkg *gosrc.Package, file *gosrc.File, decls *declIndex) *routeCollector {
+	imports := fileImports(file.Syntax)
+	frameworks := make(map[string]bool)
+	for prefix, framework := range map[string]string{
+		"net/http":                 frameworkNetHTTP,
+		"github.com/go-chi/chi":    frameworkChi,
+		"github.com/gin-gonic/gin": frameworkGin,
+		"github.com/labstack/echo": frameworkEcho,
+		"github.com/gorilla/mux":   frameworkGorilla,
+	} {
+		if hasImportPrefix(imports, prefix) {
+			frameworks[framework] = true
+		}
+	}
+	c := &routeCollector{
+		pkg:        pkg,
+		file:       file,
+		decls:      decls,
+		imports:    imports,
+		frameworks: frameworks,
+		methods:    make(map[ast.Expr][]string),
+	}
+	if frameworks[frameworkGorilla] {
+		ast.Inspect(file.Syntax, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if recv, name, ok := methodCall(call); ok && name == "Methods" {
+				for _, arg := range call.Args {
+					if method, ok := stringValue(arg); ok {
+						c.methods[recv] = append(c.methods[recv], strings.ToUpper(method))
+					}
+				}
+			}
+			return true
+		})
+	}
+	return c
+}
+
+// walk visits node tracking the path prefix bound to router variables
+// (gin/echo groups, gorilla subrouters, chi Route callbacks) in env.
+func (c *routeCollector) walk(node ast.Node, env map[string]string) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, rhs := range n.Rhs {
+				if i >= len(n.Lhs) {
+					break
+				}
+				if ident, ok := n.Lhs[i].(*ast.Ident); ok {
+					if prefix, ok := c.groupPrefix(rhs, env); ok {
+						env[ident.Name] = prefix
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, value := range n.Values {
+				if i < len(n.Names) {
+					if prefix, ok := c.groupPrefix(value, env); ok {
+						env[n.Names[i].Name] = prefix
+					}
+				}
+			}
+		case *ast.CallExpr:
+			return c.visitCall(n, env)
+		}
+		return true
+	})
+}
+
+func (c *routeCollector) visitCall(call *ast.CallExpr, env map[string]string) bool {
+	recv, name, ok := methodCall(call)
+	if !ok {
+		return true
+	}
+
+	// chi: r.Route("/api", func(r chi.Router) {...}) and r.Group(func(r chi.Router) {...}).
+	if c.frameworks[frameworkChi] && (name == "Route" || name == "Group") {
+		var (
+			prefix = c.prefixOf(recv, env)
+			fn     *ast.FuncLit
+		)
+		for _, arg := range call.Args {
+			if value, ok := stringValue(arg); ok {
+				prefix = joinRoutePath(prefix, value)
+			}
+			if lit, ok := arg.(*ast.FuncLit); ok {
+				fn = lit
+			}
+		}
+		if fn != nil {
+			inner := make(map[string]string, len(env)+1)
+			for k, v := range env {
+				inner[k] = v
+			}
+			if params := fn.Type.Params.List; len(params) > 0 && len(params[0].Names) > 0 {
+				inner[params[0].Names[0].Name] = prefix
+			}
+			c.walk(fn.Body, inner)
+			return false
+		}
+	}
+
+	if ident, ok := recv.(*ast.Ident); ok {
+		if importPath, ok := c.imports[ident.Name]; ok {
+			if importPath == "net/http" && (name == "HandleFunc" || name == "Handle") {
+				c.addPatternRoute(call, frameworkNetHTTP, "", call.Args, 0, 1)
+			}
+			return true
+		}
+	}
+
+	prefix := c.prefixOf(recv, env)
+	args := call.Args
+	switch {
+	case name == "HandleFunc" || name == "Handle":
+		if len(args) >= 3 && c.frameworks[frameworkGin] {
+			if method, ok := stringValue(args[0]); ok && upperHTTPMethods[strings.ToUpper(method)] {
+				c.addRoute(call, frameworkGin, strings.ToUpper(method), prefix, args[1], args[len(args)-1])
+				return true
+			}
+		}
+		if len(args) < 2 {
+			return true
+		}
+		framework := frameworkNetHTTP
+		switch {
+		case c.frameworks[frameworkGorilla]:
+			framework = frameworkGorilla
+		case c.frameworks[frameworkChi]:
+			framework = frameworkChi
+		}
+		c.addPatternRoute(call, framework, prefix, args, 0, 1)
+	case upperHTTPMethods[name] || name == "Any":
+		if len(args) < 2 || !(c.frameworks[frameworkGin] || c.frameworks[frameworkEcho]) {
+			return true
+		}
+		method := name
+		if name == "Any" {
+			method = "*"
+		}
+		// gin takes the handler last (after middleware), echo right after
+		// the path.
+		if c.frameworks[frameworkEcho] && !c.frameworks[frameworkGin] {
+			c.addRoute(call, frameworkEcho, method, prefix, args[0], args[1])
+		} else {
+			c.addRoute(call, frameworkGin, method, prefix, args[0], args[len(args)-1])
+		}
+	case chiHTTPMethods[name] != "":
+		if len(args) >= 2 && c.frameworks[frameworkChi] {
+			c.addRoute(call, frameworkChi, chiHTTPMethods[name], prefix, args[0], args[1])
+		}
+	case name == "Method" || name == "MethodFunc":
+		if len(args) >= 3 && c.frameworks[frameworkChi] {
+			if method, ok := stringValue(args[0]); ok {
+				c.addRoute(call, frameworkChi, strings.ToUpper(method), prefix, args[1], args[2])
+			}
+		}
+	case name == "Mount":
+		if len(args) >= 2 && c.frameworks[frameworkChi] {
+			if path, ok := stringValue(args[0]); ok {
+				c.appendRoute(call, frameworkChi, "*", joinRoutePath(prefix, path)+"/*", args[1])
+			}
+		}
+	case name == "Match":
+		if len(args) >= 3 && (c.frameworks[frameworkGin] || c.frameworks[frameworkEcho]) {
+			framework := frameworkGin
+			handler := args[len(args)-1]
+			if c.frameworks[frameworkEcho] && !c.frameworks[frameworkGin] {
+				framework, handler = frameworkEcho, args[2]
+			}
+			for _, method := range stringSliceLiteral(args[0]) {
+				c.addRoute(call, framework, strings.ToUpper(method), prefix, args[1], handler)
+			}
+		}
+	case name == "HandlerFunc" || name == "Handler":
+		// gorilla: r.Path("/x").HandlerFunc(h).Methods("GET")
+		if len(args) == 1 && c.frameworks[frameworkGorilla] {
+			if inner, ok := recv.(*ast.CallExpr); ok {
+				if base, innerName, ok := methodCall(inner); ok && innerName == "Path" && len(inner.Args) == 1 {
+					if path, ok := stringValue(inner.Args[0]); ok {
+						c.appendRoute(call, frameworkGorilla, c.gorillaMethod(call), joinRoutePath(c.prefixOf(base, env), path), args[0])
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// addPatternRoute registers net/http style routes whose pattern may carry
+// a method ("GET /items/{id}", Go 1.22+).
+func (c *routeCollector) addPatternRoute(call *ast.CallExpr, framework, prefix string, args []ast.Expr, pathArg, handlerArg int) {
+	pattern, ok := stringValue(args[pathArg])
+	if !ok {
+		return
+	}
+	method := "*"
+	if verb, rest, found := strings.Cut(pattern, " "); found && upperHTTPMethods[verb] {
+		method, pattern = verb, strings.TrimSpace(rest)
+	}
+	if framework == frameworkGorilla {
+		method = c.gorillaMethod(call)
+	}
+	c.appendRoute(call, framework, method, joinRoutePath(prefix, pattern), args[handlerArg])
+}
+
+func (c *routeCollector) addRoute(call *ast.CallExpr, framework, method, prefix string, pathExpr, handler ast.Expr) {
+	path, ok := stringValue(pathExpr)
+	if !ok {
+		return
+	}
+	c.appendRoute(call, framework, method, joinRoutePath(prefix, path), handler)
+}
+
+func (c *routeCollector) appendRoute(call *ast.CallExpr, framework, method, path string, handler ast.Expr) {
+	c.routes = append(c.routes, httpRoute{
+		Method:          method,
+		Path:            path,
+		Handler:         exprText(handler),
+		Framework:       framework,
+		Location:        sourceLocation(c.file, call.Pos(), call.End()),
+		HandlerLocation: c.decls.resolve(c.pkg.ImportPath, c.imports, handler),
+	})
+}
+
+func (c *routeCollector) gorillaMethod(call *ast.CallExpr) string {
+	if methods := c.methods[call]; len(methods) > 0 {
+		return strings.Join(methods, ",")
+	}
+	return "*"
+}
+
+// groupPrefix reports the path prefix of expressions creating a router
+// group: gin/echo Group("/p"), gorilla PathPrefix("/p").Subrouter() and
+// chi's With/Group chaining.
+func (c *routeCollector) groupPrefix(expr ast.Expr, env map[string]string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	recv, name, ok := methodCall(call)
+	if !ok {
+		return "", false
+	}
+	switch name {
+	case "Group":
+		if len(call.Args) > 0 {
+			if value, ok := stringValue(call.Args[0]); ok {
+				return joinRoutePath(c.prefixOf(recv, env), value), true
+			}
+		}
+	case "Subrouter":
+		if inner, ok := recv.(*ast.CallExpr); ok {
+			if base, innerName, ok := methodCall(inner); ok && innerName == "PathPrefix" && len(inner.Args) == 1 {
+				if value, ok := stringValue(inner.Args[0]); ok {
+					return joinRoutePath(c.prefixOf(base, env), value), true
+				}
+			}
+		}
+	case "With", "Use":
+		if prefix := c.prefixOf(recv, env); prefix != "" {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+func (c *routeCollector) prefixOf(expr ast.Expr, env map[string]string) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return env[e.Name]
+	case *ast.CallExpr:
+		if prefix, ok := c.groupPrefix(e, env); ok {
+			return prefix
+		}
+	}
+	return ""
+}
+
+func joinRoutePath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" || path == "/" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func stringSliceLiteral(expr ast.Expr) []string {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, elt := range lit.Elts {
+		if value, ok := stringValue(elt); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCollectHTTPRoutes(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/api\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "handlers/handlers.go", `package handlers
+
+import "net/http"
+
+func ListUsers(w http.ResponseWriter, r *http.Request) {}
+`)
+	writeWorkspaceFile(t, workspace, "std.go", `package main
+
+import (
+	"net/http"
+
+	"example.com/api/handlers"
+)
+
+func health(w http.ResponseWriter, r *http.Request) {}
+
+func std() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", handlers.ListUsers)
+	http.Handle("/health", http.HandlerFunc(health))
+	_, _ = http.Get("http://example.com")
+}
+`)
+	writeWorkspaceFile(t, workspace, "chi.go", `package main
+
+import "github.com/go-chi/chi/v5"
+
+type server struct{}
+
+func (s *server) getItem() {}
+
+func chiRoutes(r chi.Router, s *server) {
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/items/{id}", s.getItem)
+		r.With(nil).Post("/items", s.getItem)
+	})
+	r.Mount("/admin", nil)
+}
+`)
+	writeWorkspaceFile(t, workspace, "gin.go", `package main
+
+import "github.com/gin-gonic/gin"
+
+func ginRoutes(r *gin.Engine) {
+	v1 := r.Group("/v1")
+	v1.GET("/ping", auth, func(c *gin.Context) {})
+	r.Handle("DELETE", "/things", nil)
+}
+
+func auth(c *gin.Context) {}
+`)
+	writeWorkspaceFile(t, workspace, "mux.go", `package main
+
+import "github.com/gorilla/mux"
+
+func muxRoutes(r *mux.Router) {
+	s := r.PathPrefix("/v2").Subrouter()
+	s.HandleFunc("/orders", health).Methods("GET", "POST")
+	r.Path("/status").HandlerFunc(health).Methods("HEAD")
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	routes := collectHTTPRoutes(ws)
+
+	type key struct{ method, path, framework string }
+	got := make(map[key]httpRoute)
+	for _, route := range routes {
+		got[key{route.Method, route.Path, route.Framework}] = route
+	}
+	expected := map[key]string{
+		{"GET", "/users", frameworkNetHTTP}:          "handlers.ListUsers",
+		{"*", "/health", frameworkNetHTTP}:           "http.HandlerFunc(health)",
+		{"GET", "/api/items/{id}", frameworkChi}:     "s.getItem",
+		{"POST", "/api/items", frameworkChi}:         "s.getItem",
+		{"*", "/admin/*", frameworkChi}:              "nil",
+		{"GET", "/v1/ping", frameworkGin}:            "func literal",
+		{"DELETE", "/things", frameworkGin}:          "nil",
+		{"GET,POST", "/v2/orders", frameworkGorilla}: "health",
+		{"HEAD", "/status", frameworkGorilla}:        "health",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected routes %#v", routes)
+	}
+	for k, handler := range expected {
+		route, ok := got[k]
+		if !ok {
+			t.Fatalf("missing route %#v in %#v", k, routes)
+		}
+		if route.Handler != handler {
+			t.Fatalf("route %#v: expected handler %q, got %q", k, handler, route.Handler)
+		}
+	}
+
+	users := got[key{"GET", "/users", frameworkNetHTTP}]
+	if users.HandlerLocation == nil || users.HandlerLocation.Range.Start.Line != 4 {
+		t.Fatalf("expected handler location for ListUsers, got %#v", users.HandlerLocation)
+	}
+	if got[key{"*", "/health", frameworkNetHTTP}].HandlerLocation == nil {
+		t.Fatal("expected handler location through http.HandlerFunc conversion")
+	}
+	if got[key{"GET", "/api/items/{id}", frameworkChi}].HandlerLocation == nil {
+		t.Fatal("expected method handler location")
+	}
+	if users.Location.Range.Start.Line != 12 {
+		t.Fatalf("unexpected registration location %#v", users.Location)
+	}
+}
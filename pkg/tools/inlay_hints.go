@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// inlayHintKinds names the LSP InlayHintKinds, indexed by kind.
+var inlayHintKinds = []string{1: "type", 2: "parameter"}
+
+type inlayHint struct {
+	Position protocol.Position `json:"position"`
+	Kind     string            `json:"kind,omitempty"`
+	Label    string            `json:"label"`
+}
+
+// annotatedLine is a source line with its hints written inline, the way an
+// editor shows them.
+type annotatedLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+func (t *LSPTools) registerInlayHints(s *server.MCPServer) {
+	tool := mcp.NewTool("inlay_hints",
+		mcp.WithDescription("Show what gopls would display inline in a range of a Go file: inferred generic type arguments, parameter names at call sites, composite literal field names and types, the types of variables declared with := or range, and constant values. Returns each hint and the source lines with the hints written in"),
+		mcp.WithTitleAnnotation("Inlay Hints"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("range",
+			mcp.Description("Range to annotate (default: the whole file)"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("Only return type or parameter hints"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		kind := getOptionalStringArg(args, "kind")
+		if kind != "" && !slices.Contains(inlayHintKinds, kind) {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown hint kind %q; use type or parameter", kind)), nil
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		src, err := os.ReadFile(convertURIToPath(fileURI))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", convertURIToPath(fileURI), err)), nil
+		}
+		rng := documentRange(src)
+		if _, ok := args["range"]; ok {
+			if rng, err = parseRangeArg(args, "range"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		hints, err := lspClient.InlayHints(ctx, fileURI, rng)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if kind != "" {
+			hints = slices.DeleteFunc(hints, func(hint protocol.InlayHint) bool {
+				return hint.Kind >= len(inlayHintKinds) || inlayHintKinds[hint.Kind] != kind
+			})
+		}
+		slices.SortStableFunc(hints, func(a, b protocol.InlayHint) int {
+			return cmp.Or(cmp.Compare(a.Position.Line, b.Position.Line), cmp.Compare(a.Position.Character, b.Position.Character))
+		})
+
+		converted := make([]inlayHint, 0, len(hints))
+		for _, hint := range hints {
+			entry := inlayHint{Position: hint.Position, Label: hint.LabelText()}
+			if hint.Kind > 0 && hint.Kind < len(inlayHintKinds) {
+				entry.Kind = inlayHintKinds[hint.Kind]
+			}
+			converted = append(converted, entry)
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri": fileURI,
+			"hints":    converted,
+			"lines":    annotateLines(src, hints),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// annotateLines writes sorted hints into the lines they belong to. Lines
+// are 1-based.
+func annotateLines(src []byte, hints []protocol.InlayHint) []annotatedLine {
+	lines := []annotatedLine{}
+	for start := 0; start < len(hints); {
+		line := hints[start].Position.Line
+		end := start
+		for end < len(hints) && hints[end].Position.Line == line {
+			end++
+		}
+		lineStart, err := textedit.Offset(src, line, 0)
+		if err != nil {
+			start = end
+			continue
+		}
+		lineEnd := lineStart
+		for lineEnd < len(src) && src[lineEnd] != '\n' {
+			lineEnd++
+		}
+		edits := make([]textedit.Edit, 0, end-start)
+		for _, hint := range hints[start:end] {
+			offset, err := textedit.Offset(src, line, hint.Position.Character)
+			if err != nil {
+				continue
+			}
+			label := hint.LabelText()
+			if hint.PaddingLeft {
+				label = " " + label
+			}
+			if hint.PaddingRight {
+				label += " "
+			}
+			edits = append(edits, textedit.Edit{Start: offset - lineStart, End: offset - lineStart, New: label})
+		}
+		if text, err := textedit.Apply(src[lineStart:lineEnd], edits); err == nil {
+			lines = append(lines, annotatedLine{Line: line + 1, Text: strings.TrimSpace(string(text))})
+		}
+		start = end
+	}
+	return lines
+}
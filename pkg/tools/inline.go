@@ -0,0 +1,337 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	inlineCallKind     = "refactor.inline.call"
+	inlineVariableKind = "refactor.inline.variable"
+)
+
+// inlineSkip is a call site inline_call left alone, at a 1-based line.
+type inlineSkip struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+func (t *LSPTools) registerInlineTools(s *server.MCPServer) {
+	t.registerInlineCall(s)
+	t.registerInlineVariable(s)
+}
+
+func (t *LSPTools) registerInlineCall(s *server.MCPServer) {
+	tool := mcp.NewTool("inline_call",
+		mcp.WithDescription("Replace a call by the body of the function or method it calls, with the gopls inline call refactoring, which type-checks the result: arguments are bound to parameters, literalized only when safe, and imports are added. With all, position is on the function's name in its declaration and every call site in the workspace is inlined one at a time, reporting the sites gopls declines; the declaration itself stays. Returns the edit as a unified diff, and writes it when apply is true"),
+		mcp.WithTitleAnnotation("Inline Call"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position of the call, or of the function's name in its declaration with all"),
+		),
+		mcp.WithBoolean("all",
+			mcp.Description("Inline every call of the function declared at position; needs apply, and is refused while edits wait for approval (default false)"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edit to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		apply := getOptionalBoolArg(args, "apply")
+		all := getOptionalBoolArg(args, "all")
+		if all && !apply {
+			return mcp.NewToolResultError("all inlines the call sites one at a time on disk, as each changes the positions of the next; set apply to true, and review the diff afterwards"), nil
+		}
+		if all && t.approvals != nil {
+			return mcp.NewToolResultError("all writes each inlined call before computing the next; it cannot run while edits wait for approval, inline the calls one by one instead"), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		if all {
+			return t.inlineAllCalls(ctx, lspClient, fileURI, line, character)
+		}
+		position := protocol.Position{Line: line, Character: character}
+		return t.inlineAt(ctx, lspClient, fileURI, protocol.Range{Start: position, End: position}, inlineCallKind, apply)
+	})
+}
+
+func (t *LSPTools) registerInlineVariable(s *server.MCPServer) {
+	tool := mcp.NewTool("inline_variable",
+		mcp.WithDescription("Replace a use of a local variable by the expression it was initialized with, with the gopls inline variable refactoring, which declines when a name of the expression means something else at the use or the variable is assigned again. Returns the edit as a unified diff, and writes it when apply is true"),
+		mcp.WithTitleAnnotation("Inline Variable"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position of a use of the variable"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edit to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		position := protocol.Position{Line: line, Character: character}
+		return t.inlineAt(ctx, lspClient, fileURI, protocol.Range{Start: position, End: position}, inlineVariableKind, getOptionalBoolArg(args, "apply"))
+	})
+}
+
+// inlineAt runs the inline code action of kind offered for rng.
+func (t *LSPTools) inlineAt(ctx context.Context, lspClient client.LSPClient, fileURI string, rng protocol.Range, kind string, apply bool) (*mcp.CallToolResult, error) {
+	changes, title, problem, err := inlineChanges(ctx, lspClient, fileURI, rng, kind)
+	if err != nil {
+		return nil, t.handleLSPError(err)
+	}
+	if problem != "" {
+		return mcp.NewToolResultError(problem), nil
+	}
+	if apply {
+		if err := t.writeFileChanges(ctx, changes); err != nil {
+			return writeFailureResult("apply inline", err), nil
+		}
+	}
+	result, err := mcp.NewToolResultJSON(map[string]any{
+		"file_uri": fileURI,
+		"title":    title,
+		"files":    t.summarizeFileChanges(changes),
+		"applied":  apply,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// inlineChanges returns the file changes of the inline code action of kind
+// offered for rng. problem explains why there is none.
+func inlineChanges(ctx context.Context, lspClient client.LSPClient, fileURI string, rng protocol.Range, kind string) ([]fileChange, string, string, error) {
+	if kind == inlineCallKind {
+		return kindActionChanges(ctx, lspClient, fileURI, rng, kind, "inline this call",
+			"gopls offers no inline call here; place position on a call of a function or method declared in the workspace")
+	}
+	return kindActionChanges(ctx, lspClient, fileURI, rng, kind, "inline this variable",
+		"gopls offers no inline variable here; place position on a use of a local variable")
+}
+
+// inlineAllCalls inlines the calls of the function declared at line and
+// character one at a time, writing each before looking up the next, since
+// inlining moves the call sites that follow. Sites gopls declines, such as
+// the function used as a value, are skipped and reported.
+func (t *LSPTools) inlineAllCalls(ctx context.Context, lspClient client.LSPClient, fileURI string, line, character int) (*mcp.CallToolResult, error) {
+	path := convertURIToPath(fileURI)
+	decl, problem := funcDeclAt(path, line, character, "position is not on the name of a function or method declaration; with all, point at the name in the declaration")
+	if problem != "" {
+		return mcp.NewToolResultError(problem), nil
+	}
+	symbol := decl.symbol
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+
+	var cumulative []fileChange
+	index := make(map[string]int)
+	var skipped []inlineSkip
+	inlined, budget := 0, -1
+	for budget != 0 {
+		refs, err := lspClient.FindReferences(ctx, fileURI, decl.name.Line, decl.name.Character, false)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		refs = slices.DeleteFunc(refs, func(ref protocol.Location) bool {
+			return ref.URI == fileURI && ref.Range.Start.Line >= decl.start && ref.Range.Start.Line <= decl.end
+		})
+		slices.SortFunc(refs, func(a, b protocol.Location) int {
+			return cmp.Or(cmp.Compare(a.URI, b.URI), cmp.Compare(a.Range.Start.Line, b.Range.Start.Line), cmp.Compare(a.Range.Start.Character, b.Range.Start.Character))
+		})
+		if budget < 0 {
+			// Every call is inlined or skipped once; the bound holds even if
+			// gopls keeps reporting a site that was inlined.
+			budget = len(refs)
+		}
+		// Skipped sites precede the remaining ones, as sites are visited in
+		// order and inlining keeps the order of those that follow.
+		if len(skipped) >= len(refs) || budget == 0 {
+			break
+		}
+		budget--
+		ref := refs[len(skipped)]
+		changes, _, problem, err := inlineChanges(ctx, lspClient, ref.URI, ref.Range, inlineCallKind)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if problem != "" {
+			skipped = append(skipped, inlineSkip{Path: relativeSlashPath(root, convertURIToPath(ref.URI)), Line: ref.Range.Start.Line + 1, Reason: problem})
+			continue
+		}
+		if err := t.writeFileChanges(ctx, changes); err != nil {
+			return writeFailureResult(fmt.Sprintf("apply inline at %s:%d, after inlining %d calls", relativeSlashPath(root, convertURIToPath(ref.URI)), ref.Range.Start.Line+1, inlined), err), nil
+		}
+		inlined++
+		for _, change := range changes {
+			if i, ok := index[change.path]; ok {
+				cumulative[i].after, cumulative[i].deleted = change.after, change.deleted
+				continue
+			}
+			index[change.path] = len(cumulative)
+			cumulative = append(cumulative, change)
+		}
+
+		if err := t.awaitEdit(ctx, lspClient); err != nil {
+			return nil, err
+		}
+		if decl, problem = funcDeclNamed(path, symbol); problem != "" {
+			return mcp.NewToolResultError(fmt.Sprintf("%s after inlining %d calls", problem, inlined)), nil
+		}
+	}
+
+	payload := map[string]any{
+		"symbol":  symbol,
+		"inlined": inlined,
+		"files":   t.summarizeFileChanges(cumulative),
+		"applied": inlined > 0,
+	}
+	if len(skipped) > 0 {
+		payload["skipped"] = skipped
+	}
+	switch {
+	case inlined == 0 && len(skipped) == 0:
+		payload["message"] = fmt.Sprintf("%s has no calls to inline", symbol)
+	case len(skipped) == 0:
+		payload["message"] = fmt.Sprintf("every call is inlined; %s is left in place, delete it if nothing else needs it", symbol)
+	}
+	result, err := mcp.NewToolResultJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// locatedFunc locates a function declaration: the position of its name
+// and of its parameters, and its first and last lines, all 0-based.
+type locatedFunc struct {
+	symbol     string
+	name       protocol.Position
+	params     []funcParam
+	start, end int
+}
+
+// funcParam is a parameter of a located function; name is "" for unnamed
+// parameters, and position is that of the name or else of the type.
+type funcParam struct {
+	name     string
+	position protocol.Position
+}
+
+// funcDeclAt returns the function or method declaration whose name is at
+// line and character of the file. missing explains the error otherwise.
+func funcDeclAt(path string, line, character int, missing string) (locatedFunc, string) {
+	return locateFuncDecl(path, func(fset *token.FileSet, src []byte, decl *ast.FuncDecl) bool {
+		start, end := lspPosition(fset, src, decl.Name.Pos()), lspPosition(fset, src, decl.Name.End())
+		return start.Line == line && start.Character <= character && character <= end.Character
+	}, missing)
+}
+
+// funcDeclNamed returns the declaration of the function or method symbol,
+// as funcSymbol names it, in the file.
+func funcDeclNamed(path, symbol string) (locatedFunc, string) {
+	return locateFuncDecl(path, func(_ *token.FileSet, _ []byte, decl *ast.FuncDecl) bool {
+		return funcSymbol(decl) == symbol
+	}, fmt.Sprintf("%s is no longer declared in %s", symbol, path))
+}
+
+func locateFuncDecl(path string, match func(*token.FileSet, []byte, *ast.FuncDecl) bool, missing string) (locatedFunc, string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return locatedFunc{}, fmt.Sprintf("read %s: %v", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if file == nil {
+		return locatedFunc{}, fmt.Sprintf("parse %s: %v", path, err)
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !match(fset, src, fn) {
+			continue
+		}
+		located := locatedFunc{
+			symbol: funcSymbol(fn),
+			name:   lspPosition(fset, src, fn.Name.Pos()),
+			start:  fset.Position(fn.Pos()).Line - 1,
+			end:    fset.Position(fn.End()).Line - 1,
+		}
+		for _, field := range fn.Type.Params.List {
+			if len(field.Names) == 0 {
+				located.params = append(located.params, funcParam{position: lspPosition(fset, src, field.Type.Pos())})
+			}
+			for _, name := range field.Names {
+				located.params = append(located.params, funcParam{name: name.Name, position: lspPosition(fset, src, name.Pos())})
+			}
+		}
+		return located, ""
+	}
+	return locatedFunc{}, missing
+}
@@ -3,15 +3,23 @@ package tools
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
 
 func (t *LSPTools) registerInsightTools(s *server.MCPServer) {
 	t.registerHover(s)
+	t.registerStructuredHover(s)
 	t.registerCompletion(s)
+	t.registerInlayHints(s)
+	t.registerSemanticTokens(s)
+	t.registerFindSimilar(s)
+	t.registerReachability(s)
 }
 
 func (t *LSPTools) registerHover(s *server.MCPServer) {
@@ -73,10 +81,33 @@ func (t *LSPTools) registerHover(s *server.MCPServer) {
 	})
 }
 
+// defaultCompletionLimit caps get_completions results, in gopls' order of
+// relevance.
+const defaultCompletionLimit = 50
+
+// completionKindNames names the LSP CompletionItemKinds, indexed by kind.
+var completionKindNames = []string{
+	1: "text", 2: "method", 3: "function", 4: "constructor", 5: "field",
+	6: "variable", 7: "class", 8: "interface", 9: "module", 10: "property",
+	11: "unit", 12: "value", 13: "enum", 14: "keyword", 15: "snippet",
+	16: "color", 17: "file", 18: "reference", 19: "folder", 20: "enum_member",
+	21: "constant", 22: "struct", 23: "event", 24: "operator", 25: "type_parameter",
+}
+
+// completion is a completion item with its kind spelled out. InsertText is
+// only set when it differs from the label, as for snippets.
+type completion struct {
+	Label         string `json:"label"`
+	Kind          string `json:"kind,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+	InsertText    string `json:"insert_text,omitempty"`
+}
+
 func (t *LSPTools) registerCompletion(s *server.MCPServer) {
-	completionTool := mcp.NewTool("get_completion",
-		mcp.WithDescription("Get completion suggestions at a position"),
-		mcp.WithTitleAnnotation("Get Completion"),
+	completionTool := mcp.NewTool("get_completions",
+		mcp.WithDescription("List the completions gopls offers at a position, such as the methods and fields valid after a selector, most relevant first, with their kind, signature and documentation. prefix narrows them to labels starting with it"),
+		mcp.WithTitleAnnotation("Get Completions"),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("file_uri",
 			mcp.Required(),
@@ -86,6 +117,12 @@ func (t *LSPTools) registerCompletion(s *server.MCPServer) {
 			mcp.Required(),
 			mcp.Description("Position where to get completion"),
 		),
+		mcp.WithString("prefix",
+			mcp.Description("Only return completions whose label starts with this, ignoring case"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of completions to return (default %d)", defaultCompletionLimit)),
+		),
 	)
 
 	s.AddTool(completionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -99,6 +136,79 @@ func (t *LSPTools) registerCompletion(s *server.MCPServer) {
 			return nil, err
 		}
 
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return nil, err
+		}
+		limit := defaultCompletionLimit
+		if _, ok := args["limit"]; ok {
+			if limit, err = getIntFromObject(args, "limit"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 {
+				return mcp.NewToolResultError("limit must be at least 1"), nil
+			}
+		}
+		prefix := strings.ToLower(getOptionalStringArg(args, "prefix"))
+
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		list, err := lspClient.GetCompletionList(ctx, fileURI, line, character)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		completions := completionsFor(list.Items, prefix)
+		total := len(completions)
+		if total > limit {
+			completions = completions[:limit]
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri":    fileURI,
+			"total":       total,
+			"truncated":   total > len(completions),
+			"incomplete":  list.IsIncomplete,
+			"completions": completions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+
+	// get_completion is the tool's original name, kept for clients that
+	// still call it; it returns every label, as it always did.
+	alias := mcp.NewTool("get_completion",
+		mcp.WithDescription("Deprecated: use get_completions. Return every completion label at a position, as a list of strings"),
+		mcp.WithTitleAnnotation("Get Completion"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position where to get completion"),
+		),
+	)
+	s.AddTool(alias, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+
 		line, character, err := parsePosition(args)
 		if err != nil {
 			return nil, err
@@ -115,10 +225,7 @@ func (t *LSPTools) registerCompletion(s *server.MCPServer) {
 
 		completions, err := lspClient.GetCompletion(ctx, fileURI, line, character)
 		if err != nil {
-			if strings.Contains(err.Error(), "client closed") {
-				return nil, fmt.Errorf("LSP service not available, please restart the server: %w", err)
-			}
-			return nil, fmt.Errorf("failed to get completions: %w", err)
+			return nil, t.handleLSPError(err)
 		}
 
 		result, err := mcp.NewToolResultJSON(map[string]any{
@@ -131,3 +238,29 @@ func (t *LSPTools) registerCompletion(s *server.MCPServer) {
 		return result, nil
 	})
 }
+
+// completionsFor converts completion items in gopls' sort order, keeping
+// those whose label starts with the lower-cased prefix.
+func completionsFor(items []protocol.CompletionItem, prefix string) []completion {
+	items = slices.Clone(items)
+	slices.SortStableFunc(items, func(a, b protocol.CompletionItem) int { return strings.Compare(a.SortText, b.SortText) })
+	completions := make([]completion, 0, len(items))
+	for _, item := range items {
+		if !strings.HasPrefix(strings.ToLower(item.Label), prefix) {
+			continue
+		}
+		entry := completion{Label: item.Label, Detail: item.Detail, Documentation: item.DocumentationText()}
+		if item.Kind > 0 && item.Kind < len(completionKindNames) {
+			entry.Kind = completionKindNames[item.Kind]
+		}
+		insert := item.InsertText
+		if item.TextEdit != nil {
+			insert = item.TextEdit.NewText
+		}
+		if insert != item.Label {
+			entry.InsertText = insert
+		}
+		completions = append(completions, entry)
+	}
+	return completions
+}
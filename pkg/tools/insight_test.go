@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestCompletionsFor(t *testing.T) {
+	items := []protocol.CompletionItem{
+		{Label: "Write", Kind: 2, SortText: "00002", Detail: "func(p []byte) (n int, err error)"},
+		{Label: "WriteString", Kind: 2, SortText: "00001", InsertText: "WriteString(${1:s string})", Documentation: json.RawMessage(`"WriteString appends s."`)},
+		{Label: "Len", Kind: 2, SortText: "00000", TextEdit: &protocol.TextEdit{NewText: "Len"}},
+		{Label: "buf", Kind: 5, SortText: "00003", Documentation: json.RawMessage(`{"kind":"markdown","value":"The buffer."}`)},
+	}
+
+	got := completionsFor(items, "")
+	if len(got) != 4 || got[0].Label != "Len" || got[1].Label != "WriteString" || got[3].Kind != "field" || got[3].Documentation != "The buffer." {
+		t.Fatalf("unexpected completions %+v", got)
+	}
+	if got[0].InsertText != "" || got[1].InsertText != "WriteString(${1:s string})" || got[1].Documentation != "WriteString appends s." {
+		t.Fatalf("unexpected insert text or documentation %+v", got[:2])
+	}
+	if got := completionsFor(items, "write"); len(got) != 2 || got[0].Label != "WriteString" || got[1].Detail == "" {
+		t.Fatalf("prefix should keep both Write methods in order, got %+v", got)
+	}
+}
+
+func TestInlayHints(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nfunc main() {\n\tout := Map(xs, double)\n\tp := Point{1, 2}\n}\n")
+	uri := convertPathToURI(filepath.Join(workspace, "main.go"))
+	hint := func(line, character, kind int, label string, left, right bool) protocol.InlayHint {
+		raw, _ := json.Marshal(label)
+		return protocol.InlayHint{Position: protocol.Position{Line: line, Character: character}, Kind: kind, Label: raw, PaddingLeft: left, PaddingRight: right}
+	}
+	fakeClient := &fakeLSPClient{hints: []protocol.InlayHint{
+		hint(4, 15, 2, "Y:", false, true),
+		hint(3, 12, 2, "in:", false, true),
+		hint(3, 11, 1, "[int]", false, false),
+		hint(3, 4, 1, "[]int", true, false),
+		hint(4, 12, 2, "X:", false, true),
+		hint(4, 2, 1, "Point", true, false),
+	}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(arguments map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("inlay_hints").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "inlay_hints", Arguments: arguments},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("inlay_hints: %v %#v", err, result)
+		}
+		return structured(result)
+	}
+
+	all := call(map[string]any{"file_uri": uri})
+	lines := all["lines"].([]any)
+	if len(all["hints"].([]any)) != 6 || len(lines) != 2 {
+		t.Fatalf("unexpected hints %#v", all)
+	}
+	if text := lines[0].(map[string]any)["text"]; text != "out []int := Map[int](in: xs, double)" {
+		t.Fatalf("unexpected annotated line %q", text)
+	}
+	if text := lines[1].(map[string]any)["text"]; text != "p Point := Point{X: 1, Y: 2}" {
+		t.Fatalf("unexpected annotated line %q", text)
+	}
+	params := call(map[string]any{"file_uri": uri, "kind": "parameter"})
+	if hints := params["hints"].([]any); len(hints) != 3 || hints[0].(map[string]any)["label"] != "in:" {
+		t.Fatalf("unexpected parameter hints %#v", hints)
+	}
+}
+
+func TestSemanticTokens(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nfunc main() {\n\tvar b strings.Builder\n\tb.WriteString(\"x\")\n}\n")
+	uri := convertPathToURI(filepath.Join(workspace, "main.go"))
+	token := func(line, character, length int, typ string, modifiers ...string) protocol.SemanticToken {
+		return protocol.SemanticToken{Position: protocol.Position{Line: line, Character: character}, Length: length, Type: typ, Modifiers: modifiers}
+	}
+	fakeClient := &fakeLSPClient{tokens: []protocol.SemanticToken{
+		token(2, 5, 4, "function", "definition"),
+		token(3, 5, 1, "variable", "definition"),
+		token(3, 7, 7, "namespace"),
+		token(3, 15, 7, "type", "defaultLibrary"),
+		token(4, 1, 1, "variable"),
+		token(4, 3, 11, "method", "defaultLibrary"),
+	}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(arguments map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("semantic_tokens").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "semantic_tokens", Arguments: arguments},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("semantic_tokens: %v %#v", err, result)
+		}
+		return structured(result)
+	}
+
+	all := call(map[string]any{"file_uri": uri})
+	tokens := all["tokens"].([]any)
+	if len(tokens) != 6 || all["counts"].(map[string]any)["variable"] != float64(2) {
+		t.Fatalf("unexpected tokens %#v", all)
+	}
+	if builder := tokens[3].(map[string]any); builder["text"] != "Builder" || builder["type"] != "type" || builder["modifiers"].([]any)[0] != "defaultLibrary" {
+		t.Fatalf("unexpected type token %#v", builder)
+	}
+	if rng := all["range"].(map[string]any)["end"].(map[string]any); rng["line"] != float64(6) {
+		t.Fatalf("the default range should cover the file, got %#v", rng)
+	}
+
+	filtered := call(map[string]any{"file_uri": uri, "types": "method, function"})
+	if tokens := filtered["tokens"].([]any); len(tokens) != 2 || tokens[1].(map[string]any)["text"] != "WriteString" {
+		t.Fatalf("unexpected filtered tokens %#v", tokens)
+	}
+}
@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+type interfaceDecl struct {
+	pkgPath  string
+	name     string
+	methods  []string
+	embeds   [][2]string
+	location protocol.Location
+	// open is set when the method set cannot be known from the workspace
+	// (embedded external interfaces, type constraints).
+	open bool
+}
+
+type interfaceFinding struct {
+	Interface       string            `json:"interface"`
+	Kind            string            `json:"kind"`
+	Message         string            `json:"message"`
+	Implementations []string          `json:"implementations"`
+	Consumers       []string          `json:"consumers"`
+	Location        protocol.Location `json:"location"`
+}
+
+type emptyInterfaceParam struct {
+	Function string            `json:"function"`
+	Param    string            `json:"param"`
+	Package  string            `json:"package"`
+	Location protocol.Location `json:"location"`
+}
+
+func (t *LSPTools) registerAuditInterfaces(s *server.MCPServer) {
+	tool := mcp.NewTool("audit_interfaces",
+		mcp.WithDescription("Find simplification candidates: interfaces with a single implementation and at most one consumer, interfaces declared next to their only implementations instead of where they are consumed, and any/interface{} parameters"),
+		mcp.WithTitleAnnotation("Audit Interfaces"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only report packages whose import path starts with this prefix")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+
+		// Test files are loaded so fakes count as implementations: an
+		// interface with a test double is doing its job.
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		findings, params := auditInterfaces(ws, prefix)
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"interfaces":             findings,
+			"empty_interface_params": params,
+			"parse_errors":           ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func auditInterfaces(ws *gosrc.Workspace, prefix string) ([]interfaceFinding, []emptyInterfaceParam) {
+	interfaces := make(map[string]*interfaceDecl)
+	// methodSets maps each concrete type to its method names, pointer and
+	// value receivers alike.
+	methodSets := make(map[string][]string)
+	typeTest := make(map[string]bool)
+	consumers := make(map[string][]string)
+	params := []emptyInterfaceParam{}
+
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			for _, decl := range file.Syntax.Decls {
+				switch d := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						ts, ok := spec.(*ast.TypeSpec)
+						if !ok {
+							continue
+						}
+						key := pkg.ImportPath + "." + ts.Name.Name
+						if it, ok := ts.Type.(*ast.InterfaceType); ok {
+							if !file.Test {
+								interfaces[key] = newInterfaceDecl(pkg.ImportPath, imports, ts, it, sourceLocation(file, ts.Name.Pos(), ts.Name.End()))
+							}
+							continue
+						}
+						if _, ok := methodSets[key]; !ok {
+							methodSets[key] = []string{}
+						}
+						typeTest[key] = file.Test
+						if st, ok := ts.Type.(*ast.StructType); ok && !file.Test {
+							for _, field := range st.Fields.List {
+								for _, used := range interfaceRefs(pkg.ImportPath, imports, field.Type) {
+									consumers[used] = appendUnique(consumers[used], pkg.ImportPath+"."+ts.Name.Name)
+								}
+							}
+						}
+					}
+				case *ast.FuncDecl:
+					name := funcDeclName(d)
+					if d.Recv != nil && len(d.Recv.List) > 0 {
+						key := pkg.ImportPath + "." + receiverTypeName(d.Recv.List[0].Type)
+						methodSets[key] = appendUnique(methodSets[key], d.Name.Name)
+					}
+					if file.Test {
+						continue
+					}
+					for _, field := range d.Type.Params.List {
+						for _, used := range interfaceRefs(pkg.ImportPath, imports, field.Type) {
+							consumers[used] = appendUnique(consumers[used], pkg.ImportPath+"."+name)
+						}
+						if isEmptyInterface(field.Type) {
+							for _, ident := range field.Names {
+								params = append(params, emptyInterfaceParam{
+									Function: name,
+									Param:    ident.Name,
+									Package:  pkg.ImportPath,
+									Location: sourceLocation(file, ident.Pos(), field.Type.End()),
+								})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	findings := []interfaceFinding{}
+	for _, key := range sortedKeys(interfaces) {
+		iface := interfaces[key]
+		if prefix != "" && !strings.HasPrefix(iface.pkgPath, prefix) {
+			continue
+		}
+		methods, ok := resolveInterfaceMethods(interfaces, key, nil)
+		if !ok || len(methods) == 0 {
+			continue
+		}
+		var impls []string
+		samePackage := true
+		for _, typeKey := range sortedKeys(methodSets) {
+			if !containsAll(methodSets[typeKey], methods) {
+				continue
+			}
+			label := typeKey
+			if typeTest[typeKey] {
+				label += " (test)"
+			}
+			impls = append(impls, label)
+			if keyPackage(typeKey) != iface.pkgPath {
+				samePackage = false
+			}
+		}
+		if len(impls) == 0 {
+			continue
+		}
+		users := consumers[key]
+		localUse := false
+		for _, user := range users {
+			if keyPackage(user) == iface.pkgPath {
+				localUse = true
+			}
+		}
+		finding := interfaceFinding{
+			Interface:       key,
+			Implementations: impls,
+			Consumers:       users,
+			Location:        iface.location,
+		}
+		if finding.Consumers == nil {
+			finding.Consumers = []string{}
+		}
+		switch {
+		case len(impls) == 1 && len(users) <= 1:
+			finding.Kind = "single_implementation"
+			finding.Message = fmt.Sprintf("%s has one implementation (%s) and %d consumer(s); the concrete type could be used directly", iface.name, impls[0], len(users))
+		case samePackage && len(users) > 0 && !localUse:
+			finding.Kind = "producer_side"
+			finding.Message = fmt.Sprintf("%s is declared beside its implementations but only consumed in other packages; consider declaring it where it is used", iface.name)
+		default:
+			continue
+		}
+		findings = append(findings, finding)
+	}
+
+	if prefix != "" {
+		filtered := params[:0]
+		for _, param := range params {
+			if strings.HasPrefix(param.Package, prefix) {
+				filtered = append(filtered, param)
+			}
+		}
+		params = filtered
+	}
+	return findings, params
+}
+
+func newInterfaceDecl(pkgPath string, imports map[string]string, ts *ast.TypeSpec, it *ast.InterfaceType, location protocol.Location) *interfaceDecl {
+	iface := &interfaceDecl{pkgPath: pkgPath, name: ts.Name.Name, location: location, open: ts.TypeParams != nil}
+	for _, field := range it.Methods.List {
+		if len(field.Names) > 0 {
+			for _, name := range field.Names {
+				iface.methods = append(iface.methods, name.Name)
+			}
+			continue
+		}
+		embedPkg, embedName := qualifiedTypeName(pkgPath, imports, field.Type)
+		if embedName == "" {
+			// Unions and ~T terms make this a type constraint.
+			iface.open = true
+			continue
+		}
+		iface.embeds = append(iface.embeds, [2]string{embedPkg, embedName})
+	}
+	return iface
+}
+
+// resolveInterfaceMethods flattens embedded interfaces. It fails when an
+// embedded interface lies outside the workspace.
+func resolveInterfaceMethods(interfaces map[string]*interfaceDecl, key string, seen []string) ([]string, bool) {
+	iface, ok := interfaces[key]
+	if !ok || iface.open || slices.Contains(seen, key) {
+		return nil, false
+	}
+	methods := append([]string{}, iface.methods...)
+	for _, embed := range iface.embeds {
+		if embed[1] == "error" && embed[0] == iface.pkgPath {
+			methods = appendUnique(methods, "Error")
+			continue
+		}
+		embedded, ok := resolveInterfaceMethods(interfaces, embed[0]+"."+embed[1], append(seen, key))
+		if !ok {
+			return nil, false
+		}
+		for _, method := range embedded {
+			methods = appendUnique(methods, method)
+		}
+	}
+	return methods, true
+}
+
+// interfaceRefs lists the named types a field or parameter type mentions,
+// including through pointers, slices, maps and channels.
+func interfaceRefs(pkgPath string, imports map[string]string, expr ast.Expr) []string {
+	var refs []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncType:
+			return false
+		case *ast.Ident, *ast.SelectorExpr:
+			if typePkg, name := qualifiedTypeName(pkgPath, imports, node.(ast.Expr)); name != "" {
+				refs = append(refs, typePkg+"."+name)
+			}
+			return false
+		}
+		return true
+	})
+	return refs
+}
+
+// isEmptyInterface matches any and interface{}. Variadic ...any parameters,
+// the norm for fmt-style wrappers, are left alone.
+func isEmptyInterface(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name == "any"
+	case *ast.InterfaceType:
+		return len(e.Methods.List) == 0
+	}
+	return false
+}
+
+// keyPackage returns the import path of a "path.Name" key. Method keys
+// (path.Type.Method) are not passed here.
+func keyPackage(key string) string {
+	return key[:strings.LastIndex(key, ".")]
+}
+
+func containsAll(have, want []string) bool {
+	for _, name := range want {
+		if !slices.Contains(have, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func appendUnique(items []string, item string) []string {
+	if slices.Contains(items, item) {
+		return items
+	}
+	return append(items, item)
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestAuditInterfaces(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+import "io"
+
+type Getter interface {
+	Get(key string) (string, error)
+}
+
+type Store interface {
+	Getter
+	Put(key, value string) error
+}
+
+type memory struct{}
+
+func (m *memory) Get(key string) (string, error) { return "", nil }
+func (m *memory) Put(key, value string) error    { return nil }
+
+type disk struct{}
+
+func (d disk) Get(key string) (string, error) { return "", nil }
+func (d disk) Put(key, value string) error    { return nil }
+
+func New() Store { return &memory{} }
+
+type Closer interface {
+	io.Closer
+	Flush()
+}
+
+func Encode(v any, opts ...interface{}) {}
+`)
+	writeWorkspaceFile(t, workspace, "api/api.go", `package api
+
+import "example.com/app/store"
+
+type Server struct {
+	store store.Store
+}
+
+func Lookup(g store.Getter) {}
+
+func Serve(s store.Store) {}
+`)
+	writeWorkspaceFile(t, workspace, "cache/cache.go", `package cache
+
+type Loader interface {
+	Load(key string) ([]byte, error)
+}
+
+type httpLoader struct{}
+
+func (httpLoader) Load(key string) ([]byte, error) { return nil, nil }
+
+func Warm(l Loader) {}
+`)
+	writeWorkspaceFile(t, workspace, "notify/notify.go", `package notify
+
+type Sender interface {
+	Send(msg string) error
+}
+
+type smtp struct{}
+
+func (smtp) Send(msg string) error { return nil }
+
+func Alert(s Sender) {}
+`)
+	writeWorkspaceFile(t, workspace, "notify/notify_test.go", `package notify
+
+type fakeSender struct{}
+
+func (fakeSender) Send(msg string) error { return nil }
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	findings, params := auditInterfaces(ws, "")
+
+	var got []string
+	for _, finding := range findings {
+		got = append(got, finding.Interface+":"+finding.Kind)
+	}
+	// Sender has a test fake and Closer embeds io.Closer, so neither is
+	// reported.
+	want := []string{
+		"example.com/app/cache.Loader:single_implementation",
+		"example.com/app/store.Getter:producer_side",
+		"example.com/app/store.Store:producer_side",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected findings:\n got %v\nwant %v", got, want)
+	}
+	if !reflect.DeepEqual(findings[2].Implementations, []string{"example.com/app/store.disk", "example.com/app/store.memory"}) {
+		t.Fatalf("unexpected implementations: %v", findings[2].Implementations)
+	}
+	if !reflect.DeepEqual(findings[2].Consumers, []string{"example.com/app/api.Server", "example.com/app/api.Serve"}) {
+		t.Fatalf("unexpected consumers: %v", findings[2].Consumers)
+	}
+
+	if len(params) != 1 || params[0].Function != "Encode" || params[0].Param != "v" {
+		t.Fatalf("unexpected empty interface params: %+v", params)
+	}
+}
@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// The inventory tools answer "where does this codebase do X" questions
+// (routes, services, queries, configuration, error propagation) from syntax
+// alone, so they work without gopls and on code that does not currently
+// build.
+func (t *LSPTools) registerInventoryTools(s *server.MCPServer) {
+	t.registerListHTTPRoutes(s)
+	t.registerListGRPCServices(s)
+	t.registerListSQLUsages(s)
+	t.registerListConfigKeys(s)
+	t.registerListFeatureFlags(s)
+	t.registerErrorFlow(s)
+}
+
+func sourceLocation(file *gosrc.File, start, end token.Pos) protocol.Location {
+	startLine, startChar := file.Position(start)
+	endLine, endChar := file.Position(end)
+	return protocol.Location{
+		URI: convertPathToURI(file.Path),
+		Range: protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		},
+	}
+}
+
+// fileImports maps the local name of each import of file to its path.
+// Blank and dot imports are omitted.
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string, len(file.Imports))
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := defaultImportName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+// defaultImportName guesses the package name of an import path, skipping
+// major version suffixes and go- prefixes the way most packages are named.
+func defaultImportName(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersionSuffix.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	if idx := strings.Index(name, ".v"); idx > 0 {
+		name = name[:idx]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.ReplaceAll(name, "-", "")
+}
+
+func hasImportPrefix(imports map[string]string, prefix string) bool {
+	for _, importPath := range imports {
+		if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// methodCall splits a call of the form X.Name(args).
+func methodCall(call *ast.CallExpr) (ast.Expr, string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, "", false
+	}
+	return sel.X, sel.Sel.Name, true
+}
+
+// packageCall reports the import path and function name of a call to a
+// package-level function such as os.Getenv.
+func packageCall(imports map[string]string, call *ast.CallExpr) (string, string, bool) {
+	recv, name, ok := methodCall(call)
+	if !ok {
+		return "", "", false
+	}
+	ident, ok := recv.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+	importPath, ok := imports[ident.Name]
+	if !ok {
+		return "", "", false
+	}
+	return importPath, name, true
+}
+
+// stringValue evaluates string literals and constant concatenations of
+// them.
+func stringValue(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(e.Value)
+		return value, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		left, ok := stringValue(e.X)
+		if !ok {
+			return "", false
+		}
+		right, ok := stringValue(e.Y)
+		return left + right, ok
+	case *ast.ParenExpr:
+		return stringValue(e.X)
+	}
+	return "", false
+}
+
+func exprText(expr ast.Expr) string {
+	if _, ok := expr.(*ast.FuncLit); ok {
+		return "func literal"
+	}
+	return types.ExprString(expr)
+}
+
+type methodDecl struct {
+	pkgPath  string
+	recv     string
+	location protocol.Location
+}
+
+// declIndex locates function and method declarations across the workspace
+// so inventories can point at handler implementations.
+type declIndex struct {
+	funcs   map[string]map[string]protocol.Location
+	methods map[string][]methodDecl
+}
+
+func buildDeclIndex(ws *gosrc.Workspace) *declIndex {
+	index := &declIndex{
+		funcs:   make(map[string]map[string]protocol.Location),
+		methods: make(map[string][]methodDecl),
+	}
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				location := sourceLocation(file, fn.Name.Pos(), fn.Name.End())
+				if fn.Recv == nil || len(fn.Recv.List) == 0 {
+					if index.funcs[pkg.ImportPath] == nil {
+						index.funcs[pkg.ImportPath] = make(map[string]protocol.Location)
+					}
+					index.funcs[pkg.ImportPath][fn.Name.Name] = location
+					continue
+				}
+				index.methods[fn.Name.Name] = append(index.methods[fn.Name.Name], methodDecl{
+					pkgPath:  pkg.ImportPath,
+					recv:     receiverTypeName(fn.Recv.List[0].Type),
+					location: location,
+				})
+			}
+		}
+	}
+	return index
+}
+
+// resolve finds the declaration a function-valued expression refers to:
+// a function of the current package, a function of an imported workspace
+// package, or a method whose name is unique (preferring the current
+// package).
+func (idx *declIndex) resolve(pkgPath string, imports map[string]string, expr ast.Expr) *protocol.Location {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if loc, ok := idx.funcs[pkgPath][e.Name]; ok {
+			return &loc
+		}
+	case *ast.SelectorExpr:
+		if ident, ok := e.X.(*ast.Ident); ok {
+			if importPath, ok := imports[ident.Name]; ok {
+				if loc, ok := idx.funcs[importPath][e.Sel.Name]; ok {
+					return &loc
+				}
+				return nil
+			}
+		}
+		candidates := idx.methods[e.Sel.Name]
+		var local []methodDecl
+		for _, m := range candidates {
+			if m.pkgPath == pkgPath {
+				local = append(local, m)
+			}
+		}
+		if len(local) == 1 {
+			return &local[0].location
+		}
+		if len(local) == 0 && len(candidates) == 1 {
+			return &candidates[0].location
+		}
+	case *ast.CallExpr:
+		// Conversions such as http.HandlerFunc(fn) wrap the real handler.
+		if len(e.Args) == 1 {
+			if _, name, ok := methodCall(e); ok && strings.HasSuffix(name, "Func") {
+				return idx.resolve(pkgPath, imports, e.Args[0])
+			}
+		}
+	}
+	return nil
+}
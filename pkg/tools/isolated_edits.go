@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/scratch"
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+)
+
+// SetScratchWorkspace puts the tools in isolated edits mode: the workspace
+// directory is the copy, and diff_against_original and promote_changes
+// compare it with the user's checkout.
+func (t *LSPTools) SetScratchWorkspace(workspace *scratch.Workspace) {
+	t.scratch = workspace
+}
+
+// scratchChange reports a file of the copy that differs from the checkout.
+type scratchChange struct {
+	Path     string `json:"path"`
+	Status   string `json:"status"`
+	Conflict bool   `json:"conflict,omitempty"`
+	Diff     string `json:"diff"`
+}
+
+func (t *LSPTools) registerIsolatedEditsTools(s *server.MCPServer) {
+	if t.scratch == nil {
+		return
+	}
+	t.registerDiffAgainstOriginal(s)
+	t.registerPromoteChanges(s)
+}
+
+func (t *LSPTools) registerDiffAgainstOriginal(s *server.MCPServer) {
+	tool := mcp.NewTool("diff_against_original",
+		mcp.WithDescription("In isolated edits mode, where every edit goes to a private copy of the workspace, return the files of the copy that differ from the user's checkout as unified diffs. A file is in conflict when the checkout changed since the copy was made; promote_changes leaves those alone unless forced"),
+		mcp.WithTitleAnnotation("Diff Against Original"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithArray("paths",
+			mcp.Description("Only report these files or directories, relative to the workspace"),
+			mcp.WithStringItems(),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		paths := getOptionalStringListArg(args, "paths")
+		changes, err := t.scratch.Changes(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compare with the checkout: %v", err)), nil
+		}
+
+		files := make([]scratchChange, 0, len(changes))
+		conflicts := 0
+		for _, change := range changes {
+			if !pathSelected(paths, change.Path) {
+				continue
+			}
+			files = append(files, scratchChange{
+				Path:     change.Path,
+				Status:   change.Status(),
+				Conflict: change.Conflict,
+				Diff:     textedit.Unified(change.Path, change.Original, change.Scratch),
+			})
+			if change.Conflict {
+				conflicts++
+			}
+		}
+		payload := map[string]any{
+			"original":  t.scratch.Original(),
+			"workspace": t.scratch.Dir(),
+			"mode":      t.scratch.Mode(),
+			"files":     files,
+			"conflicts": conflicts,
+		}
+		if len(files) == 0 {
+			payload["message"] = "the copy matches the checkout"
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) registerPromoteChanges(s *server.MCPServer) {
+	tool := mcp.NewTool("promote_changes",
+		mcp.WithDescription("In isolated edits mode, copy the changes of the private copy of the workspace to the user's checkout: every changed file, or the files listed in paths. Files whose checkout changed since the copy was made are reported as conflicts and left alone unless force is true. Promoted files no longer show in diff_against_original"),
+		mcp.WithTitleAnnotation("Promote Changes"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithArray("paths",
+			mcp.Description("Files or directories to promote, relative to the workspace (default every changed file)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Overwrite files of the checkout that changed since the copy was made (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		paths := getOptionalStringListArg(args, "paths")
+		changes, err := t.scratch.Changes(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compare with the checkout: %v", err)), nil
+		}
+		var selected []string
+		for _, change := range changes {
+			if pathSelected(paths, change.Path) {
+				selected = append(selected, change.Path)
+			}
+		}
+		if len(selected) == 0 {
+			if len(paths) > 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("no changed file under %s; diff_against_original lists them", strings.Join(paths, ", "))), nil
+			}
+			return mcp.NewToolResultError("the copy matches the checkout; there is nothing to promote"), nil
+		}
+
+		promoted, conflicts, err := t.scratch.Promote(ctx, selected, getOptionalBoolArg(args, "force"))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("promote changes after %d files: %v", len(promoted), err)), nil
+		}
+		remaining, err := t.scratch.Changes(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compare with the checkout: %v", err)), nil
+		}
+		payload := map[string]any{
+			"original":  t.scratch.Original(),
+			"promoted":  append([]string{}, promoted...),
+			"conflicts": append([]string{}, conflicts...),
+			"remaining": len(remaining),
+		}
+		if len(conflicts) > 0 {
+			payload["message"] = "the conflicting files changed in the checkout since the copy was made; review them with diff_against_original and promote them with force to overwrite"
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// checkIsolatedEdits refuses changes to files of the checkout in isolated
+// edits mode, such as those named by the URIs of a client that opened it.
+func (t *LSPTools) checkIsolatedEdits(changes []fileChange) error {
+	if t.scratch == nil {
+		return nil
+	}
+	for _, change := range changes {
+		if isWithinDir(t.scratch.Original(), change.path) && !isWithinDir(t.scratch.Dir(), change.path) {
+			rel := relativeSlashPath(t.scratch.Original(), change.path)
+			return fmt.Errorf("%s is in the checkout, which isolated edits leave untouched; edit %s instead and promote it with promote_changes", rel, filepath.Join(t.scratch.Dir(), filepath.FromSlash(rel)))
+		}
+	}
+	return nil
+}
+
+// pathSelected reports whether rel is one of paths or below one of them;
+// every path is selected when paths is empty.
+func pathSelected(paths []string, rel string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, selected := range paths {
+		selected = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(selected)), "/")
+		if selected == "." || rel == selected || strings.HasPrefix(rel, selected+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithinDir reports whether path is dir or below it.
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/scratch"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestIsolatedEdits(t *testing.T) {
+	original := t.TempDir()
+	writeWorkspaceFile(t, original, "calc/calc.go", "package calc\n\nfunc Double(n int) int { return n * 2 }\n")
+	workspace, err := scratch.New(context.Background(), original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = workspace.Close(context.Background()) })
+	renameIn := func(dir string) *protocol.WorkspaceEdit {
+		uri := convertPathToURI(filepath.Join(dir, "calc", "calc.go"))
+		return &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{uri: {{
+			Range:   protocol.Range{Start: protocol.Position{Line: 2, Character: 5}, End: protocol.Position{Line: 2, Character: 11}},
+			NewText: "Twice",
+		}}}}
+	}
+	fakeClient := &fakeLSPClient{}
+	tools := NewLSPTools(fakeClient, workspace.Dir())
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	tools.SetScratchWorkspace(workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	rename := func(dir string) *mcp.CallToolResult {
+		fakeClient.rename = renameIn(dir)
+		return call("rename_symbol", map[string]any{
+			"file_uri": filepath.Join(dir, "calc", "calc.go"), "position": map[string]any{"line": 2, "character": 6}, "new_name": "Twice", "apply": true,
+		})
+	}
+	readOriginal := func() string {
+		data, _ := os.ReadFile(filepath.Join(original, "calc", "calc.go"))
+		return string(data)
+	}
+
+	if result := rename(original); !result.IsError {
+		t.Fatal("expected an edit of the checkout to be refused")
+	}
+	if result := rename(workspace.Dir()); result.IsError {
+		t.Fatalf("rename in the copy: %v", result)
+	}
+	if strings.Contains(readOriginal(), "Twice") {
+		t.Fatal("an isolated edit reached the checkout")
+	}
+
+	diff := structured(call("diff_against_original", map[string]any{}))
+	files := diff["files"].([]any)
+	if len(files) != 1 || diff["mode"] != "copy" {
+		t.Fatalf("unexpected diff %v", diff)
+	}
+	if file := files[0].(map[string]any); file["path"] != "calc/calc.go" || file["status"] != "modified" || !strings.Contains(file["diff"].(string), "+func Twice(n int) int") {
+		t.Fatalf("unexpected file %v", file)
+	}
+	if filtered := structured(call("diff_against_original", map[string]any{"paths": []any{"docs"}})); len(filtered["files"].([]any)) != 0 {
+		t.Fatalf("expected no change under docs, got %v", filtered)
+	}
+
+	promoted := structured(call("promote_changes", map[string]any{"paths": []any{"calc"}}))
+	if promoted["remaining"] != float64(0) || len(promoted["promoted"].([]any)) != 1 || len(promoted["conflicts"].([]any)) != 0 {
+		t.Fatalf("unexpected promotion %v", promoted)
+	}
+	if !strings.Contains(readOriginal(), "func Twice") {
+		t.Fatalf("calc.go not promoted: %q", readOriginal())
+	}
+	if result := call("promote_changes", map[string]any{}); !result.IsError {
+		t.Fatal("expected nothing left to promote")
+	}
+}
@@ -0,0 +1,352 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+const (
+	ldflagsSourceMakefile   = "makefile"
+	ldflagsSourceTaskfile   = "taskfile"
+	ldflagsSourceScript     = "script"
+	ldflagsSourceGoreleaser = "goreleaser"
+	ldflagsSourceCI         = "ci"
+	ldflagsSourceDockerfile = "dockerfile"
+	ldflagsSourceGOFLAGS    = "GOFLAGS"
+)
+
+var (
+	// ldflagsXPattern matches -X importpath.name=value, with the symbol
+	// possibly quoted and joined to the flag by = or spaces.
+	ldflagsXPattern = regexp.MustCompile(`(?:^|[\s'"=])-X(?:=|\s+)['"]?([^\s'"=]+\.[^\s'"=.]+)=([^\s'"]*)`)
+	// makeVariablePattern matches simple Makefile assignments.
+	makeVariablePattern  = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*(?:::=|:=|\?=|\+=|=)\s*(.*)$`)
+	makeReferencePattern = regexp.MustCompile(`\$[({]([A-Za-z_][A-Za-z0-9_]*)[)}]`)
+	// buildInfoNamePattern names the variables projects usually fill in at
+	// build time, reported even when nothing sets them.
+	buildInfoNamePattern = regexp.MustCompile(`(?i)^(?:app|build|git)?(?:version|commit|revision|sha|date|time|tag|branch|builtby|buildinfo)$`)
+
+	goreleaserNames = []string{".goreleaser.yml", ".goreleaser.yaml", "goreleaser.yml", "goreleaser.yaml"}
+)
+
+// ldflagsReference is one -X flag found in build configuration. Local is
+// set for the places a developer build goes through: Makefiles,
+// Taskfiles, scripts and GOFLAGS.
+type ldflagsReference struct {
+	Symbol string `json:"symbol"`
+	Value  string `json:"value"`
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Source string `json:"source"`
+	Local  bool   `json:"local"`
+}
+
+// ldflagsVariable is a package-level string variable that -X sets or that
+// looks like build information.
+type ldflagsVariable struct {
+	Symbol   string             `json:"symbol"`
+	Package  string             `json:"package"`
+	Name     string             `json:"name"`
+	File     string             `json:"file"`
+	Line     int                `json:"line"`
+	Default  string             `json:"default"`
+	Settable bool               `json:"settable"`
+	Problem  string             `json:"problem,omitempty"`
+	Status   string             `json:"status"`
+	SetBy    []ldflagsReference `json:"set_by"`
+}
+
+func (t *LSPTools) registerInspectLdflags(s *server.MCPServer) {
+	tool := mcp.NewTool("inspect_ldflags",
+		mcp.WithDescription("Map the variables a build fills in with -ldflags -X (version, commit, build date): the package-level string variables of the workspace that -X flags in Makefiles, Taskfiles, scripts, GoReleaser, CI workflows, Dockerfiles and GOFLAGS set, plus those that look like build information. Reports for each its default value, whether -X can set it at all, and whether local builds set it or only release builds do, and lists -X flags naming variables that do not exist. Use it when a binary reports version dev"),
+		mcp.WithTitleAnnotation("Inspect Ldflags"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{AllPlatforms: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		references, files, err := collectLdflagsReferences(t.workspaceDir)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if goflags := os.Getenv("GOFLAGS"); goflags != "" {
+			for _, match := range ldflagsXPattern.FindAllStringSubmatch(" "+goflags, -1) {
+				references = append(references, ldflagsReference{Symbol: match[1], Value: match[2], File: "$GOFLAGS", Source: ldflagsSourceGOFLAGS, Local: true})
+			}
+		}
+		variables, unmatched := matchLdflagsVariables(ws, references)
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"variables":    variables,
+			"unmatched":    unmatched,
+			"files":        files,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// collectLdflagsReferences scans the build configuration of the workspace
+// for -X flags, returning them with the files that had any.
+func collectLdflagsReferences(root string) ([]ldflagsReference, []string, error) {
+	var paths []string
+	err := walkWorkspaceFiles(root, func(path string) error {
+		if ldflagsFileSource(root, path) != "" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	// walkWorkspaceFiles skips hidden directories, where workflows live.
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		workflows, _ := filepath.Glob(filepath.Join(root, ".github", "workflows", pattern))
+		paths = append(paths, workflows...)
+	}
+	slices.Sort(paths)
+
+	references := []ldflagsReference{}
+	files := []string{}
+	for _, path := range paths {
+		found, err := scanLdflagsFile(root, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(found) > 0 {
+			files = append(files, relativeSlashPath(root, path))
+			references = append(references, found...)
+		}
+	}
+	return references, files, nil
+}
+
+// ldflagsFileSource classifies a file that may pass -X flags, or returns ""
+// for other files.
+func ldflagsFileSource(root, path string) string {
+	name := filepath.Base(path)
+	rel := relativeSlashPath(root, path)
+	switch {
+	case slices.Contains(makefileNames, name) || strings.HasSuffix(name, ".mk"):
+		return ldflagsSourceMakefile
+	case slices.Contains(taskfileNames, name):
+		return ldflagsSourceTaskfile
+	case slices.Contains(goreleaserNames, name):
+		return ldflagsSourceGoreleaser
+	case strings.HasPrefix(rel, ".github/workflows/") || name == ".gitlab-ci.yml":
+		return ldflagsSourceCI
+	case strings.HasPrefix(name, "Dockerfile") || strings.HasSuffix(name, ".Dockerfile") || strings.HasSuffix(name, ".dockerfile"):
+		return ldflagsSourceDockerfile
+	case strings.HasSuffix(name, ".sh") || strings.HasSuffix(name, ".bash"):
+		return ldflagsSourceScript
+	}
+	return ""
+}
+
+func scanLdflagsFile(root, path string) ([]ldflagsReference, error) {
+	source := ldflagsFileSource(root, path)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var references []ldflagsReference
+	makeVars := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if source == ldflagsSourceMakefile && !strings.HasPrefix(line, "\t") {
+			if match := makeVariablePattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+				makeVars[match[1]] = strings.TrimSpace(match[2])
+			}
+		}
+		for _, match := range ldflagsXPattern.FindAllStringSubmatch(line, -1) {
+			symbol, value := match[1], match[2]
+			if source == ldflagsSourceMakefile {
+				symbol, value = expandMakeVariables(symbol, makeVars), expandMakeVariables(value, makeVars)
+			}
+			references = append(references, ldflagsReference{
+				Symbol: symbol,
+				Value:  value,
+				File:   relativeSlashPath(root, path),
+				Line:   lineNo,
+				Source: source,
+				Local:  source == ldflagsSourceMakefile || source == ldflagsSourceTaskfile || source == ldflagsSourceScript,
+			})
+		}
+	}
+	return references, scanner.Err()
+}
+
+// expandMakeVariables substitutes the Makefile variables assigned so far,
+// leaving $(shell ...) and unknown variables as written.
+func expandMakeVariables(text string, vars map[string]string) string {
+	for range 5 {
+		expanded := makeReferencePattern.ReplaceAllStringFunc(text, func(ref string) string {
+			if value, ok := vars[ref[2:len(ref)-1]]; ok {
+				return value
+			}
+			return ref
+		})
+		if expanded == text {
+			break
+		}
+		text = expanded
+	}
+	return text
+}
+
+// matchLdflagsVariables pairs the references with the variables of the
+// workspace, returning the variables in package order and the references
+// that name no variable.
+func matchLdflagsVariables(ws *gosrc.Workspace, references []ldflagsReference) ([]*ldflagsVariable, []ldflagsReference) {
+	consts := stringConstants(ws)
+	var variables []*ldflagsVariable
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			if file.Test {
+				continue
+			}
+			imports := fileImports(file.Syntax)
+			for _, decl := range file.Syntax.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					vs := spec.(*ast.ValueSpec)
+					for i, name := range vs.Names {
+						if name.Name == "_" {
+							continue
+						}
+						variable := describeLdflagsVariable(pkg, file, vs, i, imports, consts)
+						if variable != nil {
+							variables = append(variables, variable)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	unmatched := []ldflagsReference{}
+	for _, ref := range references {
+		matched := false
+		for _, variable := range variables {
+			if ldflagsSymbolMatches(ref.Symbol, variable.Symbol) {
+				variable.SetBy = append(variable.SetBy, ref)
+				matched = true
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, ref)
+		}
+	}
+
+	reported := []*ldflagsVariable{}
+	for _, variable := range variables {
+		if len(variable.SetBy) == 0 && !buildInfoNamePattern.MatchString(variable.Name) {
+			continue
+		}
+		switch {
+		case !variable.Settable && len(variable.SetBy) > 0:
+			variable.Status = "set by -X, but the linker ignores it: " + variable.Problem
+		case slices.ContainsFunc(variable.SetBy, func(ref ldflagsReference) bool { return ref.Local }):
+			variable.Status = "set in local builds"
+		case len(variable.SetBy) > 0:
+			variable.Status = "set only in release or CI builds; plain go build keeps the default"
+		default:
+			variable.Status = "never set; always the default"
+		}
+		if variable.SetBy == nil {
+			variable.SetBy = []ldflagsReference{}
+		}
+		reported = append(reported, variable)
+	}
+	return reported, unmatched
+}
+
+// describeLdflagsVariable returns the i-th variable of vs when it may hold
+// a string, or nil. The linker only sets variables of type string that are
+// uninitialized or initialized to a constant expression.
+func describeLdflagsVariable(pkg *gosrc.Package, file *gosrc.File, vs *ast.ValueSpec, i int, imports map[string]string, consts map[string]map[string]string) *ldflagsVariable {
+	name := vs.Names[i]
+	var value ast.Expr
+	if len(vs.Values) == len(vs.Names) {
+		value = vs.Values[i]
+	} else if len(vs.Values) > 0 {
+		// var a, b = f() cannot be set either way.
+		return nil
+	}
+	typeName := ""
+	if ident, ok := vs.Type.(*ast.Ident); ok {
+		typeName = ident.Name
+	}
+	defaultValue, constant := "", true
+	if value != nil {
+		defaultValue, constant = constantString(pkg.ImportPath, imports, consts, value)
+	}
+	isString := typeName == "string" || (vs.Type == nil && constant)
+	if !isString && !buildInfoNamePattern.MatchString(name.Name) {
+		return nil
+	}
+
+	symbol := pkg.ImportPath + "." + name.Name
+	if pkg.Name == "main" {
+		symbol = "main." + name.Name
+	}
+	variable := &ldflagsVariable{
+		Symbol:   symbol,
+		Package:  pkg.ImportPath,
+		Name:     name.Name,
+		File:     file.RelPath,
+		Line:     file.Line(name.Pos()),
+		Default:  defaultValue,
+		Settable: true,
+	}
+	switch {
+	case vs.Type != nil && typeName != "string":
+		variable.Settable, variable.Problem = false, "its type is not string"
+	case !constant:
+		variable.Settable, variable.Problem = false, "it is initialized by a non-constant expression"
+		variable.Default = exprText(value)
+	}
+	return variable
+}
+
+// ldflagsSymbolMatches reports whether a -X symbol names the variable,
+// comparing only what follows the last unexpanded variable or template
+// when the symbol has one.
+func ldflagsSymbolMatches(symbol, variable string) bool {
+	if symbol == variable {
+		return true
+	}
+	cut := strings.LastIndexAny(symbol, ")}")
+	if cut < 0 {
+		return false
+	}
+	suffix := symbol[cut+1:]
+	return suffix != "" && strings.HasSuffix(variable, suffix) && strings.Contains(suffix, ".")
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestInspectLdflags(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "internal/version/version.go", `package version
+
+import "time"
+
+var (
+	Version = "dev"
+	Commit  string
+	Date    = time.Now().String()
+	Other   = "not build info"
+	BuiltBy = prefix + "local"
+)
+
+const prefix = "by-"
+`)
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nvar tag = \"none\"\n\nfunc main() {}\n")
+	writeWorkspaceFile(t, workspace, "Makefile", `PKG := example.com/app/internal/version
+VERSION ?= $(shell git describe --tags)
+LDFLAGS := -X $(PKG).Version=$(VERSION) -X '$(PKG).Date=now'
+
+build:
+	go build -ldflags "$(LDFLAGS)" ./...
+`)
+	writeWorkspaceFile(t, workspace, ".goreleaser.yml", `builds:
+  - ldflags:
+      - -s -w -X example.com/app/internal/version.Commit={{.Commit}}
+      - -X main.tag={{.Tag}} -X main.missing=x
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{AllPlatforms: true})
+	if err != nil {
+		t.Fatalf("gosrc.Load: %v", err)
+	}
+	references, files, err := collectLdflagsReferences(workspace)
+	if err != nil {
+		t.Fatalf("collectLdflagsReferences: %v", err)
+	}
+	if len(files) != 2 || files[0] != ".goreleaser.yml" || files[1] != "Makefile" {
+		t.Fatalf("unexpected files %v", files)
+	}
+	variables, unmatched := matchLdflagsVariables(ws, references)
+	if len(unmatched) != 1 || unmatched[0].Symbol != "main.missing" || unmatched[0].Line != 4 {
+		t.Fatalf("unexpected unmatched references %+v", unmatched)
+	}
+
+	bySymbol := make(map[string]*ldflagsVariable)
+	for _, variable := range variables {
+		bySymbol[variable.Symbol] = variable
+	}
+	if len(variables) != 5 || bySymbol["example.com/app/internal/version.Other"] != nil {
+		t.Fatalf("unexpected variables %+v", variables)
+	}
+	version := bySymbol["example.com/app/internal/version.Version"]
+	if version.Default != "dev" || version.Status != "set in local builds" || version.SetBy[0].Value != "$(shell git describe --tags)" {
+		t.Fatalf("unexpected Version %+v", version)
+	}
+	if commit := bySymbol["example.com/app/internal/version.Commit"]; commit.Status != "set only in release or CI builds; plain go build keeps the default" || commit.SetBy[0].Source != ldflagsSourceGoreleaser {
+		t.Fatalf("unexpected Commit %+v", commit)
+	}
+	if date := bySymbol["example.com/app/internal/version.Date"]; date.Settable || date.Default != "time.Now().String()" || len(date.SetBy) != 1 {
+		t.Fatalf("expected Date to be unsettable, got %+v", date)
+	}
+	if builtBy := bySymbol["example.com/app/internal/version.BuiltBy"]; !builtBy.Settable || builtBy.Default != "by-local" || builtBy.Status != "never set; always the default" {
+		t.Fatalf("unexpected BuiltBy %+v", builtBy)
+	}
+	if tag := bySymbol["main.tag"]; tag == nil || tag.Line != 3 || tag.File != "main.go" {
+		t.Fatalf("unexpected main.tag %+v", tag)
+	}
+}
@@ -0,0 +1,664 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/imports"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	logLibraryStd    = "log"
+	logLibrarySlog   = "slog"
+	logLibraryZap    = "zap"
+	logLibraryLogrus = "logrus"
+)
+
+var (
+	logLibraryImports = map[string]string{
+		"log":                        logLibraryStd,
+		"log/slog":                   logLibrarySlog,
+		"go.uber.org/zap":            logLibraryZap,
+		"github.com/sirupsen/logrus": logLibraryLogrus,
+	}
+	// logLevels maps the logging methods of each library to a level.
+	logLevels = map[string]map[string]string{
+		logLibraryStd: {
+			"Print": "info", "Printf": "info", "Println": "info",
+			"Fatal": "fatal", "Fatalf": "fatal", "Fatalln": "fatal",
+			"Panic": "panic", "Panicf": "panic", "Panicln": "panic",
+		},
+		logLibrarySlog: {
+			"Debug": "debug", "Info": "info", "Warn": "warn", "Error": "error",
+			"DebugContext": "debug", "InfoContext": "info", "WarnContext": "warn", "ErrorContext": "error",
+			"Log": "dynamic", "LogAttrs": "dynamic",
+		},
+		logLibraryZap: {
+			"Debug": "debug", "Info": "info", "Warn": "warn", "Error": "error", "DPanic": "panic", "Panic": "panic", "Fatal": "fatal",
+			"Debugf": "debug", "Infof": "info", "Warnf": "warn", "Errorf": "error", "DPanicf": "panic", "Panicf": "panic", "Fatalf": "fatal",
+			"Debugw": "debug", "Infow": "info", "Warnw": "warn", "Errorw": "error", "DPanicw": "panic", "Panicw": "panic", "Fatalw": "fatal",
+		},
+		logLibraryLogrus: {
+			"Trace": "debug", "Debug": "debug", "Info": "info", "Print": "info", "Warn": "warn", "Warning": "warn", "Error": "error", "Fatal": "fatal", "Panic": "panic",
+			"Tracef": "debug", "Debugf": "debug", "Infof": "info", "Printf": "info", "Warnf": "warn", "Warningf": "warn", "Errorf": "error", "Fatalf": "fatal", "Panicf": "panic",
+			"Traceln": "debug", "Debugln": "debug", "Infoln": "info", "Println": "info", "Warnln": "warn", "Warningln": "warn", "Errorln": "error", "Fatalln": "fatal", "Panicln": "panic",
+		},
+	}
+	piiFieldPattern   = regexp.MustCompile(`(?i)(e-?mail|passw(or)?d|passwd|secret|token|ssn|social_?security|phone|credit_?card|card_?number|cvv|iban|birth|dob|api_?key|access_?key|private_?key|ip_?addr|street)`)
+	failureMessage    = regexp.MustCompile(`(?i)\b(fail|failed|failure|error|unable|cannot|can't|could not)\b`)
+	formatVerbPattern = regexp.MustCompile(`%[vsdt]`)
+	formatKeySuffix   = regexp.MustCompile(`([A-Za-z_][\w.]*)\s*[=:]\s*$`)
+)
+
+type logCall struct {
+	Library  string            `json:"library"`
+	Level    string            `json:"level"`
+	Message  string            `json:"message,omitempty"`
+	Fields   []string          `json:"fields,omitempty"`
+	Call     string            `json:"call"`
+	Package  string            `json:"package"`
+	Location protocol.Location `json:"location"`
+
+	node      *ast.CallExpr
+	file      *gosrc.File
+	hasError  bool
+	errInFunc bool
+}
+
+type loggingFinding struct {
+	Kind     string            `json:"kind"`
+	Message  string            `json:"message"`
+	Location protocol.Location `json:"location"`
+}
+
+func (t *LSPTools) registerAuditLogging(s *server.MCPServer) {
+	tool := mcp.NewTool("audit_logging",
+		mcp.WithDescription("Inventory logging calls (slog, zap, logrus, log), flag packages mixing libraries, error-level calls without the error attached and PII-looking fields; optionally migrate simple log and logrus calls to slog"),
+		mcp.WithTitleAnnotation("Audit Logging"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithBoolean("include_calls", mcp.Description("Include the full call inventory, not only findings and counts (default false)")),
+		mcp.WithBoolean("migrate_to_slog", mcp.Description("Rewrite simple log.Print*/logrus calls to log/slog (default false)")),
+		mcp.WithBoolean("apply", mcp.Description("Write the slog migration to disk instead of returning a preview diff (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		includeCalls := getOptionalBoolArg(args, "include_calls")
+		migrate := getOptionalBoolArg(args, "migrate_to_slog")
+		apply := getOptionalBoolArg(args, "apply")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		calls := collectLogCalls(ws)
+		counts := make(map[string]int)
+		for _, call := range calls {
+			counts[call.Library]++
+		}
+		payload := map[string]any{
+			"libraries":    counts,
+			"findings":     auditLogCalls(calls),
+			"parse_errors": ws.ParseErrors,
+		}
+		if includeCalls {
+			payload["calls"] = calls
+		}
+
+		if migrate {
+			changes, migrated, err := migrateLogCallsToSlog(calls)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if apply {
+				if err := t.writeFileChanges(ctx, changes); err != nil {
+					return writeFailureResult("apply slog migration", err), nil
+				}
+			}
+			payload["migration"] = map[string]any{
+				"migrated": migrated,
+				"applied":  apply,
+				"files":    t.summarizeFileChanges(changes),
+			}
+		}
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func collectLogCalls(ws *gosrc.Workspace) []*logCall {
+	calls := []*logCall{}
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			libraries := make(map[string]bool)
+			for _, importPath := range imports {
+				if library, ok := logLibraryImports[importPath]; ok {
+					libraries[library] = true
+				}
+			}
+			if len(libraries) == 0 {
+				continue
+			}
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				errInFunc := mentionsError(fn.Body)
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					if lc := classifyLogCall(imports, libraries, call); lc != nil {
+						lc.Package = pkg.ImportPath
+						lc.Location = sourceLocation(file, call.Pos(), call.End())
+						lc.file = file
+						lc.errInFunc = errInFunc
+						calls = append(calls, lc)
+					}
+					return true
+				})
+			}
+		}
+	}
+	return calls
+}
+
+// classifyLogCall recognises call as a logging call. Package-level calls and
+// chains rooted at a logging package (zap.L().Info, logrus.WithField(...).Warn)
+// are attributed directly; calls on logger variables are attributed from the
+// file's imports.
+func classifyLogCall(imports map[string]string, libraries map[string]bool, call *ast.CallExpr) *logCall {
+	recv, name, ok := methodCall(call)
+	if !ok {
+		return nil
+	}
+	library := ""
+	if root := chainRoot(recv); root != nil {
+		if importPath, ok := imports[root.Name]; ok {
+			library = logLibraryImports[importPath]
+			if library == "" {
+				return nil
+			}
+		}
+	}
+	if library == "" {
+		if len(call.Args) == 0 {
+			return nil
+		}
+		var candidates []string
+		for lib := range libraries {
+			if _, ok := logLevels[lib][name]; ok {
+				candidates = append(candidates, lib)
+			}
+		}
+		switch {
+		case len(candidates) == 1:
+			library = candidates[0]
+		case len(candidates) > 1:
+			library = libraryFromFields(imports, call.Args)
+		}
+		if library == "" {
+			return nil
+		}
+		// Print and Fatal are common method names outside logging (testing.T,
+		// fmt-like writers); only trust them on receivers named like loggers.
+		if (library == logLibraryStd || library == logLibraryLogrus) && !strings.Contains(strings.ToLower(exprText(recv)), "log") {
+			return nil
+		}
+	}
+	level, ok := logLevels[library][name]
+	if !ok {
+		return nil
+	}
+
+	lc := &logCall{Library: library, Level: level, Call: exprText(call.Fun), node: call}
+	msgIndex := 0
+	switch {
+	case library == logLibrarySlog && strings.HasSuffix(name, "Context"):
+		msgIndex = 1
+	case library == logLibrarySlog && (name == "Log" || name == "LogAttrs"):
+		msgIndex = 2
+	}
+	if msgIndex < len(call.Args) {
+		lc.Message = literalText(call.Args[msgIndex])
+		lc.Fields = logFields(imports, library, name, call.Args[msgIndex+1:])
+	}
+	chain := chainCalls(recv)
+	for i := len(chain) - 1; i >= 0; i-- {
+		link := chain[i]
+		if _, method, ok := methodCall(link); ok {
+			switch method {
+			case "WithField", "With":
+				lc.Fields = append(lc.Fields, logFields(imports, library, "With", link.Args)...)
+			case "WithFields":
+				if len(link.Args) == 1 {
+					if lit, ok := link.Args[0].(*ast.CompositeLit); ok {
+						for _, elt := range lit.Elts {
+							if kv, ok := elt.(*ast.KeyValueExpr); ok {
+								lc.Fields = append(lc.Fields, literalText(kv.Key))
+							}
+						}
+					}
+				}
+			case "WithError":
+				lc.Fields = append(lc.Fields, "error")
+			}
+		}
+	}
+	exprs := append([]ast.Expr{}, call.Args...)
+	for _, link := range chain {
+		exprs = append(exprs, link.Args...)
+		if _, method, ok := methodCall(link); ok && method == "WithError" {
+			lc.hasError = true
+		}
+	}
+	for _, expr := range exprs {
+		if mentionsError(expr) {
+			lc.hasError = true
+		}
+	}
+	return lc
+}
+
+// chainRoot returns the identifier a selector/call chain starts from.
+func chainRoot(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.CallExpr:
+			expr = e.Fun
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// chainCalls lists the calls of a receiver chain such as
+// logrus.WithField("a", 1).WithError(err).
+func chainCalls(expr ast.Expr) []*ast.CallExpr {
+	var calls []*ast.CallExpr
+	for {
+		switch e := expr.(type) {
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.CallExpr:
+			calls = append(calls, e)
+			expr = e.Fun
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return calls
+		}
+	}
+}
+
+// libraryFromFields disambiguates calls such as logger.Info, which exist in
+// several libraries, from the field constructors passed to them.
+func libraryFromFields(imports map[string]string, args []ast.Expr) string {
+	for _, arg := range args {
+		if call, ok := arg.(*ast.CallExpr); ok {
+			if importPath, _, ok := packageCall(imports, call); ok {
+				switch logLibraryImports[importPath] {
+				case logLibraryZap:
+					return logLibraryZap
+				case logLibrarySlog:
+					return logLibrarySlog
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// logFields extracts the field names of structured arguments: alternating
+// key/value pairs (slog, zap's w-suffixed methods, With) and field
+// constructors such as zap.String("key", v) or slog.Int("key", v).
+// Printf-style calls report the formatted argument expressions.
+func logFields(imports map[string]string, library, method string, args []ast.Expr) []string {
+	var fields []string
+	if library == logLibraryStd || strings.HasSuffix(method, "f") || strings.HasSuffix(method, "ln") || (library == logLibraryLogrus && method != "With") {
+		for _, arg := range args {
+			fields = append(fields, exprText(arg))
+		}
+		return fields
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if call, ok := arg.(*ast.CallExpr); ok {
+			if _, name, ok := packageCall(imports, call); ok {
+				switch {
+				case name == "Error" && library == logLibraryZap:
+					fields = append(fields, "error")
+				case len(call.Args) > 0:
+					if key, ok := stringValue(call.Args[0]); ok {
+						fields = append(fields, key)
+					}
+				}
+				continue
+			}
+		}
+		if key, ok := stringValue(arg); ok {
+			fields = append(fields, key)
+			i++
+		}
+	}
+	return fields
+}
+
+// mentionsError reports whether node refers to an error value: an
+// identifier named err/xxxErr/errXxx or an .Error() call.
+func mentionsError(node ast.Node) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch e := n.(type) {
+		case *ast.Ident:
+			name := e.Name
+			if name == "err" || strings.HasSuffix(name, "Err") || (strings.HasPrefix(name, "err") && len(name) > 3 && name[3] >= 'A' && name[3] <= 'Z') {
+				found = true
+			}
+		case *ast.SelectorExpr:
+			if e.Sel.Name == "Error" || e.Sel.Name == "NamedError" {
+				if _, ok := e.X.(*ast.Ident); ok {
+					found = true
+				}
+			}
+		case *ast.FuncLit:
+			// Closures have their own scope; only look at what they are
+			// passed, not inside them.
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func auditLogCalls(calls []*logCall) []loggingFinding {
+	findings := []loggingFinding{}
+
+	byPackage := make(map[string]map[string]int)
+	first := make(map[string]map[string]protocol.Location)
+	for _, call := range calls {
+		if byPackage[call.Package] == nil {
+			byPackage[call.Package] = make(map[string]int)
+			first[call.Package] = make(map[string]protocol.Location)
+		}
+		if byPackage[call.Package][call.Library] == 0 {
+			first[call.Package][call.Library] = call.Location
+		}
+		byPackage[call.Package][call.Library]++
+	}
+	for _, pkgPath := range sortedKeys(byPackage) {
+		libraries := byPackage[pkgPath]
+		if len(libraries) < 2 {
+			continue
+		}
+		var parts []string
+		for _, library := range sortedKeys(libraries) {
+			parts = append(parts, fmt.Sprintf("%s (%d)", library, libraries[library]))
+		}
+		// Point at the first call of the least used library, the likely
+		// odd one out.
+		names := sortedKeys(libraries)
+		sort.SliceStable(names, func(i, j int) bool { return libraries[names[i]] < libraries[names[j]] })
+		findings = append(findings, loggingFinding{
+			Kind:     "mixed_libraries",
+			Message:  fmt.Sprintf("package %s logs through %s", pkgPath, strings.Join(parts, ", ")),
+			Location: first[pkgPath][names[0]],
+		})
+	}
+
+	for _, call := range calls {
+		failure := call.Level == "error" || call.Level == "fatal" || call.Level == "panic" ||
+			(call.Library == logLibraryStd && failureMessage.MatchString(call.Message))
+		if failure && !call.hasError && call.errInFunc {
+			findings = append(findings, loggingFinding{
+				Kind:     "missing_error",
+				Message:  fmt.Sprintf("%s logs a failure without attaching the error in scope", call.Call),
+				Location: call.Location,
+			})
+		}
+		for _, field := range call.Fields {
+			name := field
+			if idx := strings.LastIndex(name, "."); idx >= 0 {
+				name = name[idx+1:]
+			}
+			if piiFieldPattern.MatchString(name) {
+				findings = append(findings, loggingFinding{
+					Kind:     "pii",
+					Message:  fmt.Sprintf("%s logs %q, which looks like personal or secret data", call.Call, field),
+					Location: call.Location,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// migrateLogCallsToSlog rewrites the calls that have a direct slog
+// equivalent and returns one change per touched file. Imports are fixed up
+// afterwards, adding log/slog and dropping packages left unused.
+func migrateLogCallsToSlog(calls []*logCall) ([]fileChange, int, error) {
+	edits := make(map[*gosrc.File][]textedit.Edit)
+	var files []*gosrc.File
+	migrated := 0
+	for _, call := range calls {
+		replacement, ok := slogReplacement(call)
+		if !ok {
+			continue
+		}
+		start := call.file.Offset(call.node.Pos())
+		end := call.file.Offset(call.node.End())
+		if _, seen := edits[call.file]; !seen {
+			files = append(files, call.file)
+		}
+		edits[call.file] = append(edits[call.file], textedit.Edit{Start: start, End: end, New: replacement})
+		migrated++
+	}
+
+	changes := make([]fileChange, 0, len(files))
+	for _, file := range files {
+		change, err := newFileChange(file.Path, file.Src, edits[file])
+		if err != nil {
+			return nil, 0, err
+		}
+		processed, err := imports.Process(file.Path, change.after, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", file.RelPath, err)
+		}
+		change.after = processed
+		changes = append(changes, change)
+	}
+	return changes, migrated, nil
+}
+
+// slogReplacement renders the slog call equivalent to a log.Print*/logrus
+// call, when the call is simple enough to translate faithfully.
+func slogReplacement(call *logCall) (string, bool) {
+	recv, name, _ := methodCall(call.node)
+	var pairs []string
+	switch call.Library {
+	case logLibraryStd:
+		if ident, ok := recv.(*ast.Ident); !ok || ident.Name != "log" || call.Level != "info" {
+			return "", false
+		}
+	case logLibraryLogrus:
+		if call.Level == "fatal" || call.Level == "panic" {
+			return "", false
+		}
+		links := chainCalls(recv)
+		if root := chainRoot(recv); root == nil || root.Name != "logrus" {
+			return "", false
+		}
+		// Chains are outermost first; fields read in source order.
+		for i := len(links) - 1; i >= 0; i-- {
+			linkPairs, ok := logrusFieldPairs(links[i])
+			if !ok {
+				return "", false
+			}
+			pairs = append(pairs, linkPairs...)
+		}
+	default:
+		return "", false
+	}
+
+	var message string
+	switch {
+	case strings.HasSuffix(name, "f"):
+		if len(call.node.Args) == 0 {
+			return "", false
+		}
+		format, ok := stringValue(call.node.Args[0])
+		if !ok {
+			return "", false
+		}
+		formatPairs, msg, ok := formatToPairs(format, call.node.Args[1:])
+		if !ok {
+			return "", false
+		}
+		message = msg
+		pairs = append(pairs, formatPairs...)
+	default:
+		if len(call.node.Args) != 1 {
+			return "", false
+		}
+		msg, ok := stringValue(call.node.Args[0])
+		if !ok {
+			return "", false
+		}
+		message = strings.TrimSpace(msg)
+	}
+	if message == "" {
+		return "", false
+	}
+
+	level := map[string]string{"debug": "Debug", "info": "Info", "warn": "Warn", "error": "Error"}[call.Level]
+	if level == "Info" && call.hasError {
+		level = "Error"
+	}
+	args := append([]string{strconv.Quote(message)}, pairs...)
+	return fmt.Sprintf("slog.%s(%s)", level, strings.Join(args, ", ")), true
+}
+
+func logrusFieldPairs(link *ast.CallExpr) ([]string, bool) {
+	_, method, ok := methodCall(link)
+	if !ok {
+		return nil, false
+	}
+	switch method {
+	case "WithField":
+		if len(link.Args) != 2 {
+			return nil, false
+		}
+		return []string{exprText(link.Args[0]), exprText(link.Args[1])}, true
+	case "WithError":
+		if len(link.Args) != 1 {
+			return nil, false
+		}
+		return []string{`"error"`, exprText(link.Args[0])}, true
+	case "WithFields":
+		if len(link.Args) != 1 {
+			return nil, false
+		}
+		lit, ok := link.Args[0].(*ast.CompositeLit)
+		if !ok {
+			return nil, false
+		}
+		var pairs []string
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return nil, false
+			}
+			pairs = append(pairs, exprText(kv.Key), exprText(kv.Value))
+		}
+		return pairs, true
+	}
+	return nil, false
+}
+
+// formatToPairs turns a Printf format whose verbs are only separated by
+// punctuation, e.g. "open %s: %v" or "user=%s id=%d", into a message and
+// slog key/value pairs.
+func formatToPairs(format string, args []ast.Expr) ([]string, string, bool) {
+	if strings.Contains(format, "%%") {
+		return nil, "", false
+	}
+	format = strings.TrimSuffix(format, "\n")
+	verbs := formatVerbPattern.FindAllStringIndex(format, -1)
+	if strings.Count(format, "%") != len(verbs) || len(verbs) != len(args) {
+		return nil, "", false
+	}
+	if len(verbs) == 0 {
+		return nil, strings.TrimSpace(format), true
+	}
+	var (
+		pairs   []string
+		message string
+		last    int
+	)
+	for i, verb := range verbs {
+		text := format[last:verb[0]]
+		key := ""
+		if match := formatKeySuffix.FindStringSubmatchIndex(text); match != nil && (i > 0 || match[0] > 0) {
+			key = text[match[2]:match[3]]
+			text = text[:match[0]]
+		}
+		if i == 0 {
+			message = strings.TrimRight(text, " :,-=")
+		} else if strings.Trim(text, " :,;-") != "" {
+			return nil, "", false
+		}
+		if key == "" {
+			key = fieldKey(args[i])
+		}
+		if key == "" {
+			return nil, "", false
+		}
+		pairs = append(pairs, strconv.Quote(key), exprText(args[i]))
+		last = verb[1]
+	}
+	if strings.Trim(format[last:], " .:,;-") != "" {
+		return nil, "", false
+	}
+	return pairs, strings.TrimSpace(message), true
+}
+
+// fieldKey derives a slog key from a formatted argument: err, id, user.Name.
+func fieldKey(expr ast.Expr) string {
+	if mentionsError(expr) {
+		return "error"
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return strings.ToLower(e.Sel.Name[:1]) + e.Sel.Name[1:]
+	}
+	return ""
+}
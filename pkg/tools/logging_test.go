@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+const loggingFixture = `package main
+
+import (
+	"log"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+func run(path string, userEmail string) error {
+	err := open(path)
+	if err != nil {
+		slog.Error("open failed", "path", path)
+		log.Printf("open %s: %v", path, err)
+		logrus.WithField("path", path).WithError(err).Warn("retrying")
+		return err
+	}
+	log.Println("opened")
+	slog.Info("login", "email", userEmail)
+	log.Fatal("unreachable")
+	return nil
+}
+
+func open(string) error { return nil }
+`
+
+func TestAuditLogging(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "main.go", loggingFixture)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	calls := collectLogCalls(ws)
+	if len(calls) != 6 {
+		t.Fatalf("expected 6 logging calls, got %d: %+v", len(calls), calls)
+	}
+	if calls[2].Library != logLibraryLogrus || calls[2].Level != "warn" || strings.Join(calls[2].Fields, ",") != "path,error" {
+		t.Fatalf("unexpected logrus call: %+v", calls[2])
+	}
+
+	kinds := make(map[string]int)
+	for _, finding := range auditLogCalls(calls) {
+		kinds[finding.Kind]++
+		if line := finding.Location.Range.Start.Line; finding.Kind == "missing_error" && line != 12 && line != 19 {
+			t.Errorf("unexpected missing_error location: %+v", finding)
+		}
+	}
+	if kinds["mixed_libraries"] != 1 || kinds["missing_error"] != 2 || kinds["pii"] != 1 {
+		t.Fatalf("unexpected findings: %v", kinds)
+	}
+
+	changes, migrated, err := migrateLogCallsToSlog(calls)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if migrated != 3 || len(changes) != 1 {
+		t.Fatalf("expected 3 migrated calls in one file, got %d in %d", migrated, len(changes))
+	}
+	after := string(changes[0].after)
+	for _, want := range []string{
+		`slog.Error("open", "path", path, "error", err)`,
+		`slog.Warn("retrying", "path", path, "error", err)`,
+		`slog.Info("opened")`,
+		`log.Fatal("unreachable")`,
+	} {
+		if !strings.Contains(after, want) {
+			t.Errorf("expected migrated source to contain %s:\n%s", want, after)
+		}
+	}
+	if strings.Contains(after, "logrus") {
+		t.Errorf("expected the unused logrus import to be removed:\n%s", after)
+	}
+
+	tools := NewLSPTools(nil, workspace)
+	if diff := tools.summarizeFileChanges(changes)[0].Diff; !strings.Contains(diff, "+\tslog.Info(\"opened\")") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+	if err := tools.writeFileChanges(context.Background(), changes); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(workspace, "main.go"))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(written) != after {
+		t.Fatalf("written file does not match the change")
+	}
+	if err := tools.writeFileChanges(context.Background(), changes); err == nil || !strings.Contains(err.Error(), "modified since it was read") {
+		t.Fatalf("expected stale change to be rejected, got %v", err)
+	}
+}
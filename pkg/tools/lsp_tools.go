@@ -6,22 +6,39 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/hloiseau/mcp-gopls/v2/internal/goenv"
+	"github.com/hloiseau/mcp-gopls/v2/internal/scratch"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
 
-type commandRunner func(*LSPTools, context.Context, *server.MCPServer, mcp.ProgressToken, string, ...string) (commandResult, error)
+type commandRunner func(*LSPTools, context.Context, *server.MCPServer, mcp.ProgressToken, commandSpec) (commandResult, error)
+
+// commandSpec describes an external command. dir defaults to the workspace
+// directory and env entries are appended to the inherited environment. When
+// quitAfter is set the command gets SIGQUIT once it has run that long, which
+// makes a Go program print every goroutine's stack and exit.
+type commandSpec struct {
+	name      string
+	args      []string
+	dir       string
+	env       []string
+	quitAfter time.Duration
+}
 
 type LSPTools struct {
 	client        client.LSPClient
@@ -29,6 +46,22 @@ type LSPTools struct {
 	resetFunc     func(error) bool
 	workspaceDir  string
 	commandRunner commandRunner
+
+	// contract is the edit contract of the session, nil until declared.
+	contractMu sync.Mutex
+	contract   *editContract
+
+	// scratch is the copy the tools edit in isolated edits mode, nil
+	// otherwise.
+	scratch *scratch.Workspace
+	// approvals queues the edits while they need approval, nil otherwise.
+	approvals *approvalQueue
+	// touched records the files the session read or edited.
+	touched *touchedFiles
+	// history records what the session looked at.
+	history *sessionHistory
+	// bookmarks are the locations the session tagged with notes.
+	bookmarks bookmarkStore
 }
 
 func NewLSPTools(lspClient client.LSPClient, workspaceDir string) *LSPTools {
@@ -38,6 +71,8 @@ func NewLSPTools(lspClient client.LSPClient, workspaceDir string) *LSPTools {
 		resetFunc:     func(error) bool { return false },
 		workspaceDir:  workspaceDir,
 		commandRunner: defaultCommandRunner,
+		touched:       newTouchedFiles(),
+		history:       newSessionHistory(),
 	}
 }
 
@@ -67,6 +102,47 @@ func (t *LSPTools) handleLSPError(err error) error {
 	return nil
 }
 
+// interactiveTools are the tools answered by a few gopls requests, as
+// opposed to the ones running commands or loading the workspace packages.
+var interactiveTools = map[string]bool{
+	"go_to_definition":         true,
+	"go_to_type_definition":    true,
+	"go_to_declaration":        true,
+	"find_references":          true,
+	"find_implementations":     true,
+	"document_highlight":       true,
+	"document_symbols":         true,
+	"folding_ranges":           true,
+	"call_hierarchy_incoming":  true,
+	"call_hierarchy_outgoing":  true,
+	"type_hierarchy":           true,
+	"get_hover_info":           true,
+	"hover":                    true,
+	"get_completions":          true,
+	"get_completion":           true,
+	"inlay_hints":              true,
+	"semantic_tokens":          true,
+	"selection_range":          true,
+	"check_diagnostics":        true,
+	"get_diagnostics":          true,
+	"format_document":          true,
+	"rename_symbol":            true,
+	"list_code_actions":        true,
+	"apply_code_action":        true,
+	"code_lens":                true,
+	"edit_contract":            true,
+	"organize_imports":         true,
+	"workspace_symbols":        true,
+	"search_workspace_symbols": true,
+}
+
+// IsInteractive reports whether the named tool is a quick gopls query. The
+// server runs the other tools, such as tests, builds and workspace analyses,
+// on a separate queue so that they do not hold up these.
+func IsInteractive(name string) bool {
+	return interactiveTools[name]
+}
+
 func (t *LSPTools) Register(s *server.MCPServer) {
 	t.registerNavigationTools(s)
 	t.registerDiagnosticsTools(s)
@@ -74,6 +150,13 @@ func (t *LSPTools) Register(s *server.MCPServer) {
 	t.registerTestingTools(s)
 	t.registerRefactorTools(s)
 	t.registerWorkspaceTools(s)
+	t.registerProjectTools(s)
+	t.registerDocsTools(s)
+	t.registerInventoryTools(s)
+	t.registerAuditTools(s)
+	t.registerSessionTools(s)
+	t.registerIsolatedEditsTools(s)
+	t.registerApprovalTools(s)
 }
 
 func convertPathToURI(path string) string {
@@ -101,6 +184,20 @@ func convertPathToURI(path string) string {
 	return path
 }
 
+// convertURIToPath is the inverse of convertPathToURI. Non-file URIs are
+// returned unchanged.
+func convertURIToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	path := parsed.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
 func getArguments(request mcp.CallToolRequest) (map[string]any, error) {
 	args := request.GetArguments()
 	if args == nil {
@@ -121,6 +218,52 @@ func getStringArg(args map[string]any, key string) (string, error) {
 	return str, nil
 }
 
+func getOptionalStringArg(args map[string]any, key string) string {
+	if args == nil {
+		return ""
+	}
+	if str, ok := args[key].(string); ok {
+		return strings.TrimSpace(str)
+	}
+	return ""
+}
+
+func getOptionalBoolArg(args map[string]any, key string) bool {
+	if args == nil {
+		return false
+	}
+	value, _ := args[key].(bool)
+	return value
+}
+
+// getOptionalStringListArg returns the non-blank strings of an array
+// argument, trimmed.
+func getOptionalStringListArg(args map[string]any, key string) []string {
+	raw, _ := args[key].([]any)
+	var items []string
+	for _, item := range raw {
+		if str, ok := item.(string); ok && strings.TrimSpace(str) != "" {
+			items = append(items, strings.TrimSpace(str))
+		}
+	}
+	return items
+}
+
+func getOptionalDurationArg(args map[string]any, key string, fallback time.Duration) (time.Duration, error) {
+	raw := getOptionalStringArg(args, key)
+	if raw == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 30s or 5m: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive", key)
+	}
+	return d, nil
+}
+
 func getObjectArg(args map[string]any, key string) (map[string]any, error) {
 	val, ok := args[key]
 	if !ok {
@@ -211,15 +354,32 @@ type commandResult struct {
 }
 
 func (t *LSPTools) runCommand(ctx context.Context, srv *server.MCPServer, token mcp.ProgressToken, name string, args ...string) (commandResult, error) {
-	return t.commandRunner(t, ctx, srv, token, name, args...)
+	return t.runCommandSpec(ctx, srv, token, commandSpec{name: name, args: args})
+}
+
+func (t *LSPTools) runCommandSpec(ctx context.Context, srv *server.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+	return t.commandRunner(t, ctx, srv, token, spec)
 }
 
-func defaultCommandRunner(t *LSPTools, ctx context.Context, srv *server.MCPServer, token mcp.ProgressToken, name string, args ...string) (commandResult, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	if t.workspaceDir != "" {
+func defaultCommandRunner(t *LSPTools, ctx context.Context, srv *server.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+	if spec.quitAfter > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.quitAfter)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, spec.name, spec.args...)
+	if spec.quitAfter > 0 {
+		cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGQUIT) }
+		// Programs that ignore SIGQUIT are killed after a grace period.
+		cmd.WaitDelay = 10 * time.Second
+	}
+	switch {
+	case spec.dir != "":
+		cmd.Dir = spec.dir
+	case t.workspaceDir != "":
 		cmd.Dir = t.workspaceDir
 	}
-	cmd.Env = ensureLocalToolchainEnv(os.Environ())
+	cmd.Env = append(ensureLocalToolchainEnv(os.Environ()), spec.env...)
 
 	var stdout, stderr bytes.Buffer
 	stdoutEmitter := newLineEmitter(ctx, srv, token, "stdout")
@@ -244,7 +404,7 @@ func defaultCommandRunner(t *LSPTools, ctx context.Context, srv *server.MCPServe
 	}
 
 	result := commandResult{
-		Command:  append([]string{name}, args...),
+		Command:  append([]string{spec.name}, spec.args...),
 		ExitCode: exitCode,
 		Stdout:   stdout.String(),
 		Stderr:   stderr.String(),
@@ -306,8 +466,13 @@ func (e *lineEmitter) flush() {
 	e.buf.Reset()
 }
 
+// ensureLocalToolchainEnv returns env for the commands the tools run, pinned
+// to the local toolchain and without the approval token, which the code they
+// run could otherwise print.
 func ensureLocalToolchainEnv(env []string) []string {
-	cloned := append([]string(nil), env...)
+	cloned := slices.DeleteFunc(append([]string(nil), env...), func(kv string) bool {
+		return strings.HasPrefix(kv, "MCP_GOPLS_APPROVAL_TOKEN=")
+	})
 	hasGoto := false
 	pathIdx := -1
 
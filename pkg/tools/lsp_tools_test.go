@@ -1,11 +1,14 @@
 package tools
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
 
 	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
@@ -46,6 +49,19 @@ func TestParseRangeArg(t *testing.T) {
 	}
 }
 
+func TestInteractiveToolsAreRegistered(t *testing.T) {
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	NewLSPTools(&fakeLSPClient{}, t.TempDir()).Register(server)
+	for name := range interactiveTools {
+		if server.GetTool(name) == nil {
+			t.Errorf("interactive tool %s is not registered", name)
+		}
+	}
+	if IsInteractive("run_go_test") || !IsInteractive("hover") {
+		t.Fatal("run_go_test is heavy and hover interactive")
+	}
+}
+
 func TestGetArguments(t *testing.T) {
 	request := mcp.CallToolRequest{
 		Params: mcp.CallToolParams{
@@ -93,3 +109,25 @@ func TestCommandFailureResultIncludesStderr(t *testing.T) {
 		t.Fatalf("exit code missing from error text: %q", textContent.Text)
 	}
 }
+
+func TestProgressSinkReceivesOutputLines(t *testing.T) {
+	var got []string
+	ctx := WithProgressSink(context.Background(), func(message string) { got = append(got, message) })
+	sendProgressNotification(ctx, nil, nil, "Running go test ./...")
+	emitter := newLineEmitter(ctx, nil, nil, "stdout")
+	_, _ = emitter.Write([]byte("=== RUN   TestA\n--- PASS: Test"))
+	_, _ = emitter.Write([]byte("A (0.00s)"))
+	emitter.flush()
+
+	want := []string{"Running go test ./...", "[stdout] === RUN   TestA", "[stdout] --- PASS: TestA (0.00s)"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("sink got %q, want %q", got, want)
+	}
+}
+
+func TestEnsureLocalToolchainEnvDropsApprovalToken(t *testing.T) {
+	env := ensureLocalToolchainEnv([]string{"HOME=/home/dev", "MCP_GOPLS_APPROVAL_TOKEN=secret", "GOTOOLCHAIN=auto"})
+	if slices.Contains(env, "MCP_GOPLS_APPROVAL_TOKEN=secret") || !slices.Contains(env, "HOME=/home/dev") || !slices.Contains(env, "GOTOOLCHAIN=auto") {
+		t.Fatalf("expected the approval token to be dropped and the rest kept, got %q", env)
+	}
+}
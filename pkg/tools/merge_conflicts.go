@@ -0,0 +1,484 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mergeConflict is a conflict between the markers of a file. Lines are
+// 1-based and include the markers; Base is only known for diff3 style
+// conflicts.
+type mergeConflict struct {
+	Index         int                 `json:"index"`
+	StartLine     int                 `json:"start_line"`
+	EndLine       int                 `json:"end_line"`
+	OursLabel     string              `json:"ours_label,omitempty"`
+	TheirsLabel   string              `json:"theirs_label,omitempty"`
+	Ours          string              `json:"ours"`
+	Base          string              `json:"base,omitempty"`
+	Theirs        string              `json:"theirs"`
+	OursSymbols   []conflictDecl      `json:"ours_symbols,omitempty"`
+	TheirsSymbols []conflictDecl      `json:"theirs_symbols,omitempty"`
+	Candidates    []conflictCandidate `json:"candidates,omitempty"`
+	hasBase       bool
+}
+
+// conflictDecl is a declaration a side of a conflict touches, with its
+// declaration as go doc prints it.
+type conflictDecl struct {
+	Symbol string `json:"symbol"`
+	Decl   string `json:"decl"`
+}
+
+// conflictCandidate tells whether the file compiles with a conflict
+// resolved by Choice.
+type conflictCandidate struct {
+	Choice   string       `json:"choice"`
+	Compiles bool         `json:"compiles"`
+	Errors   []buildError `json:"errors,omitempty"`
+}
+
+// conflictResolution resolves a conflict with ours, theirs, both (ours then
+// theirs), base, or custom text.
+type conflictResolution struct {
+	Choice string
+	Text   string
+}
+
+var conflictChoices = []string{"ours", "theirs", "both"}
+
+func (t *LSPTools) registerAnalyzeMergeConflicts(s *server.MCPServer) {
+	tool := mcp.NewTool("analyze_merge_conflicts",
+		mcp.WithDescription("Help resolve the merge or rebase conflicts of Go files. Without file_uri, lists the files git reports as conflicted with their number of conflicts. With file_uri, parses the conflict markers of the file and returns each conflict with both sides, the base side of diff3 conflicts, and the declarations each side touches with their signatures; checks whether the package compiles with each conflict resolved as ours, theirs or both; and with resolutions, checks that choice and with apply writes it"),
+		mcp.WithTitleAnnotation("Analyze Merge Conflicts"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Description("URI of a conflicted Go file; omit to list the conflicted files"),
+		),
+		mcp.WithArray("resolutions",
+			mcp.Description("How to resolve conflicts, by index: [{\"conflict\": 1, \"choice\": \"theirs\"}, {\"conflict\": 2, \"choice\": \"custom\", \"text\": \"...\"}]. Choices are ours, theirs, both (ours then theirs), base for diff3 conflicts, and custom with the replacement text"),
+			mcp.Items(map[string]any{"type": "object"}),
+		),
+		mcp.WithBoolean("verify",
+			mcp.Description("Check that the package compiles with each candidate resolution (default true)"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the file resolved as resolutions say, which must cover every conflict (default false). Refused when verify finds it does not compile"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		fileURI := getOptionalStringArg(args, "file_uri")
+		if fileURI == "" {
+			return t.listConflictedFiles(ctx, s)
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		filePath := convertURIToPath(fileURI)
+		if !strings.HasSuffix(filePath, ".go") {
+			return mcp.NewToolResultError("file_uri must be a Go file"), nil
+		}
+		verify := true
+		if _, ok := args["verify"]; ok {
+			verify = getOptionalBoolArg(args, "verify")
+		}
+		apply := getOptionalBoolArg(args, "apply")
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("read %s: %v", filePath, err)), nil
+		}
+		lines := strings.SplitAfter(string(content), "\n")
+		conflicts, err := parseMergeConflicts(lines)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(conflicts) == 0 {
+			return mcp.NewToolResultError("the file has no conflict markers"), nil
+		}
+		resolutions, err := parseConflictResolutions(args, conflicts)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if apply && len(resolutions) < len(conflicts) {
+			return mcp.NewToolResultError(fmt.Sprintf("apply needs a resolution for each of the %d conflicts", len(conflicts))), nil
+		}
+
+		// The declarations of a side are found in the file with every
+		// conflict resolved to that side.
+		for _, side := range []string{"ours", "theirs"} {
+			choices := make(map[int]conflictResolution)
+			for _, conflict := range conflicts {
+				choices[conflict.Index] = conflictResolution{Choice: side}
+			}
+			text, spans := resolveConflicts(lines, conflicts, choices)
+			fset := token.NewFileSet()
+			file, _ := parser.ParseFile(fset, filePath, text, parser.ParseComments|parser.SkipObjectResolution)
+			if file == nil {
+				continue
+			}
+			for i := range conflicts {
+				span := spans[conflicts[i].Index]
+				if span[1] < span[0] {
+					continue
+				}
+				decls := conflictDeclarations(fset, file, span[0], span[1])
+				if side == "ours" {
+					conflicts[i].OursSymbols = decls
+				} else {
+					conflicts[i].TheirsSymbols = decls
+				}
+			}
+		}
+
+		var warnings []string
+		if others := otherConflictedFiles(filePath); len(others) > 0 {
+			warnings = append(warnings, fmt.Sprintf("other files of the package still have conflict markers, so builds fail until they are resolved: %s", strings.Join(others, ", ")))
+		}
+		payload := map[string]any{"file_uri": fileURI, "conflicts": conflicts}
+		var resolved string
+		complete, compiles := len(resolutions) == len(conflicts), true
+		if complete {
+			resolved, _ = resolveConflicts(lines, conflicts, resolutions)
+		}
+		if verify {
+			// A candidate resolves the other conflicts as resolutions say,
+			// or else with the same choice.
+			for i, conflict := range conflicts {
+				for _, choice := range conflictChoices {
+					choices := maps.Clone(resolutions)
+					for _, other := range conflicts {
+						if _, ok := choices[other.Index]; !ok {
+							choices[other.Index] = conflictResolution{Choice: choice}
+						}
+					}
+					choices[conflict.Index] = conflictResolution{Choice: choice}
+					text, _ := resolveConflicts(lines, conflicts, choices)
+					candidate := conflictCandidate{Choice: choice}
+					candidate.Compiles, candidate.Errors, err = t.compilesWith(ctx, s, filePath, text)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					conflicts[i].Candidates = append(conflicts[i].Candidates, candidate)
+				}
+			}
+			if complete {
+				var errs []buildError
+				compiles, errs, err = t.compilesWith(ctx, s, filePath, resolved)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				payload["resolution"] = conflictCandidate{Choice: "resolutions", Compiles: compiles, Errors: errs}
+			}
+		}
+
+		if complete {
+			change := fileChange{path: filePath, before: content, after: []byte(resolved)}
+			payload["files"] = t.summarizeFileChanges([]fileChange{change})
+			if apply {
+				if !compiles {
+					return mcp.NewToolResultError("the resolution does not compile; fix it or set verify to false to write it anyway"), nil
+				}
+				if err := t.writeFileChanges(ctx, []fileChange{change}); err != nil {
+					return writeFailureResult("apply resolution", err), nil
+				}
+			}
+		}
+		payload["applied"] = apply
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// listConflictedFiles lists the files git reports as unmerged, counting the
+// conflicts of the Go ones.
+func (t *LSPTools) listConflictedFiles(ctx context.Context, s *server.MCPServer) (*mcp.CallToolResult, error) {
+	result, err := t.runCommand(ctx, s, nil, "git", "diff", "--name-only", "--diff-filter=U", "--relative")
+	if err != nil {
+		return t.commandFailureResult("git diff", result, err)
+	}
+	type conflictedFile struct {
+		Path      string `json:"path"`
+		URI       string `json:"uri"`
+		Conflicts int    `json:"conflicts"`
+	}
+	files := []conflictedFile{}
+	var others []string
+	for _, name := range splitLines(result.Stdout) {
+		if !strings.HasSuffix(name, ".go") {
+			others = append(others, name)
+			continue
+		}
+		filePath := filepath.Join(t.workspaceDir, filepath.FromSlash(name))
+		file := conflictedFile{Path: name, URI: convertPathToURI(filePath)}
+		if content, err := os.ReadFile(filePath); err == nil {
+			conflicts, _ := parseMergeConflicts(strings.SplitAfter(string(content), "\n"))
+			file.Conflicts = len(conflicts)
+		}
+		files = append(files, file)
+	}
+	payload := map[string]any{"files": files}
+	if len(others) > 0 {
+		payload["other_files"] = others
+	}
+	return mcp.NewToolResultJSON(payload)
+}
+
+// parseMergeConflicts finds the conflicts of a file split after its line
+// ends.
+func parseMergeConflicts(lines []string) ([]mergeConflict, error) {
+	marker := func(line, prefix string) (string, bool) {
+		line = strings.TrimRight(line, "\r\n")
+		if line != prefix && !strings.HasPrefix(line, prefix+" ") {
+			return "", false
+		}
+		return strings.TrimSpace(strings.TrimPrefix(line, prefix)), true
+	}
+	var conflicts []mergeConflict
+	var current *mergeConflict
+	var side *string
+	for i, line := range lines {
+		if label, ok := marker(line, "<<<<<<<"); ok {
+			if current != nil {
+				return nil, fmt.Errorf("conflict at line %d is not closed before line %d", current.StartLine, i+1)
+			}
+			current = &mergeConflict{Index: len(conflicts) + 1, StartLine: i + 1, OursLabel: label}
+			side = &current.Ours
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if _, ok := marker(line, "|||||||"); ok && side == &current.Ours {
+			current.hasBase = true
+			side = &current.Base
+			continue
+		}
+		if _, ok := marker(line, "======="); ok && side != &current.Theirs {
+			side = &current.Theirs
+			continue
+		}
+		if label, ok := marker(line, ">>>>>>>"); ok && side == &current.Theirs {
+			current.EndLine, current.TheirsLabel = i+1, label
+			conflicts = append(conflicts, *current)
+			current, side = nil, nil
+			continue
+		}
+		*side += line
+	}
+	if current != nil {
+		return nil, fmt.Errorf("conflict at line %d is not closed", current.StartLine)
+	}
+	return conflicts, nil
+}
+
+// parseConflictResolutions reads the resolutions argument, keyed by
+// conflict index.
+func parseConflictResolutions(args map[string]any, conflicts []mergeConflict) (map[int]conflictResolution, error) {
+	resolutions := make(map[int]conflictResolution)
+	raw, ok := args["resolutions"].([]any)
+	if !ok {
+		return resolutions, nil
+	}
+	for _, item := range raw {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("each resolution must be an object with conflict and choice")
+		}
+		index, err := getIntFromObject(entry, "conflict")
+		if err != nil {
+			return nil, fmt.Errorf("resolution: %w", err)
+		}
+		if index < 1 || index > len(conflicts) {
+			return nil, fmt.Errorf("conflict %d does not exist; the file has %d", index, len(conflicts))
+		}
+		resolution := conflictResolution{}
+		resolution.Choice, _ = entry["choice"].(string)
+		resolution.Text, _ = entry["text"].(string)
+		switch resolution.Choice {
+		case "ours", "theirs", "both", "custom":
+		case "base":
+			if !conflicts[index-1].hasBase {
+				return nil, fmt.Errorf("conflict %d has no base side; enable diff3 conflict style to resolve to base", index)
+			}
+		default:
+			return nil, fmt.Errorf("choice of conflict %d must be ours, theirs, both, base or custom", index)
+		}
+		if resolution.Text != "" && !strings.HasSuffix(resolution.Text, "\n") {
+			resolution.Text += "\n"
+		}
+		resolutions[index] = resolution
+	}
+	return resolutions, nil
+}
+
+// resolveConflicts returns the file with the conflicts resolved as
+// choices say, left as they are otherwise, and the 1-based lines each
+// resolved conflict spans in it. An empty resolution spans [start,
+// start-1].
+func resolveConflicts(lines []string, conflicts []mergeConflict, choices map[int]conflictResolution) (string, map[int][2]int) {
+	var b strings.Builder
+	spans := make(map[int][2]int)
+	line, next := 1, 0
+	for i := 0; i < len(lines); i++ {
+		if next < len(conflicts) && i+1 == conflicts[next].StartLine {
+			conflict := conflicts[next]
+			next++
+			choice, ok := choices[conflict.Index]
+			if ok {
+				var text string
+				switch choice.Choice {
+				case "ours":
+					text = conflict.Ours
+				case "theirs":
+					text = conflict.Theirs
+				case "both":
+					text = conflict.Ours + conflict.Theirs
+				case "base":
+					text = conflict.Base
+				case "custom":
+					text = choice.Text
+				}
+				count := strings.Count(text, "\n")
+				spans[conflict.Index] = [2]int{line, line + count - 1}
+				b.WriteString(text)
+				line += count
+				i = conflict.EndLine - 1
+				continue
+			}
+		}
+		b.WriteString(lines[i])
+		line++
+	}
+	return b.String(), spans
+}
+
+// conflictDeclarations returns the declarations of file overlapping the
+// lines from first to last.
+func conflictDeclarations(fset *token.FileSet, file *ast.File, first, last int) []conflictDecl {
+	var decls []conflictDecl
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+		}
+		if fset.Position(start).Line > last || fset.Position(decl.End()).Line < first {
+			continue
+		}
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			decls = append(decls, conflictDecl{Symbol: funcSymbol(decl), Decl: printDecl(fset, decl)})
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if len(decl.Specs) > 1 && (fset.Position(spec.Pos()).Line > last || fset.Position(spec.End()).Line < first) {
+					continue
+				}
+				single := printDecl(fset, &ast.GenDecl{Tok: decl.Tok, Specs: []ast.Spec{spec}})
+				switch spec := spec.(type) {
+				case *ast.ImportSpec:
+					decls = append(decls, conflictDecl{Symbol: "import", Decl: single})
+				case *ast.TypeSpec:
+					decls = append(decls, conflictDecl{Symbol: "type " + spec.Name.Name, Decl: single})
+				case *ast.ValueSpec:
+					var names []string
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+					}
+					decls = append(decls, conflictDecl{Symbol: decl.Tok.String() + " " + strings.Join(names, ", "), Decl: single})
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// compilesWith builds the package of filePath, with its tests for a test
+// file, as if the file held content, and returns the compiler errors.
+func (t *LSPTools) compilesWith(ctx context.Context, s *server.MCPServer, filePath, content string) (bool, []buildError, error) {
+	if _, err := parser.ParseFile(token.NewFileSet(), filePath, content, parser.SkipObjectResolution); err != nil {
+		return false, []buildError{{File: path.Base(filePath), Message: err.Error()}}, nil
+	}
+	tmpDir, err := os.MkdirTemp("", "mcp-gopls-conflict-*")
+	if err != nil {
+		return false, nil, err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	source := filepath.Join(tmpDir, filepath.Base(filePath))
+	if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+		return false, nil, err
+	}
+	overlay, err := json.Marshal(map[string]any{"Replace": map[string]string{filePath: source}})
+	if err != nil {
+		return false, nil, err
+	}
+	overlayPath := filepath.Join(tmpDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0o644); err != nil {
+		return false, nil, err
+	}
+
+	args := []string{"build", "-overlay", overlayPath, "."}
+	if strings.HasSuffix(filePath, "_test.go") {
+		args = []string{"test", "-overlay", overlayPath, "-run", "^$", "-vet", "off", "-count", "1", "."}
+	}
+	result, err := t.runCommandSpec(ctx, s, nil, commandSpec{name: "go", args: args, dir: filepath.Dir(filePath)})
+	if err == nil {
+		return true, nil, nil
+	}
+	if ctx.Err() != nil {
+		return false, nil, ctx.Err()
+	}
+	errs := parseBuildErrors(result.Stdout + "\n" + result.Stderr)
+	if len(errs) == 0 {
+		errs = []buildError{{Message: buildCommandErrorMessage("go "+args[0], result, err)}}
+	}
+	return false, errs, nil
+}
+
+// otherConflictedFiles returns the other Go files of the directory of
+// filePath that hold conflict markers.
+func otherConflictedFiles(filePath string) []string {
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(filePath), "*.go"))
+	var others []string
+	for _, match := range matches {
+		if match == filePath {
+			continue
+		}
+		content, err := os.ReadFile(match)
+		if err != nil {
+			continue
+		}
+		if conflicts, _ := parseMergeConflicts(strings.SplitAfter(string(content), "\n")); len(conflicts) > 0 {
+			others = append(others, filepath.Base(match))
+		}
+	}
+	slices.Sort(others)
+	return others
+}
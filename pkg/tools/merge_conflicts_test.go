@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+const conflictedSource = `package calc
+
+<<<<<<< HEAD
+// Double doubles n.
+func Double(n int) int { return n * 2 }
+||||||| base
+func Double(n int) int { return n + n }
+=======
+func Double(n int) int { return twice(n) }
+>>>>>>> feature
+`
+
+func TestParseMergeConflicts(t *testing.T) {
+	lines := strings.SplitAfter(conflictedSource+"\n<<<<<<< HEAD\nconst A = 1\n=======\n>>>>>>> feature\n", "\n")
+	conflicts, err := parseMergeConflicts(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %+v", conflicts)
+	}
+	first := conflicts[0]
+	if first.StartLine != 3 || first.EndLine != 10 || first.OursLabel != "HEAD" || first.TheirsLabel != "feature" || !first.hasBase ||
+		first.Ours != "// Double doubles n.\nfunc Double(n int) int { return n * 2 }\n" || first.Theirs != "func Double(n int) int { return twice(n) }\n" {
+		t.Fatalf("unexpected first conflict %+v", first)
+	}
+	if conflicts[1].Ours != "const A = 1\n" || conflicts[1].Theirs != "" || conflicts[1].hasBase {
+		t.Fatalf("unexpected second conflict %+v", conflicts[1])
+	}
+
+	resolved, spans := resolveConflicts(lines, conflicts, map[int]conflictResolution{1: {Choice: "theirs"}, 2: {Choice: "both"}})
+	if resolved != "package calc\n\nfunc Double(n int) int { return twice(n) }\n\nconst A = 1\n" || spans[1] != [2]int{3, 3} || spans[2] != [2]int{5, 5} {
+		t.Fatalf("unexpected resolution %q %v", resolved, spans)
+	}
+
+	if _, err := parseMergeConflicts(strings.SplitAfter("<<<<<<< HEAD\na\n=======\n", "\n")); err == nil {
+		t.Fatal("expected an unclosed conflict to fail")
+	}
+}
+
+func TestAnalyzeMergeConflicts(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/calc\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "calc.go", conflictedSource)
+	uri := convertPathToURI(filepath.Join(workspace, "calc.go"))
+
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["file_uri"] = uri
+		result, err := server.GetTool("analyze_merge_conflicts").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "analyze_merge_conflicts", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("analyze_merge_conflicts: %v", err)
+		}
+		return result
+	}
+
+	analysis := structured(call(map[string]any{}))
+	conflict := analysis["conflicts"].([]any)[0].(map[string]any)
+	ours := conflict["ours_symbols"].([]any)[0].(map[string]any)
+	if ours["symbol"] != "func Double" || ours["decl"] != "func Double(n int) int" || conflict["base"] != "func Double(n int) int { return n + n }\n" {
+		t.Fatalf("unexpected conflict %v", conflict)
+	}
+	compiles := make(map[string]bool)
+	for _, candidate := range conflict["candidates"].([]any) {
+		candidate := candidate.(map[string]any)
+		compiles[candidate["choice"].(string)] = candidate["compiles"].(bool)
+	}
+	if !compiles["ours"] || compiles["theirs"] || compiles["both"] {
+		t.Fatalf("unexpected candidates %v", conflict["candidates"])
+	}
+
+	if result := call(map[string]any{"resolutions": []any{map[string]any{"conflict": 1, "choice": "theirs"}}, "apply": true}); !result.IsError {
+		t.Fatal("expected a resolution that does not compile to be refused")
+	}
+	result := structured(call(map[string]any{"resolutions": []any{map[string]any{"conflict": 1, "choice": "base"}}, "verify": false, "apply": true}))
+	if result["applied"] != true {
+		t.Fatalf("unexpected apply result %v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "calc.go")); string(data) != "package calc\n\nfunc Double(n int) int { return n + n }\n" {
+		t.Fatalf("conflict not resolved: %q", data)
+	}
+}
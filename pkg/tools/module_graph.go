@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// graphModule is a node of the module graph; Depth is its distance from
+// the main module, 0 for the main module itself.
+type graphModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Depth   int    `json:"depth"`
+}
+
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// moduleGraph is the requirement graph go mod graph prints, keyed by
+// path@version, or by path for the main modules.
+type moduleGraph struct {
+	roots    []string
+	requires map[string][]string
+	depth    map[string]int
+}
+
+// parseModuleGraph reads go mod graph output. The go and toolchain
+// pseudo-modules are left out.
+func parseModuleGraph(output string) *moduleGraph {
+	graph := &moduleGraph{requires: make(map[string][]string), depth: make(map[string]int)}
+	for line := range strings.Lines(output) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		from, to := fields[0], fields[1]
+		if strings.HasPrefix(to, "go@") || strings.HasPrefix(to, "toolchain@") {
+			continue
+		}
+		if !strings.Contains(from, "@") && !slices.Contains(graph.roots, from) {
+			graph.roots = append(graph.roots, from)
+		}
+		if !slices.Contains(graph.requires[from], to) {
+			graph.requires[from] = append(graph.requires[from], to)
+		}
+	}
+	queue := slices.Clone(graph.roots)
+	for _, root := range graph.roots {
+		graph.depth[root] = 0
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range graph.requires[node] {
+			if _, seen := graph.depth[next]; !seen {
+				graph.depth[next] = graph.depth[node] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return graph
+}
+
+// graphNodeMatches reports whether node is module, given as a path or path@version.
+func graphNodeMatches(node, module string) bool {
+	if strings.Contains(module, "@") {
+		return node == module
+	}
+	path, _, _ := strings.Cut(node, "@")
+	return path == module
+}
+
+// reaching returns the nodes from which one of targets can be reached,
+// targets included.
+func (g *moduleGraph) reaching(targets []string) map[string]bool {
+	requiredBy := make(map[string][]string)
+	for from, tos := range g.requires {
+		for _, to := range tos {
+			requiredBy[to] = append(requiredBy[to], from)
+		}
+	}
+	keep := make(map[string]bool)
+	queue := slices.Clone(targets)
+	for _, target := range targets {
+		keep[target] = true
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, from := range requiredBy[node] {
+			if !keep[from] {
+				keep[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+	return keep
+}
+
+// chains returns, for each requirement of the main modules that leads to
+// one of targets, the shortest chain from the main module through it.
+func (g *moduleGraph) chains(targets []string) [][]string {
+	var chains [][]string
+	for _, root := range g.roots {
+		for _, direct := range g.requires[root] {
+			parent := map[string]string{direct: ""}
+			queue := []string{direct}
+			for len(queue) > 0 {
+				node := queue[0]
+				queue = queue[1:]
+				if slices.Contains(targets, node) {
+					chain := []string{node}
+					for at := parent[node]; at != ""; at = parent[at] {
+						chain = append(chain, at)
+					}
+					chain = append(chain, root)
+					slices.Reverse(chain)
+					chains = append(chains, chain)
+					break
+				}
+				for _, next := range g.requires[node] {
+					if _, seen := parent[next]; !seen {
+						parent[next] = node
+						queue = append(queue, next)
+					}
+				}
+			}
+		}
+	}
+	return chains
+}
+
+// moduleGraphDOT renders edges in Graphviz DOT, the main modules boxed.
+func moduleGraphDOT(roots []string, edges []graphEdge) string {
+	var b strings.Builder
+	b.WriteString("digraph modules {\n\trankdir=LR;\n\tnode [shape=ellipse];\n")
+	for _, root := range roots {
+		fmt.Fprintf(&b, "\t%s [shape=box];\n", strconv.Quote(root))
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (t *LSPTools) registerModuleGraph(s *server.MCPServer) {
+	tool := mcp.NewTool("module_graph",
+		mcp.WithDescription("Return the Go module dependency graph from go mod graph: the modules with their version and depth (1 for the direct requirements of the main module) and the requirement edges between them, next to the raw go mod graph command result. module keeps only what leads to that module and lists, for each direct requirement responsible, the shortest chain from the main module to it, answering \"why is X in my build?\"; depth cuts the graph at that distance. with format dot the graph is returned in Graphviz DOT for visualization"),
+		mcp.WithTitleAnnotation("Module Graph"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("module",
+			mcp.Description("Only keep the paths leading to this module, given as a module path or path@version"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("Only keep modules at most this many requirements away from the main module"),
+		),
+		mcp.WithString("format",
+			mcp.Description("json (default) or dot"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		module := getOptionalStringArg(args, "module")
+		maxDepth := -1
+		if _, ok := args["depth"]; ok {
+			if maxDepth, err = getIntFromObject(args, "depth"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if maxDepth < 1 {
+				return mcp.NewToolResultError("depth must be positive"), nil
+			}
+		}
+		format := getOptionalStringArg(args, "format")
+		if format != "" && format != "json" && format != "dot" {
+			return mcp.NewToolResultError("format must be json or dot"), nil
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Building module graph")
+		// Disable per-line progress streaming here to avoid overwhelming clients
+		// with thousands of dependency lines.
+		result, err := t.runCommand(ctx, s, nil, "go", "mod", "graph")
+		if err != nil {
+			return t.commandFailureResult("go mod graph", result, err)
+		}
+		graph := parseModuleGraph(result.Stdout)
+
+		var targets []string
+		keep := func(node string) bool {
+			depth, reached := graph.depth[node]
+			return reached && (maxDepth < 0 || depth <= maxDepth)
+		}
+		if module != "" {
+			for node := range graph.depth {
+				if graphNodeMatches(node, module) {
+					targets = append(targets, node)
+				}
+			}
+			if len(targets) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("%s is not in the module graph", module)), nil
+			}
+			slices.Sort(targets)
+			reaching := graph.reaching(targets)
+			byDepth := keep
+			keep = func(node string) bool { return reaching[node] && byDepth(node) }
+		}
+
+		modules := []graphModule{}
+		edges := []graphEdge{}
+		for node, depth := range graph.depth {
+			if !keep(node) {
+				continue
+			}
+			path, version, _ := strings.Cut(node, "@")
+			modules = append(modules, graphModule{Path: path, Version: version, Depth: depth})
+			for _, to := range graph.requires[node] {
+				if keep(to) {
+					edges = append(edges, graphEdge{From: node, To: to})
+				}
+			}
+		}
+		slices.SortFunc(modules, func(a, b graphModule) int {
+			if a.Depth != b.Depth {
+				return a.Depth - b.Depth
+			}
+			if a.Path != b.Path {
+				return strings.Compare(a.Path, b.Path)
+			}
+			return strings.Compare(a.Version, b.Version)
+		})
+		slices.SortFunc(edges, func(a, b graphEdge) int {
+			if a.From != b.From {
+				return strings.Compare(a.From, b.From)
+			}
+			return strings.Compare(a.To, b.To)
+		})
+
+		payload := map[string]any{
+			"main":  graph.roots,
+			"count": len(modules),
+		}
+		if format == "dot" {
+			payload["dot"] = moduleGraphDOT(graph.roots, edges)
+		} else {
+			payload["result"] = result
+			payload["modules"] = modules
+			payload["edges"] = edges
+		}
+		if module != "" {
+			payload["module"] = module
+			payload["matches"] = targets
+			payload["chains"] = graph.chains(targets)
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+const testModuleGraph = `example.com/app go@1.22
+example.com/app github.com/a/web@v1.2.0
+example.com/app github.com/b/log@v1.0.0
+github.com/a/web@v1.2.0 github.com/c/yaml@v3.0.1
+github.com/a/web@v1.2.0 github.com/b/log@v1.1.0
+github.com/b/log@v1.0.0 github.com/c/yaml@v3.0.0
+github.com/c/yaml@v3.0.1 toolchain@go1.22.1
+`
+
+func TestParseModuleGraph(t *testing.T) {
+	graph := parseModuleGraph(testModuleGraph)
+	if !slices.Equal(graph.roots, []string{"example.com/app"}) {
+		t.Fatalf("unexpected roots %v", graph.roots)
+	}
+	if _, ok := graph.depth["go@1.22"]; ok {
+		t.Fatal("expected the go pseudo-module to be left out")
+	}
+	if graph.depth["github.com/b/log@v1.0.0"] != 1 || graph.depth["github.com/c/yaml@v3.0.1"] != 2 {
+		t.Fatalf("unexpected depths %v", graph.depth)
+	}
+
+	chains := graph.chains([]string{"github.com/c/yaml@v3.0.0", "github.com/c/yaml@v3.0.1"})
+	want := [][]string{
+		{"example.com/app", "github.com/a/web@v1.2.0", "github.com/c/yaml@v3.0.1"},
+		{"example.com/app", "github.com/b/log@v1.0.0", "github.com/c/yaml@v3.0.0"},
+	}
+	if !slices.EqualFunc(chains, want, slices.Equal) {
+		t.Fatalf("unexpected chains %v", chains)
+	}
+}
+
+func TestModuleGraph(t *testing.T) {
+	tools := NewLSPTools(nil, t.TempDir())
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		return commandResult{Stdout: testModuleGraph}, nil
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("module_graph").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "module_graph", Arguments: args},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	graph := structured(call(map[string]any{}))
+	if graph["count"] != float64(6) || len(graph["edges"].([]any)) != 5 || graph["result"].(map[string]any)["stdout"] != testModuleGraph {
+		t.Fatalf("unexpected graph %v", graph)
+	}
+
+	shallow := structured(call(map[string]any{"depth": 1}))
+	if shallow["count"] != float64(3) || len(shallow["edges"].([]any)) != 2 {
+		t.Fatalf("unexpected depth-limited graph %v", shallow)
+	}
+
+	why := structured(call(map[string]any{"module": "github.com/b/log"}))
+	if why["count"] != float64(4) || len(why["matches"].([]any)) != 2 || len(why["chains"].([]any)) != 2 {
+		t.Fatalf("unexpected filtered graph %v", why)
+	}
+	for _, module := range why["modules"].([]any) {
+		if strings.HasPrefix(module.(map[string]any)["path"].(string), "github.com/c/") {
+			t.Fatalf("expected modules not leading to github.com/b/log to be left out, got %v", module)
+		}
+	}
+
+	dot := structured(call(map[string]any{"module": "github.com/c/yaml@v3.0.0", "format": "dot"}))
+	if want := "\t\"example.com/app\" -> \"github.com/b/log@v1.0.0\";\n\t\"github.com/b/log@v1.0.0\" -> \"github.com/c/yaml@v3.0.0\";\n"; !strings.Contains(dot["dot"].(string), want) {
+		t.Fatalf("unexpected dot %q", dot["dot"])
+	}
+
+	if result := call(map[string]any{"module": "github.com/missing/mod"}); !result.IsError {
+		t.Fatal("expected an error for a module outside the graph")
+	}
+}
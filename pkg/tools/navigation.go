@@ -3,21 +3,63 @@ package tools
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// LSP SymbolKinds gopls reports for type declarations: structs, interfaces
+// and other named types, and for functions and methods.
+const (
+	symbolKindClass     = 5
+	symbolKindMethod    = 6
+	symbolKindInterface = 11
+	symbolKindFunction  = 12
+	symbolKindStruct    = 23
 )
 
 func (t *LSPTools) registerNavigationTools(s *server.MCPServer) {
 	t.registerGoToDefinition(s)
 	t.registerFindReferences(s)
+	t.registerDocumentHighlight(s)
+	t.registerFindImplementations(s)
+	t.registerCallHierarchy(s)
+	t.registerCallGraph(s)
+	t.registerDataFlow(s)
+	t.registerShowSSA(s)
+	t.registerGCDetails(s)
+	t.registerShowAssembly(s)
+	t.registerTypeHierarchy(s)
+	t.registerDocumentSymbols(s)
+	t.registerFoldingRanges(s)
+	t.registerSelectionRange(s)
+	t.registerFindUsageExamples(s)
 }
 
 func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
-	definitionTool := mcp.NewTool("go_to_definition",
-		mcp.WithDescription("Navigate to the definition of a symbol"),
-		mcp.WithTitleAnnotation("Go To Definition"),
+	t.registerLocationTool(s, "go_to_definition", "Go To Definition",
+		"Navigate to the definition of a symbol",
+		client.LSPClient.GoToDefinition)
+	t.registerLocationTool(s, "go_to_type_definition", "Go To Type Definition",
+		"Navigate to the definition of the type of a symbol: from a variable, field, parameter or expression to the declaration of its named type, through pointers, slices, maps and channels",
+		client.LSPClient.GoToTypeDefinition)
+	t.registerLocationTool(s, "go_to_declaration", "Go To Declaration",
+		"Navigate to the declaration of a symbol. In Go a symbol is declared where it is defined, so this matches go_to_definition; it is provided for clients that ask for declarations",
+		client.LSPClient.GoToDeclaration)
+}
+
+// registerLocationTool registers a tool that answers a position with the
+// locations lookup returns.
+func (t *LSPTools) registerLocationTool(s *server.MCPServer, name, title, description string, lookup func(client.LSPClient, context.Context, string, int, int) ([]protocol.Location, error)) {
+	tool := mcp.NewTool(name,
+		mcp.WithDescription(description),
+		mcp.WithTitleAnnotation(title),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("file_uri",
 			mcp.Required(),
@@ -29,7 +71,7 @@ func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
 		),
 	)
 
-	s.AddTool(definitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, err := getArguments(request)
 		if err != nil {
 			return nil, err
@@ -54,7 +96,7 @@ func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
 			return nil, fmt.Errorf("LSP client not available")
 		}
 
-		locations, err := lspClient.GoToDefinition(ctx, fileURI, line, character)
+		locations, err := lookup(lspClient, ctx, fileURI, line, character)
 		if err != nil {
 			return nil, t.handleLSPError(err)
 		}
@@ -130,3 +172,130 @@ func (t *LSPTools) registerFindReferences(s *server.MCPServer) {
 		return result, nil
 	})
 }
+
+type implementation struct {
+	Name     string            `json:"name,omitempty"`
+	Location protocol.Location `json:"location"`
+}
+
+func (t *LSPTools) registerFindImplementations(s *server.MCPServer) {
+	tool := mcp.NewTool("find_implementations",
+		mcp.WithDescription("Find the types implementing an interface (including through embedded interfaces), or the interfaces a type implements, via gopls"),
+		mcp.WithTitleAnnotation("Find Implementations"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Description("URI of the file containing the symbol; required with position"),
+		),
+		mcp.WithObject("position",
+			mcp.Description("Position of the interface, type or method"),
+		),
+		mcp.WithString("interface",
+			mcp.Description("Interface name (Name or pkg.Name) to look up instead of a position"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not available")
+		}
+
+		var fileURI string
+		var line, character int
+		if name := getOptionalStringArg(args, "interface"); name != "" {
+			location, problem, err := t.lookupTypeSymbol(ctx, lspClient, name, "interface", symbolKindInterface)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+			fileURI = location.URI
+			line, character = location.Range.Start.Line, location.Range.Start.Character
+		} else {
+			var err error
+			if fileURI, err = getStringArg(args, "file_uri"); err != nil {
+				return mcp.NewToolResultError("pass file_uri and position, or interface"), nil
+			}
+			if line, character, err = parsePosition(args); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !strings.HasPrefix(fileURI, "file://") {
+				fileURI = convertPathToURI(fileURI)
+			}
+		}
+
+		locations, err := lspClient.FindImplementations(ctx, fileURI, line, character)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		implementations := make([]implementation, 0, len(locations))
+		for _, location := range locations {
+			implementations = append(implementations, implementation{
+				Name:     locationText(location),
+				Location: location,
+			})
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri":        fileURI,
+			"position":        protocol.Position{Line: line, Character: character},
+			"implementations": implementations,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// lookupTypeSymbol finds the declaration of a type given as Name or
+// pkg.Name among the workspace symbols of the given kinds. A name that is
+// unknown or ambiguous is reported as a problem for the caller to return to
+// the user.
+func (t *LSPTools) lookupTypeSymbol(ctx context.Context, lspClient client.LSPClient, name, noun string, kinds ...int) (protocol.Location, string, error) {
+	symbols, err := lspClient.WorkspaceSymbols(ctx, name)
+	if err != nil {
+		return protocol.Location{}, "", err
+	}
+	var matches []protocol.SymbolInformation
+	for _, symbol := range symbols {
+		if slices.Contains(kinds, symbol.Kind) && (symbol.Name == name || strings.HasSuffix(symbol.Name, "."+name)) {
+			matches = append(matches, symbol)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return protocol.Location{}, fmt.Sprintf("no %s named %q found in the workspace", noun, name), nil
+	case 1:
+		return matches[0].Location, "", nil
+	}
+	candidates := make([]string, 0, len(matches))
+	for _, match := range matches {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", match.Name, relativeSlashPath(t.workspaceDir, convertURIToPath(match.Location.URI))))
+	}
+	return protocol.Location{}, fmt.Sprintf("%s name %q is ambiguous: %s; qualify it or pass a position", noun, name, strings.Join(candidates, ", ")), nil
+}
+
+// locationText returns the single-line source text a location covers,
+// which for implementation results is the type or method name.
+func locationText(location protocol.Location) string {
+	start, end := location.Range.Start, location.Range.End
+	if start.Line != end.Line || end.Character <= start.Character {
+		return ""
+	}
+	data, err := os.ReadFile(convertURIToPath(location.URI))
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	if start.Line >= len(lines) {
+		return ""
+	}
+	text := []rune(lines[start.Line])
+	if end.Character > len(text) {
+		return ""
+	}
+	return string(text[start.Character:end.Character])
+}
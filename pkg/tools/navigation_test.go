@@ -0,0 +1,415 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestFindImplementationsByInterfaceName(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n\ntype Store interface{ Get() }\n\ntype memStore struct{}\n\nfunc (memStore) Get() {}\n")
+	uri := convertPathToURI(workspace + "/store/store.go")
+	span := func(line, start, end int) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		}
+	}
+
+	fakeClient := &fakeLSPClient{
+		symbols: []protocol.SymbolInformation{
+			{Name: "store.Store", Kind: symbolKindInterface, Location: protocol.Location{URI: uri, Range: span(2, 5, 10)}},
+			{Name: "store.memStore", Kind: 23, Location: protocol.Location{URI: uri, Range: span(4, 5, 13)}},
+			{Name: "cache.Store", Kind: symbolKindInterface, Location: protocol.Location{URI: "file:///other/cache.go"}},
+		},
+		impls: []protocol.Location{{URI: uri, Range: span(4, 5, 13)}},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("find_implementations").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "find_implementations", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("find_implementations: %v", err)
+		}
+		return result
+	}
+
+	ambiguous := call(map[string]any{"interface": "Store"})
+	if !ambiguous.IsError {
+		t.Fatalf("expected an ambiguity error, got %#v", ambiguous)
+	}
+	if text := ambiguous.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "cache.Store") {
+		t.Fatalf("ambiguity error should list the candidates, got %q", text)
+	}
+
+	content := structured(call(map[string]any{"interface": "store.Store"}))
+	position := content["position"].(map[string]any)
+	if content["file_uri"] != uri || position["line"] != float64(2) || position["character"] != float64(5) {
+		t.Fatalf("unexpected lookup %#v", content)
+	}
+	impls := content["implementations"].([]any)
+	if len(impls) != 1 || impls[0].(map[string]any)["name"] != "memStore" {
+		t.Fatalf("unexpected implementations %#v", impls)
+	}
+
+	if missing := call(map[string]any{"interface": "Nope"}); !missing.IsError {
+		t.Fatalf("expected an error for an unknown interface, got %#v", missing)
+	}
+	if noArgs := call(map[string]any{}); !noArgs.IsError {
+		t.Fatalf("expected an error without position or interface, got %#v", noArgs)
+	}
+}
+
+func TestWalkCallHierarchy(t *testing.T) {
+	item := func(name string, line int) protocol.CallHierarchyItem {
+		return protocol.CallHierarchyItem{
+			Name:           name,
+			URI:            "file:///app/" + name + ".go",
+			SelectionRange: protocol.Range{Start: protocol.Position{Line: line}},
+		}
+	}
+	site := protocol.Range{Start: protocol.Position{Line: 9, Character: 2}}
+	save, handler, main, retry := item("Save", 1), item("handler", 2), item("main", 3), item("retry", 4)
+	fakeClient := &fakeLSPClient{
+		incoming: map[string][]protocol.CallHierarchyIncomingCall{
+			"Save":    {{From: handler, FromRanges: []protocol.Range{site}}, {From: retry, FromRanges: []protocol.Range{site, site}}},
+			"handler": {{From: main, FromRanges: []protocol.Range{site}}},
+			// retry calls itself.
+			"retry": {{From: retry, FromRanges: []protocol.Range{site}}},
+		},
+	}
+
+	entries, err := walkCallHierarchy(context.Background(), fakeClient, []protocol.CallHierarchyItem{save}, true, 2)
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		line := fmt.Sprintf("%d %s<-%s sites=%d", entry.Depth, entry.Parent, entry.Name, len(entry.CallSites))
+		if entry.Truncated {
+			line += " truncated"
+		}
+		got = append(got, line)
+	}
+	want := []string{
+		"1 Save<-handler sites=1",
+		"1 Save<-retry sites=2",
+		"2 handler<-main sites=1",
+		"2 retry<-retry sites=1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries = %#v, want %#v", got, want)
+	}
+	if entries[0].CallSites[0].URI != handler.URI {
+		t.Fatalf("incoming call sites should be in the caller's file, got %s", entries[0].CallSites[0].URI)
+	}
+
+	fakeClient.outgoing = map[string][]protocol.CallHierarchyOutgoingCall{"main": {{To: handler, FromRanges: []protocol.Range{site}}}}
+	entries, err = walkCallHierarchy(context.Background(), fakeClient, []protocol.CallHierarchyItem{main}, false, 1)
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "handler" || entries[0].CallSites[0].URI != main.URI {
+		t.Fatalf("unexpected outgoing calls %+v", entries)
+	}
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	item := func(name string, kind, line int) protocol.TypeHierarchyItem {
+		return protocol.TypeHierarchyItem{
+			Name:           name,
+			Kind:           kind,
+			URI:            "file:///app/store.go",
+			SelectionRange: protocol.Range{Start: protocol.Position{Line: line, Character: 5}},
+		}
+	}
+	reader, store, memStore, cached := item("Reader", symbolKindInterface, 1), item("Store", symbolKindInterface, 2), item("memStore", symbolKindStruct, 3), item("cachedStore", symbolKindStruct, 4)
+	fakeClient := &fakeLSPClient{
+		symbols: []protocol.SymbolInformation{
+			{Name: "store.Store", Kind: symbolKindInterface, Location: protocol.Location{URI: store.URI, Range: store.SelectionRange}},
+			{Name: "store.NewStore", Kind: 12, Location: protocol.Location{URI: store.URI}},
+		},
+		typeItems:  []protocol.TypeHierarchyItem{store},
+		supertypes: map[string][]protocol.TypeHierarchyItem{"Store": {reader}},
+		subtypes: map[string][]protocol.TypeHierarchyItem{
+			"Store": {memStore, cached},
+			// A cycle back to the root must not be expanded again.
+			"cachedStore": {store},
+		},
+	}
+	tools := NewLSPTools(fakeClient, t.TempDir())
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("type_hierarchy").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "type_hierarchy", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("type_hierarchy: %v", err)
+		}
+		return result
+	}
+
+	content := structured(call(map[string]any{"type": "Store", "depth": 2}))
+	describe := func(key string) []string {
+		var got []string
+		for _, raw := range content[key].([]any) {
+			entry := raw.(map[string]any)
+			line := fmt.Sprintf("%v %s>%s %s", entry["depth"], entry["parent"], entry["name"], entry["kind"])
+			if entry["truncated"] == true {
+				line += " truncated"
+			}
+			got = append(got, line)
+		}
+		return got
+	}
+	if got, want := describe("supertypes"), []string{"1 Store>Reader interface"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("supertypes = %#v, want %#v", got, want)
+	}
+	wantSubtypes := []string{
+		"1 Store>memStore struct",
+		"1 Store>cachedStore struct",
+		"2 cachedStore>Store interface",
+	}
+	if got := describe("subtypes"); !reflect.DeepEqual(got, wantSubtypes) {
+		t.Fatalf("subtypes = %#v, want %#v", got, wantSubtypes)
+	}
+
+	content = structured(call(map[string]any{"file_uri": store.URI, "position": map[string]any{"line": 2, "character": 5}, "direction": "supertypes"}))
+	if _, ok := content["subtypes"]; ok {
+		t.Fatalf("subtypes should be omitted for direction supertypes: %#v", content)
+	}
+	if invalid := call(map[string]any{"type": "Store", "direction": "sideways"}); !invalid.IsError {
+		t.Fatalf("expected an error for an invalid direction, got %#v", invalid)
+	}
+	if missing := call(map[string]any{"type": "NewStore"}); !missing.IsError {
+		t.Fatalf("functions should not resolve as types, got %#v", missing)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n")
+	writeWorkspaceFile(t, workspace, "store/mem.go", "package store\n")
+	writeWorkspaceFile(t, workspace, "store/store_test.go", "package store\n")
+	span := func(start, end int) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end}}
+	}
+	storeURI := convertPathToURI(workspace + "/store/store.go")
+	fakeClient := &fakeLSPClient{outline: map[string][]protocol.DocumentSymbol{
+		storeURI: {
+			{Name: "Store", Kind: symbolKindStruct, Detail: "struct{...}", Range: span(2, 5), SelectionRange: protocol.Range{Start: protocol.Position{Line: 2, Character: 5}}, Children: []protocol.DocumentSymbol{
+				{Name: "items", Kind: 8, Detail: "map[string]string", Range: span(3, 3), SelectionRange: span(3, 3)},
+			}},
+			{Name: "(*Store).Get", Kind: 6, Detail: "func(key string) string", Range: span(7, 9), SelectionRange: span(7, 7)},
+		},
+		convertPathToURI(workspace + "/store/mem.go"): {{Name: "mem", Kind: 13, Range: span(2, 2)}},
+	}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+
+	call := func(args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("document_symbols").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "document_symbols", Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("document_symbols: %v %#v", err, result)
+		}
+		return structured(result)
+	}
+
+	content := call(map[string]any{"file_uri": storeURI})
+	symbols := content["symbols"].([]any)
+	store := symbols[0].(map[string]any)
+	if content["path"] != "store/store.go" || len(symbols) != 2 || store["kind"] != "struct" || store["line"] != float64(3) || store["end_line"] != float64(6) {
+		t.Fatalf("unexpected outline %#v", content)
+	}
+	if field := store["children"].([]any)[0].(map[string]any); field["name"] != "items" || field["kind"] != "field" {
+		t.Fatalf("unexpected field %#v", field)
+	}
+
+	content = call(map[string]any{"file_uri": workspace + "/store", "depth": 1})
+	var paths []string
+	for _, raw := range content["files"].([]any) {
+		file := raw.(map[string]any)
+		paths = append(paths, file["path"].(string))
+		for _, symbol := range file["symbols"].([]any) {
+			if _, ok := symbol.(map[string]any)["children"]; ok {
+				t.Fatalf("depth 1 should drop children: %#v", symbol)
+			}
+		}
+	}
+	if content["directory"] != "store" || !reflect.DeepEqual(paths, []string{"store/mem.go", "store/store.go"}) {
+		t.Fatalf("unexpected package outline %#v", content)
+	}
+}
+
+func TestFoldingRanges(t *testing.T) {
+	workspace := t.TempDir()
+	src := "package app\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\n// Run prints\n// its arguments.\nfunc Run() {\n\tif len(os.Args) > 1 {\n\t\tfmt.Println(os.Args)\n\t}\n}\n"
+	writeWorkspaceFile(t, workspace, "app.go", src)
+	uri := convertPathToURI(workspace + "/app.go")
+	fakeClient := &fakeLSPClient{folds: []protocol.FoldingRange{
+		{StartLine: 10, EndLine: 11},
+		{StartLine: 9, EndLine: 12},
+		{StartLine: 2, EndLine: 4, Kind: "imports"},
+		{StartLine: 7, EndLine: 8, Kind: "comment"},
+	}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+
+	call := func(args map[string]any) map[string]any {
+		t.Helper()
+		args["file_uri"] = uri
+		result, err := server.GetTool("folding_ranges").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "folding_ranges", Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("folding_ranges: %v %#v", err, result)
+		}
+		return structured(result)
+	}
+
+	content := call(map[string]any{"skeleton": true})
+	folds := content["folds"].([]any)
+	first, body := folds[0].(map[string]any), folds[2].(map[string]any)
+	if len(folds) != 4 || first["kind"] != "imports" || first["line"] != float64(3) || first["end_line"] != float64(5) || first["lines"] != float64(2) {
+		t.Fatalf("unexpected folds %#v", folds)
+	}
+	if body["header"] != "func Run() {" || body["kind"] != "code" || body["lines"] != float64(3) {
+		t.Fatalf("unexpected function fold %#v", body)
+	}
+	want := "package app\n\nimport (\n\t// ... lines 4-5 folded\n)\n\n// Run prints\n// ... line 9 folded\nfunc Run() {\n\t// ... lines 11-13 folded\n}\n"
+	if content["skeleton"] != want {
+		t.Fatalf("unexpected skeleton:\n%s", content["skeleton"])
+	}
+
+	content = call(map[string]any{"min_lines": 2, "kind": "code"})
+	if folds := content["folds"].([]any); len(folds) != 1 || folds[0].(map[string]any)["line"] != float64(10) || content["skeleton"] != nil {
+		t.Fatalf("unexpected filtered folds %#v", content)
+	}
+}
+
+func TestSelectionRange(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "sum.go", "package app\n\nfunc Sum(xs []int) int {\n\ttotal := 0\n\tfor _, x := range xs {\n\t\ttotal += x\n\t}\n\treturn total\n}\n")
+	uri := convertPathToURI(workspace + "/sum.go")
+	span := func(line, char, endLine, endChar int, parent *protocol.SelectionRange) *protocol.SelectionRange {
+		return &protocol.SelectionRange{Parent: parent, Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: char},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}}
+	}
+	function := span(2, 0, 8, 1, nil)
+	loop := span(4, 1, 6, 2, span(2, 23, 8, 1, function))
+	fakeClient := &fakeLSPClient{selections: []protocol.SelectionRange{*span(5, 11, 5, 12, span(5, 2, 5, 12, span(4, 22, 6, 2, loop)))}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+
+	result, err := server.GetTool("selection_range").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "selection_range", Arguments: map[string]any{
+			"file_uri": uri,
+			"position": map[string]any{"line": 5, "character": 11},
+		}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("selection_range: %v %#v", err, result)
+	}
+	selections := structured(result)["selections"].([]any)
+	if len(selections) != 6 {
+		t.Fatalf("expected the whole chain, got %#v", selections)
+	}
+	want := []struct{ node, kind, text string }{
+		{"Ident", "expression", "x"},
+		{"AssignStmt", "statement", "total += x"},
+		{"BlockStmt", "block", "{\n\t\ttotal += x\n\t}"},
+		{"RangeStmt", "statement", "for _, x := range xs {\n\t\ttotal += x\n\t}"},
+		{"BlockStmt", "block", ""},
+		{"FuncDecl", "function", ""},
+	}
+	for i, w := range want {
+		got := selections[i].(map[string]any)
+		if got["level"] != float64(i) || got["node"] != w.node || got["kind"] != w.kind || w.text != "" && got["text"] != w.text {
+			t.Fatalf("unexpected selection %d: %#v", i, got)
+		}
+	}
+	if selections[5].(map[string]any)["line"] != float64(3) || selections[5].(map[string]any)["end_line"] != float64(9) {
+		t.Fatalf("unexpected function lines %#v", selections[5])
+	}
+}
+
+func TestDocumentHighlight(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "count.go", "package count\n\nvar total int\n\nfunc Add(n int) {\n\ttotal += n\n}\n\nfunc Get() int {\n\treturn total\n}\n")
+	uri := convertPathToURI(workspace + "/count.go")
+	highlight := func(line, start, kind int) protocol.DocumentHighlight {
+		return protocol.DocumentHighlight{Kind: kind, Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: start + 5},
+		}}
+	}
+	fakeClient := &fakeLSPClient{highlights: []protocol.DocumentHighlight{
+		highlight(9, 8, 2),
+		highlight(2, 4, 1),
+		highlight(5, 1, 3),
+	}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(scope string) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("document_highlight").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "document_highlight", Arguments: map[string]any{
+				"file_uri": uri,
+				"position": map[string]any{"line": 5, "character": 2},
+				"scope":    scope,
+			}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("document_highlight: %v %#v", err, result)
+		}
+		return structured(result)
+	}
+
+	file := call("file")
+	var got []string
+	for _, entry := range file["occurrences"].([]any) {
+		entry := entry.(map[string]any)
+		got = append(got, fmt.Sprintf("%v:%v %v", entry["line"], entry["kind"], entry["code"]))
+	}
+	want := []string{"3:text var total int", "6:write total += n", "10:read return total"}
+	if !reflect.DeepEqual(got, want) || file["symbol"] != "total" || file["reads"] != float64(1) || file["writes"] != float64(1) {
+		t.Fatalf("occurrences = %v (%#v)\nwant %v", got, file, want)
+	}
+
+	function := call("function")
+	if occurrences := function["occurrences"].([]any); len(occurrences) != 1 || function["function"] != "Add" || function["writes"] != float64(1) {
+		t.Fatalf("unexpected function scope %#v", function)
+	}
+}
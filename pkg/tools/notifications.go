@@ -16,14 +16,25 @@ func getProgressToken(meta *mcp.Meta) mcp.ProgressToken {
 	return meta.ProgressToken
 }
 
-func sendProgressNotification(ctx context.Context, srv *server.MCPServer, token mcp.ProgressToken, message string) {
-	if srv == nil || token == nil {
-		return
-	}
+type progressSinkKey struct{}
+
+// WithProgressSink returns a context whose tool calls also hand their
+// progress messages and command output lines to sink, whether or not the
+// client asked for progress notifications.
+func WithProgressSink(ctx context.Context, sink func(message string)) context.Context {
+	return context.WithValue(ctx, progressSinkKey{}, sink)
+}
 
+func sendProgressNotification(ctx context.Context, srv *server.MCPServer, token mcp.ProgressToken, message string) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if sink, ok := ctx.Value(progressSinkKey{}).(func(string)); ok {
+		sink(message)
+	}
+	if srv == nil || token == nil {
+		return
+	}
 
 	payload, err := protocol.NewProgressNotification(token, 0, message)
 	if err != nil {
@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+)
+
+// Briefing summarizes a workspace for an agent starting a session: the
+// module, its layout, the conventions it follows and the commands that
+// build and check it. The server adds the tools it serves.
+type Briefing struct {
+	Root          string         `json:"root"`
+	Module        string         `json:"module,omitempty"`
+	GoVersion     string         `json:"go_version,omitempty"`
+	Packages      int            `json:"packages"`
+	Directories   []string       `json:"directories,omitempty"`
+	Conventions   []string       `json:"conventions,omitempty"`
+	BuildCommands []BuildCommand `json:"build_commands"`
+	Tools         []string       `json:"tools,omitempty"`
+	Warnings      []string       `json:"warnings,omitempty"`
+}
+
+// BuildCommand is a command the workspace is known to be built or checked
+// with, and where it was found: a Makefile, Taskfile or magefile target, a
+// CI workflow, or go for the standard commands.
+type BuildCommand struct {
+	Command     string `json:"command"`
+	Source      string `json:"source"`
+	Description string `json:"description,omitempty"`
+}
+
+// WorkspaceBriefing builds the briefing of the workspace at root from its
+// files alone, without gopls. Files that cannot be parsed are reported as
+// warnings.
+func WorkspaceBriefing(root string) (Briefing, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return Briefing{}, err
+	}
+	briefing := Briefing{Root: root, GoVersion: readGoModVersion(root)}
+	if modPath, err := readModulePath(root); err == nil {
+		briefing.Module = modPath
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			briefing.Directories = append(briefing.Directories, entry.Name())
+		}
+	}
+
+	packages := make(map[string]bool)
+	var tests, golden bool
+	err = walkWorkspaceFiles(root, func(path string) error {
+		rel := relativeSlashPath(root, path)
+		if strings.HasPrefix(rel, "testdata/") || strings.Contains(rel, "/testdata/") {
+			golden = golden || strings.HasSuffix(path, ".golden")
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") {
+			packages[filepath.Dir(path)] = true
+			tests = tests || strings.HasSuffix(path, "_test.go")
+		}
+		return nil
+	})
+	if err != nil {
+		return Briefing{}, err
+	}
+	briefing.Packages = len(packages)
+
+	briefing.Conventions = workspaceConventions(root, &briefing, tests, golden)
+	briefing.BuildCommands = workspaceBuildCommands(root, &briefing)
+	return briefing, nil
+}
+
+func workspaceConventions(root string, briefing *Briefing, tests, golden bool) []string {
+	var conventions []string
+
+	formatter := "Formatted with gofmt"
+	cfg, err := projectconfig.Load(root)
+	if err != nil {
+		briefing.Warnings = append(briefing.Warnings, err.Error())
+	} else if enabled, _ := cfg.Gopls["gofumpt"].(bool); enabled {
+		formatter = "Formatted with gofumpt (gopls gofumpt setting)"
+	}
+	conventions = append(conventions, formatter)
+	if fileExists(filepath.Join(root, projectconfig.FileName)) {
+		conventions = append(conventions, "Project settings in "+projectconfig.FileName)
+	}
+	for _, name := range golangciConfigNames {
+		if fileExists(filepath.Join(root, name)) {
+			conventions = append(conventions, "Linted with golangci-lint ("+name+")")
+			break
+		}
+	}
+	if fileExists(filepath.Join(root, "go.work")) {
+		conventions = append(conventions, "go.work workspace: the modules it uses resolve to their local copies")
+	}
+	if fileExists(filepath.Join(root, "vendor", "modules.txt")) {
+		conventions = append(conventions, "Dependencies are vendored: run go mod vendor after changing go.mod")
+	}
+	if slices.Contains(briefing.Directories, "cmd") {
+		conventions = append(conventions, "Binaries live under cmd/")
+	}
+	if slices.Contains(briefing.Directories, "internal") {
+		conventions = append(conventions, "Packages private to the module live under internal/")
+	}
+
+	if tests {
+		framework := "Tests use the standard testing package"
+		if requiresModule(root, "github.com/stretchr/testify") {
+			framework = "Tests use github.com/stretchr/testify"
+		}
+		conventions = append(conventions, framework)
+	}
+	if golden {
+		conventions = append(conventions, "Golden files (*.golden) under testdata; regenerate them with update_golden_files")
+	}
+	return conventions
+}
+
+// workspaceBuildCommands lists the build targets at root, then the Go
+// commands CI runs, then the standard go commands not already listed.
+func workspaceBuildCommands(root string, briefing *Briefing) []BuildCommand {
+	var commands []BuildCommand
+	seen := make(map[string]bool)
+	add := func(command BuildCommand) {
+		if !seen[command.Command] {
+			seen[command.Command] = true
+			commands = append(commands, command)
+		}
+	}
+
+	targets, err := discoverBuildTargets(root)
+	if err != nil {
+		briefing.Warnings = append(briefing.Warnings, err.Error())
+	}
+	for _, target := range targets {
+		add(BuildCommand{Command: target.Runner + " " + target.Name, Source: target.File, Description: target.Description})
+	}
+
+	jobs, _, err := collectCIJobs(root)
+	if err != nil {
+		briefing.Warnings = append(briefing.Warnings, err.Error())
+	}
+	for _, job := range jobs {
+		for _, command := range job.Commands {
+			add(BuildCommand{Command: command, Source: job.File})
+		}
+	}
+
+	for _, command := range []string{"go build ./...", "go vet ./...", "go test ./..."} {
+		add(BuildCommand{Command: command, Source: "go"})
+	}
+	return commands
+}
+
+// requiresModule reports whether the go.mod at root requires path.
+func requiresModule(root, path string) bool {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return false
+	}
+	file, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(file.Require, func(r *modfile.Require) bool { return r.Mod.Path == path })
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWorkspaceBriefing(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.26\n\nrequire github.com/stretchr/testify v1.9.0\n")
+	writeWorkspaceFile(t, workspace, ".mcp-gopls.json", `{"gopls": {"gofumpt": true}}`)
+	writeWorkspaceFile(t, workspace, ".golangci.yml", "linters: {}\n")
+	writeWorkspaceFile(t, workspace, "Makefile", "## Build the binary\nbuild:\n\tgo build ./cmd/app\n\ntest:\n\tgo test ./...\n")
+	writeWorkspaceFile(t, workspace, ".github/workflows/ci.yml", "on: push\njobs:\n  test:\n    runs-on: ubuntu-latest\n    steps:\n      - run: go test -race ./...\n      - run: make build\n")
+	writeWorkspaceFile(t, workspace, "cmd/app/main.go", "package main\n")
+	writeWorkspaceFile(t, workspace, "internal/store/store.go", "package store\n")
+	writeWorkspaceFile(t, workspace, "internal/store/store_test.go", "package store\n")
+	writeWorkspaceFile(t, workspace, "internal/store/testdata/list.golden", "[]\n")
+	writeWorkspaceFile(t, workspace, "internal/store/testdata/fixture.go", "package fixture\n")
+
+	briefing, err := WorkspaceBriefing(workspace)
+	if err != nil {
+		t.Fatalf("WorkspaceBriefing: %v", err)
+	}
+	if briefing.Module != "example.com/app" || briefing.GoVersion != "1.26" || briefing.Packages != 2 {
+		t.Fatalf("unexpected briefing %+v", briefing)
+	}
+	if !slices.Equal(briefing.Directories, []string{"cmd", "internal"}) {
+		t.Fatalf("unexpected directories %v", briefing.Directories)
+	}
+	for _, want := range []string{
+		"Formatted with gofumpt (gopls gofumpt setting)",
+		"Linted with golangci-lint (.golangci.yml)",
+		"Binaries live under cmd/",
+		"Tests use github.com/stretchr/testify",
+		"Golden files (*.golden) under testdata; regenerate them with update_golden_files",
+	} {
+		if !slices.Contains(briefing.Conventions, want) {
+			t.Fatalf("missing convention %q in %v", want, briefing.Conventions)
+		}
+	}
+
+	var commands []string
+	for _, command := range briefing.BuildCommands {
+		commands = append(commands, command.Command+" @ "+command.Source)
+	}
+	want := []string{
+		"make build @ Makefile",
+		"make test @ Makefile",
+		"go test -race ./... @ .github/workflows/ci.yml",
+		"go build ./... @ go",
+		"go vet ./... @ go",
+		"go test ./... @ go",
+	}
+	if !slices.Equal(commands, want) {
+		t.Fatalf("unexpected build commands %v", commands)
+	}
+	if briefing.BuildCommands[0].Description != "Build the binary" {
+		t.Fatalf("unexpected description %+v", briefing.BuildCommands[0])
+	}
+}
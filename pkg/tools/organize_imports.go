@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const organizeImportsKind = "source.organizeImports"
+
+func (t *LSPTools) registerOrganizeImports(s *server.MCPServer) {
+	tool := mcp.NewTool("organize_imports",
+		mcp.WithDescription("Organize the imports of a Go file, or of every file of a package directory, with the gopls source.organizeImports code action: add missing imports, remove unused ones, and sort and group them. Returns a unified diff per changed file, and writes the changes when apply is true"),
+		mcp.WithTitleAnnotation("Organize Imports"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or path of a Go file, or of a package directory"),
+		),
+		mcp.WithBoolean("include_tests",
+			mcp.Description("For a package directory, also organize its _test.go files (default true)"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the changes to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		includeTests := true
+		if _, ok := args["include_tests"]; ok {
+			includeTests = getOptionalBoolArg(args, "include_tests")
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		path := convertURIToPath(fileURI)
+		info, err := os.Stat(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", path, err)), nil
+		}
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = packageGoFiles(path, includeTests); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(files) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("no Go files in %s", path)), nil
+			}
+		}
+
+		var changes []fileChange
+		for _, file := range files {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", file, err)), nil
+			}
+			uri := convertPathToURI(file)
+			actions, err := lspClient.CodeActions(ctx, uri, documentRange(src), organizeImportsKind)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			for _, action := range actions {
+				if action.Kind != organizeImportsKind {
+					continue
+				}
+				edit, problem, err := codeActionEdit(ctx, lspClient, action)
+				if err != nil {
+					return nil, t.handleLSPError(err)
+				}
+				if problem != "" {
+					return mcp.NewToolResultError(problem), nil
+				}
+				fileChanges, err := workspaceEditChanges(edit)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("compute imports diff for %s: %v", relativeSlashPath(t.workspaceDir, file), err)), nil
+				}
+				for _, change := range fileChanges {
+					if !bytes.Equal(change.before, change.after) {
+						changes = append(changes, change)
+					}
+				}
+				break
+			}
+		}
+
+		apply := getOptionalBoolArg(args, "apply") && len(changes) > 0
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply organized imports", err), nil
+			}
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri": fileURI,
+			"checked":  len(files),
+			"changed":  len(changes),
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// documentRange is the range covering all of src, in LSP positions.
+func documentRange(src []byte) protocol.Range {
+	line := bytes.Count(src, []byte("\n"))
+	last := src[bytes.LastIndexByte(src, '\n')+1:]
+	return protocol.Range{End: protocol.Position{Line: line, Character: len(utf16.Encode([]rune(string(last))))}}
+}
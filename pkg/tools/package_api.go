@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// apiValue is a documented constant or variable declaration, which may
+// declare several names.
+type apiValue struct {
+	Names      []string `json:"names"`
+	Decl       string   `json:"decl"`
+	Doc        string   `json:"doc,omitempty"`
+	Deprecated string   `json:"deprecated,omitempty"`
+}
+
+type apiFunc struct {
+	Name       string `json:"name"`
+	Recv       string `json:"recv,omitempty"`
+	Signature  string `json:"signature"`
+	Doc        string `json:"doc,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// apiType is an exported type with the declarations go doc groups under
+// it: its constants and variables, the functions returning it, and its
+// methods.
+type apiType struct {
+	Name         string     `json:"name"`
+	Kind         string     `json:"kind"`
+	Decl         string     `json:"decl"`
+	Doc          string     `json:"doc,omitempty"`
+	Deprecated   string     `json:"deprecated,omitempty"`
+	Constants    []apiValue `json:"constants,omitempty"`
+	Variables    []apiValue `json:"variables,omitempty"`
+	Constructors []apiFunc  `json:"constructors,omitempty"`
+	Methods      []apiFunc  `json:"methods,omitempty"`
+}
+
+func (t *LSPTools) registerPackageAPI(s *server.MCPServer) {
+	tool := mcp.NewTool("package_api",
+		mcp.WithDescription("Summarize the exported API of a package in one response: its doc, constants, variables, functions and types with their declarations, constructors, methods and doc comments, grouped as go doc groups them. Works for workspace packages, dependencies and the standard library, to understand a package without reading its source files"),
+		mcp.WithTitleAnnotation("Package API"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package",
+			mcp.Required(),
+			mcp.Description("Import path, such as net/http or a module dependency, or a directory or file URI or path in the package"),
+		),
+		mcp.WithString("docs",
+			mcp.Description("Doc comments to include: full (default), synopsis (the first sentence) or none"),
+			mcp.Enum("full", "synopsis", "none"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		pkg, err := getStringArg(args, "package")
+		if err != nil {
+			return nil, err
+		}
+		docs := getOptionalStringArg(args, "docs")
+		if docs == "" {
+			docs = "full"
+		}
+		if docs != "full" && docs != "synopsis" && docs != "none" {
+			return mcp.NewToolResultError("docs must be full, synopsis or none"), nil
+		}
+		if strings.Contains(pkg, "...") {
+			return mcp.NewToolResultError("package must name a single package, not a pattern"), nil
+		}
+
+		// Directories and files are listed from the package directory,
+		// import paths from the workspace so its go.mod resolves them.
+		spec := commandSpec{name: "go", args: []string{"list", "-f", "{{.ImportPath}}\t{{.Dir}}\t{{.Name}}\t{{join .GoFiles \",\"}},{{join .CgoFiles \",\"}}"}, dir: t.workspaceDir}
+		path := pkg
+		if strings.HasPrefix(path, "file://") {
+			path = convertURIToPath(path)
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(t.workspaceDir, path)
+		}
+		if info, err := os.Stat(path); err == nil && (strings.HasPrefix(pkg, "file://") || filepath.IsAbs(pkg) || strings.HasPrefix(pkg, ".")) {
+			if !info.IsDir() {
+				path = filepath.Dir(path)
+			}
+			spec.dir = path
+			pkg = "."
+		}
+		spec.args = append(spec.args, pkg)
+		listed, err := t.runCommandSpec(ctx, s, nil, spec)
+		if err != nil {
+			return t.commandFailureResult("go list", listed, err)
+		}
+		fields := strings.Split(strings.TrimSpace(listed.Stdout), "\t")
+		if len(fields) != 4 {
+			return mcp.NewToolResultError(fmt.Sprintf("unexpected go list output %q", listed.Stdout)), nil
+		}
+		importPath, dir, files := fields[0], fields[1], strings.FieldsFunc(fields[3], func(r rune) bool { return r == ',' })
+
+		fset := token.NewFileSet()
+		var syntax []*ast.File
+		for _, name := range files {
+			file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments|parser.SkipObjectResolution)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("parse %s: %v", name, err)), nil
+			}
+			syntax = append(syntax, file)
+		}
+		docPkg, err := doc.NewFromFiles(fset, syntax, importPath)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("document %s: %v", importPath, err)), nil
+		}
+
+		comment := func(text string) string {
+			switch docs {
+			case "none":
+				return ""
+			case "synopsis":
+				return docPkg.Synopsis(text)
+			}
+			return strings.TrimSpace(text)
+		}
+		values := func(list []*doc.Value) []apiValue {
+			var out []apiValue
+			for _, v := range list {
+				out = append(out, apiValue{Names: v.Names, Decl: printDecl(fset, v.Decl), Doc: comment(v.Doc), Deprecated: deprecation(v.Doc)})
+			}
+			return out
+		}
+		funcs := func(list []*doc.Func) []apiFunc {
+			var out []apiFunc
+			for _, fn := range list {
+				out = append(out, apiFunc{Name: fn.Name, Recv: fn.Recv, Signature: printDecl(fset, fn.Decl), Doc: comment(fn.Doc), Deprecated: deprecation(fn.Doc)})
+			}
+			return out
+		}
+
+		counts := map[string]int{"functions": len(docPkg.Funcs), "types": len(docPkg.Types)}
+		for _, v := range docPkg.Consts {
+			counts["constants"] += len(v.Names)
+		}
+		for _, v := range docPkg.Vars {
+			counts["variables"] += len(v.Names)
+		}
+		types := []apiType{}
+		for _, typ := range docPkg.Types {
+			entry := apiType{
+				Name:         typ.Name,
+				Kind:         typeKind(typ.Decl, typ.Name),
+				Decl:         printDecl(fset, typ.Decl),
+				Doc:          comment(typ.Doc),
+				Deprecated:   deprecation(typ.Doc),
+				Constants:    values(typ.Consts),
+				Variables:    values(typ.Vars),
+				Constructors: funcs(typ.Funcs),
+				Methods:      funcs(typ.Methods),
+			}
+			for _, v := range typ.Consts {
+				counts["constants"] += len(v.Names)
+			}
+			for _, v := range typ.Vars {
+				counts["variables"] += len(v.Names)
+			}
+			counts["functions"] += len(typ.Funcs)
+			counts["methods"] += len(typ.Methods)
+			types = append(types, entry)
+		}
+
+		location := dir
+		if root, err := filepath.Abs(t.workspaceDir); err == nil {
+			if rel := relativeSlashPath(root, dir); !strings.HasPrefix(rel, "../") {
+				location = rel
+			}
+		}
+		payload := map[string]any{
+			"import_path": importPath,
+			"name":        docPkg.Name,
+			"dir":         location,
+			"doc":         comment(docPkg.Doc),
+			"constants":   values(docPkg.Consts),
+			"variables":   values(docPkg.Vars),
+			"functions":   funcs(docPkg.Funcs),
+			"types":       types,
+			"counts":      counts,
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// typeKind tells what kind of type a declaration declares: struct,
+// interface, func, alias, or defined for the other types.
+func typeKind(decl *ast.GenDecl, name string) string {
+	for _, spec := range decl.Specs {
+		spec, ok := spec.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != name {
+			continue
+		}
+		if spec.Assign.IsValid() {
+			return "alias"
+		}
+		switch spec.Type.(type) {
+		case *ast.StructType:
+			return "struct"
+		case *ast.InterfaceType:
+			return "interface"
+		case *ast.FuncType:
+			return "func"
+		}
+	}
+	return "defined"
+}
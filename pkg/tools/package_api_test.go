@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestPackageAPI(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", `// Package store keeps items.
+package store
+
+// Limit is the most items a store keeps.
+const Limit = 10
+
+type (
+	// Store keeps items.
+	Store struct {
+		Name  string
+		items []int
+	}
+
+	// ID identifies an item.
+	ID = int
+)
+
+// New returns an empty store.
+func New(name string) *Store { return &Store{Name: name} }
+
+// Add adds an item. It reports whether the store had room.
+//
+// Deprecated: use Put.
+func (s *Store) Add(item int) bool { return s.Put(item) == nil }
+
+// Put adds an item.
+func (s *Store) Put(item int) error { return nil }
+
+func (s *Store) grow() {}
+
+// Parse reads an ID.
+func Parse(text string) (ID, error) { return 0, nil }
+`)
+	writeWorkspaceFile(t, workspace, "store/store_test.go", "package store\n\nfunc TestOnly() {}\n")
+
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("package_api").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "package_api", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("package_api: %v", err)
+		}
+		if result.IsError {
+			t.Fatalf("package_api failed: %v", result.Content)
+		}
+		return structured(result)
+	}
+
+	api := call(map[string]any{"package": "example.com/app/store"})
+	if api["name"] != "store" || api["dir"] != "store" || api["doc"] != "Package store keeps items." {
+		t.Fatalf("unexpected package %v", api)
+	}
+	counts := api["counts"].(map[string]any)
+	if counts["types"] != float64(2) || counts["functions"] != float64(2) || counts["methods"] != float64(2) || counts["constants"] != float64(1) {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+	types := api["types"].([]any)
+	id, store := types[0].(map[string]any), types[1].(map[string]any)
+	if id["name"] != "ID" || id["kind"] != "alias" || len(id["constructors"].([]any)) != 1 {
+		t.Fatalf("expected the ID alias with Parse as constructor, got %v", id)
+	}
+	if store["kind"] != "struct" || store["decl"] != "type Store struct {\n\tName string\n\t// contains filtered or unexported fields\n}" {
+		t.Fatalf("unexpected Store %q", store["decl"])
+	}
+	methods := store["methods"].([]any)
+	add := methods[0].(map[string]any)
+	if len(methods) != 2 || add["signature"] != "func (s *Store) Add(item int) bool" || add["deprecated"] != "use Put." {
+		t.Fatalf("unexpected methods %v", methods)
+	}
+
+	brief := call(map[string]any{"package": "file://" + filepath.Join(workspace, "store", "store.go"), "docs": "synopsis"})
+	if brief["import_path"] != "example.com/app/store" || brief["types"].([]any)[1].(map[string]any)["methods"].([]any)[0].(map[string]any)["doc"] != "Add adds an item." {
+		t.Fatalf("unexpected synopsis %v", brief["types"])
+	}
+	if std := call(map[string]any{"package": "errors", "docs": "none"}); std["dir"] == "" || std["import_path"] != "errors" {
+		t.Fatalf("unexpected standard library package %v", std)
+	}
+}
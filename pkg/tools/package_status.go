@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Per-package outcomes of a command run over several packages.
+const (
+	packageOK          = "ok"
+	packageFailed      = "fail"
+	packageBuildFailed = "build_failed"
+	packageSetupFailed = "setup_failed"
+	packageNoTests     = "no_test_files"
+)
+
+// maxPackageOutput bounds the output kept for each failed package.
+const maxPackageOutput = 8000
+
+var (
+	goTestOKPattern       = regexp.MustCompile(`^ok\s+(\S+)\s+(\S+)(?:\s+coverage: ([0-9.]+)% of statements)?`)
+	goTestFailPattern     = regexp.MustCompile(`^FAIL\s+(\S+)\s+(?:\[(build|setup) failed\]|(\S+))$`)
+	goTestNoTestsPattern  = regexp.MustCompile(`^\?\s+(\S+)\s+\[no test files\]$`)
+	goTestCoveragePattern = regexp.MustCompile(`^\s+(\S+)\s+coverage: ([0-9.]+)% of statements$`)
+	// goBuildHeaderPattern starts the compiler errors of a package, such as
+	// "# example.com/app [example.com/app.test]".
+	goBuildHeaderPattern = regexp.MustCompile(`^# (\S+)`)
+)
+
+// packageStatus is the outcome of a multi-package command for one package.
+// Output holds what the command printed about the package when it failed,
+// and Assertions the testify and go-cmp failures found in it.
+type packageStatus struct {
+	Package    string             `json:"package"`
+	Status     string             `json:"status"`
+	Duration   string             `json:"duration,omitempty"`
+	Coverage   *float64           `json:"coverage,omitempty"`
+	Output     string             `json:"output,omitempty"`
+	Assertions []assertionFailure `json:"assertions,omitempty"`
+}
+
+// packageSummary counts the statuses of a multi-package run. Status is ok
+// when no package failed, partial when some did and failed when all did.
+type packageSummary struct {
+	Status  string `json:"status"`
+	Total   int    `json:"total"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	NoTests int    `json:"no_test_files"`
+}
+
+// parseGoTestPackages reads the per-package lines of go test output, so
+// that the packages that passed are reported even when others fail. The
+// output printed before a package's FAIL line, and the compiler errors
+// printed on stderr for a package that did not build, become its Output.
+func parseGoTestPackages(stdout, stderr string) []packageStatus {
+	buildErrors := make(map[string]string)
+	var current string
+	var block []string
+	flush := func() {
+		if current != "" {
+			buildErrors[current] = strings.Join(block, "\n")
+		}
+	}
+	for line := range strings.SplitSeq(stderr, "\n") {
+		if match := goBuildHeaderPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			current, block = match[1], nil
+			continue
+		}
+		if current != "" && line != "" {
+			block = append(block, line)
+		}
+	}
+	flush()
+
+	statuses := []packageStatus{}
+	var pending []string
+	for line := range strings.SplitSeq(stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case goTestOKPattern.MatchString(line):
+			match := goTestOKPattern.FindStringSubmatch(line)
+			status := packageStatus{Package: match[1], Status: packageOK, Duration: match[2]}
+			if match[3] != "" {
+				status.Coverage = parseCoverage(match[3])
+			}
+			statuses = append(statuses, status)
+		case goTestFailPattern.MatchString(line):
+			match := goTestFailPattern.FindStringSubmatch(line)
+			status := packageStatus{Package: match[1], Status: packageFailed, Duration: match[3], Output: strings.Join(pending, "\n")}
+			switch match[2] {
+			case "build":
+				status.Status = packageBuildFailed
+				status.Output = strings.TrimSpace(status.Output + "\n" + buildErrors[match[1]])
+			case "setup":
+				status.Status = packageSetupFailed
+			default:
+				status.Assertions = parseAssertionFailures(status.Output)
+			}
+			status.Output = truncateOutput(strings.TrimSpace(status.Output))
+			statuses = append(statuses, status)
+		case goTestNoTestsPattern.MatchString(line):
+			statuses = append(statuses, packageStatus{Package: goTestNoTestsPattern.FindStringSubmatch(line)[1], Status: packageNoTests})
+		case goTestCoveragePattern.MatchString(line):
+			// Since Go 1.22, go test -cover reports the packages without
+			// tests with their (zero) coverage.
+			match := goTestCoveragePattern.FindStringSubmatch(line)
+			statuses = append(statuses, packageStatus{Package: match[1], Status: packageNoTests, Coverage: parseCoverage(match[2])})
+		default:
+			if line != "FAIL" && line != "PASS" && line != "" {
+				pending = append(pending, line)
+			}
+			continue
+		}
+		pending = nil
+	}
+	return statuses
+}
+
+func summarizePackages(statuses []packageStatus) packageSummary {
+	summary := packageSummary{Total: len(statuses)}
+	for _, status := range statuses {
+		switch status.Status {
+		case packageOK:
+			summary.Passed++
+		case packageNoTests:
+			summary.NoTests++
+		default:
+			summary.Failed++
+		}
+	}
+	switch {
+	case summary.Failed == 0:
+		summary.Status = "ok"
+	case summary.Failed < summary.Total-summary.NoTests:
+		summary.Status = "partial"
+	default:
+		summary.Status = "failed"
+	}
+	return summary
+}
+
+func parseCoverage(text string) *float64 {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+func truncateOutput(output string) string {
+	if len(output) <= maxPackageOutput {
+		return output
+	}
+	return output[:maxPackageOutput] + "\n... (truncated)"
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+const partialGoTestStdout = `ok  	example.com/app/api	0.012s	coverage: 81.5% of statements
+--- FAIL: TestParse (0.00s)
+    parse_test.go:12: got 1, want 2
+FAIL
+coverage: 40.0% of statements
+FAIL	example.com/app/parse	0.004s
+?   	example.com/app/cmd	[no test files]
+	example.com/app/internal/gen		coverage: 0.0% of statements
+FAIL	example.com/app/broken [build failed]
+ok  	example.com/app/util	(cached)
+FAIL
+`
+
+const partialGoTestStderr = `# example.com/app/broken [example.com/app/broken.test]
+broken/broken.go:5:2: undefined: missing
+`
+
+func TestParseGoTestPackages(t *testing.T) {
+	statuses := parseGoTestPackages(partialGoTestStdout, partialGoTestStderr)
+	var got []string
+	for _, status := range statuses {
+		got = append(got, status.Package+"="+status.Status)
+	}
+	want := "example.com/app/api=ok example.com/app/parse=fail example.com/app/cmd=no_test_files example.com/app/internal/gen=no_test_files example.com/app/broken=build_failed example.com/app/util=ok"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("statuses = %v\nwant %s", got, want)
+	}
+	if statuses[0].Coverage == nil || *statuses[0].Coverage != 81.5 || statuses[5].Duration != "(cached)" {
+		t.Fatalf("unexpected ok packages %+v %+v", statuses[0], statuses[5])
+	}
+	if output := statuses[1].Output; !strings.HasPrefix(output, "--- FAIL: TestParse") || !strings.Contains(output, "got 1, want 2") {
+		t.Fatalf("unexpected failure output %q", output)
+	}
+	if output := statuses[4].Output; output != "broken/broken.go:5:2: undefined: missing" {
+		t.Fatalf("unexpected build output %q", output)
+	}
+
+	summary := summarizePackages(statuses)
+	if summary != (packageSummary{Status: "partial", Total: 6, Passed: 2, Failed: 2, NoTests: 2}) {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if summarizePackages(statuses[1:2]).Status != "failed" || summarizePackages(nil).Status != "ok" {
+		t.Fatal("expected failed for only failures and ok for no packages")
+	}
+}
+
+func TestCoverageKeepsPassingPackages(t *testing.T) {
+	tools := NewLSPTools(nil, t.TempDir())
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		command := append([]string{spec.name}, spec.args...)
+		return commandResult{Command: command, ExitCode: 1, Stdout: partialGoTestStdout, Stderr: partialGoTestStderr}, errors.New("exit status 1")
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+
+	for _, name := range []string{"analyze_coverage", "run_go_test"} {
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: map[string]any{"path": "./..."}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("%s: expected a partial result, got %v %#v", name, err, result)
+		}
+		content := structured(result)
+		summary := content["summary"].(map[string]any)
+		if summary["status"] != "partial" || summary["passed"] != float64(2) || len(content["packages"].([]any)) != 6 {
+			t.Fatalf("%s: unexpected result %#v", name, content)
+		}
+	}
+
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		return commandResult{ExitCode: 1, Stderr: "pattern ./nope: directory not found"}, errors.New("exit status 1")
+	}
+	result, err := server.GetTool("analyze_coverage").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "analyze_coverage", Arguments: map[string]any{"path": "./nope"}},
+	})
+	if err != nil || !result.IsError {
+		t.Fatalf("expected an error when no package ran, got %v %#v", err, result)
+	}
+}
+
+func TestParseGoTestPackagesAssertions(t *testing.T) {
+	stdout := strings.Join([]string{
+		"--- FAIL: TestLoad (0.00s)",
+		"    load_test.go:18: ",
+		"        \tError Trace:\t/app/load_test.go:18",
+		"        \tError:      \tNot equal: ",
+		"        \t            \texpected: 1",
+		"        \t            \tactual  : 2",
+		"        \tTest:       \tTestLoad",
+		"FAIL",
+		"FAIL\texample.com/app\t0.004s",
+		"FAIL",
+	}, "\n")
+	statuses := parseGoTestPackages(stdout, "")
+	if len(statuses) != 1 || len(statuses[0].Assertions) != 1 {
+		t.Fatalf("expected one package with one assertion, got %+v", statuses)
+	}
+	got := statuses[0].Assertions[0]
+	if got.Library != "testify" || got.File != "load_test.go" || got.Line != 18 || got.Expected != "1" || got.Actual != "2" {
+		t.Fatalf("unexpected assertion %+v", got)
+	}
+}
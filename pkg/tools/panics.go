@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+type panicSite struct {
+	Value    string            `json:"value,omitempty"`
+	Function string            `json:"function,omitempty"`
+	Package  string            `json:"package"`
+	Note     string            `json:"note,omitempty"`
+	Location protocol.Location `json:"location"`
+}
+
+type recoverSite struct {
+	Function string            `json:"function,omitempty"`
+	Package  string            `json:"package"`
+	Deferred bool              `json:"deferred"`
+	Repanics bool              `json:"repanics,omitempty"`
+	Location protocol.Location `json:"location"`
+}
+
+type fatalSite struct {
+	Call     string            `json:"call"`
+	Function string            `json:"function,omitempty"`
+	Package  string            `json:"package"`
+	Location protocol.Location `json:"location"`
+}
+
+type panicsReport struct {
+	Panics   []panicSite   `json:"panics"`
+	Recovers []recoverSite `json:"recovers"`
+	Fatals   []fatalSite   `json:"fatal_calls"`
+}
+
+func (t *LSPTools) registerAuditPanics(s *server.MCPServer) {
+	tool := mcp.NewTool("audit_panics",
+		mcp.WithDescription("List panic() calls outside tests, recover() usages, and log.Fatal/os.Exit calls in library (non-main) packages, each with its enclosing function"),
+		mcp.WithTitleAnnotation("Audit Panics"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only report packages whose import path starts with this prefix")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		report := auditPanics(ws, prefix)
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"panics":       report.Panics,
+			"recovers":     report.Recovers,
+			"fatal_calls":  report.Fatals,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func auditPanics(ws *gosrc.Workspace, prefix string) panicsReport {
+	report := panicsReport{Panics: []panicSite{}, Recovers: []recoverSite{}, Fatals: []fatalSite{}}
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		library := pkg.Name != "main"
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			libraries := make(map[string]bool)
+			for _, importPath := range imports {
+				if lib, ok := logLibraryImports[importPath]; ok {
+					libraries[lib] = true
+				}
+			}
+			for _, decl := range file.Syntax.Decls {
+				function := ""
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					function = funcDeclName(fn)
+				}
+				// deferred holds the function literals run by defer, where
+				// recover has an effect.
+				deferred := make(map[*ast.FuncLit]bool)
+				var enclosing []ast.Node
+				ast.Inspect(decl, func(n ast.Node) bool {
+					if n == nil {
+						enclosing = enclosing[:len(enclosing)-1]
+						return true
+					}
+					enclosing = append(enclosing, n)
+					switch node := n.(type) {
+					case *ast.DeferStmt:
+						if lit, ok := node.Call.Fun.(*ast.FuncLit); ok {
+							deferred[lit] = true
+						}
+					case *ast.CallExpr:
+						location := sourceLocation(file, node.Pos(), node.End())
+						if ident, ok := node.Fun.(*ast.Ident); ok {
+							switch ident.Name {
+							case "panic":
+								site := panicSite{Function: function, Package: pkg.ImportPath, Location: location}
+								if len(node.Args) == 1 {
+									site.Value = exprText(node.Args[0])
+								}
+								switch {
+								case function == "init":
+									site.Note = "panics during package initialisation"
+								case strings.HasPrefix(function, "Must") || strings.Contains(function, ".Must"):
+									site.Note = "Must* helper; panicking is its documented contract"
+								}
+								report.Panics = append(report.Panics, site)
+							case "recover":
+								site := recoverSite{Function: function, Package: pkg.ImportPath, Location: location}
+								for i := len(enclosing) - 1; i >= 0; i-- {
+									if lit, ok := enclosing[i].(*ast.FuncLit); ok {
+										site.Deferred = deferred[lit]
+										site.Repanics = callsPanic(lit.Body)
+										break
+									}
+								}
+								report.Recovers = append(report.Recovers, site)
+							}
+							return true
+						}
+						if !library {
+							return true
+						}
+						if importPath, name, ok := packageCall(imports, node); ok && importPath == "os" && name == "Exit" {
+							report.Fatals = append(report.Fatals, fatalSite{Call: "os.Exit", Function: function, Package: pkg.ImportPath, Location: location})
+							return true
+						}
+						if lc := classifyLogCall(imports, libraries, node); lc != nil && lc.Level == "fatal" {
+							report.Fatals = append(report.Fatals, fatalSite{Call: lc.Call, Function: function, Package: pkg.ImportPath, Location: location})
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+	return report
+}
+
+// callsPanic reports whether body calls the panic builtin, as recover
+// handlers that only log and re-raise do.
+func callsPanic(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "panic" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestAuditPanics(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "main.go", `package main
+
+import "log"
+
+func main() {
+	log.Fatal(run())
+}
+
+func run() error { return nil }
+`)
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+)
+
+type Store struct{}
+
+func MustOpen(path string) *Store {
+	if path == "" {
+		panic("empty path")
+	}
+	return &Store{}
+}
+
+func (s *Store) Get(key string) string {
+	if key == "" {
+		log.Fatalf("missing key")
+	}
+	if key == "exit" {
+		os.Exit(2)
+	}
+	panic(fmt.Sprintf("unknown key %q", key))
+}
+
+func (s *Store) Safe(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered: %v", r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+var pattern = regexp.MustCompile("x")
+`)
+	writeWorkspaceFile(t, workspace, "store/store_test.go", "package store\n\nfunc helper() { panic(\"test only\") }\n")
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	report := auditPanics(ws, "")
+
+	if len(report.Panics) != 2 {
+		t.Fatalf("expected two panics outside tests, got %+v", report.Panics)
+	}
+	if report.Panics[0].Function != "MustOpen" || report.Panics[0].Value != `"empty path"` || report.Panics[0].Note == "" {
+		t.Fatalf("unexpected Must panic: %+v", report.Panics[0])
+	}
+	if report.Panics[1].Function != "Store.Get" || report.Panics[1].Note != "" || report.Panics[1].Location.Range.Start.Line != 25 {
+		t.Fatalf("unexpected Get panic: %+v", report.Panics[1])
+	}
+
+	if len(report.Recovers) != 1 || report.Recovers[0].Function != "Store.Safe" || !report.Recovers[0].Deferred || report.Recovers[0].Repanics {
+		t.Fatalf("unexpected recovers: %+v", report.Recovers)
+	}
+
+	// log.Fatal in package main is expected and not reported.
+	if len(report.Fatals) != 2 || report.Fatals[0].Call != "log.Fatalf" || report.Fatals[1].Call != "os.Exit" || report.Fatals[0].Function != "Store.Get" {
+		t.Fatalf("unexpected fatal calls: %+v", report.Fatals)
+	}
+}
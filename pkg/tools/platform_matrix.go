@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+var (
+	defaultPlatforms = []string{"linux/amd64", "linux/arm64", "darwin/amd64", "darwin/arm64", "windows/amd64"}
+	// knownOS and knownArch mirror go/build's lists, used to tell filename
+	// suffixes apart from ordinary underscores.
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true, "illumos": true, "ios": true,
+		"js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true, "arm64be": true, "loong64": true,
+		"mips": true, "mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true,
+		"ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true, "sparc": true, "sparc64": true,
+		"wasm": true,
+	}
+)
+
+type platformExclusion struct {
+	Platform string `json:"platform"`
+	Reason   string `json:"reason"`
+}
+
+type platformFile struct {
+	File       string              `json:"file"`
+	Constraint string              `json:"constraint,omitempty"`
+	IncludedOn []string            `json:"included_on"`
+	ExcludedOn []platformExclusion `json:"excluded_on"`
+}
+
+type platformSymbol struct {
+	Name      string              `json:"name"`
+	DefinedOn []string            `json:"defined_on"`
+	MissingOn []string            `json:"missing_on"`
+	Locations []protocol.Location `json:"locations"`
+}
+
+type platformPackage struct {
+	Package string           `json:"package"`
+	Dir     string           `json:"dir"`
+	EmptyOn []string         `json:"empty_on,omitempty"`
+	Files   []platformFile   `json:"platform_specific_files"`
+	Symbols []platformSymbol `json:"platform_specific_symbols,omitempty"`
+}
+
+func (t *LSPTools) registerPlatformMatrix(s *server.MCPServer) {
+	tool := mcp.NewTool("platform_matrix",
+		mcp.WithDescription("Report, per package, which files each GOOS/GOARCH includes or excludes (build constraints and filename suffixes) and which symbols exist only on some platforms"),
+		mcp.WithTitleAnnotation("Platform Matrix"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("platforms", mcp.Description("Comma-separated GOOS/GOARCH pairs (default linux/amd64,linux/arm64,darwin/amd64,darwin/arm64,windows/amd64)")),
+		mcp.WithString("tags", mcp.Description("Comma-separated extra build tags to enable")),
+		mcp.WithBoolean("cgo", mcp.Description("Evaluate with cgo enabled (default false, as when cross-compiling)")),
+		mcp.WithBoolean("include_tests", mcp.Description("Include _test.go files (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		platforms := defaultPlatforms
+		if raw := getOptionalStringArg(args, "platforms"); raw != "" {
+			platforms = splitCommaList(raw)
+		}
+		for _, platform := range platforms {
+			if goos, goarch, ok := strings.Cut(platform, "/"); !ok || !knownOS[goos] || !knownArch[goarch] {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid platform %q: expected GOOS/GOARCH", platform)), nil
+			}
+		}
+		tags := splitCommaList(getOptionalStringArg(args, "tags"))
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{
+			Tests:        getOptionalBoolArg(args, "include_tests"),
+			AllPlatforms: true,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		packages := buildPlatformMatrix(ws, platforms, tags, getOptionalBoolArg(args, "cgo"))
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"platforms":    platforms,
+			"packages":     packages,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func splitCommaList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// buildPlatformMatrix evaluates every file against each platform. Packages
+// whose files are included everywhere are omitted.
+func buildPlatformMatrix(ws *gosrc.Workspace, platforms, tags []string, cgo bool) []platformPackage {
+	contexts := make([]build.Context, len(platforms))
+	for i, platform := range platforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		ctxt := build.Default
+		ctxt.GOOS, ctxt.GOARCH = goos, goarch
+		ctxt.CgoEnabled = cgo
+		ctxt.BuildTags = tags
+		contexts[i] = ctxt
+	}
+
+	packages := []platformPackage{}
+	for _, pkg := range ws.Packages {
+		entry := platformPackage{Package: pkg.ImportPath, Dir: pkg.RelDir, Files: []platformFile{}}
+		fileCount := make([]int, len(platforms))
+		// symbols maps each top-level name to the platforms defining it.
+		symbols := make(map[string]map[int]bool)
+		locations := make(map[string][]protocol.Location)
+		for _, file := range pkg.Files {
+			info := platformFile{File: file.RelPath, Constraint: buildConstraint(file.Syntax), IncludedOn: []string{}, ExcludedOn: []platformExclusion{}}
+			var included []int
+			for i, ctxt := range contexts {
+				match, err := ctxt.MatchFile(filepath.Dir(file.Path), filepath.Base(file.Path))
+				if err == nil && match {
+					included = append(included, i)
+					info.IncludedOn = append(info.IncludedOn, platforms[i])
+					fileCount[i]++
+					continue
+				}
+				reason := "build constraint"
+				if err != nil {
+					reason = err.Error()
+				} else if !filenameMatches(filepath.Base(file.Path), ctxt.GOOS, ctxt.GOARCH) {
+					reason = "filename suffix"
+				}
+				info.ExcludedOn = append(info.ExcludedOn, platformExclusion{Platform: platforms[i], Reason: reason})
+			}
+			if len(info.ExcludedOn) > 0 {
+				entry.Files = append(entry.Files, info)
+			}
+			for _, decl := range topLevelNames(file) {
+				if symbols[decl.name] == nil {
+					symbols[decl.name] = make(map[int]bool)
+				}
+				for _, i := range included {
+					symbols[decl.name][i] = true
+				}
+				locations[decl.name] = append(locations[decl.name], decl.location)
+			}
+		}
+		for i, count := range fileCount {
+			if count == 0 {
+				entry.EmptyOn = append(entry.EmptyOn, platforms[i])
+			}
+		}
+		for _, name := range sortedKeys(symbols) {
+			defined := symbols[name]
+			if len(defined) == len(platforms) {
+				continue
+			}
+			symbol := platformSymbol{Name: name, DefinedOn: []string{}, MissingOn: []string{}, Locations: locations[name]}
+			for i, platform := range platforms {
+				// Platforms where the whole package is excluded are reported
+				// by empty_on instead.
+				if fileCount[i] == 0 {
+					continue
+				}
+				if defined[i] {
+					symbol.DefinedOn = append(symbol.DefinedOn, platform)
+				} else {
+					symbol.MissingOn = append(symbol.MissingOn, platform)
+				}
+			}
+			if len(symbol.MissingOn) > 0 {
+				entry.Symbols = append(entry.Symbols, symbol)
+			}
+		}
+		if len(entry.Files) > 0 || len(entry.EmptyOn) > 0 {
+			packages = append(packages, entry)
+		}
+	}
+	return packages
+}
+
+// buildConstraint returns the //go:build line of file, if any.
+func buildConstraint(file *ast.File) string {
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if constraint.IsGoBuild(comment.Text) {
+				return comment.Text
+			}
+		}
+	}
+	return ""
+}
+
+// filenameMatches applies the _GOOS, _GOARCH and _GOOS_GOARCH filename
+// conventions.
+func filenameMatches(name, goos, goarch string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test")
+	parts := strings.Split(name, "_")
+	n := len(parts)
+	if n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		return matchOS(parts[n-2], goos) && parts[n-1] == goarch
+	}
+	if n >= 1 && knownOS[parts[n-1]] {
+		return matchOS(parts[n-1], goos)
+	}
+	if n >= 1 && knownArch[parts[n-1]] {
+		return parts[n-1] == goarch
+	}
+	return true
+}
+
+func matchOS(name, goos string) bool {
+	// android and ios files also build for linux and darwin respectively,
+	// and illumos for solaris.
+	return name == goos || (name == "linux" && goos == "android") || (name == "darwin" && goos == "ios") || (name == "solaris" && goos == "illumos")
+}
+
+type topLevelName struct {
+	name     string
+	location protocol.Location
+}
+
+// topLevelNames lists the package-level declarations of file, with methods
+// named Type.Method.
+func topLevelNames(file *gosrc.File) []topLevelName {
+	var names []topLevelName
+	add := func(name string, ident *ast.Ident) {
+		if ident.Name == "_" || ident.Name == "init" {
+			return
+		}
+		names = append(names, topLevelName{name: name, location: sourceLocation(file, ident.Pos(), ident.End())})
+	}
+	for _, decl := range file.Syntax.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverTypeName(d.Recv.List[0].Type) + "." + name
+			}
+			add(name, d.Name)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					add(sp.Name.Name, sp.Name)
+				case *ast.ValueSpec:
+					for _, ident := range sp.Names {
+						add(ident.Name, ident)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestBuildPlatformMatrix(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "term/term.go", "package term\n\nfunc Width() int { return size() }\n")
+	writeWorkspaceFile(t, workspace, "term/term_unix.go", "//go:build linux || darwin\n\npackage term\n\nfunc size() int { return 80 }\n\nfunc Raw() {}\n")
+	writeWorkspaceFile(t, workspace, "term/term_windows.go", "package term\n\nfunc size() int { return 120 }\n")
+	writeWorkspaceFile(t, workspace, "linuxonly/probe_linux.go", "package linuxonly\n\nfunc Probe() {}\n")
+	writeWorkspaceFile(t, workspace, "portable/portable.go", "package portable\n")
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{AllPlatforms: true})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	platforms := []string{"linux/amd64", "darwin/arm64", "windows/amd64"}
+	packages := buildPlatformMatrix(ws, platforms, nil, false)
+	if len(packages) != 2 || packages[0].Package != "example.com/app/linuxonly" || packages[1].Package != "example.com/app/term" {
+		t.Fatalf("unexpected packages: %+v", packages)
+	}
+
+	if !reflect.DeepEqual(packages[0].EmptyOn, []string{"darwin/arm64", "windows/amd64"}) {
+		t.Fatalf("unexpected empty_on: %v", packages[0].EmptyOn)
+	}
+	if exclusion := packages[0].Files[0].ExcludedOn[0]; exclusion.Reason != "filename suffix" {
+		t.Fatalf("expected filename suffix exclusion, got %+v", exclusion)
+	}
+
+	term := packages[1]
+	if len(term.Files) != 2 || term.Files[0].Constraint != "//go:build linux || darwin" || term.Files[0].ExcludedOn[0].Reason != "build constraint" {
+		t.Fatalf("unexpected term files: %+v", term.Files)
+	}
+	if len(term.Symbols) != 1 || term.Symbols[0].Name != "Raw" || !reflect.DeepEqual(term.Symbols[0].MissingOn, []string{"windows/amd64"}) {
+		t.Fatalf("expected only Raw to be platform specific, got %+v", term.Symbols)
+	}
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func (t *LSPTools) registerProjectTools(s *server.MCPServer) {
+	t.registerBuildTargetTools(s)
+	t.registerSummarizeCI(s)
+	t.registerCheckDockerBuild(s)
+	t.registerCheckRelease(s)
+	t.registerDraftChangelog(s)
+	t.registerPrepareReview(s)
+	t.registerSummarizeDiff(s)
+	t.registerAnalyzeMergeConflicts(s)
+	t.registerPlatformMatrix(s)
+	t.registerInspectLdflags(s)
+	t.registerInspectBinary(s)
+}
+
+// walkWorkspaceFiles calls fn for every regular file below root, skipping
+// hidden directories, vendor trees and node_modules.
+func walkWorkspaceFiles(root string, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return fn(path)
+	})
+}
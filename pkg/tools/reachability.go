@@ -0,0 +1,287 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/types"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/ssa"
+)
+
+const defaultReachabilityLimit = 200
+
+// unreachableFunction is a function of the module no root reaches. Lines
+// is the size of its declaration, to weigh what removing it saves.
+type unreachableFunction struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Lines   int    `json:"lines"`
+}
+
+// unusedPackage is a package of the module none of whose functions is
+// reachable.
+type unusedPackage struct {
+	Package   string `json:"package"`
+	Functions int    `json:"functions"`
+	Reason    string `json:"reason"`
+}
+
+// reachabilityReport is the outcome of the analysis before it is limited
+// for the client.
+type reachabilityReport struct {
+	Mode      string
+	Roots     []string
+	Functions int
+	Dead      []unreachableFunction
+	Packages  []unusedPackage
+}
+
+func (t *LSPTools) registerReachability(s *server.MCPServer) {
+	tool := mcp.NewTool("reachability",
+		mcp.WithDescription("Find the functions of the module that no binary can reach, and the packages that contribute nothing to any binary, to guide dead code removal. Rapid type analysis starts from the main packages of the workspace, or from the exported API of its non-internal packages for a library, and follows interface and function-value calls to the types the program instantiates. Functions only reached through reflection by name, go:linkname or cgo exports are reported as unreachable"),
+		mcp.WithTitleAnnotation("Reachability"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("mode",
+			mcp.Description("Roots of the analysis: main (main packages), exported (the exported API of non-internal packages, plus any main packages), or auto (default: main when the module has main packages, exported otherwise)"),
+		),
+		mcp.WithBoolean("include_tests",
+			mcp.Description("Also start from the test binaries, so functions only tests use are not reported (default false)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of unreachable functions to return (default %d)", defaultReachabilityLimit)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		mode := getOptionalStringArg(args, "mode")
+		if mode == "" {
+			mode = "auto"
+		}
+		if mode != "auto" && mode != "main" && mode != "exported" {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown mode %q; use auto, main or exported", mode)), nil
+		}
+		limit := defaultReachabilityLimit
+		if _, ok := args["limit"]; ok {
+			if limit, err = getIntFromObject(args, "limit"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 {
+				return mcp.NewToolResultError("limit must be at least 1"), nil
+			}
+		}
+		includeTests := getOptionalBoolArg(args, "include_tests")
+
+		if token := getProgressToken(request.Params.Meta); token != nil {
+			sendProgressNotification(ctx, s, token, "Loading and analyzing the module")
+		}
+		prog, _, modulePath, err := t.loadSSAProgram(ctx, "./...", includeTests)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		report, problem := t.analyzeReachability(prog, modulePath, mode)
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+
+		dead := report.Dead
+		if len(dead) > limit {
+			dead = dead[:limit]
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"mode":                  report.Mode,
+			"roots":                 report.Roots,
+			"include_tests":         includeTests,
+			"functions":             report.Functions,
+			"unreachable":           len(report.Dead),
+			"unreachable_functions": dead,
+			"unused_packages":       report.Packages,
+			"truncated":             len(dead) < len(report.Dead),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// analyzeReachability runs RTA over the program from the roots of mode and
+// reports the module functions it does not reach. Functions are identified
+// by their source position, so the test variant of a package and the
+// package itself count as one.
+func (t *LSPTools) analyzeReachability(prog *ssa.Program, modulePath, mode string) (reachabilityReport, string) {
+	var modulePkgs, mains []*ssa.Package
+	for _, pkg := range prog.AllPackages() {
+		if !inModule(pkg.Pkg.Path(), modulePath) && !inModule(strings.TrimSuffix(pkg.Pkg.Path(), ".test"), modulePath) {
+			continue
+		}
+		modulePkgs = append(modulePkgs, pkg)
+		if pkg.Pkg.Name() == "main" && pkg.Func("main") != nil {
+			mains = append(mains, pkg)
+		}
+	}
+	slices.SortFunc(modulePkgs, func(a, b *ssa.Package) int { return strings.Compare(a.Pkg.Path(), b.Pkg.Path()) })
+	isTestMain := func(pkg *ssa.Package) bool { return strings.HasSuffix(pkg.Pkg.Path(), ".test") }
+	if mode == "auto" {
+		mode = "exported"
+		if slices.ContainsFunc(mains, func(pkg *ssa.Package) bool { return !isTestMain(pkg) }) {
+			mode = "main"
+		}
+	}
+
+	report := reachabilityReport{Mode: mode, Roots: []string{}, Dead: []unreachableFunction{}, Packages: []unusedPackage{}}
+	var roots []*ssa.Function
+	var rootPkgs []*ssa.Package
+	addRoot := func(pkg *ssa.Package, fns ...*ssa.Function) {
+		roots = append(roots, fns...)
+		if init := pkg.Func("init"); init != nil {
+			roots = append(roots, init)
+		}
+		rootPkgs = append(rootPkgs, pkg)
+		if !slices.Contains(report.Roots, pkg.Pkg.Path()) {
+			report.Roots = append(report.Roots, pkg.Pkg.Path())
+		}
+	}
+	// Generic functions have no body RTA can follow; reaching them is
+	// recorded directly.
+	reachedGeneric := make(map[*ssa.Function]bool)
+	for _, pkg := range modulePkgs {
+		switch {
+		case slices.Contains(mains, pkg):
+			addRoot(pkg, pkg.Func("main"))
+		case mode == "exported" && pkg.Pkg.Name() != "main" && !isInternalPackage(pkg.Pkg.Path()):
+			var exported []*ssa.Function
+			for _, fn := range packageFunctions(pkg) {
+				if obj := fn.Object(); obj == nil || !obj.Exported() {
+					continue
+				}
+				if fn.TypeParams().Len() > 0 {
+					reachedGeneric[fn] = true
+					continue
+				}
+				exported = append(exported, fn)
+			}
+			addRoot(pkg, exported...)
+		}
+	}
+	if len(roots) == 0 {
+		if mode == "main" {
+			return report, "the module has no main packages; use mode exported to start from its API"
+		}
+		return report, "the module has no exported API outside internal packages"
+	}
+
+	reachable := make(map[string]bool)
+	for fn := range rta.Analyze(roots, false).Reachable {
+		for ; fn != nil; fn = fn.Parent() {
+			reachable[functionKey(prog, fn)] = true
+			if origin := fn.Origin(); origin != nil {
+				reachable[functionKey(prog, origin)] = true
+			}
+		}
+	}
+	for fn := range reachedGeneric {
+		reachable[functionKey(prog, fn)] = true
+	}
+
+	imported := importClosure(rootPkgs)
+	seen := make(map[string]bool)
+	counts := make(map[string][2]int)
+	var order []string
+	for _, pkg := range modulePkgs {
+		if isTestMain(pkg) {
+			continue
+		}
+		importPath := pkg.Pkg.Path()
+		for _, fn := range packageFunctions(pkg) {
+			// init functions run whenever their package is linked in; the
+			// package report covers them.
+			key := functionKey(prog, fn)
+			if seen[key] || strings.HasPrefix(fn.Name(), "init#") || strings.HasSuffix(prog.Fset.Position(fn.Pos()).Filename, "_test.go") {
+				continue
+			}
+			seen[key] = true
+			if _, ok := counts[importPath]; !ok {
+				order = append(order, importPath)
+			}
+			count := counts[importPath]
+			count[0]++
+			if reachable[key] {
+				count[1]++
+			} else {
+				report.Dead = append(report.Dead, t.unreachableFunction(prog, fn))
+			}
+			counts[importPath] = count
+		}
+	}
+	report.Functions = len(seen)
+	for _, importPath := range order {
+		if count := counts[importPath]; count[1] == 0 {
+			reason := "imported, but none of its functions is reachable; only its package initialization runs"
+			if !imported[importPath] {
+				reason = "not imported by any root package"
+			}
+			report.Packages = append(report.Packages, unusedPackage{Package: importPath, Functions: count[0], Reason: reason})
+		}
+	}
+	slices.SortFunc(report.Dead, func(a, b unreachableFunction) int {
+		return cmp.Or(strings.Compare(a.Package, b.Package), strings.Compare(a.Path, b.Path), cmp.Compare(a.Line, b.Line))
+	})
+	return report, ""
+}
+
+func (t *LSPTools) unreachableFunction(prog *ssa.Program, fn *ssa.Function) unreachableFunction {
+	dead := unreachableFunction{Name: fn.Pkg.Pkg.Name() + "." + ssaFunctionName(fn), Package: fn.Pkg.Pkg.Path()}
+	if pos := prog.Fset.Position(fn.Pos()); pos.IsValid() {
+		dead.Path, dead.Line = relativeSlashPath(t.workspaceDir, pos.Filename), pos.Line
+	}
+	if syntax := fn.Syntax(); syntax != nil {
+		dead.Lines = prog.Fset.Position(syntax.End()).Line - prog.Fset.Position(syntax.Pos()).Line + 1
+	}
+	return dead
+}
+
+// functionKey identifies a function by where it is declared.
+func functionKey(prog *ssa.Program, fn *ssa.Function) string {
+	pos := prog.Fset.Position(fn.Pos())
+	return pos.Filename + ":" + strconv.Itoa(pos.Offset) + ":" + fn.Name()
+}
+
+// importClosure returns the import paths of pkgs and of everything they
+// import, directly or not.
+func importClosure(pkgs []*ssa.Package) map[string]bool {
+	imported := make(map[string]bool)
+	var visit func(pkg *types.Package)
+	visit = func(pkg *types.Package) {
+		if imported[pkg.Path()] {
+			return
+		}
+		imported[pkg.Path()] = true
+		for _, dep := range pkg.Imports() {
+			visit(dep)
+		}
+	}
+	for _, pkg := range pkgs {
+		visit(pkg.Pkg)
+	}
+	return imported
+}
+
+// isInternalPackage reports whether an import path is below an internal
+// directory, out of reach of other modules.
+func isInternalPackage(importPath string) bool {
+	return path.Base(importPath) == "internal" || strings.Contains(importPath, "/internal/") || strings.HasPrefix(importPath, "internal/")
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeReachability(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "cmd/app/main.go", `package main
+
+import (
+	"fmt"
+
+	"example.com/app/lib"
+	_ "example.com/app/plugins"
+)
+
+func main() {
+	fmt.Println(lib.Total([]lib.Shape{lib.Square{Side: 2}}), lib.Map([]int{1}, double))
+}
+
+func double(n int) int { return 2 * n }
+`)
+	writeWorkspaceFile(t, workspace, "lib/lib.go", `package lib
+
+type Shape interface{ Area() int }
+
+type Square struct{ Side int }
+
+func (s Square) Area() int { return s.Side * s.Side }
+
+type Circle struct{ R int }
+
+func (c Circle) Area() int { return 3 * c.R * c.R }
+
+func Total(shapes []Shape) int {
+	sum := 0
+	for _, s := range shapes {
+		sum += s.Area()
+	}
+	return sum
+}
+
+func Map[T any](in []T, f func(T) T) []T {
+	out := make([]T, 0, len(in))
+	for _, v := range in {
+		out = append(out, f(v))
+	}
+	return out
+}
+
+func Unused() int {
+	return helper()
+}
+
+func helper() int { return 1 }
+
+func OnlyTested() bool { return true }
+`)
+	writeWorkspaceFile(t, workspace, "lib/lib_test.go", `package lib
+
+import "testing"
+
+func TestOnlyTested(t *testing.T) {
+	if !OnlyTested() {
+		t.Fatal("false")
+	}
+}
+`)
+	writeWorkspaceFile(t, workspace, "plugins/plugins.go", `package plugins
+
+var registered []string
+
+func init() { registered = append(registered, "x") }
+
+func Names() []string { return registered }
+`)
+	writeWorkspaceFile(t, workspace, "internal/orphan/orphan.go", "package orphan\n\nfunc Lonely() {}\n")
+
+	tools := NewLSPTools(nil, workspace)
+	analyze := func(mode string, tests bool) reachabilityReport {
+		t.Helper()
+		prog, _, modulePath, err := tools.loadSSAProgram(context.Background(), "./...", tests)
+		if err != nil {
+			t.Fatalf("loadSSAProgram: %v", err)
+		}
+		report, problem := tools.analyzeReachability(prog, modulePath, mode)
+		if problem != "" {
+			t.Fatalf("analyzeReachability: %s", problem)
+		}
+		return report
+	}
+	names := func(report reachabilityReport) []string {
+		var got []string
+		for _, fn := range report.Dead {
+			got = append(got, fn.Name)
+		}
+		return got
+	}
+
+	report := analyze("auto", false)
+	want := []string{"orphan.Lonely", "lib.Circle.Area", "lib.Unused", "lib.helper", "lib.OnlyTested", "plugins.Names"}
+	if got := names(report); report.Mode != "main" || !slices.Equal(report.Roots, []string{"example.com/app/cmd/app"}) || !slices.Equal(got, want) {
+		t.Fatalf("mode %s roots %v: unreachable = %v, want %v", report.Mode, report.Roots, got, want)
+	}
+	if dead := report.Dead[2]; dead.Path != "lib/lib.go" || dead.Line != 29 || dead.Lines != 3 {
+		t.Fatalf("unexpected location %+v", dead)
+	}
+	if len(report.Packages) != 2 || report.Packages[0].Package != "example.com/app/internal/orphan" || report.Packages[0].Reason != "not imported by any root package" ||
+		report.Packages[1].Package != "example.com/app/plugins" || report.Packages[1].Functions != 1 {
+		t.Fatalf("unexpected unused packages %+v", report.Packages)
+	}
+
+	if got := names(analyze("main", true)); slices.Contains(got, "lib.OnlyTested") || !slices.Contains(got, "lib.Unused") {
+		t.Fatalf("with tests, only test-reached functions should drop out: %v", got)
+	}
+
+	exported := analyze("exported", false)
+	if got := names(exported); !slices.Equal(got, []string{"orphan.Lonely"}) || slices.Contains(exported.Roots, "example.com/app/internal/orphan") {
+		t.Fatalf("exported mode: roots %v, unreachable %v", exported.Roots, got)
+	}
+}
@@ -2,17 +2,50 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
 
 func (t *LSPTools) registerRefactorTools(s *server.MCPServer) {
 	t.registerFormatDocument(s)
+	t.registerFormatCode(s)
 	t.registerRenameSymbol(s)
 	t.registerCodeActionsTool(s)
+	t.registerApplyCodeAction(s)
+	t.registerCodeLens(s)
+	t.registerGoplsCommand(s)
+	t.registerOrganizeImports(s)
+	t.registerFillStruct(s)
+	t.registerModifyStructTags(s)
+	t.registerExtractTools(s)
+	t.registerSimulateChange(s)
+	t.registerInlineTools(s)
+	t.registerChangeSignature(s)
+	t.registerRefactorPlan(s)
+	t.registerEditContract(s)
+}
+
+// editSettleTimeout bounds the wait for gopls to load the edit of a step
+// of a multi-step refactoring.
+const editSettleTimeout = 10 * time.Second
+
+// editOnlyCommands are the gopls commands whose only effect is to send the
+// edit of a code action back through workspace/applyEdit, so running them
+// for a preview is safe.
+var editOnlyCommands = map[string]bool{
+	"gopls.add_test":         true,
+	"gopls.apply_fix":        true,
+	"gopls.change_signature": true,
+	"gopls.add_import":       true,
+	"gopls.modify_tags":      true,
 }
 
 func (t *LSPTools) registerFormatDocument(s *server.MCPServer) {
@@ -64,9 +97,9 @@ func (t *LSPTools) registerFormatDocument(s *server.MCPServer) {
 
 func (t *LSPTools) registerRenameSymbol(s *server.MCPServer) {
 	tool := mcp.NewTool("rename_symbol",
-		mcp.WithDescription("Compute rename edits for a symbol"),
+		mcp.WithDescription("Rename a symbol and every reference to it via gopls. Returns the edits as a unified diff per file, and writes them when apply is true"),
 		mcp.WithTitleAnnotation("Rename Symbol"),
-		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
 		mcp.WithString("file_uri",
 			mcp.Required(),
 			mcp.Description("URI of the file"),
@@ -79,6 +112,9 @@ func (t *LSPTools) registerRenameSymbol(s *server.MCPServer) {
 			mcp.Required(),
 			mcp.Description("New identifier name"),
 		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the rename to disk instead of returning a preview diff (default false)"),
+		),
 	)
 
 	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -115,11 +151,23 @@ func (t *LSPTools) registerRenameSymbol(s *server.MCPServer) {
 		if err != nil {
 			return nil, t.handleLSPError(err)
 		}
+		changes, err := workspaceEditChanges(edit)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compute rename diff: %v", err)), nil
+		}
+		apply := getOptionalBoolArg(args, "apply") && len(changes) > 0
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply rename", err), nil
+			}
+		}
 
 		payload := map[string]any{
 			"file_uri": fileURI,
 			"new_name": newName,
 			"edits":    edit,
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
 		}
 		result, err := mcp.NewToolResultJSON(payload)
 		if err != nil {
@@ -184,3 +232,218 @@ func (t *LSPTools) registerCodeActionsTool(s *server.MCPServer) {
 		return result, nil
 	})
 }
+
+func (t *LSPTools) registerApplyCodeAction(s *server.MCPServer) {
+	tool := mcp.NewTool("apply_code_action",
+		mcp.WithDescription("Apply a code action (quick fix or refactoring) listed by list_code_actions for a range. The action's edit is resolved via codeAction/resolve or its gopls command, returned as a unified diff per file, and written when apply is true"),
+		mcp.WithTitleAnnotation("Apply Code Action"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("range",
+			mcp.Required(),
+			mcp.Description("Range the action was listed for"),
+		),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the action, or a case-insensitive part of it that matches a single action"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edit to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		rng, err := parseRangeArg(args, "range")
+		if err != nil {
+			return nil, err
+		}
+		title, err := getStringArg(args, "title")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		actions, err := lspClient.CodeActions(ctx, fileURI, rng)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		action, problem := selectCodeAction(actions, title)
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		if action.Disabled != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("code action %q is disabled: %s", action.Title, action.Disabled.Reason)), nil
+		}
+
+		edit, problem, err := codeActionEdit(ctx, lspClient, action)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		changes, err := workspaceEditChanges(edit)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compute code action diff: %v", err)), nil
+		}
+		if len(changes) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("code action %q produced no edits", action.Title)), nil
+		}
+		apply := getOptionalBoolArg(args, "apply")
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply code action", err), nil
+			}
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri": fileURI,
+			"title":    action.Title,
+			"kind":     action.Kind,
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// selectCodeAction picks the action with the given title, falling back to
+// the single action whose title contains it.
+func selectCodeAction(actions []protocol.CodeAction, title string) (protocol.CodeAction, string) {
+	var matches []protocol.CodeAction
+	for _, action := range actions {
+		if action.Title == title {
+			return action, ""
+		}
+		if strings.Contains(strings.ToLower(action.Title), strings.ToLower(title)) {
+			matches = append(matches, action)
+		}
+	}
+	quoted := func(actions []protocol.CodeAction) string {
+		titles := make([]string, 0, len(actions))
+		for _, action := range actions {
+			titles = append(titles, fmt.Sprintf("%q", action.Title))
+		}
+		return strings.Join(titles, ", ")
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], ""
+	case len(matches) > 1:
+		return protocol.CodeAction{}, fmt.Sprintf("%q matches several code actions: %s", title, quoted(matches))
+	case len(actions) == 0:
+		return protocol.CodeAction{}, "no code actions are available for this range"
+	default:
+		return protocol.CodeAction{}, fmt.Sprintf("no code action matches %q; available: %s", title, quoted(actions))
+	}
+}
+
+// codeActionEdit returns the workspace edit of a code action, resolving it
+// through codeAction/resolve or running its command when it is edit-only.
+// problem explains why an action that runs other commands is not applied.
+func codeActionEdit(ctx context.Context, lspClient client.LSPClient, action protocol.CodeAction) (*protocol.WorkspaceEdit, string, error) {
+	if action.Edit == nil && action.Data != nil {
+		resolved, err := lspClient.ResolveCodeAction(ctx, action)
+		if err != nil {
+			return nil, "", err
+		}
+		action.Edit = resolved.Edit
+		if resolved.Command != nil {
+			action.Command = resolved.Command
+		}
+	}
+	if action.Edit != nil || action.Command == nil {
+		return action.Edit, "", nil
+	}
+	if !editOnlyCommands[action.Command.Command] {
+		return nil, fmt.Sprintf("code action %q runs the gopls command %s, which does more than edit files; it is not applied by this tool", action.Title, action.Command.Command), nil
+	}
+	edits, err := lspClient.ExecuteCommand(ctx, *action.Command)
+	if err != nil {
+		return nil, "", err
+	}
+	return mergeWorkspaceEdits(edits), "", nil
+}
+
+// kindActionChanges returns the file changes of the first enabled code
+// action of kind offered for rng, with its title. problem explains why
+// there is none: "gopls cannot <what>" with the reasons gopls gave for
+// disabling the actions, or none when it offered nothing.
+func kindActionChanges(ctx context.Context, lspClient client.LSPClient, fileURI string, rng protocol.Range, kind, what, none string) ([]fileChange, string, string, error) {
+	actions, err := lspClient.CodeActions(ctx, fileURI, rng, kind)
+	if err != nil {
+		return nil, "", "", err
+	}
+	var reasons []string
+	for _, action := range actions {
+		if action.Kind != kind {
+			continue
+		}
+		if action.Disabled != nil {
+			reasons = append(reasons, action.Disabled.Reason)
+			continue
+		}
+		edit, problem, err := codeActionEdit(ctx, lspClient, action)
+		if err != nil || problem != "" {
+			return nil, "", problem, err
+		}
+		changes, err := workspaceEditChanges(edit)
+		if err != nil {
+			return nil, "", fmt.Sprintf("compute the diff of %q: %v", action.Title, err), nil
+		}
+		if len(changes) == 0 {
+			return nil, "", fmt.Sprintf("code action %q produced no edits", action.Title), nil
+		}
+		return changes, action.Title, "", nil
+	}
+	if len(reasons) > 0 {
+		return nil, "", fmt.Sprintf("gopls cannot %s: %s", what, strings.Join(reasons, "; ")), nil
+	}
+	return nil, "", none, nil
+}
+
+// awaitEdit waits, for a bounded time, for gopls to load the files a step
+// of a multi-step refactoring wrote before the next step is looked up.
+func (t *LSPTools) awaitEdit(ctx context.Context, lspClient client.LSPClient) error {
+	waitCtx, cancel := context.WithTimeout(ctx, editSettleTimeout)
+	defer cancel()
+	if err := lspClient.WaitForQuiescence(waitCtx, diagnosticsQuietPeriod); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return t.handleLSPError(err)
+	}
+	return nil
+}
+
+// mergeWorkspaceEdits combines the edits a command sent into one.
+func mergeWorkspaceEdits(edits []protocol.WorkspaceEdit) *protocol.WorkspaceEdit {
+	if len(edits) == 0 {
+		return nil
+	}
+	merged := &protocol.WorkspaceEdit{Changes: make(map[string][]protocol.TextEdit)}
+	for _, edit := range edits {
+		for uri, changes := range edit.Changes {
+			merged.Changes[uri] = append(merged.Changes[uri], changes...)
+		}
+		merged.DocumentChanges = append(merged.DocumentChanges, edit.DocumentChanges...)
+	}
+	return merged
+}
@@ -0,0 +1,339 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// planStepTools are the tools a refactoring plan step may run. They all
+// write through writeFileChanges when apply is set, so their writes are
+// journaled.
+var planStepTools = []string{
+	"rename_symbol",
+	"inline_call",
+	"inline_variable",
+	"change_signature",
+	"extract_function",
+	"extract_method",
+	"extract_variable",
+	"fill_struct",
+	"modify_struct_tags",
+	"organize_imports",
+	"format_code",
+	"apply_code_action",
+	"add_test",
+}
+
+var planChecks = []string{"build", "diagnostics"}
+
+// planStep is one operation of a refactoring plan: a tool call, or a
+// rewrite of files given as simulate_change changes.
+type planStep struct {
+	description string
+	tool        string
+	arguments   map[string]any
+	changes     any
+}
+
+// planStepResult reports a step of a refactoring plan. Status is ok,
+// failed or not_run.
+type planStepResult struct {
+	Step        int                  `json:"step"`
+	Description string               `json:"description,omitempty"`
+	Tool        string               `json:"tool,omitempty"`
+	Status      string               `json:"status"`
+	Files       []string             `json:"files,omitempty"`
+	Error       string               `json:"error,omitempty"`
+	BuildErrors []buildError         `json:"build_errors,omitempty"`
+	Diagnostics []baselineDiagnostic `json:"new_diagnostics,omitempty"`
+	RolledBack  []string             `json:"rolled_back,omitempty"`
+}
+
+func (t *LSPTools) registerRefactorPlan(s *server.MCPServer) {
+	tool := mcp.NewTool("refactor_plan",
+		mcp.WithDescription(fmt.Sprintf("Run an ordered list of refactoring steps, checking the workspace after each one: the packages the step changed and those depending on them must build, and gopls must report no error diagnostic that was not there at the previous checkpoint. The first step that fails, or fails its checkpoint, has its writes rolled back so the files are left as of the last good checkpoint, and the steps after it are not run. A step runs one of %s with apply set, or rewrites files; moving a declaration is a rewrite of both files. Returns each step's status and the combined diff of the steps kept", strings.Join(planStepTools, ", "))),
+		mcp.WithTitleAnnotation("Refactor Plan"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithArray("steps",
+			mcp.Required(),
+			mcp.Description("Steps in order: {\"tool\": \"rename_symbol\", \"arguments\": {...}} runs a tool with the arguments it takes, and {\"changes\": [...]} writes changes in the format of simulate_change. Either may have a \"description\" echoed in the report"),
+			mcp.Items(map[string]any{"type": "object"}),
+		),
+		mcp.WithArray("checks",
+			mcp.Description("Checkpoint checks among build and diagnostics (default both)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("timeout",
+			mcp.Description("How long to wait for gopls to settle at each diagnostics checkpoint, as a Go duration (default 30s)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		if t.approvals != nil {
+			return mcp.NewToolResultError("refactor_plan writes each step and checks it before the next; it cannot run while edits wait for approval"), nil
+		}
+		token := getProgressToken(request.Params.Meta)
+		steps, err := parsePlanSteps(args["steps"])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		checks := make(map[string]bool)
+		if raw, ok := args["checks"].([]any); ok {
+			for _, item := range raw {
+				check, _ := item.(string)
+				if !slices.Contains(planChecks, check) {
+					return mcp.NewToolResultError(fmt.Sprintf("checks must be among %s", strings.Join(planChecks, ", "))), nil
+				}
+				checks[check] = true
+			}
+		} else {
+			for _, check := range planChecks {
+				checks[check] = true
+			}
+		}
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultDiagnosticsTimeout)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var warnings []string
+		var diagnosticsBase []baselineDiagnostic
+		lspClient := t.getClient()
+		if checks["diagnostics"] {
+			if lspClient == nil {
+				warnings = append(warnings, "gopls is not running; the checkpoints only build")
+				checks["diagnostics"] = false
+			} else {
+				published, quiescent, err := t.settledDiagnostics(ctx, s, token, lspClient, timeout)
+				if err != nil {
+					return nil, err
+				}
+				if !quiescent {
+					warnings = append(warnings, "gopls was still busy when the initial diagnostics were taken")
+				}
+				diagnosticsBase = errorDiagnostics(t.baselineDiagnostics(published))
+			}
+		}
+
+		plan := newEditJournal()
+		results := make([]planStepResult, len(steps))
+		failed := false
+		for i, step := range steps {
+			results[i] = planStepResult{Step: i + 1, Description: step.description, Tool: step.tool, Status: "not_run"}
+			if failed {
+				continue
+			}
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Step %d of %d", i+1, len(steps)))
+			result := &results[i]
+			journal := newEditJournal()
+			problem, err := t.runPlanStep(withEditJournal(ctx, journal), s, step)
+			if err != nil {
+				return nil, err
+			}
+			written, err := journal.changes(false)
+			if err != nil {
+				return nil, err
+			}
+			for _, change := range written {
+				result.Files = append(result.Files, relativeSlashPath(t.workspaceDir, change.path))
+			}
+
+			if problem == "" && len(written) > 0 && checks["build"] {
+				sendProgressNotification(ctx, s, token, fmt.Sprintf("Building after step %d", i+1))
+				if result.BuildErrors, err = t.checkpointBuild(ctx, s, token, written); err != nil {
+					return nil, err
+				}
+				if len(result.BuildErrors) > 0 {
+					problem = "the workspace does not build after this step"
+				}
+			}
+			if problem == "" && len(written) > 0 && checks["diagnostics"] {
+				published, quiescent, err := t.settledDiagnostics(ctx, s, token, lspClient, timeout)
+				if err != nil {
+					return nil, err
+				}
+				if !quiescent {
+					warnings = append(warnings, fmt.Sprintf("gopls was still busy at the checkpoint of step %d", i+1))
+				}
+				current := errorDiagnostics(t.baselineDiagnostics(published))
+				if result.Diagnostics, _, _ = diffDiagnostics(diagnosticsBase, current); len(result.Diagnostics) > 0 {
+					problem = "gopls reports new errors after this step"
+				} else {
+					diagnosticsBase = current
+				}
+			}
+
+			if problem == "" {
+				result.Status = "ok"
+				plan.absorb(journal)
+				continue
+			}
+			failed = true
+			result.Status, result.Error = "failed", problem
+			undo, err := journal.changes(true)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.writeFileChanges(ctx, undo); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("step %d failed (%s) and rolling it back failed too: %v", i+1, problem, err)), nil
+			}
+			for _, change := range undo {
+				result.RolledBack = append(result.RolledBack, relativeSlashPath(t.workspaceDir, change.path))
+			}
+		}
+
+		kept, err := plan.changes(false)
+		if err != nil {
+			return nil, err
+		}
+		completed := 0
+		for _, result := range results {
+			if result.Status == "ok" {
+				completed++
+			}
+		}
+		payload := map[string]any{
+			"ok":        !failed,
+			"completed": completed,
+			"steps":     results,
+			"files":     t.summarizeFileChanges(kept),
+		}
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// parsePlanSteps reads the steps argument of refactor_plan.
+func parsePlanSteps(value any) ([]planStep, error) {
+	raw, ok := value.([]any)
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("steps must be a non-empty array of objects")
+	}
+	steps := make([]planStep, 0, len(raw))
+	for i, item := range raw {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("step %d must be an object", i+1)
+		}
+		step := planStep{changes: entry["changes"]}
+		step.description, _ = entry["description"].(string)
+		step.tool, _ = entry["tool"].(string)
+		switch {
+		case step.tool != "" && step.changes != nil:
+			return nil, fmt.Errorf("step %d: set tool or changes, not both", i+1)
+		case step.tool != "":
+			if !slices.Contains(planStepTools, step.tool) {
+				return nil, fmt.Errorf("step %d: %s cannot be a plan step; use one of %s", i+1, step.tool, strings.Join(planStepTools, ", "))
+			}
+			arguments, _ := entry["arguments"].(map[string]any)
+			step.arguments = maps.Clone(arguments)
+			if step.arguments == nil {
+				step.arguments = make(map[string]any)
+			}
+			step.arguments["apply"] = true
+		case step.changes == nil:
+			return nil, fmt.Errorf("step %d: set tool or changes", i+1)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// runPlanStep runs a step, its writes journaled through ctx. problem
+// explains why the step failed.
+func (t *LSPTools) runPlanStep(ctx context.Context, s *server.MCPServer, step planStep) (string, error) {
+	if step.tool == "" {
+		changes, err := proposedChanges(step.changes)
+		if err != nil {
+			return err.Error(), nil
+		}
+		if err := t.writeFileChanges(ctx, changes); err != nil {
+			return fmt.Sprintf("write the changes: %v", err), nil
+		}
+		return "", nil
+	}
+	tool := s.GetTool(step.tool)
+	if tool == nil {
+		return fmt.Sprintf("%s is not available", step.tool), nil
+	}
+	result, err := tool.Handler(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: step.tool, Arguments: step.arguments}})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return err.Error(), nil
+	}
+	if result.IsError {
+		var texts []string
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+		return strings.Join(texts, "\n"), nil
+	}
+	return "", nil
+}
+
+// checkpointBuild builds the packages the changes touch and the packages
+// depending on them, compiling the tests of the packages whose test files
+// changed too, and returns the compile errors.
+func (t *LSPTools) checkpointBuild(ctx context.Context, s *server.MCPServer, token mcp.ProgressToken, changes []fileChange) ([]buildError, error) {
+	packages, err := t.listWorkspacePackages(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	var paths, testPaths []string
+	for _, change := range changes {
+		paths = append(paths, change.path)
+		if strings.HasSuffix(change.path, "_test.go") {
+			testPaths = append(testPaths, change.path)
+		}
+	}
+	_, targets := t.affectedPackages(packages, paths)
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	commands := [][]string{append([]string{"build"}, targets...)}
+	if tested, _ := t.affectedPackages(packages, testPaths); len(tested) > 0 {
+		commands = append(commands, append([]string{"test", "-count=1", "-run", "^$"}, slices.Sorted(maps.Keys(tested))...))
+	}
+	for _, args := range commands {
+		result, err := t.runCommand(ctx, s, token, "go", args...)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs := parseBuildErrors(result.Stdout + "\n" + result.Stderr)
+		if len(errs) == 0 {
+			errs = []buildError{{Message: buildCommandErrorMessage("go "+args[0], result, err)}}
+		}
+		return errs, nil
+	}
+	return nil, nil
+}
+
+// errorDiagnostics keeps the error findings.
+func errorDiagnostics(diagnostics []baselineDiagnostic) []baselineDiagnostic {
+	return slices.DeleteFunc(diagnostics, func(d baselineDiagnostic) bool {
+		return d.Severity != diagnosticSeverities[1]
+	})
+}
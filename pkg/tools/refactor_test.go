@@ -0,0 +1,892 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestRenameSymbolPreviewAndApply(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n\nfunc Load() {}\n")
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nimport \"example.com/app/store\"\n\nfunc main() { store.Load() }\n")
+	storeURI := convertPathToURI(filepath.Join(workspace, "store", "store.go"))
+	mainURI := convertPathToURI(filepath.Join(workspace, "main.go"))
+	span := func(line, start, end int) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line, Character: start}, End: protocol.Position{Line: line, Character: end}}
+	}
+
+	fakeClient := &fakeLSPClient{
+		rename: &protocol.WorkspaceEdit{
+			Changes: map[string][]protocol.TextEdit{storeURI: {{Range: span(2, 5, 9), NewText: "Fetch"}}},
+			DocumentChanges: []protocol.TextDocumentEdit{{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{URI: mainURI},
+				Edits:        []protocol.TextEdit{{Range: span(4, 20, 24), NewText: "Fetch"}},
+			}},
+		},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(apply bool) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("rename_symbol").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "rename_symbol", Arguments: map[string]any{
+				"file_uri": storeURI,
+				"position": map[string]any{"line": 2, "character": 5},
+				"new_name": "Fetch",
+				"apply":    apply,
+			}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("rename_symbol: %v %#v", err, result)
+		}
+		return structured(result)
+	}
+
+	preview := call(false)
+	files := preview["files"].([]any)
+	if len(files) != 2 || preview["applied"] != false {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	if diff := files[0].(map[string]any)["diff"].(string); files[0].(map[string]any)["path"] != "main.go" || !strings.Contains(diff, "+func main() { store.Fetch() }") {
+		t.Fatalf("unexpected main.go diff %#v", files[0])
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "store", "store.go")); strings.Contains(string(data), "Fetch") {
+		t.Fatal("a preview must not write files")
+	}
+
+	if applied := call(true); applied["applied"] != true {
+		t.Fatalf("unexpected apply result %#v", applied)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "store", "store.go")); string(data) != "package store\n\nfunc Fetch() {}\n" {
+		t.Fatalf("store.go not renamed: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "main.go")); !strings.Contains(string(data), "store.Fetch()") {
+		t.Fatalf("main.go not renamed: %q", data)
+	}
+}
+
+func TestApplyCodeAction(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nfunc main() {\n\tx := 1\n}\n")
+	uri := convertPathToURI(filepath.Join(workspace, "main.go"))
+	edit := func(text string) *protocol.WorkspaceEdit {
+		return &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{uri: {{
+			Range:   protocol.Range{Start: protocol.Position{Line: 3, Character: 1}, End: protocol.Position{Line: 3, Character: 7}},
+			NewText: text,
+		}}}}
+	}
+	fakeClient := &fakeLSPClient{
+		actions: []protocol.CodeAction{
+			{Title: "Remove variable x", Kind: "quickfix", Edit: edit("")},
+			{Title: "Extract variable", Kind: "refactor.extract", Data: map[string]any{"id": 1}},
+			{Title: "Inline call", Kind: "refactor.inline", Command: &protocol.Command{Command: "gopls.apply_fix"}},
+			{Title: "Run go get", Command: &protocol.Command{Command: "gopls.go_get_package"}},
+			{Title: "Extract function", Disabled: &protocol.CodeActionDisabled{Reason: "no statements selected"}},
+		},
+		resolved: map[string]*protocol.WorkspaceEdit{"Extract variable": edit("y := 1")},
+		commands: map[string][]protocol.WorkspaceEdit{"gopls.apply_fix": {*edit("_ = 1")}},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(title string, apply bool) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("apply_code_action").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "apply_code_action", Arguments: map[string]any{
+				"file_uri": uri,
+				"range": map[string]any{
+					"start": map[string]any{"line": 3, "character": 1},
+					"end":   map[string]any{"line": 3, "character": 2},
+				},
+				"title": title,
+				"apply": apply,
+			}},
+		})
+		if err != nil {
+			t.Fatalf("apply_code_action: %v", err)
+		}
+		return result
+	}
+	diffOf := func(result *mcp.CallToolResult) string {
+		t.Helper()
+		if result.IsError {
+			t.Fatalf("unexpected error %#v", result.Content)
+		}
+		return structured(result)["files"].([]any)[0].(map[string]any)["diff"].(string)
+	}
+
+	if diff := diffOf(call("extract variable", false)); !strings.Contains(diff, "+\ty := 1") {
+		t.Fatalf("resolved edit missing from diff:\n%s", diff)
+	}
+	if diff := diffOf(call("Inline call", false)); !strings.Contains(diff, "+\t_ = 1") || len(fakeClient.executed) != 1 {
+		t.Fatalf("command edit missing from diff (executed %v):\n%s", fakeClient.executed, diff)
+	}
+	for _, title := range []string{"Run go get", "Extract function", "extract", "nothing like it"} {
+		if result := call(title, false); !result.IsError {
+			t.Fatalf("expected an error for %q, got %#v", title, result)
+		}
+	}
+	if len(fakeClient.executed) != 1 {
+		t.Fatalf("commands with side effects must not run, executed %v", fakeClient.executed)
+	}
+
+	call("Remove variable x", true)
+	if data, _ := os.ReadFile(filepath.Join(workspace, "main.go")); string(data) != "package main\n\nfunc main() {\n\t\n}\n" {
+		t.Fatalf("quick fix not applied: %q", data)
+	}
+}
+
+func TestOrganizeImports(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n\nfunc Hello() { fmt.Println(\"héllo\") }\n")
+	writeWorkspaceFile(t, workspace, "app/clean.go", "package app\n")
+	writeWorkspaceFile(t, workspace, "app/app_test.go", "package app\n\nimport \"testing\"\n\nfunc TestHello(t *testing.T) { Hello() }")
+	appURI := convertPathToURI(filepath.Join(workspace, "app", "app.go"))
+	testURI := convertPathToURI(filepath.Join(workspace, "app", "app_test.go"))
+	fakeClient := &fakeLSPClient{
+		sourceActions: map[string][]protocol.CodeAction{
+			appURI: {{Title: "Organize Imports", Kind: organizeImportsKind, Edit: &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{appURI: {{
+				Range:   protocol.Range{Start: protocol.Position{Line: 2, Character: 0}, End: protocol.Position{Line: 6, Character: 0}},
+				NewText: "import \"fmt\"\n",
+			}}}}}},
+			testURI: {{Title: "Organize Imports", Kind: organizeImportsKind, Data: map[string]any{"id": 1}}},
+		},
+		resolved: map[string]*protocol.WorkspaceEdit{"Organize Imports": {}},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(target string, apply bool) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("organize_imports").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "organize_imports", Arguments: map[string]any{"file_uri": target, "apply": apply}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("organize_imports: %v %#v", err, result)
+		}
+		return structured(result)
+	}
+
+	preview := call(filepath.Join(workspace, "app"), false)
+	files := preview["files"].([]any)
+	if preview["checked"] != float64(3) || preview["changed"] != float64(1) || len(files) != 1 {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	if diff := files[0].(map[string]any)["diff"].(string); files[0].(map[string]any)["path"] != "app/app.go" || !strings.Contains(diff, "-\t\"os\"") {
+		t.Fatalf("unexpected diff %#v", files[0])
+	}
+
+	if applied := call(appURI, true); applied["applied"] != true || applied["checked"] != float64(1) {
+		t.Fatalf("unexpected apply result %#v", applied)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "app", "app.go")); string(data) != "package app\n\nimport \"fmt\"\n\nfunc Hello() { fmt.Println(\"héllo\") }\n" {
+		t.Fatalf("imports not organized: %q", data)
+	}
+
+	if got := documentRange([]byte("package app\n\nvar s = \"é𝄞\"")); got.End.Line != 2 || got.End.Character != 13 {
+		t.Fatalf("unexpected document range %#v", got)
+	}
+}
+
+func TestFillStruct(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\ntype Options struct {\n\tName string\n\tSize int\n}\n\nvar o = Options{}\n")
+	appURI := convertPathToURI(filepath.Join(workspace, "app", "app.go"))
+	fill := func(title string) protocol.CodeAction {
+		return protocol.CodeAction{Title: title, Kind: fillStructKind, Data: map[string]any{"id": 1}}
+	}
+	fakeClient := &fakeLSPClient{
+		sourceActions: map[string][]protocol.CodeAction{appURI: {fill("Fill app.Options")}},
+		resolved: map[string]*protocol.WorkspaceEdit{"Fill app.Options": {Changes: map[string][]protocol.TextEdit{appURI: {{
+			Range:   protocol.Range{Start: protocol.Position{Line: 7, Character: 16}, End: protocol.Position{Line: 7, Character: 16}},
+			NewText: "\n\tName: \"\",\n\tSize: 0,\n",
+		}}}}},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["file_uri"] = appURI
+		args["position"] = map[string]any{"line": 7, "character": 16}
+		result, err := server.GetTool("fill_struct").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "fill_struct", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("fill_struct: %v", err)
+		}
+		return result
+	}
+
+	preview := structured(call(map[string]any{}))
+	if files := preview["files"].([]any); preview["title"] != "Fill app.Options" || len(files) != 1 || !strings.Contains(files[0].(map[string]any)["diff"].(string), "+\tSize: 0,") {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	if applied := structured(call(map[string]any{"apply": true})); applied["applied"] != true {
+		t.Fatalf("unexpected apply result %#v", applied)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "app", "app.go")); !strings.HasSuffix(string(data), "var o = Options{\n\tName: \"\",\n\tSize: 0,\n}\n") {
+		t.Fatalf("struct not filled: %q", data)
+	}
+
+	fakeClient.sourceActions[appURI] = append(fakeClient.sourceActions[appURI], fill("Fill app.Inner"))
+	if result := call(map[string]any{}); !result.IsError {
+		t.Fatal("expected nested literals to need a type")
+	}
+	if result := call(map[string]any{"type": "Options"}); result.IsError {
+		t.Fatalf("type should pick the literal: %#v", result.Content)
+	}
+	fakeClient.sourceActions[appURI] = nil
+	if result := call(map[string]any{}); !result.IsError {
+		t.Fatal("expected an error without a literal to fill")
+	}
+}
+
+func TestModifyStructTags(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\ntype User struct {\n\tName string\n}\n\nfunc f() {\n\ttype Local struct{}\n}\n")
+	appURI := convertPathToURI(filepath.Join(workspace, "app", "app.go"))
+	fakeClient := &fakeLSPClient{
+		commands: map[string][]protocol.WorkspaceEdit{modifyTagsCommand: {{Changes: map[string][]protocol.TextEdit{appURI: {{
+			Range:   protocol.Range{Start: protocol.Position{Line: 3, Character: 12}, End: protocol.Position{Line: 3, Character: 12}},
+			NewText: " `json:\"name,omitempty\"`",
+		}}}}}},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["file_uri"] = appURI
+		result, err := server.GetTool("modify_struct_tags").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "modify_struct_tags", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("modify_struct_tags: %v", err)
+		}
+		return result
+	}
+
+	preview := structured(call(map[string]any{"struct": "User", "add": []any{"json"}, "add_options": []any{"json=omitempty"}, "transform": "camelcase"}))
+	if files := preview["files"].([]any); len(files) != 1 || !strings.Contains(files[0].(map[string]any)["diff"].(string), "+\tName string `json:\"name,omitempty\"`") {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	sent := fakeClient.arguments[0][0].(map[string]any)
+	want := protocol.Range{Start: protocol.Position{Line: 2, Character: 10}, End: protocol.Position{Line: 4, Character: 1}}
+	if sent["Add"] != "json" || sent["AddOptions"] != "json=omitempty" || sent["Transform"] != "camelcase" || sent["Range"] != want {
+		t.Fatalf("unexpected command arguments %#v", sent)
+	}
+	if applied := structured(call(map[string]any{"struct": "User", "add": []any{"json"}, "apply": true})); applied["applied"] != true {
+		t.Fatalf("unexpected apply result %#v", applied)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "app", "app.go")); !strings.Contains(string(data), "Name string `json:\"name,omitempty\"`") {
+		t.Fatalf("tags not written: %q", data)
+	}
+
+	for _, args := range []map[string]any{
+		{"struct": "User"},
+		{"add": []any{"json"}},
+		{"struct": "Missing", "add": []any{"json"}},
+		{"struct": "User", "add": []any{"json"}, "transform": "upper"},
+	} {
+		if result := call(args); !result.IsError {
+			t.Fatalf("expected an error for %v", args)
+		}
+	}
+	if result := call(map[string]any{"struct": "Local", "clear": true}); result.IsError {
+		t.Fatalf("expected local struct types to be found: %#v", result.Content)
+	}
+}
+
+func TestExtractTools(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\nfunc total(a, b int) int {\n\tsum := a + b\n\treturn sum * 2\n}\n")
+	appURI := convertPathToURI(filepath.Join(workspace, "app", "app.go"))
+	at := func(line, character int) protocol.Position {
+		return protocol.Position{Line: line, Character: character}
+	}
+	fakeClient := &fakeLSPClient{
+		sourceActions: map[string][]protocol.CodeAction{appURI: {
+			{Title: "Extract function", Kind: "refactor.extract.function", Data: map[string]any{"id": 1}},
+			{Title: "Extract variable", Kind: "refactor.extract.variable", Data: map[string]any{"id": 2}},
+			{Title: "Extract 2 occurrences", Kind: "refactor.extract.variable-all", Disabled: &protocol.CodeActionDisabled{Reason: "only one occurrence"}},
+		}},
+		resolved: map[string]*protocol.WorkspaceEdit{
+			"Extract function": {Changes: map[string][]protocol.TextEdit{appURI: {
+				{Range: protocol.Range{Start: at(3, 1), End: at(3, 13)}, NewText: "sum := newFunction(a, b)"},
+				{Range: protocol.Range{Start: at(6, 0), End: at(6, 0)}, NewText: "\nfunc newFunction(a int, b int) int {\n\t// newFunction stays in comments\n\tsum := a + b\n\treturn sum\n}\n"},
+			}}},
+			"Extract variable": {Changes: map[string][]protocol.TextEdit{appURI: {
+				{Range: protocol.Range{Start: at(3, 1), End: at(3, 1)}, NewText: "x := 2\n\t"},
+				{Range: protocol.Range{Start: at(4, 14), End: at(4, 15)}, NewText: "x"},
+			}}},
+		},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["file_uri"] = appURI
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	statement := map[string]any{"start": map[string]any{"line": 3, "character": 1}, "end": map[string]any{"line": 3, "character": 13}}
+	literal := map[string]any{"start": map[string]any{"line": 4, "character": 14}, "end": map[string]any{"line": 4, "character": 15}}
+
+	extracted := structured(call("extract_function", map[string]any{"range": statement, "name": "add"}))
+	diff := extracted["files"].([]any)[0].(map[string]any)["diff"].(string)
+	if extracted["name"] != "add" || !strings.Contains(diff, "+\tsum := add(a, b)") || !strings.Contains(diff, "+func add(a int, b int) int {") ||
+		!strings.Contains(diff, "// newFunction stays in comments") {
+		t.Fatalf("unexpected extract_function result %v\n%s", extracted, diff)
+	}
+	variable := structured(call("extract_variable", map[string]any{"range": literal, "name": "factor", "apply": true}))
+	if variable["name"] != "factor" || variable["applied"] != true {
+		t.Fatalf("unexpected extract_variable result %v", variable)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "app", "app.go")); !strings.Contains(string(data), "\tfactor := 2\n\tsum := a + b\n\treturn sum * factor\n") {
+		t.Fatalf("variable not extracted: %q", data)
+	}
+
+	if result := call("extract_variable", map[string]any{"range": literal, "all_occurrences": true}); !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "only one occurrence") {
+		t.Fatalf("expected the disabled reason, got %#v", result.Content)
+	}
+	if result := call("extract_method", map[string]any{"range": statement}); !result.IsError {
+		t.Fatal("expected extract_method to fail outside a method")
+	}
+	if result := call("extract_function", map[string]any{"range": statement, "name": "1st"}); !result.IsError {
+		t.Fatal("expected an invalid name to fail")
+	}
+}
+
+func TestInlineTools(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\nfunc double(n int) int { return n * 2 }\n\nfunc Use() int {\n\tx := double(3)\n\treturn x + 1\n}\n")
+	writeWorkspaceFile(t, workspace, "app/a_value.go", "package app\n\nvar scale = double\n")
+	appURI := convertPathToURI(filepath.Join(workspace, "app", "app.go"))
+	valueURI := convertPathToURI(filepath.Join(workspace, "app", "a_value.go"))
+	at := func(line, character int) protocol.Position {
+		return protocol.Position{Line: line, Character: character}
+	}
+	fakeClient := &fakeLSPClient{
+		references: []protocol.Location{
+			{URI: appURI, Range: protocol.Range{Start: at(5, 6), End: at(5, 12)}},
+			{URI: valueURI, Range: protocol.Range{Start: at(2, 12), End: at(2, 18)}},
+		},
+		sourceActions: map[string][]protocol.CodeAction{
+			appURI: {
+				{Title: "Inline call to double", Kind: "refactor.inline.call", Data: map[string]any{"id": 1}},
+				{Title: "Inline variable x", Kind: "refactor.inline.variable", Data: map[string]any{"id": 2}},
+			},
+			valueURI: {{Title: "Inline call to double", Kind: "refactor.inline.call", Disabled: &protocol.CodeActionDisabled{Reason: "not a call"}}},
+		},
+		resolved: map[string]*protocol.WorkspaceEdit{
+			"Inline call to double": {Changes: map[string][]protocol.TextEdit{appURI: {{Range: protocol.Range{Start: at(5, 6), End: at(5, 15)}, NewText: "3 * 2"}}}},
+			"Inline variable x": {Changes: map[string][]protocol.TextEdit{appURI: {
+				{Range: protocol.Range{Start: at(5, 0), End: at(6, 0)}, NewText: ""},
+				{Range: protocol.Range{Start: at(6, 8), End: at(6, 9)}, NewText: "double(3)"},
+			}}},
+		},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["file_uri"] = appURI
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	position := func(line, character int) map[string]any {
+		return map[string]any{"line": line, "character": character}
+	}
+
+	variable := structured(call("inline_variable", map[string]any{"position": position(6, 8)}))
+	diff := variable["files"].([]any)[0].(map[string]any)["diff"].(string)
+	if variable["applied"] != false || !strings.Contains(diff, "-\tx := double(3)") || !strings.Contains(diff, "+\treturn double(3) + 1") {
+		t.Fatalf("unexpected inline_variable result %v\n%s", variable, diff)
+	}
+
+	if result := call("inline_call", map[string]any{"position": position(2, 6), "all": true}); !result.IsError {
+		t.Fatal("expected all without apply to fail")
+	}
+	if result := call("inline_call", map[string]any{"position": position(5, 2), "all": true, "apply": true}); !result.IsError {
+		t.Fatal("expected all outside a declaration name to fail")
+	}
+	inlined := structured(call("inline_call", map[string]any{"position": position(2, 6), "all": true, "apply": true}))
+	skipped := inlined["skipped"].([]any)
+	if inlined["symbol"] != "func double" || inlined["inlined"] != float64(1) || len(skipped) != 1 ||
+		skipped[0].(map[string]any)["path"] != "app/a_value.go" || !strings.Contains(skipped[0].(map[string]any)["reason"].(string), "not a call") {
+		t.Fatalf("unexpected inline_call result %v", inlined)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "app", "app.go")); !strings.Contains(string(data), "\tx := 3 * 2\n") {
+		t.Fatalf("call not inlined: %q", data)
+	}
+
+	tools.SetApprovalToken("secret")
+	if result := call("inline_call", map[string]any{"position": position(2, 6), "all": true, "apply": true}); !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "approval") {
+		t.Fatalf("expected all to be refused under approval, got %#v", result.Content)
+	}
+	if pending := tools.approvals.list(); len(pending) != 0 {
+		t.Fatalf("expected nothing queued, got %v", pending)
+	}
+}
+
+func TestParamMoves(t *testing.T) {
+	for _, tc := range []struct {
+		order, moves []int
+	}{
+		{order: []int{0, 1, 2}},
+		{order: []int{1, 0, 2}, moves: []int{1}},
+		{order: []int{2, 0, 1}, moves: []int{2, 1}},
+		{order: []int{2, 1, 0}, moves: []int{2, 1, 2}},
+	} {
+		if moves := paramMoves(tc.order); !reflect.DeepEqual(moves, tc.moves) {
+			t.Errorf("paramMoves(%v) = %v, want %v", tc.order, moves, tc.moves)
+		}
+	}
+}
+
+func TestChangeSignature(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\nfunc pick(a, b int, _ string) int { return a }\n\nvar x = pick(1, 2, \"\")\n")
+	appURI := convertPathToURI(filepath.Join(workspace, "app", "app.go"))
+	at := func(line, character int) protocol.Position {
+		return protocol.Position{Line: line, Character: character}
+	}
+	fakeClient := &fakeLSPClient{
+		sourceActions: map[string][]protocol.CodeAction{appURI: {
+			{Title: "Remove unused parameter", Kind: "refactor.rewrite.removeUnusedParam", Data: map[string]any{"id": 1}},
+			{Title: "Move parameter left", Kind: "refactor.rewrite.moveParamLeft", Data: map[string]any{"id": 2}},
+		}},
+		resolved: map[string]*protocol.WorkspaceEdit{
+			"Remove unused parameter": {Changes: map[string][]protocol.TextEdit{appURI: {
+				{Range: protocol.Range{Start: at(2, 11), End: at(2, 14)}, NewText: ""},
+				{Range: protocol.Range{Start: at(4, 14), End: at(4, 17)}, NewText: ""},
+			}}},
+			// Applied once per move, at the top of the file whatever moved.
+			"Move parameter left": {Changes: map[string][]protocol.TextEdit{appURI: {{Range: protocol.Range{Start: at(0, 0), End: at(0, 0)}, NewText: "// moved\n"}}}},
+		},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["file_uri"] = appURI
+		args["position"] = map[string]any{"line": 2, "character": 6}
+		result, err := server.GetTool("change_signature").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "change_signature", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("change_signature: %v", err)
+		}
+		return result
+	}
+
+	removed := structured(call(map[string]any{"remove": "b"}))
+	diff := removed["files"].([]any)[0].(map[string]any)["diff"].(string)
+	if removed["symbol"] != "func pick" || removed["applied"] != false || !strings.Contains(diff, "+func pick(a int, _ string) int { return a }") {
+		t.Fatalf("unexpected remove result %v\n%s", removed, diff)
+	}
+	if result := call(map[string]any{"remove": "c"}); !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "a, b, 2") {
+		t.Fatalf("expected the parameters to be listed, got %#v", result.Content)
+	}
+	if result := call(map[string]any{"order": []any{"2", "a"}}); !result.IsError {
+		t.Fatal("expected an incomplete order to fail")
+	}
+	if result := call(map[string]any{"order": []any{"2", "a", "b"}}); !result.IsError {
+		t.Fatal("expected a reorder of several moves to need apply")
+	}
+
+	reordered := structured(call(map[string]any{"order": []any{"2", "a", "b"}, "apply": true}))
+	if reordered["applied"] != true || len(reordered["files"].([]any)) != 1 {
+		t.Fatalf("unexpected reorder result %v", reordered)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "app", "app.go")); !strings.HasPrefix(string(data), "// moved\n// moved\npackage app\n") {
+		t.Fatalf("expected one edit per move: %q", data)
+	}
+
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\nfunc pick(a, b int, _ string) int { return a }\n\nvar x = pick(1, 2, \"\")\n")
+	tools.SetApprovalToken("secret")
+	if result := call(map[string]any{"order": []any{"2", "a", "b"}, "apply": true}); !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "approval") {
+		t.Fatalf("expected a reorder of several moves to be refused under approval, got %#v", result.Content)
+	}
+	if pending := tools.approvals.list(); len(pending) != 0 {
+		t.Fatalf("expected nothing queued, got %v", pending)
+	}
+	moved := structured(call(map[string]any{"order": []any{"a", "2", "b"}, "apply": true}))
+	if moved["status"] != "pending_approval" || len(tools.approvals.list()) != 1 {
+		t.Fatalf("expected a single move to be queued for approval, got %v", moved)
+	}
+}
+
+func TestRefactorPlan(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nfunc Double(n int) int { return n * 2 }\n")
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nimport \"example.com/app/calc\"\n\nfunc main() { println(calc.Double(1)) }\n")
+	calcURI := convertPathToURI(filepath.Join(workspace, "calc", "calc.go"))
+	mainURI := convertPathToURI(filepath.Join(workspace, "main.go"))
+	word := func(line, start, end int) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line, Character: start}, End: protocol.Position{Line: line, Character: end}}
+	}
+	fakeClient := &fakeLSPClient{rename: &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{
+		calcURI: {{Range: word(2, 5, 11), NewText: "Twice"}},
+		mainURI: {{Range: word(4, 27, 33), NewText: "Twice"}},
+	}}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+
+	result, err := server.GetTool("refactor_plan").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "refactor_plan", Arguments: map[string]any{"steps": []any{
+			map[string]any{"description": "rename", "tool": "rename_symbol", "arguments": map[string]any{
+				"file_uri": calcURI, "position": map[string]any{"line": 2, "character": 6}, "new_name": "Twice",
+			}},
+			map[string]any{"description": "document", "changes": []any{map[string]any{"file_uri": calcURI, "edits": []any{map[string]any{
+				"range": map[string]any{"start": map[string]any{"line": 2, "character": 0}, "end": map[string]any{"line": 2, "character": 0}}, "newText": "// Twice doubles n.\n",
+			}}}}},
+			map[string]any{"description": "break", "changes": []any{
+				map[string]any{"file_uri": filepath.Join(workspace, "calc", "half.go"), "content": "package calc\n\nfunc Half(n int) int { return Double(n) / 4 }\n"},
+			}},
+			map[string]any{"description": "never", "tool": "format_code", "arguments": map[string]any{}},
+		}}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("refactor_plan: %v %v", err, result)
+	}
+	plan := structured(result)
+	steps := plan["steps"].([]any)
+	status := func(i int) map[string]any { return steps[i].(map[string]any) }
+	if plan["ok"] != false || plan["completed"] != float64(2) || status(0)["status"] != "ok" || status(1)["status"] != "ok" ||
+		status(2)["status"] != "failed" || status(3)["status"] != "not_run" {
+		t.Fatalf("unexpected plan result %v", plan)
+	}
+	if errs := status(2)["build_errors"].([]any); len(errs) == 0 || !reflect.DeepEqual(status(2)["rolled_back"], []any{"calc/half.go"}) {
+		t.Fatalf("expected the failing step to be rolled back, got %v", status(2))
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "calc", "half.go")); !os.IsNotExist(err) {
+		t.Fatalf("the failing step was not rolled back: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "calc", "calc.go")); string(data) != "package calc\n\n// Twice doubles n.\nfunc Twice(n int) int { return n * 2 }\n" {
+		t.Fatalf("the good steps were not kept: %q", data)
+	}
+	if files := plan["files"].([]any); len(files) != 2 {
+		t.Fatalf("expected the diff of calc.go and main.go, got %v", files)
+	}
+
+	if result, _ := server.GetTool("refactor_plan").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "refactor_plan", Arguments: map[string]any{"steps": []any{map[string]any{"tool": "run_go_test"}}}},
+	}); !result.IsError {
+		t.Fatal("expected a tool that is not a refactoring to be refused")
+	}
+}
+
+func TestEditContract(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nfunc Double(n int) int { return n * 2 }\n")
+	writeWorkspaceFile(t, workspace, "main.go", "package main\n\nimport \"example.com/app/calc\"\n\nfunc main() { println(calc.Double(1)) }\n")
+	calcURI := convertPathToURI(filepath.Join(workspace, "calc", "calc.go"))
+	mainURI := convertPathToURI(filepath.Join(workspace, "main.go"))
+	word := func(line, start, end int) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line, Character: start}, End: protocol.Position{Line: line, Character: end}}
+	}
+	fakeClient := &fakeLSPClient{rename: &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{
+		calcURI: {{Range: word(2, 5, 11), NewText: "Twice"}},
+		mainURI: {{Range: word(4, 27, 33), NewText: "Twice"}},
+	}}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	tools.SetApprovalToken("secret")
+	server := mcpsrv.NewMCPServer("test", "1.0", mcpsrv.WithResourceCapabilities(true, true))
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	rename := func() *mcp.CallToolResult {
+		return call("rename_symbol", map[string]any{
+			"file_uri": calcURI, "position": map[string]any{"line": 2, "character": 6}, "new_name": "Twice", "apply": true,
+		})
+	}
+
+	if result := call("edit_contract", map[string]any{"action": "declare", "packages": []any{"example.com/app/calc"}, "reason": "rename Double"}); result.IsError {
+		t.Fatalf("declare: %v", result)
+	}
+	result := rename()
+	if !result.IsError {
+		t.Fatal("expected the rename reaching main.go to be refused")
+	}
+	if refusal := structured(result); refusal["error"] != "edit_scope_violation" || !reflect.DeepEqual(refusal["violations"], []any{"main.go"}) {
+		t.Fatalf("unexpected refusal %v", refusal)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "calc", "calc.go")); strings.Contains(string(data), "Twice") {
+		t.Fatal("a refused edit must not write any file")
+	}
+	shown := structured(call("edit_contract", map[string]any{}))
+	contract := shown["contract"].(map[string]any)
+	if shown["declared"] != true || contract["refused_writes"] != float64(1) || len(contract["violations"].([]any)) != 1 {
+		t.Fatalf("unexpected contract %v", shown)
+	}
+	if result := call("edit_contract", map[string]any{"action": "declare", "packages": []any{"./..."}}); !result.IsError {
+		t.Fatal("expected a second contract to be refused")
+	}
+
+	if result := call("edit_contract", map[string]any{"action": "clear"}); !result.IsError {
+		t.Fatal("expected clearing without the approval token to be refused")
+	}
+	if result := call("edit_contract", map[string]any{"action": "clear", "token": "guess"}); !result.IsError {
+		t.Fatal("expected clearing with a wrong token to be refused")
+	}
+	if result := call("edit_contract", map[string]any{"action": "clear", "token": "secret"}); result.IsError {
+		t.Fatalf("clear: %v", result)
+	}
+	if result := call("edit_contract", map[string]any{"action": "declare", "packages": []any{"./calc"}, "files": []any{"*.go"}}); result.IsError {
+		t.Fatalf("declare: %v", result)
+	}
+	if queued := structured(rename()); queued["status"] != "pending_approval" {
+		t.Fatalf("expected the rename to be covered and queued: %v", queued)
+	}
+	if result := call("approve_pending_change", map[string]any{"id": "change-1", "token": "secret"}); result.IsError {
+		t.Fatalf("approve: %v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "main.go")); !strings.Contains(string(data), "calc.Twice") {
+		t.Fatalf("main.go not renamed: %q", data)
+	}
+
+	unapproved := NewLSPTools(fakeClient, workspace)
+	unapprovedServer := mcpsrv.NewMCPServer("test", "1.0")
+	unapproved.Register(unapprovedServer)
+	result, err := unapprovedServer.GetTool("edit_contract").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "edit_contract", Arguments: map[string]any{"action": "clear"}},
+	})
+	if err != nil || !result.IsError {
+		t.Fatalf("expected clear to be refused without --require-approval, got %v %v", result, err)
+	}
+
+	subtree, err := tools.newEditContract([]string{"./internal/..."}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]bool{"internal/store/store.go": true, "internal/x.go": true, "internals/x.go": false, "../internal/x.go": false} {
+		if got := subtree.covers(rel); got != want {
+			t.Errorf("covers(%s) = %v, want %v", rel, got, want)
+		}
+	}
+	if _, err := tools.newEditContract([]string{"example.com/other"}, nil); err == nil {
+		t.Error("expected a package outside the module to be refused")
+	}
+}
+
+func TestFormatCode(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, ".mcp-gopls.json", `{"gopls": {"gofumpt": true}}`)
+	writeWorkspaceFile(t, workspace, "app/app.go", "package app\n\nfunc  Hello() {}\n")
+	writeWorkspaceFile(t, workspace, "app/clean.go", "package app\n")
+	writeWorkspaceFile(t, workspace, "app/inner/broken.go", "package inner\n\nfunc {\n")
+	writeWorkspaceFile(t, workspace, "app/testdata/src.go", "package  src\n")
+	writeWorkspaceFile(t, workspace, "app/vendor/dep/dep.go", "package  dep\n")
+	appURI := convertPathToURI(filepath.Join(workspace, "app", "app.go"))
+	brokenURI := convertPathToURI(filepath.Join(workspace, "app", "inner", "broken.go"))
+	fakeClient := &fakeLSPClient{
+		formatting: map[string][]protocol.TextEdit{appURI: {{
+			Range:   protocol.Range{Start: protocol.Position{Line: 2, Character: 4}, End: protocol.Position{Line: 2, Character: 6}},
+			NewText: " ",
+		}}},
+		formatErrors: map[string]error{brokenURI: errors.New("expected 'IDENT', found '{'")},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(arguments map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("format_code").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "format_code", Arguments: arguments},
+		})
+		if err != nil {
+			t.Fatalf("format_code: %v", err)
+		}
+		return result
+	}
+
+	preview := structured(call(map[string]any{"file_uri": filepath.Join(workspace, "app")}))
+	files := preview["files"].([]any)
+	if preview["checked"] != float64(3) || preview["changed"] != float64(1) || preview["gofumpt"] != true || len(preview["failed"].([]any)) != 1 {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	if diff := files[0].(map[string]any)["diff"].(string); !strings.Contains(diff, "+func Hello() {}") {
+		t.Fatalf("unexpected diff %#v", files[0])
+	}
+	if flat := structured(call(map[string]any{"file_uri": filepath.Join(workspace, "app"), "recursive": false})); flat["checked"] != float64(2) {
+		t.Fatalf("a non-recursive run should only check the directory itself, got %#v", flat)
+	}
+
+	if applied := structured(call(map[string]any{"file_uri": filepath.Join(workspace, "app"), "apply": true})); applied["applied"] != true {
+		t.Fatalf("unexpected apply result %#v", applied)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "app", "app.go")); string(data) != "package app\n\nfunc Hello() {}\n" {
+		t.Fatalf("file not formatted: %q", data)
+	}
+}
+
+func TestCodeLens(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n\nrequire example.com/dep v1.0.0\n")
+	uri := convertPathToURI(filepath.Join(workspace, "go.mod"))
+	lensAt := func(line int, title, command string) protocol.CodeLens {
+		return protocol.CodeLens{
+			Range:   protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line, Character: 6}},
+			Command: &protocol.Command{Title: title, Command: command, Arguments: []any{map[string]any{"URIs": []any{uri}}}},
+		}
+	}
+	fakeClient := &fakeLSPClient{
+		lenses: []protocol.CodeLens{
+			lensAt(4, "Upgrade direct dependencies", "gopls.upgrade_dependency"),
+			lensAt(0, "Run go mod tidy", "gopls.tidy"),
+			{Range: protocol.Range{Start: protocol.Position{Line: 2}}},
+		},
+		commands: map[string][]protocol.WorkspaceEdit{"gopls.tidy": {{Changes: map[string][]protocol.TextEdit{uri: {{
+			Range: protocol.Range{Start: protocol.Position{Line: 4}, End: protocol.Position{Line: 4, Character: 31}},
+		}}}}}},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["file_uri"] = uri
+		result, err := server.GetTool("code_lens").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "code_lens", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("code_lens: %v", err)
+		}
+		return result
+	}
+
+	lenses := structured(call(map[string]any{}))["lenses"].([]any)
+	if len(lenses) != 2 {
+		t.Fatalf("expected the two lenses with a command, got %#v", lenses)
+	}
+	if first := lenses[0].(map[string]any); first["command"] != "gopls.tidy" || first["line"] != float64(1) || first["code"] != "module example.com/app" {
+		t.Fatalf("lenses should be sorted by position, got %#v", first)
+	}
+	if len(fakeClient.executed) != 0 {
+		t.Fatalf("listing must not execute commands, executed %v", fakeClient.executed)
+	}
+
+	preview := structured(call(map[string]any{"execute": 0}))
+	diff := preview["files"].([]any)[0].(map[string]any)["diff"].(string)
+	if !strings.Contains(diff, "-require example.com/dep v1.0.0") || preview["applied"] != false || fakeClient.executed[0] != "gopls.tidy" {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "go.mod")); !strings.Contains(string(data), "require") {
+		t.Fatal("preview must not write the edit")
+	}
+	if result := call(map[string]any{"execute": 2}); !result.IsError {
+		t.Fatalf("expected an error for an unknown lens, got %#v", result)
+	}
+	call(map[string]any{"execute": 0, "apply": true})
+	if data, _ := os.ReadFile(filepath.Join(workspace, "go.mod")); strings.Contains(string(data), "require") {
+		t.Fatalf("tidy edit not applied: %q", data)
+	}
+}
+
+func TestGoplsCommand(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n\nrequire example.com/dep v1.0.0\n")
+	uri := convertPathToURI(filepath.Join(workspace, "go.mod"))
+	fakeClient := &fakeLSPClient{
+		commands: map[string][]protocol.WorkspaceEdit{
+			"gopls.tidy": {{Changes: map[string][]protocol.TextEdit{uri: {{
+				Range: protocol.Range{Start: protocol.Position{Line: 4}, End: protocol.Position{Line: 4, Character: 31}},
+			}}}}},
+			"gopls.list_known_packages": nil,
+		},
+		results: map[string]json.RawMessage{"gopls.list_known_packages": json.RawMessage(`{"Packages":["fmt","os"]}`)},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("gopls_command").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "gopls_command", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("gopls_command: %v", err)
+		}
+		return result
+	}
+
+	listed := structured(call(map[string]any{}))["commands"].([]any)
+	if len(listed) != 2 || listed[1].(map[string]any)["name"] != "gopls.tidy" || listed[1].(map[string]any)["tool"] != "go_mod_tidy" {
+		t.Fatalf("unexpected commands %#v", listed)
+	}
+
+	content := structured(call(map[string]any{"command": "gopls.list_known_packages", "arguments": `{"URI": "` + uri + `"}`}))
+	if packages := content["result"].(map[string]any)["Packages"].([]any); len(packages) != 2 || content["applied"] != false {
+		t.Fatalf("unexpected result %#v", content)
+	}
+	if args := fakeClient.arguments[0]; len(args) != 1 || args[0].(map[string]any)["URI"] != uri {
+		t.Fatalf("an object should be passed as the single argument, got %#v", args)
+	}
+
+	if result := call(map[string]any{"command": "gopls.unknown"}); !result.IsError {
+		t.Fatalf("expected an error for a command gopls does not advertise, got %#v", result)
+	}
+	if result := call(map[string]any{"command": "gopls.tidy", "arguments": "[oops"}); !result.IsError {
+		t.Fatalf("expected an error for invalid JSON arguments, got %#v", result)
+	}
+
+	content = structured(call(map[string]any{"command": "gopls.tidy", "arguments": []any{map[string]any{"URIs": []any{uri}}}, "apply": true}))
+	if content["applied"] != true {
+		t.Fatalf("unexpected tidy result %#v", content)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "go.mod")); strings.Contains(string(data), "require") {
+		t.Fatalf("tidy edit not applied: %q", data)
+	}
+}
@@ -0,0 +1,442 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+var lookupApidiffBinary = exec.LookPath
+
+const (
+	releaseCheckPass    = "pass"
+	releaseCheckFail    = "fail"
+	releaseCheckWarn    = "warn"
+	releaseCheckSkipped = "skipped"
+)
+
+type releaseCheck struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	Summary string   `json:"summary"`
+	Details []string `json:"details,omitempty"`
+}
+
+type apiChanges struct {
+	Incompatible []string `json:"incompatible"`
+	Compatible   []string `json:"compatible"`
+}
+
+type releaseReport struct {
+	Module           string         `json:"module"`
+	LatestTag        string         `json:"latest_tag,omitempty"`
+	Checks           []releaseCheck `json:"checks"`
+	API              *apiChanges    `json:"api,omitempty"`
+	SuggestedBump    string         `json:"suggested_bump"`
+	SuggestedVersion string         `json:"suggested_version"`
+	Decision         string         `json:"decision"`
+}
+
+func (t *LSPTools) registerCheckRelease(s *server.MCPServer) {
+	tool := mcp.NewTool("check_release",
+		mcp.WithDescription("Check release readiness: tag conventions, API compatibility against the latest tag (apidiff), go.mod tidiness and govulncheck, with a go/no-go decision and suggested semver bump"),
+		mcp.WithTitleAnnotation("Check Release"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("skip_vulncheck", mcp.Description("Skip the govulncheck step")),
+		mcp.WithBoolean("skip_apidiff", mcp.Description("Skip the API compatibility step")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		token := getProgressToken(request.Params.Meta)
+
+		modPath, err := readModulePath(t.workspaceDir)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		report := releaseReport{Module: modPath}
+
+		sendProgressNotification(ctx, s, token, "Inspecting release tags")
+		tagCheck, latest := t.checkReleaseTags(ctx, s, modPath)
+		report.LatestTag = latest
+		report.Checks = append(report.Checks, tagCheck, t.checkCleanTree(ctx, s))
+
+		sendProgressNotification(ctx, s, token, "Checking go.mod tidiness")
+		report.Checks = append(report.Checks, t.checkModTidy(ctx, s))
+
+		if getOptionalBoolArg(args, "skip_vulncheck") {
+			report.Checks = append(report.Checks, releaseCheck{Name: "vulnerabilities", Status: releaseCheckSkipped, Summary: "skipped on request"})
+		} else {
+			sendProgressNotification(ctx, s, token, "Running govulncheck")
+			report.Checks = append(report.Checks, t.checkVulnerabilities(ctx, s))
+		}
+
+		switch {
+		case getOptionalBoolArg(args, "skip_apidiff"):
+			report.Checks = append(report.Checks, releaseCheck{Name: "api_compatibility", Status: releaseCheckSkipped, Summary: "skipped on request"})
+		case latest == "":
+			report.Checks = append(report.Checks, releaseCheck{Name: "api_compatibility", Status: releaseCheckSkipped, Summary: "no previous release tag"})
+		default:
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Comparing API against %s", latest))
+			check, changes := t.checkAPICompatibility(ctx, s, modPath, latest)
+			report.Checks = append(report.Checks, check)
+			report.API = changes
+		}
+
+		report.SuggestedBump, report.SuggestedVersion = suggestReleaseVersion(tagVersion(latest), modPath, report.API)
+		report.Decision = releaseDecision(report.Checks)
+
+		result, err := mcp.NewToolResultJSON(report)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func readModulePath(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("unable to read go.mod: %w", err)
+	}
+	modPath := modfile.ModulePath(data)
+	if modPath == "" {
+		return "", errors.New("go.mod does not declare a module path")
+	}
+	return modPath, nil
+}
+
+func (t *LSPTools) checkReleaseTags(ctx context.Context, s *server.MCPServer, modPath string) (releaseCheck, string) {
+	check := releaseCheck{Name: "tags"}
+
+	prefixResult, err := t.runCommand(ctx, s, nil, "git", "rev-parse", "--show-prefix")
+	if err != nil {
+		check.Status = releaseCheckSkipped
+		check.Summary = "workspace is not a git repository"
+		return check, ""
+	}
+	tagsResult, err := t.runCommand(ctx, s, nil, "git", "tag", "--list")
+	if err != nil {
+		check.Status = releaseCheckSkipped
+		check.Summary = "unable to list git tags"
+		return check, ""
+	}
+
+	prefix := strings.TrimSpace(prefixResult.Stdout)
+	latest, problems := evaluateReleaseTags(splitLines(tagsResult.Stdout), prefix, modPath)
+	check.Details = problems
+	switch {
+	case latest == "":
+		check.Status = releaseCheckWarn
+		check.Summary = "no release tags found"
+	case len(problems) > 0:
+		check.Status = releaseCheckWarn
+		check.Summary = fmt.Sprintf("latest release is %s; %d tag issue(s)", latest, len(problems))
+	default:
+		check.Status = releaseCheckPass
+		check.Summary = fmt.Sprintf("latest release is %s", latest)
+	}
+	return check, latest
+}
+
+// evaluateReleaseTags returns the latest release tag for the module rooted
+// at prefix (the path of the module relative to the repository root) along
+// with any tags that break Go module tagging conventions.
+func evaluateReleaseTags(tags []string, prefix, modPath string) (string, []string) {
+	prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	_, pathMajor, _ := module.SplitPathVersion(modPath)
+
+	var (
+		latest   string
+		problems []string
+	)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		version := strings.TrimPrefix(tag, prefix)
+		if strings.Contains(version, "/") {
+			continue // belongs to a nested module
+		}
+		if !semver.IsValid(version) {
+			if looksLikeVersion(version) {
+				problems = append(problems, fmt.Sprintf("%s is not a valid semantic version tag (expected vMAJOR.MINOR.PATCH)", tag))
+			}
+			continue
+		}
+		if semver.Canonical(version) != version {
+			problems = append(problems, fmt.Sprintf("%s is not canonical; use %s%s", tag, prefix, semver.Canonical(version)))
+			continue
+		}
+		if err := module.CheckPathMajor(version, pathMajor); err != nil {
+			if semver.Build(version) == "" && !strings.HasSuffix(version, "+incompatible") {
+				problems = append(problems, fmt.Sprintf("%s does not match module path %s", tag, modPath))
+			}
+			continue
+		}
+		if semver.Prerelease(version) != "" {
+			continue
+		}
+		if latest == "" || semver.Compare(version, tagVersion(latest)) > 0 {
+			latest = tag
+		}
+	}
+	sort.Strings(problems)
+	return latest, problems
+}
+
+func looksLikeVersion(tag string) bool {
+	tag = strings.TrimPrefix(tag, "v")
+	return leadingDigits(tag) != "" && strings.Contains(tag, ".")
+}
+
+func tagVersion(tag string) string {
+	if idx := strings.LastIndex(tag, "/"); idx >= 0 {
+		return tag[idx+1:]
+	}
+	return tag
+}
+
+func (t *LSPTools) checkCleanTree(ctx context.Context, s *server.MCPServer) releaseCheck {
+	check := releaseCheck{Name: "clean_tree"}
+	result, err := t.runCommand(ctx, s, nil, "git", "status", "--porcelain")
+	if err != nil {
+		check.Status = releaseCheckSkipped
+		check.Summary = "git status unavailable"
+		return check
+	}
+	changes := splitLines(result.Stdout)
+	if len(changes) == 0 {
+		check.Status = releaseCheckPass
+		check.Summary = "working tree is clean"
+		return check
+	}
+	check.Status = releaseCheckFail
+	check.Summary = fmt.Sprintf("%d uncommitted change(s)", len(changes))
+	check.Details = changes
+	return check
+}
+
+func (t *LSPTools) checkModTidy(ctx context.Context, s *server.MCPServer) releaseCheck {
+	check := releaseCheck{Name: "go_mod_tidy"}
+	result, err := t.runCommand(ctx, s, nil, "go", "mod", "tidy", "-diff")
+	switch {
+	case err == nil:
+		check.Status = releaseCheckPass
+		check.Summary = "go.mod and go.sum are tidy"
+	case result.ExitCode == 1 && strings.TrimSpace(result.Stdout) != "":
+		check.Status = releaseCheckFail
+		check.Summary = "go mod tidy would modify go.mod or go.sum"
+		check.Details = []string{limitOutputLines(strings.TrimSpace(result.Stdout), 40)}
+	default:
+		check.Status = releaseCheckFail
+		check.Summary = buildCommandErrorMessage("go mod tidy -diff", result, err)
+	}
+	return check
+}
+
+func (t *LSPTools) checkVulnerabilities(ctx context.Context, s *server.MCPServer) releaseCheck {
+	check := releaseCheck{Name: "vulnerabilities"}
+	cmd, args, _ := determineGovulncheckCommand()
+	result, err := t.runCommand(ctx, s, nil, cmd, args...)
+	switch {
+	case err == nil:
+		check.Status = releaseCheckPass
+		check.Summary = "no known vulnerabilities reachable"
+	case result.ExitCode == 3:
+		check.Status = releaseCheckFail
+		check.Summary = "govulncheck reported vulnerabilities"
+		check.Details = govulncheckFindings(result.Stdout)
+	default:
+		check.Status = releaseCheckSkipped
+		check.Summary = buildCommandErrorMessage("govulncheck", result, err)
+	}
+	return check
+}
+
+// govulncheckFindings extracts the "Vulnerability #N: ID" headings from
+// govulncheck text output.
+func govulncheckFindings(output string) []string {
+	var findings []string
+	for _, line := range splitLines(output) {
+		if strings.HasPrefix(line, "Vulnerability #") {
+			findings = append(findings, line)
+		}
+	}
+	return findings
+}
+
+func (t *LSPTools) checkAPICompatibility(ctx context.Context, s *server.MCPServer, modPath, tag string) (releaseCheck, *apiChanges) {
+	check := releaseCheck{Name: "api_compatibility"}
+	skip := func(summary string) (releaseCheck, *apiChanges) {
+		check.Status = releaseCheckSkipped
+		check.Summary = summary
+		return check, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "mcp-gopls-release-")
+	if err != nil {
+		return skip(fmt.Sprintf("unable to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(tmp)
+
+	topResult, err := t.runCommand(ctx, s, nil, "git", "rev-parse", "--show-prefix")
+	if err != nil {
+		return skip("workspace is not a git repository")
+	}
+	worktree := filepath.Join(tmp, "worktree")
+	if result, err := t.runCommand(ctx, s, nil, "git", "worktree", "add", "--detach", worktree, tag); err != nil {
+		return skip(buildCommandErrorMessage("git worktree add", result, err))
+	}
+	defer t.runCommand(context.WithoutCancel(ctx), s, nil, "git", "worktree", "remove", "--force", worktree)
+
+	oldDir := filepath.Join(worktree, filepath.FromSlash(strings.TrimSpace(topResult.Stdout)))
+	exportFile := filepath.Join(tmp, "old.api")
+	cmd, baseArgs := determineApidiffCommand()
+
+	writeArgs := append(append([]string{}, baseArgs...), "-m", "-w", exportFile, modPath)
+	if result, err := t.runCommandSpec(ctx, s, nil, commandSpec{name: cmd, args: writeArgs, dir: oldDir}); err != nil {
+		return skip(buildCommandErrorMessage(fmt.Sprintf("apidiff on %s", tag), result, err))
+	}
+	diffArgs := append(append([]string{}, baseArgs...), "-m", exportFile, modPath)
+	result, err := t.runCommand(ctx, s, nil, cmd, diffArgs...)
+	if err != nil {
+		return skip(buildCommandErrorMessage("apidiff", result, err))
+	}
+
+	changes := parseApidiffOutput(result.Stdout)
+	switch {
+	case len(changes.Incompatible) > 0:
+		check.Status = releaseCheckWarn
+		check.Summary = fmt.Sprintf("%d incompatible and %d compatible change(s) since %s", len(changes.Incompatible), len(changes.Compatible), tag)
+	case len(changes.Compatible) > 0:
+		check.Status = releaseCheckPass
+		check.Summary = fmt.Sprintf("%d compatible change(s) since %s", len(changes.Compatible), tag)
+	default:
+		check.Status = releaseCheckPass
+		check.Summary = fmt.Sprintf("no API changes since %s", tag)
+	}
+	return check, changes
+}
+
+func determineApidiffCommand() (string, []string) {
+	if path, err := lookupApidiffBinary("apidiff"); err == nil {
+		return path, nil
+	}
+	return "go", []string{"run", "golang.org/x/exp/cmd/apidiff@latest"}
+}
+
+// parseApidiffOutput reads the text report written by apidiff, which groups
+// "- " prefixed entries (optionally under package headers) into
+// "Incompatible changes:" and "Compatible changes:" sections.
+func parseApidiffOutput(output string) *apiChanges {
+	changes := &apiChanges{Incompatible: []string{}, Compatible: []string{}}
+	var (
+		section *[]string
+		pkg     string
+	)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "Incompatible changes:":
+			section = &changes.Incompatible
+		case trimmed == "Compatible changes:":
+			section = &changes.Compatible
+		case strings.HasPrefix(trimmed, "- "):
+			if section == nil {
+				continue
+			}
+			entry := strings.TrimPrefix(trimmed, "- ")
+			if pkg != "" {
+				entry = pkg + ": " + entry
+			}
+			*section = append(*section, entry)
+		case !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t"):
+			pkg = trimmed
+			section = nil
+		}
+	}
+	return changes
+}
+
+// suggestReleaseVersion derives the next version from the latest release and
+// the API report. Breaking changes in v0 only bump the minor version; when
+// the API could not be compared the minor version is bumped conservatively.
+func suggestReleaseVersion(latest, modPath string, changes *apiChanges) (string, string) {
+	bump := "patch"
+	switch {
+	case changes == nil && latest != "":
+		bump = "unknown"
+	case changes != nil && len(changes.Incompatible) > 0:
+		bump = "major"
+	case changes != nil && len(changes.Compatible) > 0:
+		bump = "minor"
+	}
+
+	if latest == "" {
+		_, pathMajor, _ := module.SplitPathVersion(modPath)
+		if major := strings.TrimPrefix(pathMajor, "/"); major != "" {
+			return "initial", major + ".0.0"
+		}
+		return "initial", "v0.1.0"
+	}
+
+	var major, minor, patch int
+	if _, err := fmt.Sscanf(semver.Canonical(latest), "v%d.%d.%d", &major, &minor, &patch); err != nil {
+		return bump, ""
+	}
+	switch bump {
+	case "major":
+		if major == 0 {
+			bump = "minor"
+			minor, patch = minor+1, 0
+		} else {
+			major, minor, patch = major+1, 0, 0
+		}
+	case "minor", "unknown":
+		minor, patch = minor+1, 0
+	default:
+		patch++
+	}
+	return bump, fmt.Sprintf("v%d.%d.%d", major, minor, patch)
+}
+
+func releaseDecision(checks []releaseCheck) string {
+	for _, check := range checks {
+		if check.Status == releaseCheckFail {
+			return "no-go"
+		}
+	}
+	return "go"
+}
+
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluateReleaseTags(t *testing.T) {
+	tags := []string{
+		"v1.9.0",
+		"v2.0.0",
+		"v2.1.0",
+		"v2.2.0-rc.1",
+		"v2.1",
+		"2.0.3",
+		"tools/v0.3.0",
+		"nightly",
+	}
+
+	latest, problems := evaluateReleaseTags(tags, "", "example.com/mod/v2")
+	if latest != "v2.1.0" {
+		t.Fatalf("expected latest v2.1.0, got %q", latest)
+	}
+	expected := []string{
+		"2.0.3 is not a valid semantic version tag (expected vMAJOR.MINOR.PATCH)",
+		"v1.9.0 does not match module path example.com/mod/v2",
+		"v2.1 is not canonical; use v2.1.0",
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Fatalf("unexpected problems %#v", problems)
+	}
+
+	latest, problems = evaluateReleaseTags(tags, "tools/", "example.com/mod/tools")
+	if latest != "tools/v0.3.0" || len(problems) != 0 {
+		t.Fatalf("unexpected nested module result %q %#v", latest, problems)
+	}
+}
+
+func TestParseApidiffOutput(t *testing.T) {
+	output := `example.com/mod/pkg/a
+Incompatible changes:
+- Foo: removed
+- Bar: changed from func() to func(int)
+Compatible changes:
+- Baz: added
+
+example.com/mod/pkg/b
+Compatible changes:
+- Qux: added
+`
+	changes := parseApidiffOutput(output)
+	expectedIncompatible := []string{
+		"example.com/mod/pkg/a: Foo: removed",
+		"example.com/mod/pkg/a: Bar: changed from func() to func(int)",
+	}
+	expectedCompatible := []string{
+		"example.com/mod/pkg/a: Baz: added",
+		"example.com/mod/pkg/b: Qux: added",
+	}
+	if !reflect.DeepEqual(changes.Incompatible, expectedIncompatible) {
+		t.Fatalf("unexpected incompatible changes %#v", changes.Incompatible)
+	}
+	if !reflect.DeepEqual(changes.Compatible, expectedCompatible) {
+		t.Fatalf("unexpected compatible changes %#v", changes.Compatible)
+	}
+}
+
+func TestSuggestReleaseVersion(t *testing.T) {
+	breaking := &apiChanges{Incompatible: []string{"Foo: removed"}}
+	additive := &apiChanges{Compatible: []string{"Bar: added"}}
+	none := &apiChanges{}
+
+	tests := []struct {
+		latest  string
+		modPath string
+		changes *apiChanges
+		bump    string
+		version string
+	}{
+		{"v1.4.2", "example.com/mod", breaking, "major", "v2.0.0"},
+		{"v0.4.2", "example.com/mod", breaking, "minor", "v0.5.0"},
+		{"v1.4.2", "example.com/mod", additive, "minor", "v1.5.0"},
+		{"v1.4.2", "example.com/mod", none, "patch", "v1.4.3"},
+		{"v1.4.2", "example.com/mod", nil, "unknown", "v1.5.0"},
+		{"", "example.com/mod/v3", nil, "initial", "v3.0.0"},
+		{"", "example.com/mod", nil, "initial", "v0.1.0"},
+	}
+	for _, tt := range tests {
+		bump, version := suggestReleaseVersion(tt.latest, tt.modPath, tt.changes)
+		if bump != tt.bump || version != tt.version {
+			t.Fatalf("suggestReleaseVersion(%q, %q) = %s %s, want %s %s", tt.latest, tt.modPath, bump, version, tt.bump, tt.version)
+		}
+	}
+}
@@ -0,0 +1,564 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/cover"
+)
+
+// reviewFile is a changed file as prepare_review reports it: its hunks
+// with the declarations they touch, and for Go files the diagnostics and
+// coverage of the changed lines.
+type reviewFile struct {
+	diffFile
+	Diagnostics []fileDiagnostic `json:"diagnostics,omitempty"`
+	Coverage    *lineCoverage    `json:"coverage,omitempty"`
+}
+
+// lineCoverage counts the changed lines holding statements by whether the
+// tests ran them.
+type lineCoverage struct {
+	Covered   int   `json:"covered"`
+	Uncovered int   `json:"uncovered"`
+	Lines     []int `json:"uncovered_lines,omitempty"`
+}
+
+// listedPackage is a workspace package as go list -json reports it.
+type listedPackage struct {
+	ImportPath   string
+	Dir          string
+	Name         string
+	GoFiles      []string
+	Deps         []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// apiDelta is the change of the exported API of a package: declarations
+// added, removed, and changed with their declaration before and after.
+type apiDelta struct {
+	Package string         `json:"package"`
+	Added   []apiDecl      `json:"added"`
+	Removed []apiDecl      `json:"removed"`
+	Changed []apiDeclDelta `json:"changed"`
+}
+
+type apiDecl struct {
+	Symbol string `json:"symbol"`
+	Decl   string `json:"decl"`
+}
+
+type apiDeclDelta struct {
+	Symbol string `json:"symbol"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func (t *LSPTools) registerPrepareReview(s *server.MCPServer) {
+	tool := mcp.NewTool("prepare_review",
+		mcp.WithDescription("Assemble everything needed to review the changes since a git ref in one call: the changed files and hunks with the declarations each hunk touches, the diagnostics on the changed lines, the tests of the changed packages and of the packages depending on them with their results, the coverage of the changed lines by those tests, and the changes of the exported API"),
+		mcp.WithTitleAnnotation("Prepare Review"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("base",
+			mcp.Description("Git ref the working tree is compared against, staged and unstaged changes and untracked files included (default HEAD)"),
+		),
+		mcp.WithBoolean("run_tests",
+			mcp.Description("Run the affected tests with coverage (default true)"),
+		),
+		mcp.WithBoolean("api",
+			mcp.Description("Compare the exported API of the changed packages with base (default true)"),
+		),
+		mcp.WithString("timeout",
+			mcp.Description(fmt.Sprintf("How long to wait for gopls to settle before reading the diagnostics, as a Go duration (default %s)", defaultDiagnosticsTimeout)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultDiagnosticsTimeout)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		base := getOptionalStringArg(args, "base")
+		if base == "" {
+			base = "HEAD"
+		}
+		runTests, compareAPI := true, true
+		if _, ok := args["run_tests"]; ok {
+			runTests = getOptionalBoolArg(args, "run_tests")
+		}
+		if _, ok := args["api"]; ok {
+			compareAPI = getOptionalBoolArg(args, "api")
+		}
+		token := getProgressToken(request.Params.Meta)
+
+		sendProgressNotification(ctx, s, token, "Reading the changes since "+base)
+		changes, err := t.workingTreeDiff(ctx, s, base)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		files := make([]reviewFile, len(changes))
+		var warnings []string
+		for i, change := range changes {
+			files[i] = reviewFile{diffFile: change}
+			if strings.HasSuffix(change.Path, ".go") && !change.Binary {
+				t.annotateHunkSymbols(ctx, s, base, &files[i].diffFile)
+			}
+		}
+		payload := map[string]any{"base": base, "files": files}
+		if len(files) == 0 {
+			payload["message"] = "no changes since " + base
+			return mcp.NewToolResultJSON(payload)
+		}
+
+		introduced := 0
+		if lspClient := t.getClient(); lspClient == nil {
+			warnings = append(warnings, "diagnostics skipped: LSP client not initialized")
+		} else {
+			published, quiescent, err := t.settledDiagnostics(ctx, s, token, lspClient, timeout)
+			if err != nil {
+				return nil, err
+			}
+			if filtered, _, err := t.suppressBaselined(ctx, s, published); err != nil {
+				warnings = append(warnings, err.Error())
+			} else {
+				published = filtered
+			}
+			if !quiescent {
+				warnings = append(warnings, "gopls did not settle in time; diagnostics may be incomplete")
+			}
+			byPath := make(map[string][]fileDiagnostic)
+			for _, d := range t.baselineDiagnostics(published) {
+				byPath[d.Path] = append(byPath[d.Path], d.fileDiagnostic)
+			}
+			for i := range files {
+				added := files[i].addedLines()
+				for _, d := range byPath[files[i].Path] {
+					if added[d.Line] {
+						files[i].Diagnostics = append(files[i].Diagnostics, d)
+					}
+				}
+				introduced += len(files[i].Diagnostics)
+			}
+		}
+		payload["diagnostics"] = introduced
+
+		packages, err := t.listWorkspacePackages(ctx, s)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+		}
+		root, err := filepath.Abs(t.workspaceDir)
+		if err != nil {
+			root = t.workspaceDir
+		}
+		changedDirs := make(map[string]bool)
+		for _, file := range files {
+			if strings.HasSuffix(file.Path, ".go") {
+				changedDirs[path.Dir(file.Path)] = true
+			}
+		}
+		changed := make(map[string]listedPackage)
+		for _, pkg := range packages {
+			if changedDirs[relativeSlashPath(root, pkg.Dir)] {
+				changed[pkg.ImportPath] = pkg
+			}
+		}
+
+		if runTests && len(changed) > 0 {
+			affected := affectedTestPackages(packages, changed)
+			tests := map[string]any{"packages": affected}
+			if len(affected) > 0 {
+				summary, profiles, err := t.runReviewTests(ctx, s, token, affected, slices.Sorted(maps.Keys(changed)))
+				if err != nil {
+					warnings = append(warnings, err.Error())
+				} else {
+					tests["summary"] = summary
+					payload["coverage"] = applyLineCoverage(files, profiles, changed, root)
+				}
+			}
+			payload["tests"] = tests
+		}
+
+		if compareAPI && len(changedDirs) > 0 {
+			sendProgressNotification(ctx, s, token, "Comparing the exported API with "+base)
+			deltas := []apiDelta{}
+			for _, dir := range slices.Sorted(maps.Keys(changedDirs)) {
+				delta, err := t.compareAPI(ctx, s, base, dir, packages, root)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("API of %s: %v", dir, err))
+					continue
+				}
+				if len(delta.Added)+len(delta.Removed)+len(delta.Changed) > 0 {
+					deltas = append(deltas, delta)
+				}
+			}
+			payload["api"] = deltas
+		}
+
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// annotateHunkSymbols sets the declarations each hunk of a Go file
+// touches: those its new lines fall in, then those its deleted lines fell
+// in at base, such as a function the hunk replaced.
+func (t *LSPTools) annotateHunkSymbols(ctx context.Context, s *server.MCPServer, base string, file *diffFile) {
+	fset := token.NewFileSet()
+	var current, previous *ast.File
+	if file.Status != "deleted" {
+		current, _ = parser.ParseFile(fset, filepath.Join(t.workspaceDir, filepath.FromSlash(file.Path)), nil, parser.ParseComments|parser.SkipObjectResolution)
+	}
+	if file.Status != "added" && slices.ContainsFunc(file.Hunks, func(hunk diffHunk) bool { return hunk.OldLines > 0 }) {
+		oldPath := cmp.Or(file.OldPath, file.Path)
+		if shown, err := t.runCommand(ctx, s, nil, "git", "show", base+":./"+oldPath); err == nil {
+			previous, _ = parser.ParseFile(fset, oldPath, shown.Stdout, parser.ParseComments|parser.SkipObjectResolution)
+		}
+	}
+	for i := range file.Hunks {
+		hunk := &file.Hunks[i]
+		if current != nil && hunk.NewLines > 0 {
+			hunk.Symbols = declarationsBetween(fset, current, hunk.NewStart, hunk.NewStart+hunk.NewLines-1)
+		}
+		if previous != nil && hunk.OldLines > 0 {
+			for _, symbol := range declarationsBetween(fset, previous, hunk.OldStart, hunk.OldStart+hunk.OldLines-1) {
+				if !slices.Contains(hunk.Symbols, symbol) {
+					hunk.Symbols = append(hunk.Symbols, symbol)
+				}
+			}
+		}
+	}
+}
+
+// declarationsBetween names the top-level declarations of file overlapping
+// the lines from first to last: func F, func (*T).M, type T, var v, const
+// c or import.
+func declarationsBetween(fset *token.FileSet, file *ast.File, first, last int) []string {
+	var names []string
+	// Declarations start at their doc comment.
+	overlaps := func(node ast.Node, doc *ast.CommentGroup) bool {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return fset.Position(start).Line <= last && fset.Position(node.End()).Line >= first
+	}
+	for _, decl := range file.Decls {
+		var doc *ast.CommentGroup
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			doc = decl.Doc
+		case *ast.GenDecl:
+			doc = decl.Doc
+		}
+		if !overlaps(decl, doc) {
+			continue
+		}
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			names = append(names, funcSymbol(decl))
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				names = append(names, "import")
+				continue
+			}
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if len(decl.Specs) == 1 || overlaps(spec, spec.Doc) {
+						names = append(names, "type "+spec.Name.Name)
+					}
+				case *ast.ValueSpec:
+					if len(decl.Specs) > 1 && !overlaps(spec, spec.Doc) {
+						continue
+					}
+					for _, name := range spec.Names {
+						names = append(names, decl.Tok.String()+" "+name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// funcSymbol names a function declaration: func F, func (T).M or
+// func (*T).M.
+func funcSymbol(decl *ast.FuncDecl) string {
+	name := decl.Name.Name
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		recv := decl.Recv.List[0].Type
+		name = fmt.Sprintf("(%s).%s", receiverTypeName(recv), name)
+		if _, ok := recv.(*ast.StarExpr); ok {
+			name = "(*" + name[1:]
+		}
+	}
+	return "func " + name
+}
+
+// listWorkspacePackages lists the packages of the workspace with their
+// files and dependencies, passing go list the build flags.
+func (t *LSPTools) listWorkspacePackages(ctx context.Context, s *server.MCPServer, flags ...string) ([]listedPackage, error) {
+	args := append([]string{"list", "-e", "-json=ImportPath,Dir,Name,GoFiles,Deps,TestGoFiles,XTestGoFiles,TestImports,XTestImports"}, flags...)
+	result, err := t.runCommand(ctx, s, nil, "go", append(args, "./...")...)
+	if err != nil {
+		return nil, fmt.Errorf("%s", buildCommandErrorMessage("go list", result, err))
+	}
+	var packages []listedPackage
+	decoder := json.NewDecoder(strings.NewReader(result.Stdout))
+	for {
+		var pkg listedPackage
+		if err := decoder.Decode(&pkg); errors.Is(err, io.EOF) {
+			return packages, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("read go list output: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+}
+
+// affectedTestPackages returns the packages with tests that are changed or
+// that import a changed package, directly or not.
+func affectedTestPackages(packages []listedPackage, changed map[string]listedPackage) []string {
+	var affected []string
+	for _, pkg := range packages {
+		if len(pkg.TestGoFiles)+len(pkg.XTestGoFiles) == 0 {
+			continue
+		}
+		_, hit := changed[pkg.ImportPath]
+		for _, list := range [][]string{pkg.Deps, pkg.TestImports, pkg.XTestImports} {
+			hit = hit || slices.ContainsFunc(list, func(dep string) bool { _, ok := changed[dep]; return ok })
+		}
+		if hit {
+			affected = append(affected, pkg.ImportPath)
+		}
+	}
+	slices.Sort(affected)
+	return affected
+}
+
+// runReviewTests runs the affected tests with the coverage of the changed
+// packages.
+func (t *LSPTools) runReviewTests(ctx context.Context, s *server.MCPServer, token mcp.ProgressToken, affected, changed []string) (testRunSummary, []*cover.Profile, error) {
+	profile, err := os.CreateTemp("", "mcp-gopls-review-*.out")
+	if err != nil {
+		return testRunSummary{}, nil, err
+	}
+	_ = profile.Close()
+	defer os.Remove(profile.Name())
+
+	sendProgressNotification(ctx, s, token, fmt.Sprintf("Running the tests of %d affected packages", len(affected)))
+	testArgs := append([]string{"test", "-json", "-count=1", "-coverprofile=" + profile.Name(), "-coverpkg=" + strings.Join(changed, ",")}, affected...)
+	result, err := t.runCommand(ctx, s, token, "go", testArgs...)
+	summary := parseTestEvents(result.Stdout)
+	if err != nil && len(summary.Packages) == 0 {
+		return summary, nil, fmt.Errorf("%s", buildCommandErrorMessage("go test", result, err))
+	}
+	profiles, err := cover.ParseProfiles(profile.Name())
+	if err != nil {
+		// Failing builds leave no profile; the test results still stand.
+		return summary, nil, nil
+	}
+	return summary, profiles, nil
+}
+
+// applyLineCoverage sets the coverage of the changed lines of the non-test
+// Go files of the changed packages, and returns the totals.
+func applyLineCoverage(files []reviewFile, profiles []*cover.Profile, changed map[string]listedPackage, root string) lineCoverage {
+	blocks := make(map[string][]cover.ProfileBlock)
+	for _, profile := range profiles {
+		importPath, name := path.Split(profile.FileName)
+		if pkg, ok := changed[strings.TrimSuffix(importPath, "/")]; ok {
+			rel := relativeSlashPath(root, filepath.Join(pkg.Dir, name))
+			blocks[rel] = append(blocks[rel], profile.Blocks...)
+		}
+	}
+	var total lineCoverage
+	for i := range files {
+		file := &files[i]
+		fileBlocks, ok := blocks[file.Path]
+		if !ok {
+			continue
+		}
+		coverage := &lineCoverage{}
+		for _, line := range slices.Sorted(maps.Keys(file.addedLines())) {
+			statement, covered := false, false
+			for _, block := range fileBlocks {
+				if block.NumStmt > 0 && block.StartLine <= line && line <= block.EndLine {
+					statement = true
+					covered = covered || block.Count > 0
+				}
+			}
+			switch {
+			case covered:
+				coverage.Covered++
+			case statement:
+				coverage.Uncovered++
+				coverage.Lines = append(coverage.Lines, line)
+			}
+		}
+		file.Coverage = coverage
+		total.Covered += coverage.Covered
+		total.Uncovered += coverage.Uncovered
+	}
+	return total
+}
+
+// compareAPI compares the exported API of the package in dir with the one
+// at base.
+func (t *LSPTools) compareAPI(ctx context.Context, s *server.MCPServer, base, dir string, packages []listedPackage, root string) (apiDelta, error) {
+	delta := apiDelta{Package: dir, Added: []apiDecl{}, Removed: []apiDecl{}, Changed: []apiDeclDelta{}}
+	fset := token.NewFileSet()
+	importPath := dir
+
+	var current []*ast.File
+	for _, pkg := range packages {
+		if relativeSlashPath(root, pkg.Dir) != dir {
+			continue
+		}
+		importPath, delta.Package = pkg.ImportPath, pkg.ImportPath
+		if pkg.Name == "main" {
+			return delta, nil
+		}
+		for _, name := range pkg.GoFiles {
+			file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.ParseComments|parser.SkipObjectResolution)
+			if err != nil {
+				return delta, err
+			}
+			current = append(current, file)
+		}
+	}
+
+	listed, err := t.runCommand(ctx, s, nil, "git", "ls-tree", "--name-only", base, "--", "./"+dir+"/")
+	if err != nil {
+		return delta, fmt.Errorf("%s", buildCommandErrorMessage("git ls-tree", listed, err))
+	}
+	sources := make(map[string][]byte)
+	for _, name := range splitLines(listed.Stdout) {
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			shown, err := t.runCommand(ctx, s, nil, "git", "show", base+":./"+name)
+			if err != nil {
+				return delta, fmt.Errorf("%s", buildCommandErrorMessage("git show", shown, err))
+			}
+			sources[path.Base(name)] = []byte(shown.Stdout)
+		}
+	}
+	// The files of base are matched against the build constraints as go
+	// list matched the current ones.
+	ctxt := build.Default
+	ctxt.OpenFile = func(name string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(sources[filepath.Base(name)])), nil
+	}
+	var previous []*ast.File
+	for _, name := range slices.Sorted(maps.Keys(sources)) {
+		if ok, err := ctxt.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path.Join(dir, name), sources[name], parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return delta, err
+		}
+		if file.Name.Name == "main" {
+			return delta, nil
+		}
+		previous = append(previous, file)
+	}
+
+	before, err := exportedDecls(fset, previous, importPath)
+	if err != nil {
+		return delta, err
+	}
+	after, err := exportedDecls(fset, current, importPath)
+	if err != nil {
+		return delta, err
+	}
+	for _, symbol := range slices.Sorted(maps.Keys(after)) {
+		old, ok := before[symbol]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, apiDecl{Symbol: symbol, Decl: after[symbol]})
+		case old != after[symbol]:
+			delta.Changed = append(delta.Changed, apiDeclDelta{Symbol: symbol, Before: old, After: after[symbol]})
+		}
+	}
+	for _, symbol := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[symbol]; !ok {
+			delta.Removed = append(delta.Removed, apiDecl{Symbol: symbol, Decl: before[symbol]})
+		}
+	}
+	return delta, nil
+}
+
+// exportedDecls returns the exported declarations of a package by symbol,
+// such as const C, func F, type T or func (*T).M, printed without their doc
+// comments.
+func exportedDecls(fset *token.FileSet, files []*ast.File, importPath string) (map[string]string, error) {
+	decls := make(map[string]string)
+	if len(files) == 0 {
+		return decls, nil
+	}
+	pkg, err := doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, err
+	}
+	values := func(list []*doc.Value) {
+		for _, value := range list {
+			for _, spec := range value.Decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				single := printDecl(fset, &ast.GenDecl{Tok: value.Decl.Tok, Specs: []ast.Spec{spec}})
+				for _, name := range spec.Names {
+					if name.IsExported() {
+						decls[value.Decl.Tok.String()+" "+name.Name] = single
+					}
+				}
+			}
+		}
+	}
+	funcs := func(list []*doc.Func) {
+		for _, fn := range list {
+			name := fn.Name
+			if fn.Recv != "" {
+				name = fmt.Sprintf("(%s).%s", fn.Recv, fn.Name)
+			}
+			decls["func "+name] = printDecl(fset, fn.Decl)
+		}
+	}
+	values(pkg.Consts)
+	values(pkg.Vars)
+	funcs(pkg.Funcs)
+	for _, typ := range pkg.Types {
+		decls["type "+typ.Name] = printDecl(fset, typ.Decl)
+		values(typ.Consts)
+		values(typ.Vars)
+		funcs(typ.Funcs)
+		funcs(typ.Methods)
+	}
+	return decls, nil
+}
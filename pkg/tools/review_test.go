@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	files := parseUnifiedDiff(`diff --git a/store/store.go b/store/store.go
+index 1111111..2222222 100644
+--- a/store/store.go
++++ b/store/store.go
+@@ -3,0 +4,2 @@ package store
++// Limit caps a store.
++const Limit = 10
+@@ -10 +12 @@ func New() *Store {
+-	return nil
++	return &Store{}
+diff --git a/old.go b/old.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package store
+-
+-func Old() {}
+diff --git a/a.go b/b.go
+similarity index 100%
+rename from a.go
+rename to b.go
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`)
+	want := []diffFile{
+		{Path: "store/store.go", Status: "modified", Hunks: []diffHunk{{OldStart: 3, NewStart: 4, NewLines: 2}, {OldStart: 10, OldLines: 1, NewStart: 12, NewLines: 1}}},
+		{Path: "old.go", Status: "deleted", OldPath: "old.go", Hunks: []diffHunk{{OldStart: 1, OldLines: 3}}},
+		{Path: "b.go", OldPath: "a.go", Status: "renamed", Hunks: []diffHunk{}},
+		{Path: "logo.png", Status: "modified", Binary: true, Hunks: []diffHunk{}},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("parseUnifiedDiff =\n%+v\nwant\n%+v", files, want)
+	}
+}
+
+func TestPrepareReview(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	workspace := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = workspace
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+// Get returns the item at i.
+func Get(items []int, i int) int {
+	return items[i]
+}
+
+// Old is going away.
+func Old() {}
+`)
+	writeWorkspaceFile(t, workspace, "app/app.go", `package app
+
+import "example.com/app/store"
+
+func First(items []int) int { return store.Get(items, 0) }
+`)
+	writeWorkspaceFile(t, workspace, "app/app_test.go", `package app
+
+import "testing"
+
+func TestFirst(t *testing.T) {
+	if First([]int{4}) != 4 {
+		t.Fatal("wrong")
+	}
+}
+`)
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+// Get returns the item at i, or -1.
+func Get(items []int, i int) int {
+	if i >= len(items) {
+		return -1
+	}
+	return items[i]
+}
+
+// Count returns how many items there are.
+func Count(items []int) int { return len(items) }
+`)
+
+	uri := convertPathToURI(workspace + "/store/store.go")
+	fakeClient := &fakeLSPClient{published: map[string][]protocol.Diagnostic{uri: {
+		{Severity: 2, Source: "SA4006", Message: "on a changed line", Range: protocol.Range{Start: protocol.Position{Line: 4}}},
+		{Severity: 2, Message: "on an unchanged line", Range: protocol.Range{Start: protocol.Position{Line: 0}}},
+	}}}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	result, err := server.GetTool("prepare_review").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "prepare_review"},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("prepare_review: %v %v", err, result)
+	}
+	review := structured(result)
+	if review["warnings"] != nil {
+		t.Fatalf("unexpected warnings %v", review["warnings"])
+	}
+
+	files := review["files"].([]any)
+	if len(files) != 1 {
+		t.Fatalf("expected the store change only, got %v", files)
+	}
+	file := files[0].(map[string]any)
+	var symbols []any
+	for _, hunk := range file["hunks"].([]any) {
+		symbols = append(symbols, hunk.(map[string]any)["symbols"].([]any)...)
+	}
+	if !reflect.DeepEqual(symbols, []any{"func Get", "func Get", "func Count", "func Old"}) {
+		t.Fatalf("unexpected hunk symbols %v", symbols)
+	}
+	if diagnostics := file["diagnostics"].([]any); len(diagnostics) != 1 || review["diagnostics"] != float64(1) {
+		t.Fatalf("expected the changed-line diagnostic only, got %v", diagnostics)
+	}
+
+	tests := review["tests"].(map[string]any)
+	if !reflect.DeepEqual(tests["packages"], []any{"example.com/app/app"}) {
+		t.Fatalf("expected the dependent app tests, got %v", tests["packages"])
+	}
+	if summary := tests["summary"].(map[string]any); summary["passed"] != float64(1) {
+		t.Fatalf("unexpected test summary %v", summary)
+	}
+	coverage := file["coverage"].(map[string]any)
+	if coverage["covered"] != float64(1) || !reflect.DeepEqual(coverage["uncovered_lines"], []any{float64(6), float64(7), float64(12)}) {
+		t.Fatalf("unexpected coverage %v", coverage)
+	}
+
+	api := review["api"].([]any)[0].(map[string]any)
+	added, removed := api["added"].([]any), api["removed"].([]any)
+	if api["package"] != "example.com/app/store" || len(added) != 1 || added[0].(map[string]any)["symbol"] != "func Count" ||
+		len(removed) != 1 || removed[0].(map[string]any)["symbol"] != "func Old" || len(api["changed"].([]any)) != 0 {
+		t.Fatalf("unexpected API changes %v", api)
+	}
+}
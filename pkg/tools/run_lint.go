@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var lookupGolangciLintBinary = exec.LookPath
+
+// golangciConfigNames are the configuration files golangci-lint reads from
+// the workspace root.
+var golangciConfigNames = []string{".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"}
+
+// lintIssue is a golangci-lint issue with its file relative to the workspace.
+type lintIssue struct {
+	Linter   string `json:"linter"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+	Source   string `json:"source,omitempty"`
+	Fixable  bool   `json:"fixable,omitempty"`
+}
+
+// golangciReport is the part of golangci-lint's JSON output the tool reads.
+type golangciReport struct {
+	Issues []struct {
+		FromLinter  string   `json:"FromLinter"`
+		Text        string   `json:"Text"`
+		Severity    string   `json:"Severity"`
+		SourceLines []string `json:"SourceLines"`
+		Pos         struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+		Replacement    *json.RawMessage  `json:"Replacement"`
+		SuggestedFixes []json.RawMessage `json:"SuggestedFixes"`
+	} `json:"Issues"`
+	Report struct {
+		Error string `json:"Error"`
+	} `json:"Report"`
+}
+
+func (t *LSPTools) registerRunLint(s *server.MCPServer) {
+	tool := mcp.NewTool("run_lint",
+		mcp.WithDescription("Run golangci-lint, when it is installed, with the workspace's own configuration (.golangci.yml and the like) and return the issues as {linter, file, line, column, severity, message, source, fixable}, with counts per linter. With fix, golangci-lint's autofixes are applied and the diff of each changed file is returned; the changes go through the same checks as the other edits (edit contract, approval) and the remaining issues are reported"),
+		mcp.WithTitleAnnotation("Run Lint"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("target",
+			mcp.Description("Package patterns to lint, separated by spaces (default ./...)"),
+		),
+		mcp.WithArray("linters",
+			mcp.Description("Only run these linters, such as [\"errcheck\", \"revive\"] (default those the configuration enables)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("config",
+			mcp.Description("Configuration file to use instead of the one golangci-lint finds in the workspace"),
+		),
+		mcp.WithBoolean("fix",
+			mcp.Description("Apply the fixes the linters suggest and return the diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		binary, err := lookupGolangciLintBinary("golangci-lint")
+		if err != nil {
+			return mcp.NewToolResultError("golangci-lint is not installed; see https://golangci-lint.run/welcome/install/"), nil
+		}
+		targets := strings.Fields(getOptionalStringArg(args, "target"))
+		if len(targets) == 0 {
+			targets = []string{"./..."}
+		}
+		lintArgs := []string{"run", "--output.json.path=stdout", "--output.text.path=stderr", "--show-stats=false"}
+		if linters := getOptionalStringListArg(args, "linters"); len(linters) > 0 {
+			for _, linter := range linters {
+				if strings.HasPrefix(linter, "-") || strings.ContainsAny(linter, "=, ") {
+					return mcp.NewToolResultError("linters must be linter names such as errcheck"), nil
+				}
+			}
+			lintArgs = append(lintArgs, "--enable-only="+strings.Join(linters, ","))
+		}
+		config := getOptionalStringArg(args, "config")
+		if config != "" {
+			lintArgs = append(lintArgs, "--config="+config)
+		} else {
+			for _, name := range golangciConfigNames {
+				if fileExists(filepath.Join(t.workspaceDir, name)) {
+					config = name
+					break
+				}
+			}
+		}
+		fix := getOptionalBoolArg(args, "fix")
+		var before map[string][]byte
+		if fix {
+			lintArgs = append(lintArgs, "--fix")
+			if before, err = snapshotGoFiles(t.workspaceDir); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("snapshot Go files: %v", err)), nil
+			}
+		}
+		lintArgs = append(lintArgs, targets...)
+
+		token := getProgressToken(request.Params.Meta)
+		sendProgressNotification(ctx, s, token, "Running golangci-lint "+strings.Join(targets, " "))
+		result, runErr := t.runCommandSpec(ctx, s, token, commandSpec{name: binary, args: lintArgs})
+		if runErr != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			var exitErr *exec.ExitError
+			if !errors.As(runErr, &exitErr) {
+				return t.commandFailureResult("golangci-lint", result, runErr)
+			}
+		}
+
+		var fixed []fileChangeSummary
+		if fix {
+			changes, err := t.takeLintFixes(before)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("collect the fixes: %v", err)), nil
+			}
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply the lint fixes", err), nil
+			}
+			fixed = t.summarizeFileChanges(changes)
+		}
+
+		var report golangciReport
+		if err := json.Unmarshal([]byte(result.Stdout), &report); err != nil {
+			if runErr == nil {
+				runErr = err
+			}
+			return t.commandFailureResult("golangci-lint", result, runErr)
+		}
+		issues := t.lintIssues(report)
+		byLinter := make(map[string]int)
+		for _, issue := range issues {
+			byLinter[issue.Linter]++
+		}
+		payload := map[string]any{
+			"ok":        len(issues) == 0 && report.Report.Error == "",
+			"targets":   targets,
+			"issues":    issues,
+			"count":     len(issues),
+			"by_linter": byLinter,
+		}
+		if config != "" {
+			payload["config"] = config
+		}
+		if report.Report.Error != "" {
+			payload["error"] = report.Report.Error
+		}
+		if fix {
+			payload["fixed"] = fixed
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// lintIssues converts the issues of a golangci-lint report, sorted by file
+// and position.
+func (t *LSPTools) lintIssues(report golangciReport) []lintIssue {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	issues := make([]lintIssue, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		file := issue.Pos.Filename
+		if filepath.IsAbs(file) {
+			file = relativeSlashPath(root, file)
+		}
+		converted := lintIssue{
+			Linter:   issue.FromLinter,
+			File:     filepath.ToSlash(file),
+			Line:     issue.Pos.Line,
+			Column:   issue.Pos.Column,
+			Severity: issue.Severity,
+			Message:  issue.Text,
+			Fixable:  issue.Replacement != nil || len(issue.SuggestedFixes) > 0,
+		}
+		if len(issue.SourceLines) > 0 {
+			converted.Source = strings.Join(issue.SourceLines, "\n")
+		}
+		issues = append(issues, converted)
+	}
+	slices.SortStableFunc(issues, func(a, b lintIssue) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return issues
+}
+
+// takeLintFixes compares the Go files golangci-lint --fix left with the
+// snapshot taken before it ran and puts the snapshot back, returning the
+// fixes as changes to write.
+func (t *LSPTools) takeLintFixes(before map[string][]byte) ([]fileChange, error) {
+	after, err := snapshotGoFiles(t.workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+	var changes []fileChange
+	for _, path := range sortedKeys(after) {
+		original, ok := before[path]
+		if !ok || bytes.Equal(original, after[path]) {
+			continue
+		}
+		if err := os.WriteFile(path, original, 0o644); err != nil {
+			return nil, err
+		}
+		changes = append(changes, fileChange{path: path, before: original, after: after[path]})
+	}
+	return changes, nil
+}
+
+// snapshotGoFiles reads the Go files of the workspace, outside hidden and
+// vendor directories.
+func snapshotGoFiles(root string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[path] = data
+		return nil
+	})
+	return files, err
+}
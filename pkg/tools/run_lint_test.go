@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestRunLint(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, ".golangci.yml", "version: \"2\"\n")
+	mainPath := writeWorkspaceFile(t, workspace, "main.go", "package main\n\nfunc main() {\n\tx := 1\n\tx = x\n}\n")
+	origLookup := lookupGolangciLintBinary
+	t.Cleanup(func() { lookupGolangciLintBinary = origLookup })
+	lookupGolangciLintBinary = func(string) (string, error) { return "golangci-lint", nil }
+
+	tools := NewLSPTools(nil, workspace)
+	var ran [][]string
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		ran = append(ran, spec.args)
+		if slices.Contains(spec.args, "--fix") {
+			if err := os.WriteFile(mainPath, []byte("package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n"), 0o644); err != nil {
+				return commandResult{}, err
+			}
+			return commandResult{Stdout: `{"Issues":[],"Report":{}}`}, nil
+		}
+		return commandResult{Stdout: `{"Issues":[
+			{"FromLinter":"staticcheck","Text":"SA4018: self-assignment of x to x","SourceLines":["\tx = x"],"Pos":{"Filename":"main.go","Line":5,"Column":2},"SuggestedFixes":[{"Message":"remove"}]},
+			{"FromLinter":"ineffassign","Text":"ineffectual assignment to x","Pos":{"Filename":"main.go","Line":4,"Column":2}}
+		],"Report":{}}`}, &exec.ExitError{}
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	lint := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool("run_lint").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "run_lint", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("run_lint: %v", err)
+		}
+		return result
+	}
+
+	linted := structured(lint(map[string]any{"linters": []any{"staticcheck", "ineffassign"}}))
+	issues := linted["issues"].([]any)
+	if linted["ok"] != false || linted["count"] != float64(2) || linted["config"] != ".golangci.yml" {
+		t.Fatalf("unexpected lint result %v", linted)
+	}
+	first := issues[0].(map[string]any)
+	if first["linter"] != "ineffassign" || first["line"] != float64(4) || issues[1].(map[string]any)["fixable"] != true {
+		t.Fatalf("expected issues sorted by position, got %v", issues)
+	}
+	if byLinter := linted["by_linter"].(map[string]any); byLinter["staticcheck"] != float64(1) {
+		t.Fatalf("unexpected counts %v", byLinter)
+	}
+	if !slices.Contains(ran[0], "--enable-only=staticcheck,ineffassign") {
+		t.Fatalf("unexpected arguments %v", ran[0])
+	}
+
+	fixed := structured(lint(map[string]any{"fix": true}))
+	files := fixed["fixed"].([]any)
+	if fixed["ok"] != true || len(files) != 1 || !strings.Contains(files[0].(map[string]any)["diff"].(string), "+\t_ = x") {
+		t.Fatalf("unexpected fix result %v", fixed)
+	}
+	if data, _ := os.ReadFile(mainPath); !strings.Contains(string(data), "_ = x") {
+		t.Fatalf("the fix was not written: %q", data)
+	}
+
+	lookupGolangciLintBinary = func(string) (string, error) { return "", errors.New("not found") }
+	if result := lint(map[string]any{}); !result.IsError {
+		t.Fatal("expected an error without golangci-lint")
+	}
+}
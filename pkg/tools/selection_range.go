@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// selectionTextLimit is the size past which a selection's text is cut to
+// its first line.
+const selectionTextLimit = 400
+
+// selection is one step of a selection range chain. Node is the syntax node
+// spanning exactly the range, when there is one, and Kind its category:
+// expression, statement, block, function, declaration or file. Line and
+// EndLine are 1-based.
+type selection struct {
+	Level     int            `json:"level"`
+	Range     protocol.Range `json:"range"`
+	Line      int            `json:"line"`
+	EndLine   int            `json:"end_line"`
+	Kind      string         `json:"kind,omitempty"`
+	Node      string         `json:"node,omitempty"`
+	Text      string         `json:"text"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+func (t *LSPTools) registerSelectionRange(s *server.MCPServer) {
+	tool := mcp.NewTool("selection_range",
+		mcp.WithDescription("Expand a position to its enclosing syntax with gopls textDocument/selectionRange: the identifier, then each enclosing expression, statement, block, function and declaration up to the whole file, innermost first. Each step gives its range, the syntax node it spans and its text, to pick a precise range for extract_function, extract_variable or a code action"),
+		mcp.WithTitleAnnotation("Selection Range"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position to expand from"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		path := convertURIToPath(fileURI)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", path, err)), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		ranges, err := lspClient.SelectionRanges(ctx, fileURI, []protocol.Position{{Line: line, Character: character}})
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if len(ranges) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("no selection range at %d:%d", line, character)), nil
+		}
+
+		selections := describeSelections(&ranges[0], src, syntaxNodes(path, src))
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri":   fileURI,
+			"position":   protocol.Position{Line: line, Character: character},
+			"selections": selections,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// describeSelections flattens a selection range chain, innermost first,
+// labelling each step with the node of nodes spanning it.
+func describeSelections(r *protocol.SelectionRange, src []byte, nodes map[[2]int]ast.Node) []selection {
+	var selections []selection
+	for ; r != nil; r = r.Parent {
+		entry := selection{
+			Level:   len(selections),
+			Range:   r.Range,
+			Line:    r.Range.Start.Line + 1,
+			EndLine: r.Range.End.Line + 1,
+		}
+		start, err := textedit.Offset(src, r.Range.Start.Line, r.Range.Start.Character)
+		if err != nil {
+			selections = append(selections, entry)
+			continue
+		}
+		end, err := textedit.Offset(src, r.Range.End.Line, r.Range.End.Character)
+		if err != nil || end < start {
+			selections = append(selections, entry)
+			continue
+		}
+		if node, ok := nodes[[2]int{start, end}]; ok {
+			entry.Node = strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+			entry.Kind = syntaxKind(node)
+		}
+		entry.Text = string(src[start:end])
+		if len(entry.Text) > selectionTextLimit {
+			first, _, _ := strings.Cut(entry.Text, "\n")
+			entry.Text = first + " ..."
+			entry.Truncated = true
+		}
+		selections = append(selections, entry)
+	}
+	return selections
+}
+
+// syntaxNodes indexes the nodes of a Go file by their byte span, keeping
+// the outermost node when several share one. A file with syntax errors
+// still labels the parts that parsed.
+func syntaxNodes(path string, src []byte) map[[2]int]ast.Node {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+	nodes := make(map[[2]int]ast.Node)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || !n.Pos().IsValid() || !n.End().IsValid() {
+			return true
+		}
+		span := [2]int{fset.Position(n.Pos()).Offset, fset.Position(n.End()).Offset}
+		if _, ok := nodes[span]; !ok {
+			nodes[span] = n
+		}
+		return true
+	})
+	return nodes
+}
+
+func syntaxKind(node ast.Node) string {
+	switch node.(type) {
+	case *ast.File:
+		return "file"
+	case *ast.FuncDecl, *ast.FuncLit:
+		return "function"
+	case *ast.BlockStmt:
+		return "block"
+	case ast.Stmt:
+		return "statement"
+	case ast.Expr, *ast.Field, *ast.FieldList:
+		return "expression"
+	case ast.Decl, ast.Spec:
+		return "declaration"
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// semanticToken is a classified token with its source text.
+type semanticToken struct {
+	Position  protocol.Position `json:"position"`
+	Text      string            `json:"text"`
+	Type      string            `json:"type"`
+	Modifiers []string          `json:"modifiers,omitempty"`
+}
+
+func (t *LSPTools) registerSemanticTokens(s *server.MCPServer) {
+	tool := mcp.NewTool("semantic_tokens",
+		mcp.WithDescription("Classify the tokens of a range of a Go file with gopls semantic tokens: whether each identifier is a type, function, method, variable, parameter, field (property), type parameter or package (namespace), with modifiers such as definition, readonly or defaultLibrary for builtins. Returns each token with its text and the count per type"),
+		mcp.WithTitleAnnotation("Semantic Tokens"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the file"),
+		),
+		mcp.WithObject("range",
+			mcp.Description("Range to classify (default: the whole file)"),
+		),
+		mcp.WithString("types",
+			mcp.Description("Comma-separated token types to keep, such as type,function,method"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		var types []string
+		if list := getOptionalStringArg(args, "types"); list != "" {
+			for typ := range strings.SplitSeq(list, ",") {
+				if typ = strings.TrimSpace(typ); typ != "" {
+					types = append(types, typ)
+				}
+			}
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		src, err := os.ReadFile(convertURIToPath(fileURI))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", convertURIToPath(fileURI), err)), nil
+		}
+		rng := documentRange(src)
+		if _, ok := args["range"]; ok {
+			if rng, err = parseRangeArg(args, "range"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+
+		tokens, err := lspClient.SemanticTokens(ctx, fileURI, rng)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		classified := make([]semanticToken, 0, len(tokens))
+		counts := make(map[string]int)
+		for _, token := range tokens {
+			if len(types) > 0 && !slices.Contains(types, token.Type) {
+				continue
+			}
+			counts[token.Type]++
+			entry := semanticToken{Position: token.Position, Type: token.Type, Modifiers: token.Modifiers}
+			start, err := textedit.Offset(src, token.Position.Line, token.Position.Character)
+			if err == nil {
+				if end, err := textedit.Offset(src, token.Position.Line, token.Position.Character+token.Length); err == nil && end >= start {
+					entry.Text = string(src[start:end])
+				}
+			}
+			classified = append(classified, entry)
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"file_uri": fileURI,
+			"range":    rng,
+			"tokens":   classified,
+			"counts":   counts,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+)
+
+const sessionHistoryURI = "resource://session/history"
+
+// historyFile is a file the session read or edited.
+type historyFile struct {
+	Path string `json:"path"`
+	// Access is "edited" once the session edited the file, "read" before.
+	Access string    `json:"access"`
+	Visits int       `json:"visits"`
+	First  time.Time `json:"first"`
+	Last   time.Time `json:"last"`
+	Tools  []string  `json:"tools,omitempty"`
+}
+
+// historySymbol is the identifier at a position a tool was called on, or a
+// symbol a tool was given by name.
+type historySymbol struct {
+	Name   string    `json:"name"`
+	Path   string    `json:"path,omitempty"`
+	Line   int       `json:"line,omitempty"`
+	Visits int       `json:"visits"`
+	Last   time.Time `json:"last"`
+	Tools  []string  `json:"tools"`
+}
+
+// historyDiagnostics are the diagnostics of a file the last time the
+// session checked them.
+type historyDiagnostics struct {
+	Path        string           `json:"path"`
+	Checked     time.Time        `json:"checked"`
+	Diagnostics []fileDiagnostic `json:"diagnostics"`
+}
+
+// historyTestRun is a run of run_go_test with its outcome; Failed lists
+// the packages that did not pass.
+type historyTestRun struct {
+	Target  string         `json:"target"`
+	Test    string         `json:"test,omitempty"`
+	Ran     time.Time      `json:"ran"`
+	Summary packageSummary `json:"summary"`
+	Failed  []string       `json:"failed,omitempty"`
+	Crashes int            `json:"crashes,omitempty"`
+}
+
+// sessionHistory records what the session looked at, so that a later turn
+// can pick up where it stopped without navigating again.
+type sessionHistory struct {
+	mu          sync.Mutex
+	started     time.Time
+	calls       int
+	tools       map[string]int
+	files       map[string]*historyFile
+	symbols     map[string]*historySymbol
+	diagnostics map[string]*historyDiagnostics
+	tests       []historyTestRun
+	// edits keeps the content files had before the session first wrote
+	// them, for the session report.
+	edits *editJournal
+}
+
+func newSessionHistory() *sessionHistory {
+	return &sessionHistory{
+		started:     time.Now().UTC(),
+		tools:       make(map[string]int),
+		files:       make(map[string]*historyFile),
+		symbols:     make(map[string]*historySymbol),
+		diagnostics: make(map[string]*historyDiagnostics),
+		edits:       newEditJournal(),
+	}
+}
+
+// recordCall counts a tool call.
+func (h *sessionHistory) recordCall(tool string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	h.tools[tool]++
+}
+
+// recordFile notes a visit of the file at the workspace-relative path rel.
+// tool is empty for edits, which are not tied to a call.
+func (h *sessionHistory) recordFile(rel, access, tool string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now().UTC()
+	file, ok := h.files[rel]
+	if !ok {
+		file = &historyFile{Path: rel, Access: access, First: now}
+		h.files[rel] = file
+	}
+	file.Visits++
+	file.Last = now
+	if access == "edited" {
+		file.Access = access
+	}
+	if tool != "" && !slices.Contains(file.Tools, tool) {
+		file.Tools = append(file.Tools, tool)
+	}
+}
+
+// recordSymbol notes a visit of a symbol, declared at rel:line when known.
+func (h *sessionHistory) recordSymbol(name, rel string, line int, tool string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := name + "\x00" + rel
+	symbol, ok := h.symbols[key]
+	if !ok {
+		symbol = &historySymbol{Name: name, Path: rel}
+		h.symbols[key] = symbol
+	}
+	symbol.Line = line
+	symbol.Visits++
+	symbol.Last = time.Now().UTC()
+	if !slices.Contains(symbol.Tools, tool) {
+		symbol.Tools = append(symbol.Tools, tool)
+	}
+}
+
+// recordDiagnostics replaces the diagnostics remembered for the files.
+func (h *sessionHistory) recordDiagnostics(files []fileDiagnostics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now().UTC()
+	for _, file := range files {
+		h.diagnostics[file.Path] = &historyDiagnostics{Path: file.Path, Checked: now, Diagnostics: file.Diagnostics}
+	}
+}
+
+// recordTestRun notes a go test run of target, narrowed to test when set.
+func (h *sessionHistory) recordTestRun(target, test string, packages []packageStatus, crashes int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	run := historyTestRun{Target: target, Test: test, Ran: time.Now().UTC(), Summary: summarizePackages(packages), Crashes: crashes}
+	for _, pkg := range packages {
+		if pkg.Status != packageOK && pkg.Status != packageNoTests {
+			run.Failed = append(run.Failed, pkg.Package)
+		}
+	}
+	h.tests = append(h.tests, run)
+}
+
+// snapshot returns the history, most recent visits first, restricted to
+// paths starting with prefix.
+func (h *sessionHistory) snapshot(prefix string) map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	files := []historyFile{}
+	for _, file := range h.files {
+		if strings.HasPrefix(file.Path, prefix) {
+			files = append(files, *file)
+		}
+	}
+	slices.SortFunc(files, func(a, b historyFile) int {
+		if c := b.Last.Compare(a.Last); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	symbols := []historySymbol{}
+	for _, symbol := range h.symbols {
+		if strings.HasPrefix(symbol.Path, prefix) {
+			symbols = append(symbols, *symbol)
+		}
+	}
+	slices.SortFunc(symbols, func(a, b historySymbol) int {
+		if c := b.Last.Compare(a.Last); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	diagnostics := []historyDiagnostics{}
+	for _, path := range sortedKeys(h.diagnostics) {
+		if entry := h.diagnostics[path]; strings.HasPrefix(path, prefix) && len(entry.Diagnostics) > 0 {
+			diagnostics = append(diagnostics, *entry)
+		}
+	}
+	tools := make(map[string]int, len(h.tools))
+	for tool, count := range h.tools {
+		tools[tool] = count
+	}
+	return map[string]any{
+		"started":     h.started,
+		"calls":       h.calls,
+		"tools":       tools,
+		"files":       files,
+		"symbols":     symbols,
+		"diagnostics": diagnostics,
+	}
+}
+
+// recordHistory adds a successful tool call to the session history: the
+// call itself, the file of its file_uri argument and the identifier at its
+// position argument, and the symbol it was given by name.
+func (t *LSPTools) recordHistory(request *mcp.CallToolRequest, path string, content []byte) {
+	tool := request.Params.Name
+	t.history.recordCall(tool)
+	args := request.GetArguments()
+	if name, _ := args["symbol"].(string); name != "" {
+		t.history.recordSymbol(name, "", 0, tool)
+	}
+	if path == "" {
+		return
+	}
+	rel := relativeSlashPath(t.workspaceDir, path)
+	t.history.recordFile(rel, "read", tool)
+	line, character, err := parsePosition(args)
+	if err != nil {
+		return
+	}
+	if name := identifierAt(content, line, character); name != "" {
+		t.history.recordSymbol(name, rel, line+1, tool)
+	}
+}
+
+// identifierAt returns the Go identifier around the 0-based LSP position,
+// or "" when there is none.
+func identifierAt(content []byte, line, character int) string {
+	offset, err := textedit.Offset(content, line, character)
+	if err != nil {
+		return ""
+	}
+	isIdent := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	start := offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRune(content[:start])
+		if !isIdent(r) {
+			break
+		}
+		start -= size
+	}
+	end := offset
+	for end < len(content) {
+		r, size := utf8.DecodeRune(content[end:])
+		if !isIdent(r) {
+			break
+		}
+		end += size
+	}
+	name := string(content[start:end])
+	if first, _ := utf8.DecodeRuneInString(name); name == "" || unicode.IsDigit(first) {
+		return ""
+	}
+	return name
+}
+
+// registerSessionTools registers the tools reporting on the session itself.
+func (t *LSPTools) registerSessionTools(s *server.MCPServer) {
+	t.registerSessionHistory(s)
+	t.registerBookmarkTools(s)
+	t.registerExportSessionReport(s)
+}
+
+func (t *LSPTools) registerSessionHistory(s *server.MCPServer) {
+	tool := mcp.NewTool("session_history",
+		mcp.WithDescription("Return what this session has looked at so far: the files it read (through tools taking a file_uri) or edited, with how often and by which tools, the symbols at the positions it queried or that it named, the diagnostics last seen for each file, and the number of calls per tool; most recent first. Call it at the start of a follow-up turn to re-orient instead of navigating again; it is also readable as resource://session/history"),
+		mcp.WithTitleAnnotation("Session History"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("path",
+			mcp.Description("Only return files, symbols and diagnostics whose path, relative to the workspace, starts with this prefix"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		result, err := mcp.NewToolResultJSON(t.history.snapshot(getOptionalStringArg(args, "path")))
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+
+	s.AddResource(mcp.Resource{
+		URI:         sessionHistoryURI,
+		Name:        "Session History",
+		Description: "Files, symbols and diagnostics the session touched, most recent first.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		data, err := json.MarshalIndent(t.history.snapshot(""), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: sessionHistoryURI, MIMEType: "application/json", Text: string(data)}}, nil
+	})
+}
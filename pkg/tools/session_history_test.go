@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestIdentifierAt(t *testing.T) {
+	content := []byte("package calc\n\nfunc Double(n int) int { return n * 2 }\n")
+	for _, tc := range []struct {
+		line, character int
+		want            string
+	}{
+		{2, 5, "Double"},
+		{2, 11, "Double"},
+		{2, 8, "Double"},
+		{2, 12, "n"},
+		{2, 4, "func"},
+		{2, 37, ""},
+		{2, 11 + 40, ""},
+	} {
+		if got := identifierAt(content, tc.line, tc.character); got != tc.want {
+			t.Errorf("identifierAt(%d, %d) = %q, want %q", tc.line, tc.character, got, tc.want)
+		}
+	}
+}
+
+func TestSessionHistory(t *testing.T) {
+	workspace := t.TempDir()
+	calcPath := writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nfunc Double(n int) int { return n * 2 }\n")
+	utilPath := writeWorkspaceFile(t, workspace, "util/util.go", "package util\n")
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0", mcpsrv.WithResourceCapabilities(true, true))
+	tools.Register(server)
+	track := func(name string, args map[string]any) {
+		tools.TrackToolCall(&mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name, Arguments: args}}, &mcp.CallToolResult{})
+	}
+
+	position := map[string]any{"line": 2, "character": 7}
+	track("get_hover_info", map[string]any{"file_uri": convertPathToURI(calcPath), "position": position})
+	track("find_references", map[string]any{"file_uri": convertPathToURI(calcPath), "position": position})
+	track("find_symbol", map[string]any{"symbol": "util.Helper"})
+	if err := tools.writeFileChanges(context.Background(), []fileChange{{
+		path: utilPath, before: []byte("package util\n"), after: []byte("package util\n\nfunc Helper() {}\n"),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	tools.history.recordDiagnostics([]fileDiagnostics{
+		{Path: "calc/calc.go", Diagnostics: []fileDiagnostic{{Line: 3, Column: 6, Severity: "warning", Message: "unused"}}},
+		{Path: "util/util.go", Diagnostics: []fileDiagnostic{}},
+	})
+
+	result, err := server.GetTool("session_history").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "session_history", Arguments: map[string]any{}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("session_history: %v %v", err, result)
+	}
+	history := structured(result)
+	if history["calls"] != float64(3) || history["tools"].(map[string]any)["find_references"] != float64(1) {
+		t.Fatalf("unexpected calls %v", history)
+	}
+	files := history["files"].([]any)
+	if len(files) != 2 {
+		t.Fatalf("unexpected files %v", files)
+	}
+	if edited := files[0].(map[string]any); edited["path"] != "util/util.go" || edited["access"] != "edited" {
+		t.Fatalf("expected the edited file first, got %v", edited)
+	}
+	if read := files[1].(map[string]any); read["path"] != "calc/calc.go" || read["visits"] != float64(2) || len(read["tools"].([]any)) != 2 {
+		t.Fatalf("unexpected read file %v", read)
+	}
+	symbols := history["symbols"].([]any)
+	if len(symbols) != 2 || symbols[0].(map[string]any)["name"] != "util.Helper" {
+		t.Fatalf("unexpected symbols %v", symbols)
+	}
+	if double := symbols[1].(map[string]any); double["name"] != "Double" || double["path"] != "calc/calc.go" || double["line"] != float64(3) || double["visits"] != float64(2) {
+		t.Fatalf("unexpected symbol %v", double)
+	}
+	if diagnostics := history["diagnostics"].([]any); len(diagnostics) != 1 || diagnostics[0].(map[string]any)["path"] != "calc/calc.go" {
+		t.Fatalf("expected only the file with diagnostics, got %v", diagnostics)
+	}
+
+	response := server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"resource://session/history"}}`))
+	if data, _ := json.Marshal(response); !strings.Contains(string(data), "util.Helper") {
+		t.Fatalf("unexpected resource %s", data)
+	}
+}
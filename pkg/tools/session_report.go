@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+)
+
+const sessionReportURI = "resource://session/report"
+
+// reportFile is a file the session changed, with the diff from its content
+// before the first write to its content now.
+type reportFile struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Diff   string `json:"diff"`
+}
+
+type reportToolCount struct {
+	Tool  string `json:"tool"`
+	Calls int    `json:"calls"`
+}
+
+// sessionReport summarizes a session: what it called, what it changed,
+// the tests it ran and what is left open.
+type sessionReport struct {
+	Started   time.Time         `json:"started"`
+	Generated time.Time         `json:"generated"`
+	Calls     int               `json:"calls"`
+	Tools     []reportToolCount `json:"tools"`
+	Files     []reportFile      `json:"files"`
+	Tests     []historyTestRun  `json:"tests"`
+	// Diagnostics, Bookmarks and Pending are the open findings: the files
+	// whose last diagnostics check found something, the notes left on the
+	// code and the edits waiting for approval.
+	Diagnostics []historyDiagnostics `json:"diagnostics"`
+	Bookmarks   []bookmark           `json:"bookmarks"`
+	Pending     []*pendingChange     `json:"pending_changes,omitempty"`
+}
+
+// sessionReport gathers the report of the session so far.
+func (t *LSPTools) sessionReport() (sessionReport, error) {
+	snapshot := t.history.snapshot("")
+	report := sessionReport{
+		Started:     snapshot["started"].(time.Time),
+		Generated:   time.Now().UTC(),
+		Calls:       snapshot["calls"].(int),
+		Tools:       []reportToolCount{},
+		Files:       []reportFile{},
+		Diagnostics: snapshot["diagnostics"].([]historyDiagnostics),
+	}
+	for tool, calls := range snapshot["tools"].(map[string]int) {
+		report.Tools = append(report.Tools, reportToolCount{Tool: tool, Calls: calls})
+	}
+	slices.SortFunc(report.Tools, func(a, b reportToolCount) int {
+		return cmp.Or(b.Calls-a.Calls, strings.Compare(a.Tool, b.Tool))
+	})
+
+	changes, err := t.history.edits.changes(false)
+	if err != nil {
+		return sessionReport{}, err
+	}
+	for _, change := range changes {
+		rel := relativeSlashPath(t.workspaceDir, change.path)
+		status := "modified"
+		switch {
+		case change.created:
+			status = "created"
+		case change.deleted:
+			status = "deleted"
+		}
+		report.Files = append(report.Files, reportFile{Path: rel, Status: status, Diff: textedit.Unified(rel, change.before, change.after)})
+	}
+	slices.SortFunc(report.Files, func(a, b reportFile) int { return strings.Compare(a.Path, b.Path) })
+
+	t.history.mu.Lock()
+	report.Tests = slices.Clone(t.history.tests)
+	t.history.mu.Unlock()
+	if report.Tests == nil {
+		report.Tests = []historyTestRun{}
+	}
+	if report.Bookmarks, err = t.listBookmarks("", ""); err != nil {
+		return sessionReport{}, err
+	}
+	if t.approvals != nil {
+		report.Pending = t.approvals.list()
+	}
+	return report, nil
+}
+
+// markdown renders the report for a reader, such as a pull request
+// description.
+func (r sessionReport) markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Session report\n\n")
+	fmt.Fprintf(&sb, "Started %s, generated %s: %d tool calls, %d files changed, %d test runs.\n",
+		r.Started.Format(time.RFC3339), r.Generated.Format(time.RFC3339), r.Calls, len(r.Files), len(r.Tests))
+
+	sb.WriteString("\n## Tool calls\n\n")
+	if len(r.Tools) == 0 {
+		sb.WriteString("None.\n")
+	}
+	for _, count := range r.Tools {
+		fmt.Fprintf(&sb, "- `%s`: %d\n", count.Tool, count.Calls)
+	}
+
+	sb.WriteString("\n## Files changed\n")
+	if len(r.Files) == 0 {
+		sb.WriteString("\nNone.\n")
+	}
+	for _, file := range r.Files {
+		fmt.Fprintf(&sb, "\n### %s (%s)\n\n```diff\n%s", file.Path, file.Status, file.Diff)
+		if !strings.HasSuffix(file.Diff, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```\n")
+	}
+
+	sb.WriteString("\n## Tests\n\n")
+	if len(r.Tests) == 0 {
+		sb.WriteString("None run.\n")
+	}
+	for _, run := range r.Tests {
+		target := "`" + run.Target + "`"
+		if run.Test != "" {
+			target += " `" + run.Test + "`"
+		}
+		fmt.Fprintf(&sb, "- %s: %s, %d passed, %d failed", target, run.Summary.Status, run.Summary.Passed, run.Summary.Failed)
+		if run.Crashes > 0 {
+			fmt.Fprintf(&sb, ", %d crashed", run.Crashes)
+		}
+		sb.WriteString("\n")
+		for _, pkg := range run.Failed {
+			fmt.Fprintf(&sb, "  - failed: `%s`\n", pkg)
+		}
+	}
+
+	sb.WriteString("\n## Open findings\n")
+	if len(r.Diagnostics) == 0 && len(r.Bookmarks) == 0 && len(r.Pending) == 0 {
+		sb.WriteString("\nNone.\n")
+	}
+	if len(r.Diagnostics) > 0 {
+		sb.WriteString("\n### Diagnostics\n\n")
+		for _, file := range r.Diagnostics {
+			for _, diagnostic := range file.Diagnostics {
+				fmt.Fprintf(&sb, "- %s:%d:%d %s: %s\n", file.Path, diagnostic.Line, diagnostic.Column, diagnostic.Severity, diagnostic.Message)
+			}
+		}
+	}
+	if len(r.Bookmarks) > 0 {
+		sb.WriteString("\n### Bookmarks\n\n")
+		for _, mark := range r.Bookmarks {
+			fmt.Fprintf(&sb, "- %s:%d: %s", mark.Path, mark.Line, mark.Note)
+			if len(mark.Tags) > 0 {
+				sb.WriteString(" (" + strings.Join(mark.Tags, ", ") + ")")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if len(r.Pending) > 0 {
+		sb.WriteString("\n### Pending changes\n\n")
+		for _, change := range r.Pending {
+			paths := make([]string, 0, len(change.Files))
+			for _, file := range change.Files {
+				paths = append(paths, file.Path)
+			}
+			fmt.Fprintf(&sb, "- %s: %s\n", change.ID, strings.Join(paths, ", "))
+		}
+	}
+	return sb.String()
+}
+
+func (t *LSPTools) registerExportSessionReport(s *server.MCPServer) {
+	tool := mcp.NewTool("export_session_report",
+		mcp.WithDescription("Summarize the session as a markdown (default) or JSON report: the tool calls made, the files changed with the diff from their content before the session first wrote them, the tests run through run_go_test with their results, and the open findings (diagnostics from the last check of each file, bookmarks, edits waiting for approval). Call it at the end of a run to hand over what was done; output also writes the report to a file in the workspace, through the same checks as the other edits (edit contract, approval). It is also readable as resource://session/report"),
+		mcp.WithTitleAnnotation("Export Session Report"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("format",
+			mcp.Description("markdown (default) or json"),
+		),
+		mcp.WithString("output",
+			mcp.Description("File inside the workspace to write the report to, relative to it unless absolute"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		format := getOptionalStringArg(args, "format")
+		if format == "" {
+			format = "markdown"
+		}
+		if format != "markdown" && format != "json" {
+			return mcp.NewToolResultError("format must be markdown or json"), nil
+		}
+		report, err := t.sessionReport()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		markdown := report.markdown()
+		output := getOptionalStringArg(args, "output")
+		if output != "" {
+			if !filepath.IsAbs(output) {
+				output = filepath.Join(t.workspaceDir, output)
+			}
+			output = filepath.Clean(output)
+			if !isWithinDir(t.workspaceDir, output) {
+				return mcp.NewToolResultError(fmt.Sprintf("output %s is outside the workspace", output)), nil
+			}
+			content := []byte(markdown)
+			if format == "json" {
+				if content, err = json.MarshalIndent(report, "", "  "); err != nil {
+					return nil, err
+				}
+				content = append(content, '\n')
+			}
+			change, err := contentFileChange(output, string(content))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if change != nil {
+				if err := t.writeFileChanges(ctx, []fileChange{*change}); err != nil {
+					return writeFailureResult("write the report", err), nil
+				}
+			}
+		}
+
+		if format == "markdown" {
+			result := mcp.NewToolResultText(markdown)
+			if output != "" {
+				result.Content = append(result.Content, mcp.NewTextContent("Written to "+output))
+			}
+			return result, nil
+		}
+		payload := map[string]any{"report": report}
+		if output != "" {
+			payload["output"] = output
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+
+	s.AddResource(mcp.Resource{
+		URI:         sessionReportURI,
+		Name:        "Session Report",
+		Description: "Markdown summary of the session: tool calls, files changed with diffs, tests run and open findings.",
+		MIMEType:    "text/markdown",
+	}, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		report, err := t.sessionReport()
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: sessionReportURI, MIMEType: "text/markdown", Text: report.markdown()}}, nil
+	})
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestExportSessionReport(t *testing.T) {
+	workspace := t.TempDir()
+	utilPath := writeWorkspaceFile(t, workspace, "util/util.go", "package util\n")
+	tools := NewLSPTools(nil, workspace)
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		return commandResult{Stdout: "ok  \texample.com/app/calc\t0.01s\nFAIL\texample.com/app/util\t0.02s\n"}, nil
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0", mcpsrv.WithResourceCapabilities(true, true))
+	tools.Register(server)
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("%s: %v %v", name, err, result)
+		}
+		tools.TrackToolCall(&mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name, Arguments: args}}, result)
+		return result
+	}
+
+	for _, content := range []string{"package util\n\nfunc Helper() {}\n", "package util\n\nfunc Helper() int { return 1 }\n"} {
+		before, _ := os.ReadFile(utilPath)
+		if err := tools.writeFileChanges(context.Background(), []fileChange{{path: utilPath, before: before, after: []byte(content)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	call("run_go_test", map[string]any{"path": "./...", "test": "TestHelper"})
+	tools.history.recordDiagnostics([]fileDiagnostics{
+		{Path: "util/util.go", Diagnostics: []fileDiagnostic{{Line: 3, Column: 6, Severity: "warning", Message: "exported function Helper should have comment"}}},
+	})
+
+	result := call("export_session_report", map[string]any{"output": "report.md"})
+	markdown := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"## Tool calls\n\n- `run_go_test`: 1\n",
+		"### util/util.go (modified)\n\n```diff\n",
+		"+func Helper() int { return 1 }\n",
+		"- `./...` `TestHelper`: partial, 1 passed, 1 failed\n  - failed: `example.com/app/util`\n",
+		"- util/util.go:3:6 warning: exported function Helper should have comment\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected %q in report:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(markdown, "+func Helper() {}") {
+		t.Fatalf("expected the diff from the content before the session, got:\n%s", markdown)
+	}
+	if written, err := os.ReadFile(filepath.Join(workspace, "report.md")); err != nil || string(written) != markdown {
+		t.Fatalf("unexpected written report %q: %v", written, err)
+	}
+
+	report := structured(call("export_session_report", map[string]any{"format": "json"}))["report"].(map[string]any)
+	files := report["files"].([]any)
+	if len(files) != 2 || files[0].(map[string]any)["path"] != "report.md" || files[1].(map[string]any)["path"] != "util/util.go" {
+		t.Fatalf("expected the edited file and the written report, got %v", files)
+	}
+	if report["calls"] != float64(2) || len(report["tests"].([]any)) != 1 {
+		t.Fatalf("unexpected report %v", report)
+	}
+
+	for _, output := range []string{"../escape.md", filepath.Join(t.TempDir(), "report.md")} {
+		result, err := server.GetTool("export_session_report").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "export_session_report", Arguments: map[string]any{"output": output}},
+		})
+		if err != nil || !result.IsError {
+			t.Fatalf("expected %s outside the workspace to be refused, got %v %v", output, result, err)
+		}
+	}
+
+	response := server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"resource://session/report"}}`))
+	if data, _ := json.Marshal(response); !strings.Contains(string(data), "# Session report") {
+		t.Fatalf("unexpected resource %s", data)
+	}
+}
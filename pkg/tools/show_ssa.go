@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/go/ssa"
+)
+
+// ssaPasses maps the optional builder passes show_ssa accepts to the modes
+// that enable them. lift is on unless left out of passes, as it is the form
+// the compiler and analyzers reason about.
+var ssaPasses = map[string]ssa.BuilderMode{
+	"lift":        0,
+	"instantiate": ssa.InstantiateGenerics,
+	"debug":       ssa.GlobalDebug,
+}
+
+var defaultSSAPasses = []string{"lift", "instantiate"}
+
+// ssaBlock is a basic block of a function in the json format. Idom is the
+// immediate dominator, -1 for the entry and unreachable blocks.
+type ssaBlock struct {
+	Index        int              `json:"index"`
+	Comment      string           `json:"comment,omitempty"`
+	Preds        []int            `json:"preds"`
+	Succs        []int            `json:"succs"`
+	Idom         int              `json:"idom"`
+	Instructions []ssaInstruction `json:"instructions"`
+}
+
+type ssaInstruction struct {
+	Text string `json:"text"`
+	Line int    `json:"line,omitempty"`
+}
+
+// ssaFunction is one function of the dump: the selected one, then its
+// closures.
+type ssaFunction struct {
+	Name      string     `json:"name"`
+	Signature string     `json:"signature"`
+	Path      string     `json:"path,omitempty"`
+	Line      int        `json:"line,omitempty"`
+	Params    []string   `json:"params"`
+	FreeVars  []string   `json:"free_vars,omitempty"`
+	Locals    []string   `json:"locals,omitempty"`
+	Blocks    []ssaBlock `json:"blocks,omitempty"`
+	Text      string     `json:"text,omitempty"`
+}
+
+func (t *LSPTools) registerShowSSA(s *server.MCPServer) {
+	tool := mcp.NewTool("show_ssa",
+		mcp.WithDescription("Build and return the SSA form of a function: its basic blocks, the instructions of each with their source lines, and the control flow between blocks, to reason about the code the way the compiler and analyzers see it. Closures defined in the function follow it. passes selects the optional builder passes: lift promotes local variables to registers and inserts phi nodes, instantiate builds generic instantiations, debug records which source expressions each value comes from"),
+		mcp.WithTitleAnnotation("Show SSA"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("function",
+			mcp.Required(),
+			mcp.Description("Function or method (Name, pkg.Name, import/path.Name or Type.Method)"),
+		),
+		mcp.WithString("package",
+			mcp.Description("Package pattern to search, such as ./pkg/server (default ./...)"),
+		),
+		mcp.WithString("passes",
+			mcp.Description(fmt.Sprintf("Comma-separated builder passes among lift, instantiate and debug (default %s); leave out lift to see the naive form with explicit allocations, loads and stores", strings.Join(defaultSSAPasses, ","))),
+		),
+		mcp.WithString("format",
+			mcp.Description("text (default, the ssa package's listing) or json (blocks, edges and dominators)"),
+		),
+		mcp.WithBoolean("include_closures",
+			mcp.Description("Also dump the anonymous functions defined in the function (default true)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		name, err := getStringArg(args, "function")
+		if err != nil {
+			return nil, err
+		}
+		pattern := getOptionalStringArg(args, "package")
+		if pattern == "" {
+			pattern = "./..."
+		}
+		format := getOptionalStringArg(args, "format")
+		if format == "" {
+			format = "text"
+		}
+		if format != "text" && format != "json" {
+			return mcp.NewToolResultError("format must be text or json"), nil
+		}
+		passes := defaultSSAPasses
+		if value := getOptionalStringArg(args, "passes"); value != "" {
+			passes = nil
+			for pass := range strings.SplitSeq(value, ",") {
+				pass = strings.TrimSpace(pass)
+				if _, ok := ssaPasses[pass]; !ok {
+					return mcp.NewToolResultError(fmt.Sprintf("unknown pass %q; use lift, instantiate or debug", pass)), nil
+				}
+				if !slices.Contains(passes, pass) {
+					passes = append(passes, pass)
+				}
+			}
+		}
+		includeClosures := true
+		if _, ok := args["include_closures"]; ok {
+			includeClosures = getOptionalBoolArg(args, "include_closures")
+		}
+
+		if token := getProgressToken(request.Params.Meta); token != nil {
+			sendProgressNotification(ctx, s, token, "Building SSA for "+pattern)
+		}
+		prog, initial, _, err := t.loadSSAProgramMode(ctx, pattern, false, ssaBuilderMode(passes))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		fn, problem := findSSAFunction(initial, name)
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		if fn.Blocks == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s has no body (it is declared in assembly or linked in)", name)), nil
+		}
+
+		functions := []ssaFunction{t.describeSSAFunction(prog, fn, format)}
+		if includeClosures {
+			for _, anon := range anonFuncs(fn) {
+				functions = append(functions, t.describeSSAFunction(prog, anon, format))
+			}
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"function":  fn.Pkg.Pkg.Path() + "." + ssaFunctionName(fn),
+			"passes":    passes,
+			"format":    format,
+			"functions": functions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// ssaBuilderMode turns pass names into a builder mode; without lift the
+// builder keeps the naive form.
+func ssaBuilderMode(passes []string) ssa.BuilderMode {
+	mode := ssa.NaiveForm
+	for _, pass := range passes {
+		if pass == "lift" {
+			mode &^= ssa.NaiveForm
+		}
+		mode |= ssaPasses[pass]
+	}
+	return mode
+}
+
+// anonFuncs lists the closures of fn, nested ones included, in the order
+// they are defined.
+func anonFuncs(fn *ssa.Function) []*ssa.Function {
+	var funcs []*ssa.Function
+	for _, anon := range fn.AnonFuncs {
+		funcs = append(funcs, anon)
+		funcs = append(funcs, anonFuncs(anon)...)
+	}
+	return funcs
+}
+
+func (t *LSPTools) describeSSAFunction(prog *ssa.Program, fn *ssa.Function, format string) ssaFunction {
+	described := ssaFunction{
+		Name:      fn.Pkg.Pkg.Name() + "." + ssaFunctionName(fn),
+		Signature: fn.Signature.String(),
+		Params:    valueNames(fn.Params),
+		FreeVars:  valueNames(fn.FreeVars),
+	}
+	if pos := prog.Fset.Position(fn.Pos()); pos.IsValid() {
+		described.Path, described.Line = relativeSlashPath(t.workspaceDir, pos.Filename), pos.Line
+	}
+	if format == "text" {
+		var buf bytes.Buffer
+		ssa.WriteFunction(&buf, fn)
+		described.Text = buf.String()
+		return described
+	}
+
+	for _, local := range fn.Locals {
+		described.Locals = append(described.Locals, local.Name()+" = "+local.String())
+	}
+	for _, block := range fn.Blocks {
+		entry := ssaBlock{Index: block.Index, Comment: block.Comment, Preds: blockIndexes(block.Preds), Succs: blockIndexes(block.Succs), Idom: -1}
+		if idom := block.Idom(); idom != nil {
+			entry.Idom = idom.Index
+		}
+		for _, instr := range block.Instrs {
+			text := instr.String()
+			if value, ok := instr.(ssa.Value); ok && value.Name() != "" {
+				text = value.Name() + " = " + text
+			}
+			entry.Instructions = append(entry.Instructions, ssaInstruction{Text: text, Line: prog.Fset.Position(instr.Pos()).Line})
+		}
+		described.Blocks = append(described.Blocks, entry)
+	}
+	return described
+}
+
+func valueNames[V ssa.Value](values []V) []string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, value.Name()+" "+value.Type().String())
+	}
+	return names
+}
+
+func blockIndexes(blocks []*ssa.BasicBlock) []int {
+	indexes := make([]int, 0, len(blocks))
+	for _, block := range blocks {
+		indexes = append(indexes, block.Index)
+	}
+	return indexes
+}
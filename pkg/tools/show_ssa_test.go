@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestShowSSA(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "calc/calc.go", `package calc
+
+func Sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	apply := func() int { return total * 2 }
+	return apply()
+}
+`)
+
+	tools := NewLSPTools(nil, workspace)
+	describe := func(passes []string, format string) []ssaFunction {
+		t.Helper()
+		prog, initial, _, err := tools.loadSSAProgramMode(context.Background(), "./...", false, ssaBuilderMode(passes))
+		if err != nil {
+			t.Fatalf("loadSSAProgramMode: %v", err)
+		}
+		fn, problem := findSSAFunction(initial, "calc.Sum")
+		if problem != "" {
+			t.Fatalf("findSSAFunction: %s", problem)
+		}
+		functions := []ssaFunction{tools.describeSSAFunction(prog, fn, format)}
+		for _, anon := range anonFuncs(fn) {
+			functions = append(functions, tools.describeSSAFunction(prog, anon, format))
+		}
+		return functions
+	}
+
+	lifted := describe(defaultSSAPasses, "text")
+	if len(lifted) != 2 || lifted[1].Name != "calc.Sum$1" || len(lifted[1].FreeVars) != 1 {
+		t.Fatalf("expected Sum and its closure, got %+v", lifted)
+	}
+	if lifted[0].Path != "calc/calc.go" || lifted[0].Line != 3 || !strings.Contains(lifted[0].Text, "make closure Sum$1") {
+		t.Fatalf("unexpected listing %+v", lifted[0])
+	}
+	// total is captured by the closure, so it stays in memory; the range
+	// index becomes a phi.
+	if !strings.Contains(lifted[0].Text, "phi") || !strings.Contains(lifted[0].Text, "new int (total)") {
+		t.Fatalf("expected lifted form with a phi and a heap total:\n%s", lifted[0].Text)
+	}
+
+	naive := describe(nil, "json")[0]
+	if naive.Text != "" || len(naive.Blocks) == 0 || len(naive.Locals) == 0 {
+		t.Fatalf("expected json blocks with locals in naive form, got %+v", naive)
+	}
+	entry := naive.Blocks[0]
+	if entry.Idom != -1 || len(entry.Succs) != 1 || entry.Instructions[0].Line == 0 {
+		t.Fatalf("unexpected entry block %+v", entry)
+	}
+	for _, block := range naive.Blocks {
+		for _, instr := range block.Instructions {
+			if strings.Contains(instr.Text, "phi") {
+				t.Fatalf("naive form should have no phi nodes: %s", instr.Text)
+			}
+		}
+	}
+}
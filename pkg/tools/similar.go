@@ -0,0 +1,408 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+const (
+	defaultSimilarResults = 5
+	maxSimilarResults     = 20
+	// maxSimilarLines caps the source returned for each function.
+	maxSimilarLines = 40
+)
+
+// Weights of where a word appears in a function: its name says the most
+// about what it does, then its doc comment and the types it handles, then
+// the identifiers its body uses.
+const (
+	similarNameWeight      = 3
+	similarDocWeight       = 2
+	similarTypeWeight      = 2
+	similarParamNameWeight = 1
+	similarBodyWeight      = 1
+)
+
+var (
+	wordPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*`)
+	// similarStopWords are the words too common in descriptions and code to
+	// tell functions apart.
+	similarStopWords = map[string]bool{
+		"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+		"for": true, "from": true, "func": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+		"its": true, "of": true, "on": true, "or": true, "return": true, "returns": true, "that": true,
+		"the": true, "this": true, "to": true, "with": true,
+	}
+)
+
+// similarFunction is an existing function close to the draft. Shared lists
+// the words contributing most to the score.
+type similarFunction struct {
+	Name      string   `json:"name"`
+	Package   string   `json:"package"`
+	Path      string   `json:"path"`
+	Line      int      `json:"line"`
+	Signature string   `json:"signature"`
+	Doc       string   `json:"doc,omitempty"`
+	Score     float64  `json:"score"`
+	Shared    []string `json:"shared"`
+	Source    string   `json:"source"`
+}
+
+// similarDraft is what the agent intends to write: a parsed signature, a
+// description, or both.
+type similarDraft struct {
+	decl  *ast.FuncDecl
+	words map[string]float64
+}
+
+type similarCandidate struct {
+	pkg   *gosrc.Package
+	file  *gosrc.File
+	fn    *ast.FuncDecl
+	words map[string]float64
+}
+
+func (t *LSPTools) registerFindSimilar(s *server.MCPServer) {
+	tool := mcp.NewTool("find_similar",
+		mcp.WithDescription("Given a draft function signature and/or a description of what a new function should do, return the closest existing functions in the workspace with their source, ranked by word similarity over names, doc comments, types and bodies plus signature shape and gopls symbol search, so a new implementation can follow the repo's conventions"),
+		mcp.WithTitleAnnotation("Find Similar Code"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("signature",
+			mcp.Description("Draft signature, such as func LoadConfig(path string) (*Config, error) or func (s *Store) Delete(ctx context.Context, key string) error"),
+		),
+		mcp.WithString("description",
+			mcp.Description("What the function should do, in a few words"),
+		),
+		mcp.WithBoolean("include_tests",
+			mcp.Description("Also consider functions in _test.go files (default false)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of functions (default %d, at most %d)", defaultSimilarResults, maxSimilarResults)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		signature := getOptionalStringArg(args, "signature")
+		description := getOptionalStringArg(args, "description")
+		if signature == "" && description == "" {
+			return mcp.NewToolResultError("pass signature, description or both"), nil
+		}
+		limit := defaultSimilarResults
+		if _, ok := args["limit"]; ok {
+			var err error
+			if limit, err = getIntFromObject(args, "limit"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 || limit > maxSimilarResults {
+				return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxSimilarResults)), nil
+			}
+		}
+		draft, err := parseSimilarDraft(signature, description)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: getOptionalBoolArg(args, "include_tests")})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Functions gopls' fuzzy symbol search matches to the draft name
+		// get a bonus; the search is skipped without a name or a client.
+		var notes []string
+		matched := make(map[string]bool)
+		if lspClient := t.getClient(); lspClient != nil && draft.decl != nil {
+			symbols, err := lspClient.WorkspaceSymbols(ctx, draft.decl.Name.Name)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("symbol search failed: %v", err))
+			}
+			for _, symbol := range symbols {
+				if symbol.Kind == symbolKindMethod || symbol.Kind == symbolKindFunction {
+					matched[similarSymbolKey(convertURIToPath(symbol.Location.URI), symbol.Location.Range.Start.Line)] = true
+				}
+			}
+		}
+
+		functions := rankSimilarFunctions(ws, draft, matched, limit)
+		payload := map[string]any{"functions": functions}
+		if len(notes) > 0 {
+			payload["notes"] = notes
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// parseSimilarDraft reads the draft signature, with or without the func
+// keyword, and the description into one weighted word bag.
+func parseSimilarDraft(signature, description string) (similarDraft, error) {
+	draft := similarDraft{words: make(map[string]float64)}
+	if signature = strings.TrimSpace(signature); signature != "" {
+		signature = strings.TrimSuffix(strings.TrimSpace(strings.TrimSuffix(signature, "{")), "{}")
+		if !strings.HasPrefix(signature, "func") {
+			signature = "func " + signature
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), "draft.go", "package draft\n\n"+signature+" {}\n", parser.SkipObjectResolution)
+		if err != nil || len(file.Decls) != 1 {
+			return draft, fmt.Errorf("cannot parse signature %q: write it as func Name(params) results", signature)
+		}
+		fn, ok := file.Decls[0].(*ast.FuncDecl)
+		if !ok {
+			return draft, fmt.Errorf("signature %q is not a function", signature)
+		}
+		draft.decl = fn
+		addSignatureWords(draft.words, fn)
+	}
+	for _, word := range textWords(description) {
+		draft.words[word] += similarDocWeight
+	}
+	if len(draft.words) == 0 {
+		return draft, fmt.Errorf("the draft has no words to compare")
+	}
+	return draft, nil
+}
+
+// rankSimilarFunctions scores every function with a body by the TF-IDF
+// cosine similarity of its words to the draft's, plus bonuses for a
+// matching signature shape and for gopls symbol matches.
+func rankSimilarFunctions(ws *gosrc.Workspace, draft similarDraft, matched map[string]bool, limit int) []similarFunction {
+	var candidates []similarCandidate
+	documents := make(map[string]int)
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				words := functionWords(fn)
+				for word := range words {
+					documents[word]++
+				}
+				candidates = append(candidates, similarCandidate{pkg: pkg, file: file, fn: fn, words: words})
+			}
+		}
+	}
+	idf := func(word string) float64 {
+		return math.Log(1 + float64(len(candidates)+1)/float64(documents[word]+1))
+	}
+	query := make(map[string]float64, len(draft.words))
+	for word, weight := range draft.words {
+		query[word] = weight * idf(word)
+	}
+
+	functions := make([]similarFunction, 0, len(candidates))
+	for _, candidate := range candidates {
+		vector := make(map[string]float64, len(candidate.words))
+		for word, weight := range candidate.words {
+			vector[word] = weight * idf(word)
+		}
+		cosine, shared := cosineSimilarity(query, vector)
+		score := cosine + signatureShapeBonus(draft.decl, candidate.fn)
+		if matched[similarSymbolKey(candidate.file.Path, candidate.file.Line(candidate.fn.Name.Pos())-1)] {
+			score += 0.15
+		}
+		if cosine == 0 {
+			continue
+		}
+		function := similarFunction{
+			Name:      funcDeclName(candidate.fn),
+			Package:   candidate.pkg.ImportPath,
+			Path:      candidate.file.RelPath,
+			Line:      candidate.file.Line(candidate.fn.Pos()),
+			Signature: candidate.file.Text(candidate.fn.Pos(), candidate.fn.Type.End()),
+			Score:     math.Round(score*1000) / 1000,
+			Shared:    shared,
+			Source:    truncateLines(candidate.file.Text(candidate.fn.Pos(), candidate.fn.End()), maxSimilarLines),
+		}
+		if candidate.fn.Doc != nil {
+			function.Doc = firstSentence(candidate.fn.Doc)
+		}
+		functions = append(functions, function)
+	}
+	slices.SortStableFunc(functions, func(a, b similarFunction) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Path, b.Path), strings.Compare(a.Name, b.Name))
+	})
+	return functions[:min(limit, len(functions))]
+}
+
+// functionWords weighs the words of a function's name, doc comment,
+// signature and body.
+func functionWords(fn *ast.FuncDecl) map[string]float64 {
+	words := make(map[string]float64)
+	addSignatureWords(words, fn)
+	if fn.Doc != nil {
+		for _, word := range textWords(fn.Doc.Text()) {
+			words[word] += similarDocWeight
+		}
+	}
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			for _, word := range identifierWords(ident.Name) {
+				words[word] += similarBodyWeight
+			}
+		}
+		return true
+	})
+	return words
+}
+
+// addSignatureWords adds the words of a function's name, receiver,
+// parameter and result types, and parameter names.
+func addSignatureWords(words map[string]float64, fn *ast.FuncDecl) {
+	for _, word := range identifierWords(fn.Name.Name) {
+		words[word] += similarNameWeight
+	}
+	for _, fields := range []*ast.FieldList{fn.Recv, fn.Type.Params, fn.Type.Results} {
+		if fields == nil {
+			continue
+		}
+		for _, field := range fields.List {
+			for _, word := range textWords(exprText(field.Type)) {
+				words[word] += similarTypeWeight
+			}
+			for _, name := range field.Names {
+				for _, word := range identifierWords(name.Name) {
+					words[word] += similarParamNameWeight
+				}
+			}
+		}
+	}
+}
+
+// signatureShapeBonus rewards a function whose parameter and result counts,
+// error result and receiver match the draft's.
+func signatureShapeBonus(draft, fn *ast.FuncDecl) float64 {
+	if draft == nil {
+		return 0
+	}
+	bonus := 0.0
+	if fieldCount(draft.Type.Params) == fieldCount(fn.Type.Params) {
+		bonus += 0.05
+	}
+	if fieldCount(draft.Type.Results) == fieldCount(fn.Type.Results) {
+		bonus += 0.05
+	}
+	if funcReturnsError(draft) == funcReturnsError(fn) {
+		bonus += 0.05
+	}
+	if (draft.Recv == nil) == (fn.Recv == nil) {
+		bonus += 0.05
+	}
+	return bonus
+}
+
+// fieldCount counts the parameters or results of a field list, naming or
+// not.
+func fieldCount(fields *ast.FieldList) int {
+	if fields == nil {
+		return 0
+	}
+	return fields.NumFields()
+}
+
+// cosineSimilarity returns the cosine of two word vectors and up to five of
+// the words contributing most to it.
+func cosineSimilarity(a, b map[string]float64) (float64, []string) {
+	var dot, normA, normB float64
+	type contribution struct {
+		word  string
+		value float64
+	}
+	var contributions []contribution
+	for word, weight := range a {
+		normA += weight * weight
+		if other, ok := b[word]; ok {
+			dot += weight * other
+			contributions = append(contributions, contribution{word, weight * other})
+		}
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if dot == 0 {
+		return 0, nil
+	}
+	slices.SortFunc(contributions, func(x, y contribution) int {
+		return cmp.Or(cmp.Compare(y.value, x.value), strings.Compare(x.word, y.word))
+	})
+	shared := make([]string, 0, 5)
+	for _, c := range contributions[:min(5, len(contributions))] {
+		shared = append(shared, c.word)
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), shared
+}
+
+// textWords splits free text, or source text such as a type, into
+// lowercase words, dropping stop words.
+func textWords(text string) []string {
+	var words []string
+	for _, token := range wordPattern.FindAllString(text, -1) {
+		words = append(words, identifierWords(token)...)
+	}
+	return words
+}
+
+// identifierWords splits an identifier at case changes, underscores and
+// digits: parseHTTPConfig2 gives parse, http and config.
+func identifierWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			word := strings.ToLower(string(runes[start:end]))
+			if len(word) > 1 && !similarStopWords[word] {
+				words = append(words, word)
+			}
+		}
+		start = end
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_' || unicode.IsDigit(r):
+			flush(i)
+			start = i + 1
+		case unicode.IsUpper(r) && i > start:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush(i)
+			}
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+// truncateLines keeps the first n lines of text, marking the cut.
+func truncateLines(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= n {
+		return text
+	}
+	return strings.Join(append(lines[:n], "\t// ..."), "\n")
+}
+
+func similarSymbolKey(path string, line int) string {
+	return fmt.Sprintf("%s:%d", path, line)
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestIdentifierWords(t *testing.T) {
+	cases := map[string][]string{
+		"parseHTTPConfig2": {"parse", "http", "config"},
+		"load_from_disk":   {"load", "disk"},
+		"ID":               {"id"},
+		"x":                nil,
+		"NewJSONEncoder":   {"new", "json", "encoder"},
+	}
+	for name, want := range cases {
+		if got := identifierWords(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("identifierWords(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRankSimilarFunctions(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "config/config.go", `package config
+
+import "os"
+
+type Config struct{ Name string }
+
+// LoadConfig reads the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Name: string(data)}, nil
+}
+`)
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+type Store struct{ items map[string]string }
+
+// Delete removes a key from the store.
+func (s *Store) Delete(key string) error {
+	delete(s.items, key)
+	return nil
+}
+
+func (s *Store) Len() int { return len(s.items) }
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	draft, err := parseSimilarDraft("ReadSettings(file string) (*Settings, error)", "load settings from a configuration file")
+	if err != nil {
+		t.Fatalf("parseSimilarDraft: %v", err)
+	}
+	functions := rankSimilarFunctions(ws, draft, nil, 5)
+	if len(functions) == 0 || functions[0].Name != "LoadConfig" || functions[0].Package != "example.com/app/config" {
+		t.Fatalf("expected LoadConfig first, got %+v", functions)
+	}
+	if !slices.Contains(functions[0].Shared, "file") || functions[0].Signature != "func LoadConfig(path string) (*Config, error)" || functions[0].Doc == "" {
+		t.Fatalf("unexpected match %+v", functions[0])
+	}
+
+	draft, err = parseSimilarDraft("func (c *Cache) Remove(key string) error", "")
+	if err != nil {
+		t.Fatalf("parseSimilarDraft: %v", err)
+	}
+	functions = rankSimilarFunctions(ws, draft, nil, 1)
+	if len(functions) != 1 || functions[0].Name != "Store.Delete" {
+		t.Fatalf("expected Store.Delete, got %+v", functions)
+	}
+
+	if _, err := parseSimilarDraft("func (", ""); err == nil {
+		t.Fatal("expected an unparsable signature to be rejected")
+	}
+}
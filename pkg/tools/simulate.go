@@ -0,0 +1,338 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// simulatedFinding is a go vet finding of the simulated state, marked when
+// the files on disk have it too.
+type simulatedFinding struct {
+	buildError
+	PreExisting bool `json:"pre_existing,omitempty"`
+}
+
+var simulationChecks = []string{"build", "vet", "tests"}
+
+func (t *LSPTools) registerSimulateChange(s *server.MCPServer) {
+	tool := mcp.NewTool("simulate_change",
+		mcp.WithDescription("Check a proposed change before writing anything: the new content or text edits of files are laid over the workspace with the go command's -overlay, never written to disk, then the changed packages and the packages depending on them are built and vetted, and their tests run, except while edits wait for approval or under an edit contract narrower than ./..., since the tests run the proposed code. Reports the compile errors, the go vet findings with those the change introduces, the test results and the diff, with ok true when the change builds, introduces no finding and fails no test"),
+		mcp.WithTitleAnnotation("Simulate Change"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithArray("changes",
+			mcp.Required(),
+			mcp.Description("Files to change: {\"file_uri\": \"...\", \"content\": \"...\"} for new content, which creates missing files, {\"file_uri\": \"...\", \"edits\": [{\"range\": {...}, \"newText\": \"...\"}]} for text edits, or {\"file_uri\": \"...\", \"delete\": true}"),
+			mcp.Items(map[string]any{"type": "object"}),
+		),
+		mcp.WithArray("checks",
+			mcp.Description("Checks to run among build, vet and tests (default all). vet and tests are skipped when the build fails"),
+			mcp.WithStringItems(),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		token := getProgressToken(request.Params.Meta)
+		changes, err := proposedChanges(args["changes"])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		checks := make(map[string]bool)
+		if raw, ok := args["checks"].([]any); ok {
+			for _, item := range raw {
+				check, _ := item.(string)
+				if !slices.Contains(simulationChecks, check) {
+					return mcp.NewToolResultError(fmt.Sprintf("checks must be among %s", strings.Join(simulationChecks, ", "))), nil
+				}
+				checks[check] = true
+			}
+		} else {
+			for _, check := range simulationChecks {
+				checks[check] = true
+			}
+		}
+
+		overlay, cleanup, err := writeChangeOverlay(changes)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		overlayFlag := "-overlay=" + overlay
+
+		payload := map[string]any{"files": t.summarizeFileChanges(changes)}
+		var warnings []string
+		packages, err := t.listWorkspacePackages(ctx, s, overlayFlag)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		paths := make([]string, 0, len(changes))
+		for _, change := range changes {
+			paths = append(paths, change.path)
+		}
+		changed, targets := t.affectedPackages(packages, paths)
+		if len(changed) == 0 {
+			payload["ok"] = true
+			payload["message"] = "the change touches no Go package of the workspace; nothing to check"
+			return mcp.NewToolResultJSON(payload)
+		}
+		payload["packages"] = targets
+
+		ok := true
+		if checks["build"] {
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Building %d packages", len(targets)))
+			result, err := t.runCommand(ctx, s, token, "go", append([]string{"build", overlayFlag}, targets...)...)
+			build := map[string]any{"ok": err == nil}
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				errs := parseBuildErrors(result.Stdout + "\n" + result.Stderr)
+				if len(errs) == 0 {
+					errs = []buildError{{Message: buildCommandErrorMessage("go build", result, err)}}
+				}
+				build["errors"] = errs
+				ok = false
+			}
+			payload["build"] = build
+		}
+
+		if checks["vet"] && ok {
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Vetting %d packages", len(targets)))
+			findings, err := t.simulatedVetFindings(ctx, s, token, overlayFlag, targets)
+			if err != nil {
+				warnings = append(warnings, err.Error())
+			} else {
+				introduced := 0
+				for _, finding := range findings {
+					if !finding.PreExisting {
+						introduced++
+					}
+				}
+				payload["vet"] = map[string]any{"findings": findings, "introduced": introduced}
+				ok = ok && introduced == 0
+			}
+		}
+
+		if checks["tests"] && ok {
+			affected := affectedTestPackages(packages, changed)
+			tests := map[string]any{"packages": affected}
+			refusal := t.simulatedTestsRefusal()
+			if refusal != "" && len(affected) > 0 {
+				tests["refused"] = refusal
+				warnings = append(warnings, refusal)
+			} else if len(affected) > 0 {
+				sendProgressNotification(ctx, s, token, fmt.Sprintf("Running the tests of %d affected packages", len(affected)))
+				result, err := t.runCommand(ctx, s, token, "go", append([]string{"test", "-json", "-count=1", overlayFlag}, affected...)...)
+				summary := parseTestEvents(result.Stdout)
+				if err != nil && len(summary.Packages) == 0 {
+					warnings = append(warnings, buildCommandErrorMessage("go test", result, err))
+				} else {
+					tests["summary"] = summary
+				}
+				ok = ok && err == nil
+			}
+			payload["tests"] = tests
+		}
+
+		payload["ok"] = ok
+		if len(warnings) > 0 {
+			payload["warnings"] = warnings
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// simulatedTestsRefusal tells why the tests of a simulated change cannot
+// run, or returns "". The tests run the proposed code, which can write to
+// the workspace itself, past the approval queue and the edit contract.
+func (t *LSPTools) simulatedTestsRefusal() string {
+	if t.approvals != nil {
+		return "the tests run the proposed code, which could change files bypassing the approval queue; they do not run while edits wait for approval"
+	}
+	if err := t.checkCommandContract("go test"); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// affectedPackages returns the packages with a Go file among paths, by
+// import path, and those packages with every package depending on them,
+// sorted.
+func (t *LSPTools) affectedPackages(packages []listedPackage, paths []string) (map[string]listedPackage, []string) {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	changedDirs := make(map[string]bool)
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".go") {
+			changedDirs[relativeSlashPath(root, filepath.Dir(path))] = true
+		}
+	}
+	changed := make(map[string]listedPackage)
+	for _, pkg := range packages {
+		if changedDirs[relativeSlashPath(root, pkg.Dir)] {
+			changed[pkg.ImportPath] = pkg
+		}
+	}
+	var targets []string
+	for _, pkg := range packages {
+		_, hit := changed[pkg.ImportPath]
+		if hit || slices.ContainsFunc(pkg.Deps, func(dep string) bool { _, ok := changed[dep]; return ok }) {
+			targets = append(targets, pkg.ImportPath)
+		}
+	}
+	slices.Sort(targets)
+	return changed, targets
+}
+
+// proposedChanges reads the changes argument of simulate_change into file
+// changes against the files on disk.
+func proposedChanges(value any) ([]fileChange, error) {
+	raw, ok := value.([]any)
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("changes must be a non-empty array of objects")
+	}
+	var changes []fileChange
+	seen := make(map[string]bool)
+	for i, item := range raw {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("change %d must be an object", i+1)
+		}
+		fileURI, _ := entry["file_uri"].(string)
+		if fileURI == "" {
+			return nil, fmt.Errorf("change %d: file_uri is required", i+1)
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		path := convertURIToPath(fileURI)
+		if seen[path] {
+			return nil, fmt.Errorf("change %d: %s is already changed; merge its changes", i+1, filepath.Base(path))
+		}
+		seen[path] = true
+		before, err := os.ReadFile(path)
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		content, hasContent := entry["content"].(string)
+		edits, hasEdits := entry["edits"].([]any)
+		var change fileChange
+		switch {
+		case entry["delete"] == true:
+			if !exists {
+				return nil, fmt.Errorf("change %d: %s does not exist", i+1, filepath.Base(path))
+			}
+			change = fileChange{path: path, before: before, deleted: true}
+		case hasContent && hasEdits:
+			return nil, fmt.Errorf("change %d: set content or edits, not both", i+1)
+		case hasContent:
+			change = fileChange{path: path, before: before, after: []byte(content), created: !exists}
+		case hasEdits:
+			if !exists {
+				return nil, fmt.Errorf("change %d: %s does not exist; pass its content instead", i+1, filepath.Base(path))
+			}
+			lspEdits := make([]protocol.TextEdit, 0, len(edits))
+			for _, raw := range edits {
+				edit, ok := raw.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("change %d: each edit must be an object with range and newText", i+1)
+				}
+				rng, err := parseRangeArg(edit, "range")
+				if err != nil {
+					return nil, fmt.Errorf("change %d: %w", i+1, err)
+				}
+				newText, _ := edit["newText"].(string)
+				lspEdits = append(lspEdits, protocol.TextEdit{Range: rng, NewText: newText})
+			}
+			if change, err = textEditsChange(path, before, lspEdits); err != nil {
+				return nil, fmt.Errorf("change %d: %w", i+1, err)
+			}
+		default:
+			return nil, fmt.Errorf("change %d: set content, edits or delete", i+1)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// writeChangeOverlay writes a go command overlay replacing the changed
+// files by their new content and hiding the deleted ones. The returned
+// cleanup removes the temporary files.
+func writeChangeOverlay(changes []fileChange) (string, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "mcp-gopls-simulate-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+	replace := make(map[string]string)
+	for i, change := range changes {
+		if change.deleted {
+			replace[change.path] = ""
+			continue
+		}
+		source := filepath.Join(tmpDir, fmt.Sprintf("%d%s", i, filepath.Ext(change.path)))
+		if err := os.WriteFile(source, change.after, 0o644); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		replace[change.path] = source
+	}
+	overlay, err := json.Marshal(map[string]any{"Replace": replace})
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	overlayPath := filepath.Join(tmpDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0o644); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return overlayPath, cleanup, nil
+}
+
+// simulatedVetFindings vets the packages with the overlay, then without it
+// to mark the findings the files on disk already have, matched by file and
+// message as lines may have moved.
+func (t *LSPTools) simulatedVetFindings(ctx context.Context, s *server.MCPServer, token mcp.ProgressToken, overlayFlag string, packages []string) ([]simulatedFinding, error) {
+	result, err := t.runCommand(ctx, s, token, "go", append([]string{"vet", overlayFlag}, packages...)...)
+	if err == nil {
+		return []simulatedFinding{}, nil
+	}
+	errs := parseBuildErrors(result.Stdout + "\n" + result.Stderr)
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%s", buildCommandErrorMessage("go vet", result, err))
+	}
+	// Packages the change adds fail to vet on disk; their findings are new.
+	current, _ := t.runCommand(ctx, s, token, "go", append([]string{"vet"}, packages...)...)
+	existing := make(map[string]int)
+	for _, finding := range parseBuildErrors(current.Stdout + "\n" + current.Stderr) {
+		existing[finding.File+"\x00"+finding.Message]++
+	}
+	findings := make([]simulatedFinding, 0, len(errs))
+	for _, finding := range errs {
+		key := finding.File + "\x00" + finding.Message
+		findings = append(findings, simulatedFinding{buildError: finding, PreExisting: existing[key] > 0})
+		existing[key]--
+	}
+	return findings, nil
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestSimulateChange(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	calc := "package calc\n\nfunc Double(n int) int { return n * 2 }\n"
+	writeWorkspaceFile(t, workspace, "calc/calc.go", calc)
+	writeWorkspaceFile(t, workspace, "calc/calc_test.go", "package calc\n\nimport \"testing\"\n\nfunc TestDouble(t *testing.T) {\n\tif Double(2) != 4 {\n\t\tt.Fatal(\"wrong\")\n\t}\n}\n")
+	writeWorkspaceFile(t, workspace, "cmd/main.go", "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/calc\"\n)\n\nfunc main() { fmt.Println(calc.Double(1)) }\n")
+	calcURI := convertPathToURI(filepath.Join(workspace, "calc", "calc.go"))
+
+	tools := NewLSPTools(nil, workspace)
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	simulate := func(changes ...any) map[string]any {
+		t.Helper()
+		result, err := server.GetTool("simulate_change").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "simulate_change", Arguments: map[string]any{"changes": changes}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("simulate_change: %v %v", err, result)
+		}
+		return structured(result)
+	}
+
+	// A signature change breaks the package depending on calc.
+	broken := simulate(map[string]any{"file_uri": calcURI, "content": "package calc\n\nfunc Double(n, m int) int { return n * m }\n"})
+	if broken["ok"] != false || !reflect.DeepEqual(broken["packages"], []any{"example.com/app/calc", "example.com/app/cmd"}) {
+		t.Fatalf("unexpected result %v", broken)
+	}
+	if errs := broken["build"].(map[string]any)["errors"].([]any); len(errs) == 0 || errs[0].(map[string]any)["file"] != "cmd/main.go" {
+		t.Fatalf("expected the caller to fail to build, got %v", errs)
+	}
+
+	// A behavior change builds but fails the test.
+	failing := simulate(map[string]any{"file_uri": calcURI, "edits": []any{map[string]any{
+		"range":   map[string]any{"start": map[string]any{"line": 2, "character": 36}, "end": map[string]any{"line": 2, "character": 37}},
+		"newText": "3",
+	}}})
+	summary := failing["tests"].(map[string]any)["summary"].(map[string]any)
+	if failing["ok"] != false || failing["build"].(map[string]any)["ok"] != true || summary["failed"] != float64(1) {
+		t.Fatalf("expected a failing test, got %v", failing)
+	}
+
+	// A new file with a vet finding.
+	vetted := simulate(map[string]any{
+		"file_uri": filepath.Join(workspace, "calc", "format.go"),
+		"content":  "package calc\n\nimport \"fmt\"\n\nfunc Format(n int) string { return fmt.Sprintf(\"%s\", n) }\n",
+	})
+	vet := vetted["vet"].(map[string]any)
+	if vetted["ok"] != false || vet["introduced"] != float64(1) {
+		t.Fatalf("expected an introduced vet finding, got %v", vetted)
+	}
+
+	if ok := simulate(map[string]any{"file_uri": calcURI, "content": "package calc\n\n// Double doubles n.\nfunc Double(n int) int { return n + n }\n"}); ok["ok"] != true {
+		t.Fatalf("expected the change to pass, got %v", ok)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "calc", "calc.go")); string(data) != calc {
+		t.Fatalf("simulate_change wrote to disk: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "calc", "format.go")); !os.IsNotExist(err) {
+		t.Fatalf("simulate_change created a file: %v", err)
+	}
+
+	// The tests run the proposed code, which could write files itself.
+	passing := map[string]any{"file_uri": calcURI, "content": "package calc\n\nfunc Double(n int) int { return n + n }\n"}
+	tools.SetApprovalToken("secret")
+	if approval := simulate(passing)["tests"].(map[string]any); approval["refused"] == nil || approval["summary"] != nil {
+		t.Fatalf("expected the tests to be refused while edits need approval, got %v", approval)
+	}
+	tools.approvals = nil
+	contract, err := tools.newEditContract([]string{"./calc"}, nil)
+	if err != nil {
+		t.Fatalf("declare: %v", err)
+	}
+	tools.contract = contract
+	if contract := simulate(passing)["tests"].(map[string]any); contract["refused"] == nil || contract["summary"] != nil {
+		t.Fatalf("expected the tests to be refused under a narrow contract, got %v", contract)
+	}
+}
@@ -0,0 +1,416 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/imports"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+const (
+	snippetKindFile         = "file"
+	snippetKindDeclarations = "declarations"
+	snippetKindStatements   = "statements"
+)
+
+// docSnippet is a fenced Go code block found in a markdown file.
+type docSnippet struct {
+	File       string         `json:"file"`
+	Line       int            `json:"line"`
+	Kind       string         `json:"kind,omitempty"`
+	Status     string         `json:"status"`
+	SkipReason string         `json:"skip_reason,omitempty"`
+	Errors     []snippetError `json:"errors,omitempty"`
+
+	code   string
+	source []byte
+	offset int
+}
+
+type snippetError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (t *LSPTools) registerVerifyDocSnippets(s *server.MCPServer) {
+	tool := mcp.NewTool("verify_doc_snippets",
+		mcp.WithDescription("Extract fenced Go code blocks from workspace markdown files, compile them in a scratch module that replaces the workspace module, and report the examples that no longer build"),
+		mcp.WithTitleAnnotation("Verify Doc Snippets"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file", mcp.Description("Markdown file relative to the workspace. Defaults to every .md file")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		token := getProgressToken(request.Params.Meta)
+
+		var files []string
+		if file := getOptionalStringArg(args, "file"); file != "" {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(t.workspaceDir, file)
+			}
+			files = []string{file}
+		} else {
+			err := walkWorkspaceFiles(t.workspaceDir, func(path string) error {
+				if strings.EqualFold(filepath.Ext(path), ".md") {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		var snippets []*docSnippet
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("read %s: %v", file, err)), nil
+			}
+			for _, snippet := range extractGoSnippets(string(data)) {
+				snippet.File = relativeSlashPath(t.workspaceDir, file)
+				snippets = append(snippets, snippet)
+			}
+		}
+
+		workspacePackages := map[string]string{}
+		if ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{}); err == nil {
+			workspacePackages = packagesByName(ws)
+		}
+
+		var toBuild []*docSnippet
+		for _, snippet := range snippets {
+			if snippet.Status == "skipped" {
+				continue
+			}
+			if err := prepareSnippet(snippet, workspacePackages); err != nil {
+				snippet.Status = "fail"
+				snippet.Errors = []snippetError{{Line: snippet.Line, Message: err.Error()}}
+				continue
+			}
+			toBuild = append(toBuild, snippet)
+		}
+
+		if len(toBuild) > 0 {
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Compiling %d snippets", len(toBuild)))
+			if err := t.buildSnippets(ctx, s, toBuild); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		counts := map[string]int{}
+		for _, snippet := range snippets {
+			counts[snippet.Status]++
+		}
+		payload := map[string]any{
+			"snippets": snippets,
+			"passed":   counts["pass"],
+			"failed":   counts["fail"],
+			"skipped":  counts["skipped"],
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// extractGoSnippets returns the fenced blocks tagged go or golang. Blocks
+// whose info string carries an extra word such as "nocompile", "skip" or
+// "ignore", and blocks eliding code with a bare "..." line, are reported as
+// skipped.
+func extractGoSnippets(markdown string) []*docSnippet {
+	var (
+		snippets []*docSnippet
+		current  *docSnippet
+		fence    string
+		body     []string
+	)
+	lines := bufio.NewScanner(strings.NewReader(markdown))
+	lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for lines.Scan() {
+		lineNo++
+		line := lines.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.TrimLeft(trimmed, fence[:1]) == "" {
+				if current != nil {
+					current.code = strings.Join(body, "\n") + "\n"
+					if current.Status == "" {
+						for _, l := range body {
+							if strings.TrimSpace(l) == "..." {
+								current.Status = "skipped"
+								current.SkipReason = "contains elided code"
+								break
+							}
+						}
+					}
+					snippets = append(snippets, current)
+				}
+				fence, current, body = "", nil, nil
+				continue
+			}
+			body = append(body, line)
+			continue
+		}
+
+		for _, marker := range []string{"```", "~~~"} {
+			if !strings.HasPrefix(trimmed, marker) {
+				continue
+			}
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, marker[:1]))]
+			info := strings.Fields(strings.TrimSpace(trimmed[len(fence):]))
+			if len(info) == 0 || (info[0] != "go" && info[0] != "golang") {
+				break
+			}
+			current = &docSnippet{Line: lineNo + 1}
+			for _, flag := range info[1:] {
+				switch strings.ToLower(flag) {
+				case "nocompile", "no-compile", "skip", "ignore", "noverify":
+					current.Status = "skipped"
+					current.SkipReason = "marked " + flag
+				}
+			}
+			break
+		}
+	}
+	return snippets
+}
+
+// prepareSnippet turns the snippet into a complete Go file: full files are
+// kept as-is, top-level declarations get a package clause and statements are
+// wrapped in func main. Missing imports are then added.
+func prepareSnippet(snippet *docSnippet, workspacePackages map[string]string) error {
+	fset := token.NewFileSet()
+	code := snippet.code
+
+	if file, err := parser.ParseFile(fset, "", code, parser.PackageClauseOnly); err == nil && file.Name != nil {
+		snippet.Kind = snippetKindFile
+		snippet.source = []byte(code)
+		snippet.offset = 0
+	} else if _, err := parser.ParseFile(fset, "", "package snippet\n"+code, parser.AllErrors); err == nil {
+		snippet.Kind = snippetKindDeclarations
+		pkg := "snippet"
+		if strings.Contains(code, "func main()") {
+			pkg = "main"
+		}
+		snippet.source = []byte("package " + pkg + "\n" + code)
+		snippet.offset = 1
+	} else {
+		snippet.Kind = snippetKindStatements
+		snippet.source = []byte("package main\n\nfunc main() {\n" + code + "}\n")
+		snippet.offset = 3
+		if _, err := parser.ParseFile(fset, "", snippet.source, parser.AllErrors); err != nil {
+			return fmt.Errorf("snippet does not parse as a file, declarations or statements: %v", firstParseError(err))
+		}
+	}
+
+	snippet.source = addMissingImports(snippet.source, workspacePackages)
+	return nil
+}
+
+// addMissingImports adds the imports the source lacks on the package clause
+// line, so snippet line numbers stay unchanged. Workspace packages are
+// matched by package name first; goimports resolves the rest.
+func addMissingImports(src []byte, workspacePackages map[string]string) []byte {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.AllErrors)
+	if err != nil {
+		return src
+	}
+	imported := make(map[string]bool, len(file.Imports))
+	for _, spec := range file.Imports {
+		value, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(value)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imported[name] = true
+	}
+	var missing []string
+	for _, ident := range file.Unresolved {
+		if importPath, ok := workspacePackages[ident.Name]; ok && !imported[ident.Name] {
+			imported[ident.Name] = true
+			missing = append(missing, strconv.Quote(importPath))
+		}
+	}
+	src = insertImports(src, fset.Position(file.Name.End()).Offset, missing)
+
+	processed, err := imports.Process("snippet.go", src, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+	if err != nil {
+		return src
+	}
+	fset = token.NewFileSet()
+	original, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return src
+	}
+	updated, err := parser.ParseFile(token.NewFileSet(), "", processed, parser.ImportsOnly)
+	if err != nil {
+		return src
+	}
+	have := make(map[string]bool, len(original.Imports))
+	for _, spec := range original.Imports {
+		have[spec.Path.Value] = true
+	}
+	missing = missing[:0]
+	for _, spec := range updated.Imports {
+		if have[spec.Path.Value] {
+			continue
+		}
+		entry := spec.Path.Value
+		if spec.Name != nil {
+			entry = spec.Name.Name + " " + entry
+		}
+		missing = append(missing, entry)
+	}
+	return insertImports(src, fset.Position(original.Name.End()).Offset, missing)
+}
+
+func insertImports(src []byte, offset int, entries []string) []byte {
+	if len(entries) == 0 {
+		return src
+	}
+	var out bytes.Buffer
+	out.Write(src[:offset])
+	out.WriteString("; import (" + strings.Join(entries, "; ") + ")")
+	out.Write(src[offset:])
+	return out.Bytes()
+}
+
+// packagesByName maps package names to import paths for the importable
+// workspace packages, leaving out names shared by several packages.
+func packagesByName(ws *gosrc.Workspace) map[string]string {
+	byName := make(map[string]string)
+	ambiguous := make(map[string]bool)
+	for _, pkg := range ws.Packages {
+		if pkg.Name == "main" || strings.HasSuffix(pkg.Name, "_test") {
+			continue
+		}
+		if existing, ok := byName[pkg.Name]; ok && existing != pkg.ImportPath {
+			ambiguous[pkg.Name] = true
+		}
+		byName[pkg.Name] = pkg.ImportPath
+	}
+	for name := range ambiguous {
+		delete(byName, name)
+	}
+	return byName
+}
+
+func firstParseError(err error) error {
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		return list[0]
+	}
+	return err
+}
+
+func (t *LSPTools) buildSnippets(ctx context.Context, s *server.MCPServer, snippets []*docSnippet) error {
+	scratch, err := os.MkdirTemp("", "mcp-gopls-snippets-")
+	if err != nil {
+		return fmt.Errorf("create scratch module: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	goMod := "module mcpgopls.snippets\n"
+	if version := readGoModVersion(t.workspaceDir); version != "" {
+		goMod += "\ngo " + version + "\n"
+	}
+	if modPath, err := readModulePath(t.workspaceDir); err == nil {
+		goMod += fmt.Sprintf("\nrequire %s v0.0.0\n\nreplace %s => %s\n", modPath, modPath, t.workspaceDir)
+		if sum, err := os.ReadFile(filepath.Join(t.workspaceDir, "go.sum")); err == nil {
+			if err := os.WriteFile(filepath.Join(scratch, "go.sum"), sum, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(scratch, "go.mod"), []byte(goMod), 0o644); err != nil {
+		return err
+	}
+
+	byDir := make(map[string]*docSnippet, len(snippets))
+	for i, snippet := range snippets {
+		dir := "snippet" + strconv.Itoa(i+1)
+		byDir[dir] = snippet
+		if err := writeWorkspaceSnippet(scratch, dir, snippet.source); err != nil {
+			return err
+		}
+	}
+
+	result, err := t.runCommandSpec(ctx, s, nil, commandSpec{
+		name: "go",
+		args: []string{"build", "./..."},
+		dir:  scratch,
+		env:  []string{"GOFLAGS=-mod=mod", "GOWORK=off"},
+	})
+	if err != nil && result.ExitCode == 0 {
+		return fmt.Errorf("go build: %w", err)
+	}
+
+	for _, line := range strings.Split(result.Stderr, "\n") {
+		match := goCompilerErrorPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		dir := path.Dir(strings.TrimPrefix(filepath.ToSlash(match[1]), "./"))
+		snippet, ok := byDir[dir]
+		if !ok {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		message := match[4]
+		// Fragments routinely declare values only to show them; do not fail
+		// the snippet for that.
+		warning := snippet.Kind != snippetKindFile && (strings.Contains(message, "declared and not used") || strings.Contains(message, "imported and not used"))
+		snippet.Errors = append(snippet.Errors, snippetError{
+			Line:    snippet.Line + lineNo - 1 - snippet.offset,
+			Column:  column,
+			Message: message,
+			Warning: warning,
+		})
+	}
+
+	for _, snippet := range snippets {
+		snippet.Status = "pass"
+		for _, e := range snippet.Errors {
+			if !e.Warning {
+				snippet.Status = "fail"
+				break
+			}
+		}
+		sort.SliceStable(snippet.Errors, func(i, j int) bool { return snippet.Errors[i].Line < snippet.Errors[j].Line })
+	}
+	return nil
+}
+
+func writeWorkspaceSnippet(root, dir string, source []byte) error {
+	target := filepath.Join(root, dir)
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(target, "main.go"), source, 0o644)
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractGoSnippets(t *testing.T) {
+	markdown := "# Title\n\n```go\nfmt.Println(\"hi\")\n```\n\n```sh\ngo test ./...\n```\n\n" +
+		"```go nocompile\nbroken(\n```\n\n~~~golang\nfunc A() {\n\t...\n}\n~~~\n"
+
+	snippets := extractGoSnippets(markdown)
+	if len(snippets) != 3 {
+		t.Fatalf("expected 3 snippets, got %#v", snippets)
+	}
+	if snippets[0].Line != 4 || snippets[0].Status != "" || snippets[0].code != "fmt.Println(\"hi\")\n" {
+		t.Fatalf("unexpected first snippet %#v", snippets[0])
+	}
+	if snippets[1].Status != "skipped" || snippets[1].SkipReason != "marked nocompile" {
+		t.Fatalf("unexpected second snippet %#v", snippets[1])
+	}
+	if snippets[2].Status != "skipped" || snippets[2].Line != 16 {
+		t.Fatalf("unexpected third snippet %#v", snippets[2])
+	}
+}
+
+func TestPrepareSnippet(t *testing.T) {
+	statements := &docSnippet{code: "x := strings.ToUpper(\"a\")\nfmt.Println(x)\n"}
+	if err := prepareSnippet(statements, nil); err != nil {
+		t.Fatalf("prepareSnippet returned error: %v", err)
+	}
+	source := string(statements.source)
+	if statements.Kind != snippetKindStatements || statements.offset != 3 {
+		t.Fatalf("unexpected statements snippet %#v", statements)
+	}
+	if !strings.HasPrefix(source, "package main; import (\"fmt\"; \"strings\")\n\nfunc main() {\n") {
+		t.Fatalf("unexpected source:\n%s", source)
+	}
+
+	local := &docSnippet{code: "fmt.Println(lib.Hello())\n"}
+	if err := prepareSnippet(local, map[string]string{"lib": "example.com/mod/lib"}); err != nil {
+		t.Fatalf("prepareSnippet returned error: %v", err)
+	}
+	if !strings.HasPrefix(string(local.source), "package main; import (\"fmt\"); import (\"example.com/mod/lib\")\n") {
+		t.Fatalf("unexpected source:\n%s", local.source)
+	}
+
+	decls := &docSnippet{code: "type T struct{}\n\nfunc (T) String() string { return \"t\" }\n"}
+	if err := prepareSnippet(decls, nil); err != nil {
+		t.Fatalf("prepareSnippet returned error: %v", err)
+	}
+	if decls.Kind != snippetKindDeclarations || !strings.HasPrefix(string(decls.source), "package snippet\n") {
+		t.Fatalf("unexpected declarations snippet %#v", decls)
+	}
+
+	file := &docSnippet{code: "package demo\n\nvar V = 1\n"}
+	if err := prepareSnippet(file, nil); err != nil {
+		t.Fatalf("prepareSnippet returned error: %v", err)
+	}
+	if file.Kind != snippetKindFile || file.offset != 0 {
+		t.Fatalf("unexpected file snippet %#v", file)
+	}
+
+	if err := prepareSnippet(&docSnippet{code: "func {\n"}, nil); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
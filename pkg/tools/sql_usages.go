@@ -0,0 +1,334 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+var (
+	sqlStatementPattern = regexp.MustCompile(`(?is)^\s*(?:--[^\n]*\n\s*|/\*.*?\*/\s*)*(select|insert|update|delete|with|create|alter|drop|truncate|merge|replace|upsert)\b`)
+	sqlcHeaderPattern   = regexp.MustCompile(`^--\s*name:\s*(\w+)\s*:(\w+)`)
+	sqlCommentPattern   = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	sqlQuotedPattern    = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlIdentifier       = `((?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][\w$]*)(?:\.(?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][\w$]*))?)`
+	sqlTablePattern     = regexp.MustCompile(`(?i)\b(?:from|join|into|update|table(?:\s+if\s+(?:not\s+)?exists)?|using)\s+(?:only\s+)?` + sqlIdentifier)
+	sqlCTEPattern       = regexp.MustCompile(`(?i)(?:\bwith(?:\s+recursive)?|,)\s*([A-Za-z_]\w*)\s*(?:\([^)]*\)\s*)?as\s*\(`)
+	sqlVerbPattern      = regexp.MustCompile(`(?i)\b(insert|update|delete|merge)\b`)
+	sqlKeywords         = map[string]bool{"select": true, "lateral": true, "unnest": true, "values": true, "set": true, "where": true}
+)
+
+// sqlArgIndex is the position of the query argument for the database/sql,
+// sqlx and pgx methods that accept one.
+var sqlArgIndex = map[string]int{
+	"Query": 0, "QueryRow": 0, "Exec": 0, "Prepare": 0,
+	"QueryContext": 1, "QueryRowContext": 1, "ExecContext": 1, "PrepareContext": 1,
+	"Queryx": 0, "QueryRowx": 0, "MustExec": 0, "NamedExec": 0, "NamedQuery": 0, "Preparex": 0, "PrepareNamed": 0,
+	"QueryxContext": 1, "QueryRowxContext": 1, "MustExecContext": 1, "NamedExecContext": 1, "NamedQueryContext": 1, "PreparexContext": 1,
+	"Get": 1, "Select": 1, "GetContext": 2, "SelectContext": 2,
+}
+
+type sqlUsage struct {
+	Operation string              `json:"operation"`
+	Statement string              `json:"statement,omitempty"`
+	Tables    []string            `json:"tables,omitempty"`
+	Call      string              `json:"call"`
+	Package   string              `json:"package"`
+	Dynamic   bool                `json:"dynamic,omitempty"`
+	QueryName string              `json:"query_name,omitempty"`
+	Location  protocol.Location   `json:"location"`
+	Callers   []protocol.Location `json:"callers,omitempty"`
+}
+
+type sqlTable struct {
+	Name       string      `json:"name"`
+	Operations []string    `json:"operations"`
+	Usages     []*sqlUsage `json:"usages"`
+}
+
+func (t *LSPTools) registerListSQLUsages(s *server.MCPServer) {
+	tool := mcp.NewTool("list_sql_usages",
+		mcp.WithDescription("Find SQL statements passed to database/sql, sqlx, pgx and sqlc-generated calls and group the call sites by referenced table"),
+		mcp.WithTitleAnnotation("List SQL Usages"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("table", mcp.Description("Only report statements referencing this table (case-insensitive, schema prefix optional)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		table := strings.TrimSpace(getOptionalStringArg(args, "table"))
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		usages := collectSQLUsages(ws)
+		tables, dynamic := groupSQLUsages(usages, table)
+		payload := map[string]any{
+			"tables":       tables,
+			"statements":   len(usages) - len(dynamic),
+			"parse_errors": ws.ParseErrors,
+		}
+		if table == "" {
+			payload["dynamic"] = dynamic
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func collectSQLUsages(ws *gosrc.Workspace) []*sqlUsage {
+	consts := stringConstants(ws)
+	usages := []*sqlUsage{}
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			usesDB := hasImportPrefix(imports, "database/sql") || hasImportPrefix(imports, "github.com/jmoiron/sqlx")
+			ast.Inspect(file.Syntax, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				_, name, ok := methodCall(call)
+				if !ok {
+					return true
+				}
+				index, ok := sqlArgIndex[name]
+				if !ok {
+					return true
+				}
+				usage := &sqlUsage{
+					Call:     exprText(call.Fun),
+					Package:  pkg.ImportPath,
+					Location: sourceLocation(file, call.Pos(), call.End()),
+				}
+				// pgx and friends take the context first even on methods
+				// without a Context suffix, so look for the statement among
+				// all arguments before falling back to the usual position.
+				for _, arg := range call.Args {
+					if statement, ok := constantString(pkg.ImportPath, imports, consts, arg); ok && sqlStatementPattern.MatchString(statement) {
+						describeSQL(usage, statement)
+						usages = append(usages, usage)
+						return true
+					}
+				}
+				if usesDB && index < len(call.Args) {
+					usage.Operation = "UNKNOWN"
+					usage.Dynamic = true
+					usage.Statement = exprText(call.Args[index])
+					usages = append(usages, usage)
+				}
+				return true
+			})
+		}
+	}
+	attachSQLCCallers(ws, usages)
+	return usages
+}
+
+// stringConstants maps each package to the string values of its
+// package-level constants and of variables initialised with a literal.
+func stringConstants(ws *gosrc.Workspace) map[string]map[string]string {
+	consts := make(map[string]map[string]string)
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Syntax.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					vs, ok := spec.(*ast.ValueSpec)
+					if !ok || len(vs.Names) != len(vs.Values) {
+						continue
+					}
+					for i, name := range vs.Names {
+						value, ok := stringValue(vs.Values[i])
+						if !ok {
+							continue
+						}
+						if consts[pkg.ImportPath] == nil {
+							consts[pkg.ImportPath] = make(map[string]string)
+						}
+						consts[pkg.ImportPath][name.Name] = value
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+// constantString evaluates expr as a string literal or a reference to a
+// package-level string constant of the workspace.
+func constantString(pkgPath string, imports map[string]string, consts map[string]map[string]string, expr ast.Expr) (string, bool) {
+	if value, ok := stringValue(expr); ok {
+		return value, true
+	}
+	switch e := unwrapParens(expr).(type) {
+	case *ast.Ident:
+		value, ok := consts[pkgPath][e.Name]
+		return value, ok
+	case *ast.SelectorExpr:
+		if ident, ok := e.X.(*ast.Ident); ok {
+			if importPath, ok := imports[ident.Name]; ok {
+				value, ok := consts[importPath][e.Sel.Name]
+				return value, ok
+			}
+		}
+	case *ast.BinaryExpr:
+		left, ok := constantString(pkgPath, imports, consts, e.X)
+		if !ok {
+			return "", false
+		}
+		right, ok := constantString(pkgPath, imports, consts, e.Y)
+		return left + right, ok
+	}
+	return "", false
+}
+
+// describeSQL fills in the operation, normalised statement and referenced
+// tables of usage.
+func describeSQL(usage *sqlUsage, statement string) {
+	trimmed := strings.TrimSpace(statement)
+	if match := sqlcHeaderPattern.FindStringSubmatch(trimmed); match != nil {
+		usage.QueryName = match[1]
+	}
+	usage.Statement = strings.Join(strings.Fields(sqlCommentPattern.ReplaceAllString(trimmed, " ")), " ")
+	usage.Operation = strings.ToUpper(sqlStatementPattern.FindStringSubmatch(trimmed)[1])
+	usage.Tables = sqlTables(usage.Statement)
+	if usage.Operation == "WITH" {
+		usage.Operation = "SELECT"
+		if verb := sqlVerbPattern.FindString(sqlBodyAfterCTEs(usage.Statement)); verb != "" {
+			usage.Operation = strings.ToUpper(verb)
+		}
+	}
+}
+
+// sqlTables lists the tables a statement reads or writes, excluding common
+// table expressions it defines itself.
+func sqlTables(statement string) []string {
+	statement = sqlQuotedPattern.ReplaceAllString(statement, "''")
+	ctes := make(map[string]bool)
+	if strings.HasPrefix(strings.ToLower(statement), "with") {
+		for _, match := range sqlCTEPattern.FindAllStringSubmatch(statement, -1) {
+			ctes[strings.ToLower(match[1])] = true
+		}
+	}
+	seen := make(map[string]bool)
+	var tables []string
+	for _, match := range sqlTablePattern.FindAllStringSubmatch(statement, -1) {
+		name := strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "").Replace(match[1])
+		lower := strings.ToLower(name)
+		if sqlKeywords[lower] || ctes[lower] || seen[lower] {
+			continue
+		}
+		seen[lower] = true
+		tables = append(tables, name)
+	}
+	return tables
+}
+
+// sqlBodyAfterCTEs skips the parenthesised common table expressions of a
+// WITH statement so the main verb can be found.
+func sqlBodyAfterCTEs(statement string) string {
+	depth, last := 0, 0
+	for i, r := range statement {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				last = i + 1
+			}
+		}
+	}
+	return statement[last:]
+}
+
+// attachSQLCCallers points sqlc queries at the calls of their generated
+// methods, which are the call sites a schema change actually affects.
+func attachSQLCCallers(ws *gosrc.Workspace, usages []*sqlUsage) {
+	byMethod := make(map[string][]*sqlUsage)
+	for _, usage := range usages {
+		if usage.QueryName != "" {
+			byMethod[usage.QueryName] = append(byMethod[usage.QueryName], usage)
+		}
+	}
+	if len(byMethod) == 0 {
+		return
+	}
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			if ast.IsGenerated(file.Syntax) {
+				continue
+			}
+			imports := fileImports(file.Syntax)
+			ast.Inspect(file.Syntax, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				_, name, ok := methodCall(call)
+				if !ok {
+					return true
+				}
+				for _, usage := range byMethod[name] {
+					if usage.Package != pkg.ImportPath && !hasImportPrefix(imports, usage.Package) {
+						continue
+					}
+					usage.Callers = append(usage.Callers, sourceLocation(file, call.Pos(), call.End()))
+				}
+				return true
+			})
+		}
+	}
+}
+
+// groupSQLUsages indexes usages by table. Usages whose statement could not
+// be resolved are returned separately.
+func groupSQLUsages(usages []*sqlUsage, filter string) ([]*sqlTable, []*sqlUsage) {
+	filter = strings.ToLower(filter)
+	byName := make(map[string]*sqlTable)
+	dynamic := []*sqlUsage{}
+	for _, usage := range usages {
+		if usage.Dynamic {
+			dynamic = append(dynamic, usage)
+			continue
+		}
+		for _, name := range usage.Tables {
+			key := strings.ToLower(name)
+			if filter != "" && key != filter && !strings.HasSuffix(key, "."+filter) {
+				continue
+			}
+			table, ok := byName[key]
+			if !ok {
+				table = &sqlTable{Name: name}
+				byName[key] = table
+			}
+			table.Usages = append(table.Usages, usage)
+			if !slices.Contains(table.Operations, usage.Operation) {
+				table.Operations = append(table.Operations, usage.Operation)
+			}
+		}
+	}
+	tables := make([]*sqlTable, 0, len(byName))
+	for _, key := range sortedKeys(byName) {
+		sort.Strings(byName[key].Operations)
+		tables = append(tables, byName[key])
+	}
+	return tables, dynamic
+}
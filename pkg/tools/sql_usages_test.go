@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCollectSQLUsages(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "db/query.sql.go", `// Code generated by sqlc. DO NOT EDIT.
+
+package db
+
+import "context"
+
+const getAuthor = `+"`"+`-- name: GetAuthor :one
+SELECT id, name FROM authors
+WHERE id = $1 LIMIT 1
+`+"`"+`
+
+func (q *Queries) GetAuthor(ctx context.Context, id int64) (Author, error) {
+	row := q.db.QueryRowContext(ctx, getAuthor, id)
+	return Author{}, row.Scan()
+}
+`)
+	writeWorkspaceFile(t, workspace, "store.go", `package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"example.com/app/db"
+)
+
+const deleteBooks = "DELETE FROM books WHERE author_id = ?"
+
+func store(ctx context.Context, conn *sql.DB, q *db.Queries, table string) {
+	conn.ExecContext(ctx, deleteBooks, 1)
+	conn.QueryContext(ctx, "WITH recent AS (SELECT * FROM books) "+
+		"UPDATE public.authors SET name = 'x' FROM recent WHERE authors.id = recent.author_id")
+	conn.Query(fmt.Sprintf("SELECT * FROM %s", table))
+	q.GetAuthor(ctx, 1)
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	usages := collectSQLUsages(ws)
+	if len(usages) != 4 {
+		t.Fatalf("expected 4 usages, got %d: %+v", len(usages), usages)
+	}
+
+	byOperation := make(map[string]*sqlUsage)
+	for _, usage := range usages {
+		byOperation[usage.Operation] = usage
+	}
+	if got := byOperation["DELETE"]; got == nil || !reflect.DeepEqual(got.Tables, []string{"books"}) {
+		t.Fatalf("unexpected DELETE usage: %+v", got)
+	}
+	if got := byOperation["UPDATE"]; got == nil || !reflect.DeepEqual(got.Tables, []string{"books", "public.authors"}) {
+		t.Fatalf("unexpected CTE UPDATE usage: %+v", got)
+	}
+	sqlc := byOperation["SELECT"]
+	if sqlc == nil || sqlc.QueryName != "GetAuthor" || len(sqlc.Callers) != 1 || sqlc.Callers[0].Range.Start.Line != 17 {
+		t.Fatalf("unexpected sqlc usage: %+v", sqlc)
+	}
+	if got := byOperation["UNKNOWN"]; got == nil || !got.Dynamic || got.Statement != `fmt.Sprintf("SELECT * FROM %s", table)` {
+		t.Fatalf("unexpected dynamic usage: %+v", got)
+	}
+
+	tables, dynamic := groupSQLUsages(usages, "authors")
+	if len(dynamic) != 1 || len(tables) != 2 || tables[0].Name != "authors" || tables[1].Name != "public.authors" {
+		t.Fatalf("unexpected grouping: %+v", tables)
+	}
+	if !reflect.DeepEqual(tables[1].Operations, []string{"UPDATE"}) {
+		t.Fatalf("unexpected operations: %v", tables[1].Operations)
+	}
+}
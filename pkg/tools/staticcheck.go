@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var lookupStaticcheckBinary = exec.LookPath
+
+// staticcheckFinding is a staticcheck diagnostic with its position in the
+// workspace.
+type staticcheckFinding struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+	Message   string `json:"message"`
+}
+
+// staticcheckCheck groups the findings of one check with its explanation.
+type staticcheckCheck struct {
+	ID          string               `json:"id"`
+	Explanation string               `json:"explanation,omitempty"`
+	Docs        string               `json:"docs"`
+	Count       int                  `json:"count"`
+	Findings    []staticcheckFinding `json:"findings"`
+}
+
+// staticcheckJSONDiagnostic is a line of staticcheck -f json output.
+type staticcheckJSONDiagnostic struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Location struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+	} `json:"location"`
+	End struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"end"`
+	Message string `json:"message"`
+}
+
+func (t *LSPTools) registerStaticcheck(s *server.MCPServer) {
+	tool := mcp.NewTool("staticcheck",
+		mcp.WithDescription("Run staticcheck on packages and return the findings grouped by check ID (SA4006, S1002, ST1003...), each check with its explanation from staticcheck -explain and documentation link, so a fix can be justified by the rule it addresses; findings carry their file relative to the workspace, line, column, end, severity and message. Packages that fail to compile are reported under errors. Runs the staticcheck binary, or go run honnef.co/go/tools/cmd/staticcheck@latest when it is not installed"),
+		mcp.WithTitleAnnotation("Staticcheck"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("target",
+			mcp.Description("Package patterns to check, separated by spaces (default ./...)"),
+		),
+		mcp.WithArray("checks",
+			mcp.Description("Checks to run, in staticcheck -checks syntax, such as [\"all\", \"-ST1000\"] or [\"SA*\"] (default the configured ones)"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("tags",
+			mcp.Description("Comma-separated build tags, passed as -tags"),
+		),
+		mcp.WithBoolean("explain",
+			mcp.Description("Include each check's explanation (default true)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		targets := strings.Fields(getOptionalStringArg(args, "target"))
+		if len(targets) == 0 {
+			targets = []string{"./..."}
+		}
+		checkArgs := []string{"-f", "json"}
+		if checks := getOptionalStringListArg(args, "checks"); len(checks) > 0 {
+			checkArgs = append(checkArgs, "-checks", strings.Join(checks, ","))
+		}
+		if tags := getOptionalStringArg(args, "tags"); tags != "" {
+			checkArgs = append(checkArgs, "-tags", tags)
+		}
+		explain := true
+		if _, ok := args["explain"]; ok {
+			explain = getOptionalBoolArg(args, "explain")
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		cmd, cmdArgs, fallback := determineStaticcheckCommand(append(checkArgs, targets...)...)
+		if fallback {
+			sendProgressNotification(ctx, s, token, "Running staticcheck via go run (binary not found in PATH)")
+		} else {
+			sendProgressNotification(ctx, s, token, "Running staticcheck "+strings.Join(targets, " "))
+		}
+		result, err := t.runCommand(ctx, s, token, cmd, cmdArgs...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return t.commandFailureResult("staticcheck", result, err)
+			}
+		}
+		checks, errs := t.parseStaticcheckOutput(result.Stdout)
+		if err != nil && len(checks) == 0 && len(errs) == 0 {
+			errs = append(errs, buildError{Message: buildCommandErrorMessage("staticcheck", result, err)})
+		}
+		if explain {
+			for i := range checks {
+				sendProgressNotification(ctx, s, token, "Explaining "+checks[i].ID)
+				explained, err := t.runCommand(ctx, s, nil, cmd, staticcheckExplainArgs(cmdArgs, fallback, checks[i].ID)...)
+				if err == nil {
+					checks[i].Explanation = staticcheckExplanation(explained.Stdout)
+				}
+			}
+		}
+
+		count := 0
+		for _, check := range checks {
+			count += check.Count
+		}
+		payload := map[string]any{
+			"ok":      count == 0 && len(errs) == 0,
+			"targets": targets,
+			"checks":  checks,
+			"count":   count,
+			"errors":  errs,
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// determineStaticcheckCommand returns the command running staticcheck with
+// args and whether it falls back to go run because the binary is not in
+// PATH.
+func determineStaticcheckCommand(args ...string) (string, []string, bool) {
+	if path, err := lookupStaticcheckBinary("staticcheck"); err == nil {
+		return path, args, false
+	}
+	return "go", append([]string{"run", "honnef.co/go/tools/cmd/staticcheck@latest"}, args...), true
+}
+
+// staticcheckExplainArgs returns the arguments of the command explaining
+// check, for the command whose arguments cmdArgs are.
+func staticcheckExplainArgs(cmdArgs []string, fallback bool, check string) []string {
+	if fallback {
+		return []string{cmdArgs[0], cmdArgs[1], "-explain", check}
+	}
+	return []string{"-explain", check}
+}
+
+// staticcheckExplanation keeps the explanation of staticcheck -explain,
+// without the trailing "Available since" and "Online documentation"
+// sections.
+func staticcheckExplanation(output string) string {
+	if index := strings.Index(output, "\nAvailable since"); index >= 0 {
+		output = output[:index]
+	}
+	if index := strings.Index(output, "\nOnline documentation"); index >= 0 {
+		output = output[:index]
+	}
+	return strings.TrimSpace(output)
+}
+
+// parseStaticcheckOutput groups the JSON lines of staticcheck -f json by
+// check, in ID order. The "compile" diagnostics of packages that do not
+// build are returned as errors.
+func (t *LSPTools) parseStaticcheckOutput(output string) ([]staticcheckCheck, []buildError) {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	byID := make(map[string]*staticcheckCheck)
+	errs := []buildError{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var diagnostic staticcheckJSONDiagnostic
+		if json.Unmarshal(scanner.Bytes(), &diagnostic) != nil || diagnostic.Code == "" {
+			continue
+		}
+		file := diagnostic.Location.File
+		if filepath.IsAbs(file) {
+			file = relativeSlashPath(root, file)
+		}
+		if diagnostic.Code == "compile" {
+			errs = append(errs, buildError{File: file, Line: diagnostic.Location.Line, Column: diagnostic.Location.Column, Message: diagnostic.Message})
+			continue
+		}
+		check, ok := byID[diagnostic.Code]
+		if !ok {
+			check = &staticcheckCheck{ID: diagnostic.Code, Docs: fmt.Sprintf("https://staticcheck.dev/docs/checks#%s", diagnostic.Code)}
+			byID[diagnostic.Code] = check
+		}
+		finding := staticcheckFinding{
+			File:     file,
+			Line:     diagnostic.Location.Line,
+			Column:   diagnostic.Location.Column,
+			Severity: diagnostic.Severity,
+			Message:  diagnostic.Message,
+		}
+		if diagnostic.End.Line != 0 && (diagnostic.End.Line != finding.Line || diagnostic.End.Column != finding.Column) {
+			finding.EndLine, finding.EndColumn = diagnostic.End.Line, diagnostic.End.Column
+		}
+		check.Findings = append(check.Findings, finding)
+		check.Count++
+	}
+	checks := make([]staticcheckCheck, 0, len(byID))
+	for _, id := range sortedKeys(byID) {
+		check := byID[id]
+		slices.SortFunc(check.Findings, func(a, b staticcheckFinding) int {
+			if a.File != b.File {
+				return strings.Compare(a.File, b.File)
+			}
+			if a.Line != b.Line {
+				return a.Line - b.Line
+			}
+			return a.Column - b.Column
+		})
+		checks = append(checks, *check)
+	}
+	return checks, errs
+}
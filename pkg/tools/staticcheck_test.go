@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseStaticcheckOutput(t *testing.T) {
+	tools := NewLSPTools(nil, "/work")
+	output := `{"code":"SA4006","severity":"error","location":{"file":"/work/main.go","line":9,"column":2},"end":{"file":"/work/main.go","line":9,"column":5},"message":"this value of x is never used"}
+{"code":"S1002","severity":"error","location":{"file":"/work/util/util.go","line":4,"column":5},"end":{"file":"","line":0,"column":0},"message":"should omit comparison to bool constant"}
+{"code":"SA4006","severity":"error","location":{"file":"/work/main.go","line":3,"column":2},"end":{"file":"","line":0,"column":0},"message":"this value of y is never used"}
+{"code":"compile","severity":"error","location":{"file":"/work/broken/broken.go","line":3,"column":12},"end":{"file":"","line":0,"column":0},"message":"undefined: missing"}
+-: not JSON
+`
+	checks, errs := tools.parseStaticcheckOutput(output)
+	if len(checks) != 2 || checks[0].ID != "S1002" || checks[1].ID != "SA4006" || checks[1].Count != 2 {
+		t.Fatalf("unexpected checks %+v", checks)
+	}
+	if first := checks[1].Findings[0]; first.File != "main.go" || first.Line != 3 || first.EndLine != 0 {
+		t.Fatalf("expected findings sorted by position, got %+v", checks[1].Findings)
+	}
+	if second := checks[1].Findings[1]; second.EndLine != 9 || second.EndColumn != 5 {
+		t.Fatalf("unexpected end %+v", second)
+	}
+	if checks[0].Docs != "https://staticcheck.dev/docs/checks#S1002" {
+		t.Fatalf("unexpected docs %s", checks[0].Docs)
+	}
+	if len(errs) != 1 || errs[0].File != "broken/broken.go" || errs[0].Message != "undefined: missing" {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+}
+
+func TestStaticcheck(t *testing.T) {
+	origLookup := lookupStaticcheckBinary
+	t.Cleanup(func() { lookupStaticcheckBinary = origLookup })
+	lookupStaticcheckBinary = func(string) (string, error) { return "staticcheck", nil }
+	workspace := t.TempDir()
+	tools := NewLSPTools(nil, workspace)
+	var ran [][]string
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		ran = append(ran, spec.args)
+		if slices.Contains(spec.args, "-explain") {
+			return commandResult{Stdout: "Unused assignment\n\nThe assigned value is never read.\n\nAvailable since\n    2017.1\n\nOnline documentation\n    https://staticcheck.dev/docs/checks#SA4006\n"}, nil
+		}
+		return commandResult{Stdout: `{"code":"SA4006","severity":"error","location":{"file":"` + workspace + `/main.go","line":9,"column":2},"end":{"file":"","line":0,"column":0},"message":"this value of x is never used"}` + "\n"}, &exec.ExitError{}
+	}
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	result, err := server.GetTool("staticcheck").Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "staticcheck", Arguments: map[string]any{"target": "./cmd/...", "checks": []any{"SA*", "-SA1019"}}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("staticcheck: %v %v", err, result)
+	}
+	checked := structured(result)
+	checks := checked["checks"].([]any)
+	if checked["ok"] != false || checked["count"] != float64(1) || len(checks) != 1 {
+		t.Fatalf("unexpected result %v", checked)
+	}
+	if explanation := checks[0].(map[string]any)["explanation"]; explanation != "Unused assignment\n\nThe assigned value is never read." {
+		t.Fatalf("unexpected explanation %q", explanation)
+	}
+	if want := []string{"-f", "json", "-checks", "SA*,-SA1019", "./cmd/..."}; !slices.Equal(ran[0], want) {
+		t.Fatalf("unexpected arguments %v", ran[0])
+	}
+	if !strings.Contains(strings.Join(ran[1], " "), "-explain SA4006") {
+		t.Fatalf("unexpected explain arguments %v", ran[1])
+	}
+}
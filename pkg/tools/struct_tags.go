@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const modifyTagsCommand = "gopls.modify_tags"
+
+var tagTransforms = []string{"snakecase", "camelcase", "lispcase", "pascalcase", "titlecase", "keep"}
+
+func (t *LSPTools) registerModifyStructTags(s *server.MCPServer) {
+	tool := mcp.NewTool("modify_struct_tags",
+		mcp.WithDescription("Add, update or remove struct field tags such as json, yaml or db with the gopls modify_tags command, which implements gomodifytags: on every field of a struct named by struct, or on the fields within range. Added tag values are derived from the field names with transform; options such as omitempty are added or removed per tag. Returns the edit as a unified diff, and writes it when apply is true"),
+		mcp.WithTitleAnnotation("Modify Struct Tags"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the Go file declaring the struct"),
+		),
+		mcp.WithString("struct",
+			mcp.Description("Name of the struct type whose fields to change; required without range"),
+		),
+		mcp.WithObject("range",
+			mcp.Description("Only change the fields within this range, 0-based like the other tools: {\"start\": {\"line\": 10, \"character\": 0}, \"end\": {\"line\": 14, \"character\": 0}}"),
+		),
+		mcp.WithArray("add",
+			mcp.Description("Tag keys to add, such as [\"json\", \"yaml\"]"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("add_options",
+			mcp.Description("Options to add to tags, as key=option, such as [\"json=omitempty\"]"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("remove",
+			mcp.Description("Tag keys to remove"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("remove_options",
+			mcp.Description("Options to remove from tags, as key=option"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithBoolean("clear",
+			mcp.Description("Remove every tag of the fields (default false)"),
+		),
+		mcp.WithString("transform",
+			mcp.Description("How added tag values are derived from field names (default snakecase)"),
+			mcp.Enum(tagTransforms...),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace the value of tags the fields already have (default false)"),
+		),
+		mcp.WithBoolean("skip_unexported",
+			mcp.Description("Leave unexported fields alone (default false)"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the edit to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		name := getOptionalStringArg(args, "struct")
+		transform := getOptionalStringArg(args, "transform")
+		if transform != "" && !slices.Contains(tagTransforms, transform) {
+			return mcp.NewToolResultError(fmt.Sprintf("transform must be one of %s", strings.Join(tagTransforms, ", "))), nil
+		}
+		list := func(key string) (string, error) {
+			raw, ok := args[key].([]any)
+			if !ok {
+				return "", nil
+			}
+			var items []string
+			for _, item := range raw {
+				text, ok := item.(string)
+				if !ok || strings.TrimSpace(text) == "" {
+					return "", fmt.Errorf("%s must be an array of strings", key)
+				}
+				items = append(items, strings.TrimSpace(text))
+			}
+			return strings.Join(items, ","), nil
+		}
+		command := map[string]any{
+			"URI":                  fileURI,
+			"Clear":                getOptionalBoolArg(args, "clear"),
+			"Overwrite":            getOptionalBoolArg(args, "overwrite"),
+			"SkipUnexportedFields": getOptionalBoolArg(args, "skip_unexported"),
+			"Transform":            transform,
+		}
+		for key, field := range map[string]string{"add": "Add", "add_options": "AddOptions", "remove": "Remove", "remove_options": "RemoveOptions"} {
+			if command[field], err = list(key); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if command["Add"] == "" && command["AddOptions"] == "" && command["Remove"] == "" && command["RemoveOptions"] == "" && command["Clear"] == false {
+			return mcp.NewToolResultError("nothing to do: set add, add_options, remove, remove_options or clear"), nil
+		}
+
+		switch _, hasRange := args["range"]; {
+		case hasRange:
+			rng, err := parseRangeArg(args, "range")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			command["Range"] = rng
+		case name != "":
+			rng, problem := structTypeRange(convertURIToPath(fileURI), name)
+			if problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+			command["Range"] = rng
+		default:
+			return mcp.NewToolResultError("set struct or range"), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		if advertised := lspClient.Commands(); len(advertised) > 0 && !slices.Contains(advertised, modifyTagsCommand) {
+			return mcp.NewToolResultError(fmt.Sprintf("gopls does not advertise %s; it needs gopls v0.18 or later", modifyTagsCommand)), nil
+		}
+		edits, err := lspClient.ExecuteCommand(ctx, protocol.Command{Command: modifyTagsCommand, Arguments: []any{command}})
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		changes, err := workspaceEditChanges(mergeWorkspaceEdits(edits))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compute tag diff: %v", err)), nil
+		}
+		apply := getOptionalBoolArg(args, "apply") && len(changes) > 0
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply tags", err), nil
+			}
+		}
+
+		payload := map[string]any{
+			"file_uri": fileURI,
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
+		}
+		if len(changes) == 0 {
+			payload["message"] = "the tags already match; nothing to change"
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// structTypeRange returns the range of the struct type named name in the
+// file, which may be declared in a function. problem explains why there is
+// none or several.
+func structTypeRange(path, name string) (protocol.Range, string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return protocol.Range{}, fmt.Sprintf("read %s: %v", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if file == nil {
+		return protocol.Range{}, fmt.Sprintf("parse %s: %v", path, err)
+	}
+	var found []*ast.StructType
+	ast.Inspect(file, func(node ast.Node) bool {
+		if spec, ok := node.(*ast.TypeSpec); ok && spec.Name.Name == name {
+			if structType, ok := spec.Type.(*ast.StructType); ok {
+				found = append(found, structType)
+			}
+		}
+		return true
+	})
+	switch len(found) {
+	case 0:
+		return protocol.Range{}, fmt.Sprintf("no struct type named %s in the file", name)
+	case 1:
+		return protocol.Range{Start: lspPosition(fset, src, found[0].Pos()), End: lspPosition(fset, src, found[0].End())}, ""
+	}
+	var lines []string
+	for _, structType := range found {
+		lines = append(lines, fmt.Sprint(fset.Position(structType.Pos()).Line))
+	}
+	return protocol.Range{}, fmt.Sprintf("%d struct types are named %s, at lines %s; pass the range of one instead", len(found), name, strings.Join(lines, ", "))
+}
+
+// lspPosition converts a position of the parsed src to an LSP position,
+// whose character counts UTF-16 code units.
+func lspPosition(fset *token.FileSet, src []byte, pos token.Pos) protocol.Position {
+	position := fset.Position(pos)
+	lineStart := bytes.LastIndexByte(src[:position.Offset], '\n') + 1
+	return protocol.Position{Line: position.Line - 1, Character: len(utf16.Encode([]rune(string(src[lineStart:position.Offset]))))}
+}
@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// assertionFailure is a failed testify or go-cmp assertion recovered from
+// test output. Expected and Actual hold the rendered values, Diff the diff
+// as printed by the library.
+type assertionFailure struct {
+	Library   string `json:"library"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Assertion string `json:"assertion,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+	Actual    string `json:"actual,omitempty"`
+	Diff      string `json:"diff,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// Details holds the rest of the assertion error, such as the error
+	// value for require.NoError.
+	Details string `json:"details,omitempty"`
+}
+
+var (
+	testLogLinePattern = regexp.MustCompile(`^(\s*)([\w./-]+\.go):(\d+): ?(.*)$`)
+	cmpDiffHeader      = regexp.MustCompile(`\(-(\w+) \+(\w+)\)`)
+)
+
+// testLogEntry is one t.Log/t.Error call: the file:line header and the
+// lines indented below it.
+type testLogEntry struct {
+	file  string
+	line  int
+	text  string
+	lines []string
+}
+
+// parseAssertionFailures finds testify and go-cmp failures in the output of
+// a test. Other log lines are ignored.
+func parseAssertionFailures(output string) []assertionFailure {
+	var failures []assertionFailure
+	for _, entry := range splitTestLog(output) {
+		if failure, ok := parseTestifyFailure(entry); ok {
+			failures = append(failures, failure)
+		} else if failure, ok := parseCmpFailure(entry); ok {
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+func splitTestLog(output string) []testLogEntry {
+	var entries []testLogEntry
+	var current *testLogEntry
+	indent := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if match := testLogLinePattern.FindStringSubmatch(line); match != nil {
+			lineNumber, _ := strconv.Atoi(match[3])
+			entries = append(entries, testLogEntry{file: match[2], line: lineNumber, text: match[4]})
+			current = &entries[len(entries)-1]
+			// go test indents continuation lines four spaces past the header.
+			indent = match[1] + "    "
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if !strings.HasPrefix(line, indent) {
+			current = nil
+			continue
+		}
+		current.lines = append(current.lines, strings.TrimPrefix(line, indent))
+	}
+	return entries
+}
+
+// parseTestifyFailure reads the tab-aligned block testify prints:
+//
+//	Error Trace:	file.go:18
+//	Error:      	Not equal:
+//	            	expected: 1
+//	            	actual  : 2
+//	Messages:   	context
+func parseTestifyFailure(entry testLogEntry) (assertionFailure, bool) {
+	fields := make(map[string][]string)
+	label := ""
+	for _, line := range entry.lines {
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "\t"), "\t")
+		if !ok || !strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if name = strings.TrimSuffix(strings.TrimSpace(name), ":"); name != "" {
+			label = name
+		}
+		if label != "" {
+			fields[label] = append(fields[label], strings.TrimRight(value, " "))
+		}
+	}
+	errorLines, ok := fields["Error"]
+	if !ok || fields["Error Trace"] == nil {
+		return assertionFailure{}, false
+	}
+
+	failure := assertionFailure{
+		Library:   "testify",
+		File:      entry.file,
+		Line:      entry.line,
+		Assertion: strings.TrimSuffix(strings.TrimSpace(errorLines[0]), ":"),
+		Message:   strings.Join(fields["Messages"], "\n"),
+	}
+	var details, diff []string
+	inDiff := false
+	for _, line := range errorLines[1:] {
+		switch {
+		case inDiff:
+			diff = append(diff, line)
+		case line == "Diff:":
+			inDiff = true
+		case strings.HasPrefix(line, "expected: "):
+			failure.Expected = strings.TrimPrefix(line, "expected: ")
+		case strings.HasPrefix(line, "actual  : "):
+			failure.Actual = strings.TrimPrefix(line, "actual  : ")
+		case line != "":
+			details = append(details, line)
+		}
+	}
+	failure.Diff = strings.Join(diff, "\n")
+	failure.Details = strings.Join(details, "\n")
+	return failure, true
+}
+
+// parseCmpFailure recognizes a cmp.Diff report logged after a header such as
+// "mismatch (-want +got):". The removed lines of the diff make up the first
+// value of the header and the added lines the second.
+func parseCmpFailure(entry testLogEntry) (assertionFailure, bool) {
+	loc := cmpDiffHeader.FindStringSubmatchIndex(entry.text)
+	if loc == nil || len(entry.lines) == 0 {
+		return assertionFailure{}, false
+	}
+	removed := entry.text[loc[2]:loc[3]]
+
+	var removedSide, addedSide []string
+	for _, line := range entry.lines {
+		// cmp randomly separates the marker with a space or a
+		// non-breaking space to discourage depending on its output.
+		marker, rest := "", strings.ReplaceAll(line, "\u00a0", " ")
+		if len(rest) >= 2 && strings.ContainsRune("-+ ", rune(rest[0])) && rest[1] == ' ' {
+			marker, rest = rest[:1], rest[2:]
+		}
+		if marker != "+" {
+			removedSide = append(removedSide, rest)
+		}
+		if marker != "-" {
+			addedSide = append(addedSide, rest)
+		}
+	}
+	failure := assertionFailure{
+		Library:   "cmp",
+		File:      entry.file,
+		Line:      entry.line,
+		Assertion: strings.TrimSpace(entry.text[:loc[0]]),
+		Expected:  strings.Join(removedSide, "\n"),
+		Actual:    strings.Join(addedSide, "\n"),
+		Diff:      strings.ReplaceAll(strings.Join(entry.lines, "\n"), "\u00a0", " "),
+	}
+	if removed == "got" || removed == "actual" {
+		failure.Expected, failure.Actual = failure.Actual, failure.Expected
+	}
+	return failure, true
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAssertionFailures(t *testing.T) {
+	output := strings.Join([]string{
+		"=== RUN   TestLoad",
+		"    load_test.go:18: ",
+		"        \tError Trace:\t/app/load_test.go:18",
+		"        \tError:      \tNot equal: ",
+		"        \t            \texpected: app.user{Name:\"a\", Age:1}",
+		"        \t            \tactual  : app.user{Name:\"a\", Age:2}",
+		"        \t            \t",
+		"        \t            \tDiff:",
+		"        \t            \t--- Expected",
+		"        \t            \t+++ Actual",
+		"        \t            \t@@ -2,3 +2,3 @@",
+		"        \t            \t- Age: (int) 1",
+		"        \t            \t+ Age: (int) 2",
+		"        \tTest:       \tTestLoad",
+		"        \tMessages:   \tuser 7",
+		"    load_test.go:20: ",
+		"        \tError Trace:\t/app/load_test.go:20",
+		"        \tError:      \tReceived unexpected error:",
+		"        \t            \tboom",
+		"        \tTest:       \tTestLoad",
+		"    load_test.go:25: load() mismatch (-want +got):",
+		"          app.user{",
+		"        - \tName: \"a\",",
+		"        + \tName: \"b\",",
+		"          }",
+		"    load_test.go:27: after",
+		"    load_test.go:30: mismatch (-got +want):",
+		"        - 1",
+		"        + 2",
+		"--- FAIL: TestLoad (0.00s)",
+	}, "\n")
+
+	want := []assertionFailure{
+		{
+			Library:   "testify",
+			File:      "load_test.go",
+			Line:      18,
+			Assertion: "Not equal",
+			Expected:  `app.user{Name:"a", Age:1}`,
+			Actual:    `app.user{Name:"a", Age:2}`,
+			Diff:      "--- Expected\n+++ Actual\n@@ -2,3 +2,3 @@\n- Age: (int) 1\n+ Age: (int) 2",
+			Message:   "user 7",
+		},
+		{
+			Library:   "testify",
+			File:      "load_test.go",
+			Line:      20,
+			Assertion: "Received unexpected error",
+			Details:   "boom",
+		},
+		{
+			Library:   "cmp",
+			File:      "load_test.go",
+			Line:      25,
+			Assertion: "load() mismatch",
+			Expected:  "app.user{\n\tName: \"a\",\n}",
+			Actual:    "app.user{\n\tName: \"b\",\n}",
+			Diff:      "  app.user{\n- \tName: \"a\",\n+ \tName: \"b\",\n  }",
+		},
+		{
+			Library:   "cmp",
+			File:      "load_test.go",
+			Line:      30,
+			Assertion: "mismatch",
+			Expected:  "2",
+			Actual:    "1",
+			Diff:      "- 1\n+ 2",
+		},
+	}
+	if got := parseAssertionFailures(output); !reflect.DeepEqual(got, want) {
+		t.Fatalf("failures =\n%#v\nwant\n%#v", got, want)
+	}
+	// cmp sometimes separates its markers with non-breaking spaces.
+	nbsp := strings.NewReplacer("          app", "        \u00a0\u00a0app", "- \t", "-\u00a0\t", "+ \t", "+\u00a0\t").Replace(output)
+	if got := parseAssertionFailures(nbsp); !reflect.DeepEqual(got[2], want[2]) {
+		t.Fatalf("non-breaking spaces: got %#v, want %#v", got[2], want[2])
+	}
+	if got := parseAssertionFailures("    store_test.go:9: connection refused\n"); got != nil {
+		t.Fatalf("plain log lines are not assertions, got %#v", got)
+	}
+}
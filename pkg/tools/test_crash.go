@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// testCrash describes a test binary that died instead of reporting its
+// results: a panic, a runtime fatal error, a -timeout alarm, a signal such as
+// the OOM killer's SIGKILL, or an os.Exit call.
+type testCrash struct {
+	Package string `json:"package,omitempty"`
+	Test    string `json:"test,omitempty"`
+	// Kind is panic, fatal_error, timeout, signal or exit.
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	Signal   string `json:"signal,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	// Site is the innermost stack frame in the workspace, which is usually
+	// where to start looking.
+	Site   *stackFrame  `json:"site,omitempty"`
+	Frames []stackFrame `json:"frames,omitempty"`
+	Stack  string       `json:"stack,omitempty"`
+}
+
+type stackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Workspace frames have File relative to the workspace and the source
+	// line they point at.
+	Workspace bool   `json:"workspace,omitempty"`
+	Source    string `json:"source,omitempty"`
+}
+
+var (
+	exitStatusPattern = regexp.MustCompile(`^exit status (\d+)$`)
+	stackFilePattern  = regexp.MustCompile(`^\t(.+?):(\d+)(?: \+0x[0-9a-f]+)?(?: fp=\S+ sp=\S+ pc=\S+)?$`)
+)
+
+// parseTestCrashes finds crashed packages in plain go test output. The
+// output of each package ends with its ok/FAIL summary line, which names the
+// package.
+func parseTestCrashes(output string) []testCrash {
+	var crashes []testCrash
+	var chunk []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "FAIL\t") || strings.HasPrefix(line, "ok  \t") || strings.HasPrefix(line, "?   \t") {
+			if fields := strings.Fields(line); fields[0] == "FAIL" && len(fields) >= 2 {
+				if crash := detectTestCrash(chunk); crash != nil {
+					crash.Package = fields[1]
+					crashes = append(crashes, *crash)
+				}
+			}
+			chunk = nil
+			continue
+		}
+		chunk = append(chunk, line)
+	}
+	return crashes
+}
+
+// detectTestCrash looks for the output of a test binary dying in the lines
+// printed by one package's tests. It returns nil for ordinary test failures.
+func detectTestCrash(lines []string) *testCrash {
+	for i, line := range lines {
+		var crash *testCrash
+		switch {
+		case strings.HasPrefix(line, "panic: test timed out after "):
+			crash = &testCrash{Kind: "timeout", Message: strings.TrimPrefix(line, "panic: ")}
+			if i+2 < len(lines) && strings.TrimSpace(lines[i+1]) == "running tests:" {
+				crash.Test, _, _ = strings.Cut(strings.TrimSpace(lines[i+2]), " ")
+			}
+		case strings.HasPrefix(line, "panic: "):
+			message := strings.TrimPrefix(line, "panic: ")
+			if idx := strings.Index(message, " [recovered"); idx >= 0 {
+				message = message[:idx]
+			}
+			crash = &testCrash{Kind: "panic", Message: message}
+		case strings.HasPrefix(line, "fatal error: "):
+			crash = &testCrash{Kind: "fatal_error", Message: strings.TrimPrefix(line, "fatal error: ")}
+		case line == "signal: killed":
+			crash = &testCrash{Kind: "signal", Signal: "SIGKILL", Message: "test binary was killed, usually by the kernel OOM killer or an external timeout"}
+		case strings.HasPrefix(line, "signal: "):
+			crash = &testCrash{Kind: "signal", Message: "test binary terminated by " + strings.TrimPrefix(line, "signal: ")}
+		default:
+			// go test reports the exit status only when the binary did not
+			// exit through the testing package, as os.Exit or log.Fatal do.
+			if match := exitStatusPattern.FindStringSubmatch(line); match != nil && (i == 0 || lines[i-1] != "FAIL") {
+				code, _ := strconv.Atoi(match[1])
+				crash = &testCrash{Kind: "exit", ExitCode: code, Message: "test binary exited with status " + match[1]}
+			}
+		}
+		if crash == nil {
+			continue
+		}
+		crash.attachStack(lines[i+1:])
+		return crash
+	}
+	return nil
+}
+
+// attachStack records the goroutine that crashed from the dump following
+// the crash message. For timeouts that is the goroutine running the test
+// rather than the alarm that fired.
+func (c *testCrash) attachStack(lines []string) {
+	var blocks [][]string
+	var block []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "[signal ") && c.Signal == "" {
+			c.Signal, _, _ = strings.Cut(strings.TrimPrefix(line, "[signal "), ":")
+		}
+		switch {
+		case strings.HasPrefix(line, "goroutine ") && strings.HasSuffix(line, ":"):
+			block = []string{line}
+		case block != nil && line == "":
+			blocks = append(blocks, block)
+			block = nil
+		case block != nil:
+			block = append(block, line)
+		}
+	}
+	if block != nil {
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 0 {
+		return
+	}
+
+	chosen := blocks[0]
+	if c.Kind == "timeout" && c.Test != "" {
+		// A test with subtests has one goroutine waiting in t.Run and one
+		// per subtest; the subtest's is the one that is stuck.
+		name, _, _ := strings.Cut(c.Test, "/")
+		waiting := true
+		for _, candidate := range blocks {
+			frames := parseStackFrames(candidate)
+			if !slices.ContainsFunc(frames, func(frame stackFrame) bool {
+				return strings.HasSuffix(frame.Function, "."+name) || strings.Contains(frame.Function, "."+name+".")
+			}) {
+				continue
+			}
+			inRun := slices.ContainsFunc(frames, func(frame stackFrame) bool { return frame.Function == "testing.(*T).Run" })
+			if waiting {
+				chosen, waiting = candidate, inRun
+			}
+		}
+	}
+	c.Stack = strings.Join(chosen, "\n")
+	c.Frames = parseStackFrames(chosen)
+	if c.Test == "" {
+		c.Test = stackTestName(c.Frames)
+	}
+}
+
+// parseStackFrames reads the function/file:line pairs of a goroutine dump,
+// skipping the goroutine header and the "created by" trailer.
+func parseStackFrames(block []string) []stackFrame {
+	var frames []stackFrame
+	for i := 1; i+1 < len(block); i++ {
+		match := stackFilePattern.FindStringSubmatch(block[i+1])
+		if match == nil || strings.HasPrefix(block[i], "\t") {
+			continue
+		}
+		if strings.HasPrefix(block[i], "created by ") {
+			break
+		}
+		line, _ := strconv.Atoi(match[2])
+		frames = append(frames, stackFrame{Function: stackFunction(block[i]), File: match[1], Line: line})
+		i++
+	}
+	return frames
+}
+
+// stackTestName finds the test function in a stack: the outermost frame of
+// a _test.go file whose function is TestXxx or a closure inside it.
+func stackTestName(frames []stackFrame) string {
+	for i := len(frames) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(frames[i].File, "_test.go") {
+			continue
+		}
+		function := frames[i].Function
+		if idx := strings.LastIndex(function, "/"); idx >= 0 {
+			function = function[idx+1:]
+		}
+		parts := strings.Split(function, ".")
+		for _, part := range parts[1:] {
+			if strings.HasPrefix(part, "Test") {
+				return part
+			}
+		}
+	}
+	return ""
+}
+
+// symbolicate resolves the frames that point into the workspace: their
+// files become workspace-relative and carry the source line, and the
+// innermost one becomes the crash site.
+func (c *testCrash) symbolicate(workspaceDir string) {
+	root, err := filepath.Abs(workspaceDir)
+	if err != nil {
+		return
+	}
+	sources := make(map[string][]string)
+	for i := range c.Frames {
+		frame := &c.Frames[i]
+		rel, err := filepath.Rel(root, filepath.FromSlash(frame.File))
+		if err != nil || !filepath.IsAbs(filepath.FromSlash(frame.File)) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		lines, ok := sources[frame.File]
+		if !ok {
+			if data, err := os.ReadFile(frame.File); err == nil {
+				lines = strings.Split(string(data), "\n")
+			}
+			sources[frame.File] = lines
+		}
+		frame.File = filepath.ToSlash(rel)
+		frame.Workspace = true
+		if frame.Line >= 1 && frame.Line <= len(lines) {
+			frame.Source = strings.TrimSpace(lines[frame.Line-1])
+		}
+		if c.Site == nil {
+			site := *frame
+			c.Site = &site
+		}
+	}
+}
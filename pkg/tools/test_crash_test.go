@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTestCrashesSymbolicatesPanic(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "crash/crash_test.go", "package crash\n\ntype T struct{ p *int }\n\nfunc deref(t *T) int { return *t.p }\n")
+	file := filepath.ToSlash(filepath.Join(workspace, "crash", "crash_test.go"))
+	output := strings.Join([]string{
+		"--- FAIL: TestPanic (0.00s)",
+		"panic: runtime error: invalid memory address or nil pointer dereference [recovered, repanicked]",
+		"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x54347b]",
+		"",
+		"goroutine 7 [running]:",
+		"testing.tRunner.func1.2({0x6b6f60, 0x6ef110})",
+		"\t/usr/local/go/src/testing/testing.go:2123 +0x232",
+		"panic({0x6b6f60?, 0x6ef110?})",
+		"\t/usr/local/go/src/runtime/panic.go:859 +0x125",
+		"example.com/crash.deref(...)",
+		"\t" + file + ":5",
+		"example.com/crash.TestPanic(0x1937ea0d2488?)",
+		"\t" + file + ":18 +0x3b",
+		"testing.tRunner(0x1937ea0d2488, 0x6d49b0)",
+		"\t/usr/local/go/src/testing/testing.go:2193 +0xea",
+		"created by testing.(*T).Run in goroutine 1",
+		"\t/usr/local/go/src/testing/testing.go:2258 +0x4d4",
+		"FAIL\texample.com/crash\t0.005s",
+		"ok  \texample.com/other\t0.002s",
+		"exit status 3",
+		"FAIL\texample.com/exits\t0.002s",
+		"--- FAIL: TestNormal (0.00s)",
+		"    normal_test.go:5: x",
+		"FAIL",
+		"FAIL\texample.com/normal\t0.003s",
+		"FAIL",
+	}, "\n")
+
+	crashes := parseTestCrashes(output)
+	if len(crashes) != 2 {
+		t.Fatalf("expected crashes for the panicking and exiting packages, got %+v", crashes)
+	}
+	crash := crashes[0]
+	crash.symbolicate(workspace)
+	if crash.Package != "example.com/crash" || crash.Test != "TestPanic" || crash.Kind != "panic" || crash.Signal != "SIGSEGV" {
+		t.Fatalf("unexpected crash %+v", crash)
+	}
+	if crash.Message != "runtime error: invalid memory address or nil pointer dereference" {
+		t.Fatalf("unexpected message %q", crash.Message)
+	}
+	want := stackFrame{Function: "example.com/crash.deref", File: "crash/crash_test.go", Line: 5, Workspace: true, Source: "func deref(t *T) int { return *t.p }"}
+	if crash.Site == nil || *crash.Site != want {
+		t.Fatalf("site = %+v, want %+v", crash.Site, want)
+	}
+	if len(crash.Frames) != 5 || crash.Frames[0].Workspace || crash.Frames[0].File != "/usr/local/go/src/testing/testing.go" {
+		t.Fatalf("unexpected frames %+v", crash.Frames)
+	}
+
+	if exit := crashes[1]; exit.Package != "example.com/exits" || exit.Kind != "exit" || exit.ExitCode != 3 {
+		t.Fatalf("unexpected exit crash %+v", exit)
+	}
+}
+
+func TestParseTestEventsCrashes(t *testing.T) {
+	event := func(pkg, test, action, output string) string {
+		data, _ := json.Marshal(testEvent{Action: action, Package: pkg, Test: test, Output: output})
+		return string(data)
+	}
+	lines := []string{
+		// -timeout fires while TestHang sleeps.
+		event("example.com/hang", "TestHang", "run", ""),
+		event("example.com/hang", "TestHang", "output", "panic: test timed out after 1s\n"),
+		event("example.com/hang", "TestHang", "output", "\trunning tests:\n"),
+		event("example.com/hang", "TestHang", "output", "\t\tTestHang (1s)\n"),
+		event("example.com/hang", "TestHang", "output", "\n"),
+		event("example.com/hang", "TestHang", "output", "goroutine 8 [running]:\n"),
+		event("example.com/hang", "TestHang", "output", "testing.(*M).startAlarm.func1()\n"),
+		event("example.com/hang", "TestHang", "output", "\t/usr/local/go/src/testing/testing.go:2959 +0x34a\n"),
+		event("example.com/hang", "TestHang", "output", "\n"),
+		event("example.com/hang", "TestHang", "output", "goroutine 7 [sleep]:\n"),
+		event("example.com/hang", "TestHang", "output", "time.Sleep(0x34630b8a000)\n"),
+		event("example.com/hang", "TestHang", "output", "\t/usr/local/go/src/runtime/time.go:363 +0x165\n"),
+		event("example.com/hang", "TestHang", "output", "example.com/hang.TestHang(0x1937ea0d2488?)\n"),
+		event("example.com/hang", "TestHang", "output", "\t/app/hang/hang_test.go:9 +0x1d\n"),
+		event("example.com/hang", "", "output", "FAIL\texample.com/hang\t1.005s\n"),
+		event("example.com/hang", "", "fail", ""),
+		// The OOM killer stops TestBig.
+		event("example.com/big", "TestOK", "run", ""),
+		event("example.com/big", "TestOK", "pass", ""),
+		event("example.com/big", "TestBig", "run", ""),
+		event("example.com/big", "TestBig", "output", "signal: killed\n"),
+		event("example.com/big", "", "fail", ""),
+		// TestExit calls os.Exit, which -json does not report.
+		event("example.com/exit", "TestExit", "run", ""),
+		event("example.com/exit", "", "fail", ""),
+	}
+
+	summary := parseTestEvents(strings.Join(lines, "\n"))
+	var got []string
+	for _, pkg := range summary.Packages {
+		crash := pkg.Crash
+		got = append(got, strings.Join([]string{crash.Package, crash.Test, crash.Kind, crash.Signal, crash.Message}, "|"))
+	}
+	want := []string{
+		"example.com/hang|TestHang|timeout||test timed out after 1s",
+		"example.com/big|TestBig|signal|SIGKILL|test binary was killed, usually by the kernel OOM killer or an external timeout",
+		"example.com/exit|TestExit|exit||test binary exited while tests were running",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("crashes = %#v, want %#v", got, want)
+	}
+	if hang := summary.Packages[0].Crash; !strings.HasPrefix(hang.Stack, "goroutine 7 [sleep]:") || hang.Frames[1].Function != "example.com/hang.TestHang" {
+		t.Fatalf("timeouts should report the test goroutine, got %+v", hang)
+	}
+	if summary.Passed != 1 || summary.Failed != 3 || len(summary.Failures) != 3 || summary.Failures[1].Output != "signal: killed\n" {
+		t.Fatalf("unfinished tests should count as failures, got %+v", summary)
+	}
+}
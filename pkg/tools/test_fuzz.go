@@ -0,0 +1,544 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	fuzzCorpusHeader = "go test fuzz v1"
+	// fuzzCandidateName is the corpus entry minimize_fuzz_input writes each
+	// candidate to while it runs.
+	fuzzCandidateName     = "mcp-gopls-minimize"
+	defaultFuzzBudget     = 60 * time.Second
+	fuzzCandidateTimeout  = 10 * time.Second
+	maxFuzzValueDisplayed = 200
+)
+
+var errFuzzBudget = errors.New("minimization budget exhausted")
+
+// fuzzTarget is a FuzzXxx function with its corpus: the entries committed
+// under testdata/fuzz, which go test runs as seeds, and the inputs the fuzzer
+// cached as interesting.
+type fuzzTarget struct {
+	Package  string            `json:"package"`
+	Name     string            `json:"name"`
+	Seeds    int               `json:"seeds_in_code"`
+	Location protocol.Location `json:"location"`
+	Corpus   []fuzzCorpusEntry `json:"corpus"`
+
+	dir string
+	// importPath is the package under test, which names the fuzz cache
+	// directory also for external test packages.
+	importPath string
+}
+
+type fuzzCorpusEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Source is testdata or cache.
+	Source string `json:"source"`
+	// Tracked tells whether a testdata entry is committed. go test -fuzz
+	// writes the failing inputs it finds there, so untracked entries are
+	// usually new failures.
+	Tracked *bool    `json:"tracked,omitempty"`
+	Size    int      `json:"size"`
+	Values  []string `json:"values,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func (t *LSPTools) registerListFuzzCorpus(s *server.MCPServer) {
+	tool := mcp.NewTool("list_fuzz_corpus",
+		mcp.WithDescription("List fuzz targets with their corpus: the f.Add seeds in code, the testdata/fuzz entries go test runs as seeds (flagging the untracked ones go test -fuzz wrote for new failures), and the inputs cached by the fuzzer, with the decoded values of each entry"),
+		mcp.WithTitleAnnotation("List Fuzz Corpus"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only list packages whose import path starts with this prefix")),
+		mcp.WithString("fuzz", mcp.Description("Only list this fuzz target")),
+		mcp.WithBoolean("include_cache", mcp.Description("Also list the inputs in the fuzz cache under GOCACHE (default true)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		args := request.GetArguments()
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		targets := collectFuzzTargets(ws, getOptionalStringArg(args, "package"), getOptionalStringArg(args, "fuzz"))
+
+		payload := map[string]any{}
+		var tracked map[string]bool
+		if listed, err := t.runCommand(ctx, s, token, "git", "ls-files"); err == nil {
+			tracked = make(map[string]bool)
+			for _, line := range splitLines(listed.Stdout) {
+				tracked[line] = true
+			}
+		}
+		cacheDir := ""
+		if include, ok := args["include_cache"].(bool); !ok || include {
+			if cacheDir, err = t.fuzzCacheDir(ctx, s, token); err != nil {
+				payload["cache_error"] = err.Error()
+			}
+		}
+
+		for i := range targets {
+			target := &targets[i]
+			for _, entry := range readFuzzCorpus(target.testdataDir(), "testdata") {
+				entry.Path = relativeSlashPath(t.workspaceDir, entry.Path)
+				if tracked != nil {
+					isTracked := tracked[entry.Path]
+					entry.Tracked = &isTracked
+				}
+				target.Corpus = append(target.Corpus, entry)
+			}
+			if cacheDir != "" {
+				target.Corpus = append(target.Corpus, readFuzzCorpus(target.cacheDir(cacheDir), "cache")...)
+			}
+			for j := range target.Corpus {
+				for k, value := range target.Corpus[j].Values {
+					if len(value) > maxFuzzValueDisplayed {
+						target.Corpus[j].Values[k] = value[:maxFuzzValueDisplayed] + "..."
+					}
+				}
+			}
+		}
+		payload["targets"] = targets
+		if cacheDir != "" {
+			payload["cache_dir"] = cacheDir
+		}
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func (t *LSPTools) registerMinimizeFuzzInput(s *server.MCPServer) {
+	tool := mcp.NewTool("minimize_fuzz_input",
+		mcp.WithDescription("Confirm that a fuzz corpus entry still fails by running it with go test -run=FuzzX/entry, shrink its []byte and string values while it keeps failing, and move the minimized input into the target's testdata/fuzz seed corpus so it runs as a regression test. Candidates are written to a temporary entry in the corpus directory while minimizing; the seed file itself is only written with apply"),
+		mcp.WithTitleAnnotation("Minimize Fuzz Input"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("fuzz", mcp.Required(), mcp.Description("Fuzz target, e.g. FuzzParse")),
+		mcp.WithString("input", mcp.Required(), mcp.Description("Corpus entry name, from testdata/fuzz or the fuzz cache")),
+		mcp.WithString("package", mcp.Description("Import path prefix of the package, needed when several packages have the target")),
+		mcp.WithString("name", mcp.Description("File name of the seed entry to write (default the input name)")),
+		mcp.WithString("budget", mcp.Description("How long to spend minimizing (Go duration, default 60s)")),
+		mcp.WithBoolean("apply", mcp.Description("Write the seed entry instead of returning a preview diff (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		args := request.GetArguments()
+		fuzzName, err := getStringArg(args, "fuzz")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		input, err := getStringArg(args, "input")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		name := getOptionalStringArg(args, "name")
+		if name == "" {
+			name = input
+		}
+		for _, entry := range []string{input, name} {
+			if !validCorpusEntryName(entry) {
+				return mcp.NewToolResultError(fmt.Sprintf("%q is not a corpus entry name", entry)), nil
+			}
+		}
+		budget, err := getOptionalDurationArg(args, "budget", defaultFuzzBudget)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		targets := collectFuzzTargets(ws, getOptionalStringArg(args, "package"), fuzzName)
+		switch {
+		case len(targets) == 0:
+			return mcp.NewToolResultError(fmt.Sprintf("no fuzz target %s found", fuzzName)), nil
+		case len(targets) > 1:
+			return mcp.NewToolResultError(fmt.Sprintf("%s is defined in several packages; pass package to pick one", fuzzName)), nil
+		}
+		target := targets[0]
+
+		source := "testdata"
+		inputPath := filepath.Join(target.testdataDir(), input)
+		original, err := os.ReadFile(inputPath)
+		if err != nil {
+			cacheDir, cacheErr := t.fuzzCacheDir(ctx, s, token)
+			if cacheErr != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("%s is not in testdata/fuzz/%s and the fuzz cache is unavailable: %v", input, fuzzName, cacheErr)), nil
+			}
+			source, inputPath = "cache", filepath.Join(target.cacheDir(cacheDir), input)
+			if original, err = os.ReadFile(inputPath); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("no corpus entry %s for %s in testdata/fuzz or the fuzz cache", input, fuzzName)), nil
+			}
+		}
+		values, err := parseFuzzCorpusFile(original)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s: %v", input, err)), nil
+		}
+
+		tmpDir, err := os.MkdirTemp("", "mcp-gopls-fuzz-*")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+		binary := filepath.Join(tmpDir, "fuzz.test")
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Building test binary for %s", target.Package))
+		built, err := t.runCommand(ctx, s, token, "go", "test", "-c", "-o", binary, target.importPath)
+		if err != nil {
+			return t.commandFailureResult("go test -c", built, err)
+		}
+
+		corpusDir := target.testdataDir()
+		cleanup, err := ensureDir(corpusDir)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		candidatePath := filepath.Join(corpusDir, fuzzCandidateName)
+		defer os.Remove(candidatePath)
+
+		deadline := time.Now().Add(budget)
+		var failure string
+		fails := func(candidate []string) (bool, error) {
+			if time.Now().After(deadline) {
+				return false, errFuzzBudget
+			}
+			if err := os.WriteFile(candidatePath, formatFuzzCorpusFile(candidate), 0o644); err != nil {
+				return false, err
+			}
+			result, runErr := t.runCommandSpec(ctx, s, token, commandSpec{
+				name:      binary,
+				args:      []string{"-test.v", "-test.count=1", "-test.run", "^" + fuzzName + "$/^" + fuzzCandidateName + "$"},
+				dir:       target.dir,
+				quitAfter: fuzzCandidateTimeout,
+			})
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			if !strings.Contains(result.Stdout, "=== RUN   "+fuzzName+"/"+fuzzCandidateName) {
+				return false, fmt.Errorf("%s", buildCommandErrorMessage("the test binary did not run the corpus entry", result, runErr))
+			}
+			// A candidate that hangs is a different problem from the one
+			// being minimized.
+			hung := strings.Contains(result.Stderr, "SIGQUIT: quit")
+			if runErr == nil || hung {
+				return false, nil
+			}
+			if failure == "" {
+				failure = strings.TrimSpace(result.Stdout + "\n" + result.Stderr)
+			}
+			return true, nil
+		}
+
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Reproducing %s/%s", fuzzName, input))
+		reproduces, err := fails(values)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if !reproduces {
+			return mcp.NewToolResultError(fmt.Sprintf("%s passes %s; only inputs that still fail are minimized and moved into the seed corpus", input, fuzzName)), nil
+		}
+
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Minimizing %s/%s for up to %s", fuzzName, input, budget))
+		minimized, runs, err := minimizeFuzzValues(values, fails)
+		exhausted := errors.Is(err, errFuzzBudget)
+		if err != nil && !exhausted {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		content := formatFuzzCorpusFile(minimized)
+		destPath := filepath.Join(corpusDir, name)
+		var changes []fileChange
+		switch {
+		case source == "testdata" && name == input:
+			changes = append(changes, fileChange{path: destPath, before: original, after: content})
+		default:
+			changes = append(changes, fileChange{path: destPath, after: content, created: true})
+			if source == "testdata" {
+				changes = append(changes, fileChange{path: inputPath, before: original, deleted: true})
+			}
+		}
+
+		applied := false
+		if getOptionalBoolArg(args, "apply") {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("write the seed corpus entry", err), nil
+			}
+			applied = true
+		}
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"package":          target.Package,
+			"fuzz":             fuzzName,
+			"input":            input,
+			"source":           source,
+			"failure":          failure,
+			"original_size":    fuzzValuesSize(values),
+			"minimized_size":   fuzzValuesSize(minimized),
+			"values":           minimized,
+			"runs":             runs,
+			"budget_exhausted": exhausted,
+			"run_pattern":      "^" + fuzzName + "$/^" + name + "$",
+			"files":            t.summarizeFileChanges(changes),
+			"applied":          applied,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// collectFuzzTargets finds the FuzzXxx functions of the packages whose import
+// path starts with prefix, optionally only the one called name.
+func collectFuzzTargets(ws *gosrc.Workspace, prefix, name string) []fuzzTarget {
+	var targets []fuzzTarget
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if !file.Test {
+				continue
+			}
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || fn.Body == nil || (name != "" && fn.Name.Name != name) {
+					continue
+				}
+				if kind, _, ok := testFuncKind(fn); !ok || kind != "fuzz" {
+					continue
+				}
+				targets = append(targets, fuzzTarget{
+					Package:    pkg.ImportPath,
+					Name:       fn.Name.Name,
+					Seeds:      countFuzzSeeds(fn),
+					Location:   sourceLocation(file, fn.Name.Pos(), fn.Name.End()),
+					Corpus:     []fuzzCorpusEntry{},
+					dir:        pkg.Dir,
+					importPath: strings.TrimSuffix(pkg.ImportPath, "_test"),
+				})
+			}
+		}
+	}
+	return targets
+}
+
+// countFuzzSeeds counts the f.Add calls on the fuzz function's *testing.F.
+func countFuzzSeeds(fn *ast.FuncDecl) int {
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) != 1 {
+		return 0
+	}
+	receiver := params[0].Names[0].Name
+	count := 0
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Add" {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == receiver {
+				count++
+			}
+		}
+		return true
+	})
+	return count
+}
+
+func (f fuzzTarget) testdataDir() string {
+	return filepath.Join(f.dir, "testdata", "fuzz", f.Name)
+}
+
+func (f fuzzTarget) cacheDir(root string) string {
+	return filepath.Join(root, "fuzz", filepath.FromSlash(f.importPath), f.Name)
+}
+
+func (t *LSPTools) fuzzCacheDir(ctx context.Context, s *server.MCPServer, token mcp.ProgressToken) (string, error) {
+	result, err := t.runCommand(ctx, s, token, "go", "env", "GOCACHE")
+	if err != nil {
+		return "", fmt.Errorf("%s", buildCommandErrorMessage("go env GOCACHE", result, err))
+	}
+	dir := strings.TrimSpace(result.Stdout)
+	if dir == "" || dir == "off" {
+		return "", fmt.Errorf("the build cache is disabled")
+	}
+	return dir, nil
+}
+
+// readFuzzCorpus reads the entries of one corpus directory. A missing
+// directory is an empty corpus.
+func readFuzzCorpus(dir, source string) []fuzzCorpusEntry {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var entries []fuzzCorpusEntry
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || dirEntry.Name() == fuzzCandidateName {
+			continue
+		}
+		path := filepath.Join(dir, dirEntry.Name())
+		entry := fuzzCorpusEntry{Name: dirEntry.Name(), Path: path, Source: source}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.Size = len(data)
+			if entry.Values, err = parseFuzzCorpusFile(data); err != nil {
+				entry.Error = err.Error()
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// parseFuzzCorpusFile returns the values of a corpus file, one Go expression
+// per fuzz argument, such as []byte("abc") or int(5).
+func parseFuzzCorpusFile(data []byte) ([]string, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if strings.TrimSpace(lines[0]) != fuzzCorpusHeader {
+		return nil, fmt.Errorf("not a %q corpus file", fuzzCorpusHeader)
+	}
+	var values []string
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return values, nil
+}
+
+func formatFuzzCorpusFile(values []string) []byte {
+	return []byte(fuzzCorpusHeader + "\n" + strings.Join(values, "\n") + "\n")
+}
+
+// decodeFuzzBytes decodes a []byte or string corpus value, returning the
+// conversion it is wrapped in.
+func decodeFuzzBytes(value string) (string, []byte, bool) {
+	for _, conversion := range []string{"[]byte(", "string("} {
+		if strings.HasPrefix(value, conversion) && strings.HasSuffix(value, ")") {
+			decoded, err := strconv.Unquote(value[len(conversion) : len(value)-1])
+			if err != nil {
+				return "", nil, false
+			}
+			return conversion, []byte(decoded), true
+		}
+	}
+	return "", nil, false
+}
+
+func encodeFuzzBytes(conversion string, data []byte) string {
+	return conversion + strconv.Quote(string(data)) + ")"
+}
+
+// minimizeFuzzValues shrinks the []byte and string values of a failing input
+// while fails still reports a failure, trying the empty value first and then
+// removing ever smaller chunks. Other values are kept. It returns the
+// smallest failing input found and the number of runs; when fails returns an
+// error the input found so far is returned with it.
+func minimizeFuzzValues(values []string, fails func([]string) (bool, error)) ([]string, int, error) {
+	values = slices.Clone(values)
+	runs := 0
+	try := func(i int, candidate string) (bool, error) {
+		trial := slices.Clone(values)
+		trial[i] = candidate
+		runs++
+		ok, err := fails(trial)
+		if err != nil || !ok {
+			return false, err
+		}
+		values = trial
+		return true, nil
+	}
+
+	for i := range values {
+		conversion, data, ok := decodeFuzzBytes(values[i])
+		if !ok || len(data) == 0 {
+			continue
+		}
+		if ok, err := try(i, encodeFuzzBytes(conversion, nil)); err != nil {
+			return values, runs, err
+		} else if ok {
+			continue
+		}
+		for chunk := len(data) / 2; chunk >= 1; chunk /= 2 {
+			for start := 0; start+chunk <= len(data); {
+				candidate := slices.Concat(data[:start], data[start+chunk:])
+				ok, err := try(i, encodeFuzzBytes(conversion, candidate))
+				if err != nil {
+					return values, runs, err
+				}
+				if ok {
+					data = candidate
+				} else {
+					start += chunk
+				}
+			}
+		}
+	}
+	return values, runs, nil
+}
+
+func fuzzValuesSize(values []string) int {
+	size := 0
+	for _, value := range values {
+		if _, data, ok := decodeFuzzBytes(value); ok {
+			size += len(data)
+		}
+	}
+	return size
+}
+
+func validCorpusEntryName(name string) bool {
+	return name != "" && name != "." && name != ".." && name != fuzzCandidateName && !strings.ContainsAny(name, `/\`)
+}
+
+// ensureDir creates dir if needed. The returned cleanup removes what was
+// created, provided it is empty again.
+func ensureDir(dir string) (func(), error) {
+	missing := ""
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(current); err == nil {
+			break
+		}
+		missing = current
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return func() {
+		for current := dir; missing != ""; current = filepath.Dir(current) {
+			if os.Remove(current) != nil || current == missing {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCollectFuzzTargetsAndCorpus(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "parse/parse_test.go", `package parse_test
+
+import "testing"
+
+func FuzzParse(f *testing.F) {
+	f.Add([]byte("a"), 1)
+	for _, seed := range []string{"b", "c"} {
+		f.Add([]byte(seed), 2)
+	}
+	f.Fuzz(func(t *testing.T, data []byte, n int) {})
+}
+
+func TestParse(t *testing.T) {}
+`)
+	writeWorkspaceFile(t, workspace, "parse/testdata/fuzz/FuzzParse/seed1", "go test fuzz v1\n[]byte(\"x\\x00\")\nint(-3)\n")
+	writeWorkspaceFile(t, workspace, "parse/testdata/fuzz/FuzzParse/broken", "not a corpus file\n")
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	targets := collectFuzzTargets(ws, "", "")
+	if len(targets) != 1 {
+		t.Fatalf("expected one fuzz target, got %+v", targets)
+	}
+	target := targets[0]
+	if target.Name != "FuzzParse" || target.Seeds != 2 || target.importPath != "example.com/app/parse" {
+		t.Fatalf("unexpected target %+v", target)
+	}
+	if got := target.cacheDir("/cache"); got != filepath.Join("/cache", "fuzz", "example.com", "app", "parse", "FuzzParse") {
+		t.Fatalf("cache dir = %s", got)
+	}
+
+	entries := readFuzzCorpus(target.testdataDir(), "testdata")
+	if len(entries) != 2 {
+		t.Fatalf("expected two entries, got %+v", entries)
+	}
+	if entries[0].Name != "broken" || entries[0].Error == "" {
+		t.Fatalf("expected the broken entry to report an error, got %+v", entries[0])
+	}
+	if entries[1].Name != "seed1" || !reflect.DeepEqual(entries[1].Values, []string{`[]byte("x\x00")`, "int(-3)"}) {
+		t.Fatalf("unexpected entry %+v", entries[1])
+	}
+}
+
+func TestMinimizeFuzzValues(t *testing.T) {
+	values := []string{`[]byte("xxBADyyy\x00")`, "int(7)", `string("keep")`}
+	fails := func(candidate []string) (bool, error) {
+		_, data, _ := decodeFuzzBytes(candidate[0])
+		_, text, _ := decodeFuzzBytes(candidate[2])
+		return strings.Contains(string(data), "BAD") && len(text) > 0, nil
+	}
+	minimized, runs, err := minimizeFuzzValues(values, fails)
+	if err != nil {
+		t.Fatalf("minimize: %v", err)
+	}
+	want := []string{`[]byte("BAD")`, "int(7)", `string("p")`}
+	if !reflect.DeepEqual(minimized, want) {
+		t.Fatalf("minimized = %#v, want %#v", minimized, want)
+	}
+	if runs == 0 || fuzzValuesSize(values) != 13 || fuzzValuesSize(minimized) != 4 {
+		t.Fatalf("runs = %d, sizes %d -> %d", runs, fuzzValuesSize(values), fuzzValuesSize(minimized))
+	}
+
+	calls := 0
+	limited := func(candidate []string) (bool, error) {
+		if calls++; calls > 2 {
+			return false, errFuzzBudget
+		}
+		return true, nil
+	}
+	minimized, _, err = minimizeFuzzValues([]string{`[]byte("abcd")`, `[]byte("efgh")`, `[]byte("ijkl")`}, limited)
+	if err != errFuzzBudget || !reflect.DeepEqual(minimized, []string{`[]byte("")`, `[]byte("")`, `[]byte("ijkl")`}) {
+		t.Fatalf("expected the progress made before the budget ran out, got %v %v", minimized, err)
+	}
+}
+
+func TestEnsureDirCleanup(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "testdata", "fuzz", "FuzzX")
+	cleanup, err := ensureDir(dir)
+	if err != nil {
+		t.Fatalf("ensureDir: %v", err)
+	}
+	cleanup()
+	if _, err := os.Stat(filepath.Join(root, "testdata")); !os.IsNotExist(err) {
+		t.Fatalf("expected the created directories to be removed, got %v", err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Fatalf("the existing root was removed: %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultHangTimeout = 60 * time.Second
+	maxHangRuns        = 8
+)
+
+// hangRun is one run of the test binary while looking for a hanging test.
+// Running lists the tests that had started and not finished when the run was
+// stopped.
+type hangRun struct {
+	Run      string   `json:"run"`
+	Hung     bool     `json:"hung"`
+	Running  []string `json:"running,omitempty"`
+	Duration string   `json:"duration"`
+	dump     string
+}
+
+func (t *LSPTools) registerFindHangingTest(s *server.MCPServer) {
+	tool := mcp.NewTool("find_hanging_test",
+		mcp.WithDescription("Find the test that hangs in a package: build its test binary, run it verbosely with a per-run timeout, stop it with SIGQUIT when the timeout expires and read which tests were still running, bisecting with -run when several were, then return the hanging test and its goroutine dump"),
+		mcp.WithTitleAnnotation("Find Hanging Test"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package",
+			mcp.Required(),
+			mcp.Description("Package whose tests hang, e.g. ./internal/store"),
+		),
+		mcp.WithString("run",
+			mcp.Description("Only consider tests matching this regular expression (go test -run)"),
+		),
+		mcp.WithString("timeout",
+			mcp.Description("How long a run may take before it counts as hung (Go duration, default 60s)"),
+		),
+		mcp.WithString("tags",
+			mcp.Description("Comma-separated build tags"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		args := request.GetArguments()
+		target, err := getStringArg(args, "package")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		timeout, err := getOptionalDurationArg(args, "timeout", defaultHangTimeout)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		run := getOptionalStringArg(args, "run")
+		if run != "" {
+			if _, err := regexp.Compile(run); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid run pattern: %v", err)), nil
+			}
+		}
+		var tagArgs []string
+		if tags := getOptionalStringArg(args, "tags"); tags != "" {
+			tagArgs = []string{"-tags", tags}
+		}
+
+		listArgs := append(append([]string{"list"}, tagArgs...), "-f", "{{.ImportPath}}\t{{.Dir}}", target)
+		listed, err := t.runCommand(ctx, s, token, "go", listArgs...)
+		if err != nil {
+			return t.commandFailureResult("go list", listed, err)
+		}
+		lines := splitLines(listed.Stdout)
+		if len(lines) != 1 {
+			return mcp.NewToolResultError(fmt.Sprintf("package must match exactly one package, %q matches %d", target, len(lines))), nil
+		}
+		importPath, dir, _ := strings.Cut(lines[0], "\t")
+
+		tmpDir, err := os.MkdirTemp("", "mcp-gopls-hang-*")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+		binary := filepath.Join(tmpDir, "hang.test")
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Building test binary for %s", importPath))
+		buildArgs := append(append([]string{"test", "-c", "-o", binary}, tagArgs...), target)
+		built, err := t.runCommand(ctx, s, token, "go", buildArgs...)
+		if err != nil {
+			return t.commandFailureResult("go test -c", built, err)
+		}
+		if _, err := os.Stat(binary); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s has no tests", importPath)), nil
+		}
+
+		probe := func(run string) (hangRun, error) {
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Running %s -run %q for up to %s", importPath, run, timeout))
+			testArgs := []string{"-test.v", "-test.count=1", "-test.timeout=0"}
+			if run != "" {
+				testArgs = append(testArgs, "-test.run", run)
+			}
+			result, err := t.runCommandSpec(ctx, s, token, commandSpec{
+				name:      binary,
+				args:      testArgs,
+				dir:       dir,
+				quitAfter: timeout,
+			})
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return hangRun{}, ctxErr
+			}
+			hung := strings.Contains(result.Stderr, "SIGQUIT: quit")
+			if err != nil && !hung && result.Stdout == "" {
+				return hangRun{}, fmt.Errorf("%s", buildCommandErrorMessage("test binary", result, err))
+			}
+			entry := hangRun{Run: run, Hung: hung, Duration: result.Duration}
+			if hung {
+				entry.Running = runningTests(result.Stdout)
+				entry.dump = result.Stderr
+			}
+			return entry, nil
+		}
+
+		first, err := probe(run)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		payload := map[string]any{
+			"package": importPath,
+			"timeout": timeout.String(),
+			"hung":    first.Hung,
+		}
+		if !first.Hung {
+			payload["runs"] = []hangRun{first}
+			payload["message"] = fmt.Sprintf("the tests finished within %s; raise timeout if the hang only shows up after longer", timeout)
+			result, err := mcp.NewToolResultJSON(payload)
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		last, suspects, runs, err := bisectHang(first, func(tests []string) (hangRun, error) {
+			return probe(runPattern(tests))
+		}, maxHangRuns)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		payload["runs"] = runs
+		payload["suspects"] = suspects
+		payload["running"] = last.Running
+
+		crash := &testCrash{Package: importPath, Kind: "timeout"}
+		if len(suspects) == 1 {
+			crash.Test = runningLeaf(last.Running, suspects[0])
+			payload["test"] = crash.Test
+			crash.Message = fmt.Sprintf("%s was still running after %s", crash.Test, timeout)
+		} else {
+			crash.Message = fmt.Sprintf("%s were still running after %s", strings.Join(suspects, ", "), timeout)
+			if len(runs) >= maxHangRuns {
+				payload["note"] = fmt.Sprintf("stopped bisecting after %d runs", maxHangRuns)
+			} else {
+				payload["note"] = "neither half of these tests hangs on its own; they only hang when run together"
+			}
+		}
+		crash.attachStack(strings.Split(last.dump, "\n"))
+		crash.symbolicate(t.workspaceDir)
+		payload["crash"] = crash
+		payload["goroutine_dump"] = last.dump
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// runningTests replays the === RUN/PAUSE/CONT and --- PASS/FAIL/SKIP lines
+// of verbose test output and returns the tests that were still running, in
+// the order they started. Paused parallel tests are waiting, not running.
+// Subtest results are only printed once their parent finishes, so finished
+// subtests of a running test are still listed.
+func runningTests(output string) []string {
+	var running []string
+	for _, line := range splitLines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		name := fields[2]
+		switch {
+		case fields[0] == "===" && (fields[1] == "RUN" || fields[1] == "CONT"):
+			if !slices.Contains(running, name) {
+				running = append(running, name)
+			}
+		case fields[0] == "===" && fields[1] == "PAUSE",
+			fields[0] == "---" && (fields[1] == "PASS:" || fields[1] == "FAIL:" || fields[1] == "SKIP:"):
+			running = slices.DeleteFunc(running, func(test string) bool { return test == name })
+		}
+	}
+	return running
+}
+
+// topLevelTests returns the distinct top-level tests of running, which are
+// what -run can select on their own.
+func topLevelTests(running []string) []string {
+	var tests []string
+	for _, name := range running {
+		top, _, _ := strings.Cut(name, "/")
+		if !slices.Contains(tests, top) {
+			tests = append(tests, top)
+		}
+	}
+	return tests
+}
+
+// runningLeaf returns the most recently started running test under top that
+// has no running subtests of its own.
+func runningLeaf(running []string, top string) string {
+	leaf := top
+	for _, name := range running {
+		if name == top || strings.HasPrefix(name, top+"/") {
+			if !slices.ContainsFunc(running, func(other string) bool { return strings.HasPrefix(other, name+"/") }) {
+				leaf = name
+			}
+		}
+	}
+	return leaf
+}
+
+func runPattern(tests []string) string {
+	quoted := make([]string, len(tests))
+	for i, test := range tests {
+		quoted[i] = regexp.QuoteMeta(test)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// bisectHang narrows down the top-level tests still running in a hung run by
+// re-running halves of them. It stops when one test is left, when neither
+// half hangs on its own (the tests only hang together), or after maxRuns
+// runs. It returns the last hung run and the remaining suspects.
+func bisectHang(first hangRun, probe func(tests []string) (hangRun, error), maxRuns int) (hangRun, []string, []hangRun, error) {
+	runs := []hangRun{first}
+	last := first
+	suspects := topLevelTests(first.Running)
+	for len(suspects) > 1 && len(runs)+1 <= maxRuns {
+		narrowed := false
+		for _, half := range [][]string{suspects[:len(suspects)/2], suspects[len(suspects)/2:]} {
+			if len(runs) >= maxRuns {
+				break
+			}
+			run, err := probe(half)
+			if err != nil {
+				return hangRun{}, nil, nil, err
+			}
+			runs = append(runs, run)
+			if !run.Hung {
+				continue
+			}
+			last = run
+			suspects = slices.DeleteFunc(topLevelTests(run.Running), func(test string) bool { return !slices.Contains(half, test) })
+			if len(suspects) == 0 {
+				suspects = half
+			}
+			narrowed = true
+			break
+		}
+		if !narrowed {
+			break
+		}
+	}
+	return last, suspects, runs, nil
+}
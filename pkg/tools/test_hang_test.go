@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunningTests(t *testing.T) {
+	output := strings.Join([]string{
+		"=== RUN   TestFast",
+		"--- PASS: TestFast (0.00s)",
+		"=== RUN   TestHang",
+		"=== RUN   TestHang/ok",
+		"=== RUN   TestHang/stuck",
+		"=== RUN   TestParallel",
+		"=== PAUSE TestParallel",
+		"=== RUN   TestSlow",
+		"=== CONT  TestParallel",
+		"    slow_test.go:9: waiting",
+		"--- FAIL: TestSlow (1.00s)",
+	}, "\n")
+	running := runningTests(output)
+	want := []string{"TestHang", "TestHang/ok", "TestHang/stuck", "TestParallel"}
+	if !reflect.DeepEqual(running, want) {
+		t.Fatalf("running = %#v, want %#v", running, want)
+	}
+	if got := topLevelTests(running); !reflect.DeepEqual(got, []string{"TestHang", "TestParallel"}) {
+		t.Fatalf("top-level tests = %#v", got)
+	}
+	if got := runningLeaf(running, "TestHang"); got != "TestHang/stuck" {
+		t.Fatalf("leaf = %q, want TestHang/stuck", got)
+	}
+	if got := runPattern([]string{"TestA", "TestB.x"}); got != `^(TestA|TestB\.x)$` {
+		t.Fatalf("run pattern = %q", got)
+	}
+}
+
+func TestBisectHang(t *testing.T) {
+	first := hangRun{Hung: true, Running: []string{"TestA", "TestB", "TestC", "TestD/sub", "TestD"}}
+	var probed []string
+	probe := func(tests []string) (hangRun, error) {
+		probed = append(probed, strings.Join(tests, ","))
+		if !slices.Contains(tests, "TestC") {
+			return hangRun{Run: runPattern(tests)}, nil
+		}
+		return hangRun{Run: runPattern(tests), Hung: true, Running: tests}, nil
+	}
+
+	last, suspects, runs, err := bisectHang(first, probe, maxHangRuns)
+	if err != nil {
+		t.Fatalf("bisect: %v", err)
+	}
+	if !reflect.DeepEqual(suspects, []string{"TestC"}) || last.Run != "^(TestC)$" {
+		t.Fatalf("suspects = %v, last run %q", suspects, last.Run)
+	}
+	if want := []string{"TestA,TestB", "TestC,TestD", "TestC"}; !reflect.DeepEqual(probed, want) {
+		t.Fatalf("probed = %v, want %v", probed, want)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("expected the first run and three probes, got %d", len(runs))
+	}
+
+	// Tests that only hang together are left as suspects.
+	together := func(tests []string) (hangRun, error) { return hangRun{Run: runPattern(tests)}, nil }
+	_, suspects, runs, err = bisectHang(first, together, maxHangRuns)
+	if err != nil {
+		t.Fatalf("bisect: %v", err)
+	}
+	if len(suspects) != 4 || len(runs) != 3 {
+		t.Fatalf("suspects = %v after %d runs", suspects, len(runs))
+	}
+}
+
+func TestAttachStackPicksStuckSubtest(t *testing.T) {
+	dump := strings.Join([]string{
+		"SIGQUIT: quit",
+		"PC=0x40ee0e m=0 sigcode=0",
+		"",
+		"goroutine 7 gp=0x1c3903a112c0 m=nil [chan receive]:",
+		"testing.(*T).Run(0x1c3903a8a908, {0x554f18?, 0x6918b63caa0?}, 0x6d49b8)",
+		"\t/usr/local/go/src/testing/testing.go:2266 +0x4f2 fp=0x6918b63ca80 sp=0x6918b63c9a8 pc=0x4ee372",
+		"example.com/hang.TestHang(0x1c3903a8a908)",
+		"\t/tmp/hang/hang_test.go:12 +0x4c fp=0x6918b63cac0 sp=0x6918b63ca80 pc=0x4f39f7",
+		"",
+		"goroutine 9 gp=0x1c3903a114a0 m=nil [select]:",
+		"example.com/hang.TestHang.func2(0x1d77c8f3a908?)",
+		"\t/tmp/hang/hang_test.go:14 +0x6e fp=0x1d77c8ede770 sp=0x1d77c8ede700 pc=0x543a6e",
+		"testing.tRunner(0x1d77c8f3a908, 0x6d6070)",
+		"\t/usr/local/go/src/testing/testing.go:2193 +0xea fp=0x1d77c8ede7c0 sp=0x1d77c8ede770 pc=0x4ee2aa",
+	}, "\n")
+	crash := &testCrash{Kind: "timeout", Test: "TestHang/stuck"}
+	crash.attachStack(strings.Split(dump, "\n"))
+	if !strings.HasPrefix(crash.Stack, "goroutine 9 ") {
+		t.Fatalf("expected the subtest goroutine, got %q", crash.Stack)
+	}
+	if len(crash.Frames) != 2 || crash.Frames[0].File != "/tmp/hang/hang_test.go" || crash.Frames[0].Line != 14 {
+		t.Fatalf("unexpected frames %+v", crash.Frames)
+	}
+}
@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	testHelperKindTesting   = "testing_helper"
+	testHelperKindGolden    = "golden"
+	testHelperKindFake      = "fake"
+	testHelperKindFixture   = "fixture"
+	testHelperKindAssertion = "assertion"
+	testHelperKindOther     = "helper"
+)
+
+var (
+	testSupportPackages = map[string]bool{
+		"testutil": true, "testutils": true, "testhelper": true, "testhelpers": true, "testing": true,
+		"testenv": true, "testdata": true, "testfixtures": true, "fixtures": true, "fakes": true, "mocks": true, "mock": true, "fake": true,
+	}
+	fakeNamePattern      = regexp.MustCompile(`^(?i)(new)?(fake|mock|stub|spy|dummy|inmemory)`)
+	fixtureNamePattern   = regexp.MustCompile(`^(?i)(new|make|build|create|setup|must|with|fixture|seed|load|start)`)
+	assertionNamePattern = regexp.MustCompile(`^(?i)(assert|require|check|expect|verify|equal|compare|diff|must(equal|match))`)
+	testFuncPattern      = regexp.MustCompile(`^(Test|Benchmark|Fuzz|Example)([^a-z]|$)`)
+)
+
+type testHelper struct {
+	Name      string            `json:"name"`
+	Kind      string            `json:"kind"`
+	Package   string            `json:"package"`
+	Signature string            `json:"signature"`
+	Doc       string            `json:"doc,omitempty"`
+	CallsT    bool              `json:"calls_t_helper,omitempty"`
+	TestFile  bool              `json:"test_file"`
+	Uses      int               `json:"uses"`
+	Location  protocol.Location `json:"location"`
+}
+
+func (t *LSPTools) registerListTestHelpers(s *server.MCPServer) {
+	tool := mcp.NewTool("list_test_helpers",
+		mcp.WithDescription("Inventory reusable test infrastructure: helpers in _test.go files and exported functions and fake types in testutil-style packages, classified as testing.T helpers, golden-file helpers, fakes, fixtures and assertions, with how often each is used"),
+		mcp.WithTitleAnnotation("List Test Helpers"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only report packages whose import path starts with this prefix")),
+		mcp.WithString("kind",
+			mcp.Description("Only report helpers of this kind"),
+			mcp.Enum(testHelperKindTesting, testHelperKindGolden, testHelperKindFake, testHelperKindFixture, testHelperKindAssertion, testHelperKindOther),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+		kind := getOptionalStringArg(args, "kind")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		helpers := []testHelper{}
+		for _, helper := range collectTestHelpers(ws) {
+			if prefix != "" && !strings.HasPrefix(helper.Package, prefix) {
+				continue
+			}
+			if kind != "" && helper.Kind != kind {
+				continue
+			}
+			helpers = append(helpers, helper)
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"helpers":      helpers,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// isTestSupportPackage recognises packages that exist to support tests:
+// testutil-style directories and the xtest/fake naming conventions
+// (httptest, storetest, fakeclock).
+func isTestSupportPackage(pkg *gosrc.Package) bool {
+	if testSupportPackages[path.Base(pkg.ImportPath)] || testSupportPackages[pkg.Name] {
+		return true
+	}
+	return (strings.HasSuffix(pkg.Name, "test") && !strings.HasSuffix(pkg.Name, "_test")) ||
+		strings.HasPrefix(pkg.Name, "fake") || strings.HasPrefix(pkg.Name, "mock")
+}
+
+func collectTestHelpers(ws *gosrc.Workspace) []testHelper {
+	var helpers []testHelper
+	uses := make(map[string]int)
+	for _, pkg := range ws.Packages {
+		support := isTestSupportPackage(pkg)
+		for _, file := range pkg.Files {
+			if !file.Test && !support {
+				continue
+			}
+			imports := fileImports(file.Syntax)
+			// Count calls from test code so popular helpers stand out.
+			if file.Test {
+				ast.Inspect(file.Syntax, func(n ast.Node) bool {
+					if call, ok := n.(*ast.CallExpr); ok {
+						switch fn := call.Fun.(type) {
+						case *ast.Ident:
+							uses[pkg.ImportPath+"."+fn.Name]++
+						case *ast.SelectorExpr:
+							if ident, ok := fn.X.(*ast.Ident); ok && imports[ident.Name] != "" {
+								uses[imports[ident.Name]+"."+fn.Sel.Name]++
+							}
+						}
+					}
+					return true
+				})
+			}
+
+			for _, decl := range file.Syntax.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv != nil || d.Name.Name == "TestMain" || testFuncPattern.MatchString(d.Name.Name) || d.Name.Name == "init" {
+						continue
+					}
+					if !file.Test && !d.Name.IsExported() {
+						continue
+					}
+					helpers = append(helpers, testHelper{
+						Name:      d.Name.Name,
+						Kind:      classifyTestHelper(imports, d),
+						Package:   pkg.ImportPath,
+						Signature: d.Name.Name + strings.TrimPrefix(exprText(d.Type), "func"),
+						Doc:       firstSentence(d.Doc),
+						CallsT:    callsTHelper(d.Body),
+						TestFile:  file.Test,
+						Location:  sourceLocation(file, d.Name.Pos(), d.Name.End()),
+					})
+				case *ast.GenDecl:
+					if d.Tok != token.TYPE {
+						continue
+					}
+					for _, spec := range d.Specs {
+						ts := spec.(*ast.TypeSpec)
+						if _, ok := ts.Type.(*ast.InterfaceType); ok {
+							continue
+						}
+						if !fakeNamePattern.MatchString(ts.Name.Name) && !(support && strings.HasPrefix(pkg.Name, "fake")) {
+							continue
+						}
+						if !file.Test && !ts.Name.IsExported() {
+							continue
+						}
+						comment := ts.Doc
+						if comment == nil {
+							comment = d.Doc
+						}
+						helpers = append(helpers, testHelper{
+							Name:      ts.Name.Name,
+							Kind:      testHelperKindFake,
+							Package:   pkg.ImportPath,
+							Signature: "type " + ts.Name.Name,
+							Doc:       firstSentence(comment),
+							TestFile:  file.Test,
+							Location:  sourceLocation(file, ts.Name.Pos(), ts.Name.End()),
+						})
+					}
+				}
+			}
+		}
+	}
+	for i := range helpers {
+		helpers[i].Uses = uses[helpers[i].Package+"."+helpers[i].Name]
+	}
+	return helpers
+}
+
+func classifyTestHelper(imports map[string]string, fn *ast.FuncDecl) string {
+	name := fn.Name.Name
+	if strings.Contains(strings.ToLower(name), "golden") || mentionsGolden(fn.Body) {
+		return testHelperKindGolden
+	}
+	if fakeNamePattern.MatchString(name) && fn.Type.Results != nil {
+		return testHelperKindFake
+	}
+	if assertionNamePattern.MatchString(name) {
+		return testHelperKindAssertion
+	}
+	if fixtureNamePattern.MatchString(name) && fn.Type.Results != nil {
+		return testHelperKindFixture
+	}
+	for _, field := range fn.Type.Params.List {
+		switch qualifiedTypeText(imports, unstar(field.Type)) {
+		case "testing.T", "testing.TB", "testing.B", "testing.F":
+			return testHelperKindTesting
+		}
+	}
+	return testHelperKindOther
+}
+
+func unstar(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return expr
+}
+
+// mentionsGolden spots golden-file helpers by their file names.
+func mentionsGolden(body *ast.BlockStmt) bool {
+	found := false
+	if body == nil {
+		return false
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING && strings.Contains(lit.Value, ".golden") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func callsTHelper(body *ast.BlockStmt) bool {
+	found := false
+	if body == nil {
+		return false
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if _, name, ok := methodCall(call); ok && name == "Helper" && len(call.Args) == 0 {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// firstSentence trims a doc comment to its opening sentence.
+func firstSentence(comment *ast.CommentGroup) string {
+	return new(doc.Package).Synopsis(comment.Text())
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCollectTestHelpers(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n\nfunc Helper() {}\n")
+	writeWorkspaceFile(t, workspace, "store/store_test.go", `package store
+
+import (
+	"os"
+	"testing"
+
+	"example.com/app/internal/testutil"
+)
+
+func TestGet(t *testing.T) {
+	s := newFakeStore()
+	assertEqual(t, s.get(), "x")
+	testutil.Golden(t, "get", []byte("x"))
+	withDB(t)
+}
+
+type fakeStore struct{}
+
+func (f *fakeStore) get() string { return "x" }
+
+func newFakeStore() *fakeStore { return &fakeStore{} }
+
+func assertEqual(t *testing.T, got, want string) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func withDB(t testing.TB) { t.Helper() }
+
+func readFixture(name string) []byte {
+	data, _ := os.ReadFile("testdata/" + name + ".golden")
+	return data
+}
+`)
+	writeWorkspaceFile(t, workspace, "internal/testutil/testutil.go", `package testutil
+
+import "testing"
+
+// Golden compares got with testdata/name.golden. Pass -update to rewrite it.
+func Golden(t *testing.T, name string, got []byte) {}
+
+// NewServer starts a test server.
+func NewServer() string { return "" }
+
+func unexported() {}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	byName := make(map[string]testHelper)
+	for _, helper := range collectTestHelpers(ws) {
+		byName[helper.Name] = helper
+	}
+	if len(byName) != 7 {
+		t.Fatalf("unexpected helpers: %+v", byName)
+	}
+	want := map[string]string{
+		"Golden":       testHelperKindGolden,
+		"NewServer":    testHelperKindFixture,
+		"fakeStore":    testHelperKindFake,
+		"newFakeStore": testHelperKindFake,
+		"assertEqual":  testHelperKindAssertion,
+		"withDB":       testHelperKindTesting,
+		"readFixture":  testHelperKindGolden,
+	}
+	for name, kind := range want {
+		if byName[name].Kind != kind {
+			t.Fatalf("%s: got kind %q, want %q", name, byName[name].Kind, kind)
+		}
+	}
+	golden := byName["Golden"]
+	if golden.Doc != "Golden compares got with testdata/name.golden." || golden.Uses != 1 || golden.TestFile {
+		t.Fatalf("unexpected Golden helper: %+v", golden)
+	}
+	if golden.Signature != "Golden(t *testing.T, name string, got []byte)" {
+		t.Fatalf("unexpected signature: %q", golden.Signature)
+	}
+	if assert := byName["assertEqual"]; !assert.CallsT || assert.Uses != 1 || !assert.TestFile {
+		t.Fatalf("unexpected assertEqual helper: %+v", assert)
+	}
+}
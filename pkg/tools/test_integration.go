@@ -0,0 +1,309 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+)
+
+// testEvent is one line of go test -json output.
+type testEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Output  string  `json:"Output"`
+	Elapsed float64 `json:"Elapsed"`
+}
+
+type testPackageResult struct {
+	Package string  `json:"package"`
+	Status  string  `json:"status"`
+	Elapsed float64 `json:"elapsed"`
+	// Crash is set when the test binary died instead of finishing.
+	Crash *testCrash `json:"crash,omitempty"`
+}
+
+// testFailure is a failed test, or a package that failed without a failing
+// test (a build error or a panic in TestMain), with its output.
+type testFailure struct {
+	Package string `json:"package"`
+	Test    string `json:"test,omitempty"`
+	Output  string `json:"output"`
+	// Assertions are the testify and go-cmp failures found in Output.
+	Assertions []assertionFailure `json:"assertions,omitempty"`
+}
+
+type testRunSummary struct {
+	Passed   int                 `json:"passed"`
+	Failed   int                 `json:"failed"`
+	Skipped  int                 `json:"skipped"`
+	Packages []testPackageResult `json:"packages"`
+	Failures []testFailure       `json:"failures"`
+	// Output holds the lines that were not test events, such as build
+	// errors printed by go test itself.
+	Output string `json:"output,omitempty"`
+}
+
+type integrationPlan struct {
+	Tags        []string `json:"tags"`
+	Packages    []string `json:"packages"`
+	ComposeFile string   `json:"compose_file,omitempty"`
+	Services    []string `json:"services,omitempty"`
+	Env         []string `json:"env,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	Source      string   `json:"source"`
+
+	needsDocker bool
+	env         []string
+}
+
+func (t *LSPTools) registerRunIntegrationTests(s *server.MCPServer) {
+	tool := mcp.NewTool("run_integration_tests",
+		mcp.WithDescription("Run the build-tag gated integration tests: start the docker compose environment configured in .mcp-gopls.json, run the tagged packages with the configured environment variables, tear the environment down, and return per-package results and failing tests, with testify and go-cmp assertion failures split into expected, actual and diff, and crashed test binaries as a symbolicated crash per package"),
+		mcp.WithTitleAnnotation("Run Integration Tests"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("tags", mcp.Description("Comma-separated build tags overriding the configured or detected ones")),
+		mcp.WithString("package", mcp.Description("Only run integration packages whose import path starts with this prefix (ignored when packages are configured)")),
+		mcp.WithString("run", mcp.Description("Only run tests matching this regular expression (go test -run)")),
+		mcp.WithBoolean("keep_environment", mcp.Description("Leave the compose environment running after the tests (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		args := request.GetArguments()
+
+		cfg, err := projectconfig.Load(t.workspaceDir)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true, AllPlatforms: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		plan, err := planIntegrationTests(ws, cfg.Integration, getOptionalStringArg(args, "package"), splitCommaList(getOptionalStringArg(args, "tags")))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var binary string
+		if plan.ComposeFile != "" || plan.needsDocker {
+			if binary, err = determineContainerBinary(); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("integration tests need containers: %v", err)), nil
+			}
+		}
+
+		payload := map[string]any{"plan": plan}
+		teardown := func() {}
+		if plan.ComposeFile != "" {
+			composeArgs := []string{"compose", "-f", plan.ComposeFile}
+			down := func() {
+				// Tear down even when the tests were cancelled, so no
+				// containers are left behind.
+				sendProgressNotification(ctx, s, token, fmt.Sprintf("Stopping %s", plan.ComposeFile))
+				result, _ := t.runCommand(context.WithoutCancel(ctx), s, token, binary, append(composeArgs, "down", "--volumes")...)
+				payload["compose_down"] = result
+			}
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Starting %s", plan.ComposeFile))
+			up := append(append(slices.Clone(composeArgs), "up", "-d", "--wait"), plan.Services...)
+			upResult, err := t.runCommand(ctx, s, token, binary, up...)
+			if err != nil {
+				down()
+				return t.commandFailureResult("compose up", upResult, err)
+			}
+			payload["compose_up"] = upResult
+			if !getOptionalBoolArg(args, "keep_environment") {
+				teardown = down
+			}
+		}
+
+		testArgs := []string{"test", "-json", "-count=1", "-tags", strings.Join(plan.Tags, ",")}
+		if run := getOptionalStringArg(args, "run"); run != "" {
+			testArgs = append(testArgs, "-run", run)
+		}
+		if plan.Timeout != "" {
+			testArgs = append(testArgs, "-timeout", plan.Timeout)
+		}
+		testArgs = append(testArgs, plan.Packages...)
+
+		sendProgressNotification(ctx, s, token, fmt.Sprintf("Running integration tests (%s) for %s", strings.Join(plan.Tags, ","), strings.Join(plan.Packages, " ")))
+		result, runErr := t.runCommandSpec(ctx, s, token, commandSpec{name: "go", args: testArgs, env: plan.env})
+		teardown()
+		summary := parseTestEvents(result.Stdout)
+		// The events are summarized; keep only what go test printed itself.
+		result.Stdout = summary.Output
+		if runErr != nil && len(summary.Packages) == 0 {
+			return t.commandFailureResult("go test", result, runErr)
+		}
+		for _, pkg := range summary.Packages {
+			if pkg.Crash != nil {
+				pkg.Crash.symbolicate(t.workspaceDir)
+			}
+		}
+		payload["result"] = result
+		payload["summary"] = summary
+		payload["passed"] = runErr == nil
+
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// planIntegrationTests combines the configured settings with the gated
+// test files found in the workspace.
+func planIntegrationTests(ws *gosrc.Workspace, cfg *projectconfig.Integration, prefix string, tags []string) (integrationPlan, error) {
+	plan := integrationPlan{Source: "detected"}
+	if cfg != nil {
+		plan.Source = projectconfig.FileName
+		plan.Tags = slices.Clone(cfg.Tags)
+		plan.Packages = slices.Clone(cfg.Packages)
+		plan.ComposeFile = filepath.ToSlash(cfg.ComposeFile)
+		plan.Services = cfg.Services
+		plan.Timeout = cfg.Timeout
+		for _, name := range sortedKeys(cfg.Env) {
+			plan.Env = append(plan.Env, name)
+			plan.env = append(plan.env, name+"="+cfg.Env[name])
+		}
+	}
+	if len(tags) > 0 {
+		plan.Tags = tags
+	}
+
+	for _, req := range analyzeTestRequirements(ws, prefix) {
+		gated := false
+		for _, file := range req.GatedFiles {
+			// Files tagged ignore are never meant to be built.
+			if slices.Equal(file.Tags, []string{"ignore"}) {
+				continue
+			}
+			gated = true
+			if len(tags) > 0 || (cfg != nil && len(cfg.Tags) > 0) {
+				continue
+			}
+			for _, tag := range file.Tags {
+				if tag != "ignore" && !slices.Contains(plan.Tags, tag) {
+					plan.Tags = append(plan.Tags, tag)
+				}
+			}
+		}
+		if !gated {
+			continue
+		}
+		for _, dep := range req.Dependencies {
+			if dep.Kind == testDependencyDocker && dep.Gated {
+				plan.needsDocker = true
+			}
+		}
+		if cfg == nil || len(cfg.Packages) == 0 {
+			target := "./" + req.Dir
+			if req.Dir == "." {
+				target = "."
+			}
+			if !slices.Contains(plan.Packages, target) {
+				plan.Packages = append(plan.Packages, target)
+			}
+		}
+	}
+
+	if len(plan.Tags) == 0 || len(plan.Packages) == 0 {
+		return plan, fmt.Errorf("no integration tests found: no test files are gated behind a build tag; configure integration.tags and integration.packages in %s", projectconfig.FileName)
+	}
+	return plan, nil
+}
+
+// parseTestEvents summarizes go test -json output. Output lines are
+// attributed to their test so failures carry their own logs.
+func parseTestEvents(stdout string) testRunSummary {
+	summary := testRunSummary{Packages: []testPackageResult{}, Failures: []testFailure{}}
+	outputs := make(map[string]*strings.Builder)
+	packageOutputs := make(map[string]*strings.Builder)
+	// running holds the tests of each package that started but have not
+	// reported a result yet.
+	running := make(map[string][]string)
+	failedTests := make(map[string]bool)
+	var other strings.Builder
+	for _, line := range splitLines(stdout) {
+		var event testEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil || event.Action == "" {
+			other.WriteString(line)
+			other.WriteByte('\n')
+			continue
+		}
+		key := event.Package + "\x00" + event.Test
+		switch event.Action {
+		case "run":
+			running[event.Package] = append(running[event.Package], event.Test)
+		case "output":
+			appendOutput(outputs, key, event.Output)
+			appendOutput(packageOutputs, event.Package, event.Output)
+		case "pass", "fail", "skip":
+			if event.Test == "" {
+				result := testPackageResult{Package: event.Package, Status: event.Action, Elapsed: event.Elapsed}
+				if event.Action == "fail" {
+					result.Crash = detectTestCrash(strings.Split(builderString(packageOutputs[event.Package]), "\n"))
+					// A binary that exits mid-test leaves the test without a
+					// result; without -json go test would report the exit.
+					unfinished := running[event.Package]
+					if result.Crash == nil && len(unfinished) > 0 {
+						result.Crash = &testCrash{Kind: "exit", Message: "test binary exited while tests were running"}
+					}
+					if result.Crash != nil {
+						result.Crash.Package = event.Package
+						if result.Crash.Test == "" && len(unfinished) > 0 {
+							result.Crash.Test = unfinished[len(unfinished)-1]
+						}
+					}
+					for _, test := range unfinished {
+						summary.Failed++
+						failedTests[event.Package] = true
+						summary.Failures = append(summary.Failures, testFailure{Package: event.Package, Test: test, Output: builderString(outputs[event.Package+"\x00"+test])})
+					}
+				}
+				summary.Packages = append(summary.Packages, result)
+				if event.Action == "fail" && !failedTests[event.Package] {
+					summary.Failures = append(summary.Failures, testFailure{Package: event.Package, Output: builderString(outputs[key])})
+				}
+				continue
+			}
+			running[event.Package] = slices.DeleteFunc(running[event.Package], func(test string) bool { return test == event.Test })
+			switch event.Action {
+			case "pass":
+				summary.Passed++
+			case "skip":
+				summary.Skipped++
+			case "fail":
+				summary.Failed++
+				failedTests[event.Package] = true
+				output := builderString(outputs[key])
+				summary.Failures = append(summary.Failures, testFailure{Package: event.Package, Test: event.Test, Output: output, Assertions: parseAssertionFailures(output)})
+			}
+		}
+	}
+	summary.Output = other.String()
+	return summary
+}
+
+func appendOutput(builders map[string]*strings.Builder, key, output string) {
+	if builders[key] == nil {
+		builders[key] = &strings.Builder{}
+	}
+	builders[key].WriteString(output)
+}
+
+func builderString(b *strings.Builder) string {
+	if b == nil {
+		return ""
+	}
+	return b.String()
+}
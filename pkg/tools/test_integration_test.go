@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/projectconfig"
+)
+
+func TestPlanIntegrationTests(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n")
+	writeWorkspaceFile(t, workspace, "store/store_it_test.go", `//go:build integration
+
+package store
+
+import (
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+var _ = testcontainers.GenericContainer
+
+func TestDB(t *testing.T) {}
+`)
+	writeWorkspaceFile(t, workspace, "api/api_e2e_test.go", "//go:build e2e\n\npackage api\n\nimport \"testing\"\n\nfunc TestAPI(t *testing.T) {}\n")
+	writeWorkspaceFile(t, workspace, "tools/tools.go", "//go:build ignore\n\npackage tools\n")
+	writeWorkspaceFile(t, workspace, "gen/gen_test.go", "//go:build ignore\n\npackage gen\n")
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true, AllPlatforms: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	plan, err := planIntegrationTests(ws, nil, "", nil)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if !reflect.DeepEqual(plan.Tags, []string{"e2e", "integration"}) || !reflect.DeepEqual(plan.Packages, []string{"./api", "./store"}) {
+		t.Fatalf("unexpected detected plan %+v", plan)
+	}
+	if !plan.needsDocker || plan.Source != "detected" {
+		t.Fatalf("expected a detected plan needing docker, got %+v", plan)
+	}
+
+	plan, err = planIntegrationTests(ws, nil, "example.com/app/api", []string{"e2e"})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if !reflect.DeepEqual(plan.Tags, []string{"e2e"}) || !reflect.DeepEqual(plan.Packages, []string{"./api"}) || plan.needsDocker {
+		t.Fatalf("unexpected filtered plan %+v", plan)
+	}
+
+	cfg := &projectconfig.Integration{
+		Tags:        []string{"it"},
+		Packages:    []string{"./..."},
+		ComposeFile: "deploy/compose.test.yml",
+		Env:         map[string]string{"DB_URL": "postgres://localhost", "API_KEY": "test"},
+		Timeout:     "5m",
+	}
+	plan, err = planIntegrationTests(ws, cfg, "", nil)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if !reflect.DeepEqual(plan.Tags, []string{"it"}) || !reflect.DeepEqual(plan.Packages, []string{"./..."}) || plan.Source != projectconfig.FileName {
+		t.Fatalf("unexpected configured plan %+v", plan)
+	}
+	if !reflect.DeepEqual(plan.Env, []string{"API_KEY", "DB_URL"}) || !reflect.DeepEqual(plan.env, []string{"API_KEY=test", "DB_URL=postgres://localhost"}) {
+		t.Fatalf("unexpected env %v / %v", plan.Env, plan.env)
+	}
+
+	if _, err := planIntegrationTests(ws, nil, "example.com/app/gen", nil); err == nil || !strings.Contains(err.Error(), "no integration tests found") {
+		t.Fatalf("expected an error for packages without integration tests, got %v", err)
+	}
+}
+
+func TestParseTestEvents(t *testing.T) {
+	stdout := strings.Join([]string{
+		`{"Action":"start","Package":"example.com/app/store"}`,
+		`{"Action":"run","Package":"example.com/app/store","Test":"TestDB"}`,
+		`{"Action":"output","Package":"example.com/app/store","Test":"TestDB","Output":"    store_test.go:9: connection refused\n"}`,
+		`{"Action":"fail","Package":"example.com/app/store","Test":"TestDB","Elapsed":0.2}`,
+		`{"Action":"pass","Package":"example.com/app/store","Test":"TestOK","Elapsed":0.1}`,
+		`{"Action":"skip","Package":"example.com/app/store","Test":"TestSlow","Elapsed":0}`,
+		`{"Action":"fail","Package":"example.com/app/store","Elapsed":0.4}`,
+		`# example.com/app/api`,
+		`api/api_test.go:3:1: undefined: x`,
+		`{"Action":"output","Package":"example.com/app/api","Output":"FAIL\texample.com/app/api [build failed]\n"}`,
+		`{"Action":"fail","Package":"example.com/app/api","Elapsed":0}`,
+	}, "\n")
+
+	summary := parseTestEvents(stdout)
+	if summary.Passed != 1 || summary.Failed != 1 || summary.Skipped != 1 {
+		t.Fatalf("unexpected counts %+v", summary)
+	}
+	wantPackages := []testPackageResult{
+		{Package: "example.com/app/store", Status: "fail", Elapsed: 0.4},
+		{Package: "example.com/app/api", Status: "fail"},
+	}
+	if !reflect.DeepEqual(summary.Packages, wantPackages) {
+		t.Fatalf("packages = %+v, want %+v", summary.Packages, wantPackages)
+	}
+	wantFailures := []testFailure{
+		{Package: "example.com/app/store", Test: "TestDB", Output: "    store_test.go:9: connection refused\n"},
+		{Package: "example.com/app/api", Output: "FAIL\texample.com/app/api [build failed]\n"},
+	}
+	if !reflect.DeepEqual(summary.Failures, wantFailures) {
+		t.Fatalf("failures = %+v, want %+v", summary.Failures, wantFailures)
+	}
+	if summary.Output != "# example.com/app/api\napi/api_test.go:3:1: undefined: x\n" {
+		t.Fatalf("unexpected output %q", summary.Output)
+	}
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	leakFileName    = "zz_mcp_gopls_leaks_test.go"
+	leakBeginMarker = "MCP_GOPLS_LEAK_BEGIN"
+	leakEndMarker   = "MCP_GOPLS_LEAK_END"
+)
+
+// leakCheckSource is added to each tested package through a go test
+// overlay. It snapshots goroutines around m.Run and fails the package when
+// new goroutines are still alive after the grace period, printing their
+// stacks between markers. Imports are aliased so they cannot clash with
+// package-level names of the package under test.
+const leakCheckSource = `package %s
+
+import (
+	mcpgoplsfmt "fmt"
+	mcpgoplsos "os"
+	mcpgoplsruntime "runtime"
+	mcpgoplsstrings "strings"
+	mcpgoplstesting "testing"
+	mcpgoplstime "time"
+)
+
+func TestMain(m *mcpgoplstesting.M) {
+	before := mcpgoplsGoroutines()
+	code := m.Run()
+	deadline := mcpgoplstime.Now().Add(%d * mcpgoplstime.Millisecond)
+	var leaked []string
+	for {
+		leaked = leaked[:0]
+		for id, stack := range mcpgoplsGoroutines() {
+			if _, ok := before[id]; !ok {
+				leaked = append(leaked, stack)
+			}
+		}
+		if len(leaked) == 0 || mcpgoplstime.Now().After(deadline) {
+			break
+		}
+		mcpgoplstime.Sleep(10 * mcpgoplstime.Millisecond)
+	}
+	for _, stack := range leaked {
+		mcpgoplsfmt.Printf("%s\n%%s\n%s\n", stack)
+	}
+	if len(leaked) > 0 && code == 0 {
+		code = 1
+	}
+	mcpgoplsos.Exit(code)
+}
+
+func mcpgoplsGoroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := mcpgoplsruntime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := make(map[string]string)
+	for i, stack := range mcpgoplsstrings.Split(string(buf), "\n\n") {
+		// The first stack is the goroutine taking the snapshot.
+		if i == 0 || mcpgoplsstrings.Contains(stack, "os/signal.signal_recv") || mcpgoplsstrings.Contains(stack, "runtime.ensureSigM") {
+			continue
+		}
+		header, _, _ := mcpgoplsstrings.Cut(stack, " [")
+		stacks[header] = stack
+	}
+	return stacks
+}
+`
+
+type leakedGoroutine struct {
+	Package   string `json:"package,omitempty"`
+	State     string `json:"state"`
+	Function  string `json:"function"`
+	CreatedBy string `json:"created_by,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	Stack     string `json:"stack"`
+}
+
+type leakPackageStatus struct {
+	Package string `json:"package"`
+	Reason  string `json:"reason"`
+}
+
+// prepareLeakOverlay writes a go test overlay that adds the leak check to
+// every package matched by target that has tests, in the package or in its
+// external test package, and no TestMain of its own. The returned cleanup removes the temporary files.
+func (t *LSPTools) prepareLeakOverlay(ctx context.Context, s *server.MCPServer, token mcp.ProgressToken, target string, grace time.Duration) (string, []leakPackageStatus, func(), error) {
+	listed, err := t.runCommand(ctx, s, token, "go", "list", "-f", "{{.ImportPath}}\t{{.Dir}}\t{{.Name}}\t{{len .TestGoFiles}}\t{{len .XTestGoFiles}}", target)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%s", buildCommandErrorMessage("go list", listed, err))
+	}
+	tmpDir, err := os.MkdirTemp("", "mcp-gopls-leaks-*")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+
+	replace := make(map[string]string)
+	var skipped []leakPackageStatus
+	for _, line := range splitLines(listed.Stdout) {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			continue
+		}
+		importPath, dir, name := fields[0], fields[1], fields[2]
+		if fields[3] == "0" && fields[4] == "0" {
+			continue
+		}
+		if hasTestMain(dir) {
+			skipped = append(skipped, leakPackageStatus{Package: importPath, Reason: "package defines TestMain; add goleak.VerifyTestMain there instead"})
+			continue
+		}
+		source := filepath.Join(tmpDir, fmt.Sprintf("%d.go", len(replace)))
+		content := fmt.Sprintf(leakCheckSource, name, grace.Milliseconds(), leakBeginMarker, leakEndMarker)
+		if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+			cleanup()
+			return "", nil, nil, err
+		}
+		replace[filepath.Join(dir, leakFileName)] = source
+	}
+
+	overlay, err := json.Marshal(map[string]any{"Replace": replace})
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	overlayPath := filepath.Join(tmpDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0o644); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	return overlayPath, skipped, cleanup, nil
+}
+
+// hasTestMain reports whether the test files of dir, external test package
+// included, declare TestMain. A test binary has a single TestMain, so the
+// leak check cannot add its own next to either.
+func hasTestMain(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	fset := token.NewFileSet()
+	for _, path := range matches {
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "TestMain" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseLeakReport extracts the goroutines printed by the leak check. go test
+// prints a package's output before its ok/FAIL summary line, which names
+// the package.
+func parseLeakReport(output string) []leakedGoroutine {
+	var (
+		leaks   []leakedGoroutine
+		pending []leakedGoroutine
+		block   []string
+		inBlock bool
+	)
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case line == leakBeginMarker:
+			inBlock, block = true, nil
+		case line == leakEndMarker && inBlock:
+			inBlock = false
+			pending = append(pending, parseGoroutineStack(strings.Join(block, "\n")))
+		case inBlock:
+			block = append(block, line)
+		case strings.HasPrefix(line, "FAIL\t") || strings.HasPrefix(line, "ok  \t"):
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				for i := range pending {
+					pending[i].Package = fields[1]
+				}
+			}
+			leaks = append(leaks, pending...)
+			pending = nil
+		}
+	}
+	return append(leaks, pending...)
+}
+
+// parseGoroutineStack summarises a runtime.Stack entry:
+//
+//	goroutine 7 [chan receive]:
+//	example.com/pkg.worker(...)
+//		/src/pkg/worker.go:12 +0x1d
+//	created by example.com/pkg.Start in goroutine 6
+//		/src/pkg/worker.go:8 +0x25
+func parseGoroutineStack(stack string) leakedGoroutine {
+	stack = strings.TrimSpace(stack)
+	lines := strings.Split(stack, "\n")
+	leak := leakedGoroutine{Stack: stack}
+	if len(lines) > 0 {
+		if start := strings.Index(lines[0], "["); start >= 0 {
+			if end := strings.Index(lines[0][start:], "]"); end > 0 {
+				leak.State = lines[0][start+1 : start+end]
+			}
+		}
+	}
+	if len(lines) > 1 {
+		leak.Function = stackFunction(lines[1])
+	}
+	for i, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "created by "); ok {
+			leak.CreatedBy, _, _ = strings.Cut(rest, " in goroutine")
+			if i+1 < len(lines) {
+				location := strings.TrimSpace(lines[i+1])
+				if idx := strings.LastIndex(location, " +0x"); idx > 0 {
+					location = location[:idx]
+				}
+				leak.CreatedAt = location
+			}
+		}
+	}
+	return leak
+}
+
+func stackFunction(frame string) string {
+	if idx := strings.LastIndex(frame, "("); idx > 0 {
+		return frame[:idx]
+	}
+	return frame
+}
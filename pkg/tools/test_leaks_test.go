@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseLeakReport(t *testing.T) {
+	output := "=== RUN   TestStart\n--- PASS: TestStart (0.00s)\nPASS\n" +
+		leakBeginMarker + "\n" +
+		"goroutine 7 [chan receive]:\n" +
+		"example.com/app/worker.Start.func1()\n" +
+		"\t/src/worker/worker.go:5 +0x19\n" +
+		"created by example.com/app/worker.Start in goroutine 6\n" +
+		"\t/src/worker/worker.go:4 +0x67\n\n" +
+		leakEndMarker + "\n" +
+		"FAIL\texample.com/app/worker\t0.511s\n" +
+		"ok  \texample.com/app/clean\t0.002s\n"
+
+	leaks := parseLeakReport(output)
+	if len(leaks) != 1 {
+		t.Fatalf("expected one leak, got %+v", leaks)
+	}
+	leak := leaks[0]
+	if leak.Package != "example.com/app/worker" || leak.State != "chan receive" || leak.Function != "example.com/app/worker.Start.func1" {
+		t.Fatalf("unexpected leak: %+v", leak)
+	}
+	if leak.CreatedBy != "example.com/app/worker.Start" || leak.CreatedAt != "/src/worker/worker.go:4" {
+		t.Fatalf("unexpected creation site: %+v", leak)
+	}
+}
+
+func TestHasTestMain(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkspaceFile(t, dir, "a_test.go", "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n")
+	if hasTestMain(dir) {
+		t.Fatal("expected no TestMain")
+	}
+	writeWorkspaceFile(t, dir, "main_test.go", "package a\n\nimport \"testing\"\n\nfunc TestMain(m *testing.M) { m.Run() }\n")
+	if !hasTestMain(dir) {
+		t.Fatal("expected TestMain to be detected")
+	}
+
+	xtest := t.TempDir()
+	writeWorkspaceFile(t, xtest, "a_test.go", "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n")
+	writeWorkspaceFile(t, xtest, "ext_test.go", "package a_test\n\nimport \"testing\"\n\nfunc TestMain(m *testing.M) { m.Run() }\n")
+	if !hasTestMain(xtest) {
+		t.Fatal("expected TestMain in the external test package to be detected")
+	}
+}
+
+func TestPrepareLeakOverlaySkipsExternalTestMain(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "a/a.go", "package a\n")
+	writeWorkspaceFile(t, workspace, "a/a_test.go", "package a\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n")
+	writeWorkspaceFile(t, workspace, "a/main_test.go", "package a_test\n\nimport (\n\t\"os\"\n\t\"testing\"\n)\n\nfunc TestMain(m *testing.M) { os.Exit(m.Run()) }\n")
+	writeWorkspaceFile(t, workspace, "b/b.go", "package b\n")
+	writeWorkspaceFile(t, workspace, "b/b_test.go", "package b\n\nimport \"testing\"\n\nfunc TestB(t *testing.T) {}\n")
+	writeWorkspaceFile(t, workspace, "c/c.go", "package c\n")
+	writeWorkspaceFile(t, workspace, "c/c_test.go", "package c_test\n\nimport \"testing\"\n\nfunc TestC(t *testing.T) {}\n")
+	writeWorkspaceFile(t, workspace, "d/d.go", "package d\n")
+	tools := NewLSPTools(nil, workspace)
+	tools.commandRunner = func(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+		return commandResult{Stdout: "example.com/app/a\t" + filepath.Join(workspace, "a") + "\ta\t1\t1\n" +
+			"example.com/app/b\t" + filepath.Join(workspace, "b") + "\tb\t1\t0\n" +
+			"example.com/app/c\t" + filepath.Join(workspace, "c") + "\tc\t0\t1\n" +
+			"example.com/app/d\t" + filepath.Join(workspace, "d") + "\td\t0\t0\n"}, nil
+	}
+
+	overlayPath, skipped, cleanup, err := tools.prepareLeakOverlay(context.Background(), nil, nil, "./...", 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if len(skipped) != 1 || skipped[0].Package != "example.com/app/a" {
+		t.Fatalf("expected the package with an external TestMain to be skipped, got %v", skipped)
+	}
+	data, err := os.ReadFile(overlayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var overlay struct{ Replace map[string]string }
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		t.Fatal(err)
+	}
+	_, injectedA := overlay.Replace[filepath.Join(workspace, "a", leakFileName)]
+	_, injectedC := overlay.Replace[filepath.Join(workspace, "c", leakFileName)]
+	if injectedA || !injectedC || len(overlay.Replace) != 2 {
+		t.Fatalf("expected the leak check in b and in the external-tests-only c, got %v", overlay.Replace)
+	}
+}
+
+func TestLeakCheckSourceParses(t *testing.T) {
+	source := fmt.Sprintf(leakCheckSource, "worker", 500, leakBeginMarker, leakEndMarker)
+	if _, err := parser.ParseFile(token.NewFileSet(), leakFileName, source, 0); err != nil {
+		t.Fatalf("generated leak check does not parse: %v\n%s", err, source)
+	}
+}
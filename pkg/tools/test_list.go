@@ -0,0 +1,457 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+type testFunc struct {
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"`
+	Package    string            `json:"package"`
+	RunPattern string            `json:"run_pattern"`
+	Subtests   []subtest         `json:"subtests,omitempty"`
+	Location   protocol.Location `json:"location"`
+}
+
+// subtest is one t.Run call. Name is the full path go test reports
+// (TestFoo/case_3); subtests whose name is only known at run time end in
+// "/*" and carry the expression that computes it.
+type subtest struct {
+	Name       string            `json:"name"`
+	RunPattern string            `json:"run_pattern"`
+	Dynamic    bool              `json:"dynamic,omitempty"`
+	Expr       string            `json:"expr,omitempty"`
+	Location   protocol.Location `json:"location"`
+}
+
+func (t *LSPTools) registerListTests(s *server.MCPServer) {
+	tool := mcp.NewTool("list_tests",
+		mcp.WithDescription("List test, benchmark, fuzz and example functions with their named subtests, expanding table-driven t.Run loops where the case names are literals, and give the anchored -run pattern for each"),
+		mcp.WithTitleAnnotation("List Tests"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only list packages whose import path starts with this prefix")),
+		mcp.WithString("name", mcp.Description("Only list the test function with this name")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+		name := getOptionalStringArg(args, "name")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		tests := []testFunc{}
+		for _, test := range collectTests(ws, prefix) {
+			if name == "" || test.Name == name {
+				tests = append(tests, test)
+			}
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"tests":        tests,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func collectTests(ws *gosrc.Workspace, prefix string) []testFunc {
+	var tests []testFunc
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		tables, structs := packageTables(pkg)
+		for _, file := range pkg.Files {
+			if !file.Test {
+				continue
+			}
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || fn.Body == nil {
+					continue
+				}
+				kind, recv, ok := testFuncKind(fn)
+				if !ok {
+					continue
+				}
+				test := testFunc{
+					Name:       fn.Name.Name,
+					Kind:       kind,
+					Package:    strings.TrimSuffix(pkg.ImportPath, "_test"),
+					RunPattern: subtestRunPattern(fn.Name.Name),
+					Location:   sourceLocation(file, fn.Name.Pos(), fn.Name.End()),
+				}
+				if recv != "" {
+					// Tables declared in the function shadow package-level ones.
+					local := localTables(fn.Body)
+					for name, lit := range tables {
+						if _, ok := local[name]; !ok {
+							local[name] = lit
+						}
+					}
+					c := &subtestCollector{file: file, tables: local, structs: structs, seen: make(map[string]int)}
+					c.walk(fn.Body, recv, fn.Name.Name, nil)
+					test.Subtests = c.subtests
+				}
+				tests = append(tests, test)
+			}
+		}
+	}
+	return tests
+}
+
+// testFuncKind recognises the functions go test runs and returns the name
+// of the *testing.T or *testing.B parameter subtests hang off.
+func testFuncKind(fn *ast.FuncDecl) (string, string, bool) {
+	match := testFuncPattern.FindStringSubmatch(fn.Name.Name)
+	if match == nil {
+		return "", "", false
+	}
+	params := fn.Type.Params.List
+	if match[1] == "Example" {
+		return "example", "", len(params) == 0
+	}
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return "", "", false
+	}
+	want := map[string]string{"Test": "T", "Benchmark": "B", "Fuzz": "F"}[match[1]]
+	sel, ok := unstar(params[0].Type).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != want {
+		return "", "", false
+	}
+	recv := ""
+	if len(params[0].Names) == 1 && match[1] != "Fuzz" {
+		recv = params[0].Names[0].Name
+	}
+	return strings.ToLower(match[1]), recv, true
+}
+
+// packageTables collects the package-level composite literals test tables
+// are usually declared as, and the struct types their cases use.
+func packageTables(pkg *gosrc.Package) (map[string]*ast.CompositeLit, map[string]*ast.StructType) {
+	tables := make(map[string]*ast.CompositeLit)
+	structs := make(map[string]*ast.StructType)
+	for _, file := range pkg.Files {
+		for _, decl := range file.Syntax.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				switch s := spec.(type) {
+				case *ast.ValueSpec:
+					for i, name := range s.Names {
+						if i < len(s.Values) {
+							if lit, ok := unwrapAddr(s.Values[i]).(*ast.CompositeLit); ok {
+								tables[name.Name] = lit
+							}
+						}
+					}
+				case *ast.TypeSpec:
+					if st, ok := s.Type.(*ast.StructType); ok {
+						structs[s.Name.Name] = st
+					}
+				}
+			}
+		}
+	}
+	return tables, structs
+}
+
+// localTables collects the composite literals assigned to variables inside
+// a test function.
+func localTables(body *ast.BlockStmt) map[string]*ast.CompositeLit {
+	tables := make(map[string]*ast.CompositeLit)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if len(node.Lhs) != len(node.Rhs) {
+				return true
+			}
+			for i, lhs := range node.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				if lit, ok := unwrapAddr(node.Rhs[i]).(*ast.CompositeLit); ok {
+					tables[ident.Name] = lit
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				if i < len(node.Values) {
+					if lit, ok := unwrapAddr(node.Values[i]).(*ast.CompositeLit); ok {
+						tables[name.Name] = lit
+					}
+				}
+			}
+		}
+		return true
+	})
+	return tables
+}
+
+func unwrapAddr(expr ast.Expr) ast.Expr {
+	expr = unwrapParens(expr)
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		return unwrapParens(unary.X)
+	}
+	return expr
+}
+
+type subtestCollector struct {
+	file     *gosrc.File
+	tables   map[string]*ast.CompositeLit
+	structs  map[string]*ast.StructType
+	seen     map[string]int
+	subtests []subtest
+}
+
+// rangeTable records which loop variables iterate over a known table.
+type rangeTable struct {
+	key, value string
+	lit        *ast.CompositeLit
+}
+
+func (c *subtestCollector) walk(node ast.Node, recv, parent string, loops []rangeTable) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.RangeStmt:
+			var lit *ast.CompositeLit
+			switch x := unwrapAddr(stmt.X).(type) {
+			case *ast.CompositeLit:
+				lit = x
+			case *ast.Ident:
+				lit = c.tables[x.Name]
+			}
+			if lit == nil {
+				return true
+			}
+			loop := rangeTable{lit: lit}
+			if ident, ok := stmt.Key.(*ast.Ident); ok {
+				loop.key = ident.Name
+			}
+			if ident, ok := stmt.Value.(*ast.Ident); ok {
+				loop.value = ident.Name
+			}
+			c.walk(stmt.Body, recv, parent, append(loops[:len(loops):len(loops)], loop))
+			return false
+		case *ast.CallExpr:
+			x, name, ok := methodCall(stmt)
+			if !ok || name != "Run" || len(stmt.Args) != 2 {
+				return true
+			}
+			if ident, ok := x.(*ast.Ident); !ok || ident.Name != recv {
+				return true
+			}
+			c.add(stmt, parent, loops)
+			return false
+		}
+		return true
+	})
+}
+
+func (c *subtestCollector) add(call *ast.CallExpr, parent string, loops []rangeTable) {
+	names, ok := c.subtestNames(call.Args[0], loops)
+	body, _ := call.Args[1].(*ast.FuncLit)
+	childRecv := ""
+	if body != nil && len(body.Type.Params.List) == 1 && len(body.Type.Params.List[0].Names) == 1 {
+		childRecv = body.Type.Params.List[0].Names[0].Name
+	}
+	location := sourceLocation(c.file, call.Pos(), call.End())
+	if !ok {
+		c.subtests = append(c.subtests, subtest{
+			Name:       parent + "/*",
+			RunPattern: subtestRunPattern(parent),
+			Dynamic:    true,
+			Expr:       exprText(call.Args[0]),
+			Location:   location,
+		})
+		if body != nil && childRecv != "" {
+			c.walk(body.Body, childRecv, parent+"/*", loops)
+		}
+		return
+	}
+	for _, name := range names {
+		path := parent + "/" + c.uniqueName(parent, name)
+		c.subtests = append(c.subtests, subtest{
+			Name:       path,
+			RunPattern: subtestRunPattern(path),
+			Location:   location,
+		})
+		if body != nil && childRecv != "" {
+			c.walk(body.Body, childRecv, path, loops)
+		}
+	}
+}
+
+// subtestNames resolves a t.Run name argument: a string literal, the key of
+// a map table, or a string field of the cases of a slice table.
+func (c *subtestCollector) subtestNames(arg ast.Expr, loops []rangeTable) ([]string, bool) {
+	if value, ok := stringValue(arg); ok {
+		return []string{value}, true
+	}
+	for i := len(loops) - 1; i >= 0; i-- {
+		loop := loops[i]
+		switch e := unwrapParens(arg).(type) {
+		case *ast.Ident:
+			if e.Name != loop.key || e.Name == "_" {
+				continue
+			}
+			if _, ok := loop.lit.Type.(*ast.MapType); !ok {
+				return nil, false
+			}
+			var names []string
+			for _, elt := range loop.lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					return nil, false
+				}
+				value, ok := stringValue(kv.Key)
+				if !ok {
+					return nil, false
+				}
+				names = append(names, value)
+			}
+			return names, true
+		case *ast.SelectorExpr:
+			ident, ok := e.X.(*ast.Ident)
+			if !ok || ident.Name != loop.value {
+				continue
+			}
+			return c.fieldValues(loop.lit, e.Sel.Name)
+		}
+	}
+	return nil, false
+}
+
+func (c *subtestCollector) fieldValues(lit *ast.CompositeLit, field string) ([]string, bool) {
+	var elem ast.Expr
+	switch typ := lit.Type.(type) {
+	case *ast.ArrayType:
+		elem = typ.Elt
+	case *ast.MapType:
+		elem = typ.Value
+	default:
+		return nil, false
+	}
+	index := -1
+	var st *ast.StructType
+	switch e := unstar(elem).(type) {
+	case *ast.StructType:
+		st = e
+	case *ast.Ident:
+		st = c.structs[e.Name]
+	}
+	if st != nil {
+		i := 0
+		for _, f := range st.Fields.List {
+			if len(f.Names) == 0 {
+				i++
+				continue
+			}
+			for _, name := range f.Names {
+				if name.Name == field {
+					index = i
+				}
+				i++
+			}
+		}
+	}
+
+	var names []string
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		cases, ok := unwrapAddr(elt).(*ast.CompositeLit)
+		if !ok {
+			return nil, false
+		}
+		found := false
+		for i, value := range cases.Elts {
+			kv, keyed := value.(*ast.KeyValueExpr)
+			switch {
+			case keyed:
+				key, ok := kv.Key.(*ast.Ident)
+				if !ok || key.Name != field {
+					continue
+				}
+				value = kv.Value
+			case i != index:
+				continue
+			}
+			name, ok := stringValue(value)
+			if !ok {
+				return nil, false
+			}
+			names = append(names, name)
+			found = true
+			break
+		}
+		if !found {
+			// An omitted field is the empty string.
+			names = append(names, "")
+		}
+	}
+	return names, true
+}
+
+// uniqueName mirrors how the testing package turns a t.Run name into the
+// name it reports: whitespace becomes underscores, unprintable runes are
+// escaped, and repeated names get a #01 style suffix.
+func (c *subtestCollector) uniqueName(parent, name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteByte('_')
+		case !strconv.IsPrint(r):
+			quoted := strconv.QuoteRune(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	rewritten := b.String()
+	key := parent + "/" + rewritten
+	n := c.seen[key]
+	c.seen[key]++
+	if rewritten == "" {
+		return fmt.Sprintf("#%02d", n)
+	}
+	if n > 0 {
+		return fmt.Sprintf("%s#%02d", rewritten, n)
+	}
+	return rewritten
+}
+
+// subtestRunPattern turns a test path such as TestFoo/case_3 into the
+// anchored -run pattern selecting exactly that test. go test splits both
+// the pattern and the test name on slashes, so each element is anchored on
+// its own. A trailing "*" element (a dynamic subtest) selects all subtests.
+func subtestRunPattern(path string) string {
+	elements := strings.Split(strings.TrimSuffix(path, "/*"), "/")
+	for i, element := range elements {
+		elements[i] = "^" + regexp.QuoteMeta(element) + "$"
+	}
+	return strings.Join(elements, "/")
+}
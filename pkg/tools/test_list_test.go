@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCollectTests(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nfunc Add(a, b int) int { return a + b }\n")
+	writeWorkspaceFile(t, workspace, "calc/calc_test.go", `package calc
+
+import (
+	"fmt"
+	"testing"
+)
+
+type addCase struct {
+	name string
+	a, b int
+}
+
+var sharedCases = []addCase{
+	{"one plus one", 1, 1},
+	{name: "zero", a: 0},
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{name: "small", want: 2},
+		{name: "small", want: 3},
+		{want: 4},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("nested", func(t *testing.T) {})
+		})
+	}
+	for _, tc := range sharedCases {
+		t.Run(tc.name, func(t *testing.T) {})
+	}
+	for name := range map[string]int{"neg": -1} {
+		t.Run(name, func(t *testing.T) {})
+	}
+	for i := 0; i < 2; i++ {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {})
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	b.Run("big", func(b *testing.B) {})
+}
+
+func FuzzAdd(f *testing.F) {}
+
+func ExampleAdd() {}
+
+func TestHelper(t *testing.T, extra int) {}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	tests := collectTests(ws, "")
+
+	var names []string
+	for _, test := range tests {
+		names = append(names, test.Kind+" "+test.Name)
+	}
+	wantNames := []string{"test TestAdd", "benchmark BenchmarkAdd", "fuzz FuzzAdd", "example ExampleAdd"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("tests = %v, want %v", names, wantNames)
+	}
+
+	var subtests []string
+	for _, sub := range tests[0].Subtests {
+		entry := sub.Name + " " + sub.RunPattern
+		if sub.Dynamic {
+			entry += " " + sub.Expr
+		}
+		subtests = append(subtests, entry)
+	}
+	wantSubtests := []string{
+		"TestAdd/small ^TestAdd$/^small$",
+		"TestAdd/small/nested ^TestAdd$/^small$/^nested$",
+		"TestAdd/small#01 ^TestAdd$/^small#01$",
+		"TestAdd/small#01/nested ^TestAdd$/^small#01$/^nested$",
+		"TestAdd/#00 ^TestAdd$/^#00$",
+		"TestAdd/#00/nested ^TestAdd$/^#00$/^nested$",
+		"TestAdd/one_plus_one ^TestAdd$/^one_plus_one$",
+		"TestAdd/zero ^TestAdd$/^zero$",
+		"TestAdd/neg ^TestAdd$/^neg$",
+		"TestAdd/* ^TestAdd$ fmt.Sprint(i)",
+	}
+	if !reflect.DeepEqual(subtests, wantSubtests) {
+		t.Fatalf("subtests = %#v, want %#v", subtests, wantSubtests)
+	}
+	if got := tests[1].Subtests; len(got) != 1 || got[0].Name != "BenchmarkAdd/big" {
+		t.Fatalf("benchmark subtests = %+v", got)
+	}
+}
+
+func TestSubtestRunPattern(t *testing.T) {
+	cases := map[string]string{
+		"TestFoo":              "^TestFoo$",
+		"TestFoo/case_3":       "^TestFoo$/^case_3$",
+		"TestFoo/a.b(c)/*":     `^TestFoo$/^a\.b\(c\)$`,
+		"TestFoo/with#01/deep": "^TestFoo$/^with#01$/^deep$",
+	}
+	for path, want := range cases {
+		if got := subtestRunPattern(path); got != want {
+			t.Errorf("subtestRunPattern(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,648 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/mod/modfile"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// propertyLibraries are the property-testing libraries suggest_properties
+// writes scaffolds for, keyed by the name the tool accepts.
+var propertyLibraries = map[string]string{
+	"rapid":  "pgregory.net/rapid",
+	"gopter": "github.com/leanovate/gopter",
+	"quick":  "testing/quick",
+}
+
+var (
+	contractClausePattern = regexp.MustCompile(`(?i)\b(must|never|always|guarantee[sd]?|at (most|least)|panics?|idempotent|invariant|sorted|unique|non-negative|positive|returns? [^.]* (if|when|unless))\b`)
+	// roundTripPairs are name prefixes of functions that undo each other.
+	roundTripPairs = [][2]string{
+		{"Encode", "Decode"}, {"Marshal", "Unmarshal"}, {"Compress", "Decompress"},
+		{"Encrypt", "Decrypt"}, {"Serialize", "Deserialize"}, {"Format", "Parse"},
+		{"Pack", "Unpack"}, {"Escape", "Unescape"}, {"Quote", "Unquote"}, {"To", "From"},
+	}
+	lengthPreservingPattern = regexp.MustCompile(`^(Sort|Reverse|Shuffle|Map|Transform)`)
+)
+
+type propertyLibrary struct {
+	Name       string `json:"name"`
+	ImportPath string `json:"import_path"`
+	// Required is set when go.mod requires the library's module.
+	Required  bool `json:"required"`
+	TestFiles int  `json:"test_files"`
+}
+
+// propertyContract is what the signature and doc comment of a function say
+// about it.
+type propertyContract struct {
+	Package      string            `json:"package"`
+	Function     string            `json:"function"`
+	Signature    string            `json:"signature"`
+	Receiver     *propertyParam    `json:"receiver,omitempty"`
+	Params       []propertyParam   `json:"params"`
+	Results      []string          `json:"results"`
+	ReturnsError bool              `json:"returns_error"`
+	Doc          string            `json:"doc,omitempty"`
+	Clauses      []string          `json:"clauses,omitempty"`
+	Location     protocol.Location `json:"location"`
+}
+
+type propertyParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Generator is the library's generator expression for the type; it is
+	// empty for testing/quick, which generates arguments by reflection.
+	Generator string `json:"generator,omitempty"`
+	variadic  bool
+}
+
+type propertySuggestion struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Check       string `json:"check"`
+}
+
+func (t *LSPTools) registerSuggestProperties(s *server.MCPServer) {
+	tool := mcp.NewTool("suggest_properties",
+		mcp.WithDescription("Detect the property-testing libraries in use (pgregory.net/rapid, gopter, testing/quick) and, for a function, extract its contract from the signature and doc comment and return a scaffold of generators and candidate properties (no panics, determinism, error results, round trips with a partner function, idempotence, algebraic laws, documented guarantees) for the agent to fill in. With run, execute the property test and report counterexamples"),
+		mcp.WithTitleAnnotation("Suggest Properties"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("function",
+			mcp.Required(),
+			mcp.Description("Function or method, optionally qualified by package name or import path (Parse, codec.Parse, Decoder.Decode)"),
+		),
+		mcp.WithString("library", mcp.Description("rapid, gopter or quick (default the library the workspace already uses, else quick)")),
+		mcp.WithBoolean("run", mcp.Description("Run the function's property test (Test<Function>Properties) and report the results (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		args := request.GetArguments()
+		name, err := getStringArg(args, "function")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		library := getOptionalStringArg(args, "library")
+		if _, ok := propertyLibraries[library]; library != "" && !ok {
+			return mcp.NewToolResultError("library must be rapid, gopter or quick"), nil
+		}
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		pkg, file, fn, err := findPropertyTarget(ws, name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		detected := detectPropertyLibraries(ws)
+		payload := map[string]any{"libraries": detected}
+		if library == "" {
+			library = "quick"
+			if len(detected) > 0 {
+				library = detected[0].Name
+			} else {
+				payload["note"] = "no property-testing library is in use; the scaffold uses testing/quick from the standard library, while pgregory.net/rapid adds shrinking and richer generators"
+			}
+		}
+
+		contract := extractPropertyContract(pkg, file, fn, library)
+		suggestions := suggestProperties(pkg, fn, contract)
+		testName := propertyTestName(fn)
+		testFile := strings.TrimSuffix(file.Path, ".go") + "_test.go"
+		_, statErr := os.Stat(testFile)
+
+		payload["library"] = library
+		payload["contract"] = contract
+		payload["properties"] = suggestions
+		payload["test"] = testName
+		payload["test_file"] = relativeSlashPath(t.workspaceDir, testFile)
+		payload["test_file_exists"] = statErr == nil
+		payload["imports"] = []string{"testing", propertyLibraries[library]}
+		if library == "gopter" {
+			payload["imports"] = []string{"testing", "github.com/leanovate/gopter", "github.com/leanovate/gopter/gen", "github.com/leanovate/gopter/prop"}
+		}
+		payload["scaffold"] = propertyScaffold(library, testName, contract, suggestions)
+
+		if getOptionalBoolArg(args, "run") {
+			pattern := "^" + regexp.QuoteMeta(testName) + "$"
+			sendProgressNotification(ctx, s, token, fmt.Sprintf("Running %s in %s", testName, pkg.ImportPath))
+			result, runErr := t.runCommandSpec(ctx, s, token, commandSpec{
+				name: "go",
+				args: []string{"test", "-json", "-count=1", "-run", pattern, "./" + filepath.ToSlash(pkg.RelDir)},
+			})
+			summary := parseTestEvents(result.Stdout)
+			result.Stdout = summary.Output
+			if runErr != nil && len(summary.Packages) == 0 {
+				return t.commandFailureResult("go test", result, runErr)
+			}
+			for _, pkgResult := range summary.Packages {
+				if pkgResult.Crash != nil {
+					pkgResult.Crash.symbolicate(t.workspaceDir)
+				}
+			}
+			counterexamples := []map[string]any{}
+			for _, failure := range summary.Failures {
+				if values := parseCounterexample(failure.Output); len(values) > 0 {
+					counterexamples = append(counterexamples, map[string]any{"test": failure.Test, "values": values})
+				}
+			}
+			run := map[string]any{"summary": summary, "counterexamples": counterexamples}
+			if summary.Passed+summary.Failed+summary.Skipped == 0 && runErr == nil {
+				run["note"] = fmt.Sprintf("no test matched %s; add the scaffold to %s first", pattern, relativeSlashPath(t.workspaceDir, testFile))
+			}
+			payload["run"] = run
+		}
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// findPropertyTarget resolves Name, Recv.Name, or either qualified by a
+// package name or import path to a single non-test function declaration.
+func findPropertyTarget(ws *gosrc.Workspace, raw string) (*gosrc.Package, *gosrc.File, *ast.FuncDecl, error) {
+	raw = strings.TrimSpace(raw)
+	type match struct {
+		pkg  *gosrc.Package
+		file *gosrc.File
+		fn   *ast.FuncDecl
+	}
+	var matches []match
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			if file.Test {
+				continue
+			}
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				name := funcDeclName(fn)
+				if raw == name || raw == pkg.Name+"."+name || raw == pkg.ImportPath+"."+name {
+					matches = append(matches, match{pkg, file, fn})
+				}
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil, nil, fmt.Errorf("no function named %s", raw)
+	case 1:
+		return matches[0].pkg, matches[0].file, matches[0].fn, nil
+	}
+	var candidates []string
+	for _, m := range matches {
+		candidates = append(candidates, m.pkg.ImportPath+"."+funcDeclName(m.fn))
+	}
+	return nil, nil, nil, fmt.Errorf("%s is ambiguous (%s); qualify it with the package", raw, strings.Join(candidates, ", "))
+}
+
+// detectPropertyLibraries lists the property-testing libraries required by
+// go.mod or imported by test files, most used first.
+func detectPropertyLibraries(ws *gosrc.Workspace) []propertyLibrary {
+	var required []string
+	if data, err := os.ReadFile(filepath.Join(ws.Root, "go.mod")); err == nil {
+		if mod, err := modfile.ParseLax("go.mod", data, nil); err == nil {
+			for _, req := range mod.Require {
+				required = append(required, req.Mod.Path)
+			}
+		}
+	}
+	libraries := []propertyLibrary{}
+	for _, name := range []string{"rapid", "gopter", "quick"} {
+		library := propertyLibrary{Name: name, ImportPath: propertyLibraries[name], Required: slices.Contains(required, propertyLibraries[name])}
+		for _, pkg := range ws.Packages {
+			for _, file := range pkg.Files {
+				if file.Test && hasImportPrefix(fileImports(file.Syntax), library.ImportPath) {
+					library.TestFiles++
+				}
+			}
+		}
+		if library.Required || library.TestFiles > 0 {
+			libraries = append(libraries, library)
+		}
+	}
+	slices.SortStableFunc(libraries, func(a, b propertyLibrary) int { return b.TestFiles - a.TestFiles })
+	return libraries
+}
+
+func extractPropertyContract(pkg *gosrc.Package, file *gosrc.File, fn *ast.FuncDecl, library string) propertyContract {
+	structs := packageStructs(pkg)
+	contract := propertyContract{
+		Package:   pkg.ImportPath,
+		Function:  funcDeclName(fn),
+		Signature: strings.TrimSuffix(file.Text(fn.Pos(), fn.Body.Lbrace), " "),
+		Params:    []propertyParam{},
+		Results:   []string{},
+		Doc:       strings.TrimSpace(fn.Doc.Text()),
+		Location:  sourceLocation(file, fn.Name.Pos(), fn.Name.End()),
+	}
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		recv := fn.Recv.List[0].Type
+		contract.Receiver = &propertyParam{Name: "recv", Type: types.ExprString(recv), Generator: propertyGenerator(library, recv, structs)}
+	}
+	taken := map[string]bool{"t": true, "recv": true, "got": true, "err": true}
+	for _, field := range fn.Type.Params.List {
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		typ := field.Type
+		variadic := false
+		if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+			typ, variadic = &ast.ArrayType{Elt: ellipsis.Elt}, true
+		}
+		for _, ident := range names {
+			name := fmt.Sprintf("arg%d", len(contract.Params))
+			if ident != nil && ident.Name != "_" && !taken[ident.Name] {
+				name = ident.Name
+			}
+			taken[name] = true
+			contract.Params = append(contract.Params, propertyParam{Name: name, Type: types.ExprString(typ), Generator: propertyGenerator(library, typ, structs), variadic: variadic})
+		}
+	}
+	if fn.Type.Results != nil {
+		for _, field := range fn.Type.Results.List {
+			for range max(len(field.Names), 1) {
+				contract.Results = append(contract.Results, types.ExprString(field.Type))
+			}
+		}
+	}
+	contract.ReturnsError = len(contract.Results) > 0 && contract.Results[len(contract.Results)-1] == "error"
+	for _, sentence := range strings.SplitAfter(strings.Join(strings.Fields(contract.Doc), " "), ". ") {
+		if sentence = strings.TrimSpace(sentence); contractClausePattern.MatchString(sentence) {
+			contract.Clauses = append(contract.Clauses, sentence)
+		}
+	}
+	return contract
+}
+
+// packageStructs maps the struct types declared in pkg by name.
+func packageStructs(pkg *gosrc.Package) map[string]bool {
+	structs := make(map[string]bool)
+	for _, file := range pkg.Files {
+		for _, decl := range file.Syntax.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					if _, ok := typeSpec.Type.(*ast.StructType); ok {
+						structs[typeSpec.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return structs
+}
+
+// propertyGenerator writes the generator expression for typ. Types the
+// library cannot derive are left as a TODO for the agent.
+func propertyGenerator(library string, typ ast.Expr, structs map[string]bool) string {
+	text := types.ExprString(typ)
+	switch library {
+	case "rapid":
+		switch e := typ.(type) {
+		case *ast.Ident:
+			if basic, ok := rapidBasicGenerators[e.Name]; ok {
+				return basic
+			}
+		case *ast.ArrayType:
+			if e.Len == nil {
+				return "rapid.SliceOf(" + propertyGenerator(library, e.Elt, structs) + ")"
+			}
+		case *ast.MapType:
+			return "rapid.MapOf(" + propertyGenerator(library, e.Key, structs) + ", " + propertyGenerator(library, e.Value, structs) + ")"
+		case *ast.StarExpr:
+			return "rapid.Ptr(" + propertyGenerator(library, e.X, structs) + ", true)"
+		case *ast.InterfaceType, *ast.FuncType, *ast.ChanType:
+			return "rapid.Custom(func(t *rapid.T) " + text + " { panic(\"TODO: generate " + text + "\") })"
+		}
+		return "rapid.Make[" + text + "]()"
+	case "gopter":
+		switch e := typ.(type) {
+		case *ast.Ident:
+			if basic, ok := gopterBasicGenerators[e.Name]; ok {
+				return basic
+			}
+			if structs[e.Name] {
+				return "gen.Struct(reflect.TypeOf(" + text + "{}), map[string]gopter.Gen{ /* TODO: field generators */ })"
+			}
+		case *ast.ArrayType:
+			if e.Len == nil {
+				return "gen.SliceOf(" + propertyGenerator(library, e.Elt, structs) + ")"
+			}
+		case *ast.MapType:
+			return "gen.MapOf(" + propertyGenerator(library, e.Key, structs) + ", " + propertyGenerator(library, e.Value, structs) + ")"
+		case *ast.StarExpr:
+			return "gen.PtrOf(" + propertyGenerator(library, e.X, structs) + ")"
+		}
+		return "nil /* TODO: gopter.Gen for " + text + " */"
+	}
+	return ""
+}
+
+var (
+	rapidBasicGenerators = map[string]string{
+		"int": "rapid.Int()", "int8": "rapid.Int8()", "int16": "rapid.Int16()", "int32": "rapid.Int32()", "int64": "rapid.Int64()",
+		"uint": "rapid.Uint()", "uint8": "rapid.Uint8()", "uint16": "rapid.Uint16()", "uint32": "rapid.Uint32()", "uint64": "rapid.Uint64()",
+		"uintptr": "rapid.Uintptr()", "byte": "rapid.Byte()", "rune": "rapid.Rune()", "string": "rapid.String()", "bool": "rapid.Bool()",
+		"float32": "rapid.Float32()", "float64": "rapid.Float64()",
+	}
+	gopterBasicGenerators = map[string]string{
+		"int": "gen.Int()", "int8": "gen.Int8()", "int16": "gen.Int16()", "int32": "gen.Int32()", "int64": "gen.Int64()",
+		"uint": "gen.UInt()", "uint8": "gen.UInt8()", "uint16": "gen.UInt16()", "uint32": "gen.UInt32()", "uint64": "gen.UInt64()",
+		"byte": "gen.UInt8()", "rune": "gen.Rune()", "string": "gen.AnyString()", "bool": "gen.Bool()",
+		"float32": "gen.Float32()", "float64": "gen.Float64()",
+	}
+)
+
+// suggestProperties derives candidate properties from the contract. They
+// are starting points: the agent decides which hold.
+func suggestProperties(pkg *gosrc.Package, fn *ast.FuncDecl, contract propertyContract) []propertySuggestion {
+	call := propertyCall(contract)
+	suggestions := []propertySuggestion{{
+		Kind:        "no_panic",
+		Description: fmt.Sprintf("%s does not panic on any input", contract.Function),
+		Check:       call,
+	}}
+	values := resultValues(contract)
+	if len(values) > 0 && contract.Receiver == nil {
+		suggestions = append(suggestions, propertySuggestion{
+			Kind:        "deterministic",
+			Description: "calling it twice with the same arguments gives the same results",
+			Check:       fmt.Sprintf("reflect.DeepEqual(%s, %s)", call, call),
+		})
+		if len(values) > 1 {
+			suggestions[len(suggestions)-1].Check = fmt.Sprintf("call %s twice and compare %s", call, strings.Join(values, ", "))
+		}
+	}
+	if contract.ReturnsError && len(values) > 1 {
+		suggestions = append(suggestions, propertySuggestion{
+			Kind:        "error_zero_value",
+			Description: "when it returns an error the other results are zero values",
+			Check:       fmt.Sprintf("err == nil || reflect.ValueOf(%s).IsZero()", values[0]),
+		})
+	}
+
+	inputs := make([]string, len(contract.Params))
+	for i, param := range contract.Params {
+		inputs[i] = param.Type
+	}
+	outputs := contract.Results
+	if contract.ReturnsError {
+		outputs = outputs[:len(outputs)-1]
+	}
+	name, callee := fn.Name.Name, fn.Name.Name
+	if contract.Receiver != nil {
+		callee = "recv." + name
+	}
+	if partner := roundTripPartner(pkg, fn); partner != nil && len(inputs) == 1 && len(outputs) == 1 {
+		undo := partner.Name.Name
+		if contract.Receiver != nil {
+			undo = "recv." + undo
+		}
+		x := contract.Params[0].Name
+		check := fmt.Sprintf("reflect.DeepEqual(%s(%s(%s)), %s)", undo, callee, x, x)
+		if contract.ReturnsError || funcReturnsError(partner) {
+			// Calls returning errors do not nest.
+			check = fmt.Sprintf("mid, _ := %s(%s); back, _ := %s(mid); reflect.DeepEqual(back, %s)", callee, x, undo, x)
+			if !contract.ReturnsError {
+				check = strings.Replace(check, "mid, _ :=", "mid :=", 1)
+			}
+			if !funcReturnsError(partner) {
+				check = strings.Replace(check, "back, _ :=", "back :=", 1)
+			}
+		}
+		suggestions = append(suggestions, propertySuggestion{
+			Kind:        "round_trip",
+			Description: fmt.Sprintf("%s undoes %s", partner.Name.Name, name),
+			Check:       check,
+		})
+	}
+	if len(inputs) == 1 && len(outputs) == 1 && inputs[0] == outputs[0] && !contract.ReturnsError {
+		x := contract.Params[0].Name
+		suggestions = append(suggestions, propertySuggestion{
+			Kind:        "idempotent",
+			Description: fmt.Sprintf("if %s normalizes its input, applying it twice changes nothing", name),
+			Check:       fmt.Sprintf("reflect.DeepEqual(%s(%s(%s)), %s(%s))", callee, callee, x, callee, x),
+		})
+		if strings.HasPrefix(inputs[0], "[]") && lengthPreservingPattern.MatchString(name) {
+			suggestions = append(suggestions, propertySuggestion{
+				Kind:        "preserves_length",
+				Description: fmt.Sprintf("%s keeps the number of elements", name),
+				Check:       fmt.Sprintf("len(%s(%s)) == len(%s)", callee, x, x),
+			})
+		}
+	}
+	if len(inputs) == 2 && len(outputs) == 1 && inputs[0] == inputs[1] && inputs[0] == outputs[0] && !contract.ReturnsError {
+		a, b := contract.Params[0].Name, contract.Params[1].Name
+		suggestions = append(suggestions,
+			propertySuggestion{
+				Kind:        "commutative",
+				Description: fmt.Sprintf("the order of the arguments of %s does not matter", name),
+				Check:       fmt.Sprintf("reflect.DeepEqual(%s(%s, %s), %s(%s, %s))", callee, a, b, callee, b, a),
+			},
+			propertySuggestion{
+				Kind:        "associative",
+				Description: fmt.Sprintf("%s can be regrouped", name),
+				Check:       fmt.Sprintf("reflect.DeepEqual(%[1]s(%[1]s(%[2]s, %[3]s), c), %[1]s(%[2]s, %[1]s(%[3]s, c))) for a third value c", callee, a, b),
+			})
+	}
+	for _, clause := range contract.Clauses {
+		suggestions = append(suggestions, propertySuggestion{
+			Kind:        "documented",
+			Description: clause,
+			Check:       "TODO: assert the documented guarantee",
+		})
+	}
+	return suggestions
+}
+
+// roundTripPartner finds the function or method of the same receiver that
+// undoes fn by name, such as Decode for Encode.
+func roundTripPartner(pkg *gosrc.Package, fn *ast.FuncDecl) *ast.FuncDecl {
+	name := fn.Name.Name
+	var candidates []string
+	for _, pair := range roundTripPairs {
+		for _, order := range [][2]string{pair, {pair[1], pair[0]}} {
+			if rest, ok := strings.CutPrefix(name, order[0]); ok && (rest == "" || unicode.IsUpper(rune(rest[0]))) {
+				candidates = append(candidates, order[1]+rest)
+			}
+		}
+	}
+	recv := strings.TrimSuffix(funcDeclName(fn), name)
+	for _, file := range pkg.Files {
+		if file.Test {
+			continue
+		}
+		for _, decl := range file.Syntax.Decls {
+			other, ok := decl.(*ast.FuncDecl)
+			if !ok || other == fn || !slices.Contains(candidates, other.Name.Name) {
+				continue
+			}
+			if otherRecv := strings.TrimSuffix(funcDeclName(other), other.Name.Name); otherRecv == recv {
+				return other
+			}
+		}
+	}
+	return nil
+}
+
+func funcReturnsError(fn *ast.FuncDecl) bool {
+	results := fn.Type.Results
+	if results == nil || len(results.List) == 0 {
+		return false
+	}
+	last, ok := results.List[len(results.List)-1].Type.(*ast.Ident)
+	return ok && last.Name == "error"
+}
+
+// resultValues names the results of the call: got (or got1, got2...) and
+// err for a trailing error.
+func resultValues(contract propertyContract) []string {
+	plain := len(contract.Results)
+	if contract.ReturnsError {
+		plain--
+	}
+	var values []string
+	for i := range contract.Results {
+		switch {
+		case i == plain:
+			values = append(values, "err")
+		case plain == 1:
+			values = append(values, "got")
+		default:
+			values = append(values, fmt.Sprintf("got%d", i+1))
+		}
+	}
+	return values
+}
+
+// propertyCall writes the call of the function under test with the
+// contract's parameter names.
+func propertyCall(contract propertyContract) string {
+	args := make([]string, len(contract.Params))
+	for i, param := range contract.Params {
+		args[i] = param.Name
+		if param.variadic {
+			args[i] += "..."
+		}
+	}
+	name := contract.Function
+	if contract.Receiver != nil {
+		_, method, _ := strings.Cut(name, ".")
+		name = "recv." + method
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func propertyTestName(fn *ast.FuncDecl) string {
+	return "Test" + strings.ReplaceAll(funcDeclName(fn), ".", "_") + "Properties"
+}
+
+// propertyScaffold writes a test function for library that draws the
+// arguments, calls the function and lists the suggested properties as
+// TODOs.
+func propertyScaffold(library, testName string, contract propertyContract, suggestions []propertySuggestion) string {
+	params := contract.Params
+	if contract.Receiver != nil {
+		params = append([]propertyParam{*contract.Receiver}, params...)
+	}
+	values := resultValues(contract)
+	var body strings.Builder
+	if len(values) > 0 {
+		fmt.Fprintf(&body, "%s := %s\n", strings.Join(values, ", "), propertyCall(contract))
+	} else {
+		body.WriteString(propertyCall(contract) + "\n")
+	}
+	for _, suggestion := range suggestions[1:] {
+		fmt.Fprintf(&body, "// TODO(%s): %s\n//\t%s\n", suggestion.Kind, suggestion.Description, suggestion.Check)
+	}
+	if len(values) > 0 {
+		fmt.Fprintf(&body, "_ = []any{%s}\n", strings.Join(values, ", "))
+	}
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "func %s(t *testing.T) {\n", testName)
+	switch library {
+	case "rapid":
+		src.WriteString("rapid.Check(t, func(t *rapid.T) {\n")
+		for _, param := range params {
+			fmt.Fprintf(&src, "%s := %s.Draw(t, %q)\n", param.Name, param.Generator, param.Name)
+		}
+		src.WriteString(body.String())
+		src.WriteString("})\n")
+	case "gopter":
+		src.WriteString("properties := gopter.NewProperties(nil)\n")
+		fmt.Fprintf(&src, "properties.Property(%q, prop.ForAll(\nfunc(%s) bool {\n", contract.Function, propertySignature(params))
+		src.WriteString(body.String())
+		src.WriteString("return true\n},\n")
+		for _, param := range params {
+			src.WriteString(param.Generator + ",\n")
+		}
+		src.WriteString("))\nproperties.TestingRun(t)\n")
+	default:
+		fmt.Fprintf(&src, "property := func(%s) bool {\n", propertySignature(params))
+		src.WriteString(body.String())
+		src.WriteString("return true\n}\n")
+		src.WriteString("if err := quick.Check(property, nil); err != nil {\nt.Error(err)\n}\n")
+	}
+	src.WriteString("}\n")
+	if formatted, err := format.Source([]byte(src.String())); err == nil {
+		return string(formatted)
+	}
+	return src.String()
+}
+
+func propertySignature(params []propertyParam) string {
+	parts := make([]string, len(params))
+	for i, param := range params {
+		parts[i] = param.Name + " " + param.Type
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseCounterexample extracts the failing input reported by rapid
+// ("[rapid] draw x: 1"), gopter ("ARG_0: 1") or testing/quick ("failed on
+// input").
+func parseCounterexample(output string) []string {
+	var values []string
+	for _, line := range splitLines(output) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[rapid] draw "):
+			values = append(values, strings.TrimPrefix(line, "[rapid] draw "))
+		case strings.HasPrefix(line, "ARG_"):
+			values = append(values, line)
+		case strings.Contains(line, "failed on input "):
+			_, input, _ := strings.Cut(line, "failed on input ")
+			values = append(values, input)
+		}
+	}
+	return values
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+const propertiesSource = `package codec
+
+// Encode escapes s. It never returns an empty string for a non-empty input.
+func Encode(s string) (string, error) { return s, nil }
+
+// Decode undoes Encode.
+func Decode(s string) (string, error) { return s, nil }
+
+func Merge(a, b []int) []int { return append(a, b...) }
+
+type Set struct{ Items []string }
+
+func (s *Set) Add(items ...string) int { return len(items) }
+`
+
+func loadPropertiesWorkspace(t *testing.T) *gosrc.Workspace {
+	t.Helper()
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n\nrequire pgregory.net/rapid v1.2.0\n")
+	writeWorkspaceFile(t, workspace, "codec/codec.go", propertiesSource)
+	writeWorkspaceFile(t, workspace, "codec/quick_test.go", "package codec\n\nimport \"testing/quick\"\n\nvar _ = quick.Check\n")
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return ws
+}
+
+func propertyKinds(suggestions []propertySuggestion) []string {
+	var kinds []string
+	for _, suggestion := range suggestions {
+		kinds = append(kinds, suggestion.Kind)
+	}
+	return kinds
+}
+
+func TestDetectPropertyLibraries(t *testing.T) {
+	ws := loadPropertiesWorkspace(t)
+	got := detectPropertyLibraries(ws)
+	want := []propertyLibrary{
+		{Name: "quick", ImportPath: "testing/quick", TestFiles: 1},
+		{Name: "rapid", ImportPath: "pgregory.net/rapid", Required: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("libraries = %+v, want %+v", got, want)
+	}
+}
+
+func TestSuggestPropertiesContract(t *testing.T) {
+	ws := loadPropertiesWorkspace(t)
+
+	pkg, file, fn, err := findPropertyTarget(ws, "codec.Encode")
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	contract := extractPropertyContract(pkg, file, fn, "rapid")
+	if contract.Signature != "func Encode(s string) (string, error)" || !contract.ReturnsError || contract.Params[0].Generator != "rapid.String()" {
+		t.Fatalf("unexpected contract %+v", contract)
+	}
+	if !reflect.DeepEqual(contract.Clauses, []string{"It never returns an empty string for a non-empty input."}) {
+		t.Fatalf("clauses = %#v", contract.Clauses)
+	}
+	suggestions := suggestProperties(pkg, fn, contract)
+	if got, want := propertyKinds(suggestions), []string{"no_panic", "deterministic", "error_zero_value", "round_trip", "documented"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("kinds = %v, want %v", got, want)
+	}
+	if check := suggestions[3].Check; check != "mid, _ := Encode(s); back, _ := Decode(mid); reflect.DeepEqual(back, s)" {
+		t.Fatalf("round trip check = %q", check)
+	}
+
+	pkg, file, fn, err = findPropertyTarget(ws, "Merge")
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	contract = extractPropertyContract(pkg, file, fn, "gopter")
+	if got, want := propertyKinds(suggestProperties(pkg, fn, contract)), []string{"no_panic", "deterministic", "commutative", "associative"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("kinds = %v, want %v", got, want)
+	}
+	if contract.Params[0].Generator != "gen.SliceOf(gen.Int())" {
+		t.Fatalf("generator = %q", contract.Params[0].Generator)
+	}
+
+	if _, _, _, err := findPropertyTarget(ws, "Missing"); err == nil {
+		t.Fatal("expected an error for an unknown function")
+	}
+}
+
+func TestPropertyScaffoldParses(t *testing.T) {
+	ws := loadPropertiesWorkspace(t)
+	pkg, file, fn, err := findPropertyTarget(ws, "Set.Add")
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	for _, library := range []string{"rapid", "gopter", "quick"} {
+		contract := extractPropertyContract(pkg, file, fn, library)
+		scaffold := propertyScaffold(library, propertyTestName(fn), contract, suggestProperties(pkg, fn, contract))
+		if !strings.HasPrefix(scaffold, "func TestSet_AddProperties(t *testing.T) {") || !strings.Contains(scaffold, "recv.Add(items...)") {
+			t.Fatalf("unexpected %s scaffold:\n%s", library, scaffold)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "x_test.go", "package codec\n\n"+scaffold, 0); err != nil {
+			t.Fatalf("%s scaffold does not parse: %v\n%s", library, err, scaffold)
+		}
+	}
+}
+
+func TestParseCounterexample(t *testing.T) {
+	output := strings.Join([]string{
+		"=== RUN   TestEncodeProperties",
+		"    encode_test.go:12: [rapid] failed after 3 tests: boom",
+		"        [rapid] draw s: \"\\x00\"",
+		"! Encode: Falsified after 2 passed tests.",
+		"ARG_0: \"a\"",
+		"    codec_test.go:13: #98: failed on input \"X\"",
+	}, "\n")
+	want := []string{`s: "\x00"`, `ARG_0: "a"`, `"X"`}
+	if got := parseCounterexample(output); !reflect.DeepEqual(got, want) {
+		t.Fatalf("counterexample = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,403 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	testDependencyDocker   = "docker"
+	testDependencyDatabase = "database"
+	testDependencyService  = "service"
+	testDependencyCloud    = "cloud"
+	testDependencyNetwork  = "network"
+	testDependencyBinary   = "binary"
+)
+
+var (
+	// testDependencyImports are client libraries whose use in a test means
+	// the test talks to something outside the process.
+	testDependencyImports = map[string]string{
+		"github.com/testcontainers/testcontainers-go": testDependencyDocker,
+		"github.com/ory/dockertest":                   testDependencyDocker,
+		"github.com/docker/docker/client":             testDependencyDocker,
+		"github.com/fsouza/go-dockerclient":           testDependencyDocker,
+		"github.com/lib/pq":                           testDependencyDatabase,
+		"github.com/jackc/pgx":                        testDependencyDatabase,
+		"github.com/go-sql-driver/mysql":              testDependencyDatabase,
+		"go.mongodb.org/mongo-driver":                 testDependencyDatabase,
+		"github.com/redis/go-redis":                   testDependencyService,
+		"github.com/go-redis/redis":                   testDependencyService,
+		"github.com/segmentio/kafka-go":               testDependencyService,
+		"github.com/IBM/sarama":                       testDependencyService,
+		"github.com/Shopify/sarama":                   testDependencyService,
+		"github.com/nats-io/nats.go":                  testDependencyService,
+		"github.com/aws/aws-sdk-go":                   testDependencyCloud,
+		"github.com/aws/aws-sdk-go-v2":                testDependencyCloud,
+		"cloud.google.com/go":                         testDependencyCloud,
+		"github.com/Azure/azure-sdk-for-go":           testDependencyCloud,
+	}
+	// testDialCalls are the calls whose address argument is dialled.
+	testDialCalls = map[string]map[string]int{
+		"net/http":               {"Get": 0, "Head": 0, "Post": 0, "PostForm": 0, "NewRequest": 1, "NewRequestWithContext": 2},
+		"net":                    {"Dial": 1, "DialTimeout": 1},
+		"google.golang.org/grpc": {"Dial": 0, "DialContext": 1, "NewClient": 0},
+	}
+	dockerBinaries = map[string]bool{"docker": true, "docker-compose": true, "podman": true}
+)
+
+type testRequirements struct {
+	Package        string             `json:"package"`
+	Dir            string             `json:"dir"`
+	TestMain       *protocol.Location `json:"test_main,omitempty"`
+	GatedFiles     []gatedTestFile    `json:"gated_files,omitempty"`
+	Env            []testEnvVar       `json:"env,omitempty"`
+	Dependencies   []testDependency   `json:"dependencies,omitempty"`
+	ShortSkips     bool               `json:"short_mode_skips,omitempty"`
+	DefaultRunSafe bool               `json:"default_run_safe"`
+	Reasons        []string           `json:"reasons,omitempty"`
+}
+
+// gatedTestFile is a test file excluded from a plain go test run by a
+// custom build tag such as integration or e2e.
+type gatedTestFile struct {
+	File       string   `json:"file"`
+	Constraint string   `json:"constraint"`
+	Tags       []string `json:"tags"`
+	Tests      []string `json:"tests,omitempty"`
+}
+
+type testEnvVar struct {
+	Name string `json:"name"`
+	// SkipsWhenUnset means the test calls t.Skip when the variable is
+	// missing, so it only gates the test instead of failing it.
+	SkipsWhenUnset bool              `json:"skips_when_unset"`
+	Gated          bool              `json:"gated,omitempty"`
+	Location       protocol.Location `json:"location"`
+}
+
+type testDependency struct {
+	Kind     string            `json:"kind"`
+	Detail   string            `json:"detail"`
+	Gated    bool              `json:"gated,omitempty"`
+	Location protocol.Location `json:"location"`
+}
+
+func (t *LSPTools) registerAnalyzeTestRequirements(s *server.MCPServer) {
+	tool := mcp.NewTool("analyze_test_requirements",
+		mcp.WithDescription("Report, per package, what its tests need before they can run: TestMain, test files gated behind build tags such as integration, environment variables (and whether missing ones skip or fail), and external dependencies like docker, databases, cloud services, network endpoints and binaries"),
+		mcp.WithTitleAnnotation("Analyze Test Requirements"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only analyze packages whose import path starts with this prefix")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true, AllPlatforms: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"packages":     analyzeTestRequirements(ws, prefix),
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// analyzeTestRequirements merges a package and its external test package
+// into one entry. Requirements found only in gated files are marked so and
+// do not make the default run unsafe.
+func analyzeTestRequirements(ws *gosrc.Workspace, prefix string) []testRequirements {
+	consts := stringConstants(ws)
+	byPackage := make(map[string]*testRequirements)
+	var order []string
+	for _, pkg := range ws.Packages {
+		importPath := strings.TrimSuffix(pkg.ImportPath, "_test")
+		if prefix != "" && !strings.HasPrefix(importPath, prefix) {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if !file.Test {
+				continue
+			}
+			req := byPackage[importPath]
+			if req == nil {
+				req = &testRequirements{Package: importPath, Dir: pkg.RelDir}
+				byPackage[importPath] = req
+				order = append(order, importPath)
+			}
+			gated := gatedTestConstraint(req, file)
+			scanTestFile(req, pkg, file, consts, gated)
+		}
+	}
+
+	packages := make([]testRequirements, 0, len(order))
+	for _, importPath := range order {
+		req := byPackage[importPath]
+		for _, dep := range req.Dependencies {
+			if !dep.Gated {
+				reason := fmt.Sprintf("needs %s (%s)", dep.Kind, dep.Detail)
+				if !slices.Contains(req.Reasons, reason) {
+					req.Reasons = append(req.Reasons, reason)
+				}
+			}
+		}
+		req.DefaultRunSafe = len(req.Reasons) == 0
+		packages = append(packages, *req)
+	}
+	return packages
+}
+
+// gatedTestConstraint records file as gated when its build constraint uses
+// custom tags and is false on the host without them.
+func gatedTestConstraint(req *testRequirements, file *gosrc.File) bool {
+	text := buildConstraint(file.Syntax)
+	if text == "" {
+		return false
+	}
+	expr, err := constraint.Parse(text)
+	if err != nil {
+		return false
+	}
+	var tags []string
+	enabled := expr.Eval(func(tag string) bool {
+		if !isCustomBuildTag(tag) {
+			return hostBuildTag(tag)
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+		return false
+	})
+	if len(tags) == 0 || enabled {
+		return false
+	}
+	gated := gatedTestFile{File: file.RelPath, Constraint: strings.TrimPrefix(text, "//go:build "), Tags: tags}
+	for _, decl := range file.Syntax.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+			if _, _, ok := testFuncKind(fn); ok {
+				gated.Tests = append(gated.Tests, fn.Name.Name)
+			}
+		}
+	}
+	req.GatedFiles = append(req.GatedFiles, gated)
+	return true
+}
+
+func isCustomBuildTag(tag string) bool {
+	switch tag {
+	case "unix", "cgo", "gc", "gccgo":
+		return false
+	}
+	return !knownOS[tag] && !knownArch[tag] && !strings.HasPrefix(tag, "go1.")
+}
+
+func hostBuildTag(tag string) bool {
+	ctxt := build.Default
+	switch tag {
+	case "unix":
+		return !slices.Contains([]string{"windows", "plan9", "js", "wasip1"}, ctxt.GOOS)
+	case "cgo":
+		return ctxt.CgoEnabled
+	case "gc", "gccgo":
+		return tag == ctxt.Compiler
+	}
+	return matchOS(tag, ctxt.GOOS) || tag == ctxt.GOARCH || slices.Contains(ctxt.ReleaseTags, tag)
+}
+
+func scanTestFile(req *testRequirements, pkg *gosrc.Package, file *gosrc.File, consts map[string]map[string]string, gated bool) {
+	imports := fileImports(file.Syntax)
+	addDependency := func(kind, detail string, node ast.Node) {
+		req.Dependencies = append(req.Dependencies, testDependency{
+			Kind:     kind,
+			Detail:   detail,
+			Gated:    gated,
+			Location: sourceLocation(file, node.Pos(), node.End()),
+		})
+	}
+
+	for _, spec := range file.Syntax.Imports {
+		importPath, _ := stringValue(spec.Path)
+		for prefix, kind := range testDependencyImports {
+			if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+				addDependency(kind, importPath, spec)
+			}
+		}
+	}
+
+	for _, decl := range file.Syntax.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "TestMain" {
+			location := sourceLocation(file, fn.Name.Pos(), fn.Name.End())
+			req.TestMain = &location
+		}
+	}
+
+	skipping := skippingEnvReads(imports, file.Syntax)
+	ast.Inspect(file.Syntax, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		importPath, name, ok := packageCall(imports, call)
+		if !ok {
+			return true
+		}
+		switch {
+		case importPath == "os" && (name == "Getenv" || name == "LookupEnv") && len(call.Args) == 1:
+			value, ok := constantString(pkg.ImportPath, imports, consts, call.Args[0])
+			if !ok {
+				return true
+			}
+			req.Env = append(req.Env, testEnvVar{
+				Name:           value,
+				SkipsWhenUnset: skipping[call],
+				Gated:          gated,
+				Location:       sourceLocation(file, call.Pos(), call.End()),
+			})
+		case importPath == "testing" && name == "Short":
+			req.ShortSkips = true
+		case importPath == "os/exec" && (name == "Command" || name == "CommandContext" || name == "LookPath"):
+			arg := 0
+			if name == "CommandContext" {
+				arg = 1
+			}
+			if arg >= len(call.Args) {
+				return true
+			}
+			program, ok := constantString(pkg.ImportPath, imports, consts, call.Args[arg])
+			if !ok || program == "go" {
+				return true
+			}
+			if dockerBinaries[path.Base(program)] {
+				addDependency(testDependencyDocker, program, call)
+			} else {
+				addDependency(testDependencyBinary, program, call)
+			}
+		default:
+			arg, ok := testDialCalls[importPath][name]
+			if !ok || arg >= len(call.Args) {
+				return true
+			}
+			address, ok := constantString(pkg.ImportPath, imports, consts, call.Args[arg])
+			if !ok {
+				return true
+			}
+			if host := remoteHost(address); host != "" {
+				addDependency(testDependencyNetwork, host, call)
+			}
+		}
+		return true
+	})
+}
+
+// skippingEnvReads finds the os.Getenv and os.LookupEnv calls whose result
+// decides an if statement that skips the test, either directly in the
+// condition or through a variable assigned from the call.
+func skippingEnvReads(imports map[string]string, file *ast.File) map[*ast.CallExpr]bool {
+	envCalls := func(node ast.Node) []*ast.CallExpr {
+		var calls []*ast.CallExpr
+		if node == nil {
+			return nil
+		}
+		ast.Inspect(node, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if importPath, name, ok := packageCall(imports, call); ok && importPath == "os" && (name == "Getenv" || name == "LookupEnv") {
+					calls = append(calls, call)
+				}
+			}
+			return true
+		})
+		return calls
+	}
+
+	assigned := make(map[string]*ast.CallExpr)
+	skipping := make(map[*ast.CallExpr]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if len(node.Rhs) != 1 {
+				return true
+			}
+			if calls := envCalls(node.Rhs[0]); len(calls) == 1 {
+				for _, lhs := range node.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok && ident.Name != "_" {
+						assigned[ident.Name] = calls[0]
+					}
+				}
+			}
+		case *ast.IfStmt:
+			if !callsSkip(node.Body) {
+				return true
+			}
+			for _, call := range append(envCalls(node.Init), envCalls(node.Cond)...) {
+				skipping[call] = true
+			}
+			ast.Inspect(node.Cond, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok && assigned[ident.Name] != nil {
+					skipping[assigned[ident.Name]] = true
+				}
+				return true
+			})
+		}
+		return true
+	})
+	return skipping
+}
+
+func callsSkip(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if _, name, ok := methodCall(call); ok && (name == "Skip" || name == "Skipf" || name == "SkipNow") {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// remoteHost returns the host an address or URL points at, or "" for
+// loopback and reserved example hosts that never leave the machine.
+func remoteHost(address string) string {
+	host := address
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.Contains(host, "/") {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsUnspecified()) {
+		return ""
+	}
+	for _, suffix := range []string{"example.com", "example.org", "example.net", ".test", ".invalid", ".example"} {
+		if host == suffix || strings.HasSuffix(host, "."+strings.TrimPrefix(suffix, ".")) {
+			return ""
+		}
+	}
+	return host
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestAnalyzeTestRequirements(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", "package store\n")
+	writeWorkspaceFile(t, workspace, "store/main_test.go", `package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+func TestUnit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	dsn := os.Getenv("STORE_DSN")
+	if dsn == "" {
+		t.Skip("STORE_DSN not set")
+	}
+	_ = os.Getenv("STORE_DEBUG")
+}
+`)
+	writeWorkspaceFile(t, workspace, "store/integration_test.go", `//go:build integration && !windows
+
+package store_test
+
+import (
+	"net/http"
+	"os/exec"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+var _ = testcontainers.GenericContainer
+
+func TestPostgres(t *testing.T) {
+	exec.Command("docker", "ps")
+	http.Get("https://api.github.com/repos")
+}
+`)
+	writeWorkspaceFile(t, workspace, "store/unit_test.go", `//go:build !integration
+
+package store
+
+import (
+	"net/http"
+	"os/exec"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	http.Get("http://127.0.0.1:8080/health")
+	http.Get("http://example.com/")
+	exec.Command("go", "version")
+	exec.Command("terraform", "plan")
+}
+`)
+	writeWorkspaceFile(t, workspace, "pure/pure_test.go", "package pure\n\nimport \"testing\"\n\nfunc TestPure(t *testing.T) {}\n")
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true, AllPlatforms: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	packages := analyzeTestRequirements(ws, "")
+	if len(packages) != 2 {
+		t.Fatalf("packages = %+v, want 2", packages)
+	}
+	var store, pure testRequirements
+	for _, pkg := range packages {
+		switch pkg.Package {
+		case "example.com/app/store":
+			store = pkg
+		case "example.com/app/pure":
+			pure = pkg
+		}
+	}
+
+	if !pure.DefaultRunSafe || pure.TestMain != nil || len(pure.Dependencies) != 0 {
+		t.Fatalf("pure = %+v", pure)
+	}
+
+	if store.TestMain == nil {
+		t.Fatal("expected TestMain to be found")
+	}
+	if !store.ShortSkips {
+		t.Fatal("expected testing.Short skip to be reported")
+	}
+	wantGated := []gatedTestFile{{
+		File:       "store/integration_test.go",
+		Constraint: "integration && !windows",
+		Tags:       []string{"integration"},
+		Tests:      []string{"TestPostgres"},
+	}}
+	if !reflect.DeepEqual(store.GatedFiles, wantGated) {
+		t.Fatalf("gated files = %+v, want %+v", store.GatedFiles, wantGated)
+	}
+
+	env := map[string]bool{}
+	for _, v := range store.Env {
+		env[v.Name] = v.SkipsWhenUnset
+	}
+	if !reflect.DeepEqual(env, map[string]bool{"STORE_DSN": true, "STORE_DEBUG": false}) {
+		t.Fatalf("env = %+v", store.Env)
+	}
+
+	var deps []string
+	for _, dep := range store.Dependencies {
+		entry := dep.Kind + " " + dep.Detail
+		if dep.Gated {
+			entry += " gated"
+		}
+		deps = append(deps, entry)
+	}
+	wantDeps := []string{
+		"binary terraform",
+		"docker github.com/testcontainers/testcontainers-go gated",
+		"docker docker gated",
+		"network api.github.com gated",
+	}
+	if !reflect.DeepEqual(deps, wantDeps) {
+		t.Fatalf("dependencies = %#v, want %#v", deps, wantDeps)
+	}
+	if store.DefaultRunSafe || !reflect.DeepEqual(store.Reasons, []string{"needs binary (terraform)"}) {
+		t.Fatalf("default run safe = %v, reasons = %v", store.DefaultRunSafe, store.Reasons)
+	}
+}
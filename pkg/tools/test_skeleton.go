@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const addTestKind = "source.addTest"
+
+func (t *LSPTools) registerAddTest(s *server.MCPServer) {
+	tool := mcp.NewTool("add_test",
+		mcp.WithDescription("Generate a table-driven test skeleton for a function or method with the gopls add_test code action, in the _test.go file gopls picks for it (created when missing) and following the package's existing test style, such as an external _test package. Returns the edit as a unified diff, and writes it when apply is true"),
+		mcp.WithTitleAnnotation("Add Test"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI of the non-test Go file declaring the function"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position of the function's name in its declaration"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("Write the test to disk instead of returning a preview diff (default false)"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := getArguments(request)
+		if err != nil {
+			return nil, err
+		}
+		fileURI, err := getStringArg(args, "file_uri")
+		if err != nil {
+			return nil, err
+		}
+		line, character, err := parsePosition(args)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		if strings.HasSuffix(fileURI, "_test.go") {
+			return mcp.NewToolResultError("file_uri must be the file declaring the function, not a test file"), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not initialized")
+		}
+		position := protocol.Position{Line: line, Character: character}
+		actions, err := lspClient.CodeActions(ctx, fileURI, protocol.Range{Start: position, End: position}, addTestKind)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		var action *protocol.CodeAction
+		for i := range actions {
+			if actions[i].Kind == addTestKind {
+				action = &actions[i]
+				break
+			}
+		}
+		if action == nil {
+			return mcp.NewToolResultError("gopls offers no test for this position; place it on the name of a function or method declared in a non-generated file"), nil
+		}
+		if action.Disabled != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("code action %q is disabled: %s", action.Title, action.Disabled.Reason)), nil
+		}
+
+		edit, problem, err := codeActionEdit(ctx, lspClient, *action)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if problem != "" {
+			return mcp.NewToolResultError(problem), nil
+		}
+		changes, err := workspaceEditChanges(edit)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("compute test diff: %v", err)), nil
+		}
+		if len(changes) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("code action %q produced no edits", action.Title)), nil
+		}
+		apply := getOptionalBoolArg(args, "apply")
+		if apply {
+			if err := t.writeFileChanges(ctx, changes); err != nil {
+				return writeFailureResult("apply test", err), nil
+			}
+		}
+
+		payload := map[string]any{
+			"file_uri": fileURI,
+			"title":    action.Title,
+			"files":    t.summarizeFileChanges(changes),
+			"applied":  apply,
+		}
+		for _, change := range changes {
+			if strings.HasSuffix(change.path, "_test.go") {
+				payload["test_file"] = relativeSlashPath(t.workspaceDir, change.path)
+				payload["created"] = change.created
+			}
+		}
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestAddTest(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nfunc Add(a, b int) int { return a + b }\n")
+	calcURI := convertPathToURI(filepath.Join(workspace, "calc", "calc.go"))
+	testURI := convertPathToURI(filepath.Join(workspace, "calc", "calc_test.go"))
+	skeleton := "package calc_test\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\ttests := []struct {\n\t\tname string\n\t}{}\n\tfor _, tt := range tests {\n\t\tt.Run(tt.name, func(t *testing.T) {})\n\t}\n}\n"
+	fakeClient := &fakeLSPClient{
+		sourceActions: map[string][]protocol.CodeAction{calcURI: {
+			{Title: "Browse documentation for func calc.Add", Kind: "source.doc"},
+			{Title: "Add test for Add", Kind: addTestKind, Command: &protocol.Command{Command: "gopls.add_test"}},
+		}},
+		commands: map[string][]protocol.WorkspaceEdit{"gopls.add_test": {{DocumentChanges: []protocol.TextDocumentEdit{
+			{Kind: "create", URI: testURI},
+			{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{URI: testURI},
+				Edits:        []protocol.TextEdit{{NewText: skeleton}},
+			},
+		}}}},
+	}
+	tools := NewLSPTools(fakeClient, workspace)
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		args["position"] = map[string]any{"line": 2, "character": 5}
+		result, err := server.GetTool("add_test").Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "add_test", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("add_test: %v", err)
+		}
+		return result
+	}
+
+	preview := structured(call(map[string]any{"file_uri": calcURI}))
+	files := preview["files"].([]any)
+	if preview["test_file"] != "calc/calc_test.go" || preview["created"] != true || preview["applied"] != false || len(files) != 1 ||
+		!strings.Contains(files[0].(map[string]any)["diff"].(string), "+func TestAdd(t *testing.T) {") {
+		t.Fatalf("unexpected preview %#v", preview)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "calc", "calc_test.go")); !os.IsNotExist(err) {
+		t.Fatalf("preview wrote the test file: %v", err)
+	}
+	if applied := structured(call(map[string]any{"file_uri": calcURI, "apply": true})); applied["applied"] != true {
+		t.Fatalf("unexpected apply result %#v", applied)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "calc", "calc_test.go")); string(data) != skeleton {
+		t.Fatalf("test file not created: %q", data)
+	}
+
+	if result := call(map[string]any{"file_uri": testURI}); !result.IsError {
+		t.Fatal("expected an error for a test file")
+	}
+	fakeClient.sourceActions[calcURI] = fakeClient.sourceActions[calcURI][:1]
+	if result := call(map[string]any{"file_uri": calcURI}); !result.IsError {
+		t.Fatal("expected an error without a function to test")
+	}
+}
@@ -0,0 +1,390 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+// testSkipMarker tags the skips manage_test_skips adds, so they can be told
+// apart from hand-written ones and found again.
+const testSkipMarker = "mcp-gopls:skip"
+
+var blameHeaderPattern = regexp.MustCompile(`^([0-9a-f]{40}) \d+ (\d+)`)
+
+type testSkip struct {
+	Test    string `json:"test"`
+	Package string `json:"package"`
+	Reason  string `json:"reason,omitempty"`
+	Call    string `json:"call"`
+	// Conditional skips sit inside an if statement or a subtest and usually
+	// gate on the environment; unconditional ones disable the test.
+	Conditional bool `json:"conditional"`
+	// Helper is set when the skip is in a helper rather than a test
+	// function.
+	Helper    bool              `json:"helper,omitempty"`
+	ByTool    bool              `json:"applied_by_tool,omitempty"`
+	Author    string            `json:"author,omitempty"`
+	Committed string            `json:"committed,omitempty"`
+	AgeDays   *int              `json:"age_days,omitempty"`
+	Location  protocol.Location `json:"location"`
+
+	file *gosrc.File
+	// line is one-based, as git blame reports it.
+	line int
+}
+
+type blameLine struct {
+	commit string
+	author string
+	time   time.Time
+}
+
+func (t *LSPTools) registerManageTestSkips(s *server.MCPServer) {
+	tool := mcp.NewTool("manage_test_skips",
+		mcp.WithDescription("List t.Skip calls with their reasons and ages (from git blame), or add or remove an unconditional skip on a named test. Added skips carry a \"// "+testSkipMarker+" <date>\" marker so agent-applied skips stay auditable"),
+		mcp.WithTitleAnnotation("Manage Test Skips"),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("action",
+			mcp.Description("list (default), add or remove"),
+			mcp.Enum("list", "add", "remove"),
+		),
+		mcp.WithString("package", mcp.Description("Only consider packages whose import path starts with this prefix")),
+		mcp.WithString("test", mcp.Description("Test function to add the skip to or remove it from (required for add and remove)")),
+		mcp.WithString("reason", mcp.Description("Why the test is skipped (required for add)")),
+		mcp.WithBoolean("apply", mcp.Description("Write the change to disk instead of returning a preview diff (default false)")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := getProgressToken(request.Params.Meta)
+		args := request.GetArguments()
+		action := getOptionalStringArg(args, "action")
+		prefix := getOptionalStringArg(args, "package")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if action == "" || action == "list" {
+			skips := collectTestSkips(ws, prefix)
+			payload := map[string]any{
+				"skips":        skips,
+				"parse_errors": ws.ParseErrors,
+			}
+			blamed := make(map[*gosrc.File]map[int]blameLine)
+			for i := range skips {
+				file := skips[i].file
+				if _, ok := blamed[file]; !ok {
+					result, err := t.runCommand(ctx, s, token, "git", "blame", "--line-porcelain", "--", file.RelPath)
+					if err != nil {
+						payload["blame_error"] = strings.TrimSpace(buildCommandErrorMessage("git blame", result, err))
+						blamed[file] = nil
+						continue
+					}
+					blamed[file] = parseBlame(result.Stdout)
+				}
+				annotateSkipAge(&skips[i], blamed[file], time.Now())
+			}
+			result, err := mcp.NewToolResultJSON(payload)
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		testName := getOptionalStringArg(args, "test")
+		if testName == "" {
+			return mcp.NewToolResultError("test is required for add and remove"), nil
+		}
+		var change fileChange
+		switch action {
+		case "add":
+			reason := getOptionalStringArg(args, "reason")
+			if reason == "" {
+				return mcp.NewToolResultError("reason is required to add a skip"), nil
+			}
+			change, err = addTestSkip(ws, prefix, testName, reason, time.Now())
+		case "remove":
+			change, err = removeTestSkip(ws, prefix, testName)
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("unknown action %q", action)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		apply := getOptionalBoolArg(args, "apply")
+		if apply {
+			if err := t.writeFileChanges(ctx, []fileChange{change}); err != nil {
+				return writeFailureResult(action+" skip", err), nil
+			}
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"action":  action,
+			"test":    testName,
+			"applied": apply,
+			"files":   t.summarizeFileChanges([]fileChange{change}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func collectTestSkips(ws *gosrc.Workspace, prefix string) []testSkip {
+	skips := []testSkip{}
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if !file.Test {
+				continue
+			}
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				_, _, isTest := testFuncKind(fn)
+				direct := make(map[*ast.CallExpr]bool)
+				for _, stmt := range fn.Body.List {
+					if expr, ok := stmt.(*ast.ExprStmt); ok {
+						if call, ok := expr.X.(*ast.CallExpr); ok {
+							direct[call] = true
+						}
+					}
+				}
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					_, name, ok := methodCall(call)
+					if !ok || (name != "Skip" && name != "Skipf" && name != "SkipNow") {
+						return true
+					}
+					line, _ := file.Position(call.Pos())
+					skips = append(skips, testSkip{
+						Test:        funcDeclName(fn),
+						Package:     strings.TrimSuffix(pkg.ImportPath, "_test"),
+						Reason:      skipReason(call),
+						Call:        name,
+						Conditional: !direct[call],
+						Helper:      !isTest,
+						ByTool:      lineHasSkipMarker(file.Src, file.Offset(call.End())),
+						Location:    sourceLocation(file, call.Pos(), call.End()),
+						file:        file,
+						line:        line + 1,
+					})
+					return true
+				})
+			}
+		}
+	}
+	return skips
+}
+
+func skipReason(call *ast.CallExpr) string {
+	if len(call.Args) == 0 {
+		return ""
+	}
+	if value, ok := stringValue(call.Args[0]); ok && len(call.Args) == 1 {
+		return value
+	}
+	parts := make([]string, 0, len(call.Args))
+	for _, arg := range call.Args {
+		if value, ok := stringValue(arg); ok {
+			parts = append(parts, value)
+		} else {
+			parts = append(parts, exprText(arg))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func lineHasSkipMarker(src []byte, offset int) bool {
+	end := offset
+	for end < len(src) && src[end] != '\n' {
+		end++
+	}
+	return strings.Contains(string(src[offset:end]), "// "+testSkipMarker)
+}
+
+// parseBlame maps the one-based line numbers of git blame --line-porcelain
+// output to the commit that last touched them.
+func parseBlame(output string) map[int]blameLine {
+	lines := make(map[int]blameLine)
+	var current blameLine
+	line := 0
+	for _, text := range strings.Split(output, "\n") {
+		if match := blameHeaderPattern.FindStringSubmatch(text); match != nil {
+			line, _ = strconv.Atoi(match[2])
+			current = blameLine{commit: match[1]}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "author "):
+			current.author = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				current.time = time.Unix(seconds, 0).UTC()
+			}
+		case strings.HasPrefix(text, "\t"):
+			if line > 0 {
+				lines[line] = current
+			}
+		}
+	}
+	return lines
+}
+
+func annotateSkipAge(skip *testSkip, blame map[int]blameLine, now time.Time) {
+	entry, ok := blame[skip.line]
+	if !ok || strings.Trim(entry.commit, "0") == "" {
+		// Uncommitted lines have no age yet.
+		return
+	}
+	skip.Author = entry.author
+	skip.Committed = entry.time.Format(time.DateOnly)
+	days := int(now.Sub(entry.time).Hours() / 24)
+	skip.AgeDays = &days
+}
+
+// findTestFunc locates a test or benchmark by name, requiring a unique
+// match and a named *testing.T or *testing.B parameter to call Skip on.
+func findTestFunc(ws *gosrc.Workspace, prefix, name string) (*gosrc.File, *ast.FuncDecl, string, error) {
+	type match struct {
+		file *gosrc.File
+		fn   *ast.FuncDecl
+		recv string
+	}
+	var matches []match
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if !file.Test {
+				continue
+			}
+			for _, decl := range file.Syntax.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || fn.Body == nil || fn.Name.Name != name {
+					continue
+				}
+				if kind, recv, ok := testFuncKind(fn); ok && (kind == "test" || kind == "benchmark") {
+					matches = append(matches, match{file, fn, recv})
+				}
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil, "", fmt.Errorf("no test or benchmark named %s found", name)
+	case 1:
+	default:
+		files := make([]string, 0, len(matches))
+		for _, m := range matches {
+			files = append(files, m.file.RelPath)
+		}
+		return nil, nil, "", fmt.Errorf("%s is defined in several packages (%s); pass package", name, strings.Join(files, ", "))
+	}
+	m := matches[0]
+	if m.recv == "" || m.recv == "_" {
+		return nil, nil, "", fmt.Errorf("%s does not name its testing parameter", name)
+	}
+	return m.file, m.fn, m.recv, nil
+}
+
+// addTestSkip inserts a marked, unconditional skip as the first statement
+// of the test.
+func addTestSkip(ws *gosrc.Workspace, prefix, name, reason string, now time.Time) (fileChange, error) {
+	file, fn, recv, err := findTestFunc(ws, prefix, name)
+	if err != nil {
+		return fileChange{}, err
+	}
+	for _, skip := range collectTestSkips(ws, prefix) {
+		if skip.file == file && skip.Test == name && !skip.Conditional {
+			return fileChange{}, fmt.Errorf("%s is already skipped unconditionally", name)
+		}
+	}
+
+	lbrace := file.Offset(fn.Body.Lbrace)
+	indent := lineIndent(file.Src, file.Offset(fn.Pos()))
+	text := fmt.Sprintf("\n%s\t%s.Skip(%s) // %s %s", indent, recv, strconv.Quote(reason), testSkipMarker, now.Format(time.DateOnly))
+	lbraceLine, _ := file.Position(fn.Body.Lbrace)
+	rbraceLine, _ := file.Position(fn.Body.Rbrace)
+	if lbraceLine == rbraceLine {
+		text += "\n" + indent
+	}
+	return newFileChange(file.Path, file.Src, []textedit.Edit{{Start: lbrace + 1, End: lbrace + 1, New: text}})
+}
+
+// removeTestSkip deletes the unconditional skips of a test, whole lines
+// included. Skips inside if statements gate on the environment and are
+// left alone.
+func removeTestSkip(ws *gosrc.Workspace, prefix, name string) (fileChange, error) {
+	file, fn, _, err := findTestFunc(ws, prefix, name)
+	if err != nil {
+		return fileChange{}, err
+	}
+	var edits []textedit.Edit
+	for _, stmt := range fn.Body.List {
+		expr, ok := stmt.(*ast.ExprStmt)
+		if !ok {
+			continue
+		}
+		call, ok := expr.X.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		if _, method, ok := methodCall(call); !ok || (method != "Skip" && method != "Skipf" && method != "SkipNow") {
+			continue
+		}
+		start, end := file.Offset(stmt.Pos()), file.Offset(stmt.End())
+		lineStart := start
+		for lineStart > 0 && (file.Src[lineStart-1] == ' ' || file.Src[lineStart-1] == '\t') {
+			lineStart--
+		}
+		lineEnd := end
+		for lineEnd < len(file.Src) && file.Src[lineEnd] != '\n' {
+			lineEnd++
+		}
+		if (lineStart == 0 || file.Src[lineStart-1] == '\n') && lineEnd < len(file.Src) && !strings.Contains(string(file.Src[end:lineEnd]), ";") {
+			// The skip has its line to itself: drop the line, trailing
+			// comment included.
+			start, end = lineStart, lineEnd+1
+		}
+		edits = append(edits, textedit.Edit{Start: start, End: end})
+	}
+	if len(edits) == 0 {
+		return fileChange{}, fmt.Errorf("%s has no unconditional skip; skips inside if statements or subtests are left alone", name)
+	}
+	return newFileChange(file.Path, file.Src, edits)
+}
+
+func lineIndent(src []byte, offset int) string {
+	start := offset
+	for start > 0 && src[start-1] != '\n' {
+		start--
+	}
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
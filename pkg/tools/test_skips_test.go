@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+const skipsTestFile = `package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDisabled(t *testing.T) {
+	t.Skip("flaky on CI, see #42")
+	run(t)
+}
+
+func TestGated(t *testing.T) {
+	if os.Getenv("DB") == "" {
+		t.Skipf("set %s to run", "DB")
+	}
+	t.Run("sub", func(t *testing.T) { t.SkipNow() })
+}
+
+func TestOneLine(t *testing.T) {}
+
+func TestMarked(t *testing.T) {
+	t.Skip("quarantined") // mcp-gopls:skip 2026-01-02
+}
+
+func run(t *testing.T) {
+	t.Helper()
+	requireDocker(t)
+}
+
+func requireDocker(t *testing.T) {
+	t.Skip("no docker")
+}
+`
+
+func loadSkipsWorkspace(t *testing.T) *gosrc.Workspace {
+	t.Helper()
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store_test.go", skipsTestFile)
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return ws
+}
+
+func TestCollectTestSkips(t *testing.T) {
+	ws := loadSkipsWorkspace(t)
+	var got []string
+	for _, skip := range collectTestSkips(ws, "") {
+		entry := skip.Test + " " + skip.Call + " " + skip.Reason
+		if skip.Conditional {
+			entry += " conditional"
+		}
+		if skip.Helper {
+			entry += " helper"
+		}
+		if skip.ByTool {
+			entry += " by_tool"
+		}
+		got = append(got, entry)
+	}
+	want := []string{
+		"TestDisabled Skip flaky on CI, see #42",
+		"TestGated Skipf set %s to run DB conditional",
+		"TestGated SkipNow  conditional",
+		"TestMarked Skip quarantined by_tool",
+		"requireDocker Skip no docker helper",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("skips = %#v, want %#v", got, want)
+	}
+}
+
+func TestAddAndRemoveTestSkip(t *testing.T) {
+	ws := loadSkipsWorkspace(t)
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	change, err := addTestSkip(ws, "", "TestGated", `needs "DB"`, now)
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if !strings.Contains(string(change.after), "func TestGated(t *testing.T) {\n\tt.Skip(\"needs \\\"DB\\\"\") // mcp-gopls:skip 2026-10-17\n\tif os.Getenv") {
+		t.Fatalf("unexpected add result:\n%s", change.after)
+	}
+
+	change, err = addTestSkip(ws, "", "TestOneLine", "broken", now)
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if !strings.Contains(string(change.after), "func TestOneLine(t *testing.T) {\n\tt.Skip(\"broken\") // mcp-gopls:skip 2026-10-17\n}") {
+		t.Fatalf("unexpected one-line add result:\n%s", change.after)
+	}
+
+	if _, err := addTestSkip(ws, "", "TestDisabled", "again", now); err == nil || !strings.Contains(err.Error(), "already skipped") {
+		t.Fatalf("expected already skipped error, got %v", err)
+	}
+	if _, err := addTestSkip(ws, "", "TestMissing", "x", now); err == nil {
+		t.Fatal("expected an error for an unknown test")
+	}
+
+	change, err = removeTestSkip(ws, "", "TestMarked")
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if !strings.Contains(string(change.after), "func TestMarked(t *testing.T) {\n}") {
+		t.Fatalf("unexpected remove result:\n%s", change.after)
+	}
+	if _, err := removeTestSkip(ws, "", "TestGated"); err == nil || !strings.Contains(err.Error(), "no unconditional skip") {
+		t.Fatalf("expected conditional skips to be left alone, got %v", err)
+	}
+}
+
+func TestParseBlame(t *testing.T) {
+	output := strings.Join([]string{
+		"1111111111111111111111111111111111111111 3 9 1",
+		"author Ada",
+		"author-time 1767225600",
+		"summary skip flaky test",
+		"filename store/store_test.go",
+		"\tt.Skip(\"flaky\")",
+		"0000000000000000000000000000000000000000 10 10 1",
+		"author Not Committed Yet",
+		"author-time 1791158400",
+		"filename store/store_test.go",
+		"\tt.Skip(\"new\")",
+	}, "\n")
+	blame := parseBlame(output)
+	if blame[9].author != "Ada" || !blame[9].time.Equal(time.Unix(1767225600, 0)) {
+		t.Fatalf("unexpected blame for line 9: %+v", blame[9])
+	}
+
+	now := time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)
+	committed := testSkip{line: 9}
+	annotateSkipAge(&committed, blame, now)
+	if committed.Author != "Ada" || committed.Committed != "2026-01-01" || committed.AgeDays == nil || *committed.AgeDays != 10 {
+		t.Fatalf("unexpected age annotation %+v", committed)
+	}
+	uncommitted := testSkip{line: 10}
+	annotateSkipAge(&uncommitted, blame, now)
+	if uncommitted.AgeDays != nil {
+		t.Fatalf("uncommitted skips should have no age, got %+v", uncommitted)
+	}
+}
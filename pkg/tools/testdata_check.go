@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	testdataRefExact  = "exact"
+	testdataRefGlob   = "glob"
+	testdataRefPrefix = "prefix"
+)
+
+type testdataRef struct {
+	Path     string            `json:"path"`
+	Kind     string            `json:"kind"`
+	Function string            `json:"function,omitempty"`
+	Location protocol.Location `json:"location"`
+
+	abs string
+}
+
+func (t *LSPTools) registerCheckTestdata(s *server.MCPServer) {
+	tool := mcp.NewTool("check_testdata",
+		mcp.WithDescription("Find testdata paths referenced by test code (string literals, filepath.Join, go:embed) and report references to missing files as well as orphaned testdata files nothing refers to"),
+		mcp.WithTitleAnnotation("Check Testdata"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only check packages whose import path starts with this prefix")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		refs, missing, orphans, err := checkTestdata(ws, prefix)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"references":   len(refs),
+			"missing":      missing,
+			"orphans":      orphans,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// checkTestdata resolves every testdata reference against the package
+// directory (go test runs tests there) and walks the testdata directories
+// of the selected packages for files no reference covers.
+func checkTestdata(ws *gosrc.Workspace, prefix string) ([]testdataRef, []testdataRef, []string, error) {
+	consts := stringConstants(ws)
+	var refs []testdataRef
+	var dirs []string
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		hasTests := false
+		for _, file := range pkg.Files {
+			if file.Test {
+				hasTests = true
+				refs = append(refs, testdataRefs(ws.Root, pkg, file, consts)...)
+			}
+		}
+		if hasTests && !slices.Contains(dirs, pkg.Dir) {
+			dirs = append(dirs, pkg.Dir)
+		}
+	}
+
+	missing := []testdataRef{}
+	for _, ref := range refs {
+		if !testdataRefExists(ref) {
+			missing = append(missing, ref)
+		}
+	}
+
+	orphans := []string{}
+	for _, dir := range dirs {
+		root := filepath.Join(dir, "testdata")
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root && os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			// go test reads fuzz corpora itself.
+			if d.IsDir() && path == filepath.Join(root, "fuzz") {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || testdataCovered(refs, path) {
+				return nil
+			}
+			orphans = append(orphans, relativeSlashPath(ws.Root, path))
+			return nil
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return refs, missing, orphans, nil
+}
+
+// testdataRefs collects the testdata paths a test file mentions. Paths
+// built from constants are exact; a trailing non-constant part turns the
+// constant prefix into a prefix reference.
+func testdataRefs(root string, pkg *gosrc.Package, file *gosrc.File, consts map[string]map[string]string) []testdataRef {
+	imports := fileImports(file.Syntax)
+	var refs []testdataRef
+	add := func(function, raw, kind string, node ast.Node) {
+		clean := path.Clean(filepath.ToSlash(raw))
+		if !slices.Contains(strings.Split(clean, "/"), "testdata") {
+			return
+		}
+		if kind == testdataRefExact && strings.ContainsAny(clean, "*?[") {
+			kind = testdataRefGlob
+		}
+		abs := filepath.Join(pkg.Dir, filepath.FromSlash(clean))
+		rel := relativeSlashPath(root, abs)
+		if kind == testdataRefPrefix && strings.HasSuffix(raw, "/") {
+			abs += string(filepath.Separator)
+			rel += "/"
+		}
+		refs = append(refs, testdataRef{
+			Path:     rel,
+			Kind:     kind,
+			Function: function,
+			Location: sourceLocation(file, node.Pos(), node.End()),
+			abs:      abs,
+		})
+	}
+
+	for _, group := range file.Syntax.Comments {
+		for _, comment := range group.List {
+			patterns, ok := strings.CutPrefix(comment.Text, "//go:embed ")
+			if !ok {
+				continue
+			}
+			for _, pattern := range strings.Fields(patterns) {
+				add("", strings.Trim(pattern, "`\""), testdataRefExact, comment)
+			}
+		}
+	}
+
+	for _, decl := range file.Syntax.Decls {
+		function := ""
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			function = funcDeclName(d)
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.CallExpr:
+				importPath, name, ok := packageCall(imports, node)
+				if !ok || name != "Join" || (importPath != "path/filepath" && importPath != "path") {
+					return true
+				}
+				var parts []string
+				complete := true
+				for _, arg := range node.Args {
+					if value, ok := constantString(pkg.ImportPath, imports, consts, arg); ok {
+						parts = append(parts, value)
+						continue
+					}
+					// analysistest.TestData() and similar helpers return
+					// the package's testdata directory.
+					if call, ok := unwrapParens(arg).(*ast.CallExpr); ok && len(parts) == 0 {
+						if _, fn, ok := methodCall(call); ok && fn == "TestData" {
+							parts = append(parts, "testdata")
+							continue
+						}
+					}
+					complete = false
+					break
+				}
+				if len(parts) == 0 {
+					return true
+				}
+				if complete {
+					add(function, path.Join(parts...), testdataRefExact, node)
+				} else {
+					add(function, path.Join(parts...)+"/", testdataRefPrefix, node)
+				}
+				return false
+			case *ast.BinaryExpr:
+				if node.Op != token.ADD {
+					return true
+				}
+				if value, ok := constantString(pkg.ImportPath, imports, consts, node); ok {
+					add(function, value, testdataRefExact, node)
+					return false
+				}
+				// "testdata/" + name + ".golden": keep the leading constant.
+				left := node
+				for {
+					inner, ok := unwrapParens(left.X).(*ast.BinaryExpr)
+					if !ok || inner.Op != token.ADD {
+						break
+					}
+					left = inner
+				}
+				if value, ok := constantString(pkg.ImportPath, imports, consts, left.X); ok {
+					add(function, value, testdataRefPrefix, node)
+					return false
+				}
+				return true
+			case *ast.BasicLit:
+				if value, ok := stringValue(node); ok {
+					add(function, value, testdataRefExact, node)
+				}
+			}
+			return true
+		})
+	}
+	return refs
+}
+
+func testdataRefExists(ref testdataRef) bool {
+	switch ref.Kind {
+	case testdataRefGlob:
+		matches, err := filepath.Glob(ref.abs)
+		return err == nil && len(matches) > 0
+	case testdataRefPrefix:
+		dir := ref.abs
+		if !strings.HasSuffix(dir, string(filepath.Separator)) {
+			dir = filepath.Dir(dir)
+		}
+		info, err := os.Stat(dir)
+		return err == nil && info.IsDir()
+	}
+	_, err := os.Stat(ref.abs)
+	return err == nil
+}
+
+// testdataCovered reports whether any reference names file, a directory
+// containing it, a glob matching it, or a prefix of its path.
+func testdataCovered(refs []testdataRef, file string) bool {
+	for _, ref := range refs {
+		switch ref.Kind {
+		case testdataRefExact:
+			if file == ref.abs || strings.HasPrefix(file, ref.abs+string(filepath.Separator)) {
+				return true
+			}
+		case testdataRefGlob:
+			for dir := file; dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+				if ok, _ := filepath.Match(ref.abs, dir); ok {
+					return true
+				}
+			}
+		case testdataRefPrefix:
+			if strings.HasPrefix(file, ref.abs) {
+				return true
+			}
+		}
+	}
+	return false
+}
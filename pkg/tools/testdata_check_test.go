@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestCheckTestdata(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "parse/parse.go", "package parse\n")
+	writeWorkspaceFile(t, workspace, "parse/parse_test.go", `package parse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const fixtures = "testdata/fixtures"
+
+func TestParse(t *testing.T) {
+	_, _ = os.ReadFile("testdata/input.json")
+	_, _ = os.ReadFile(filepath.Join("testdata", "missing.json"))
+	_, _ = os.ReadFile(filepath.Join(fixtures, "a.txt"))
+	_, _ = os.ReadFile("testdata/golden/" + t.Name() + ".golden")
+	_, _ = filepath.Glob("testdata/cases/*.yaml")
+	_, _ = filepath.Glob("testdata/none/*.yaml")
+}
+`)
+	writeWorkspaceFile(t, workspace, "parse/testdata/input.json", "{}")
+	writeWorkspaceFile(t, workspace, "parse/testdata/fixtures/a.txt", "a")
+	writeWorkspaceFile(t, workspace, "parse/testdata/golden/TestParse.golden", "x")
+	writeWorkspaceFile(t, workspace, "parse/testdata/cases/one.yaml", "x")
+	writeWorkspaceFile(t, workspace, "parse/testdata/old.json", "{}")
+	writeWorkspaceFile(t, workspace, "parse/testdata/fuzz/FuzzParse/seed", "go test fuzz v1")
+	writeWorkspaceFile(t, workspace, "embed/embed_test.go", "package embed\n\nimport \"embed\"\n\n//go:embed testdata/*.txt\nvar files embed.FS\n")
+	writeWorkspaceFile(t, workspace, "embed/testdata/a.txt", "a")
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	refs, missing, orphans, err := checkTestdata(ws, "")
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(refs) != 8 {
+		t.Fatalf("unexpected references: %+v", refs)
+	}
+
+	var gotMissing []string
+	for _, ref := range missing {
+		gotMissing = append(gotMissing, ref.Path+":"+ref.Kind+":"+ref.Function)
+	}
+	wantMissing := []string{"parse/testdata/missing.json:exact:TestParse", "parse/testdata/none/*.yaml:glob:TestParse"}
+	if !reflect.DeepEqual(gotMissing, wantMissing) {
+		t.Fatalf("unexpected missing:\n got %v\nwant %v", gotMissing, wantMissing)
+	}
+	if !reflect.DeepEqual(orphans, []string{"parse/testdata/old.json"}) {
+		t.Fatalf("unexpected orphans: %v", orphans)
+	}
+}
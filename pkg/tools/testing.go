@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -13,11 +14,23 @@ import (
 func (t *LSPTools) registerTestingTools(s *server.MCPServer) {
 	t.registerCoverageAnalysis(s)
 	t.registerGoTest(s)
+	t.registerListTestHelpers(s)
+	t.registerUpdateGoldenFiles(s)
+	t.registerCheckTestdata(s)
+	t.registerListTests(s)
+	t.registerAnalyzeTestRequirements(s)
+	t.registerRunIntegrationTests(s)
+	t.registerFindHangingTest(s)
+	t.registerListFuzzCorpus(s)
+	t.registerMinimizeFuzzInput(s)
+	t.registerSuggestProperties(s)
+	t.registerManageTestSkips(s)
+	t.registerAddTest(s)
 }
 
 func (t *LSPTools) registerCoverageAnalysis(s *server.MCPServer) {
 	coverageTool := mcp.NewTool("analyze_coverage",
-		mcp.WithDescription("Analyze test coverage for Go code"),
+		mcp.WithDescription("Analyze test coverage for Go code. Reports the status and coverage of each package; packages that fail to build or whose tests fail do not hide the coverage of the others"),
 		mcp.WithTitleAnnotation("Analyze Coverage"),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("path",
@@ -65,14 +78,19 @@ func (t *LSPTools) registerCoverageAnalysis(s *server.MCPServer) {
 			if result.cover != nil {
 				payload["cover"] = result.cover
 			}
+			payload["packages"] = result.packages
+			payload["summary"] = summarizePackages(result.packages)
 			sendProgressNotification(ctx, s, token, fmt.Sprintf("Coverage analysis finished for %s", packagePath))
 		} else {
 			sendProgressNotification(ctx, s, token, fmt.Sprintf("Running go test -cover for %s", packagePath))
 			testResult, err := t.runCommand(ctx, s, token, "go", "test", packagePath, "-cover")
-			if err != nil {
+			packages := parseGoTestPackages(testResult.Stdout, testResult.Stderr)
+			if err != nil && len(packages) == 0 {
 				return t.commandFailureResult("go test -cover", testResult, err)
 			}
 			payload["test"] = testResult
+			payload["packages"] = packages
+			payload["summary"] = summarizePackages(packages)
 		}
 
 		result, err := mcp.NewToolResultJSON(payload)
@@ -84,8 +102,9 @@ func (t *LSPTools) registerCoverageAnalysis(s *server.MCPServer) {
 }
 
 type coverageCommandResult struct {
-	test  commandResult
-	cover *commandResult
+	test     commandResult
+	cover    *commandResult
+	packages []packageStatus
 }
 
 func (t *LSPTools) runCoverageByFunction(ctx context.Context, srv *server.MCPServer, token mcp.ProgressToken, target string) (coverageCommandResult, error) {
@@ -99,50 +118,107 @@ func (t *LSPTools) runCoverageByFunction(ctx context.Context, srv *server.MCPSer
 	_ = tempFile.Close()
 
 	testResult, err := t.runCommand(ctx, srv, token, "go", "test", target, "-coverprofile", tempFile.Name())
-	if err != nil {
-		return coverageCommandResult{test: testResult}, err
+	result := coverageCommandResult{test: testResult, packages: parseGoTestPackages(testResult.Stdout, testResult.Stderr)}
+	// The profile still covers the packages that passed when others fail.
+	if err != nil && summarizePackages(result.packages).Passed == 0 {
+		return result, err
 	}
 
 	coverResult, coverErr := t.runCommand(ctx, srv, token, "go", "tool", "cover", "-func", tempFile.Name())
+	result.cover = &coverResult
 	if coverErr != nil {
-		return coverageCommandResult{test: testResult, cover: &coverResult}, coverErr
+		return result, coverErr
 	}
-
-	return coverageCommandResult{
-		test:  testResult,
-		cover: &coverResult,
-	}, nil
+	return result, nil
 }
 
 func (t *LSPTools) registerGoTest(s *server.MCPServer) {
 	runTool := mcp.NewTool("run_go_test",
-		mcp.WithDescription("Run go test for a package or pattern"),
+		mcp.WithDescription("Run go test for a package or pattern, reporting the status of each package so one failing package does not hide the results of the others. testify and go-cmp assertion failures are returned with structured expected/actual values and diffs. Test binaries that panic, time out, hit a fatal error, are killed or exit early are reported as crashes with the stack symbolicated against the workspace"),
 		mcp.WithTitleAnnotation("Run Go Test"),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("path",
 			mcp.Description("Package path or pattern. Defaults to ./..."),
 		),
+		mcp.WithString("test",
+			mcp.Description("Only run this test or subtest, given by its full name as reported by go test or list_tests (TestFoo or TestFoo/case_3)"),
+		),
+		mcp.WithBoolean("leaks",
+			mcp.Description("Fail packages whose tests leave goroutines running and report the leaked goroutines with their creation stacks (default false)"),
+		),
+		mcp.WithString("leak_grace",
+			mcp.Description("How long to wait for goroutines to exit after the tests before reporting them as leaked (default 500ms)"),
+		),
 	)
 
 	s.AddTool(runTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		token := getProgressToken(request.Params.Meta)
 		target := "./..."
-		if args := request.GetArguments(); args != nil {
+		args := request.GetArguments()
+		if args != nil {
 			if path, ok := args["path"].(string); ok {
 				target = path
 			}
 		}
 		target = normalizePackageTarget(t.workspaceDir, target)
+		leaks := getOptionalBoolArg(args, "leaks")
+		grace, err := getOptionalDurationArg(args, "leak_grace", 500*time.Millisecond)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		testArgs := []string{"test"}
+		var skipped []leakPackageStatus
+		if leaks {
+			overlay, skippedPackages, cleanup, err := t.prepareLeakOverlay(ctx, s, token, target, grace)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("prepare leak check: %v", err)), nil
+			}
+			defer cleanup()
+			skipped = skippedPackages
+			testArgs = append(testArgs, "-overlay", overlay)
+		}
+		test := getOptionalStringArg(args, "test")
+		if test != "" {
+			testArgs = append(testArgs, "-run", subtestRunPattern(test))
+		}
+		testArgs = append(testArgs, target)
 
 		sendProgressNotification(ctx, s, token, fmt.Sprintf("Running go test for %s", target))
-		result, err := t.runCommand(ctx, s, token, "go", "test", target)
+		result, err := t.runCommand(ctx, s, token, "go", testArgs...)
+		var leaked []leakedGoroutine
+		if leaks {
+			leaked = parseLeakReport(result.Stdout)
+		}
+		var crashes []testCrash
 		if err != nil {
+			crashes = parseTestCrashes(result.Stdout)
+		}
+		packages := parseGoTestPackages(result.Stdout, result.Stderr)
+		if err != nil && len(leaked) == 0 && len(crashes) == 0 && len(packages) == 0 {
 			return t.commandFailureResult("go test", result, err)
 		}
 
+		t.history.recordTestRun(target, test, packages, len(crashes))
+
 		payload := map[string]any{
-			"target": target,
-			"result": result,
+			"target":   target,
+			"result":   result,
+			"packages": packages,
+			"summary":  summarizePackages(packages),
+		}
+		if len(crashes) > 0 {
+			for i := range crashes {
+				crashes[i].symbolicate(t.workspaceDir)
+			}
+			payload["crashes"] = crashes
+		}
+		if leaks {
+			if leaked == nil {
+				leaked = []leakedGoroutine{}
+			}
+			payload["leaks"] = leaked
+			payload["leak_check_skipped"] = skipped
 		}
 
 		toolResult, err := mcp.NewToolResultJSON(payload)
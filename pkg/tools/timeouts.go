@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	timeoutKindContext   = "context"
+	timeoutKindClient    = "http_client"
+	timeoutKindServer    = "http_server"
+	timeoutKindTransport = "http_transport"
+	timeoutKindDialer    = "dialer"
+	timeoutKindConn      = "conn_deadline"
+	timeoutKindDatabase  = "database"
+)
+
+var (
+	// timeoutFields lists the timeout fields of the configuration structs
+	// worth reporting, keyed by package-qualified type name.
+	timeoutFields = map[string]map[string]string{
+		"http.Client": {"Timeout": timeoutKindClient},
+		"http.Server": {
+			"ReadTimeout": timeoutKindServer, "ReadHeaderTimeout": timeoutKindServer,
+			"WriteTimeout": timeoutKindServer, "IdleTimeout": timeoutKindServer,
+		},
+		"http.Transport": {
+			"TLSHandshakeTimeout": timeoutKindTransport, "ResponseHeaderTimeout": timeoutKindTransport,
+			"IdleConnTimeout": timeoutKindTransport, "ExpectContinueTimeout": timeoutKindTransport,
+		},
+		"net.Dialer":    {"Timeout": timeoutKindDialer, "Deadline": timeoutKindDialer},
+		"mysql.Config":  {"Timeout": timeoutKindDatabase, "ReadTimeout": timeoutKindDatabase, "WriteTimeout": timeoutKindDatabase},
+		"redis.Options": {"DialTimeout": timeoutKindDatabase, "ReadTimeout": timeoutKindDatabase, "WriteTimeout": timeoutKindDatabase, "PoolTimeout": timeoutKindDatabase},
+	}
+	// timeoutAssignFields are net/http fields distinctive enough to be
+	// recognised when assigned after construction (srv.ReadHeaderTimeout = ...).
+	timeoutAssignFields = map[string]string{
+		"Timeout":     timeoutKindClient,
+		"ReadTimeout": timeoutKindServer, "ReadHeaderTimeout": timeoutKindServer, "WriteTimeout": timeoutKindServer, "IdleTimeout": timeoutKindServer,
+		"TLSHandshakeTimeout": timeoutKindTransport, "ResponseHeaderTimeout": timeoutKindTransport, "IdleConnTimeout": timeoutKindTransport, "ExpectContinueTimeout": timeoutKindTransport,
+	}
+	// timeoutMethods are methods whose argument at the given index is a
+	// timeout or deadline.
+	timeoutMethods = map[string]struct {
+		kind string
+		arg  int
+	}{
+		"SetDeadline":        {timeoutKindConn, 0},
+		"SetReadDeadline":    {timeoutKindConn, 0},
+		"SetWriteDeadline":   {timeoutKindConn, 0},
+		"SetConnMaxLifetime": {timeoutKindDatabase, 0},
+		"SetConnMaxIdleTime": {timeoutKindDatabase, 0},
+	}
+	// uncontextedQueries have a Context variant that honours deadlines.
+	uncontextedQueries = map[string]bool{
+		"Query": true, "QueryRow": true, "Exec": true, "Prepare": true, "Ping": true, "Begin": true,
+		"Queryx": true, "QueryRowx": true, "NamedExec": true, "NamedQuery": true,
+	}
+	dsnTimeoutPattern = regexp.MustCompile(`(?i)(?:^|[?&;\s])((?:connect_?|dial_?|read_?|write_?|statement_?|lock_?|query_?)?timeout)=([^&;\s]+)`)
+)
+
+type timeoutSetting struct {
+	Kind     string            `json:"kind"`
+	Setting  string            `json:"setting"`
+	Value    string            `json:"value"`
+	Function string            `json:"function,omitempty"`
+	Package  string            `json:"package"`
+	Location protocol.Location `json:"location"`
+}
+
+type timeoutFinding struct {
+	Kind     string            `json:"kind"`
+	Message  string            `json:"message"`
+	Function string            `json:"function,omitempty"`
+	Package  string            `json:"package"`
+	Location protocol.Location `json:"location"`
+}
+
+func (t *LSPTools) registerAuditTimeouts(s *server.MCPServer) {
+	tool := mcp.NewTool("audit_timeouts",
+		mcp.WithDescription("Tabulate context deadlines, http.Client/Server/Transport, dialer and database timeouts across the workspace and flag outbound calls and servers running without one"),
+		mcp.WithTitleAnnotation("Audit Timeouts"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package", mcp.Description("Only report packages whose import path starts with this prefix")),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		prefix := getOptionalStringArg(args, "package")
+
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		settings, findings := auditTimeouts(ws, prefix)
+		counts := make(map[string]int)
+		for _, setting := range settings {
+			counts[setting.Kind]++
+		}
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"timeouts":     settings,
+			"counts":       counts,
+			"findings":     findings,
+			"parse_errors": ws.ParseErrors,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+func auditTimeouts(ws *gosrc.Workspace, prefix string) ([]timeoutSetting, []timeoutFinding) {
+	settings := []timeoutSetting{}
+	findings := []timeoutFinding{}
+	for _, pkg := range ws.Packages {
+		if prefix != "" && !strings.HasPrefix(pkg.ImportPath, prefix) {
+			continue
+		}
+		for _, file := range pkg.Files {
+			imports := fileImports(file.Syntax)
+			usesHTTP := hasImportPrefix(imports, "net/http")
+			usesDB := hasImportPrefix(imports, "database/sql") || hasImportPrefix(imports, "github.com/jmoiron/sqlx")
+			for _, decl := range file.Syntax.Decls {
+				function := ""
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					function = funcDeclName(fn)
+				}
+				setting := func(kind, name string, value ast.Expr, node ast.Node) {
+					settings = append(settings, timeoutSetting{
+						Kind:     kind,
+						Setting:  name,
+						Value:    exprText(value),
+						Function: function,
+						Package:  pkg.ImportPath,
+						Location: sourceLocation(file, node.Pos(), node.End()),
+					})
+				}
+				finding := func(kind, message string, node ast.Node) {
+					findings = append(findings, timeoutFinding{
+						Kind:     kind,
+						Message:  message,
+						Function: function,
+						Package:  pkg.ImportPath,
+						Location: sourceLocation(file, node.Pos(), node.End()),
+					})
+				}
+
+				ast.Inspect(decl, func(n ast.Node) bool {
+					switch node := n.(type) {
+					case *ast.CompositeLit:
+						typeName := qualifiedTypeText(imports, node.Type)
+						fields, ok := timeoutFields[typeName]
+						if !ok {
+							return true
+						}
+						set := make(map[string]bool)
+						for _, elt := range node.Elts {
+							kv, ok := elt.(*ast.KeyValueExpr)
+							if !ok {
+								continue
+							}
+							key, ok := kv.Key.(*ast.Ident)
+							if !ok {
+								continue
+							}
+							set[key.Name] = true
+							if kind, ok := fields[key.Name]; ok {
+								setting(kind, typeName+"."+key.Name, kv.Value, kv)
+							}
+						}
+						switch {
+						case typeName == "http.Client" && !set["Timeout"]:
+							finding("http_client_without_timeout", "http.Client has no Timeout; requests can hang indefinitely unless every call carries a context deadline", node)
+						case typeName == "http.Server" && !set["ReadHeaderTimeout"] && !set["ReadTimeout"]:
+							finding("http_server_without_timeouts", "http.Server sets neither ReadHeaderTimeout nor ReadTimeout, leaving it open to slow clients", node)
+						}
+					case *ast.AssignStmt:
+						if !usesHTTP || node.Tok != token.ASSIGN || len(node.Lhs) != len(node.Rhs) {
+							return true
+						}
+						for i, lhs := range node.Lhs {
+							sel, ok := lhs.(*ast.SelectorExpr)
+							if !ok {
+								continue
+							}
+							if kind, ok := timeoutAssignFields[sel.Sel.Name]; ok {
+								setting(kind, exprText(sel), node.Rhs[i], node)
+							}
+						}
+					case *ast.SelectorExpr:
+						if qualifiedTypeText(imports, node) == "http.DefaultClient" {
+							finding("default_http_client", "http.DefaultClient has no timeout", node)
+						}
+					case *ast.CallExpr:
+						auditTimeoutCall(imports, usesDB, node, setting, finding)
+					case *ast.BasicLit:
+						// Connection strings carry driver timeouts as
+						// parameters (connect_timeout=5, readTimeout=2s).
+						if value, ok := stringValue(node); ok {
+							for _, match := range dsnTimeoutPattern.FindAllStringSubmatch(value, -1) {
+								setting(timeoutKindDatabase, "dsn "+match[1], &ast.BasicLit{Kind: token.STRING, Value: match[2]}, node)
+							}
+						}
+					}
+					return true
+				})
+			}
+
+		}
+	}
+	return settings, findings
+}
+
+func auditTimeoutCall(imports map[string]string, usesDB bool, call *ast.CallExpr, setting func(kind, name string, value ast.Expr, node ast.Node), finding func(kind, message string, node ast.Node)) {
+	if importPath, name, ok := packageCall(imports, call); ok {
+		qualified := defaultImportName(importPath) + "." + name
+		switch {
+		case importPath == "context" && (name == "WithTimeout" || name == "WithTimeoutCause" || name == "WithDeadline" || name == "WithDeadlineCause"):
+			if len(call.Args) >= 2 {
+				setting(timeoutKindContext, qualified, call.Args[1], call)
+			}
+		case importPath == "net" && name == "DialTimeout":
+			if len(call.Args) == 3 {
+				setting(timeoutKindDialer, qualified, call.Args[2], call)
+			}
+		case importPath == "net" && name == "Dial":
+			finding("dial_without_timeout", "net.Dial has no timeout; use net.DialTimeout or a net.Dialer with DialContext", call)
+		case importPath == "net/http" && name == "TimeoutHandler":
+			if len(call.Args) >= 2 {
+				setting(timeoutKindServer, qualified, call.Args[1], call)
+			}
+		case importPath == "net/http" && (name == "Get" || name == "Head" || name == "Post" || name == "PostForm"):
+			finding("default_http_client", fmt.Sprintf("%s uses http.DefaultClient, which has no timeout", qualified), call)
+		case importPath == "net/http" && name == "NewRequest":
+			finding("request_without_context", "http.NewRequest carries no context, so no deadline can cancel it; use http.NewRequestWithContext", call)
+		case importPath == "net/http" && (name == "ListenAndServe" || name == "ListenAndServeTLS" || name == "Serve" || name == "ServeTLS"):
+			finding("http_server_without_timeouts", fmt.Sprintf("%s runs a server with no read or write timeouts; configure an http.Server instead", qualified), call)
+		}
+		return
+	}
+
+	recv, name, ok := methodCall(call)
+	if !ok {
+		return
+	}
+	if method, ok := timeoutMethods[name]; ok && method.arg < len(call.Args) {
+		setting(method.kind, exprText(recv)+"."+name, call.Args[method.arg], call)
+		return
+	}
+	// Ping and Begin take no arguments; the others take the statement, which
+	// also keeps url.Query() and friends out.
+	takesNone := name == "Ping" || name == "Begin"
+	if usesDB && uncontextedQueries[name] && (len(call.Args) == 0) == takesNone {
+		finding("query_without_context", fmt.Sprintf("%s ignores deadlines; use %sContext with a bounded context", exprText(call.Fun), name), call)
+	}
+}
+
+// funcDeclName names a function declaration, with methods written
+// Type.Method.
+func funcDeclName(fn *ast.FuncDecl) string {
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		return receiverTypeName(fn.Recv.List[0].Type) + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+)
+
+func TestAuditTimeouts(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "client/client.go", `package client
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+const dsn = "postgres://db/app?sslmode=disable&connect_timeout=5"
+
+var api = &http.Client{Timeout: 10 * time.Second}
+
+func Fetch(ctx context.Context, db *sql.DB, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if _, err := http.Get(url); err != nil {
+		return err
+	}
+	req, _ := http.NewRequest("GET", url, nil)
+	_ = req.URL.Query()
+	bare := &http.Client{}
+	bare.Timeout = time.Minute
+	_, err := db.Query("SELECT 1")
+	return err
+}
+
+func Serve(h http.Handler) error {
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 5 * time.Second}
+	srv.WriteTimeout = 30 * time.Second
+	return http.ListenAndServe(":8080", h)
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	settings, findings := auditTimeouts(ws, "")
+
+	type row struct{ kind, setting, value, function string }
+	var got []row
+	for _, s := range settings {
+		got = append(got, row{s.Kind, s.Setting, s.Value, s.Function})
+	}
+	want := []row{
+		{"database", "dsn connect_timeout", "5", ""},
+		{"http_client", "http.Client.Timeout", "10 * time.Second", ""},
+		{"context", "context.WithTimeout", "2 * time.Second", "Fetch"},
+		{"http_client", "bare.Timeout", "time.Minute", "Fetch"},
+		{"http_server", "http.Server.ReadHeaderTimeout", "5 * time.Second", "Serve"},
+		{"http_server", "srv.WriteTimeout", "30 * time.Second", "Serve"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected settings: %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("setting %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	var kinds []string
+	for _, f := range findings {
+		kinds = append(kinds, f.Kind)
+	}
+	wantKinds := []string{"default_http_client", "request_without_context", "http_client_without_timeout", "query_without_context", "http_server_without_timeouts"}
+	if len(kinds) != len(wantKinds) {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	for i := range wantKinds {
+		if kinds[i] != wantKinds[i] {
+			t.Fatalf("finding %d: got %s, want %s (%+v)", i, kinds[i], wantKinds[i], findings)
+		}
+	}
+	if findings[0].Function != "Fetch" || findings[0].Location.Range.Start.Line != 16 {
+		t.Fatalf("unexpected http.Get finding: %+v", findings[0])
+	}
+}
@@ -3,8 +3,13 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	mcpsrv "github.com/mark3labs/mcp-go/server"
@@ -17,16 +22,23 @@ func TestToolsEndToEnd(t *testing.T) {
 	origLookup := lookupGovulncheckBinary
 	t.Cleanup(func() { lookupGovulncheckBinary = origLookup })
 
+	renameFile := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(renameFile, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
 	fakeClient := &fakeLSPClient{
-		definitions: []protocol.Location{{URI: "file://tmp/main.go"}},
-		references:  []protocol.Location{{URI: "file://tmp/main.go"}},
-		diagnostics: []protocol.Diagnostic{{Message: "boom"}},
-		hover:       "hover info",
-		completions: []string{"CompleteMe"},
-		edits:       []protocol.TextEdit{{NewText: "fmt"}},
-		rename:      &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{"file://tmp/main.go": {{NewText: "name"}}}},
-		actions:     []protocol.CodeAction{{Title: "Fix"}},
-		symbols:     []protocol.SymbolInformation{{Name: "Symbol"}},
+		definitions:     []protocol.Location{{URI: "file://tmp/main.go"}},
+		typeDefinitions: []protocol.Location{{URI: "file://tmp/types.go"}},
+		references:      []protocol.Location{{URI: "file://tmp/main.go"}},
+		diagnostics:     []protocol.Diagnostic{{Message: "boom"}},
+		hover:           "hover info",
+		completions:     []string{"CompleteMe"},
+		completionItems: []protocol.CompletionItem{{Label: "CompleteMe", Kind: 3}, {Label: "Other", Kind: 6}},
+		edits:           []protocol.TextEdit{{NewText: "fmt"}},
+		rename:          &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{convertPathToURI(renameFile): {{NewText: "name"}}}},
+		actions:         []protocol.CodeAction{{Title: "Fix"}},
+		symbols:         []protocol.SymbolInformation{{Name: "Symbol"}},
 	}
 
 	tools := NewLSPTools(fakeClient, "/workspace")
@@ -36,7 +48,6 @@ func TestToolsEndToEnd(t *testing.T) {
 			"go test ./pkg -coverprofile cover.out": {Command: []string{"go", "test", "./pkg", "-coverprofile", "cover.out"}, Stdout: "ok"},
 			"go tool cover -func cover.out":         {Command: []string{"go", "tool", "cover", "-func", "cover.out"}, Stdout: "ok"},
 			"go test ./...":                         {Command: []string{"go", "test", "./..."}, Stdout: "ok"},
-			"go mod tidy":                           {Command: []string{"go", "mod", "tidy"}, Stdout: "ok"},
 			"/usr/bin/govulncheck ./...":            {Command: []string{"/usr/bin/govulncheck", "./..."}, Stdout: "ok"},
 			"go mod graph":                          {Command: []string{"go", "mod", "graph"}, Stdout: "ok"},
 		},
@@ -84,6 +95,25 @@ func TestToolsEndToEnd(t *testing.T) {
 		}
 	})
 
+	assertTool("go_to_type_definition", map[string]any{
+		"file_uri": "file://tmp/main.go",
+		"position": map[string]any{"line": 0, "character": 0},
+	}, func(t *testing.T, content map[string]any) {
+		positions := content["positions"].([]any)
+		if len(positions) != 1 || positions[0].(map[string]any)["uri"] != "file://tmp/types.go" {
+			t.Fatalf("unexpected type definition %#v", content)
+		}
+	})
+
+	assertTool("go_to_declaration", map[string]any{
+		"file_uri": "file://tmp/main.go",
+		"position": map[string]any{"line": 0, "character": 0},
+	}, func(t *testing.T, content map[string]any) {
+		if positions := content["positions"].([]any); len(positions) != 1 {
+			t.Fatalf("unexpected declaration %#v", content)
+		}
+	})
+
 	assertTool("find_references", map[string]any{
 		"file_uri": "file://tmp/main.go",
 		"position": map[string]any{"line": 0, "character": 0},
@@ -101,6 +131,15 @@ func TestToolsEndToEnd(t *testing.T) {
 		}
 	})
 
+	assertTool("get_diagnostics", map[string]any{
+		"file_uri": "file:///workspace/main.go",
+	}, func(t *testing.T, content map[string]any) {
+		files := content["files"].([]any)
+		if content["quiescent"] != true || len(files) != 1 || len(files[0].(map[string]any)["diagnostics"].([]any)) != 0 {
+			t.Fatalf("expected an empty diagnostics list for the file, got %#v", content)
+		}
+	})
+
 	assertTool("get_hover_info", map[string]any{
 		"file_uri": "file://tmp/main.go",
 		"position": map[string]any{"line": 0, "character": 0},
@@ -110,6 +149,26 @@ func TestToolsEndToEnd(t *testing.T) {
 		}
 	})
 
+	assertTool("hover", map[string]any{
+		"file_uri": "file://tmp/main.go",
+		"position": map[string]any{"line": 0, "character": 0},
+	}, func(t *testing.T, content map[string]any) {
+		if hover := content["hover"].(map[string]any); hover["doc"] != "hover info" {
+			t.Fatalf("unexpected structured hover %#v", content)
+		}
+	})
+
+	assertTool("get_completions", map[string]any{
+		"file_uri": "file://tmp/main.go",
+		"position": map[string]any{"line": 0, "character": 0},
+		"prefix":   "comp",
+	}, func(t *testing.T, content map[string]any) {
+		completions := content["completions"].([]any)
+		if len(completions) != 1 || completions[0].(map[string]any)["kind"] != "function" {
+			t.Fatalf("unexpected completions %#v", content)
+		}
+	})
+
 	assertTool("get_completion", map[string]any{
 		"file_uri": "file://tmp/main.go",
 		"position": map[string]any{"line": 0, "character": 0},
@@ -161,17 +220,21 @@ func TestToolsEndToEnd(t *testing.T) {
 		}
 	})
 
-	assertTool("search_workspace_symbols", map[string]any{
+	assertTool("workspace_symbols", map[string]any{
 		"query": "Symbol",
+		"scope": "all",
 	}, func(t *testing.T, content map[string]any) {
-		if len(content["symbols"].([]any)) != 1 {
+		symbols := content["symbols"].([]any)
+		if len(symbols) != 1 || symbols[0].(map[string]any)["name"] != "Symbol" {
 			t.Fatalf("unexpected symbols %#v", content)
 		}
 	})
 
-	assertTool("run_go_mod_tidy", map[string]any{}, func(t *testing.T, content map[string]any) {
-		if _, ok := content["result"]; !ok {
-			t.Fatalf("expected tidy result")
+	assertTool("search_workspace_symbols", map[string]any{
+		"query": "Symbol",
+	}, func(t *testing.T, content map[string]any) {
+		if len(content["symbols"].([]any)) != 1 {
+			t.Fatalf("unexpected symbols %#v", content)
 		}
 	})
 
@@ -204,8 +267,8 @@ type fakeCommandRunner struct {
 	calls   []string
 }
 
-func (f *fakeCommandRunner) Run(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, name string, args ...string) (commandResult, error) {
-	command := append([]string{name}, args...)
+func (f *fakeCommandRunner) Run(t *LSPTools, ctx context.Context, srv *mcpsrv.MCPServer, token mcp.ProgressToken, spec commandSpec) (commandResult, error) {
+	command := append([]string{spec.name}, spec.args...)
 	key := strings.Join(command, " ")
 	f.calls = append(f.calls, key)
 	if res, ok := f.results[key]; ok {
@@ -215,15 +278,48 @@ func (f *fakeCommandRunner) Run(t *LSPTools, ctx context.Context, srv *mcpsrv.MC
 }
 
 type fakeLSPClient struct {
-	definitions []protocol.Location
-	references  []protocol.Location
-	diagnostics []protocol.Diagnostic
-	hover       string
-	completions []string
-	edits       []protocol.TextEdit
-	rename      *protocol.WorkspaceEdit
-	actions     []protocol.CodeAction
-	symbols     []protocol.SymbolInformation
+	definitions     []protocol.Location
+	typeDefinitions []protocol.Location
+	references      []protocol.Location
+	impls           []protocol.Location
+	callItems       []protocol.CallHierarchyItem
+	incoming        map[string][]protocol.CallHierarchyIncomingCall
+	outgoing        map[string][]protocol.CallHierarchyOutgoingCall
+	typeItems       []protocol.TypeHierarchyItem
+	supertypes      map[string][]protocol.TypeHierarchyItem
+	subtypes        map[string][]protocol.TypeHierarchyItem
+	diagnostics     []protocol.Diagnostic
+	hover           string
+	completions     []string
+	completionItems []protocol.CompletionItem
+	edits           []protocol.TextEdit
+	// formatting and formatErrors override edits for some URIs.
+	formatting   map[string][]protocol.TextEdit
+	formatErrors map[string]error
+	rename       *protocol.WorkspaceEdit
+	actions      []protocol.CodeAction
+	// sourceActions are the actions listed for a file when kinds are
+	// requested, by URI.
+	sourceActions map[string][]protocol.CodeAction
+	resolved      map[string]*protocol.WorkspaceEdit
+	commands      map[string][]protocol.WorkspaceEdit
+	executed      []string
+	arguments     [][]any
+	results       map[string]json.RawMessage
+	symbols       []protocol.SymbolInformation
+	outline       map[string][]protocol.DocumentSymbol
+	highlights    []protocol.DocumentHighlight
+	hints         []protocol.InlayHint
+	lenses        []protocol.CodeLens
+	folds         []protocol.FoldingRange
+	tokens        []protocol.SemanticToken
+	selections    []protocol.SelectionRange
+	published     map[string][]protocol.Diagnostic
+	busy          error
+	// open holds the documents the fake reports as already open, and
+	// closed the documents closed through it.
+	open   map[string]bool
+	closed []string
 }
 
 func (f *fakeLSPClient) Initialize(ctx context.Context) error { return nil }
@@ -232,32 +328,120 @@ func (f *fakeLSPClient) Close(ctx context.Context) error      { return nil }
 func (f *fakeLSPClient) GoToDefinition(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
 	return f.definitions, nil
 }
+func (f *fakeLSPClient) GoToTypeDefinition(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	return f.typeDefinitions, nil
+}
+func (f *fakeLSPClient) GoToDeclaration(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	return f.definitions, nil
+}
 func (f *fakeLSPClient) FindReferences(ctx context.Context, uri string, line, character int, includeDeclaration bool) ([]protocol.Location, error) {
 	return f.references, nil
 }
+func (f *fakeLSPClient) FindImplementations(ctx context.Context, uri string, line, character int) ([]protocol.Location, error) {
+	return f.impls, nil
+}
+func (f *fakeLSPClient) PrepareCallHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.CallHierarchyItem, error) {
+	return f.callItems, nil
+}
+func (f *fakeLSPClient) IncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	return f.incoming[item.Name], nil
+}
+func (f *fakeLSPClient) OutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	return f.outgoing[item.Name], nil
+}
+func (f *fakeLSPClient) PrepareTypeHierarchy(ctx context.Context, uri string, line, character int) ([]protocol.TypeHierarchyItem, error) {
+	return f.typeItems, nil
+}
+func (f *fakeLSPClient) Supertypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	return f.supertypes[item.Name], nil
+}
+func (f *fakeLSPClient) Subtypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	return f.subtypes[item.Name], nil
+}
+func (f *fakeLSPClient) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (*protocol.CodeAction, error) {
+	action.Edit = f.resolved[action.Title]
+	return &action, nil
+}
+func (f *fakeLSPClient) ExecuteCommand(ctx context.Context, command protocol.Command) ([]protocol.WorkspaceEdit, error) {
+	_, edits, err := f.RunCommand(ctx, command)
+	return edits, err
+}
+func (f *fakeLSPClient) RunCommand(ctx context.Context, command protocol.Command) (json.RawMessage, []protocol.WorkspaceEdit, error) {
+	f.executed = append(f.executed, command.Command)
+	f.arguments = append(f.arguments, command.Arguments)
+	return f.results[command.Command], f.commands[command.Command], nil
+}
+func (f *fakeLSPClient) Commands() []string {
+	return slices.Sorted(maps.Keys(f.commands))
+}
 func (f *fakeLSPClient) GetDiagnostics(ctx context.Context, uri string) ([]protocol.Diagnostic, error) {
 	return f.diagnostics, nil
 }
+func (f *fakeLSPClient) WorkspaceDiagnostics() map[string][]protocol.Diagnostic {
+	return f.published
+}
+func (f *fakeLSPClient) WaitForQuiescence(ctx context.Context, quiet time.Duration) error {
+	return f.busy
+}
 func (f *fakeLSPClient) DidOpen(ctx context.Context, uri, languageID, text string) error { return nil }
-func (f *fakeLSPClient) DidClose(ctx context.Context, uri string) error                  { return nil }
+func (f *fakeLSPClient) DidClose(ctx context.Context, uri string) error {
+	f.closed = append(f.closed, uri)
+	return nil
+}
+func (f *fakeLSPClient) OpenDocument(ctx context.Context, uri, languageID, text string) (bool, error) {
+	return !f.open[uri], nil
+}
 func (f *fakeLSPClient) GetHover(ctx context.Context, uri string, line, character int) (string, error) {
 	return f.hover, nil
 }
 func (f *fakeLSPClient) GetCompletion(ctx context.Context, uri string, line, character int) ([]string, error) {
 	return f.completions, nil
 }
+func (f *fakeLSPClient) GetCompletionList(ctx context.Context, uri string, line, character int) (*protocol.CompletionList, error) {
+	return &protocol.CompletionList{Items: f.completionItems}, nil
+}
 func (f *fakeLSPClient) DocumentFormatting(ctx context.Context, uri string) ([]protocol.TextEdit, error) {
+	if err := f.formatErrors[uri]; err != nil {
+		return nil, err
+	}
+	if edits, ok := f.formatting[uri]; ok {
+		return edits, nil
+	}
 	return f.edits, nil
 }
 func (f *fakeLSPClient) Rename(ctx context.Context, uri string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
 	return f.rename, nil
 }
-func (f *fakeLSPClient) CodeActions(ctx context.Context, uri string, rng protocol.Range) ([]protocol.CodeAction, error) {
+func (f *fakeLSPClient) CodeActions(ctx context.Context, uri string, rng protocol.Range, only ...string) ([]protocol.CodeAction, error) {
+	if len(only) > 0 {
+		return f.sourceActions[uri], nil
+	}
 	return f.actions, nil
 }
 func (f *fakeLSPClient) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
 	return f.symbols, nil
 }
+func (f *fakeLSPClient) DocumentSymbols(ctx context.Context, uri string) ([]protocol.DocumentSymbol, error) {
+	return f.outline[uri], nil
+}
+func (f *fakeLSPClient) DocumentHighlights(ctx context.Context, uri string, line, character int) ([]protocol.DocumentHighlight, error) {
+	return f.highlights, nil
+}
+func (f *fakeLSPClient) InlayHints(ctx context.Context, uri string, rng protocol.Range) ([]protocol.InlayHint, error) {
+	return f.hints, nil
+}
+func (f *fakeLSPClient) CodeLenses(ctx context.Context, uri string) ([]protocol.CodeLens, error) {
+	return f.lenses, nil
+}
+func (f *fakeLSPClient) FoldingRanges(ctx context.Context, uri string) ([]protocol.FoldingRange, error) {
+	return f.folds, nil
+}
+func (f *fakeLSPClient) SemanticTokens(ctx context.Context, uri string, rng protocol.Range) ([]protocol.SemanticToken, error) {
+	return f.tokens, nil
+}
+func (f *fakeLSPClient) SelectionRanges(ctx context.Context, uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+	return f.selections, nil
+}
 func (f *fakeLSPClient) OnDiagnostics(handler client.DiagnosticsHandler) func() { return func() {} }
 func (f *fakeLSPClient) NotifyDidChangeWatchedFiles(_ context.Context, _ []protocol.FileEvent) error {
 	return nil
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// StaleFile is a file the session read or edited whose content changed on
+// disk through another process since.
+type StaleFile struct {
+	Path string `json:"path"`
+	// Access is "read" or "edited", whichever the session did last.
+	Access  string `json:"access"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// touchedFile is the content of a file as the session last saw it.
+type touchedFile struct {
+	access  string
+	hash    [32]byte
+	missing bool
+	stale   bool
+}
+
+// touchedFiles records the files the session read or edited, so that the
+// changes other processes make to them can be told from its own.
+type touchedFiles struct {
+	mu    sync.Mutex
+	files map[string]*touchedFile
+}
+
+func newTouchedFiles() *touchedFiles {
+	return &touchedFiles{files: make(map[string]*touchedFile)}
+}
+
+// record notes the content of path, as the session read or wrote it.
+func (f *touchedFiles) record(path, access string, content []byte, missing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[filepath.Clean(path)] = &touchedFile{access: access, hash: sha256.Sum256(content), missing: missing}
+}
+
+// TrackToolCall records a successful tool call in the session history,
+// with the content of the file it read through its file_uri argument. It
+// is meant as an after-call hook of the MCP server.
+func (t *LSPTools) TrackToolCall(request *mcp.CallToolRequest, result any) {
+	if request == nil {
+		return
+	}
+	if toolResult, ok := result.(*mcp.CallToolResult); !ok || toolResult.IsError {
+		return
+	}
+	var path string
+	var content []byte
+	if uri, _ := request.GetArguments()["file_uri"].(string); strings.HasPrefix(uri, "file://") {
+		if data, err := os.ReadFile(convertURIToPath(uri)); err == nil {
+			path, content = convertURIToPath(uri), data
+		}
+	}
+	t.recordHistory(request, path, content)
+	if path == "" {
+		return
+	}
+	access := "read"
+	t.touched.mu.Lock()
+	if file, ok := t.touched.files[filepath.Clean(path)]; ok && file.access == "edited" && !file.stale {
+		// Reading a file back does not make it less edited.
+		access = "edited"
+	}
+	t.touched.mu.Unlock()
+	t.touched.record(path, access, content, false)
+}
+
+// StaleFiles takes the paths the file watcher saw change and returns the
+// files among them the session read or edited whose content is no longer
+// the one it saw. Each change is returned once: a stale file is reported
+// again only after the session read or edited it anew.
+func (t *LSPTools) StaleFiles(paths []string) []StaleFile {
+	t.touched.mu.Lock()
+	defer t.touched.mu.Unlock()
+	var stale []StaleFile
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		file, ok := t.touched.files[path]
+		if !ok || file.stale {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		missing := errors.Is(err, fs.ErrNotExist)
+		if err != nil && !missing {
+			continue
+		}
+		if missing == file.missing && sha256.Sum256(content) == file.hash {
+			continue
+		}
+		file.stale = true
+		stale = append(stale, StaleFile{Path: relativeSlashPath(t.workspaceDir, path), Access: file.access, Deleted: missing})
+	}
+	slices.SortFunc(stale, func(a, b StaleFile) int { return strings.Compare(a.Path, b.Path) })
+	return stale
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestStaleFiles(t *testing.T) {
+	workspace := t.TempDir()
+	readPath := writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n")
+	editPath := writeWorkspaceFile(t, workspace, "calc/util.go", "package calc\n")
+	otherPath := writeWorkspaceFile(t, workspace, "other.go", "package main\n")
+	tools := NewLSPTools(nil, workspace)
+	read := func(path string, result *mcp.CallToolResult) {
+		tools.TrackToolCall(&mcp.CallToolRequest{Params: mcp.CallToolParams{
+			Name: "hover", Arguments: map[string]any{"file_uri": convertPathToURI(path)},
+		}}, result)
+	}
+
+	read(readPath, &mcp.CallToolResult{})
+	read(otherPath, &mcp.CallToolResult{IsError: true})
+	if err := tools.writeFileChanges(context.Background(), []fileChange{{
+		path: editPath, before: []byte("package calc\n"), after: []byte("package calc\n\nconst Two = 2\n"),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	all := []string{readPath, editPath, otherPath}
+	if stale := tools.StaleFiles(all); len(stale) != 0 {
+		t.Fatalf("expected the session's own edit not to be stale, got %v", stale)
+	}
+
+	writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n\nvar x int\n")
+	writeWorkspaceFile(t, workspace, "other.go", "package main\n\nvar y int\n")
+	if err := os.Remove(editPath); err != nil {
+		t.Fatal(err)
+	}
+	stale := tools.StaleFiles(all)
+	want := []StaleFile{{Path: "calc/calc.go", Access: "read"}, {Path: "calc/util.go", Access: "edited", Deleted: true}}
+	if len(stale) != 2 || stale[0] != want[0] || stale[1] != want[1] {
+		t.Fatalf("unexpected stale files %v", stale)
+	}
+	if stale := tools.StaleFiles(all); len(stale) != 0 {
+		t.Fatalf("expected each change to be reported once, got %v", stale)
+	}
+
+	read(readPath, &mcp.CallToolResult{})
+	writeWorkspaceFile(t, workspace, "calc/calc.go", "package calc\n")
+	if stale := tools.StaleFiles(all); len(stale) != 1 || stale[0].Path != "calc/calc.go" {
+		t.Fatalf("expected a re-read file to be tracked again, got %v", stale)
+	}
+}
@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const maxTypeHierarchyDepth = 5
+
+// typeHierarchyEntry is one supertype or subtype. Parent names the type it
+// was reached from, so entries beyond depth 1 form a tree.
+type typeHierarchyEntry struct {
+	Name     string            `json:"name"`
+	Kind     string            `json:"kind"`
+	Detail   string            `json:"detail,omitempty"`
+	Location protocol.Location `json:"location"`
+	Depth    int               `json:"depth"`
+	Parent   string            `json:"parent"`
+	// Truncated marks entries whose own supertypes or subtypes were not
+	// followed because they were already listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (t *LSPTools) registerTypeHierarchy(s *server.MCPServer) {
+	tool := mcp.NewTool("type_hierarchy",
+		mcp.WithDescription("Show the supertypes (interfaces a type implements) and subtypes (types implementing an interface) of a type via gopls type hierarchy, from a position or a type name"),
+		mcp.WithTitleAnnotation("Type Hierarchy"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("file_uri",
+			mcp.Description("URI of the file containing the type; required with position"),
+		),
+		mcp.WithObject("position",
+			mcp.Description("Position of the type name"),
+		),
+		mcp.WithString("type",
+			mcp.Description("Type name (Name or pkg.Name) to look up instead of a position"),
+		),
+		mcp.WithString("direction",
+			mcp.Description("supertypes, subtypes or both (default both)"),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description(fmt.Sprintf("How many levels to follow (default 1, at most %d)", maxTypeHierarchyDepth)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		direction := getOptionalStringArg(args, "direction")
+		if direction == "" {
+			direction = "both"
+		}
+		if direction != "supertypes" && direction != "subtypes" && direction != "both" {
+			return mcp.NewToolResultError("direction must be supertypes, subtypes or both"), nil
+		}
+		depth := 1
+		if _, ok := args["depth"]; ok {
+			var err error
+			if depth, err = getIntFromObject(args, "depth"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if depth < 1 || depth > maxTypeHierarchyDepth {
+			return mcp.NewToolResultError(fmt.Sprintf("depth must be between 1 and %d", maxTypeHierarchyDepth)), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not available")
+		}
+
+		var fileURI string
+		var line, character int
+		if name := getOptionalStringArg(args, "type"); name != "" {
+			location, problem, err := t.lookupTypeSymbol(ctx, lspClient, name, "type", symbolKindClass, symbolKindInterface, symbolKindStruct)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+			fileURI = location.URI
+			line, character = location.Range.Start.Line, location.Range.Start.Character
+		} else {
+			var err error
+			if fileURI, err = getStringArg(args, "file_uri"); err != nil {
+				return mcp.NewToolResultError("pass file_uri and position, or type"), nil
+			}
+			if line, character, err = parsePosition(args); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !strings.HasPrefix(fileURI, "file://") {
+				fileURI = convertPathToURI(fileURI)
+			}
+		}
+
+		items, err := lspClient.PrepareTypeHierarchy(ctx, fileURI, line, character)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if len(items) == 0 {
+			return mcp.NewToolResultError("no type at this position"), nil
+		}
+
+		roots := make([]typeHierarchyEntry, 0, len(items))
+		for _, item := range items {
+			roots = append(roots, newTypeHierarchyEntry(item))
+		}
+		payload := map[string]any{
+			"file_uri": fileURI,
+			"position": protocol.Position{Line: line, Character: character},
+			"items":    roots,
+		}
+		for _, dir := range []string{"supertypes", "subtypes"} {
+			if direction != dir && direction != "both" {
+				continue
+			}
+			entries, err := walkTypeHierarchy(ctx, lspClient, items, dir == "supertypes", depth)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			payload[dir] = entries
+		}
+
+		result, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// walkTypeHierarchy follows supertypes or subtypes breadth-first up to depth
+// levels, expanding each type once.
+func walkTypeHierarchy(ctx context.Context, lspClient client.LSPClient, roots []protocol.TypeHierarchyItem, supertypes bool, depth int) ([]typeHierarchyEntry, error) {
+	key := func(item protocol.TypeHierarchyItem) string {
+		return fmt.Sprintf("%s:%d:%d", item.URI, item.SelectionRange.Start.Line, item.SelectionRange.Start.Character)
+	}
+	expanded := make(map[string]bool)
+	for _, root := range roots {
+		expanded[key(root)] = true
+	}
+
+	entries := []typeHierarchyEntry{}
+	level := roots
+	for d := 1; d <= depth && len(level) > 0; d++ {
+		var next []protocol.TypeHierarchyItem
+		for _, item := range level {
+			var related []protocol.TypeHierarchyItem
+			var err error
+			if supertypes {
+				related, err = lspClient.Supertypes(ctx, item)
+			} else {
+				related, err = lspClient.Subtypes(ctx, item)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, rel := range related {
+				entry := newTypeHierarchyEntry(rel)
+				entry.Depth = d
+				entry.Parent = item.Name
+				if expanded[key(rel)] {
+					entry.Truncated = d < depth
+				} else {
+					expanded[key(rel)] = true
+					next = append(next, rel)
+				}
+				entries = append(entries, entry)
+			}
+		}
+		level = next
+	}
+	return entries, nil
+}
+
+func newTypeHierarchyEntry(item protocol.TypeHierarchyItem) typeHierarchyEntry {
+	kind := "type"
+	switch item.Kind {
+	case symbolKindInterface:
+		kind = "interface"
+	case symbolKindStruct:
+		kind = "struct"
+	}
+	return typeHierarchyEntry{
+		Name:     item.Name,
+		Kind:     kind,
+		Detail:   item.Detail,
+		Location: protocol.Location{URI: item.URI, Range: item.SelectionRange},
+	}
+}
@@ -0,0 +1,448 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/tools/go/ast/astutil"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/internal/textedit"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+const (
+	defaultUsageExamples = 5
+	maxUsageExamples     = 20
+	// maxUsageContext is how many earlier statements an example may pull
+	// in to declare the variables its call uses.
+	maxUsageContext = 3
+	// maxUsageLines caps a single statement in an example; longer ones are
+	// cut after the line with the reference.
+	maxUsageLines = 15
+	// usageGap stands for the source left out of an example.
+	usageGap = "// ..."
+)
+
+// usageContexts orders where a reference sits, from the most to the least
+// useful as an example: Example functions and example programs are written
+// to be read, tests show the API exercised with known inputs, then ordinary
+// code, then references outside function bodies such as signatures and
+// fields.
+var usageContexts = []string{"example", "test", "code", "declaration"}
+
+// usageExample is one call site of the symbol, trimmed to the statement
+// containing it and the earlier statements declaring the variables it
+// uses. Needs lists the variables of the enclosing function the snippet
+// still uses without declaring.
+type usageExample struct {
+	Path     string   `json:"path"`
+	Line     int      `json:"line"`
+	Function string   `json:"function,omitempty"`
+	Context  string   `json:"context"`
+	Snippet  string   `json:"snippet"`
+	Needs    []string `json:"needs,omitempty"`
+}
+
+func (t *LSPTools) registerFindUsageExamples(s *server.MCPServer) {
+	tool := mcp.NewTool("find_usage_examples",
+		mcp.WithDescription("Find real call sites of a function, method or type in the workspace and return the best ones as short self-contained snippets: the statement using the symbol plus the statements declaring the variables it needs, preferring Example functions and tests, one per calling function"),
+		mcp.WithTitleAnnotation("Find Usage Examples"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("symbol",
+			mcp.Description("Function, method or type name (Name, pkg.Name or Type.Method) to look up instead of a position"),
+		),
+		mcp.WithString("file_uri",
+			mcp.Description("URI of the file containing the symbol; required with position"),
+		),
+		mcp.WithObject("position",
+			mcp.Description("Position of the symbol"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of examples (default %d, at most %d)", defaultUsageExamples, maxUsageExamples)),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		limit := defaultUsageExamples
+		if _, ok := args["limit"]; ok {
+			var err error
+			if limit, err = getIntFromObject(args, "limit"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 || limit > maxUsageExamples {
+				return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxUsageExamples)), nil
+			}
+		}
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, fmt.Errorf("LSP client not available")
+		}
+
+		var fileURI string
+		var line, character int
+		if name := getOptionalStringArg(args, "symbol"); name != "" {
+			location, problem, err := t.lookupTypeSymbol(ctx, lspClient, name, "function or type", symbolKindFunction, symbolKindMethod, symbolKindClass, symbolKindInterface, symbolKindStruct)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if problem != "" {
+				return mcp.NewToolResultError(problem), nil
+			}
+			fileURI = location.URI
+			line, character = location.Range.Start.Line, location.Range.Start.Character
+		} else {
+			var err error
+			if fileURI, err = getStringArg(args, "file_uri"); err != nil {
+				return mcp.NewToolResultError("pass file_uri and position, or symbol"), nil
+			}
+			if line, character, err = parsePosition(args); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !strings.HasPrefix(fileURI, "file://") {
+				fileURI = convertPathToURI(fileURI)
+			}
+		}
+
+		references, err := lspClient.FindReferences(ctx, fileURI, line, character, false)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		ws, err := gosrc.Load(t.workspaceDir, gosrc.Options{Tests: true})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		definition := protocol.Location{URI: fileURI, Range: protocol.Range{Start: protocol.Position{Line: line, Character: character}}}
+		examples := collectUsageExamples(ws, definition, references)
+
+		result, err := mcp.NewToolResultJSON(map[string]any{
+			"definition": definition,
+			"references": len(references),
+			"examples":   rankUsageExamples(examples, limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// collectUsageExamples turns references into examples. References outside
+// the workspace and inside the symbol's own declaration are dropped.
+func collectUsageExamples(ws *gosrc.Workspace, definition protocol.Location, references []protocol.Location) []usageExample {
+	files := make(map[string]*gosrc.File)
+	for _, pkg := range ws.Packages {
+		for _, file := range pkg.Files {
+			files[file.Path] = file
+		}
+	}
+	definitionFile := files[filepath.Clean(convertURIToPath(definition.URI))]
+	var definitionPos token.Pos
+	if definitionFile != nil {
+		if offset, err := textedit.Offset(definitionFile.Src, definition.Range.Start.Line, definition.Range.Start.Character); err == nil {
+			definitionPos = definitionFile.Syntax.FileStart + token.Pos(offset)
+		}
+	}
+
+	var examples []usageExample
+	for _, reference := range references {
+		file := files[filepath.Clean(convertURIToPath(reference.URI))]
+		if file == nil {
+			continue
+		}
+		offset, err := textedit.Offset(file.Src, reference.Range.Start.Line, reference.Range.Start.Character)
+		if err != nil {
+			continue
+		}
+		pos := file.Syntax.FileStart + token.Pos(offset)
+		path, _ := astutil.PathEnclosingInterval(file.Syntax, pos, pos)
+		var fn *ast.FuncDecl
+		if len(path) >= 2 {
+			fn, _ = path[len(path)-2].(*ast.FuncDecl)
+		}
+		if fn != nil && file == definitionFile && fn.Pos() <= definitionPos && definitionPos < fn.End() {
+			continue
+		}
+		examples = append(examples, usageExampleAt(file, fn, path, pos))
+	}
+	return examples
+}
+
+// usageExampleAt builds the example for a reference at pos, given the AST
+// path enclosing it and its enclosing function, if any.
+func usageExampleAt(file *gosrc.File, fn *ast.FuncDecl, path []ast.Node, pos token.Pos) usageExample {
+	example := usageExample{Path: file.RelPath, Line: file.Line(pos), Context: "code"}
+	if fn == nil {
+		example.Context = "declaration"
+		example.Snippet = strings.TrimSpace(sourceLine(file, pos))
+		return example
+	}
+	example.Function = funcDeclName(fn)
+	kind, _, _ := testFuncKind(fn)
+	switch dirs := strings.Split(filepath.ToSlash(filepath.Dir(file.RelPath)), "/"); {
+	case kind == "example" || slices.Contains(dirs, "example") || slices.Contains(dirs, "examples"):
+		example.Context = "example"
+	case file.Test:
+		example.Context = "test"
+	}
+
+	stmt, list := enclosingStatement(path)
+	if stmt == nil {
+		// The reference is in the function's signature.
+		example.Context = "declaration"
+		example.Snippet = strings.TrimSpace(sourceLine(file, pos))
+		return example
+	}
+
+	locals := localDeclarations(fn)
+	selected := []ast.Stmt{stmt}
+	needs := snippetNeeds(selected, locals)
+	index := slices.Index(list, stmt)
+	for i := index - 1; i >= 0 && len(selected) <= maxUsageContext && len(needs) > 0; i-- {
+		if !slices.ContainsFunc(needs, func(name string) bool { return declaresName(list[i], name) }) {
+			continue
+		}
+		selected = append([]ast.Stmt{list[i]}, selected...)
+		needs = snippetNeeds(selected, locals)
+	}
+	example.Snippet = snippetText(file, selected, pos)
+	example.Needs = needs
+	return example
+}
+
+// enclosingStatement finds the innermost statement of a statement list
+// containing the path's node, and that list.
+func enclosingStatement(path []ast.Node) (ast.Stmt, []ast.Stmt) {
+	for i := 0; i+1 < len(path); i++ {
+		stmt, ok := path[i].(ast.Stmt)
+		if !ok {
+			continue
+		}
+		switch parent := path[i+1].(type) {
+		case *ast.BlockStmt:
+			return stmt, parent.List
+		case *ast.CaseClause:
+			return stmt, parent.Body
+		case *ast.CommClause:
+			return stmt, parent.Body
+		}
+	}
+	return nil, nil
+}
+
+// localDeclarations maps the names a function declares, parameters
+// included, to their positions. Parameters of testing types are left out:
+// every test has them, so they are not worth reporting as needed.
+func localDeclarations(fn *ast.FuncDecl) map[string][]token.Pos {
+	locals := make(map[string][]token.Pos)
+	declare := func(ident *ast.Ident) {
+		if ident != nil && ident.Name != "_" {
+			locals[ident.Name] = append(locals[ident.Name], ident.Pos())
+		}
+	}
+	declareFields := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			if sel, ok := unstar(field.Type).(*ast.SelectorExpr); ok && exprText(sel.X) == "testing" {
+				continue
+			}
+			for _, name := range field.Names {
+				declare(name)
+			}
+		}
+	}
+	declareFields(fn.Recv)
+	declareFields(fn.Type.Params)
+	declareFields(fn.Type.Results)
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if node.Tok == token.DEFINE {
+				for _, lhs := range node.Lhs {
+					ident, _ := lhs.(*ast.Ident)
+					declare(ident)
+				}
+			}
+		case *ast.RangeStmt:
+			if node.Tok == token.DEFINE {
+				key, _ := node.Key.(*ast.Ident)
+				value, _ := node.Value.(*ast.Ident)
+				declare(key)
+				declare(value)
+			}
+		case *ast.ValueSpec:
+			for _, name := range node.Names {
+				declare(name)
+			}
+		case *ast.FuncLit:
+			declareFields(node.Type.Params)
+			declareFields(node.Type.Results)
+		}
+		return true
+	})
+	return locals
+}
+
+// snippetNeeds lists, sorted, the local variables the statements use but
+// do not declare themselves.
+func snippetNeeds(stmts []ast.Stmt, locals map[string][]token.Pos) []string {
+	declaredWithin := func(name string) bool {
+		return slices.ContainsFunc(locals[name], func(pos token.Pos) bool {
+			return slices.ContainsFunc(stmts, func(stmt ast.Stmt) bool { return stmt.Pos() <= pos && pos < stmt.End() })
+		})
+	}
+	var needs []string
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.SelectorExpr:
+			// Only the operand can be a local; the selected name never is.
+			ast.Inspect(node.X, visit)
+			return false
+		case *ast.KeyValueExpr:
+			// Keys of struct literals are field names.
+			if _, ok := node.Key.(*ast.Ident); ok {
+				ast.Inspect(node.Value, visit)
+				return false
+			}
+		case *ast.Ident:
+			if len(locals[node.Name]) > 0 && !declaredWithin(node.Name) && !slices.Contains(needs, node.Name) {
+				needs = append(needs, node.Name)
+			}
+		}
+		return true
+	}
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, visit)
+	}
+	slices.Sort(needs)
+	return needs
+}
+
+// declaresName reports whether a statement declares name at its top level.
+func declaresName(stmt ast.Stmt, name string) bool {
+	switch stmt := stmt.(type) {
+	case *ast.AssignStmt:
+		if stmt.Tok != token.DEFINE {
+			return false
+		}
+		return slices.ContainsFunc(stmt.Lhs, func(lhs ast.Expr) bool {
+			ident, ok := lhs.(*ast.Ident)
+			return ok && ident.Name == name
+		})
+	case *ast.DeclStmt:
+		decl, ok := stmt.Decl.(*ast.GenDecl)
+		if !ok {
+			return false
+		}
+		for _, spec := range decl.Specs {
+			if value, ok := spec.(*ast.ValueSpec); ok && slices.ContainsFunc(value.Names, func(ident *ast.Ident) bool { return ident.Name == name }) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// snippetText joins the statements' source, dedented, with "// ..."
+// marking the statements skipped between them. A statement longer than
+// maxUsageLines is cut after the line containing pos.
+func snippetText(file *gosrc.File, stmts []ast.Stmt, pos token.Pos) string {
+	var lines []string
+	for i, stmt := range stmts {
+		if i > 0 && file.Line(stmts[i-1].End())+1 < file.Line(stmt.Pos()) {
+			lines = append(lines, usageGap)
+		}
+		start := file.Offset(stmt.Pos())
+		for start > 0 && file.Src[start-1] != '\n' {
+			start--
+		}
+		text := strings.Split(string(file.Src[start:file.Offset(stmt.End())]), "\n")
+		if len(text) > maxUsageLines {
+			keep := max(file.Line(pos)-file.Line(stmt.Pos())+1, 1)
+			if stmt.Pos() > pos || pos >= stmt.End() {
+				keep = maxUsageLines
+			}
+			text = append(text[:min(keep, len(text))], usageGap)
+		}
+		lines = append(lines, text...)
+	}
+	return dedentLines(lines)
+}
+
+// dedentLines removes the indentation the source lines share and joins
+// them.
+func dedentLines(lines []string) string {
+	indent, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" || line == usageGap {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first || len(lead) < len(indent) {
+			indent, first = lead, false
+		}
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sourceLine returns the source line containing pos.
+func sourceLine(file *gosrc.File, pos token.Pos) string {
+	offset := file.Offset(pos)
+	start, end := offset, offset
+	for start > 0 && file.Src[start-1] != '\n' {
+		start--
+	}
+	for end < len(file.Src) && file.Src[end] != '\n' {
+		end++
+	}
+	return string(file.Src[start:end])
+}
+
+// rankUsageExamples orders examples by context, then by how few variables
+// they leave undeclared and how short they are, keeping one example per
+// calling function before repeating a function, and returns the first
+// limit of them.
+func rankUsageExamples(examples []usageExample, limit int) []usageExample {
+	slices.SortStableFunc(examples, func(a, b usageExample) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(usageContexts, a.Context), slices.Index(usageContexts, b.Context)),
+			cmp.Compare(len(a.Needs), len(b.Needs)),
+			cmp.Compare(strings.Count(a.Snippet, "\n"), strings.Count(b.Snippet, "\n")),
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Line, b.Line),
+		)
+	})
+	ranked := make([]usageExample, 0, min(limit, len(examples)))
+	seen := make(map[string]bool)
+	var repeats []usageExample
+	for _, example := range examples {
+		key := example.Path + "\x00" + example.Function
+		if seen[key] || slices.ContainsFunc(ranked, func(other usageExample) bool { return other.Snippet == example.Snippet }) {
+			repeats = append(repeats, example)
+			continue
+		}
+		seen[key] = true
+		ranked = append(ranked, example)
+	}
+	for _, example := range repeats {
+		if !slices.ContainsFunc(ranked, func(other usageExample) bool { return other.Snippet == example.Snippet }) {
+			ranked = append(ranked, example)
+		}
+	}
+	return ranked[:min(limit, len(ranked))]
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hloiseau/mcp-gopls/v2/internal/gosrc"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
+)
+
+func TestUsageExamples(t *testing.T) {
+	workspace := t.TempDir()
+	writeWorkspaceFile(t, workspace, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	writeWorkspaceFile(t, workspace, "store/store.go", `package store
+
+type Store struct{ dir string }
+
+func Open(dir string) (*Store, error) {
+	return &Store{dir: dir}, nil
+}
+
+func MustOpen(dir string) *Store {
+	s, err := Open(dir)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+`)
+	writeWorkspaceFile(t, workspace, "store/store_test.go", `package store
+
+import "testing"
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	name := "unrelated"
+	_ = name
+	s, err := Open(dir)
+	if err != nil || s == nil {
+		t.Fatal(err)
+	}
+}
+`)
+	writeWorkspaceFile(t, workspace, "store/example_test.go", `package store
+
+func ExampleOpen() {
+	s, _ := Open("/var/lib/app")
+	_ = s
+}
+`)
+	writeWorkspaceFile(t, workspace, "cmd/app/main.go", `package main
+
+import "example.com/app/store"
+
+func main() {
+	cfg := load()
+	s, err := store.Open(cfg.Dir)
+	if err != nil {
+		return
+	}
+	backup, _ := store.Open(cfg.Dir + ".bak")
+	run(s, backup)
+}
+`)
+
+	ws, err := gosrc.Load(workspace, gosrc.Options{Tests: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	at := func(rel, text string, occurrence int) protocol.Location {
+		data, err := os.ReadFile(filepath.Join(workspace, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset := -1
+		for range occurrence + 1 {
+			offset += 1 + strings.Index(string(data[offset+1:]), text)
+		}
+		line := strings.Count(string(data[:offset]), "\n")
+		character := offset - strings.LastIndex(string(data[:offset]), "\n") - 1
+		return protocol.Location{URI: convertPathToURI(filepath.Join(workspace, rel)), Range: protocol.Range{Start: protocol.Position{Line: line, Character: character}}}
+	}
+	definition := at("store/store.go", "Open", 0)
+	references := []protocol.Location{
+		at("cmd/app/main.go", "Open", 0),
+		at("cmd/app/main.go", "Open", 1),
+		at("store/store.go", "Open", 2),
+		at("store/store_test.go", "Open", 1),
+		at("store/example_test.go", "Open", 1),
+	}
+
+	examples := rankUsageExamples(collectUsageExamples(ws, definition, references), 3)
+	var got []string
+	for _, example := range examples {
+		got = append(got, example.Context+" "+example.Function+" "+strings.Join(example.Needs, ","))
+	}
+	want := []string{"example ExampleOpen ", "test TestOpen ", "code main "}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("examples = %#v, want %#v", got, want)
+	}
+	if snippet := examples[1].Snippet; snippet != "dir := t.TempDir()\n// ...\ns, err := Open(dir)" {
+		t.Fatalf("test snippet = %q", snippet)
+	}
+
+	if snippet := examples[2].Snippet; snippet != "cfg := load()\ns, err := store.Open(cfg.Dir)" {
+		t.Fatalf("main snippet = %q", snippet)
+	}
+
+	// The second call in main only comes after every other caller.
+	examples = rankUsageExamples(collectUsageExamples(ws, definition, references), 5)
+	if len(examples) != 5 || examples[3].Function != "MustOpen" || !reflect.DeepEqual(examples[3].Needs, []string{"dir"}) {
+		t.Fatalf("unexpected examples %+v", examples)
+	}
+	if examples[4].Function != "main" || examples[4].Line != 11 || examples[4].Snippet != "cfg := load()\n// ...\nbackup, _ := store.Open(cfg.Dir + \".bak\")" {
+		t.Fatalf("unexpected repeated example %+v", examples[4])
+	}
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// vulnLevels orders the levels a vulnerability is found at, the most
+// precise first: a vulnerable function is called, a vulnerable package is
+// imported, or only a vulnerable module version is required.
+var vulnLevels = []string{"called", "imported", "required"}
+
+// govulncheckMessage is one message of the govulncheck -json stream.
+type govulncheckMessage struct {
+	Config  *govulncheckConfig  `json:"config"`
+	OSV     *govulncheckOSV     `json:"osv"`
+	Finding *govulncheckFinding `json:"finding"`
+}
+
+type govulncheckConfig struct {
+	ScannerVersion string `json:"scanner_version"`
+	DB             string `json:"db"`
+	GoVersion      string `json:"go_version"`
+	ScanLevel      string `json:"scan_level"`
+}
+
+type govulncheckOSV struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Database struct {
+		URL string `json:"url"`
+	} `json:"database_specific"`
+}
+
+type govulncheckFinding struct {
+	OSV          string             `json:"osv"`
+	FixedVersion string             `json:"fixed_version"`
+	Trace        []govulncheckFrame `json:"trace"`
+}
+
+// govulncheckFrame is a frame of a finding trace; the first frame is the
+// vulnerable symbol and the last the entry point in the workspace.
+type govulncheckFrame struct {
+	Module   string `json:"module"`
+	Version  string `json:"version"`
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Receiver string `json:"receiver"`
+	Position *struct {
+		Filename string `json:"filename"`
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+	} `json:"position"`
+}
+
+// vulnerability gathers the findings of one OSV entry.
+type vulnerability struct {
+	ID           string        `json:"id"`
+	Aliases      []string      `json:"aliases,omitempty"`
+	Summary      string        `json:"summary,omitempty"`
+	URL          string        `json:"url"`
+	Level        string        `json:"level"`
+	Module       string        `json:"module,omitempty"`
+	FoundVersion string        `json:"found_version,omitempty"`
+	FixedVersion string        `json:"fixed_version,omitempty"`
+	Symbols      []string      `json:"symbols,omitempty"`
+	CallStacks   [][]callFrame `json:"call_stacks,omitempty"`
+}
+
+// callFrame is a call stack frame, from the workspace towards the
+// vulnerable symbol.
+type callFrame struct {
+	Symbol   string `json:"symbol"`
+	Position string `json:"position,omitempty"`
+}
+
+func (t *LSPTools) registerVulncheck(s *server.MCPServer) {
+	tool := mcp.NewTool("vulncheck",
+		mcp.WithDescription("Check the workspace or a package for known vulnerabilities with govulncheck and return them as structured JSON: each vulnerability with its aliases, affected module, found and fixed versions, whether a vulnerable function is called, its package imported or only its module required, and the call stacks from the workspace down to the vulnerable symbols"),
+		mcp.WithTitleAnnotation("Vulnerability Check"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("package",
+			mcp.Description("Package pattern to check (default ./...)"),
+		),
+		mcp.WithString("scan",
+			mcp.Description("Scan level: symbol (default) finds the calls reaching vulnerable functions, package stops at imports and module at required versions"),
+			mcp.Enum("symbol", "package", "module"),
+		),
+	)
+
+	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		pattern := getOptionalStringArg(args, "package")
+		if pattern == "" {
+			pattern = "./..."
+		}
+		scan := getOptionalStringArg(args, "scan")
+		if scan == "" {
+			scan = "symbol"
+		}
+
+		token := getProgressToken(request.Params.Meta)
+		cmd, cmdArgs, fallback := determineGovulncheckCommand("-json", "-scan", scan, pattern)
+		if fallback {
+			sendProgressNotification(ctx, s, token, "Running govulncheck via go run (binary not found in PATH)")
+		} else {
+			sendProgressNotification(ctx, s, token, "Running govulncheck "+pattern)
+		}
+		// The JSON stream is decoded below, not forwarded line by line.
+		result, err := t.runCommand(ctx, s, nil, cmd, cmdArgs...)
+		if err != nil {
+			var execErr *exec.Error
+			if errors.As(err, &execErr) {
+				return mcp.NewToolResultError(fmt.Sprintf("%s binary not found", execErr.Name)), nil
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return mcp.NewToolResultError("govulncheck timed out"), nil
+			}
+			return t.commandFailureResult(strings.Join(result.Command, " "), result, err)
+		}
+
+		config, vulns, err := t.parseGovulncheck(result.Stdout)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("decode govulncheck output: %v", err)), nil
+		}
+		counts := make(map[string]int, len(vulnLevels))
+		for _, level := range vulnLevels {
+			counts[level] = 0
+		}
+		for _, vuln := range vulns {
+			counts[vuln.Level]++
+		}
+
+		payload := map[string]any{
+			"package":         pattern,
+			"scan":            scan,
+			"vulnerabilities": vulns,
+			"counts":          counts,
+		}
+		if config != nil {
+			payload["go_version"] = config.GoVersion
+			payload["scanner_version"] = config.ScannerVersion
+			payload["db"] = config.DB
+		}
+		toolResult, err := mcp.NewToolResultJSON(payload)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult, nil
+	})
+}
+
+// parseGovulncheck decodes a govulncheck -json stream into vulnerabilities,
+// called ones first, each at the most precise level it was found at.
+func (t *LSPTools) parseGovulncheck(output string) (*govulncheckConfig, []vulnerability, error) {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	var config *govulncheckConfig
+	osvs := make(map[string]*govulncheckOSV)
+	byID := make(map[string]*vulnerability)
+	var order []string
+	seenStacks := make(map[string]bool)
+
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for {
+		var message govulncheckMessage
+		if err := decoder.Decode(&message); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case message.Config != nil:
+			config = message.Config
+		case message.OSV != nil:
+			osvs[message.OSV.ID] = message.OSV
+		case message.Finding != nil && len(message.Finding.Trace) > 0:
+			finding := message.Finding
+			vuln, ok := byID[finding.OSV]
+			if !ok {
+				vuln = &vulnerability{ID: finding.OSV, Level: "required"}
+				byID[finding.OSV] = vuln
+				order = append(order, finding.OSV)
+			}
+			vulnerable := finding.Trace[0]
+			vuln.Module, vuln.FoundVersion = vulnerable.Module, vulnerable.Version
+			if finding.FixedVersion != "" {
+				vuln.FixedVersion = finding.FixedVersion
+			}
+			level := findingLevel(vulnerable)
+			if slices.Index(vulnLevels, level) < slices.Index(vulnLevels, vuln.Level) {
+				vuln.Level = level
+			}
+			if level != "called" {
+				continue
+			}
+			if symbol := frameSymbol(vulnerable); !slices.Contains(vuln.Symbols, symbol) {
+				vuln.Symbols = append(vuln.Symbols, symbol)
+			}
+			stack := make([]callFrame, 0, len(finding.Trace))
+			for _, frame := range slices.Backward(finding.Trace) {
+				stack = append(stack, callFrame{Symbol: frameSymbol(frame), Position: framePosition(root, frame)})
+			}
+			key := fmt.Sprint(finding.OSV, stack)
+			if !seenStacks[key] {
+				seenStacks[key] = true
+				vuln.CallStacks = append(vuln.CallStacks, stack)
+			}
+		}
+	}
+
+	vulns := make([]vulnerability, 0, len(order))
+	for _, id := range order {
+		vuln := byID[id]
+		vuln.URL = "https://pkg.go.dev/vuln/" + id
+		if osv := osvs[id]; osv != nil {
+			vuln.Aliases, vuln.Summary = osv.Aliases, osv.Summary
+			if osv.Database.URL != "" {
+				vuln.URL = osv.Database.URL
+			}
+		}
+		vulns = append(vulns, *vuln)
+	}
+	slices.SortStableFunc(vulns, func(a, b vulnerability) int {
+		return slices.Index(vulnLevels, a.Level) - slices.Index(vulnLevels, b.Level)
+	})
+	return config, vulns, nil
+}
+
+// findingLevel is the level of a finding from its vulnerable frame.
+func findingLevel(frame govulncheckFrame) string {
+	switch {
+	case frame.Function != "":
+		return "called"
+	case frame.Package != "":
+		return "imported"
+	}
+	return "required"
+}
+
+// frameSymbol names a frame the way Go qualifies it, such as
+// net/http.(*Client).Do, falling back to its package or module.
+func frameSymbol(frame govulncheckFrame) string {
+	switch {
+	case frame.Function == "" && frame.Package != "":
+		return frame.Package
+	case frame.Function == "":
+		return frame.Module
+	case strings.HasPrefix(frame.Receiver, "*"):
+		return fmt.Sprintf("%s.(%s).%s", frame.Package, frame.Receiver, frame.Function)
+	case frame.Receiver != "":
+		return fmt.Sprintf("%s.%s.%s", frame.Package, frame.Receiver, frame.Function)
+	}
+	return frame.Package + "." + frame.Function
+}
+
+// framePosition formats the position of a frame as file:line:column, the
+// file relative to the workspace when it is inside it.
+func framePosition(root string, frame govulncheckFrame) string {
+	if frame.Position == nil || frame.Position.Filename == "" {
+		return ""
+	}
+	name := frame.Position.Filename
+	if filepath.IsAbs(name) {
+		if rel := relativeSlashPath(root, name); !strings.HasPrefix(rel, "../") {
+			name = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", name, frame.Position.Line, frame.Position.Column)
+}
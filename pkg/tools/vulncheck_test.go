@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseGovulncheck(t *testing.T) {
+	tools := NewLSPTools(nil, "/workspace")
+	output := `{"config": {"scanner_version": "v1.1.4", "db": "https://vuln.go.dev", "go_version": "go1.26.0"}}
+{"progress": {"message": "Scanning your code and 42 packages across 3 dependent modules for known vulnerabilities..."}}
+{"osv": {"id": "GO-2025-0001", "aliases": ["CVE-2025-0001"], "summary": "Request smuggling in golang.org/x/net", "database_specific": {"url": "https://pkg.go.dev/vuln/GO-2025-0001"}}}
+{"osv": {"id": "GO-2025-0002", "summary": "Panic in example.com/yaml"}}
+{"finding": {"osv": "GO-2025-0002", "fixed_version": "v1.2.0", "trace": [{"module": "example.com/yaml", "version": "v1.1.0"}]}}
+{"finding": {"osv": "GO-2025-0001", "fixed_version": "v0.30.0", "trace": [{"module": "golang.org/x/net", "version": "v0.20.0"}]}}
+{"finding": {"osv": "GO-2025-0001", "fixed_version": "v0.30.0", "trace": [{"module": "golang.org/x/net", "version": "v0.20.0", "package": "golang.org/x/net/http2"}]}}
+{"finding": {"osv": "GO-2025-0001", "fixed_version": "v0.30.0", "trace": [
+  {"module": "golang.org/x/net", "version": "v0.20.0", "package": "golang.org/x/net/http2", "function": "ReadFrame", "receiver": "*Framer"},
+  {"module": "example.com/app", "package": "example.com/app/server", "function": "Serve", "position": {"filename": "/workspace/server/server.go", "line": 12, "column": 3}}
+]}}
+{"finding": {"osv": "GO-2025-0001", "fixed_version": "v0.30.0", "trace": [
+  {"module": "golang.org/x/net", "version": "v0.20.0", "package": "golang.org/x/net/http2", "function": "ReadFrame", "receiver": "*Framer"},
+  {"module": "example.com/app", "package": "example.com/app/server", "function": "Serve", "position": {"filename": "/workspace/server/server.go", "line": 12, "column": 3}}
+]}}
+`
+	config, vulns, err := tools.parseGovulncheck(output)
+	if err != nil {
+		t.Fatalf("parseGovulncheck: %v", err)
+	}
+	if config == nil || config.GoVersion != "go1.26.0" || config.ScannerVersion != "v1.1.4" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	if len(vulns) != 2 {
+		t.Fatalf("expected two vulnerabilities, got %+v", vulns)
+	}
+
+	called := vulns[0]
+	if called.ID != "GO-2025-0001" || called.Level != "called" || called.Module != "golang.org/x/net" || called.FoundVersion != "v0.20.0" || called.FixedVersion != "v0.30.0" {
+		t.Fatalf("the called vulnerability should come first, got %+v", called)
+	}
+	if !slices.Equal(called.Aliases, []string{"CVE-2025-0001"}) || !slices.Equal(called.Symbols, []string{"golang.org/x/net/http2.(*Framer).ReadFrame"}) {
+		t.Fatalf("unexpected aliases or symbols %+v", called)
+	}
+	want := []callFrame{
+		{Symbol: "example.com/app/server.Serve", Position: "server/server.go:12:3"},
+		{Symbol: "golang.org/x/net/http2.(*Framer).ReadFrame"},
+	}
+	if len(called.CallStacks) != 1 || !slices.Equal(called.CallStacks[0], want) {
+		t.Fatalf("expected one deduplicated stack from the workspace down, got %+v", called.CallStacks)
+	}
+
+	required := vulns[1]
+	if required.Level != "required" || required.URL != "https://pkg.go.dev/vuln/GO-2025-0002" || required.CallStacks != nil {
+		t.Fatalf("unexpected required vulnerability %+v", required)
+	}
+}
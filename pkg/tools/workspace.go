@@ -5,24 +5,90 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
 
 var lookupGovulncheckBinary = exec.LookPath
 
 func (t *LSPTools) registerWorkspaceTools(s *server.MCPServer) {
 	t.registerWorkspaceSymbols(s)
-	t.registerGoModTidy(s)
+	t.registerTidyModule(s)
+	t.registerGoBuild(s)
+	t.registerGoVet(s)
+	t.registerRunLint(s)
+	t.registerStaticcheck(s)
 	t.registerGovulncheck(s)
+	t.registerVulncheck(s)
 	t.registerModuleGraph(s)
+	t.registerGoModWhy(s)
+}
+
+// defaultWorkspaceSymbolLimit caps workspace_symbols results; gopls already
+// returns the best fuzzy matches first.
+const defaultWorkspaceSymbolLimit = 50
+
+// symbolKindNames names the LSP SymbolKinds, indexed by kind.
+var symbolKindNames = []string{
+	1: "file", 2: "module", 3: "namespace", 4: "package", 5: "class", 6: "method",
+	7: "property", 8: "field", 9: "constructor", 10: "enum", 11: "interface",
+	12: "function", 13: "variable", 14: "constant", 15: "string", 16: "number",
+	17: "boolean", 18: "array", 19: "object", 20: "key", 21: "null",
+	22: "enum_member", 23: "struct", 24: "event", 25: "operator", 26: "type_parameter",
+}
+
+func symbolKindName(kind int) string {
+	if kind > 0 && kind < len(symbolKindNames) {
+		return symbolKindNames[kind]
+	}
+	return fmt.Sprintf("kind_%d", kind)
+}
+
+// workspaceSymbol is a workspace/symbol result with its kind spelled out.
+// Match says how the query matched the symbol's own name: exact, prefix,
+// substring, or fuzzy for gopls' fuzzy matches on the characters in order.
+type workspaceSymbol struct {
+	Name      string            `json:"name"`
+	Kind      string            `json:"kind"`
+	Container string            `json:"container,omitempty"`
+	Path      string            `json:"path"`
+	Line      int               `json:"line"`
+	Match     string            `json:"match"`
+	Location  protocol.Location `json:"location"`
 }
 
 func (t *LSPTools) registerWorkspaceSymbols(s *server.MCPServer) {
-	tool := mcp.NewTool("search_workspace_symbols",
-		mcp.WithDescription("Search workspace symbols via LSP"),
+	tool := mcp.NewTool("workspace_symbols",
+		mcp.WithDescription("Search symbols across the workspace by name via gopls workspace/symbol. The query is fuzzy-matched (\"srvstart\" finds Server.Start); results come back best match first with their kind, container package and location"),
+		mcp.WithTitleAnnotation("Workspace Symbols"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Search query, matched fuzzily against symbol names"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("Comma-separated symbol kinds to keep, such as function,method,struct,interface,constant,variable,field"),
+		),
+		mcp.WithString("scope",
+			mcp.Description("workspace (default) keeps symbols declared under the workspace; all also includes dependencies and the standard library"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of symbols to return (default %d)", defaultWorkspaceSymbolLimit)),
+		),
+	)
+	s.AddTool(tool, t.workspaceSymbolsHandler("workspace", defaultWorkspaceSymbolLimit))
+
+	// search_workspace_symbols is the tool's original name, kept for
+	// clients that still call it; it searches dependencies and the
+	// standard library too, and returns every match, as it always did.
+	alias := mcp.NewTool("search_workspace_symbols",
+		mcp.WithDescription("Deprecated: use workspace_symbols. Search symbols by name in the workspace, its dependencies and the standard library, returning every match"),
 		mcp.WithTitleAnnotation("Search Workspace Symbols"),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("query",
@@ -30,8 +96,13 @@ func (t *LSPTools) registerWorkspaceSymbols(s *server.MCPServer) {
 			mcp.Description("Search query"),
 		),
 	)
+	s.AddTool(alias, t.workspaceSymbolsHandler("all", 0))
+}
 
-	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// workspaceSymbolsHandler serves workspace_symbols with the scope and
+// limit used when the call sets none; a limit of 0 returns every match.
+func (t *LSPTools) workspaceSymbolsHandler(defaultScope string, defaultLimit int) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, err := getArguments(request)
 		if err != nil {
 			return nil, err
@@ -41,6 +112,34 @@ func (t *LSPTools) registerWorkspaceSymbols(s *server.MCPServer) {
 		if err != nil {
 			return nil, err
 		}
+		var kinds []string
+		for kind := range strings.SplitSeq(getOptionalStringArg(args, "kind"), ",") {
+			kind = strings.ToLower(strings.TrimSpace(kind))
+			if kind == "" {
+				continue
+			}
+			if !slices.Contains(symbolKindNames, kind) {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown symbol kind %q", kind)), nil
+			}
+			kinds = append(kinds, kind)
+		}
+		scope := getOptionalStringArg(args, "scope")
+		switch scope {
+		case "":
+			scope = defaultScope
+		case "workspace", "all":
+		default:
+			return mcp.NewToolResultError("scope must be workspace or all"), nil
+		}
+		limit := defaultLimit
+		if _, ok := args["limit"]; ok {
+			if limit, err = getIntFromObject(args, "limit"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 {
+				return mcp.NewToolResultError("limit must be positive"), nil
+			}
+		}
 
 		lspClient := t.getClient()
 		if lspClient == nil {
@@ -51,40 +150,85 @@ func (t *LSPTools) registerWorkspaceSymbols(s *server.MCPServer) {
 		if err != nil {
 			return nil, t.handleLSPError(err)
 		}
+		matches := t.workspaceSymbols(symbols, query, kinds, scope == "workspace")
+		total := len(matches)
+		if limit > 0 && total > limit {
+			matches = matches[:limit]
+		}
 
 		result, err := mcp.NewToolResultJSON(map[string]any{
-			"query":   query,
-			"symbols": symbols,
+			"query":     query,
+			"scope":     scope,
+			"total":     total,
+			"truncated": total > len(matches),
+			"symbols":   matches,
 		})
 		if err != nil {
 			return nil, err
 		}
 		return result, nil
-	})
+	}
 }
 
-func (t *LSPTools) registerGoModTidy(s *server.MCPServer) {
-	tool := mcp.NewTool("run_go_mod_tidy",
-		mcp.WithDescription("Execute go mod tidy in the workspace"),
-		mcp.WithTitleAnnotation("Run Go Mod Tidy"),
-		mcp.WithDestructiveHintAnnotation(true),
-	)
-
-	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		token := getProgressToken(request.Params.Meta)
-		sendProgressNotification(ctx, s, token, "Running go mod tidy")
-		result, err := t.runCommand(ctx, s, token, "go", "mod", "tidy")
-		if err != nil {
-			return t.commandFailureResult("go mod tidy", result, err)
+// workspaceSymbols converts and filters workspace/symbol results. gopls
+// orders them by fuzzy score; exact, prefix and substring matches of the
+// symbol's own name are moved ahead of the rest, keeping that order within
+// each group.
+func (t *LSPTools) workspaceSymbols(symbols []protocol.SymbolInformation, query string, kinds []string, workspaceOnly bool) []workspaceSymbol {
+	root, err := filepath.Abs(t.workspaceDir)
+	if err != nil {
+		root = t.workspaceDir
+	}
+	matches := make([]workspaceSymbol, 0, len(symbols))
+	for _, symbol := range symbols {
+		kind := symbolKindName(symbol.Kind)
+		if len(kinds) > 0 && !slices.Contains(kinds, kind) {
+			continue
 		}
-
-		payload := map[string]any{"result": result}
-		toolResult, err := mcp.NewToolResultJSON(payload)
-		if err != nil {
-			return nil, err
+		path := convertURIToPath(symbol.Location.URI)
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if workspaceOnly {
+				continue
+			}
+			rel = path
 		}
-		return toolResult, nil
+		matches = append(matches, workspaceSymbol{
+			Name:      symbol.Name,
+			Kind:      kind,
+			Container: symbol.ContainerName,
+			Path:      filepath.ToSlash(rel),
+			Line:      symbol.Location.Range.Start.Line + 1,
+			Match:     symbolMatch(symbol.Name, query),
+			Location:  symbol.Location,
+		})
+	}
+	rank := map[string]int{"exact": 0, "prefix": 1, "substring": 2, "fuzzy": 3}
+	slices.SortStableFunc(matches, func(a, b workspaceSymbol) int {
+		return rank[a.Match] - rank[b.Match]
 	})
+	return matches
+}
+
+// symbolMatch classifies how query matches the last dotted component of a
+// symbol name, ignoring case.
+func symbolMatch(name, query string) string {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	name, query = strings.ToLower(name), strings.ToLower(query)
+	if idx := strings.LastIndex(query, "."); idx >= 0 {
+		query = query[idx+1:]
+	}
+	switch {
+	case name == query:
+		return "exact"
+	case strings.HasPrefix(name, query):
+		return "prefix"
+	case strings.Contains(name, query):
+		return "substring"
+	}
+	return "fuzzy"
 }
 
 func (t *LSPTools) registerGovulncheck(s *server.MCPServer) {
@@ -124,37 +268,15 @@ func (t *LSPTools) registerGovulncheck(s *server.MCPServer) {
 	})
 }
 
-func determineGovulncheckCommand() (string, []string, bool) {
+// determineGovulncheckCommand returns the command running govulncheck with
+// args, ./... when there are none, and whether it falls back to go run
+// because the binary is not in PATH.
+func determineGovulncheckCommand(args ...string) (string, []string, bool) {
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
 	if path, err := lookupGovulncheckBinary("govulncheck"); err == nil {
-		return path, []string{"./..."}, false
+		return path, args, false
 	}
-	return "go", []string{"run", "golang.org/x/vuln/cmd/govulncheck@latest", "./..."}, true
-}
-
-func (t *LSPTools) registerModuleGraph(s *server.MCPServer) {
-	tool := mcp.NewTool("module_graph",
-		mcp.WithDescription("Return the Go module dependency graph"),
-		mcp.WithTitleAnnotation("Module Graph"),
-		mcp.WithReadOnlyHintAnnotation(true),
-	)
-
-	s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		token := getProgressToken(request.Params.Meta)
-		sendProgressNotification(ctx, s, token, "Building module graph")
-		// Disable per-line progress streaming here to avoid overwhelming clients
-		// with thousands of dependency lines (they remain in the tool result).
-		result, err := t.runCommand(ctx, s, nil, "go", "mod", "graph")
-		if err != nil {
-			return t.commandFailureResult("go mod graph", result, err)
-		}
-
-		payload := map[string]any{
-			"result": result,
-		}
-		toolResult, err := mcp.NewToolResultJSON(payload)
-		if err != nil {
-			return nil, err
-		}
-		return toolResult, nil
-	})
+	return "go", append([]string{"run", "golang.org/x/vuln/cmd/govulncheck@latest"}, args...), true
 }
@@ -1,9 +1,18 @@
 package tools
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os/exec"
+	"slices"
 	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/client"
+	"github.com/hloiseau/mcp-gopls/v2/pkg/lsp/protocol"
 )
 
 func TestDetermineGovulncheckCommandBinaryPresent(t *testing.T) {
@@ -54,3 +63,69 @@ func TestDetermineGovulncheckCommandFallback(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkspaceSymbolsRanksAndFilters(t *testing.T) {
+	tools := NewLSPTools(nil, "/workspace")
+	at := func(path string, line int) protocol.Location {
+		return protocol.Location{URI: convertPathToURI(path), Range: protocol.Range{Start: protocol.Position{Line: line}}}
+	}
+	symbols := []protocol.SymbolInformation{
+		{Name: "server.Server.Restart", Kind: 6, Location: at("/workspace/server/server.go", 40), ContainerName: "example.com/app/server"},
+		{Name: "server.StartServer", Kind: 12, Location: at("/workspace/server/start.go", 3), ContainerName: "example.com/app/server"},
+		{Name: "server.Server.Start", Kind: 6, Location: at("/workspace/server/server.go", 20), ContainerName: "example.com/app/server"},
+		{Name: "http.Server.Start", Kind: 6, Location: at("/go/src/net/http/server.go", 9), ContainerName: "net/http"},
+		{Name: "server.stats", Kind: 13, Location: at("/workspace/server/stats.go", 1), ContainerName: "example.com/app/server"},
+	}
+
+	got := tools.workspaceSymbols(symbols, "Start", nil, true)
+	var names, matches []string
+	for _, symbol := range got {
+		names = append(names, symbol.Name)
+		matches = append(matches, symbol.Match)
+	}
+	if want := []string{"server.Server.Start", "server.StartServer", "server.Server.Restart", "server.stats"}; !slices.Equal(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+	if want := []string{"exact", "prefix", "substring", "fuzzy"}; !slices.Equal(matches, want) {
+		t.Fatalf("matches = %v, want %v", matches, want)
+	}
+	if got[0].Kind != "method" || got[0].Path != "server/server.go" || got[0].Line != 21 || got[0].Container != "example.com/app/server" {
+		t.Fatalf("unexpected symbol %+v", got[0])
+	}
+
+	got = tools.workspaceSymbols(symbols, "Start", []string{"method"}, false)
+	if len(got) != 3 || got[1].Name != "http.Server.Start" || got[1].Path != "/go/src/net/http/server.go" {
+		t.Fatalf("expected the dependency method with its absolute path, got %+v", got)
+	}
+}
+
+func TestSearchWorkspaceSymbolsKeepsAllMatches(t *testing.T) {
+	var symbols []protocol.SymbolInformation
+	for i := range defaultWorkspaceSymbolLimit + 10 {
+		symbols = append(symbols, protocol.SymbolInformation{Name: fmt.Sprintf("Start%d", i), Kind: 12, Location: protocol.Location{URI: convertPathToURI(fmt.Sprintf("/workspace/start%d.go", i))}})
+	}
+	symbols = append(symbols, protocol.SymbolInformation{Name: "http.Server.Start", Kind: 6, Location: protocol.Location{URI: convertPathToURI("/go/src/net/http/server.go")}})
+	fakeClient := &fakeLSPClient{symbols: symbols}
+	tools := NewLSPTools(fakeClient, "/workspace")
+	tools.clientGetter = func() client.LSPClient { return fakeClient }
+	server := mcpsrv.NewMCPServer("test", "1.0")
+	tools.Register(server)
+	call := func(name string) map[string]any {
+		t.Helper()
+		result, err := server.GetTool(name).Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: map[string]any{"query": "Start"}},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("%s: %v %v", name, err, result)
+		}
+		return structured(result)
+	}
+
+	if found := call("workspace_symbols"); len(found["symbols"].([]any)) != defaultWorkspaceSymbolLimit || found["total"] != float64(defaultWorkspaceSymbolLimit+10) {
+		t.Fatalf("expected workspace symbols capped at %d, got %v of %v", defaultWorkspaceSymbolLimit, len(found["symbols"].([]any)), found["total"])
+	}
+	found := call("search_workspace_symbols")
+	if len(found["symbols"].([]any)) != len(symbols) || found["scope"] != "all" || found["truncated"] != false {
+		t.Fatalf("expected every symbol, dependencies included, got %d %v", len(found["symbols"].([]any)), found["scope"])
+	}
+}